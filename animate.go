@@ -0,0 +1,128 @@
+package kml
+
+import (
+	"sort"
+	"time"
+)
+
+// TimeBucket is a group of placemarks whose times fall within one interval.
+type TimeBucket struct {
+	Start      time.Time
+	End        time.Time
+	Placemarks []*Placemark
+}
+
+// Time returns the moment a placemark is associated with: its TimeStamp,
+// the beginning of its TimeSpan, or the first time of a gx:Track geometry.
+// It reports false for a placemark without any of these.
+func (p *Placemark) Time() (time.Time, bool) {
+	switch {
+	case p.TimeStamp != nil && !p.TimeStamp.When.IsZero():
+		return p.TimeStamp.When, true
+	case p.TimeSpan != nil && !p.TimeSpan.Begin.IsZero():
+		return p.TimeSpan.Begin, true
+	}
+	if track, ok := p.Geometry.(*Track); ok && len(track.When) > 0 {
+		return track.When[0], true
+	}
+	return time.Time{}, false
+}
+
+// NormalizeTimes converts the TimeStamps and TimeSpans of the placemarks to
+// UTC, truncated to whole seconds, and swaps the ends of TimeSpans that
+// finish before they begin.
+func NormalizeTimes(pms []*Placemark) {
+	norm := func(t time.Time) time.Time {
+		if t.IsZero() {
+			return t
+		}
+		return t.UTC().Truncate(time.Second)
+	}
+	for _, pm := range pms {
+		if pm.TimeStamp != nil {
+			pm.TimeStamp.When = norm(pm.TimeStamp.When)
+		}
+		if span := pm.TimeSpan; span != nil {
+			span.Begin, span.End = norm(span.Begin), norm(span.End)
+			if !span.Begin.IsZero() && !span.End.IsZero() && span.End.Before(span.Begin) {
+				span.Begin, span.End = span.End, span.Begin
+			}
+		}
+	}
+}
+
+// BucketByTime groups the timed placemarks into consecutive intervals of
+// the given length, aligned to multiples of interval since the zero time so
+// that, for example, 24-hour buckets start at UTC midnight. Buckets are
+// returned in time order and empty intervals are omitted; placemarks keep
+// their input order within a bucket. Placemarks without a Time are skipped.
+func BucketByTime(pms []*Placemark, interval time.Duration) []TimeBucket {
+	if interval <= 0 {
+		return nil
+	}
+
+	index := make(map[time.Time]int)
+	var buckets []TimeBucket
+	for _, pm := range pms {
+		t, ok := pm.Time()
+		if !ok {
+			continue
+		}
+		start := t.UTC().Truncate(interval)
+		i, ok := index[start]
+		if !ok {
+			i = len(buckets)
+			index[start] = i
+			buckets = append(buckets, TimeBucket{Start: start, End: start.Add(interval)})
+		}
+		buckets[i].Placemarks = append(buckets[i].Placemarks, pm)
+	}
+
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i].Start.Before(buckets[j].Start) })
+	return buckets
+}
+
+// AnimateByTime returns a Folder that animates the placemarks on the time
+// slider. Each interval holding placemarks becomes a subfolder with a
+// TimeSpan covering it, named by its start time, so that moving the slider
+// reveals each interval's placemarks in turn. Placemarks without a Time are
+// placed directly in the returned folder and stay visible throughout.
+//
+// The placemarks are moved into the new folders, not copied.
+func AnimateByTime(pms []*Placemark, interval time.Duration) *Folder {
+	folder := &Folder{Name: "Animation"}
+	for _, pm := range pms {
+		if _, ok := pm.Time(); !ok {
+			folder.Features = append(folder.Features, pm)
+		}
+	}
+
+	for _, b := range BucketByTime(pms, interval) {
+		sub := &Folder{
+			Name:     formatDateTime(b.Start),
+			TimeSpan: &TimeSpan{Begin: b.Start, End: b.End},
+		}
+		for _, pm := range b.Placemarks {
+			sub.Features = append(sub.Features, pm)
+		}
+		folder.Features = append(folder.Features, sub)
+	}
+	return folder
+}
+
+// AssignTimeStamps gives the placemarks that have no Time a TimeStamp,
+// the i-th placemark getting start plus i steps, so that a sequence without
+// recorded times, such as waypoints in visiting order, can be animated.
+// Placemarks that already have a Time are left unchanged but still count
+// as a step. It returns the number of placemarks stamped.
+func AssignTimeStamps(pms []*Placemark, start time.Time, step time.Duration) int {
+	n := 0
+	for i, pm := range pms {
+		if _, ok := pm.Time(); ok {
+			continue
+		}
+		pm.TimeStamp = &TimeStamp{When: start.Add(time.Duration(i) * step)}
+		n++
+	}
+	return n
+}
@@ -0,0 +1,136 @@
+package kml
+
+import (
+	"testing"
+	"time"
+)
+
+// timedPlacemark returns a point placemark stamped at t.
+func timedPlacemark(name string, t time.Time) *Placemark {
+	pm := pointPlacemark(name, 0, 0)
+	pm.TimeStamp = &TimeStamp{When: t}
+	return pm
+}
+
+// TestPlacemarkTime tests the sources of a placemark's time
+func TestPlacemarkTime(t *testing.T) {
+	when := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		pm   *Placemark
+		ok   bool
+	}{
+		{"timestamp", &Placemark{TimeStamp: &TimeStamp{When: when}}, true},
+		{"timespan", &Placemark{TimeSpan: &TimeSpan{Begin: when, End: when.Add(time.Hour)}}, true},
+		{"track", &Placemark{Geometry: &Track{When: []time.Time{when}, Coords: []Coordinate{Coord(0, 0)}}}, true},
+		{"open span", &Placemark{TimeSpan: &TimeSpan{End: when}}, false},
+		{"untimed", &Placemark{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.pm.Time()
+			if ok != tt.ok {
+				t.Fatalf("Expected ok=%v, got %v", tt.ok, ok)
+			}
+			if ok && !got.Equal(when) {
+				t.Errorf("Expected %v, got %v", when, got)
+			}
+		})
+	}
+}
+
+// TestBucketByTime tests grouping placemarks into aligned intervals
+func TestBucketByTime(t *testing.T) {
+	day := func(d, h int) time.Time { return time.Date(2024, 1, d, h, 0, 0, 0, time.UTC) }
+	pms := []*Placemark{
+		timedPlacemark("c", day(3, 9)),
+		timedPlacemark("a1", day(1, 1)),
+		pointPlacemark("untimed", 0, 0),
+		timedPlacemark("a2", day(1, 23)),
+	}
+
+	buckets := BucketByTime(pms, 24*time.Hour)
+	if len(buckets) != 2 {
+		t.Fatalf("Expected 2 buckets, got %d", len(buckets))
+	}
+	if !buckets[0].Start.Equal(day(1, 0)) || !buckets[0].End.Equal(day(2, 0)) {
+		t.Errorf("Unexpected first bucket %v - %v", buckets[0].Start, buckets[0].End)
+	}
+	if len(buckets[0].Placemarks) != 2 || buckets[0].Placemarks[0].Name != "a1" {
+		t.Errorf("Expected a1 and a2 in first bucket, got %d", len(buckets[0].Placemarks))
+	}
+	if buckets[1].Placemarks[0].Name != "c" {
+		t.Errorf("Expected c in second bucket, got %s", buckets[1].Placemarks[0].Name)
+	}
+
+	if BucketByTime(pms, 0) != nil {
+		t.Error("Expected no buckets for a zero interval")
+	}
+}
+
+// TestAnimateByTime tests building TimeSpan folders
+func TestAnimateByTime(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	pms := []*Placemark{
+		timedPlacemark("a", start.Add(10*time.Minute)),
+		timedPlacemark("b", start.Add(70*time.Minute)),
+		pointPlacemark("always", 0, 0),
+	}
+
+	folder := AnimateByTime(pms, time.Hour)
+	if len(folder.Features) != 3 {
+		t.Fatalf("Expected untimed placemark and 2 folders, got %d features", len(folder.Features))
+	}
+	if pm, ok := folder.Features[0].(*Placemark); !ok || pm.Name != "always" {
+		t.Errorf("Expected untimed placemark first, got %T", folder.Features[0])
+	}
+
+	second := folder.Features[2].(*Folder)
+	if second.Name != "2024-01-01T01:00:00Z" {
+		t.Errorf("Expected folder named by start, got %s", second.Name)
+	}
+	if !second.TimeSpan.Begin.Equal(start.Add(time.Hour)) || !second.TimeSpan.End.Equal(start.Add(2*time.Hour)) {
+		t.Errorf("Unexpected TimeSpan %+v", second.TimeSpan)
+	}
+	if len(second.Features) != 1 || second.Features[0] != Feature(pms[1]) {
+		t.Error("Expected placemark b moved into second folder")
+	}
+}
+
+// TestNormalizeTimes tests conversion to UTC and span repair
+func TestNormalizeTimes(t *testing.T) {
+	zone := time.FixedZone("EST", -5*3600)
+	begin := time.Date(2024, 1, 1, 10, 0, 0, 500, zone)
+	pms := []*Placemark{
+		{TimeStamp: &TimeStamp{When: begin}},
+		{TimeSpan: &TimeSpan{Begin: begin, End: begin.Add(-time.Hour)}},
+	}
+
+	NormalizeTimes(pms)
+
+	if got := pms[0].TimeStamp.When; got.Location() != time.UTC || got.Hour() != 15 || got.Nanosecond() != 0 {
+		t.Errorf("Expected 15:00 UTC whole seconds, got %v", got)
+	}
+	if span := pms[1].TimeSpan; !span.Begin.Before(span.End) {
+		t.Errorf("Expected reversed span to be swapped, got %v - %v", span.Begin, span.End)
+	}
+}
+
+// TestAssignTimeStamps tests stamping an untimed sequence
+func TestAssignTimeStamps(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	existing := start.Add(-time.Hour)
+	pms := []*Placemark{pointPlacemark("a", 0, 0), timedPlacemark("b", existing), pointPlacemark("c", 0, 0)}
+
+	if n := AssignTimeStamps(pms, start, time.Minute); n != 2 {
+		t.Errorf("Expected 2 placemarks stamped, got %d", n)
+	}
+	if !pms[2].TimeStamp.When.Equal(start.Add(2 * time.Minute)) {
+		t.Errorf("Expected third placemark at +2m, got %v", pms[2].TimeStamp.When)
+	}
+	if !pms[1].TimeStamp.When.Equal(existing) {
+		t.Error("Expected existing TimeStamp to be kept")
+	}
+}
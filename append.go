@@ -0,0 +1,207 @@
+package kml
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// AppendTo makes AppendToFile add features to the first Folder or
+// Document, in document order, whose id or name is target, instead of the
+// top-level container. It has no effect on other output.
+func AppendTo(target string) WriteOption {
+	return func(c *writeConfig) {
+		c.appendTo = target
+	}
+}
+
+// AppendToFile adds features to the end of a container in the KML file at
+// path without parsing it into the document model: the file is scanned
+// for the closing tag of the container and the features are written in
+// front of it, followed by the rest of the file as it was. Only that rest,
+// usually the closing tags, is rewritten, so appending a batch of points
+// to a growing log costs the same however large the log is. The container
+// is the top-level Document or Folder unless AppendTo names another one.
+//
+// Features are indented to match the container's children when the file
+// is indented, as WriteFile indents it. Other write options, such as
+// Precision, apply to the new features only. Gzip and KMZ files cannot be
+// appended to in place and fail. The file is modified in place, not
+// replaced: a crash while appending can leave it truncated, so callers
+// that need atomic updates should parse and rewrite it with WriteFile.
+func AppendToFile(path string, features []Feature, opts ...WriteOption) error {
+	cfg := newWriteConfig(opts)
+
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return fmt.Errorf("kml: error opening file: %w", err)
+	}
+	defer f.Close()
+
+	spot, err := findAppendSpot(f, cfg.appendTo)
+	if err != nil {
+		return fmt.Errorf("kml: error appending to %s: %w", path, err)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(spot.before)
+	e := xml.NewEncoder(&buf)
+	if spot.indent != "" {
+		e.Indent(spot.prefix, spot.indent)
+	}
+	if len(opts) > 0 {
+		defer registerWriteConfig(e, cfg)()
+	}
+	for _, feature := range features {
+		if err := encodeFeature(e, feature); err != nil {
+			return fmt.Errorf("kml: error encoding feature: %w", err)
+		}
+	}
+	if err := e.Flush(); err != nil {
+		return fmt.Errorf("kml: error encoding feature: %w", err)
+	}
+	buf.WriteString(spot.after)
+
+	tail, err := io.ReadAll(io.NewSectionReader(f, spot.offset+spot.skip, 1<<62))
+	if err != nil {
+		return fmt.Errorf("kml: error reading %s: %w", path, err)
+	}
+	buf.Write(tail)
+	if _, err := f.WriteAt(buf.Bytes(), spot.offset); err != nil {
+		return fmt.Errorf("kml: error writing to file %s: %w", path, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("kml: error writing to file %s: %w", path, err)
+	}
+	return nil
+}
+
+// appendSpot is where AppendToFile writes new features: before is written
+// at offset, replacing skip bytes, then the features, then after, then the
+// rest of the file from offset+skip.
+type appendSpot struct {
+	offset        int64
+	skip          int64
+	before, after string
+	prefix        string // Indentation of the container's children
+	indent        string // One level of indentation; "" if the file is not indented
+}
+
+// appendFrame is an element open while scanning for the append spot.
+type appendFrame struct {
+	name       xml.Name
+	container  bool
+	id, label  string
+	childSpace string // Whitespace before the first child element
+}
+
+// findAppendSpot scans the KML document read from r for the spot at which
+// to append to target, or to the top-level container if target is "".
+func findAppendSpot(r io.Reader, target string) (*appendSpot, error) {
+	br := bufio.NewReader(r)
+	if magic, _ := br.Peek(len(zipMagic)); bytes.HasPrefix(magic, gzipMagic) || bytes.HasPrefix(magic, zipMagic) {
+		return nil, errors.New("compressed files cannot be appended to")
+	}
+
+	d := xml.NewDecoder(br)
+	var stack []*appendFrame
+	var match *appendFrame
+	space, spaceStart, inName := "", int64(-1), false
+	for {
+		offset := d.InputOffset()
+		tok, err := d.RawToken()
+		if err == io.EOF && len(stack) > 0 {
+			return nil, io.ErrUnexpectedEOF
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if n := len(stack); n > 0 && stack[n-1].childSpace == "" && spaceStart >= 0 {
+				stack[n-1].childSpace = space
+			}
+			frame := &appendFrame{name: t.Name}
+			if t.Name.Local == "Document" || t.Name.Local == "Folder" {
+				frame.container = true
+				for _, a := range t.Attr {
+					if a.Name.Local == "id" {
+						frame.id = a.Value
+					}
+				}
+			}
+			inName = t.Name.Local == "name" && len(stack) > 0 && stack[len(stack)-1].container
+			stack = append(stack, frame)
+			if match == nil && frame.container && (target == "" && len(stack) == 2 || target != "" && frame.id == target) {
+				match = frame
+			}
+
+		case xml.CharData:
+			if inName {
+				parent := stack[len(stack)-2]
+				parent.label += string(t)
+				if match == nil && target != "" && parent.label == target {
+					match = parent
+				}
+			}
+			if len(bytes.TrimSpace(t)) == 0 {
+				space, spaceStart = string(t), offset
+				continue
+			}
+
+		case xml.EndElement:
+			inName = false
+			frame := stack[len(stack)-1]
+			if frame == match {
+				// The end of a self-closing tag is read without consuming input.
+				selfClosing := d.InputOffset() == offset
+				return frame.spot(offset, selfClosing, space, spaceStart), nil
+			}
+			stack = stack[:len(stack)-1]
+		}
+		space, spaceStart = "", -1
+	}
+
+	if target != "" {
+		return nil, fmt.Errorf("no Folder or Document %q", target)
+	}
+	return nil, errors.New("no top-level Document or Folder")
+}
+
+// spot returns the append spot of a container whose end tag is at offset,
+// preceded by whitespace space starting at spaceStart, or -1 if there is
+// none.
+func (f *appendFrame) spot(offset int64, selfClosing bool, space string, spaceStart int64) *appendSpot {
+	if selfClosing {
+		// A self-closing tag: its "/>" becomes ">", new features, end tag.
+		name := f.name.Local
+		if f.name.Space != "" {
+			name = f.name.Space + ":" + name
+		}
+		return &appendSpot{offset: offset - 2, skip: 2, before: ">", after: "</" + name + ">"}
+	}
+
+	s := &appendSpot{offset: offset}
+	if spaceStart >= 0 {
+		s.offset = spaceStart
+	}
+	if i := strings.LastIndexByte(space, '\n'); i >= 0 {
+		closing := space[i+1:]
+		child := closing + "  "
+		if j := strings.LastIndexByte(f.childSpace, '\n'); j >= 0 && strings.HasPrefix(f.childSpace[j+1:], closing) && len(f.childSpace[j+1:]) > len(closing) {
+			child = f.childSpace[j+1:]
+		}
+		s.prefix, s.indent = child, child[len(closing):]
+		s.before = "\n"
+	}
+	return s
+}
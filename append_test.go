@@ -0,0 +1,166 @@
+package kml
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// appendTestKML returns a document with a log folder of n points inside
+// another folder, followed by a placemark.
+func appendTestKML(n int) *KML {
+	log := &Folder{ID: "log", Name: "Points"}
+	for i := 0; i < n; i++ {
+		log.Features = append(log.Features, &Placemark{Name: "p", Geometry: &Point{Coordinates: Coord(float64(i), 1)}})
+	}
+	return &KML{Feature: &Document{
+		Name: "Tracking",
+		Features: []Feature{
+			&Folder{Name: "Vehicle", Features: []Feature{log}},
+			&Placemark{Name: "Base", Geometry: &Point{Coordinates: Coord(0, 0)}},
+		},
+	}}
+}
+
+// TestAppendToFile tests that appending gives the file writing the whole document would
+func TestAppendToFile(t *testing.T) {
+	added := []Feature{
+		&Placemark{Name: "p", Geometry: &Point{Coordinates: Coord(2, 1)}},
+		&Placemark{Name: "p", Geometry: &Point{Coordinates: Coord(3, 1)}},
+	}
+	appended := func(target string) *KML {
+		k := appendTestKML(2)
+		doc := k.Feature.(*Document)
+		if target == "" {
+			doc.Features = append(doc.Features, added...)
+		} else {
+			log := doc.Features[0].(*Folder).Features[0].(*Folder)
+			log.Features = append(log.Features, added...)
+		}
+		return k
+	}
+
+	tests := []struct {
+		name   string
+		write  func(k *KML, path string) error
+		target string
+	}{
+		{"indented top level", func(k *KML, path string) error { return k.WriteFile(path) }, ""},
+		{"indented by id", func(k *KML, path string) error { return k.WriteFile(path) }, "log"},
+		{"indented by name", func(k *KML, path string) error { return k.WriteFile(path) }, "Points"},
+		{"compact", func(k *KML, path string) error {
+			data, err := k.Bytes()
+			if err != nil {
+				return err
+			}
+			return os.WriteFile(path, data, 0o644)
+		}, "log"},
+		{"tabs", func(k *KML, path string) error {
+			var buf bytes.Buffer
+			if err := k.WriteIndent(&buf, "", "\t"); err != nil {
+				return err
+			}
+			return os.WriteFile(path, buf.Bytes(), 0o644)
+		}, "Points"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			got, want := filepath.Join(dir, "got.kml"), filepath.Join(dir, "want.kml")
+			if err := tt.write(appendTestKML(2), got); err != nil {
+				t.Fatalf("write failed: %v", err)
+			}
+			if err := tt.write(appended(tt.target), want); err != nil {
+				t.Fatalf("write failed: %v", err)
+			}
+
+			var opts []WriteOption
+			if tt.target != "" {
+				opts = append(opts, AppendTo(tt.target))
+			}
+			if err := AppendToFile(got, added, opts...); err != nil {
+				t.Fatalf("AppendToFile failed: %v", err)
+			}
+
+			gotData, _ := os.ReadFile(got)
+			wantData, _ := os.ReadFile(want)
+			if !bytes.Equal(gotData, wantData) {
+				t.Errorf("Expected\n%s\ngot\n%s", wantData, gotData)
+			}
+		})
+	}
+}
+
+// TestAppendToFileSelfClosing tests appending to an empty self-closing folder
+func TestAppendToFileSelfClosing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.kml")
+	data := `<kml xmlns="http://www.opengis.net/kml/2.2"><Document><Folder id="log"/><Folder></Folder></Document></kml>`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	pm := &Placemark{Name: "first", Geometry: &Point{Coordinates: Coord(1.123456789, 2)}}
+	if err := AppendToFile(path, []Feature{pm}, AppendTo("log"), Precision(3)); err != nil {
+		t.Fatalf("AppendToFile failed: %v", err)
+	}
+	k, err := ParseFile(path)
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+	log := k.Feature.(*Document).Features[0].(*Folder)
+	if len(log.Features) != 1 || featureName(log.Features[0]) != "first" {
+		t.Fatalf("Expected placemark in log folder, got %+v", log.Features)
+	}
+	if c := log.Features[0].(*Placemark).Geometry.(*Point).Coordinates; c.Lon != 1.123 {
+		t.Errorf("Expected Precision applied to appended feature, got %v", c)
+	}
+}
+
+// TestAppendToFileErrors tests files that cannot be appended to
+func TestAppendToFileErrors(t *testing.T) {
+	dir := t.TempDir()
+	plain := filepath.Join(dir, "doc.kml")
+	if err := appendTestKML(1).WriteFile(plain); err != nil {
+		t.Fatal(err)
+	}
+	gz := filepath.Join(dir, "doc.kml.gz")
+	if err := appendTestKML(1).WriteFile(gz); err != nil {
+		t.Fatal(err)
+	}
+	placemark := filepath.Join(dir, "placemark.kml")
+	if err := (&KML{Feature: &Placemark{Name: "only"}}).WriteFile(placemark); err != nil {
+		t.Fatal(err)
+	}
+	broken := filepath.Join(dir, "broken.kml")
+	if err := os.WriteFile(broken, []byte("<kml><Document><Folder>"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		path string
+		opts []WriteOption
+		want string
+	}{
+		{"missing file", filepath.Join(dir, "none.kml"), nil, "error opening file"},
+		{"unknown folder", plain, []WriteOption{AppendTo("nope")}, `no Folder or Document "nope"`},
+		{"compressed", gz, nil, "compressed"},
+		{"no container", placemark, nil, "no top-level Document or Folder"},
+		{"truncated", broken, nil, "unexpected EOF"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before, _ := os.ReadFile(tt.path)
+			err := AppendToFile(tt.path, []Feature{&Placemark{Name: "x"}}, tt.opts...)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error containing %q, got %v", tt.want, err)
+			}
+			if after, _ := os.ReadFile(tt.path); !bytes.Equal(before, after) {
+				t.Errorf("Expected file unchanged")
+			}
+		})
+	}
+}
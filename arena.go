@@ -0,0 +1,66 @@
+package kml
+
+// Compact moves the coordinates of every LineString, LinearRing, Polygon
+// boundary and gx:Track in the document into one contiguous slice, which
+// it returns, and points each geometry at its section of it. A document
+// parsed with millions of points otherwise holds one small allocation per
+// geometry; compacted, the garbage collector tracks a single object and
+// passes over all coordinates, such as Bounds, read memory in order.
+//
+// Each geometry's slice is capped at its own length, so appending to it
+// later reallocates instead of overwriting its neighbor; the arena then no
+// longer reflects that geometry. Point coordinates are stored inline and
+// are not moved. Compact may be called again after editing to rebuild the
+// arena.
+func (k *KML) Compact() []Coordinate {
+	var slices []*[]Coordinate
+	k.Walk(func(f Feature) error {
+		if pm, ok := f.(*Placemark); ok && pm.Geometry != nil {
+			slices = appendCoordSlices(slices, pm.Geometry)
+		}
+		return nil
+	})
+
+	total := 0
+	for _, s := range slices {
+		total += len(*s)
+	}
+
+	arena := make([]Coordinate, 0, total)
+	for _, s := range slices {
+		start := len(arena)
+		arena = append(arena, *s...)
+		*s = arena[start:len(arena):len(arena)]
+	}
+	return arena
+}
+
+// CompactCoordinates makes Parse store the document's coordinates in one
+// contiguous arena, as Compact does.
+func CompactCoordinates() ParseOption {
+	return func(c *parseConfig) {
+		c.compact = true
+	}
+}
+
+// appendCoordSlices appends pointers to the coordinate slices held by g.
+func appendCoordSlices(slices []*[]Coordinate, g Geometry) []*[]Coordinate {
+	switch geom := g.(type) {
+	case *LineString:
+		slices = append(slices, &geom.Coordinates)
+	case *LinearRing:
+		slices = append(slices, &geom.Coordinates)
+	case *Polygon:
+		slices = append(slices, &geom.OuterBoundary.Coordinates)
+		for i := range geom.InnerBoundaries {
+			slices = append(slices, &geom.InnerBoundaries[i].Coordinates)
+		}
+	case *Track:
+		slices = append(slices, &geom.Coords)
+	case *MultiGeometry:
+		for _, child := range geom.Geometries {
+			slices = appendCoordSlices(slices, child)
+		}
+	}
+	return slices
+}
@@ -0,0 +1,67 @@
+package kml
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestCompact tests moving geometry coordinates into one arena
+func TestCompact(t *testing.T) {
+	line := &LineString{Coordinates: []Coordinate{Coord(0, 0), Coord(1, 1)}}
+	poly := &Polygon{
+		OuterBoundary:   LinearRing{Coordinates: []Coordinate{Coord(0, 0), Coord(2, 0), Coord(2, 2), Coord(0, 0)}},
+		InnerBoundaries: []LinearRing{{Coordinates: []Coordinate{Coord(0.5, 0.5), Coord(1, 0.5), Coord(1, 1), Coord(0.5, 0.5)}}},
+	}
+	track := &Track{Coords: []Coordinate{Coord(5, 5), Coord(6, 6), Coord(7, 7)}}
+	k := NewKML()
+	k.Feature = &Document{Features: []Feature{
+		&Placemark{Geometry: line},
+		&Folder{Features: []Feature{&Placemark{Geometry: &MultiGeometry{Geometries: []Geometry{poly, &Point{Coordinates: Coord(9, 9)}}}}}},
+		&Placemark{Geometry: track},
+	}}
+	before := k.Feature.Hash()
+	swBefore, neBefore := k.Bounds()
+
+	arena := k.Compact()
+
+	if len(arena) != 13 {
+		t.Fatalf("Expected 13 coordinates in arena, got %d", len(arena))
+	}
+	if &line.Coordinates[0] != &arena[0] || &poly.OuterBoundary.Coordinates[0] != &arena[2] || &track.Coords[0] != &arena[10] {
+		t.Error("Expected geometries to point into the arena in document order")
+	}
+	if k.Feature.Hash() != before {
+		t.Error("Expected compaction to leave the document unchanged")
+	}
+	if sw, ne := k.Bounds(); sw != swBefore || ne != neBefore {
+		t.Errorf("Expected bounds %v %v, got %v %v", swBefore, neBefore, sw, ne)
+	}
+
+	line.Coordinates = append(line.Coordinates, Coord(3, 3))
+	if poly.OuterBoundary.Coordinates[0] != Coord(0, 0) {
+		t.Error("Expected append to one geometry not to overwrite the next")
+	}
+}
+
+// TestCompactCoordinates tests compaction while parsing
+func TestCompactCoordinates(t *testing.T) {
+	data := `<kml><Document>
+  <Placemark><LineString><coordinates>0,0 1,1 2,2</coordinates></LineString></Placemark>
+  <Placemark><LineString><coordinates>3,3 4,4</coordinates></LineString></Placemark>
+</Document></kml>`
+
+	k, err := ParseBytes([]byte(data), CompactCoordinates())
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	pms := k.Placemarks()
+	a := pms[0].Geometry.(*LineString).Coordinates
+	b := pms[1].Geometry.(*LineString).Coordinates
+	if cap(a) != 3 || cap(b) != 2 {
+		t.Errorf("Expected capacities capped at 3 and 2, got %d and %d", cap(a), cap(b))
+	}
+
+	if out, _ := k.Bytes(); !strings.Contains(string(out), "3,3 4,4") {
+		t.Errorf("Expected coordinates to be written unchanged, got %s", out)
+	}
+}
@@ -0,0 +1,129 @@
+package kml
+
+// AssetKind identifies the role an external resource plays in a document.
+type AssetKind string
+
+const (
+	// AssetIcon is an image referenced by an IconStyle or the ItemIcon of a
+	// ListStyle.
+	AssetIcon AssetKind = "icon"
+
+	// AssetOverlay is the image of a GroundOverlay or ScreenOverlay.
+	AssetOverlay AssetKind = "overlay"
+
+	// AssetLink is a KML file loaded by a NetworkLink.
+	AssetLink AssetKind = "link"
+)
+
+// Asset is an external resource referenced by a document.
+type Asset struct {
+	Href     string    // Resource reference as it appears in the document
+	Kind     AssetKind // Role of the resource
+	Features []Feature // Features that use the resource, in document order
+}
+
+// Assets returns the deduplicated list of external resources referenced by
+// the document, in the order they first appear, together with the features
+// that use each one. A placemark uses an icon when its inline style or a
+// shared style reachable through its styleUrl (including both states of a
+// StyleMap) refers to it, and a folder uses the list item icons of the
+// style its styleUrl refers to. Icons of shared styles that no feature uses
+// are still reported, with no features.
+//
+// Packaging tools can use the result to decide what to download when
+// building a KMZ.
+func (k *KML) Assets() []Asset {
+	var assets []*Asset
+	byHref := make(map[string]*Asset)
+
+	add := func(href string, kind AssetKind) *Asset {
+		if a, ok := byHref[href]; ok {
+			return a
+		}
+		a := &Asset{Href: href, Kind: kind}
+		byHref[href] = a
+		assets = append(assets, a)
+		return a
+	}
+
+	use := func(a *Asset, f Feature) {
+		for _, existing := range a.Features {
+			if existing == f {
+				return
+			}
+		}
+		a.Features = append(a.Features, f)
+	}
+
+	styles := newStyleIndex(k)
+
+	k.Walk(func(f Feature) error {
+		switch feature := f.(type) {
+		case *Document:
+			for i := range feature.Styles {
+				for _, href := range styleIcons(&feature.Styles[i]) {
+					add(href, AssetIcon)
+				}
+			}
+		case *Folder:
+			for _, s := range styles.resolve(feature.StyleURL) {
+				for _, href := range styleIcons(s) {
+					use(add(href, AssetIcon), feature)
+				}
+			}
+		case *Placemark:
+			for _, href := range styleIcons(feature.Style) {
+				use(add(href, AssetIcon), feature)
+			}
+			for _, s := range styles.resolve(feature.StyleURL) {
+				for _, href := range styleIcons(s) {
+					use(add(href, AssetIcon), feature)
+				}
+			}
+		case *GroundOverlay:
+			if feature.Icon != nil && feature.Icon.Href != "" {
+				use(add(feature.Icon.Href, AssetOverlay), feature)
+			}
+		case *ScreenOverlay:
+			if feature.Icon != nil && feature.Icon.Href != "" {
+				use(add(feature.Icon.Href, AssetOverlay), feature)
+			}
+		case *NetworkLink:
+			if feature.Link != nil && feature.Link.Href != "" {
+				use(add(feature.Link.Href, AssetLink), feature)
+			}
+		}
+		return nil
+	})
+
+	result := make([]Asset, len(assets))
+	for i, a := range assets {
+		result[i] = *a
+	}
+	return result
+}
+
+// styleIcons returns the non-empty icon and list item icon hrefs of a
+// style.
+func styleIcons(s *Style) []string {
+	var hrefs []string
+	if href := iconHref(s); href != "" {
+		hrefs = append(hrefs, href)
+	}
+	if s != nil && s.ListStyle != nil {
+		for _, icon := range s.ListStyle.ItemIcons {
+			if icon.Href != "" {
+				hrefs = append(hrefs, icon.Href)
+			}
+		}
+	}
+	return hrefs
+}
+
+// iconHref returns the icon href of a style, or "" if it has none.
+func iconHref(s *Style) string {
+	if s == nil || s.IconStyle == nil || s.IconStyle.Icon == nil {
+		return ""
+	}
+	return s.IconStyle.Icon.Href
+}
@@ -0,0 +1,118 @@
+package kml
+
+import "testing"
+
+// TestAssets tests extraction of deduplicated icon assets and the features using them
+func TestAssets(t *testing.T) {
+	kmlData := `<?xml version="1.0" encoding="UTF-8"?>
+<kml xmlns="http://www.opengis.net/kml/2.2">
+  <Document>
+    <Style id="normal">
+      <IconStyle><Icon><href>icons/normal.png</href></Icon></IconStyle>
+    </Style>
+    <Style id="highlight">
+      <IconStyle><Icon><href>icons/highlight.png</href></Icon></IconStyle>
+    </Style>
+    <Style id="unused">
+      <IconStyle><Icon><href>icons/unused.png</href></Icon></IconStyle>
+    </Style>
+    <StyleMap id="map">
+      <Pair><key>normal</key><styleUrl>#normal</styleUrl></Pair>
+      <Pair><key>highlight</key><styleUrl>#highlight</styleUrl></Pair>
+    </StyleMap>
+    <Placemark id="a">
+      <styleUrl>#map</styleUrl>
+      <Point><coordinates>0,0</coordinates></Point>
+    </Placemark>
+    <Placemark id="b">
+      <styleUrl>#normal</styleUrl>
+      <Style>
+        <IconStyle><Icon><href>icons/normal.png</href></Icon></IconStyle>
+      </Style>
+      <Point><coordinates>1,1</coordinates></Point>
+    </Placemark>
+    <Placemark id="c">
+      <Style>
+        <IconStyle><Icon><href>http://example.com/inline.png</href></Icon></IconStyle>
+      </Style>
+      <Point><coordinates>2,2</coordinates></Point>
+    </Placemark>
+  </Document>
+</kml>`
+
+	k, err := ParseBytes([]byte(kmlData))
+	if err != nil {
+		t.Fatalf("Failed to parse KML: %v", err)
+	}
+
+	assets := k.Assets()
+
+	tests := []struct {
+		href     string
+		features []string
+	}{
+		{"icons/normal.png", []string{"a", "b"}},
+		{"icons/highlight.png", []string{"a"}},
+		{"icons/unused.png", nil},
+		{"http://example.com/inline.png", []string{"c"}},
+	}
+
+	if len(assets) != len(tests) {
+		t.Fatalf("Expected %d assets, got %d: %+v", len(tests), len(assets), assets)
+	}
+
+	for i, tt := range tests {
+		a := assets[i]
+		if a.Href != tt.href {
+			t.Errorf("assets[%d].Href = %q, want %q", i, a.Href, tt.href)
+		}
+		if a.Kind != AssetIcon {
+			t.Errorf("assets[%d].Kind = %q, want %q", i, a.Kind, AssetIcon)
+		}
+		if len(a.Features) != len(tt.features) {
+			t.Errorf("assets[%d] has %d features, want %d", i, len(a.Features), len(tt.features))
+			continue
+		}
+		for j, id := range tt.features {
+			if pm := a.Features[j].(*Placemark); pm.ID != id {
+				t.Errorf("assets[%d].Features[%d] = %q, want %q", i, j, pm.ID, id)
+			}
+		}
+	}
+}
+
+// TestAssetsStyleMapCycle tests that StyleMaps referencing each other do not loop forever
+func TestAssetsStyleMapCycle(t *testing.T) {
+	k := NewKML()
+	k.Feature = &Document{
+		StyleMaps: []StyleMap{
+			{ID: "a", Pairs: []Pair{{Key: "normal", StyleURL: "#b"}}},
+			{ID: "b", Pairs: []Pair{{Key: "normal", StyleURL: "#a"}}},
+		},
+		Features: []Feature{&Placemark{StyleURL: "#a"}},
+	}
+
+	if assets := k.Assets(); len(assets) != 0 {
+		t.Errorf("Expected no assets, got %+v", assets)
+	}
+}
+
+// TestAssetsItemIcons tests that list item icons are assets of the folders using them
+func TestAssetsItemIcons(t *testing.T) {
+	folder := &Folder{StyleURL: "#list"}
+	k := NewKML()
+	k.Feature = &Document{
+		Styles:   []Style{{ID: "list", ListStyle: &ListStyle{ItemIcons: []ItemIcon{{State: "open", Href: "open.png"}, {State: "closed", Href: "closed.png"}}}}},
+		Features: []Feature{folder},
+	}
+
+	assets := k.Assets()
+	if len(assets) != 2 || assets[0].Href != "open.png" || assets[1].Href != "closed.png" {
+		t.Fatalf("Expected the two item icons, got %+v", assets)
+	}
+	for _, a := range assets {
+		if a.Kind != AssetIcon || len(a.Features) != 1 || a.Features[0] != folder {
+			t.Errorf("Expected an icon used by the folder, got %+v", a)
+		}
+	}
+}
@@ -0,0 +1,321 @@
+package kml
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ShareDescriptionOptions configures ShareDescriptions.
+type ShareDescriptionOptions struct {
+	// MinLength is the length in bytes below which descriptions are left
+	// alone. The default is 200.
+	MinLength int
+
+	// MinCount is the number of placemarks that must share a description
+	// layout before it is replaced by a template. The default is 5.
+	MinCount int
+
+	// Prefix is prepended to a number to form style IDs. The default is
+	// "balloon-".
+	Prefix string
+
+	// HashIDs derives style IDs from the content of each style instead,
+	// as "balloon-3fa2bc", so the same template gets the same ID across
+	// runs and files. A style equal to a shared style of the document
+	// with that ID is not returned again.
+	HashIDs bool
+}
+
+// defaults returns o with zero fields set to their defaults.
+func (o ShareDescriptionOptions) defaults() ShareDescriptionOptions {
+	if o.MinLength == 0 {
+		o.MinLength = 200
+	}
+	if o.MinCount == 0 {
+		o.MinCount = 5
+	}
+	if o.Prefix == "" {
+		o.Prefix = "balloon-"
+	}
+	return o
+}
+
+// ShareDescriptions replaces large descriptions that many placemarks share
+// the layout of, such as the attribute tables machine-generated exports
+// repeat for every feature, with the text of a shared BalloonStyle. The
+// parts that differ between the placemarks become $[name] entities, filled
+// from ExtendedData: the placemark's own Data element when every placemark
+// has one holding that text, and otherwise a new Data element named after
+// the label before it, such as "Depth" for the cell after a Depth cell.
+// Each placemark's balloon shows what its description did, byte for byte.
+//
+// Descriptions are compared by their markup: placemarks whose descriptions
+// have the same tags in the same order, and whose styleUrl matches, share a
+// template when there are at least MinCount of them and the template saves
+// space. Placemarks are pointed at a new style holding the BalloonStyle; a
+// placemark with a shared Style of its own gets a copy of it, sharing its
+// sub-styles, with the BalloonStyle added. Placemarks whose style already
+// has balloon text, whose styleUrl refers to a StyleMap or another file,
+// or whose description contains a $[ entity are left unchanged. Without a
+// description, viewers show no snippet below the name in the Places panel.
+// ShareDescriptions returns the styles used, to be added to the Document.
+func (k *KML) ShareDescriptions(opts ShareDescriptionOptions) []Style {
+	opts = opts.defaults()
+	idx := newStyleIndex(k)
+
+	type group struct {
+		styleURL string
+		members  []*Placemark
+		segments [][]string // Text between the tags of each description
+		tags     []string
+	}
+	groups := make(map[[2]string]*group)
+	var order []*group
+	k.Walk(func(f Feature) error {
+		pm, ok := f.(*Placemark)
+		if !ok || len(pm.Description) < opts.MinLength || strings.Contains(pm.Description, "$[") {
+			return nil
+		}
+		if pm.Style != nil && pm.Style.BalloonStyle != nil {
+			return nil
+		}
+		if pm.StyleURL != "" {
+			id, local := localFragment(pm.StyleURL)
+			s, shared := idx.styles[id]
+			if !local || !shared || (s.BalloonStyle != nil && s.BalloonStyle.Text != "") {
+				return nil
+			}
+		}
+
+		tags := descriptionTag.FindAllString(pm.Description, -1)
+		key := [2]string{pm.StyleURL, strings.Join(tags, "\x00")}
+		g, ok := groups[key]
+		if !ok {
+			g = &group{styleURL: pm.StyleURL, tags: tags}
+			groups[key] = g
+			order = append(order, g)
+		}
+		g.members = append(g.members, pm)
+		g.segments = append(g.segments, descriptionTag.Split(pm.Description, -1))
+		return nil
+	})
+
+	var styles []Style
+	n := 0
+	for _, g := range order {
+		if len(g.members) < opts.MinCount {
+			continue
+		}
+		t := newBalloonTemplate(g.members, g.segments, g.tags)
+		if t.saving() <= 0 {
+			continue
+		}
+
+		for {
+			n++
+			if !idx.has(opts.Prefix + strconv.Itoa(n)) {
+				break
+			}
+		}
+		s := Style{}
+		if g.styleURL != "" {
+			id, _ := localFragment(g.styleURL)
+			s = *idx.styles[id]
+		}
+		balloon := BalloonStyle{}
+		if s.BalloonStyle != nil {
+			balloon = *s.BalloonStyle
+		}
+		balloon.Text = t.text
+		s.ID, s.BalloonStyle = opts.Prefix+strconv.Itoa(n), &balloon
+		styles = append(styles, s)
+
+		for i, pm := range g.members {
+			for _, field := range t.fields {
+				if field.added {
+					if pm.ExtendedData == nil {
+						pm.ExtendedData = &ExtendedData{}
+					}
+					pm.ExtendedData.Data = append(pm.ExtendedData.Data, Data{Name: field.name, Value: g.segments[i][field.segment]})
+				}
+			}
+			pm.Description = ""
+			pm.StyleURL = "#" + s.ID
+		}
+	}
+	if opts.HashIDs {
+		styles = hashStyleIDs(styles, opts.Prefix, idx, k.Feature)
+	}
+	return styles
+}
+
+// descriptionTag matches an HTML tag, comment or other markup in a
+// description.
+var descriptionTag = regexp.MustCompile(`<[^>]*>`)
+
+// balloonEntities are the names BalloonStyle text uses for the fields of
+// the feature itself, which Data elements cannot be referred to by.
+var balloonEntities = map[string]bool{
+	"name": true, "description": true, "address": true, "Snippet": true,
+	"snippet": true, "id": true, "geDirections": true,
+}
+
+// balloonTemplate is BalloonStyle text reproducing the descriptions of a
+// group of placemarks with the same markup.
+type balloonTemplate struct {
+	text   string
+	fields []balloonField
+	size   int // Bytes of the descriptions it replaces
+	bare   int // Members without ExtendedData
+}
+
+// balloonField is a $[name] entity of a template, standing for the text
+// between two tags that differs between the descriptions.
+type balloonField struct {
+	name    string
+	segment int  // Index of the text it stands for
+	added   bool // Whether a Data element is added for it
+	bytes   int  // Bytes of the added Data elements
+}
+
+// newBalloonTemplate builds the template for descriptions split into
+// segments around the same tags.
+func newBalloonTemplate(members []*Placemark, segments [][]string, tags []string) *balloonTemplate {
+	t := &balloonTemplate{}
+	for _, pm := range members {
+		t.size += len("<description></description>") + len(pm.Description)
+		if pm.ExtendedData == nil {
+			t.bare++
+		}
+	}
+
+	used := make(map[string]bool)
+	label := ""
+	var b strings.Builder
+	for j := range segments[0] {
+		if j > 0 {
+			b.WriteString(tags[j-1])
+		}
+		text, static := segments[0][j], true
+		for _, s := range segments[1:] {
+			if s[j] != text {
+				static = false
+				break
+			}
+		}
+		if static {
+			b.WriteString(text)
+			if l := strings.TrimSpace(text); l != "" {
+				label = l
+			}
+			continue
+		}
+
+		field := balloonField{segment: j}
+		if name, ok := sharedDataName(members, segments, j); ok && !used[name] {
+			field.name = name
+		} else {
+			field.name, field.added = uniqueFieldName(members, used, label), true
+			for i := range members {
+				field.bytes += len(`<Data name=""><value></value></Data>`) + len(field.name) + len(segments[i][j])
+			}
+		}
+		used[field.name] = true
+		t.fields = append(t.fields, field)
+		b.WriteString("$[" + field.name + "]")
+	}
+	t.text = b.String()
+	return t
+}
+
+// saving returns the number of bytes using the template saves.
+func (t *balloonTemplate) saving() int {
+	cost := len(`<Style id=""><BalloonStyle><text></text></BalloonStyle></Style>`) + len(t.text)
+	added := false
+	for _, f := range t.fields {
+		cost += f.bytes
+		added = added || f.added
+	}
+	if added {
+		cost += t.bare * len("<ExtendedData></ExtendedData>")
+	}
+	return t.size - cost
+}
+
+// sharedDataName returns the name of a Data element that holds segment j
+// of the description in every member, if there is one.
+func sharedDataName(members []*Placemark, segments [][]string, j int) (string, bool) {
+	if members[0].ExtendedData == nil {
+		return "", false
+	}
+	for _, d := range members[0].ExtendedData.Data {
+		if d.Value != segments[0][j] || balloonEntities[d.Name] || !validEntityName(d.Name) {
+			continue
+		}
+		shared := true
+		for i, pm := range members[1:] {
+			if v, ok := dataNamed(pm, d.Name); !ok || v != segments[i+1][j] {
+				shared = false
+				break
+			}
+		}
+		if shared {
+			return d.Name, true
+		}
+	}
+	return "", false
+}
+
+// uniqueFieldName derives a Data name from label that is not used by the
+// template or by a Data element of any member.
+func uniqueFieldName(members []*Placemark, used map[string]bool, label string) string {
+	base := strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsLetter(r), unicode.IsDigit(r), r == '_', r == '-':
+			return r
+		case unicode.IsSpace(r):
+			return '_'
+		}
+		return -1
+	}, label)
+	if base == "" {
+		base = "field"
+	}
+
+	taken := func(name string) bool {
+		if used[name] || balloonEntities[name] {
+			return true
+		}
+		for _, pm := range members {
+			if _, ok := dataNamed(pm, name); ok {
+				return true
+			}
+		}
+		return false
+	}
+	name := base
+	for i := 2; taken(name); i++ {
+		name = base + "-" + strconv.Itoa(i)
+	}
+	return name
+}
+
+// validEntityName reports whether name can be used in a $[name] entity.
+func validEntityName(name string) bool {
+	return name != "" && !strings.ContainsAny(name, "[]$/")
+}
+
+// dataNamed returns the value of the Data element of pm called name.
+func dataNamed(pm *Placemark, name string) (string, bool) {
+	if pm.ExtendedData == nil {
+		return "", false
+	}
+	for _, d := range pm.ExtendedData.Data {
+		if d.Name == name {
+			return d.Value, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,174 @@
+package kml
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// wellDescription returns an export-style attribute table for a well.
+func wellDescription(depth, owner string) string {
+	return `<table style="border-collapse:collapse;width:100%"><tr><th colspan="2">Well record</th></tr>` +
+		`<tr><td>Depth</td><td>` + depth + `</td></tr><tr><td>Owner</td><td>` + owner + `</td></tr>` +
+		`<tr><td colspan="2">Generated by the county well registry export. Values are provisional.</td></tr></table>`
+}
+
+// wellPlacemarks returns n placemarks with well descriptions, each with
+// its depth also held in a Data element.
+func wellPlacemarks(n int, styleURL string) []Feature {
+	var features []Feature
+	for i := 0; i < n; i++ {
+		depth := fmt.Sprint(100 + i)
+		features = append(features, &Placemark{
+			Name:         fmt.Sprintf("Well %d", i),
+			StyleURL:     styleURL,
+			Description:  wellDescription(depth, fmt.Sprintf("Owner %c", 'A'+i)),
+			ExtendedData: &ExtendedData{Data: []Data{{Name: "depth", Value: depth}}},
+			Geometry:     &Point{Coordinates: Coord(float64(i), 0)},
+		})
+	}
+	return features
+}
+
+var balloonEntity = regexp.MustCompile(`\$\[([^\]]+)\]`)
+
+// renderBalloon expands the entities of text with the Data of pm.
+func renderBalloon(text string, pm *Placemark) string {
+	return balloonEntity.ReplaceAllStringFunc(text, func(m string) string {
+		v, _ := dataNamed(pm, m[2:len(m)-1])
+		return v
+	})
+}
+
+// TestShareDescriptions tests replacing repeated descriptions with a balloon template
+func TestShareDescriptions(t *testing.T) {
+	features := wellPlacemarks(6, "")
+	var originals []string
+	for _, f := range features {
+		originals = append(originals, f.(*Placemark).Description)
+	}
+	k := &KML{Feature: &Document{Features: features}}
+
+	styles := k.ShareDescriptions(ShareDescriptionOptions{})
+	if len(styles) != 1 {
+		t.Fatalf("Expected 1 style, got %d", len(styles))
+	}
+	s := styles[0]
+	if s.ID != "balloon-1" || s.BalloonStyle == nil {
+		t.Fatalf("Expected balloon-1 with a BalloonStyle, got %+v", s)
+	}
+	text := s.BalloonStyle.Text
+	if !strings.Contains(text, "<td>$[depth]</td>") || !strings.Contains(text, "<td>$[Owner]</td>") {
+		t.Errorf("Expected depth and Owner entities, got %q", text)
+	}
+
+	for i, f := range features {
+		pm := f.(*Placemark)
+		if pm.Description != "" || pm.StyleURL != "#balloon-1" {
+			t.Errorf("Expected description moved to #balloon-1, got %q, %q", pm.Description, pm.StyleURL)
+		}
+		if got := renderBalloon(text, pm); got != originals[i] {
+			t.Errorf("Expected balloon %q, got %q", originals[i], got)
+		}
+		if len(pm.ExtendedData.Data) != 2 {
+			t.Errorf("Expected Owner added to depth, got %+v", pm.ExtendedData.Data)
+		}
+	}
+}
+
+// TestShareDescriptionsStyles tests sharing descriptions of styled placemarks
+func TestShareDescriptionsStyles(t *testing.T) {
+	icon := &IconStyle{Icon: &Icon{Href: "well.png"}}
+	doc := &Document{
+		Styles: []Style{
+			{ID: "well", IconStyle: icon},
+			{ID: "balloon-1"},
+			{ID: "custom", BalloonStyle: &BalloonStyle{Text: "$[name]"}},
+		},
+		StyleMaps: []StyleMap{{ID: "map"}},
+	}
+	doc.Features = append(doc.Features, wellPlacemarks(5, "#well")...)
+	doc.Features = append(doc.Features, wellPlacemarks(5, "#custom")...)
+	doc.Features = append(doc.Features, wellPlacemarks(5, "#map")...)
+	doc.Features = append(doc.Features, wellPlacemarks(5, "other.kml#well")...)
+	k := &KML{Feature: doc}
+
+	styles := k.ShareDescriptions(ShareDescriptionOptions{})
+	if len(styles) != 1 {
+		t.Fatalf("Expected 1 style, got %d", len(styles))
+	}
+	if styles[0].ID != "balloon-2" || styles[0].IconStyle != icon || styles[0].BalloonStyle == nil {
+		t.Errorf("Expected copy of well as balloon-2, got %+v", styles[0])
+	}
+	for i, f := range doc.Features {
+		pm := f.(*Placemark)
+		if moved := pm.StyleURL == "#balloon-2"; moved != (i < 5) {
+			t.Errorf("Expected only #well placemarks moved, got %q for %d", pm.StyleURL, i)
+		}
+	}
+}
+
+// TestShareDescriptionsSkipped tests descriptions left in place
+func TestShareDescriptionsSkipped(t *testing.T) {
+	tests := []struct {
+		name     string
+		features []Feature
+		opts     ShareDescriptionOptions
+	}{
+		{"too few", wellPlacemarks(4, ""), ShareDescriptionOptions{}},
+		{"too short", wellPlacemarks(6, ""), ShareDescriptionOptions{MinLength: 1000}},
+		{"entity in description", func() []Feature {
+			features := wellPlacemarks(6, "")
+			for _, f := range features {
+				f.(*Placemark).Description += "$[name]"
+			}
+			return features
+		}(), ShareDescriptionOptions{}},
+		{"no saving", func() []Feature {
+			var features []Feature
+			for i := 0; i < 6; i++ {
+				features = append(features, &Placemark{Description: strings.Repeat(fmt.Sprint(i), 300)})
+			}
+			return features
+		}(), ShareDescriptionOptions{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var originals []string
+			for _, f := range tt.features {
+				originals = append(originals, f.(*Placemark).Description)
+			}
+			k := &KML{Feature: &Document{Features: tt.features}}
+			if styles := k.ShareDescriptions(tt.opts); len(styles) != 0 {
+				t.Errorf("Expected no styles, got %d", len(styles))
+			}
+			for i, f := range tt.features {
+				if f.(*Placemark).Description != originals[i] {
+					t.Errorf("Expected description %d unchanged", i)
+				}
+			}
+		})
+	}
+}
+
+// TestShareDescriptionsIdentical tests identical descriptions becoming static balloon text
+func TestShareDescriptionsIdentical(t *testing.T) {
+	desc := "<p>" + strings.Repeat("Survey notes apply. ", 20) + "</p>"
+	var features []Feature
+	for i := 0; i < 5; i++ {
+		features = append(features, &Placemark{Name: fmt.Sprint(i), Description: desc})
+	}
+	k := &KML{Feature: &Folder{Features: features}}
+
+	styles := k.ShareDescriptions(ShareDescriptionOptions{Prefix: "notes-"})
+	if len(styles) != 1 || styles[0].ID != "notes-1" || styles[0].BalloonStyle.Text != desc {
+		t.Fatalf("Expected notes-1 with the description, got %+v", styles)
+	}
+	for _, f := range features {
+		if pm := f.(*Placemark); pm.ExtendedData != nil {
+			t.Errorf("Expected no ExtendedData, got %+v", pm.ExtendedData)
+		}
+	}
+}
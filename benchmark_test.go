@@ -393,3 +393,119 @@ func BenchmarkParseParallel(b *testing.B) {
 		}
 	})
 }
+
+// Benchmark coordinate arena
+
+// scatteredDocument returns a document of n LineStrings of m coordinates,
+// each allocated separately.
+func scatteredDocument(n, m int) *KML {
+	doc := &Document{}
+	for i := 0; i < n; i++ {
+		coords := make([]Coordinate, m)
+		for j := range coords {
+			coords[j] = Coord(float64(j%360)-180, float64(i%180)-90)
+		}
+		doc.Features = append(doc.Features, &Placemark{Geometry: &LineString{Coordinates: coords}})
+	}
+	k := NewKML()
+	k.Feature = doc
+	return k
+}
+
+func BenchmarkBoundsScattered(b *testing.B) {
+	doc := scatteredDocument(10000, 100)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = doc.Bounds()
+	}
+}
+
+func BenchmarkBoundsCompact(b *testing.B) {
+	doc := scatteredDocument(10000, 100)
+	doc.Compact()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = doc.Bounds()
+	}
+}
+
+func BenchmarkParseCompact(b *testing.B) {
+	data, err := scatteredDocument(1000, 100).Bytes()
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name string
+		opts []ParseOption
+	}{
+		{"Default", nil},
+		{"Compact", []ParseOption{CompactCoordinates()}},
+	} {
+		b.Run(tc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(data)))
+			for i := 0; i < b.N; i++ {
+				if _, err := ParseBytes(data, tc.opts...); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// Benchmark bulk computation
+
+// benchSink keeps benchmark results live so the compiler cannot discard
+// the loops computing them.
+var benchSink float64
+
+func BenchmarkBoundsOf(b *testing.B) {
+	arena := scatteredDocument(10000, 100).Compact()
+
+	b.SetBytes(int64(len(arena)) * 24)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		sw, ne := BoundsOf(arena)
+		benchSink = sw.Lon + ne.Lon
+	}
+}
+
+func BenchmarkBoundsOfNaive(b *testing.B) {
+	arena := scatteredDocument(10000, 100).Compact()
+
+	b.SetBytes(int64(len(arena)) * 24)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		sw, ne := arena[0], arena[0]
+		for _, c := range arena {
+			sw.Lon, sw.Lat = min(sw.Lon, c.Lon), min(sw.Lat, c.Lat)
+			ne.Lon, ne.Lat = max(ne.Lon, c.Lon), max(ne.Lat, c.Lat)
+		}
+		benchSink = sw.Lon + sw.Lat + ne.Lon + ne.Lat
+	}
+}
+
+func BenchmarkLengthOf(b *testing.B) {
+	arena := scatteredDocument(100, 1000).Compact()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		benchSink = LengthOf(arena)
+	}
+}
+
+func BenchmarkLengthOfHaversine(b *testing.B) {
+	arena := scatteredDocument(100, 1000).Compact()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		total := 0.0
+		for j := 1; j < len(arena); j++ {
+			total += haversine(arena[j-1], arena[j])
+		}
+		benchSink = total
+	}
+}
@@ -0,0 +1,317 @@
+package kml
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// bindField describes how a struct field maps onto a Placemark.
+type bindField struct {
+	index     int    // Field index within the struct
+	target    string // "id", "name", "description", "address", "styleUrl", "coordinates" or "data"
+	dataName  string // ExtendedData field name when target is "data"
+	omitEmpty bool   // Skip zero values when marshaling ExtendedData
+}
+
+var (
+	coordinateType  = reflect.TypeOf(Coordinate{})
+	coordinatesType = reflect.TypeOf([]Coordinate(nil))
+	textMarshaler   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshaler = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// bindFields parses the kml struct tags of t.
+//
+// Supported tags are "id", "name", "description", "address", "styleUrl",
+// "coordinates" and "data=<field>", the last optionally followed by
+// ",omitempty". Fields without a kml tag, or tagged "-", are ignored.
+func bindFields(t reflect.Type) ([]bindField, error) {
+	var fields []bindField
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag, ok := sf.Tag.Lookup("kml")
+		if !ok || tag == "-" || !sf.IsExported() {
+			continue
+		}
+
+		f := bindField{index: i}
+		name, opts, _ := strings.Cut(tag, ",")
+		f.omitEmpty = opts == "omitempty"
+
+		switch {
+		case strings.HasPrefix(name, "data="):
+			f.target = "data"
+			f.dataName = strings.TrimPrefix(name, "data=")
+			if f.dataName == "" {
+				return nil, fmt.Errorf("kml: field %s: empty data name in tag %q", sf.Name, tag)
+			}
+		case name == "id", name == "name", name == "description", name == "address", name == "styleUrl":
+			if sf.Type.Kind() != reflect.String {
+				return nil, fmt.Errorf("kml: field %s: %s must be a string, got %s", sf.Name, name, sf.Type)
+			}
+			f.target = name
+		case name == "coordinates":
+			if sf.Type != coordinateType && sf.Type != coordinatesType {
+				return nil, fmt.Errorf("kml: field %s: coordinates must be Coordinate or []Coordinate, got %s", sf.Name, sf.Type)
+			}
+			f.target = name
+		default:
+			return nil, fmt.Errorf("kml: field %s: unknown tag %q", sf.Name, tag)
+		}
+
+		fields = append(fields, f)
+	}
+
+	return fields, nil
+}
+
+// structValue returns the struct value referenced by v, which must be a
+// struct or a pointer to one.
+func structValue(v any) (reflect.Value, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return reflect.Value{}, fmt.Errorf("kml: cannot bind nil %s", rv.Type())
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("kml: cannot bind %s, expected a struct", rv.Type())
+	}
+	return rv, nil
+}
+
+// MarshalPlacemark builds a Placemark from a struct using its kml field tags:
+//
+//	type Site struct {
+//		ID    string     `kml:"id"`
+//		Name  string     `kml:"name"`
+//		Pos   Coordinate `kml:"coordinates"`
+//		Speed float64    `kml:"data=speed"`
+//		Note  string     `kml:"data=note,omitempty"`
+//	}
+//
+// A Coordinate field becomes a Point and a []Coordinate field a LineString.
+// Data fields may be strings, booleans, integers, floats, or implement
+// encoding.TextMarshaler (such as time.Time), or be pointers to those; a
+// nil pointer is written as an empty value, which UnmarshalPlacemark reads
+// back as nil.
+func MarshalPlacemark(v any) (*Placemark, error) {
+	rv, err := structValue(v)
+	if err != nil {
+		return nil, err
+	}
+
+	fields, err := bindFields(rv.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	pm := &Placemark{}
+	for _, f := range fields {
+		fv := rv.Field(f.index)
+		switch f.target {
+		case "id":
+			pm.ID = fv.String()
+		case "name":
+			pm.Name = fv.String()
+		case "description":
+			pm.Description = fv.String()
+		case "address":
+			pm.Address = fv.String()
+		case "styleUrl":
+			pm.StyleURL = fv.String()
+		case "coordinates":
+			if fv.Type() == coordinateType {
+				pm.Geometry = &Point{Coordinates: fv.Interface().(Coordinate)}
+			} else {
+				coords := fv.Interface().([]Coordinate)
+				pm.Geometry = &LineString{Coordinates: append([]Coordinate(nil), coords...)}
+			}
+		case "data":
+			if f.omitEmpty && fv.IsZero() {
+				continue
+			}
+			s, err := formatBindValue(fv)
+			if err != nil {
+				return nil, fmt.Errorf("kml: field %s: %w", rv.Type().Field(f.index).Name, err)
+			}
+			if pm.ExtendedData == nil {
+				pm.ExtendedData = &ExtendedData{}
+			}
+			pm.ExtendedData.Data = append(pm.ExtendedData.Data, Data{Name: f.dataName, Value: s})
+		}
+	}
+
+	return pm, nil
+}
+
+// UnmarshalPlacemark copies the properties of a Placemark into the struct
+// pointed to by v, using the kml field tags described in MarshalPlacemark.
+//
+// A Coordinate field receives the coordinates of a Point, or the first
+// coordinate of any other geometry; a []Coordinate field receives all of
+// the geometry's coordinates. Data fields are looked up in both Data and
+// SchemaData elements; missing fields are left unchanged.
+func UnmarshalPlacemark(pm *Placemark, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("kml: UnmarshalPlacemark requires a non-nil pointer, got %T", v)
+	}
+	rv, err := structValue(v)
+	if err != nil {
+		return err
+	}
+
+	fields, err := bindFields(rv.Type())
+	if err != nil {
+		return err
+	}
+
+	for _, f := range fields {
+		fv := rv.Field(f.index)
+		switch f.target {
+		case "id":
+			fv.SetString(pm.ID)
+		case "name":
+			fv.SetString(pm.Name)
+		case "description":
+			fv.SetString(pm.Description)
+		case "address":
+			fv.SetString(pm.Address)
+		case "styleUrl":
+			fv.SetString(pm.StyleURL)
+		case "coordinates":
+			if pm.Geometry == nil {
+				continue
+			}
+			coords := getGeometryCoordinates(pm.Geometry)
+			if fv.Type() == coordinateType {
+				if len(coords) > 0 {
+					fv.Set(reflect.ValueOf(coords[0]))
+				}
+			} else {
+				fv.Set(reflect.ValueOf(append([]Coordinate(nil), coords...)))
+			}
+		case "data":
+			s, ok := pm.dataValue(f.dataName)
+			if !ok {
+				continue
+			}
+			if err := parseBindValue(fv, s); err != nil {
+				return fmt.Errorf("kml: field %s: data %q: %w", rv.Type().Field(f.index).Name, f.dataName, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// dataValue returns the value of the named ExtendedData field, searching
+// Data elements first and then SimpleData inside SchemaData.
+func (p *Placemark) dataValue(name string) (string, bool) {
+	if p.ExtendedData == nil {
+		return "", false
+	}
+	for _, d := range p.ExtendedData.Data {
+		if d.Name == name {
+			return d.Value, true
+		}
+	}
+	for _, sd := range p.ExtendedData.SchemaData {
+		for _, d := range sd.SimpleData {
+			if d.Name == name {
+				return d.Value, true
+			}
+		}
+	}
+	return "", false
+}
+
+// formatBindValue converts a field value into its ExtendedData string form.
+func formatBindValue(v reflect.Value) (string, error) {
+	if (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) && v.IsNil() {
+		return "", nil
+	}
+	if v.Type().Implements(textMarshaler) {
+		b, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(b), err
+	}
+	if v.Kind() == reflect.Pointer {
+		return formatBindValue(v.Elem())
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits()), nil
+	}
+
+	return "", fmt.Errorf("unsupported type %s", v.Type())
+}
+
+// parseBindValue parses an ExtendedData string into a field value.
+func parseBindValue(v reflect.Value, s string) error {
+	if v.Kind() == reflect.Pointer {
+		if s == "" {
+			v.SetZero()
+			return nil
+		}
+		elem := reflect.New(v.Type().Elem())
+		if err := parseBindValue(elem.Elem(), s); err != nil {
+			return err
+		}
+		v.Set(elem)
+		return nil
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(textUnmarshaler) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+
+	if v.Kind() == reflect.String {
+		v.SetString(s)
+		return nil
+	}
+
+	s = strings.TrimSpace(s)
+	switch v.Kind() {
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+package kml
+
+import (
+	"testing"
+	"time"
+)
+
+type bindSite struct {
+	ID       string     `kml:"id"`
+	Name     string     `kml:"name"`
+	Notes    string     `kml:"description"`
+	Pos      Coordinate `kml:"coordinates"`
+	Speed    float64    `kml:"data=speed"`
+	Count    int        `kml:"data=count"`
+	Active   bool       `kml:"data=active"`
+	Seen     time.Time  `kml:"data=seen"`
+	Comment  string     `kml:"data=comment,omitempty"`
+	Internal string     `kml:"-"`
+	Untagged string
+}
+
+// TestMarshalPlacemark tests building a Placemark from a tagged struct
+func TestMarshalPlacemark(t *testing.T) {
+	seen := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	site := bindSite{
+		ID:       "s1",
+		Name:     "Station",
+		Notes:    "Main station",
+		Pos:      Coord(-122.1, 37.4, 10),
+		Speed:    12.5,
+		Count:    3,
+		Active:   true,
+		Seen:     seen,
+		Internal: "secret",
+		Untagged: "ignored",
+	}
+
+	pm, err := MarshalPlacemark(&site)
+	if err != nil {
+		t.Fatalf("MarshalPlacemark() error: %v", err)
+	}
+
+	if pm.ID != "s1" || pm.Name != "Station" || pm.Description != "Main station" {
+		t.Errorf("Unexpected placemark fields: %+v", pm)
+	}
+
+	pt, ok := pm.Geometry.(*Point)
+	if !ok || pt.Coordinates != Coord(-122.1, 37.4, 10) {
+		t.Errorf("Unexpected geometry: %#v", pm.Geometry)
+	}
+
+	want := []Data{
+		{Name: "speed", Value: "12.5"},
+		{Name: "count", Value: "3"},
+		{Name: "active", Value: "true"},
+		{Name: "seen", Value: "2024-05-01T12:00:00Z"},
+	}
+	if len(pm.ExtendedData.Data) != len(want) {
+		t.Fatalf("Expected %d data fields, got %+v", len(want), pm.ExtendedData.Data)
+	}
+	for i, d := range want {
+		if pm.ExtendedData.Data[i] != d {
+			t.Errorf("Data[%d] = %+v, want %+v", i, pm.ExtendedData.Data[i], d)
+		}
+	}
+}
+
+// TestUnmarshalPlacemark tests filling a tagged struct from a Placemark
+func TestUnmarshalPlacemark(t *testing.T) {
+	pm := &Placemark{
+		ID:       "s2",
+		Name:     "Tower",
+		Geometry: &Point{Coordinates: Coord(1, 2, 3)},
+		ExtendedData: &ExtendedData{
+			Data: []Data{
+				{Name: "speed", Value: " 4.25 "},
+				{Name: "active", Value: "false"},
+				{Name: "comment", Value: "  padded  "},
+			},
+			SchemaData: []SchemaData{{
+				SimpleData: []SimpleData{
+					{Name: "count", Value: "7"},
+					{Name: "seen", Value: "2023-01-02T03:04:05Z"},
+				},
+			}},
+		},
+	}
+
+	site := bindSite{Active: true}
+	if err := UnmarshalPlacemark(pm, &site); err != nil {
+		t.Fatalf("UnmarshalPlacemark() error: %v", err)
+	}
+
+	if site.ID != "s2" || site.Name != "Tower" {
+		t.Errorf("Unexpected identity fields: %+v", site)
+	}
+	if site.Pos != Coord(1, 2, 3) {
+		t.Errorf("Expected position (1,2,3), got %v", site.Pos)
+	}
+	if site.Speed != 4.25 || site.Count != 7 || site.Active {
+		t.Errorf("Unexpected data fields: speed=%v count=%v active=%v", site.Speed, site.Count, site.Active)
+	}
+	if want := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC); !site.Seen.Equal(want) {
+		t.Errorf("Expected seen %v, got %v", want, site.Seen)
+	}
+	if site.Comment != "  padded  " {
+		t.Errorf("Expected string data to be kept verbatim, got %q", site.Comment)
+	}
+}
+
+// TestBindLineString tests that []Coordinate fields map to LineStrings
+func TestBindLineString(t *testing.T) {
+	type route struct {
+		Path []Coordinate `kml:"coordinates"`
+	}
+
+	in := route{Path: []Coordinate{Coord(0, 0), Coord(1, 1)}}
+	pm, err := MarshalPlacemark(in)
+	if err != nil {
+		t.Fatalf("MarshalPlacemark() error: %v", err)
+	}
+	if _, ok := pm.Geometry.(*LineString); !ok {
+		t.Fatalf("Expected LineString geometry, got %T", pm.Geometry)
+	}
+
+	var out route
+	if err := UnmarshalPlacemark(pm, &out); err != nil {
+		t.Fatalf("UnmarshalPlacemark() error: %v", err)
+	}
+	if !coordSliceEqual(out.Path, in.Path) {
+		t.Errorf("Expected path %v, got %v", in.Path, out.Path)
+	}
+}
+
+// TestBindPointers tests round-tripping pointer fields, set and nil
+func TestBindPointers(t *testing.T) {
+	seen := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	count := 3
+	type visit struct {
+		Seen  *time.Time `kml:"data=seen"`
+		Count *int       `kml:"data=count"`
+	}
+
+	tests := []struct {
+		name string
+		in   visit
+		want []string
+	}{
+		{"nil", visit{}, []string{"", ""}},
+		{"set", visit{Seen: &seen, Count: &count}, []string{"2024-05-01T12:00:00Z", "3"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pm, err := MarshalPlacemark(tt.in)
+			if err != nil {
+				t.Fatalf("MarshalPlacemark() error: %v", err)
+			}
+			data := pm.ExtendedData.Data
+			if len(data) != 2 || data[0].Value != tt.want[0] || data[1].Value != tt.want[1] {
+				t.Errorf("Expected data %q, got %+v", tt.want, data)
+			}
+
+			out := visit{Seen: &time.Time{}, Count: new(int)}
+			if err := UnmarshalPlacemark(pm, &out); err != nil {
+				t.Fatalf("UnmarshalPlacemark() error: %v", err)
+			}
+			if (out.Seen == nil) != (tt.in.Seen == nil) || out.Seen != nil && !out.Seen.Equal(*tt.in.Seen) {
+				t.Errorf("Expected seen %v, got %v", tt.in.Seen, out.Seen)
+			}
+			if (out.Count == nil) != (tt.in.Count == nil) || out.Count != nil && *out.Count != *tt.in.Count {
+				t.Errorf("Expected count %v, got %v", tt.in.Count, out.Count)
+			}
+		})
+	}
+}
+
+// TestBindErrors tests error reporting for unsupported targets and values
+func TestBindErrors(t *testing.T) {
+	type badTag struct {
+		X string `kml:"bogus"`
+	}
+	type badName struct {
+		X int `kml:"name"`
+	}
+	type badData struct {
+		X []string `kml:"data=x"`
+	}
+	type number struct {
+		N int `kml:"data=n"`
+	}
+
+	tests := []struct {
+		name string
+		fn   func() error
+	}{
+		{"unknown tag", func() error { _, err := MarshalPlacemark(badTag{}); return err }},
+		{"non-string name", func() error { _, err := MarshalPlacemark(badName{}); return err }},
+		{"unsupported data type", func() error { _, err := MarshalPlacemark(badData{}); return err }},
+		{"non-struct", func() error { _, err := MarshalPlacemark(42); return err }},
+		{"non-pointer target", func() error { return UnmarshalPlacemark(&Placemark{}, number{}) }},
+		{"invalid number", func() error {
+			pm := &Placemark{ExtendedData: &ExtendedData{Data: []Data{{Name: "n", Value: "abc"}}}}
+			return UnmarshalPlacemark(pm, &number{})
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.fn(); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
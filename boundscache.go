@@ -0,0 +1,130 @@
+package kml
+
+import "sync"
+
+// boundsCache holds the bounds of the features of a document, as computed
+// by Bounds and FeatureBounds after CacheBounds. mu guards the maps, so
+// that the document may be read from several goroutines.
+type boundsCache struct {
+	mu     sync.Mutex
+	boxes  map[Feature]featureBox
+	parent map[Feature]Feature // Containers of the features with boxes
+}
+
+// featureBox is the bounding box of the coordinates of a feature and its
+// descendants; ok is false if they have none.
+type featureBox struct {
+	minLon, minLat, maxLon, maxLat float64
+	ok                             bool
+}
+
+// extend grows b to cover o.
+func (b *featureBox) extend(o featureBox) {
+	switch {
+	case !o.ok:
+	case !b.ok:
+		*b = o
+	default:
+		b.minLon, b.minLat = min(b.minLon, o.minLon), min(b.minLat, o.minLat)
+		b.maxLon, b.maxLat = max(b.maxLon, o.maxLon), max(b.maxLat, o.maxLat)
+	}
+}
+
+// corners returns the southwest and northeast corners of b.
+func (b featureBox) corners() (sw, ne Coordinate) {
+	if !b.ok {
+		return Coordinate{}, Coordinate{}
+	}
+	return Coordinate{Lon: b.minLon, Lat: b.minLat}, Coordinate{Lon: b.maxLon, Lat: b.maxLat}
+}
+
+// CacheBounds makes Bounds and FeatureBounds remember the bounds of every
+// feature they compute, so that on a large document that does not change,
+// repeated calls, such as one per request to fit a view, return without
+// reading coordinates again. Bounds and FeatureBounds remain safe to call
+// from several goroutines.
+//
+// The package's own edits, ApplyPatch, Session steps, RoundCoordinates,
+// TransformGeometries, Geocode and CheckCoordinateOrder, discard the cache.
+// After changing the document directly, call InvalidateBounds with each
+// feature changed, including containers features were added to or removed
+// from, or ResetBounds.
+func (k *KML) CacheBounds() {
+	if k.bounds == nil {
+		k.bounds = &boundsCache{boxes: make(map[Feature]featureBox), parent: make(map[Feature]Feature)}
+	}
+}
+
+// InvalidateBounds discards the cached bounds of f and of the containers
+// holding it, which are recomputed on the next call from the cached bounds
+// of their other children. It does nothing unless CacheBounds was called.
+func (k *KML) InvalidateBounds(f Feature) {
+	c := k.bounds
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for f != nil {
+		delete(c.boxes, f)
+		f = c.parent[f]
+	}
+}
+
+// ResetBounds discards all cached bounds, keeping caching on if
+// CacheBounds was called.
+func (k *KML) ResetBounds() {
+	if k.bounds != nil {
+		k.bounds = nil
+		k.CacheBounds()
+	}
+}
+
+// FeatureBounds returns the southwest and northeast corners of a bounding
+// box containing the coordinates of f and its descendants, and whether
+// there are any. After CacheBounds, f should be a feature of k.
+func (k *KML) FeatureBounds(f Feature) (sw, ne Coordinate, ok bool) {
+	b := k.featureBox(f)
+	sw, ne = b.corners()
+	return sw, ne, b.ok
+}
+
+// featureBox returns the bounding box of f and its descendants, from the
+// cache if there is one.
+func (k *KML) featureBox(f Feature) featureBox {
+	c := k.bounds
+	if c == nil {
+		return c.box(f)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.box(f)
+}
+
+// box returns the bounding box of f and its descendants, reading and
+// filling c, which may be nil, with c.mu held.
+func (c *boundsCache) box(f Feature) featureBox {
+	if c != nil {
+		if b, ok := c.boxes[f]; ok {
+			return b
+		}
+	}
+
+	var b featureBox
+	if coords := collectCoordinates(f); len(coords) > 0 {
+		b.minLon, b.minLat, b.maxLon, b.maxLat = boundsOf(coords)
+		b.ok = true
+	}
+	if children := childrenOf(f); children != nil {
+		for _, child := range *children {
+			if c != nil {
+				c.parent[child] = f
+			}
+			b.extend(c.box(child))
+		}
+	}
+	if c != nil {
+		c.boxes[f] = b
+	}
+	return b
+}
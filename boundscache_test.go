@@ -0,0 +1,98 @@
+package kml
+
+import (
+	"sync"
+	"testing"
+)
+
+// boundsCacheKML returns a document of two folders of points
+func boundsCacheKML() (*KML, *Folder, *Placemark) {
+	moved := &Placemark{Geometry: &Point{Coordinates: Coord(3, 3)}}
+	east := &Folder{Name: "east", Features: []Feature{moved, &Placemark{Geometry: &Point{Coordinates: Coord(4, 1)}}}}
+	k := NewKML()
+	k.Feature = &Document{Features: []Feature{
+		&Folder{Name: "west", Features: []Feature{&Placemark{Geometry: &Point{Coordinates: Coord(-2, -1)}}}},
+		east,
+		&Placemark{Name: "empty"},
+	}}
+	return k, east, moved
+}
+
+// TestCacheBounds tests reusing and invalidating cached bounds
+func TestCacheBounds(t *testing.T) {
+	k, east, moved := boundsCacheKML()
+	k.CacheBounds()
+
+	if sw, ne := k.Bounds(); sw != Coord(-2, -1) || ne != Coord(4, 3) {
+		t.Fatalf("Expected -2,-1 to 4,3, got %v %v", sw, ne)
+	}
+	if sw, ne, ok := k.FeatureBounds(east); !ok || sw != Coord(3, 1) || ne != Coord(4, 3) {
+		t.Errorf("Expected east folder 3,1 to 4,3, got %v %v %v", sw, ne, ok)
+	}
+
+	// Unreported changes are not seen
+	moved.Geometry.(*Point).Coordinates = Coord(9, 9)
+	if _, ne := k.Bounds(); ne != Coord(4, 3) {
+		t.Errorf("Expected the cached bounds, got %v", ne)
+	}
+
+	k.InvalidateBounds(moved)
+	if _, ok := k.bounds.boxes[k.Feature]; ok {
+		t.Errorf("Expected the root's bounds discarded with its descendant's")
+	}
+	if _, ok := k.bounds.boxes[k.Feature.(*Document).Features[0]]; !ok {
+		t.Errorf("Expected the west folder's bounds kept")
+	}
+	if sw, ne := k.Bounds(); sw != Coord(-2, -1) || ne != Coord(9, 9) {
+		t.Errorf("Expected -2,-1 to 9,9, got %v %v", sw, ne)
+	}
+
+	if _, _, ok := k.FeatureBounds(k.Feature.(*Document).Features[2]); ok {
+		t.Errorf("Expected no bounds for a placemark without geometry")
+	}
+}
+
+// TestCacheBoundsEdits tests that the package's edits discard the cache
+func TestCacheBoundsEdits(t *testing.T) {
+	k, _, _ := boundsCacheKML()
+	k.CacheBounds()
+	k.Bounds()
+
+	k.TransformGeometries(func(g Geometry) Geometry {
+		if p, ok := g.(*Point); ok {
+			return &Point{Coordinates: Coord(p.Coordinates.Lon*10, p.Coordinates.Lat)}
+		}
+		return g
+	})
+	if sw, ne := k.Bounds(); sw != Coord(-20, -1) || ne != Coord(40, 3) {
+		t.Errorf("Expected -20,-1 to 40,3, got %v %v", sw, ne)
+	}
+
+	if err := k.ApplyPatch([]PatchOp{{Op: "remove", Path: "/Feature/Features/1"}}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if sw, ne := k.Bounds(); sw != Coord(-20, -1) || ne != Coord(-20, -1) {
+		t.Errorf("Expected the west point only, got %v %v", sw, ne)
+	}
+}
+
+// TestCacheBoundsConcurrent tests computing cached bounds from several goroutines
+func TestCacheBoundsConcurrent(t *testing.T) {
+	k, east, _ := boundsCacheKML()
+	k.CacheBounds()
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if sw, ne := k.Bounds(); sw != Coord(-2, -1) || ne != Coord(4, 3) {
+				t.Errorf("Expected -2,-1 to 4,3, got %v %v", sw, ne)
+			}
+			if sw, _, ok := k.FeatureBounds(east); !ok || sw != Coord(3, 1) {
+				t.Errorf("Expected east folder from 3,1, got %v %v", sw, ok)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,107 @@
+package kml
+
+import "math"
+
+// BoundsOf returns the southwest and northeast corners of the bounding box
+// of coords, or zero coordinates if coords is empty. Altitude is ignored.
+//
+// It is meant for analytic passes over large coordinate slices, such as the
+// arena returned by Compact. It keeps two independent sets of accumulators
+// and compares with plain branches rather than the min and max builtins,
+// whose NaN handling defeats pipelining; a NaN coordinate other than the
+// first is therefore ignored.
+func BoundsOf(coords []Coordinate) (sw, ne Coordinate) {
+	if len(coords) == 0 {
+		return Coordinate{}, Coordinate{}
+	}
+	minLon, minLat, maxLon, maxLat := boundsOf(coords)
+	return Coordinate{Lon: minLon, Lat: minLat}, Coordinate{Lon: maxLon, Lat: maxLat}
+}
+
+// LengthOf returns the great-circle length in meters of the path through
+// coords, or its length in the mode set with SetMathMode. Altitude is
+// ignored.
+//
+// Unlike BoundsOf, it must be given a single path: called on an arena, it
+// would also count the jumps from the end of one geometry to the start of
+// the next.
+func LengthOf(coords []Coordinate) float64 {
+	return CurrentMathMode().Length(coords)
+}
+
+// boundsOf is the portable kernel of BoundsOf. An assembly version for
+// amd64 or arm64 can replace it behind build tags with the same signature.
+func boundsOf(coords []Coordinate) (minLon, minLat, maxLon, maxLat float64) {
+	minLon0, minLat0, maxLon0, maxLat0 := coords[0].Lon, coords[0].Lat, coords[0].Lon, coords[0].Lat
+	minLon1, minLat1, maxLon1, maxLat1 := minLon0, minLat0, maxLon0, maxLat0
+
+	i := 0
+	for ; i+2 <= len(coords); i += 2 {
+		a, b := coords[i], coords[i+1]
+		if a.Lon < minLon0 {
+			minLon0 = a.Lon
+		}
+		if a.Lon > maxLon0 {
+			maxLon0 = a.Lon
+		}
+		if a.Lat < minLat0 {
+			minLat0 = a.Lat
+		}
+		if a.Lat > maxLat0 {
+			maxLat0 = a.Lat
+		}
+		if b.Lon < minLon1 {
+			minLon1 = b.Lon
+		}
+		if b.Lon > maxLon1 {
+			maxLon1 = b.Lon
+		}
+		if b.Lat < minLat1 {
+			minLat1 = b.Lat
+		}
+		if b.Lat > maxLat1 {
+			maxLat1 = b.Lat
+		}
+	}
+	if i < len(coords) {
+		c := coords[i]
+		if c.Lon < minLon1 {
+			minLon1 = c.Lon
+		}
+		if c.Lon > maxLon1 {
+			maxLon1 = c.Lon
+		}
+		if c.Lat < minLat1 {
+			minLat1 = c.Lat
+		}
+		if c.Lat > maxLat1 {
+			maxLat1 = c.Lat
+		}
+	}
+
+	return min(minLon0, minLon1), min(minLat0, minLat1), max(maxLon0, maxLon1), max(maxLat0, maxLat1)
+}
+
+// lengthOf is the portable kernel of LengthOf. It computes the same
+// haversine distance as haversine, but carries the latitude and its cosine
+// from one segment to the next so each point is converted only once.
+func lengthOf(coords []Coordinate) float64 {
+	const rad = math.Pi / 180
+
+	var total float64
+	lat1 := coords[0].Lat * rad
+	lon1 := coords[0].Lon * rad
+	cos1 := math.Cos(lat1)
+	for _, c := range coords[1:] {
+		lat2, lon2 := c.Lat*rad, c.Lon*rad
+		cos2 := math.Cos(lat2)
+
+		sLat := math.Sin((lat2 - lat1) / 2)
+		sLon := math.Sin((lon2 - lon1) / 2)
+		h := sLat*sLat + cos1*cos2*sLon*sLon
+		total += math.Atan2(math.Sqrt(h), math.Sqrt(1-h))
+
+		lat1, lon1, cos1 = lat2, lon2, cos2
+	}
+	return 2 * earthRadius * total
+}
@@ -0,0 +1,69 @@
+package kml
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// TestBoundsOf tests bulk bounding box computation
+func TestBoundsOf(t *testing.T) {
+	tests := []struct {
+		name   string
+		coords []Coordinate
+		sw, ne Coordinate
+	}{
+		{"empty", nil, Coordinate{}, Coordinate{}},
+		{"single", []Coordinate{Coord(3, 4, 100)}, Coord(3, 4), Coord(3, 4)},
+		{"tail only", []Coordinate{Coord(1, 5), Coord(-2, 3), Coord(4, -1)}, Coord(-2, -1), Coord(4, 5)},
+		{"unrolled and tail", []Coordinate{Coord(0, 0), Coord(1, 1), Coord(2, 2), Coord(3, 3), Coord(-10, 20), Coord(5, -5), Coord(0, 30)}, Coord(-10, -5), Coord(5, 30)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sw, ne := BoundsOf(tt.coords)
+			if sw != tt.sw || ne != tt.ne {
+				t.Errorf("Expected %v %v, got %v %v", tt.sw, tt.ne, sw, ne)
+			}
+		})
+	}
+}
+
+// TestBoundsOfMatchesBounds tests BoundsOf against the document bounds
+func TestBoundsOfMatchesBounds(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	doc := &Document{}
+	for i := 0; i < 20; i++ {
+		coords := make([]Coordinate, 1+rng.Intn(30))
+		for j := range coords {
+			coords[j] = Coord(rng.Float64()*360-180, rng.Float64()*180-90)
+		}
+		doc.Features = append(doc.Features, &Placemark{Geometry: &LineString{Coordinates: coords}})
+	}
+	k := NewKML()
+	k.Feature = doc
+
+	wantSW, wantNE := k.Bounds()
+	sw, ne := BoundsOf(k.Compact())
+	if sw != wantSW || ne != wantNE {
+		t.Errorf("Expected %v %v, got %v %v", wantSW, wantNE, sw, ne)
+	}
+}
+
+// TestLengthOf tests bulk path length computation
+func TestLengthOf(t *testing.T) {
+	path := []Coordinate{Coord(0, 0), Coord(1, 0), Coord(1, 1), Coord(-0.5, 1.5), Coord(2, 51)}
+	want := 0.0
+	for i := 1; i < len(path); i++ {
+		want += haversine(path[i-1], path[i])
+	}
+
+	if got := LengthOf(path); !floatNear(got, want, 1e-6) {
+		t.Errorf("Expected %f, got %f", want, got)
+	}
+	if got := LengthOf(path[:1]); got != 0 {
+		t.Errorf("Expected 0 for a single point, got %f", got)
+	}
+	if got := LengthOf(nil); got != 0 {
+		t.Errorf("Expected 0 for no points, got %f", got)
+	}
+}
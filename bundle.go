@@ -0,0 +1,173 @@
+package kml
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"io"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// BundleOptions configures BundleIcons.
+type BundleOptions struct {
+	// Dir is the archive directory the icons are stored in. The default is
+	// "files", where Google Earth keeps the files of the KMZs it saves.
+	Dir string
+
+	// SkipMissing leaves the hrefs of icons the Fetcher fails to load as
+	// they are, instead of failing.
+	SkipMissing bool
+}
+
+// defaults returns o with zero fields set to their defaults.
+func (o BundleOptions) defaults() BundleOptions {
+	if o.Dir == "" {
+		o.Dir = "files"
+	}
+	o.Dir = strings.Trim(o.Dir, "/")
+	return o
+}
+
+// BundleIcons makes the document self-contained: it loads every icon the
+// styles of the document refer to, the IconStyle and ListStyle item icons
+// that Assets reports, with f, and rewrites their hrefs to name files in
+// opts.Dir. Each href is loaded once, and icons with the same content are
+// stored once whatever href they came from. Files are named after the last
+// element of the href's path, made safe for an archive and numbered from 2
+// when names clash, with the extension of the image format when the href
+// has none or another, as a script serving icons does.
+//
+// BundleIcons returns KMZFile options holding the icons, to be given to
+// WriteFile with a .kmz path:
+//
+//	files, err := doc.BundleIcons(fetcher, kml.BundleOptions{})
+//	if err != nil {
+//		return err
+//	}
+//	err = doc.WriteFile("portable.kmz", files...)
+//
+// If an icon cannot be loaded, BundleIcons fails without changing the
+// document, unless opts.SkipMissing is set.
+func (k *KML) BundleIcons(f Fetcher, opts BundleOptions) ([]WriteOption, error) {
+	opts = opts.defaults()
+
+	names := make(map[string]string)     // href to archive name
+	byContent := make(map[string]string) // content to archive name
+	taken := make(map[string]bool)
+	var files []WriteOption
+	for _, a := range k.Assets() {
+		if a.Kind != AssetIcon {
+			continue
+		}
+		data, err := fetchAll(f, a.Href)
+		if err != nil {
+			if opts.SkipMissing {
+				continue
+			}
+			return nil, fmt.Errorf("kml: error fetching icon %s: %w", a.Href, err)
+		}
+		if name, ok := byContent[string(data)]; ok {
+			names[a.Href] = name
+			continue
+		}
+
+		base, ext := bundleName(a.Href, data)
+		name := path.Join(opts.Dir, base+ext)
+		for n := 2; taken[name]; n++ {
+			name = path.Join(opts.Dir, base+strconv.Itoa(n)+ext)
+		}
+		taken[name] = true
+		byContent[string(data)] = name
+		names[a.Href] = name
+		files = append(files, KMZFile(name, data))
+	}
+
+	rewrite := func(href *string) {
+		if name, ok := names[*href]; ok {
+			*href = name
+		}
+	}
+	k.Walk(func(f Feature) error {
+		switch feature := f.(type) {
+		case *Document:
+			for i := range feature.Styles {
+				eachIconHref(&feature.Styles[i], rewrite)
+			}
+		case *Placemark:
+			eachIconHref(feature.Style, rewrite)
+		}
+		return nil
+	})
+	return files, nil
+}
+
+// fetchAll reads the whole resource f returns for href.
+func fetchAll(f Fetcher, href string) ([]byte, error) {
+	if f == nil {
+		return nil, ErrImageNotFound
+	}
+	rc, err := f.Fetch(href)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// eachIconHref calls fn with a pointer to the icon href and each list item
+// icon href of s, which may be nil.
+func eachIconHref(s *Style, fn func(*string)) {
+	if s == nil {
+		return
+	}
+	if s.IconStyle != nil && s.IconStyle.Icon != nil && s.IconStyle.Icon.Href != "" {
+		fn(&s.IconStyle.Icon.Href)
+	}
+	if s.ListStyle != nil {
+		for i := range s.ListStyle.ItemIcons {
+			if s.ListStyle.ItemIcons[i].Href != "" {
+				fn(&s.ListStyle.ItemIcons[i].Href)
+			}
+		}
+	}
+}
+
+// bundleName returns the base name and extension to store an icon loaded
+// from href under. Images whose extension does not match their format, as
+// when served by a script, are given the extension of the format.
+// Characters other than letters, digits, dots, dashes and underscores
+// become dashes, so the name needs no escaping as an href.
+func bundleName(href string, data []byte) (base, ext string) {
+	name := unescapeHref(href)
+	if u, err := url.Parse(href); err == nil {
+		name = u.Path
+	}
+	name = path.Base(name)
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+			return r
+		}
+		return '-'
+	}, name)
+
+	ext = path.Ext(name)
+	base = strings.TrimSuffix(name, ext)
+	if ext == "." {
+		ext = ""
+	}
+	if strings.Trim(base, ".-_") == "" {
+		base = "icon"
+	}
+	if _, format, err := image.DecodeConfig(bytes.NewReader(data)); err == nil {
+		switch e := strings.ToLower(ext); {
+		case e == "."+format, e == ".jpg" && format == "jpeg":
+		default:
+			ext = "." + strings.Replace(format, "jpeg", "jpg", 1)
+		}
+	}
+	return base, ext
+}
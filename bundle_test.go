@@ -0,0 +1,147 @@
+package kml
+
+import (
+	"archive/zip"
+	"errors"
+	"image/color"
+	"io"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestBundleIcons tests embedding the icons of a document in a KMZ
+func TestBundleIcons(t *testing.T) {
+	red := pngBytes(t, 2, 2, color.RGBA{255, 0, 0, 255})
+	blue := pngBytes(t, 2, 2, color.RGBA{0, 0, 255, 255})
+	icons := map[string]string{
+		"http://a.example/pin.png":       red,
+		"http://b.example/pin.png":       blue,
+		"http://c.example/red%20pin.png": red,
+		"http://d.example/icon?id=7":     blue,
+		"list item.png":                  blue,
+	}
+	var fetched []string
+	fetcher := FetcherFunc(func(href string) (io.ReadCloser, error) {
+		fetched = append(fetched, href)
+		data, ok := icons[href]
+		if !ok {
+			return nil, errors.New("404")
+		}
+		return io.NopCloser(strings.NewReader(data)), nil
+	})
+
+	icon := func(href string) *IconStyle { return &IconStyle{Icon: &Icon{Href: href}} }
+	k := NewKML()
+	k.Feature = &Document{
+		Styles: []Style{
+			{ID: "a", IconStyle: icon("http://a.example/pin.png")},
+			{ID: "b", IconStyle: icon("http://b.example/pin.png"), ListStyle: &ListStyle{ItemIcons: []ItemIcon{{Href: "list item.png"}}}},
+		},
+		Features: []Feature{
+			&Placemark{StyleURL: "#a"},
+			&Placemark{Style: &Style{IconStyle: icon("http://a.example/pin.png")}},
+			&Placemark{Style: &Style{IconStyle: icon("http://c.example/red%20pin.png")}},
+			&Placemark{Style: &Style{IconStyle: icon("http://d.example/icon?id=7")}},
+		},
+	}
+
+	files, err := k.BundleIcons(fetcher, BundleOptions{})
+	if err != nil {
+		t.Fatalf("BundleIcons failed: %v", err)
+	}
+	if len(fetched) != 5 {
+		t.Errorf("Expected each href fetched once, got %v", fetched)
+	}
+	var hrefs []string
+	for _, a := range k.Assets() {
+		hrefs = append(hrefs, a.Href)
+	}
+	if want := []string{"files/pin.png", "files/pin2.png"}; !reflect.DeepEqual(hrefs, want) {
+		t.Errorf("Expected hrefs %v, got %v", want, hrefs)
+	}
+
+	path := filepath.Join(t.TempDir(), "bundle.kmz")
+	if err := k.WriteFile(path, files...); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatalf("OpenReader failed: %v", err)
+	}
+	defer zr.Close()
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	if want := []string{"doc.kml", "files/pin.png", "files/pin2.png"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Expected archive %v, got %v", want, names)
+	}
+}
+
+// TestBundleIconsMissing tests icons the Fetcher cannot load
+func TestBundleIconsMissing(t *testing.T) {
+	red := pngBytes(t, 1, 1, color.RGBA{255, 0, 0, 255})
+	fetcher := FetcherFunc(func(href string) (io.ReadCloser, error) {
+		if href == "gone.png" {
+			return nil, errors.New("404")
+		}
+		return io.NopCloser(strings.NewReader(red)), nil
+	})
+	newKML := func() *KML {
+		k := NewKML()
+		k.Feature = &Document{Styles: []Style{
+			{ID: "a", IconStyle: &IconStyle{Icon: &Icon{Href: "http://example.com/marker"}}},
+			{ID: "b", IconStyle: &IconStyle{Icon: &Icon{Href: "gone.png"}}},
+		}}
+		return k
+	}
+	hrefs := func(k *KML) []string {
+		styles := k.Feature.(*Document).Styles
+		return []string{styles[0].IconStyle.Icon.Href, styles[1].IconStyle.Icon.Href}
+	}
+
+	k := newKML()
+	if _, err := k.BundleIcons(fetcher, BundleOptions{}); err == nil || !strings.Contains(err.Error(), "gone.png") {
+		t.Errorf("Expected fetch error, got %v", err)
+	}
+	if got, want := hrefs(k), []string{"http://example.com/marker", "gone.png"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected document unchanged, got %v", got)
+	}
+
+	k = newKML()
+	files, err := k.BundleIcons(fetcher, BundleOptions{Dir: "icons", SkipMissing: true})
+	if err != nil {
+		t.Fatalf("BundleIcons failed: %v", err)
+	}
+	if len(files) != 1 {
+		t.Errorf("Expected one file, got %d", len(files))
+	}
+	if got, want := hrefs(k), []string{"icons/marker.png", "gone.png"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+// TestBundleName tests naming bundled icons
+func TestBundleName(t *testing.T) {
+	png := pngBytes(t, 1, 1, color.Black)
+	tests := []struct {
+		href string
+		want string
+	}{
+		{"http://maps.google.com/mapfiles/kml/paddle/red-circle.png", "red-circle.png"},
+		{"http://example.com/icons/my%20pin.png?v=2", "my-pin.png"},
+		{"images/tent.PNG", "tent.PNG"},
+		{"http://example.com/icon.php?id=7", "icon.png"},
+		{"photo.jpeg", "photo.png"},
+		{"http://example.com/marker", "marker.png"},
+		{"http://example.com/", "icon.png"},
+	}
+
+	for _, tt := range tests {
+		if base, ext := bundleName(tt.href, []byte(png)); base+ext != tt.want {
+			t.Errorf("bundleName(%q): expected %q, got %q", tt.href, tt.want, base+ext)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package kml
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// RemovalReason says why CleanTrack removed a point.
+type RemovalReason int
+
+const (
+	// RemovedSpeed marks a point reached from the point before it faster
+	// than CleanOptions.MaxSpeed allows, such as a GPS jump.
+	RemovedSpeed RemovalReason = iota
+	// RemovedAcceleration marks a point whose speed changes from that of
+	// the segment before it faster than CleanOptions.MaxAcceleration.
+	RemovedAcceleration
+	// RemovedTime marks a point recorded at or before the time of the
+	// point before it.
+	RemovedTime
+)
+
+// String returns a short description of the reason.
+func (r RemovalReason) String() string {
+	switch r {
+	case RemovedSpeed:
+		return "impossible speed"
+	case RemovedAcceleration:
+		return "impossible acceleration"
+	case RemovedTime:
+		return "time out of order"
+	}
+	return "unknown"
+}
+
+// RemovedPoint describes a point removed by CleanTrack. Speed is the speed
+// implied by keeping the point, in meters per second, or NaN for a point
+// removed for its time.
+type RemovedPoint struct {
+	Index  int // Index of the point in the input track
+	When   time.Time
+	Coord  Coordinate
+	Reason RemovalReason
+	Speed  float64
+}
+
+// String returns a human-readable description of the removed point.
+func (p RemovedPoint) String() string {
+	if math.IsNaN(p.Speed) {
+		return fmt.Sprintf("point %d at %s: %s", p.Index, formatDateTime(p.When), p.Reason)
+	}
+	return fmt.Sprintf("point %d at %s: %s (%.1f m/s)", p.Index, formatDateTime(p.When), p.Reason, p.Speed)
+}
+
+// CleanOptions configures CleanTrack.
+type CleanOptions struct {
+	// MaxSpeed is the fastest plausible speed in meters per second. The
+	// default is 100, fast enough for any road vehicle; raise it for
+	// aircraft.
+	MaxSpeed float64
+
+	// MaxAcceleration is the largest plausible change of speed in meters
+	// per second squared. Zero, the default, does not limit it.
+	MaxAcceleration float64
+
+	// Resume is the number of consecutive points that agree with each
+	// other but not with the track before them after which the jump to
+	// them is taken as real, as when a logger is carried elsewhere while
+	// off, instead of the rest of the track being removed. The points kept
+	// before the jump are removed instead if there are fewer of them, as
+	// when a track starts with a bad fix. The default is 3.
+	Resume int
+}
+
+// defaults returns o with zero fields set to their defaults.
+func (o CleanOptions) defaults() CleanOptions {
+	if o.MaxSpeed <= 0 {
+		o.MaxSpeed = 100
+	}
+	if o.Resume <= 0 {
+		o.Resume = 3
+	}
+	return o
+}
+
+// CleanTrack returns a copy of t without the points that imply impossible
+// movement, as raw logger data has from multipath and cold-start fixes,
+// and a report of the points removed in track order. Each point is checked
+// against the last point kept, so a spike of several bad points is removed
+// whole. An untimed track cannot be checked and is returned as an
+// unchanged copy.
+func CleanTrack(t *Track, opts CleanOptions) (*Track, []RemovedPoint) {
+	opts = opts.defaults()
+	out := &Track{ID: t.ID, AltitudeMode: t.AltitudeMode}
+	n := len(t.Coords)
+	if len(t.When) != n {
+		out.When = append([]time.Time(nil), t.When...)
+		out.Coords = append([]Coordinate(nil), t.Coords...)
+		return out, nil
+	}
+
+	// segment returns the speed and duration of the move from a to b.
+	segment := func(a, b int) (speed, secs float64) {
+		secs = t.When[b].Sub(t.When[a]).Seconds()
+		return t.Coords[a].DistanceTo(t.Coords[b]) / secs, secs
+	}
+
+	removed := make(map[int]RemovedPoint)
+	remove := func(i int, reason RemovalReason, speed float64) {
+		removed[i] = RemovedPoint{Index: i, When: t.When[i], Coord: t.Coords[i], Reason: reason, Speed: speed}
+	}
+
+	var kept, pending []int // Points kept, and rejected points agreeing with each other
+	run := 0                // Start in kept of the points since the last jump
+	prevSpeed, prevSecs := math.NaN(), 0.0
+	for i := 0; i < n; i++ {
+		if len(kept) == 0 {
+			kept = append(kept, i)
+			continue
+		}
+
+		speed, secs := segment(kept[len(kept)-1], i)
+		switch {
+		case secs <= 0:
+			remove(i, RemovedTime, math.NaN())
+			continue
+		case speed > opts.MaxSpeed:
+			remove(i, RemovedSpeed, speed)
+		case opts.MaxAcceleration > 0 && !math.IsNaN(prevSpeed) &&
+			math.Abs(speed-prevSpeed)/((secs+prevSecs)/2) > opts.MaxAcceleration:
+			remove(i, RemovedAcceleration, speed)
+		default:
+			kept = append(kept, i)
+			prevSpeed, prevSecs = speed, secs
+			pending = pending[:0]
+			continue
+		}
+
+		if len(pending) > 0 {
+			if s, secs := segment(pending[len(pending)-1], i); secs <= 0 || s > opts.MaxSpeed {
+				pending = pending[:0]
+			}
+		}
+		pending = append(pending, i)
+		if len(pending) < opts.Resume {
+			continue
+		}
+
+		// The track has moved: keep the pending points and drop a short
+		// run before them.
+		if len(kept)-run < opts.Resume {
+			for _, j := range kept[run:] {
+				s, _ := segment(j, pending[0])
+				remove(j, RemovedSpeed, s)
+			}
+			kept = kept[:run]
+		}
+		for _, j := range pending {
+			delete(removed, j)
+		}
+		run = len(kept)
+		kept = append(kept, pending...)
+		prevSpeed, prevSecs = math.NaN(), 0
+		if m := len(pending); m > 1 {
+			prevSpeed, prevSecs = segment(pending[m-2], pending[m-1])
+		}
+		pending = pending[:0]
+	}
+
+	for _, i := range kept {
+		out.When = append(out.When, t.When[i])
+		out.Coords = append(out.Coords, t.Coords[i])
+	}
+	var report []RemovedPoint
+	for i := 0; i < n; i++ {
+		if p, ok := removed[i]; ok {
+			report = append(report, p)
+		}
+	}
+	return out, report
+}
@@ -0,0 +1,133 @@
+package kml
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// walkTrack returns a timed track moving north 10 m every second, with the
+// points listed in moved shifted 0.1 degrees east
+func walkTrack(n int, moved ...int) *Track {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tr := &Track{}
+	north := Coordinate{}.Destination(0, 10).Lat
+	for i := 0; i < n; i++ {
+		tr.When = append(tr.When, start.Add(time.Duration(i)*time.Second))
+		tr.Coords = append(tr.Coords, Coordinate{Lat: float64(i) * north})
+	}
+	for _, i := range moved {
+		tr.Coords[i].Lon += 0.1
+	}
+	return tr
+}
+
+// removedIndexes returns the input indices of the removed points
+func removedIndexes(report []RemovedPoint) []int {
+	var idx []int
+	for _, p := range report {
+		idx = append(idx, p.Index)
+	}
+	return idx
+}
+
+// TestCleanTrack tests the points removed from noisy tracks
+func TestCleanTrack(t *testing.T) {
+	tests := []struct {
+		name    string
+		track   *Track
+		opts    CleanOptions
+		removed []int
+		reason  RemovalReason
+	}{
+		{"clean", walkTrack(6), CleanOptions{}, nil, RemovedSpeed},
+		{"single spike", walkTrack(6, 2), CleanOptions{}, []int{2}, RemovedSpeed},
+		{"double spike", walkTrack(8, 3, 4), CleanOptions{}, []int{3, 4}, RemovedSpeed},
+		{"bad first fix", walkTrack(6, 0), CleanOptions{}, []int{0}, RemovedSpeed},
+		{"bad last fix", walkTrack(6, 5), CleanOptions{}, []int{5}, RemovedSpeed},
+		{"fast enough", walkTrack(6, 2), CleanOptions{MaxSpeed: 20000}, nil, RemovedSpeed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, report := CleanTrack(tt.track, tt.opts)
+			if idx := removedIndexes(report); !reflect.DeepEqual(idx, tt.removed) {
+				t.Fatalf("Expected removed points %v, got %v", tt.removed, idx)
+			}
+			for _, p := range report {
+				if p.Reason != tt.reason || p.Coord != tt.track.Coords[p.Index] || !p.When.Equal(tt.track.When[p.Index]) {
+					t.Errorf("Expected point %d removed for %s, got %+v", p.Index, tt.reason, p)
+				}
+			}
+			if len(got.Coords) != len(tt.track.Coords)-len(tt.removed) || len(got.When) != len(got.Coords) {
+				t.Errorf("Expected %d points kept, got %d coords and %d times", len(tt.track.Coords)-len(tt.removed), len(got.Coords), len(got.When))
+			}
+		})
+	}
+}
+
+// TestCleanTrackJump tests that a sustained jump is kept
+func TestCleanTrackJump(t *testing.T) {
+	tr := walkTrack(10, 5, 6, 7, 8, 9)
+	got, report := CleanTrack(tr, CleanOptions{})
+	if len(report) != 0 {
+		t.Errorf("Expected no points removed, got %v", report)
+	}
+	if len(got.Coords) != 10 {
+		t.Errorf("Expected all 10 points kept, got %d", len(got.Coords))
+	}
+
+	// A jump shorter than Resume is removed
+	got, report = CleanTrack(tr, CleanOptions{Resume: 6})
+	if idx := removedIndexes(report); !reflect.DeepEqual(idx, []int{5, 6, 7, 8, 9}) {
+		t.Errorf("Expected points 5 to 9 removed, got %v", idx)
+	}
+	if len(got.Coords) != 5 {
+		t.Errorf("Expected 5 points kept, got %d", len(got.Coords))
+	}
+}
+
+// TestCleanTrackTimeAndAcceleration tests the other reasons for removal
+func TestCleanTrackTimeAndAcceleration(t *testing.T) {
+	tr := walkTrack(6)
+	tr.When[3] = tr.When[2]
+	_, report := CleanTrack(tr, CleanOptions{})
+	if len(report) != 1 || report[0].Index != 3 || report[0].Reason != RemovedTime || !math.IsNaN(report[0].Speed) {
+		t.Errorf("Expected point 3 removed for its time, got %v", report)
+	}
+
+	// Stopping dead from 10 m/s within a second
+	tr = walkTrack(6)
+	tr.Coords[4] = tr.Coords[3]
+	tr.Coords[5] = tr.Coords[3].Destination(0, 10)
+	_, report = CleanTrack(tr, CleanOptions{MaxAcceleration: 5})
+	if len(report) == 0 || report[0].Index != 4 || report[0].Reason != RemovedAcceleration {
+		t.Errorf("Expected point 4 removed for acceleration, got %v", report)
+	}
+	if _, report = CleanTrack(tr, CleanOptions{}); len(report) != 0 {
+		t.Errorf("Expected no limit on acceleration by default, got %v", report)
+	}
+}
+
+// TestCleanTrackUntimed tests that untimed tracks are copied unchanged
+func TestCleanTrackUntimed(t *testing.T) {
+	tr := &Track{Coords: []Coordinate{{Lon: 0}, {Lon: 90}}}
+	got, report := CleanTrack(tr, CleanOptions{})
+	if report != nil || !reflect.DeepEqual(got, tr) {
+		t.Errorf("Expected unchanged copy and no report, got %+v and %v", got, report)
+	}
+}
+
+// TestRemovedPointString tests the report text
+func TestRemovedPointString(t *testing.T) {
+	p := RemovedPoint{Index: 4, When: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC), Reason: RemovedSpeed, Speed: 1234.56}
+	if got, want := p.String(), "point 4 at 2024-05-01T12:00:00Z: impossible speed (1234.6 m/s)"; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+	p.Reason, p.Speed = RemovedTime, math.NaN()
+	if got := p.String(); !strings.HasSuffix(got, ": time out of order") {
+		t.Errorf("Expected time reason, got %q", got)
+	}
+}
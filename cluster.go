@@ -0,0 +1,129 @@
+package kml
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ClusterPoints groups the Point placemarks that lie within radiusMeters of
+// each other and returns one placemark per group, located at the centroid
+// of its members. Placemarks without a Point geometry are ignored.
+//
+// Grouping is greedy: points are visited in input order and each joins the
+// first cluster whose seed (its first member) is within the radius, or
+// starts a new cluster. Each cluster placemark records its member count in
+// an ExtendedData field named "count" and the comma-separated IDs of its
+// members (where set) in a field named "members".
+func ClusterPoints(pms []*Placemark, radiusMeters float64) []*Placemark {
+	type cluster struct {
+		seed    Coordinate
+		members []*Placemark
+		sumLon  float64
+		sumLat  float64
+	}
+
+	var clusters []*cluster
+	for _, pm := range pms {
+		pt, ok := pm.Geometry.(*Point)
+		if !ok {
+			continue
+		}
+
+		var target *cluster
+		for _, c := range clusters {
+			if distance(c.seed, pt.Coordinates) <= radiusMeters {
+				target = c
+				break
+			}
+		}
+		if target == nil {
+			target = &cluster{seed: pt.Coordinates}
+			clusters = append(clusters, target)
+		}
+
+		target.members = append(target.members, pm)
+		target.sumLon += pt.Coordinates.Lon
+		target.sumLat += pt.Coordinates.Lat
+	}
+
+	result := make([]*Placemark, len(clusters))
+	for i, c := range clusters {
+		n := len(c.members)
+
+		var ids []string
+		for _, m := range c.members {
+			if m.ID != "" {
+				ids = append(ids, m.ID)
+			}
+		}
+
+		result[i] = &Placemark{
+			ID:   fmt.Sprintf("cluster-%d", i+1),
+			Name: strconv.Itoa(n),
+			Geometry: &Point{
+				Coordinates: Coord(c.sumLon/float64(n), c.sumLat/float64(n)),
+			},
+			ExtendedData: &ExtendedData{
+				Data: []Data{
+					{Name: "count", Value: strconv.Itoa(n)},
+					{Name: "members", Value: strings.Join(ids, ",")},
+				},
+			},
+		}
+	}
+
+	return result
+}
+
+// ClusterLOD returns a Folder holding both the clusters computed by
+// ClusterPoints and the original Point placemarks, each in a sub-folder
+// whose Region switches between them by level of detail: clusters are drawn
+// while the points' bounding box covers fewer than minLodPixels on screen,
+// and the full set of points once the viewer zooms in past that.
+//
+// The placemarks are referenced, not copied.
+func ClusterLOD(pms []*Placemark, radiusMeters, minLodPixels float64) *Folder {
+	box := LatLonAltBox{
+		North: -math.MaxFloat64,
+		South: math.MaxFloat64,
+		East:  -math.MaxFloat64,
+		West:  math.MaxFloat64,
+	}
+
+	points := &Folder{Name: "Points"}
+	for _, pm := range pms {
+		pt, ok := pm.Geometry.(*Point)
+		if !ok {
+			continue
+		}
+		c := pt.Coordinates
+		box.North = math.Max(box.North, c.Lat)
+		box.South = math.Min(box.South, c.Lat)
+		box.East = math.Max(box.East, c.Lon)
+		box.West = math.Min(box.West, c.Lon)
+		points.Features = append(points.Features, pm)
+	}
+
+	clusters := &Folder{Name: "Clusters"}
+	for _, c := range ClusterPoints(pms, radiusMeters) {
+		clusters.Features = append(clusters.Features, c)
+	}
+
+	if len(points.Features) > 0 {
+		clusters.Region = &Region{
+			LatLonAltBox: box,
+			Lod:          &Lod{MinLodPixels: 0, MaxLodPixels: minLodPixels},
+		}
+		points.Region = &Region{
+			LatLonAltBox: box,
+			Lod:          &Lod{MinLodPixels: minLodPixels, MaxLodPixels: -1},
+		}
+	}
+
+	return &Folder{
+		Name:     "Clustered Points",
+		Features: []Feature{clusters, points},
+	}
+}
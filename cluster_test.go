@@ -0,0 +1,138 @@
+package kml
+
+import (
+	"strings"
+	"testing"
+)
+
+// clusterTestPoints returns two tight groups of points about 1 km apart
+// internally and far from each other, plus a non-point placemark.
+func clusterTestPoints() []*Placemark {
+	return []*Placemark{
+		{ID: "a1", Geometry: &Point{Coordinates: Coord(0, 0)}},
+		{ID: "b1", Geometry: &Point{Coordinates: Coord(10, 10)}},
+		{ID: "a2", Geometry: &Point{Coordinates: Coord(0.002, 0)}},
+		{Geometry: &Point{Coordinates: Coord(0, 0.002)}},
+		{ID: "line", Geometry: &LineString{Coordinates: []Coordinate{Coord(0, 0), Coord(1, 1)}}},
+		{ID: "b2", Geometry: &Point{Coordinates: Coord(10.001, 10)}},
+	}
+}
+
+// TestClusterPoints tests greedy radius clustering of point placemarks
+func TestClusterPoints(t *testing.T) {
+	clusters := ClusterPoints(clusterTestPoints(), 1000)
+
+	if len(clusters) != 2 {
+		t.Fatalf("Expected 2 clusters, got %d", len(clusters))
+	}
+
+	tests := []struct {
+		id       string
+		count    string
+		members  string
+		centroid Coordinate
+	}{
+		{"cluster-1", "3", "a1,a2", Coord(0.002/3, 0.002/3)},
+		{"cluster-2", "2", "b1,b2", Coord(10.0005, 10)},
+	}
+
+	for i, tt := range tests {
+		c := clusters[i]
+		if c.ID != tt.id {
+			t.Errorf("cluster %d: ID = %q, want %q", i, c.ID, tt.id)
+		}
+		if c.Name != tt.count {
+			t.Errorf("cluster %d: Name = %q, want %q", i, c.Name, tt.count)
+		}
+		data := c.ExtendedData.Data
+		if data[0].Name != "count" || data[0].Value != tt.count {
+			t.Errorf("cluster %d: count = %+v, want %s", i, data[0], tt.count)
+		}
+		if data[1].Name != "members" || data[1].Value != tt.members {
+			t.Errorf("cluster %d: members = %+v, want %s", i, data[1], tt.members)
+		}
+		if got := c.Geometry.(*Point).Coordinates; !coordEqual(got, tt.centroid) {
+			t.Errorf("cluster %d: centroid = %v, want %v", i, got, tt.centroid)
+		}
+	}
+}
+
+// TestClusterLOD tests the Region-switched cluster and point folders
+func TestClusterLOD(t *testing.T) {
+	pms := clusterTestPoints()
+	folder := ClusterLOD(pms, 1000, 256)
+
+	if len(folder.Features) != 2 {
+		t.Fatalf("Expected 2 sub-folders, got %d", len(folder.Features))
+	}
+
+	clusters := folder.Features[0].(*Folder)
+	points := folder.Features[1].(*Folder)
+
+	if len(clusters.Features) != 2 {
+		t.Errorf("Expected 2 clusters, got %d", len(clusters.Features))
+	}
+	if len(points.Features) != 5 {
+		t.Errorf("Expected 5 points, got %d", len(points.Features))
+	}
+
+	if clusters.Region == nil || clusters.Region.Lod.MaxLodPixels != 256 {
+		t.Errorf("Expected clusters to be hidden above 256 pixels, got %+v", clusters.Region)
+	}
+	if points.Region == nil || points.Region.Lod.MinLodPixels != 256 || points.Region.Lod.MaxLodPixels != -1 {
+		t.Errorf("Expected points to appear above 256 pixels, got %+v", points.Region)
+	}
+
+	box := points.Region.LatLonAltBox
+	if box.North != 10 || box.South != 0 || box.East != 10.001 || box.West != 0 {
+		t.Errorf("Unexpected region box: %+v", box)
+	}
+}
+
+// TestRegionRoundTrip tests that Regions on features survive a write/parse cycle
+func TestRegionRoundTrip(t *testing.T) {
+	region := &Region{
+		LatLonAltBox: LatLonAltBox{North: 1, South: -1, East: 2, West: -2},
+		Lod:          &Lod{MinLodPixels: 128, MaxLodPixels: -1},
+	}
+
+	k := NewKML()
+	k.Feature = &Document{
+		Region: region,
+		Features: []Feature{
+			&Folder{Region: region, Features: []Feature{
+				&Placemark{Region: region, Geometry: &Point{Coordinates: Coord(0, 0)}},
+			}},
+		},
+	}
+
+	data, err := k.Bytes()
+	if err != nil {
+		t.Fatalf("Failed to write KML: %v", err)
+	}
+	if n := strings.Count(string(data), "<Region>"); n != 3 {
+		t.Errorf("Expected 3 Region elements, got %d", n)
+	}
+
+	parsed, err := ParseBytes(data)
+	if err != nil {
+		t.Fatalf("Failed to parse KML: %v", err)
+	}
+
+	doc := parsed.Feature.(*Document)
+	folder := doc.Features[0].(*Folder)
+	pm := folder.Features[0].(*Placemark)
+
+	for name, got := range map[string]*Region{"Document": doc.Region, "Folder": folder.Region, "Placemark": pm.Region} {
+		if got == nil {
+			t.Errorf("%s: expected Region", name)
+			continue
+		}
+		if got.LatLonAltBox != region.LatLonAltBox {
+			t.Errorf("%s: box = %+v, want %+v", name, got.LatLonAltBox, region.LatLonAltBox)
+		}
+		if got.Lod == nil || *got.Lod != *region.Lod {
+			t.Errorf("%s: lod = %+v, want %+v", name, got.Lod, region.Lod)
+		}
+	}
+}
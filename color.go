@@ -82,9 +82,45 @@ func (c *Color) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 
 	parsed, err := ParseColor(s)
 	if err != nil {
-		return err
+		return recoverable(d, "invalid color", err)
 	}
 
 	*c = parsed
 	return nil
 }
+
+// MarshalText implements encoding.TextMarshaler for Color, so colors encode
+// as their hex string in JSON and other text formats.
+func (c Color) MarshalText() ([]byte, error) {
+	return []byte(c.Hex()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler for Color.
+func (c *Color) UnmarshalText(text []byte) error {
+	parsed, err := ParseColor(string(text))
+	if err != nil {
+		return err
+	}
+	*c = parsed
+	return nil
+}
+
+// lerpColor linearly interpolates between a and b, channel by channel.
+// t is clamped to the range [0, 1].
+func lerpColor(a, b Color, t float64) Color {
+	if t < 0 {
+		t = 0
+	}
+	if t > 1 {
+		t = 1
+	}
+	mix := func(x, y uint8) uint8 {
+		return uint8(float64(x) + (float64(y)-float64(x))*t + 0.5)
+	}
+	return Color{
+		A: mix(a.A, b.A),
+		B: mix(a.B, b.B),
+		G: mix(a.G, b.G),
+		R: mix(a.R, b.R),
+	}
+}
@@ -482,3 +482,26 @@ func TestColorMarshalUnmarshalRoundTrip(t *testing.T) {
 		})
 	}
 }
+
+// TestLerpColor tests channel-wise color interpolation
+func TestLerpColor(t *testing.T) {
+	a := RGBA(0, 0, 0, 0)
+	b := RGBA(200, 100, 50, 255)
+
+	tests := []struct {
+		t    float64
+		want Color
+	}{
+		{0, a},
+		{1, b},
+		{0.5, RGBA(100, 50, 25, 128)},
+		{-1, a},
+		{2, b},
+	}
+
+	for _, tt := range tests {
+		if got := lerpColor(a, b, tt.t); got != tt.want {
+			t.Errorf("lerpColor(%v) = %v, want %v", tt.t, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package kml
+
+import (
+	"fmt"
+	"math"
+)
+
+// ColorizeOptions configures ColorizeLine and ColorizeTrack.
+type ColorizeOptions struct {
+	Classes       int         // Number of colors along the ramp (default 8)
+	Width         float64     // Line width of the segments (default 4)
+	Method        BreakMethod // Classification of the values (default EqualInterval)
+	StyleIDPrefix string      // Prefix for generated style IDs (default "colorize-")
+	HashIDs       bool        // Derive style IDs from their content, as "colorize-3fa2bc", instead of numbering them
+}
+
+// defaults returns a copy of the options with zero values replaced by defaults.
+func (o ColorizeOptions) defaults() ColorizeOptions {
+	if o.Classes <= 0 {
+		o.Classes = 8
+	}
+	if o.Width <= 0 {
+		o.Width = 4
+	}
+	if o.Method == nil {
+		o.Method = EqualInterval
+	}
+	if o.StyleIDPrefix == "" {
+		o.StyleIDPrefix = "colorize-"
+	}
+	return o
+}
+
+// ColorizeLine splits a line into segments colored by a value recorded at
+// each of its points, such as speed, heart rate or elevation, and returns a
+// Folder of LineString placemarks together with the shared styles they
+// reference. The styles should be added to the Document that will hold the
+// folder.
+//
+// Each segment takes the mean of the values at its ends, and runs of
+// consecutive segments in the same class are joined into one placemark.
+// Values are matched to points by index, and a segment with no value at
+// either end, because the values are NaN or missing, is left out as a gap.
+// The placemarks keep the line's altitude mode, extrude and tessellate
+// settings.
+func ColorizeLine(ls *LineString, values []float64, ramp ColorRamp, opts ColorizeOptions) (*Folder, []Style) {
+	opts = opts.defaults()
+	coords := ls.Coordinates
+
+	segValues := make([]float64, 0, len(coords))
+	var valid []float64
+	for i := 0; i+1 < len(coords); i++ {
+		v := segmentValue(values, i)
+		segValues = append(segValues, v)
+		if !math.IsNaN(v) {
+			valid = append(valid, v)
+		}
+	}
+
+	folder := &Folder{Name: "Colorized"}
+	breaks := opts.Method(valid, opts.Classes)
+	if len(breaks) == 0 {
+		return folder, nil
+	}
+
+	styles := make([]Style, len(breaks))
+	for i := range styles {
+		t := 0.0
+		if len(breaks) > 1 {
+			t = float64(i) / float64(len(breaks)-1)
+		}
+		styles[i] = Style{
+			ID:        fmt.Sprintf("%s%d", opts.StyleIDPrefix, i),
+			LineStyle: &LineStyle{Color: ramp.At(t), Width: opts.Width},
+		}
+	}
+
+	var run *LineString
+	runClass := -1
+	for i, v := range segValues {
+		if math.IsNaN(v) {
+			run, runClass = nil, -1
+			continue
+		}
+		class := classOf(breaks, v)
+		if run == nil || class != runClass {
+			run = &LineString{
+				Extrude:      ls.Extrude,
+				Tessellate:   ls.Tessellate,
+				AltitudeMode: ls.AltitudeMode,
+				Coordinates:  []Coordinate{coords[i]},
+			}
+			runClass = class
+			folder.Features = append(folder.Features, &Placemark{
+				StyleURL: "#" + styles[class].ID,
+				Geometry: run,
+			})
+		}
+		run.Coordinates = append(run.Coordinates, coords[i+1])
+	}
+
+	if opts.HashIDs {
+		styles = hashStyleIDs(styles, opts.StyleIDPrefix, nil, folder)
+	}
+	return folder, styles
+}
+
+// ColorizeTrack is ColorizeLine for a gx:Track. Use the track's Speeds for
+// a speed-colored track.
+func ColorizeTrack(t *Track, values []float64, ramp ColorRamp, opts ColorizeOptions) (*Folder, []Style) {
+	return ColorizeLine(t.LineString(), values, ramp, opts)
+}
+
+// Elevations returns the altitude of each coordinate, for coloring a line by
+// elevation.
+func Elevations(coords []Coordinate) []float64 {
+	alts := make([]float64, len(coords))
+	for i, c := range coords {
+		alts[i] = c.Alt
+	}
+	return alts
+}
+
+// segmentValue returns the mean of the values at the ends of segment i,
+// ignoring an end without a value.
+func segmentValue(values []float64, i int) float64 {
+	at := func(j int) float64 {
+		if j < len(values) {
+			return values[j]
+		}
+		return math.NaN()
+	}
+	a, b := at(i), at(i+1)
+	switch {
+	case math.IsNaN(a):
+		return b
+	case math.IsNaN(b):
+		return a
+	}
+	return (a + b) / 2
+}
@@ -0,0 +1,110 @@
+package kml
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// TestColorizeLine tests splitting a line into colored runs
+func TestColorizeLine(t *testing.T) {
+	ls := &LineString{
+		Tessellate: true,
+		Coordinates: []Coordinate{
+			Coord(0, 0), Coord(1, 0), Coord(2, 0), Coord(3, 0), Coord(4, 0), Coord(5, 0),
+		},
+	}
+	values := []float64{0, 0, 0, 10, 10, 10}
+
+	folder, styles := ColorizeLine(ls, values, TwoColorRamp(Blue, Red), ColorizeOptions{Classes: 2})
+
+	if len(styles) != 2 {
+		t.Fatalf("Expected 2 styles, got %d", len(styles))
+	}
+	if styles[0].LineStyle.Color != Blue || styles[1].LineStyle.Color != Red || styles[0].LineStyle.Width != 4 {
+		t.Errorf("Unexpected styles %+v %+v", styles[0].LineStyle, styles[1].LineStyle)
+	}
+
+	// Segment values are 0, 0, 5, 10, 10: class 0 for the first three.
+	tests := []struct {
+		styleURL string
+		points   int
+	}{
+		{"#colorize-0", 4},
+		{"#colorize-1", 3},
+	}
+	if len(folder.Features) != len(tests) {
+		t.Fatalf("Expected %d placemarks, got %d", len(tests), len(folder.Features))
+	}
+	for i, tt := range tests {
+		pm := folder.Features[i].(*Placemark)
+		line := pm.Geometry.(*LineString)
+		if pm.StyleURL != tt.styleURL || len(line.Coordinates) != tt.points {
+			t.Errorf("Run %d: expected %s with %d points, got %s with %d", i, tt.styleURL, tt.points, pm.StyleURL, len(line.Coordinates))
+		}
+		if !line.Tessellate {
+			t.Errorf("Run %d: expected tessellate to be kept", i)
+		}
+	}
+	if folder.Features[0].(*Placemark).Geometry.(*LineString).Coordinates[3] != Coord(3, 0) {
+		t.Error("Expected consecutive runs to share their joining point")
+	}
+}
+
+// TestColorizeLineGaps tests that segments without values are left out
+func TestColorizeLineGaps(t *testing.T) {
+	ls := &LineString{Coordinates: []Coordinate{Coord(0, 0), Coord(1, 0), Coord(2, 0), Coord(3, 0), Coord(4, 0)}}
+	nan := math.NaN()
+	values := []float64{1, nan, nan, 1} // last point has no value
+
+	folder, _ := ColorizeLine(ls, values, TwoColorRamp(Blue, Red), ColorizeOptions{Classes: 1})
+
+	// Segments 0 and 2 take their one valued end; segment 1 is a gap and
+	// segment 3 takes the value of point 3.
+	if len(folder.Features) != 2 {
+		t.Fatalf("Expected 2 runs around the gap, got %d", len(folder.Features))
+	}
+	second := folder.Features[1].(*Placemark).Geometry.(*LineString)
+	if len(second.Coordinates) != 3 || second.Coordinates[0] != Coord(2, 0) {
+		t.Errorf("Unexpected run after gap: %v", second.Coordinates)
+	}
+
+	empty, styles := ColorizeLine(&LineString{Coordinates: []Coordinate{Coord(0, 0)}}, nil, Viridis, ColorizeOptions{})
+	if len(empty.Features) != 0 || styles != nil {
+		t.Error("Expected nothing for a line without segments")
+	}
+}
+
+// TestColorizeTrack tests coloring a track by speed and by elevation
+func TestColorizeTrack(t *testing.T) {
+	a := Coord(0, 0)
+	b := a.Destination(90, 100)
+	c := b.Destination(90, 1000)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	track := &Track{
+		When:   []time.Time{start, start.Add(100 * time.Second), start.Add(200 * time.Second)},
+		Coords: []Coordinate{a, b, c},
+	}
+
+	folder, _ := ColorizeTrack(track, track.Speeds(), Viridis, ColorizeOptions{Classes: 4})
+	if len(folder.Features) != 2 {
+		t.Fatalf("Expected slow and fast runs, got %d", len(folder.Features))
+	}
+	if folder.Features[0].(*Placemark).StyleURL == folder.Features[1].(*Placemark).StyleURL {
+		t.Error("Expected slow and fast runs to differ in style")
+	}
+
+	alts := Elevations([]Coordinate{Coord(0, 0, 5), Coord(1, 1, 7)})
+	if len(alts) != 2 || alts[0] != 5 || alts[1] != 7 {
+		t.Errorf("Unexpected elevations %v", alts)
+	}
+}
+
+// TestColorizeLineJenksConstant tests Jenks classes for a line of constant values
+func TestColorizeLineJenksConstant(t *testing.T) {
+	ls := &LineString{Coordinates: []Coordinate{Coord(0, 0), Coord(1, 0), Coord(2, 0), Coord(3, 0)}}
+	folder, styles := ColorizeLine(ls, []float64{5, 5, 5, 5}, TwoColorRamp(Blue, Red), ColorizeOptions{Classes: 3, Method: Jenks})
+	if len(styles) != 1 || len(folder.Features) != 1 {
+		t.Errorf("Expected one class and one run, got %d styles and %d runs", len(styles), len(folder.Features))
+	}
+}
@@ -0,0 +1,86 @@
+package kml
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// commentState holds the comments read since the last element of the
+// container being decoded.
+type commentState struct {
+	pending []string
+}
+
+// PreserveComments makes Parse keep the XML comments that directly precede
+// each feature, storing them in the feature's Comments field so annotated,
+// hand-authored files survive being processed. A comment attaches to the
+// next sibling feature; comments followed by any other element, such as a
+// Style, or by the end of the container are dropped, as are comments inside
+// a feature's own fields and before the kml element. Write the comments
+// back out with the WriteComments option.
+func PreserveComments() ParseOption {
+	return func(c *parseConfig) {
+		c.comments = &commentState{}
+	}
+}
+
+// WriteComments makes Write emit each feature's Comments immediately before
+// its start tag. Without it comments are left out, as they are when hashing
+// and diffing features. Writing fails if a comment contains "--" or ends in
+// "-", which XML does not allow.
+func WriteComments() WriteOption {
+	return func(c *writeConfig) {
+		c.comments = true
+	}
+}
+
+// keepComment records a comment read in a container of d if comments are
+// being preserved.
+func keepComment(d *xml.Decoder, c xml.Comment) {
+	if cfg := parseConfigFor(d); cfg != nil && cfg.comments != nil {
+		cfg.comments.pending = append(cfg.comments.pending, string(c))
+	}
+}
+
+// dropComments discards the pending comments of d unless start begins a
+// feature, which takes them.
+func dropComments(d *xml.Decoder, start xml.StartElement) {
+	if newFeature(start.Name.Local) != nil {
+		return
+	}
+	if cfg := parseConfigFor(d); cfg != nil && cfg.comments != nil {
+		cfg.comments.pending = nil
+	}
+}
+
+// takeComments returns and clears the pending comments of d.
+func takeComments(d *xml.Decoder) []string {
+	cfg := parseConfigFor(d)
+	if cfg == nil || cfg.comments == nil {
+		return nil
+	}
+	comments := cfg.comments.pending
+	cfg.comments.pending = nil
+	return comments
+}
+
+// encodeComments writes comments to e if it was configured with
+// WriteComments.
+func encodeComments(e *xml.Encoder, comments []string) error {
+	if len(comments) == 0 {
+		return nil
+	}
+	if cfg := writeConfigFor(e); cfg == nil || !cfg.comments {
+		return nil
+	}
+	for _, c := range comments {
+		if strings.Contains(c, "--") || strings.HasSuffix(c, "-") {
+			return fmt.Errorf("kml: comment %q cannot be written as XML", c)
+		}
+		if err := e.EncodeToken(xml.Comment(c)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
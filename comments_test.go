@@ -0,0 +1,134 @@
+package kml
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const commentedKML = `<?xml version="1.0" encoding="UTF-8"?>
+<!-- file header -->
+<kml xmlns="http://www.opengis.net/kml/2.2">
+  <!-- the document -->
+  <Document>
+    <name>Survey</name>
+    <!-- about the style -->
+    <Style id="s"><LineStyle><width>2</width></LineStyle></Style>
+    <!-- surveyed 2024-03-01 -->
+    <!-- owner: field team -->
+    <Placemark>
+      <!-- inside the placemark -->
+      <name>A</name>
+      <Point><coordinates>1,2</coordinates></Point>
+    </Placemark>
+    <Folder>
+      <!-- overlay source: scan.png -->
+      <GroundOverlay><name>Scan</name></GroundOverlay>
+      <!-- trailing -->
+    </Folder>
+  </Document>
+</kml>`
+
+// TestPreserveComments tests attaching comments to the features they precede
+func TestPreserveComments(t *testing.T) {
+	k, err := ParseBytes([]byte(commentedKML), PreserveComments())
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	doc := k.Feature.(*Document)
+	if want := []string{" the document "}; !reflect.DeepEqual(doc.Comments, want) {
+		t.Errorf("Expected Document comments %q, got %q", want, doc.Comments)
+	}
+	pm := doc.Features[0].(*Placemark)
+	if want := []string{" surveyed 2024-03-01 ", " owner: field team "}; !reflect.DeepEqual(pm.Comments, want) {
+		t.Errorf("Expected Placemark comments %q, got %q", want, pm.Comments)
+	}
+	folder := doc.Features[1].(*Folder)
+	if folder.Comments != nil {
+		t.Errorf("Expected no Folder comments, got %q", folder.Comments)
+	}
+	overlay := folder.Features[0].(*GroundOverlay)
+	if want := []string{" overlay source: scan.png "}; !reflect.DeepEqual(overlay.Comments, want) {
+		t.Errorf("Expected GroundOverlay comments %q, got %q", want, overlay.Comments)
+	}
+
+	plain, err := ParseBytes([]byte(commentedKML))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if c := plain.Placemarks()[0].Comments; c != nil {
+		t.Errorf("Expected no comments without PreserveComments, got %q", c)
+	}
+}
+
+// TestWriteComments tests writing preserved comments back out
+func TestWriteComments(t *testing.T) {
+	k, err := ParseBytes([]byte(commentedKML), PreserveComments())
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	out, err := k.Bytes(WriteComments())
+	if err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	s := string(out)
+	if !strings.Contains(s, "<!-- surveyed 2024-03-01 --><!-- owner: field team --><Placemark>") {
+		t.Errorf("Expected comments before the Placemark, got %s", s)
+	}
+	for _, dropped := range []string{"file header", "about the style", "inside the placemark", "trailing"} {
+		if strings.Contains(s, dropped) {
+			t.Errorf("Expected comment %q to be dropped, got %s", dropped, s)
+		}
+	}
+
+	again, err := ParseBytes(out, PreserveComments())
+	if err != nil {
+		t.Fatalf("Re-parse failed: %v", err)
+	}
+	if !reflect.DeepEqual(again.Placemarks()[0].Comments, k.Placemarks()[0].Comments) {
+		t.Errorf("Expected comments to round-trip, got %q", again.Placemarks()[0].Comments)
+	}
+
+	plain, err := k.Bytes()
+	if err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if strings.Contains(string(plain), "<!--") {
+		t.Errorf("Expected no comments without WriteComments, got %s", plain)
+	}
+}
+
+// TestWriteCommentsInvalid tests rejecting comments XML cannot represent
+func TestWriteCommentsInvalid(t *testing.T) {
+	k := NewKML()
+	k.Feature = &Placemark{Name: "A", Comments: []string{"a -- b"}}
+	if _, err := k.Bytes(WriteComments()); err == nil {
+		t.Error("Expected an error for a comment containing --")
+	}
+}
+
+// TestCommentsHash tests that comments do not affect feature hashes
+func TestCommentsHash(t *testing.T) {
+	a := &Placemark{Name: "A"}
+	b := &Placemark{Name: "A", Comments: []string{" note "}}
+	if a.Hash() != b.Hash() {
+		t.Error("Expected comments to be excluded from the hash")
+	}
+}
+
+// TestPreserveCommentsFragments tests comments before fragment features
+func TestPreserveCommentsFragments(t *testing.T) {
+	data := `<!-- sample --><Placemark><name>A</name></Placemark><!-- style --><Style/><Placemark/>`
+	objs, err := ParseFragments([]byte(data), PreserveComments())
+	if err != nil {
+		t.Fatalf("ParseFragments failed: %v", err)
+	}
+	if want := []string{" sample "}; !reflect.DeepEqual(objs[0].(*Placemark).Comments, want) {
+		t.Errorf("Expected comments %q, got %q", want, objs[0].(*Placemark).Comments)
+	}
+	if c := objs[2].(*Placemark).Comments; c != nil {
+		t.Errorf("Expected the Style comment to be dropped, got %q", c)
+	}
+}
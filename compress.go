@@ -0,0 +1,98 @@
+package kml
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// Magic numbers for the compressed containers Parse recognizes.
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zipMagic  = []byte("PK\x03\x04")
+)
+
+// decompress sniffs the first bytes of r and, if it is gzip or zip (KMZ)
+// data, returns a reader for the KML document inside. Other input is
+// returned unchanged, apart from buffering. The caller closes the reader
+// once done with it; this does not close r.
+func decompress(r io.Reader) (io.ReadCloser, error) {
+	rc, _, err := decompressArchive(r)
+	return rc, err
+}
+
+// kmzArchive is a KMZ or zip archive read by Parse, kept so that the
+// files packaged with the document, such as overlay images, can be loaded.
+type kmzArchive struct {
+	zr  *zip.Reader
+	doc string // Name of the document within the archive
+}
+
+// decompressArchive is decompress, also returning the archive the document
+// came from, or nil if the input was not one.
+func decompressArchive(r io.Reader) (io.ReadCloser, *kmzArchive, error) {
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(len(zipMagic))
+
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, nil, fmt.Errorf("kml: error reading gzip data: %w", err)
+		}
+		return zr, nil, nil
+
+	case bytes.HasPrefix(magic, zipMagic):
+		// zip needs random access, so the archive is read into memory.
+		data, err := io.ReadAll(br)
+		if err != nil {
+			return nil, nil, fmt.Errorf("kml: error reading zip data: %w", err)
+		}
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, nil, fmt.Errorf("kml: error reading zip data: %w", err)
+		}
+		f := archiveKMLEntry(zr)
+		if f == nil {
+			return nil, nil, ErrNoKMLInArchive
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, nil, fmt.Errorf("kml: error opening %s in archive: %w", f.Name, err)
+		}
+		return rc, &kmzArchive{zr: zr, doc: f.Name}, nil
+	}
+
+	return io.NopCloser(br), nil, nil
+}
+
+// archiveKMLEntry picks the document of a KMZ or zip archive: doc.kml if
+// present, otherwise the first .kml file at the root, otherwise the first
+// .kml file anywhere.
+func archiveKMLEntry(zr *zip.Reader) *zip.File {
+	var root, nested *zip.File
+	for _, f := range zr.File {
+		if !strings.EqualFold(path.Ext(f.Name), ".kml") {
+			continue
+		}
+		if strings.EqualFold(f.Name, "doc.kml") {
+			return f
+		}
+		if !strings.Contains(f.Name, "/") {
+			if root == nil {
+				root = f
+			}
+		} else if nested == nil {
+			nested = f
+		}
+	}
+	if root != nil {
+		return root
+	}
+	return nested
+}
@@ -0,0 +1,109 @@
+package kml
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func placemarkKML(name string) []byte {
+	return []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<kml xmlns="http://www.opengis.net/kml/2.2"><Placemark><name>` + name + `</name></Placemark></kml>`)
+}
+
+func gzipBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		t.Fatalf("gzip write failed: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("gzip close failed: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// zipBytes builds a zip archive from name/content pairs, in order.
+func zipBytes(t *testing.T, files ...string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for i := 0; i < len(files); i += 2 {
+		w, err := zw.Create(files[i])
+		if err != nil {
+			t.Fatalf("zip create failed: %v", err)
+		}
+		if _, err := w.Write([]byte(files[i+1])); err != nil {
+			t.Fatalf("zip write failed: %v", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("zip close failed: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestParseCompressed(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"plain", placemarkKML("plain"), "plain"},
+		{"gzip", gzipBytes(t, placemarkKML("gz")), "gz"},
+		{"kmz doc.kml", zipBytes(t,
+			"files/icon.png", "png",
+			"other.kml", string(placemarkKML("other")),
+			"doc.kml", string(placemarkKML("doc"))), "doc"},
+		{"zip first root kml", zipBytes(t,
+			"nested/a.kml", string(placemarkKML("nested")),
+			"Main.KML", string(placemarkKML("main"))), "main"},
+		{"zip nested only", zipBytes(t,
+			"data/readme.txt", "hi",
+			"data/b.kml", string(placemarkKML("b"))), "b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k, err := ParseBytes(tt.data)
+			if err != nil {
+				t.Fatalf("ParseBytes failed: %v", err)
+			}
+			if name := k.Feature.(*Placemark).Name; name != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, name)
+			}
+		})
+	}
+}
+
+func TestParseCompressedErrors(t *testing.T) {
+	if _, err := ParseBytes(zipBytes(t, "readme.txt", "no kml here")); !errors.Is(err, ErrNoKMLInArchive) {
+		t.Errorf("Expected ErrNoKMLInArchive, got %v", err)
+	}
+	if _, err := ParseBytes([]byte{0x1f, 0x8b, 0, 0}); err == nil {
+		t.Error("Expected error for truncated gzip data")
+	}
+	if _, err := ParseBytes([]byte("PK\x03\x04garbage")); err == nil {
+		t.Error("Expected error for corrupt zip data")
+	}
+}
+
+func TestParseFileKMZ(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.kmz")
+	if err := os.WriteFile(path, zipBytes(t, "doc.kml", string(placemarkKML("file"))), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	k, err := ParseFile(path)
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+	if name := k.Feature.(*Placemark).Name; name != "file" {
+		t.Errorf("Expected 'file', got %q", name)
+	}
+}
@@ -0,0 +1,208 @@
+package kml
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Contacts are the phone numbers, email addresses and web links found in a
+// description, each listed once in the order they first appear.
+type Contacts struct {
+	Phones []string
+	Emails []string
+	URLs   []string
+}
+
+var (
+	// contactHref matches the target of a link in description markup.
+	contactHref = regexp.MustCompile(`(?i)\bhref\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s>]+))`)
+
+	// contactURL matches a web address in text.
+	contactURL = regexp.MustCompile(`(?i)\b(?:https?://|www\.)[^\s<>"']+`)
+
+	// contactEmail matches an email address in text.
+	contactEmail = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`)
+
+	// contactPhone matches a run of digits and the separators phone numbers
+	// are written with, such as "+1 (555) 010-4477".
+	contactPhone = regexp.MustCompile(`\+?\(?\d[\d ().-]*\d`)
+
+	// contactDate matches numbers written like dates, alone or followed by
+	// a time, which are not phone numbers however many digits they have.
+	contactDate = regexp.MustCompile(`^(?:\d{4}[-/.]\d{1,2}[-/.]\d{1,2}|\d{1,2}[-/.]\d{1,2}[-/.]\d{2,4})(?:[ T]|$)`)
+
+	// contactDecimals matches signed decimal numbers divided by spaces, such
+	// as a coordinate pair.
+	contactDecimals = regexp.MustCompile(`^[+-]?\d+\.\d+(?: +[+-]?\d+\.\d+)+$`)
+
+	// contactDottedQuad matches four dotted groups of up to three digits,
+	// as in an IP address.
+	contactDottedQuad = regexp.MustCompile(`^\d{1,3}(?:\.\d{1,3}){3}$`)
+)
+
+// FindContacts returns the phone numbers, email addresses and web links in
+// a description, which may be HTML. Links are taken from the href
+// attributes of the markup, where mailto: and tel: links give emails and
+// phone numbers, and from addresses starting with http://, https:// or
+// www. in the text. Phone numbers are runs of 7 to 15 digits divided by
+// dashes, dots, parentheses or an international "+", or into three groups
+// or more by spaces, that do not look like dates, times, decimal numbers,
+// ranges, ISBNs or IP addresses.
+// Values are kept as written, less trailing punctuation.
+func FindContacts(description string) Contacts {
+	var c Contacts
+	add := func(list *[]string, value string) {
+		for _, v := range *list {
+			if strings.EqualFold(v, value) {
+				return
+			}
+		}
+		*list = append(*list, value)
+	}
+
+	for _, m := range contactHref.FindAllStringSubmatch(description, -1) {
+		href := strings.TrimSpace(html.UnescapeString(m[1] + m[2] + m[3]))
+		scheme, rest, _ := strings.Cut(href, ":")
+		switch strings.ToLower(scheme) {
+		case "mailto":
+			address, _, _ := strings.Cut(rest, "?")
+			if address, err := url.PathUnescape(address); err == nil && contactEmail.MatchString(address) {
+				add(&c.Emails, address)
+			}
+		case "tel":
+			if isPhoneNumber(rest) {
+				add(&c.Phones, rest)
+			}
+		case "http", "https":
+			add(&c.URLs, href)
+		}
+	}
+
+	text := html.UnescapeString(descriptionTag.ReplaceAllString(description, " "))
+	text = contactURL.ReplaceAllStringFunc(text, func(u string) string {
+		add(&c.URLs, strings.TrimRight(u, ".,;:!?)"))
+		return " "
+	})
+	text = contactEmail.ReplaceAllStringFunc(text, func(e string) string {
+		add(&c.Emails, e)
+		return " "
+	})
+	for _, p := range contactPhone.FindAllString(text, -1) {
+		p = strings.TrimRight(p, ".-( ")
+		if strings.Count(p, "(") != strings.Count(p, ")") {
+			p = strings.Trim(p, "()")
+		}
+		if isPhoneNumber(p) {
+			add(&c.Phones, p)
+		}
+	}
+	return c
+}
+
+// isPhoneNumber reports whether s, a run of digits and separators, is
+// written like a phone number.
+func isPhoneNumber(s string) bool {
+	digits := 0
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			digits++
+		}
+	}
+	if digits < 7 || digits > 15 || contactDate.MatchString(s) || contactDecimals.MatchString(s) || contactDottedQuad.MatchString(s) {
+		return false
+	}
+	// Digits divided only by dots and dashes must be in three groups or
+	// more, of which only the first may be a single digit, as in
+	// "1-800-555-0199": "1990-1995" is a range, "37.4220105" a decimal and
+	// "978-3-16-148410-0" an ISBN.
+	if !strings.HasPrefix(s, "+") && !strings.ContainsAny(s, " ()") && strings.ContainsAny(s, ".-") {
+		groups := strings.FieldsFunc(s, func(r rune) bool { return r == '.' || r == '-' })
+		if len(groups) < 3 {
+			return false
+		}
+		for _, g := range groups[1:] {
+			if len(g) < 2 {
+				return false
+			}
+		}
+	}
+	// Digits divided only by spaces must be in three groups or more, as in
+	// "020 7946 0958", since "1200 2019" is as likely two numbers.
+	if !strings.HasPrefix(s, "+") && !strings.ContainsAny(s, "().-") {
+		return len(strings.Fields(s)) >= 3
+	}
+	return true
+}
+
+// ContactOptions configures ExtractContacts.
+type ContactOptions struct {
+	// PhoneField, EmailField and URLField name the ExtendedData fields the
+	// values are stored in. The defaults are "phone", "email" and "url".
+	// Further values of a kind go in fields numbered from 2, as "phone2".
+	PhoneField string
+	EmailField string
+	URLField   string
+}
+
+// defaults returns o with zero fields set to their defaults.
+func (o ContactOptions) defaults() ContactOptions {
+	if o.PhoneField == "" {
+		o.PhoneField = "phone"
+	}
+	if o.EmailField == "" {
+		o.EmailField = "email"
+	}
+	if o.URLField == "" {
+		o.URLField = "url"
+	}
+	return o
+}
+
+// ExtractContacts finds the phone numbers, email addresses and web links in
+// the description of every placemark, as FindContacts does, and stores them
+// in ExtendedData fields, so systems reading the data need not parse
+// hand-written balloon HTML. Descriptions are left as they are. A kind of
+// value a placemark already has a field for, by the name its first value
+// would get, is not extracted again. It returns the number of placemarks
+// given fields.
+func (k *KML) ExtractContacts(opts ContactOptions) int {
+	opts = opts.defaults()
+	extracted := 0
+	for _, pm := range k.Placemarks() {
+		if pm.Description == "" {
+			continue
+		}
+		c := FindContacts(pm.Description)
+		added := false
+		for _, kind := range []struct {
+			field  string
+			values []string
+		}{
+			{opts.PhoneField, c.Phones},
+			{opts.EmailField, c.Emails},
+			{opts.URLField, c.URLs},
+		} {
+			if len(kind.values) == 0 {
+				continue
+			}
+			if _, ok := pm.dataValue(kind.field); ok {
+				continue
+			}
+			for i, v := range kind.values {
+				name := kind.field
+				if i > 0 {
+					name += strconv.Itoa(i + 1)
+				}
+				setData(pm, name, v)
+			}
+			added = true
+		}
+		if added {
+			extracted++
+		}
+	}
+	return extracted
+}
@@ -0,0 +1,91 @@
+package kml
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestFindContacts tests finding contact details in descriptions
+func TestFindContacts(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		want        Contacts
+	}{
+		{
+			name: "balloon table",
+			description: `<table><tr><td>Phone</td><td>+1 (555) 010-4477</td></tr>
+<tr><td>Email</td><td><a href="mailto:Info@Example.org?subject=Hi">Info@Example.org</a></td></tr>
+<tr><td>Web</td><td><a href='https://example.org/visit?a=1&amp;b=2'>site</a></td></tr></table>`,
+			want: Contacts{
+				Phones: []string{"+1 (555) 010-4477"},
+				Emails: []string{"Info@Example.org"},
+				URLs:   []string{"https://example.org/visit?a=1&b=2"},
+			},
+		},
+		{
+			name:        "plain text",
+			description: "Call 020 7946 0958 or tel. 555.010.3321, see www.example.com/hours. Write to bookings@example.co.uk.",
+			want: Contacts{
+				Phones: []string{"020 7946 0958", "555.010.3321"},
+				Emails: []string{"bookings@example.co.uk"},
+				URLs:   []string{"www.example.com/hours"},
+			},
+		},
+		{
+			name:        "tel link",
+			description: `<a href="tel:+44-20-7946-0958">Ring us</a> (open 9-5)`,
+			want:        Contacts{Phones: []string{"+44-20-7946-0958"}},
+		},
+		{
+			name: "not phone numbers",
+			description: "Surveyed 2021-06-14 at 37.4220105, depth 1200 2019 m, ID 48213377, phone 555-01. " +
+				"Logged 2023-05-12 14:30 at 37.42204 -122.08412, open 1990-1995, 100-2000 visitors, " +
+				"host 192.168.100.200, ISBN 978-3-16-148410-0, version 1.2.3.4567",
+			want: Contacts{},
+		},
+		{
+			name:        "URL digits",
+			description: "http://example.com/items/5550104477 (archived)",
+			want:        Contacts{URLs: []string{"http://example.com/items/5550104477"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FindContacts(tt.description); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestExtractContacts tests storing contact details in ExtendedData
+func TestExtractContacts(t *testing.T) {
+	k := NewKML()
+	k.Feature = &Document{Features: []Feature{
+		&Placemark{Name: "a", Description: "Tel 555-010-1111 / 555-010-2222, info@a.example"},
+		&Placemark{Name: "b", Description: "Mail b@b.example", ExtendedData: &ExtendedData{Data: []Data{{Name: "email", Value: "kept@b.example"}}}},
+		&Placemark{Name: "c", Description: "Nothing to see"},
+		&Placemark{Name: "d"},
+	}}
+
+	if n := k.ExtractContacts(ContactOptions{EmailField: "mail"}); n != 2 {
+		t.Errorf("Expected 2 placemarks given fields, got %d", n)
+	}
+	pms := k.Placemarks()
+	want := []Data{{Name: "phone", Value: "555-010-1111"}, {Name: "phone2", Value: "555-010-2222"}, {Name: "mail", Value: "info@a.example"}}
+	if got := pms[0].ExtendedData.Data; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+	if v, _ := pms[1].dataValue("mail"); v != "b@b.example" {
+		t.Errorf("Expected mail field, got %q", v)
+	}
+	if pms[2].ExtendedData != nil || pms[3].ExtendedData != nil {
+		t.Errorf("Expected placemarks without contacts unchanged")
+	}
+
+	if n := k.ExtractContacts(ContactOptions{EmailField: "mail"}); n != 0 {
+		t.Errorf("Expected a second pass to add nothing, got %d", n)
+	}
+}
@@ -0,0 +1,79 @@
+package kml
+
+import "sort"
+
+// LineStringFromRing returns a LineString tracing the same path as the
+// LinearRing, including its closing coordinate. The ring is not modified.
+func LineStringFromRing(lr *LinearRing) *LineString {
+	return &LineString{
+		ID:           lr.ID,
+		Extrude:      lr.Extrude,
+		Tessellate:   lr.Tessellate,
+		AltitudeMode: lr.AltitudeMode,
+		Coordinates:  append([]Coordinate(nil), lr.Coordinates...),
+	}
+}
+
+// ToLineStrings returns the boundaries of the polygon as LineStrings: the
+// outer boundary first, followed by each inner boundary in order.
+// The polygon's altitude settings are carried over to every line.
+func (p *Polygon) ToLineStrings() []*LineString {
+	lines := make([]*LineString, 0, 1+len(p.InnerBoundaries))
+
+	rings := append([]LinearRing{p.OuterBoundary}, p.InnerBoundaries...)
+	for i := range rings {
+		ls := LineStringFromRing(&rings[i])
+		ls.Extrude = p.Extrude
+		ls.Tessellate = p.Tessellate
+		ls.AltitudeMode = p.AltitudeMode
+		lines = append(lines, ls)
+	}
+
+	return lines
+}
+
+// Flatten returns the non-collection geometries contained in the
+// MultiGeometry, recursively expanding nested MultiGeometries.
+// Nil geometries are dropped.
+func (mg *MultiGeometry) Flatten() []Geometry {
+	var result []Geometry
+	for _, geom := range mg.Geometries {
+		switch g := geom.(type) {
+		case nil:
+		case *MultiGeometry:
+			result = append(result, g.Flatten()...)
+		default:
+			result = append(result, g)
+		}
+	}
+	return result
+}
+
+// PointsToLineString joins the Point geometries of the placemarks into a
+// single LineString. Placemarks without a Point geometry are skipped.
+//
+// If sortBy is non-nil, the placemarks are first ordered with it as a
+// "less" function (stable, so equal placemarks keep their input order);
+// otherwise the input order is used. This turns, for example, a folder of
+// timestamped points into a track line.
+func PointsToLineString(pms []*Placemark, sortBy func(a, b *Placemark) bool) *LineString {
+	points := make([]*Placemark, 0, len(pms))
+	for _, pm := range pms {
+		if _, ok := pm.Geometry.(*Point); ok {
+			points = append(points, pm)
+		}
+	}
+
+	if sortBy != nil {
+		sort.SliceStable(points, func(i, j int) bool {
+			return sortBy(points[i], points[j])
+		})
+	}
+
+	coords := make([]Coordinate, len(points))
+	for i, pm := range points {
+		coords[i] = pm.Geometry.(*Point).Coordinates
+	}
+
+	return &LineString{Coordinates: coords}
+}
@@ -0,0 +1,123 @@
+package kml
+
+import "testing"
+
+// TestLineStringFromRing tests converting a LinearRing to a LineString
+func TestLineStringFromRing(t *testing.T) {
+	ring := &LinearRing{
+		ID:           "r",
+		Tessellate:   true,
+		AltitudeMode: AltitudeModeAbsolute,
+		Coordinates:  []Coordinate{Coord(0, 0), Coord(1, 0), Coord(1, 1), Coord(0, 0)},
+	}
+
+	ls := LineStringFromRing(ring)
+
+	if ls.ID != "r" || !ls.Tessellate || ls.AltitudeMode != AltitudeModeAbsolute {
+		t.Errorf("Expected ring attributes to be copied, got %+v", ls)
+	}
+	if !coordSliceEqual(ls.Coordinates, ring.Coordinates) {
+		t.Errorf("Expected coordinates %v, got %v", ring.Coordinates, ls.Coordinates)
+	}
+
+	ls.Coordinates[0] = Coord(9, 9)
+	if ring.Coordinates[0] != Coord(0, 0) {
+		t.Error("Expected ring coordinates to be independent of the LineString")
+	}
+}
+
+// TestPolygonToLineStrings tests converting polygon boundaries to LineStrings
+func TestPolygonToLineStrings(t *testing.T) {
+	outer := []Coordinate{Coord(0, 0), Coord(10, 0), Coord(10, 10), Coord(0, 0)}
+	inner := []Coordinate{Coord(2, 2), Coord(3, 2), Coord(3, 3), Coord(2, 2)}
+	poly := &Polygon{
+		Extrude:         true,
+		OuterBoundary:   LinearRing{Coordinates: outer},
+		InnerBoundaries: []LinearRing{{Coordinates: inner}},
+	}
+
+	lines := poly.ToLineStrings()
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %d", len(lines))
+	}
+	if !coordSliceEqual(lines[0].Coordinates, outer) {
+		t.Errorf("Expected outer boundary first, got %v", lines[0].Coordinates)
+	}
+	if !coordSliceEqual(lines[1].Coordinates, inner) {
+		t.Errorf("Expected inner boundary second, got %v", lines[1].Coordinates)
+	}
+	for i, ls := range lines {
+		if !ls.Extrude {
+			t.Errorf("lines[%d]: expected Extrude to be carried over", i)
+		}
+	}
+}
+
+// TestMultiGeometryFlatten tests recursive flattening of nested MultiGeometries
+func TestMultiGeometryFlatten(t *testing.T) {
+	mg := &MultiGeometry{
+		Geometries: []Geometry{
+			&Point{Coordinates: Coord(0, 0)},
+			&MultiGeometry{
+				Geometries: []Geometry{
+					&LineString{Coordinates: []Coordinate{Coord(0, 0), Coord(1, 1)}},
+					&MultiGeometry{Geometries: []Geometry{&Point{Coordinates: Coord(2, 2)}}},
+				},
+			},
+			nil,
+		},
+	}
+
+	flat := mg.Flatten()
+
+	want := []string{"Point", "LineString", "Point"}
+	if len(flat) != len(want) {
+		t.Fatalf("Expected %d geometries, got %d", len(want), len(flat))
+	}
+	for i, typ := range want {
+		if flat[i].geometryType() != typ {
+			t.Errorf("flat[%d] is %s, want %s", i, flat[i].geometryType(), typ)
+		}
+	}
+}
+
+// TestPointsToLineString tests joining point placemarks into a LineString
+func TestPointsToLineString(t *testing.T) {
+	pms := []*Placemark{
+		{Name: "3", Geometry: &Point{Coordinates: Coord(3, 3)}},
+		{Name: "1", Geometry: &Point{Coordinates: Coord(1, 1)}},
+		{Name: "line", Geometry: &LineString{Coordinates: []Coordinate{Coord(9, 9), Coord(8, 8)}}},
+		{Name: "2", Geometry: &Point{Coordinates: Coord(2, 2)}},
+		{Name: "empty"},
+	}
+
+	tests := []struct {
+		name   string
+		sortBy func(a, b *Placemark) bool
+		want   []Coordinate
+	}{
+		{
+			name:   "input order",
+			sortBy: nil,
+			want:   []Coordinate{Coord(3, 3), Coord(1, 1), Coord(2, 2)},
+		},
+		{
+			name:   "sorted by name",
+			sortBy: func(a, b *Placemark) bool { return a.Name < b.Name },
+			want:   []Coordinate{Coord(1, 1), Coord(2, 2), Coord(3, 3)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ls := PointsToLineString(pms, tt.sortBy)
+			if !coordSliceEqual(ls.Coordinates, tt.want) {
+				t.Errorf("PointsToLineString() = %v, want %v", ls.Coordinates, tt.want)
+			}
+		})
+	}
+
+	if pms[0].Name != "3" {
+		t.Error("Expected input slice order to be preserved")
+	}
+}
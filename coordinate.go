@@ -113,12 +113,13 @@ func (c Coordinates) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
 		return e.EncodeElement("", start)
 	}
 
+	cfg := writeConfigFor(e)
 	var sb strings.Builder
 	for i, coord := range c {
 		if i > 0 {
 			sb.WriteByte(' ')
 		}
-		sb.WriteString(coord.String())
+		sb.WriteString(cfg.formatTuple(coord))
 	}
 
 	return e.EncodeElement(sb.String(), start)
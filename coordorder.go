@@ -0,0 +1,143 @@
+package kml
+
+import (
+	"fmt"
+	"math"
+)
+
+// CoordinateOrderPolicy decides what is done with a document whose
+// coordinates appear to be written latitude first, the most common mistake
+// in data converted from spreadsheets and GeoJSON. KML, like GeoJSON,
+// writes longitude first. A document is taken to be swapped when some of
+// its latitudes are beyond ±90 degrees, which no latitude can be, while
+// every longitude is within ±90 and every latitude within ±180, as the
+// other order would have them. Data near the prime meridian and the
+// equator cannot be told apart this way and is left alone.
+type CoordinateOrderPolicy int
+
+const (
+	// OrderAllow keeps coordinates as parsed.
+	OrderAllow CoordinateOrderPolicy = iota
+	// OrderWarn keeps coordinates as parsed and reports a swapped
+	// document to the Logger given with WithLogger, if any.
+	OrderWarn
+	// OrderSwap exchanges the longitude and latitude of every coordinate
+	// of a swapped document.
+	OrderSwap
+	// OrderError fails with a *ValidationError on a swapped document.
+	OrderError
+)
+
+// String returns the name of the policy.
+func (p CoordinateOrderPolicy) String() string {
+	switch p {
+	case OrderAllow:
+		return "allow"
+	case OrderWarn:
+		return "warn"
+	case OrderSwap:
+		return "swap"
+	case OrderError:
+		return "error"
+	}
+	return "unknown"
+}
+
+// CoordinateOrder applies policy to the coordinates of placemark geometries
+// once the document has been parsed. Unless swapped is nil, it is set to
+// whether the coordinates appeared to be latitude first, whatever the
+// policy. Without this option coordinates are kept as parsed.
+func CoordinateOrder(policy CoordinateOrderPolicy, swapped *bool) ParseOption {
+	return func(c *parseConfig) {
+		c.order = &coordinateOrderConfig{policy: policy, swapped: swapped}
+	}
+}
+
+// coordinateOrderConfig holds the settings of CoordinateOrder.
+type coordinateOrderConfig struct {
+	policy  CoordinateOrderPolicy
+	swapped *bool
+}
+
+// CheckCoordinateOrder applies policy to a document built other than by
+// Parse, such as one converted from rows of a spreadsheet, and reports
+// whether its coordinates appear to be latitude first. OrderWarn only
+// reports it. With OrderError the error is a *ValidationError.
+func (k *KML) CheckCoordinateOrder(policy CoordinateOrderPolicy) (bool, error) {
+	swapped, lat := latitudeFirst(k)
+	if !swapped {
+		return false, nil
+	}
+	switch policy {
+	case OrderSwap:
+		k.ResetBounds()
+		k.Walk(func(f Feature) error {
+			if pm, ok := f.(*Placemark); ok && pm.Geometry != nil {
+				swapGeometry(pm.Geometry)
+			}
+			return nil
+		})
+	case OrderError:
+		return true, &ValidationError{
+			Element: "kml",
+			Field:   "coordinates",
+			Message: fmt.Sprintf("coordinates appear to be latitude,longitude: a latitude of %g is out of range", lat),
+		}
+	}
+	return true, nil
+}
+
+// applyCoordinateOrder resolves the coordinate order of k according to cfg.
+func applyCoordinateOrder(k *KML, cfg *coordinateOrderConfig, logger Logger) error {
+	if cfg.policy == OrderAllow && cfg.swapped == nil {
+		return nil
+	}
+	swapped, err := k.CheckCoordinateOrder(cfg.policy)
+	if cfg.swapped != nil {
+		*cfg.swapped = swapped
+	}
+	if swapped && cfg.policy == OrderWarn && logger != nil {
+		logger.Warn("kml: coordinates appear to be latitude,longitude")
+	}
+	return err
+}
+
+// latitudeFirst reports whether the placemark coordinates of k appear to be
+// latitude first, with the largest latitude seen, by absolute value.
+func latitudeFirst(k *KML) (bool, float64) {
+	maxLon, maxLat, lat := 0.0, 0.0, 0.0
+	k.Walk(func(f Feature) error {
+		if pm, ok := f.(*Placemark); ok && pm.Geometry != nil {
+			for _, c := range getGeometryCoordinates(pm.Geometry) {
+				maxLon = math.Max(maxLon, math.Abs(c.Lon))
+				if math.Abs(c.Lat) > maxLat {
+					maxLat, lat = math.Abs(c.Lat), c.Lat
+				}
+			}
+		}
+		return nil
+	})
+	return maxLat > 90 && maxLat <= 180 && maxLon <= 90, lat
+}
+
+// swapGeometry exchanges the longitude and latitude of the coordinates of g.
+func swapGeometry(g Geometry) {
+	swap := func(c *Coordinate) {
+		c.Lon, c.Lat = c.Lat, c.Lon
+	}
+
+	switch geom := g.(type) {
+	case *Point:
+		swap(&geom.Coordinates)
+	case *MultiGeometry:
+		for _, child := range geom.Geometries {
+			swapGeometry(child)
+		}
+	default:
+		for _, s := range appendCoordSlices(nil, g) {
+			for i := range *s {
+				swap(&(*s)[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package kml
+
+import (
+	"errors"
+	"testing"
+)
+
+// swappedKML has Sydney and Auckland written latitude first
+const swappedKML = `<kml xmlns="http://www.opengis.net/kml/2.2"><Document>
+<Placemark><name>Sydney</name><Point><coordinates>-33.87,151.21</coordinates></Point></Placemark>
+<Placemark><name>Route</name><MultiGeometry>
+  <Point><coordinates>-36.85,174.76</coordinates></Point>
+  <LineString><coordinates>-33.87,151.21 -36.85,174.76</coordinates></LineString>
+</MultiGeometry></Placemark>
+</Document></kml>`
+
+// TestCoordinateOrder tests each policy on a document written latitude first
+func TestCoordinateOrder(t *testing.T) {
+	tests := []struct {
+		policy   CoordinateOrderPolicy
+		wantErr  bool
+		wantLon  float64
+		warnings int
+	}{
+		{OrderAllow, false, -33.87, 0},
+		{OrderWarn, false, -33.87, 1},
+		{OrderSwap, false, 151.21, 0},
+		{OrderError, true, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy.String(), func(t *testing.T) {
+			logger := &recordingLogger{}
+			var swapped bool
+			k, err := ParseBytes([]byte(swappedKML), CoordinateOrder(tt.policy, &swapped), WithLogger(logger))
+			if !swapped {
+				t.Errorf("Expected the document reported as swapped")
+			}
+			if len(logger.warnings) != tt.warnings {
+				t.Errorf("Expected %d warnings, got %v", tt.warnings, logger.warnings)
+			}
+			if tt.wantErr {
+				var vErr *ValidationError
+				if !errors.As(err, &vErr) {
+					t.Fatalf("Expected *ValidationError, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got := k.Placemarks()[0].Geometry.(*Point).Coordinates.Lon; got != tt.wantLon {
+				t.Errorf("Expected longitude %g, got %g", tt.wantLon, got)
+			}
+		})
+	}
+}
+
+// TestCheckCoordinateOrder tests swapping every geometry and leaving
+// ambiguous or valid documents alone
+func TestCheckCoordinateOrder(t *testing.T) {
+	k, err := ParseBytes([]byte(swappedKML))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if swapped, err := k.CheckCoordinateOrder(OrderSwap); !swapped || err != nil {
+		t.Fatalf("Expected swapped coordinates, got %v, %v", swapped, err)
+	}
+	for _, c := range getGeometryCoordinates(k.Placemarks()[1].Geometry) {
+		if c.Lon < 150 || c.Lat > -30 {
+			t.Errorf("Expected longitude first, got %v", c)
+		}
+	}
+	if swapped, _ := k.CheckCoordinateOrder(OrderSwap); swapped {
+		t.Errorf("Expected a swapped document to pass once fixed")
+	}
+
+	tests := []struct {
+		name   string
+		coords []Coordinate
+	}{
+		{"near the equator", []Coordinate{Coord(10, 50), Coord(-3, 40)}},
+		{"out of every range", []Coordinate{Coord(120, 95)}},
+		{"beyond 180", []Coordinate{Coord(10, 200)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := NewKML()
+			k.Feature = &Placemark{Geometry: &LineString{Coordinates: tt.coords}}
+			if swapped, _ := k.CheckCoordinateOrder(OrderSwap); swapped {
+				t.Errorf("Expected %v not to be reported", tt.coords)
+			}
+			if got := k.Feature.(*Placemark).Geometry.(*LineString).Coordinates[0]; got != tt.coords[0] {
+				t.Errorf("Expected coordinates unchanged, got %v", got)
+			}
+		})
+	}
+}
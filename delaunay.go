@@ -0,0 +1,191 @@
+package kml
+
+import (
+	"math"
+	"sort"
+)
+
+// planarPoint is a coordinate projected to a local plane, in meters.
+type planarPoint struct {
+	x, y float64
+}
+
+// localProjection is an equirectangular projection centered on a
+// latitude, so planar distances approximate meters over regional extents.
+type localProjection struct {
+	kx, ky float64 // meters per degree of longitude and latitude
+}
+
+// newLocalProjection returns a projection centered on the mean latitude of
+// coords.
+func newLocalProjection(coords []Coordinate) localProjection {
+	var lat0 float64
+	for _, c := range coords {
+		lat0 += c.Lat
+	}
+	if len(coords) > 0 {
+		lat0 /= float64(len(coords))
+	}
+
+	ky := toRadians(1) * earthRadius
+	return localProjection{kx: ky * math.Cos(toRadians(lat0)), ky: ky}
+}
+
+// forward projects c to the plane.
+func (p localProjection) forward(c Coordinate) planarPoint {
+	return planarPoint{x: c.Lon * p.kx, y: c.Lat * p.ky}
+}
+
+// inverse maps a planar point back to a coordinate.
+func (p localProjection) inverse(pt planarPoint) Coordinate {
+	return Coordinate{Lon: pt.x / p.kx, Lat: pt.y / p.ky}
+}
+
+// projectPlanar projects coords with a local projection centered on them.
+func projectPlanar(coords []Coordinate) []planarPoint {
+	proj := newLocalProjection(coords)
+	pts := make([]planarPoint, len(coords))
+	for i, c := range coords {
+		pts[i] = proj.forward(c)
+	}
+	return pts
+}
+
+// triangle is a Delaunay triangle over point indices with its circumcircle.
+type triangle struct {
+	a, b, c int
+	cx, cy  float64 // circumcenter
+	r2      float64 // squared circumradius
+}
+
+// newTriangle builds a triangle and computes its circumcircle. Degenerate
+// (collinear) triangles get an infinite radius.
+func newTriangle(pts []planarPoint, a, b, c int) triangle {
+	t := triangle{a: a, b: b, c: c}
+	pa, pb, pc := pts[a], pts[b], pts[c]
+
+	d := 2 * (pa.x*(pb.y-pc.y) + pb.x*(pc.y-pa.y) + pc.x*(pa.y-pb.y))
+	if d == 0 {
+		t.r2 = math.Inf(1)
+		return t
+	}
+	a2 := pa.x*pa.x + pa.y*pa.y
+	b2 := pb.x*pb.x + pb.y*pb.y
+	c2 := pc.x*pc.x + pc.y*pc.y
+	t.cx = (a2*(pb.y-pc.y) + b2*(pc.y-pa.y) + c2*(pa.y-pb.y)) / d
+	t.cy = (a2*(pc.x-pb.x) + b2*(pa.x-pc.x) + c2*(pb.x-pa.x)) / d
+	dx, dy := pa.x-t.cx, pa.y-t.cy
+	t.r2 = dx*dx + dy*dy
+	return t
+}
+
+// inCircumcircle reports whether p lies strictly inside the circumcircle.
+func (t triangle) inCircumcircle(p planarPoint) bool {
+	dx, dy := p.x-t.cx, p.y-t.cy
+	return dx*dx+dy*dy < t.r2*(1-1e-12)
+}
+
+// edge is an undirected edge between two point indices, with a < b.
+type edge struct {
+	a, b int
+}
+
+// newEdge returns the normalized edge between i and j.
+func newEdge(i, j int) edge {
+	if i > j {
+		i, j = j, i
+	}
+	return edge{i, j}
+}
+
+// triangulate computes the Delaunay triangulation of coords with the
+// Bowyer-Watson algorithm and returns triangles over indices into coords.
+// Duplicate coordinates are triangulated once, using their first index.
+// Fewer than three distinct, non-collinear coordinates yield no triangles.
+func triangulate(coords []Coordinate) []triangle {
+	// Drop duplicate positions, remembering original indices.
+	seen := make(map[[2]float64]bool, len(coords))
+	var unique []Coordinate
+	var index []int
+	for i, c := range coords {
+		key := [2]float64{c.Lon, c.Lat}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, c)
+		index = append(index, i)
+	}
+	n := len(unique)
+	if n < 3 {
+		return nil
+	}
+
+	pts := projectPlanar(unique)
+
+	// Super-triangle enclosing every point.
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, p := range pts {
+		minX, maxX = math.Min(minX, p.x), math.Max(maxX, p.x)
+		minY, maxY = math.Min(minY, p.y), math.Max(maxY, p.y)
+	}
+	span := math.Max(maxX-minX, maxY-minY)
+	if span == 0 {
+		return nil
+	}
+	midX, midY := (minX+maxX)/2, (minY+maxY)/2
+	pts = append(pts,
+		planarPoint{midX - 20*span, midY - span},
+		planarPoint{midX, midY + 20*span},
+		planarPoint{midX + 20*span, midY - span},
+	)
+
+	tris := []triangle{newTriangle(pts, n, n+1, n+2)}
+	for i := 0; i < n; i++ {
+		p := pts[i]
+
+		var keep []triangle
+		edgeCount := make(map[edge]int)
+		var edgeOrder []edge
+		for _, t := range tris {
+			if !t.inCircumcircle(p) {
+				keep = append(keep, t)
+				continue
+			}
+			for _, e := range []edge{newEdge(t.a, t.b), newEdge(t.b, t.c), newEdge(t.c, t.a)} {
+				if edgeCount[e] == 0 {
+					edgeOrder = append(edgeOrder, e)
+				}
+				edgeCount[e]++
+			}
+		}
+
+		for _, e := range edgeOrder {
+			if edgeCount[e] == 1 {
+				keep = append(keep, newTriangle(pts, e.a, e.b, i))
+			}
+		}
+		tris = keep
+	}
+
+	result := make([]triangle, 0, len(tris))
+	for _, t := range tris {
+		if t.a >= n || t.b >= n || t.c >= n || math.IsInf(t.r2, 1) {
+			continue
+		}
+		t.a, t.b, t.c = index[t.a], index[t.b], index[t.c]
+		result = append(result, t)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		ri, rj := result[i], result[j]
+		if ri.a != rj.a {
+			return ri.a < rj.a
+		}
+		if ri.b != rj.b {
+			return ri.b < rj.b
+		}
+		return ri.c < rj.c
+	})
+	return result
+}
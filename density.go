@@ -0,0 +1,139 @@
+package kml
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// GridOptions configures DensityGrid.
+type GridOptions struct {
+	CellSize      float64   // Cell edge length in degrees (default 0.1)
+	Classes       int       // Number of generated styles (default 5)
+	Low           Color     // Fill color of the sparsest cells (default translucent yellow)
+	High          Color     // Fill color of the densest cells (default translucent red)
+	Ramp          ColorRamp // Colors the cells from sparsest to densest; overrides Low and High
+	StyleIDPrefix string    // Prefix for generated style IDs (default "density-")
+	HashIDs       bool      // Derive style IDs from their content, as "density-3fa2bc", instead of numbering them
+}
+
+// defaults returns a copy of the options with zero values replaced by defaults.
+func (o GridOptions) defaults() GridOptions {
+	if o.CellSize <= 0 {
+		o.CellSize = 0.1
+	}
+	if o.Classes <= 0 {
+		o.Classes = 5
+	}
+	if o.Low == (Color{}) && o.High == (Color{}) {
+		o.Low = RGBA(255, 255, 0, 160)
+		o.High = RGBA(255, 0, 0, 200)
+	}
+	if o.Ramp == nil {
+		o.Ramp = TwoColorRamp(o.Low, o.High)
+	}
+	if o.StyleIDPrefix == "" {
+		o.StyleIDPrefix = "density-"
+	}
+	return o
+}
+
+// gridCell identifies a cell of a lat/lon grid by column and row.
+type gridCell struct {
+	col, row int
+}
+
+// DensityGrid bins the Point placemarks into a regular lat/lon grid and
+// returns a Folder with one polygon placemark per non-empty cell, colored by
+// the number of points it contains, together with the gradient of shared
+// styles those placemarks reference. The styles should be added to the
+// Document that will hold the folder.
+//
+// Each cell placemark carries its point count in an ExtendedData field named
+// "count". Placemarks without a Point geometry are ignored.
+func DensityGrid(pms []*Placemark, opts GridOptions) (*Folder, []Style) {
+	opts = opts.defaults()
+
+	counts := make(map[gridCell]int)
+	for _, pm := range pms {
+		pt, ok := pm.Geometry.(*Point)
+		if !ok {
+			continue
+		}
+		cell := gridCell{
+			col: int(math.Floor(pt.Coordinates.Lon / opts.CellSize)),
+			row: int(math.Floor(pt.Coordinates.Lat / opts.CellSize)),
+		}
+		counts[cell]++
+	}
+
+	cells := make([]gridCell, 0, len(counts))
+	maxCount := 0
+	for cell, n := range counts {
+		cells = append(cells, cell)
+		if n > maxCount {
+			maxCount = n
+		}
+	}
+	sort.Slice(cells, func(i, j int) bool {
+		if cells[i].row != cells[j].row {
+			return cells[i].row < cells[j].row
+		}
+		return cells[i].col < cells[j].col
+	})
+
+	styles := make([]Style, opts.Classes)
+	for i := range styles {
+		t := 0.0
+		if opts.Classes > 1 {
+			t = float64(i) / float64(opts.Classes-1)
+		}
+		outline := false
+		styles[i] = Style{
+			ID: fmt.Sprintf("%s%d", opts.StyleIDPrefix, i),
+			PolyStyle: &PolyStyle{
+				Color:   opts.Ramp.At(t),
+				Outline: &outline,
+			},
+		}
+	}
+
+	folder := &Folder{Name: "Density"}
+	for _, cell := range cells {
+		n := counts[cell]
+		class := int(math.Ceil(float64(n)/float64(maxCount)*float64(opts.Classes))) - 1
+		if class < 0 {
+			class = 0
+		}
+
+		west := float64(cell.col) * opts.CellSize
+		south := float64(cell.row) * opts.CellSize
+		east := west + opts.CellSize
+		north := south + opts.CellSize
+
+		folder.Features = append(folder.Features, &Placemark{
+			Name:     strconv.Itoa(n),
+			StyleURL: "#" + styles[class].ID,
+			Geometry: &Polygon{
+				OuterBoundary: LinearRing{
+					Coordinates: []Coordinate{
+						Coord(west, south),
+						Coord(east, south),
+						Coord(east, north),
+						Coord(west, north),
+						Coord(west, south),
+					},
+				},
+			},
+			ExtendedData: &ExtendedData{
+				Data: []Data{{Name: "count", Value: strconv.Itoa(n)}},
+			},
+		})
+	}
+
+	if opts.HashIDs {
+		styles = hashStyleIDs(styles, opts.StyleIDPrefix, nil, folder)
+	}
+	return folder, styles
+}
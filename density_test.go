@@ -0,0 +1,86 @@
+package kml
+
+import "testing"
+
+// TestDensityGrid tests binning of points into styled grid cells
+func TestDensityGrid(t *testing.T) {
+	var pms []*Placemark
+	addPoints := func(lon, lat float64, n int) {
+		for i := 0; i < n; i++ {
+			pms = append(pms, &Placemark{Geometry: &Point{Coordinates: Coord(lon, lat)}})
+		}
+	}
+	addPoints(0.5, 0.5, 4)  // cell (0,0)
+	addPoints(1.5, 0.5, 1)  // cell (1,0)
+	addPoints(-0.5, 1.5, 2) // cell (-1,1)
+	pms = append(pms, &Placemark{Geometry: &LineString{Coordinates: []Coordinate{Coord(0, 0), Coord(1, 1)}}})
+
+	folder, styles := DensityGrid(pms, GridOptions{CellSize: 1, Classes: 4})
+
+	if len(styles) != 4 {
+		t.Fatalf("Expected 4 styles, got %d", len(styles))
+	}
+	if styles[0].ID != "density-0" || styles[3].ID != "density-3" {
+		t.Errorf("Unexpected style IDs: %s .. %s", styles[0].ID, styles[3].ID)
+	}
+	if styles[0].PolyStyle.Color == styles[3].PolyStyle.Color {
+		t.Error("Expected gradient endpoints to differ")
+	}
+
+	if len(folder.Features) != 3 {
+		t.Fatalf("Expected 3 cells, got %d", len(folder.Features))
+	}
+
+	tests := []struct {
+		count    string
+		styleURL string
+		sw       Coordinate
+	}{
+		{"4", "#density-3", Coord(0, 0)},
+		{"1", "#density-0", Coord(1, 0)},
+		{"2", "#density-1", Coord(-1, 1)},
+	}
+
+	for i, tt := range tests {
+		pm := folder.Features[i].(*Placemark)
+		if pm.ExtendedData.Data[0].Value != tt.count {
+			t.Errorf("cell %d: count = %s, want %s", i, pm.ExtendedData.Data[0].Value, tt.count)
+		}
+		if pm.StyleURL != tt.styleURL {
+			t.Errorf("cell %d: styleUrl = %s, want %s", i, pm.StyleURL, tt.styleURL)
+		}
+		poly := pm.Geometry.(*Polygon)
+		if got := poly.OuterBoundary.Coordinates[0]; got != tt.sw {
+			t.Errorf("cell %d: southwest corner = %v, want %v", i, got, tt.sw)
+		}
+		if n := len(poly.OuterBoundary.Coordinates); n != 5 {
+			t.Errorf("cell %d: expected closed ring of 5 coordinates, got %d", i, n)
+		}
+	}
+}
+
+// TestDensityGridEmpty tests that no cells are produced without points
+func TestDensityGridEmpty(t *testing.T) {
+	folder, styles := DensityGrid(nil, GridOptions{})
+
+	if len(folder.Features) != 0 {
+		t.Errorf("Expected no cells, got %d", len(folder.Features))
+	}
+	if len(styles) != 5 {
+		t.Errorf("Expected default of 5 styles, got %d", len(styles))
+	}
+}
+
+// TestDensityGridRamp tests coloring cells with a ColorRamp
+func TestDensityGridRamp(t *testing.T) {
+	pms := []*Placemark{
+		{Geometry: &Point{Coordinates: Coord(0.5, 0.5)}},
+	}
+	_, styles := DensityGrid(pms, GridOptions{CellSize: 1, Classes: 3, Ramp: Viridis})
+
+	for i, want := range Steps(Viridis, 3) {
+		if got := styles[i].PolyStyle.Color; got != want {
+			t.Errorf("Style %d: expected %s, got %s", i, want.Hex(), got.Hex())
+		}
+	}
+}
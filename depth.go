@@ -0,0 +1,49 @@
+package kml
+
+import (
+	"encoding/xml"
+	"sync"
+)
+
+// DefaultMaxDepth is the nesting limit applied when MaxDepth is not given.
+// Real documents rarely nest containers more than a few levels deep.
+const DefaultMaxDepth = 100
+
+// MaxDepth limits how deeply Documents, Folders and MultiGeometries may be
+// nested within each other, counting them together. Parsing a document
+// that goes deeper fails with a *ParseError wrapping ErrTooDeep, even with
+// CollectErrors, rather than exhausting memory on a malicious or broken
+// file. A limit of zero or less restores DefaultMaxDepth.
+func MaxDepth(n int) ParseOption {
+	return func(c *parseConfig) {
+		c.maxDepth = n
+	}
+}
+
+// decoderDepths maps an active *xml.Decoder to its current nesting depth.
+// It is kept apart from the parse configuration so that the default limit
+// also holds for documents decoded with xml.Unmarshal.
+var decoderDepths sync.Map
+
+// enterNested records that d has entered a nested container at start and
+// returns a function to call on leaving it, or an error if the container
+// is one level too deep.
+func enterNested(d *xml.Decoder, start xml.StartElement) (leave func(), err error) {
+	limit := DefaultMaxDepth
+	if cfg := parseConfigFor(d); cfg != nil && cfg.maxDepth > 0 {
+		limit = cfg.maxDepth
+	}
+
+	v, _ := decoderDepths.LoadOrStore(d, new(int))
+	depth := v.(*int)
+	if *depth >= limit {
+		line, col := d.InputPos()
+		return nil, &ParseError{Line: line, Column: col, Message: "too many levels of " + start.Name.Local, Cause: ErrTooDeep}
+	}
+	*depth++
+	return func() {
+		if *depth--; *depth == 0 {
+			decoderDepths.Delete(d)
+		}
+	}, nil
+}
@@ -0,0 +1,88 @@
+package kml
+
+import (
+	"encoding/xml"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// nestedKML returns a document with depth levels of element around a
+// placemark
+func nestedKML(element string, depth int) string {
+	inner := "<Placemark><Point><coordinates>1,2</coordinates></Point></Placemark>"
+	if element == "MultiGeometry" {
+		inner = "<Point><coordinates>1,2</coordinates></Point>"
+	}
+	body := strings.Repeat("<"+element+">", depth) + inner + strings.Repeat("</"+element+">", depth)
+	if element == "MultiGeometry" {
+		body = "<Placemark>" + body + "</Placemark>"
+	}
+	return `<kml xmlns="http://www.opengis.net/kml/2.2">` + body + `</kml>`
+}
+
+// TestMaxDepth tests the nesting limit of containers and multi-geometries
+func TestMaxDepth(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		opts    []ParseOption
+		wantErr bool
+	}{
+		{"folders at the default limit", nestedKML("Folder", DefaultMaxDepth), nil, false},
+		{"folders past the default limit", nestedKML("Folder", DefaultMaxDepth+1), nil, true},
+		{"documents past the default limit", nestedKML("Document", DefaultMaxDepth+1), nil, true},
+		{"multi-geometries past the default limit", nestedKML("MultiGeometry", DefaultMaxDepth+1), nil, true},
+		{"custom limit", nestedKML("Folder", 4), []ParseOption{MaxDepth(3)}, true},
+		{"raised limit", nestedKML("Folder", 500), []ParseOption{MaxDepth(500)}, false},
+		{"zero restores the default", nestedKML("Folder", DefaultMaxDepth+1), []ParseOption{MaxDepth(0)}, true},
+		{"errors are not collected", nestedKML("Folder", 4), []ParseOption{MaxDepth(3), CollectErrors(new([]error))}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseBytes([]byte(tt.data), tt.opts...)
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if err != nil && !errors.Is(err, ErrTooDeep) {
+				t.Errorf("Expected ErrTooDeep, got %v", err)
+			}
+		})
+	}
+}
+
+// TestMaxDepthCounted tests that containers and multi-geometries share the
+// limit and that siblings do not add up
+func TestMaxDepthCounted(t *testing.T) {
+	mixed := `<kml><Folder><Document><Placemark><MultiGeometry><MultiGeometry>` +
+		`<Point><coordinates>1,2</coordinates></Point></MultiGeometry></MultiGeometry></Placemark></Document></Folder></kml>`
+	if _, err := ParseBytes([]byte(mixed), MaxDepth(3)); !errors.Is(err, ErrTooDeep) {
+		t.Errorf("Expected ErrTooDeep for four levels, got %v", err)
+	}
+	if _, err := ParseBytes([]byte(mixed), MaxDepth(4)); err != nil {
+		t.Errorf("Expected four levels to parse, got %v", err)
+	}
+
+	siblings := `<kml><Folder>` + strings.Repeat(`<Folder><Folder></Folder></Folder>`, 10) + `</Folder></kml>`
+	if _, err := ParseBytes([]byte(siblings), MaxDepth(3)); err != nil {
+		t.Errorf("Expected siblings to parse, got %v", err)
+	}
+}
+
+// TestMaxDepthUnmarshal tests the default limit without Parse
+func TestMaxDepthUnmarshal(t *testing.T) {
+	var k KML
+	err := xml.Unmarshal([]byte(nestedKML("Folder", DefaultMaxDepth+1)), &k)
+	if !errors.Is(err, ErrTooDeep) {
+		t.Errorf("Expected ErrTooDeep, got %v", err)
+	}
+	var pe *ParseError
+	if !errors.As(err, &pe) || !strings.Contains(err.Error(), "line 1") {
+		t.Errorf("Expected a ParseError with the position, got %v", err)
+	}
+	decoderDepths.Range(func(d, _ any) bool {
+		t.Errorf("Expected depths to be released, got one for %p", d)
+		return true
+	})
+}
@@ -0,0 +1,184 @@
+package kml
+
+import (
+	"html"
+	"strings"
+)
+
+// Default inline styles for GenerateDescriptionTable, approximating Google
+// Earth's default balloon table.
+const (
+	defaultTableStyle   = "border-collapse:collapse;font-family:Arial,sans-serif;font-size:12px"
+	defaultHeaderStyle  = "padding:2px 6px;text-align:left;font-weight:bold"
+	defaultCellStyle    = "padding:2px 6px"
+	defaultStripedStyle = "background-color:#E3E3F3"
+)
+
+// DescriptionTableOptions controls how GenerateDescriptionTable renders
+// ExtendedData.
+type DescriptionTableOptions struct {
+	// Title, if set, is rendered as a heading row spanning the table.
+	Title string
+
+	// Columns lists the data names to include, in order. If empty, all
+	// Data and SimpleData entries are included in document order.
+	Columns []string
+
+	// Labels overrides the label shown for a data name. Without an
+	// override, a Data element's displayName is used, falling back to its
+	// name.
+	Labels map[string]string
+
+	// Format transforms the raw value of a data name before it is
+	// escaped and rendered, taking precedence over Locale.
+	Format map[string]func(string) string
+
+	// Locale, if set, reformats the values that have no Format function
+	// as Locale.FormatValue does, leaving integers such as IDs and ZIP
+	// codes as they are.
+	Locale *Locale
+
+	// Numeric lists the data names whose values Locale formats as numbers
+	// even when they are integers or have leading zeros, such as
+	// populations.
+	Numeric []string
+
+	// Units appends a unit suffix, such as "m" or "km/h", to the value of
+	// a data name.
+	Units map[string]string
+
+	// SkipEmpty omits rows whose formatted value is empty.
+	SkipEmpty bool
+
+	// Inline CSS for the table, label cells, value cells, and every other
+	// row. Empty values use defaults resembling Google Earth's balloon.
+	TableStyle   string
+	HeaderStyle  string
+	CellStyle    string
+	StripedStyle string
+}
+
+// descriptionRow is one label/value pair gathered from ExtendedData.
+type descriptionRow struct {
+	name  string
+	label string
+	value string
+}
+
+// GenerateDescriptionTable renders the placemark's ExtendedData as an HTML
+// table and stores it in Description, replacing any existing description.
+// Labels and values are HTML-escaped; the description is written as CDATA
+// when the document is encoded. A placemark without ExtendedData gets an
+// empty description.
+func (p *Placemark) GenerateDescriptionTable(opts DescriptionTableOptions) {
+	rows := p.descriptionRows(opts.Columns)
+	if len(rows) == 0 && opts.Title == "" {
+		p.Description = ""
+		return
+	}
+
+	tableStyle := defaultIfEmpty(opts.TableStyle, defaultTableStyle)
+	headerStyle := defaultIfEmpty(opts.HeaderStyle, defaultHeaderStyle)
+	cellStyle := defaultIfEmpty(opts.CellStyle, defaultCellStyle)
+	stripedStyle := defaultIfEmpty(opts.StripedStyle, defaultStripedStyle)
+
+	var b strings.Builder
+	b.WriteString(`<table style="` + html.EscapeString(tableStyle) + `">`)
+
+	if opts.Title != "" {
+		b.WriteString(`<tr><th colspan="2" style="` + html.EscapeString(headerStyle) + `">`)
+		b.WriteString(html.EscapeString(opts.Title))
+		b.WriteString(`</th></tr>`)
+	}
+
+	numeric := make(map[string]bool, len(opts.Numeric))
+	for _, name := range opts.Numeric {
+		numeric[name] = true
+	}
+
+	striped := false
+	for _, row := range rows {
+		value := row.value
+		if fn := opts.Format[row.name]; fn != nil {
+			value = fn(value)
+		} else if opts.Locale != nil && numeric[row.name] {
+			value = opts.Locale.formatNumberValue(value)
+		} else if opts.Locale != nil {
+			value = opts.Locale.FormatValue(value)
+		}
+		if value != "" {
+			if unit := opts.Units[row.name]; unit != "" {
+				value += " " + unit
+			}
+		}
+		if value == "" && opts.SkipEmpty {
+			continue
+		}
+
+		label := row.label
+		if l, ok := opts.Labels[row.name]; ok {
+			label = l
+		}
+
+		if striped {
+			b.WriteString(`<tr style="` + html.EscapeString(stripedStyle) + `">`)
+		} else {
+			b.WriteString(`<tr>`)
+		}
+		striped = !striped
+
+		b.WriteString(`<td style="` + html.EscapeString(headerStyle) + `">`)
+		b.WriteString(html.EscapeString(label))
+		b.WriteString(`</td><td style="` + html.EscapeString(cellStyle) + `">`)
+		b.WriteString(html.EscapeString(value))
+		b.WriteString(`</td></tr>`)
+	}
+
+	b.WriteString(`</table>`)
+	p.Description = b.String()
+}
+
+// descriptionRows gathers ExtendedData entries, restricted to and ordered
+// by columns when it is non-empty.
+func (p *Placemark) descriptionRows(columns []string) []descriptionRow {
+	if p.ExtendedData == nil {
+		return nil
+	}
+
+	var all []descriptionRow
+	for _, d := range p.ExtendedData.Data {
+		all = append(all, descriptionRow{
+			name:  d.Name,
+			label: defaultIfEmpty(d.DisplayName, d.Name),
+			value: d.Value,
+		})
+	}
+	for _, sd := range p.ExtendedData.SchemaData {
+		for _, d := range sd.SimpleData {
+			all = append(all, descriptionRow{name: d.Name, label: d.Name, value: d.Value})
+		}
+	}
+
+	if len(columns) == 0 {
+		return all
+	}
+
+	rows := make([]descriptionRow, 0, len(columns))
+	for _, col := range columns {
+		for _, row := range all {
+			if row.name == col {
+				rows = append(rows, row)
+				break
+			}
+		}
+	}
+	return rows
+}
+
+// defaultIfEmpty returns s, or def if s is empty.
+func defaultIfEmpty(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
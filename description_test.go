@@ -0,0 +1,131 @@
+package kml
+
+import (
+	"strings"
+	"testing"
+)
+
+func descriptionTestPlacemark() *Placemark {
+	return &Placemark{
+		Name: "Station",
+		ExtendedData: &ExtendedData{
+			Data: []Data{
+				{Name: "elevation", DisplayName: "Elevation", Value: "1234.5"},
+				{Name: "owner", Value: "Parks & Rec"},
+				{Name: "notes", Value: ""},
+			},
+			SchemaData: []SchemaData{{
+				SchemaURL:  "#station",
+				SimpleData: []SimpleData{{Name: "code", Value: "<X1>"}},
+			}},
+		},
+	}
+}
+
+func TestGenerateDescriptionTable(t *testing.T) {
+	pm := descriptionTestPlacemark()
+	pm.GenerateDescriptionTable(DescriptionTableOptions{Title: "Station Info"})
+
+	desc := pm.Description
+	if !strings.HasPrefix(desc, "<table") || !strings.HasSuffix(desc, "</table>") {
+		t.Fatalf("Expected HTML table, got %s", desc)
+	}
+	for _, want := range []string{"Station Info", "Elevation", "1234.5", "owner", "Parks &amp; Rec", "&lt;X1&gt;", defaultStripedStyle} {
+		if !strings.Contains(desc, want) {
+			t.Errorf("Expected description to contain %q, got %s", want, desc)
+		}
+	}
+	if strings.Index(desc, "Elevation") > strings.Index(desc, "code") {
+		t.Error("Expected rows in document order")
+	}
+}
+
+func TestGenerateDescriptionTableOptions(t *testing.T) {
+	pm := descriptionTestPlacemark()
+	pm.GenerateDescriptionTable(DescriptionTableOptions{
+		Columns:   []string{"code", "elevation", "notes", "missing"},
+		Labels:    map[string]string{"code": "Station Code"},
+		Format:    map[string]func(string) string{"elevation": func(s string) string { return strings.TrimSuffix(s, ".5") }},
+		Units:     map[string]string{"elevation": "m", "notes": "x"},
+		SkipEmpty: true,
+		CellStyle: "color:red",
+	})
+
+	desc := pm.Description
+	if strings.Contains(desc, "owner") {
+		t.Error("Expected owner column to be excluded")
+	}
+	if strings.Contains(desc, "notes") {
+		t.Error("Expected empty notes row to be skipped")
+	}
+	if !strings.Contains(desc, "1234 m") {
+		t.Errorf("Expected formatted value with unit, got %s", desc)
+	}
+	if !strings.Contains(desc, "color:red") {
+		t.Errorf("Expected custom cell style, got %s", desc)
+	}
+	if strings.Index(desc, "Station Code") > strings.Index(desc, "1234 m") {
+		t.Error("Expected rows in column order")
+	}
+}
+
+func TestGenerateDescriptionTableNoData(t *testing.T) {
+	pm := &Placemark{Description: "old"}
+	pm.GenerateDescriptionTable(DescriptionTableOptions{})
+	if pm.Description != "" {
+		t.Errorf("Expected empty description, got %q", pm.Description)
+	}
+}
+
+func TestDescriptionEncodedAsCDATA(t *testing.T) {
+	pm := descriptionTestPlacemark()
+	pm.GenerateDescriptionTable(DescriptionTableOptions{})
+	want := pm.Description
+
+	data, err := (&KML{Feature: pm}).Bytes()
+	if err != nil {
+		t.Fatalf("Bytes failed: %v", err)
+	}
+	if !strings.Contains(string(data), "<description><![CDATA[<table") {
+		t.Errorf("Expected CDATA description, got %s", data)
+	}
+
+	k, err := ParseBytes(data)
+	if err != nil {
+		t.Fatalf("ParseBytes failed: %v", err)
+	}
+	if got := k.Feature.(*Placemark).Description; got != want {
+		t.Errorf("Expected description to round-trip, got %q", got)
+	}
+
+	plain, err := (&KML{Feature: &Placemark{Description: "plain text"}}).Bytes()
+	if err != nil {
+		t.Fatalf("Bytes failed: %v", err)
+	}
+	if !strings.Contains(string(plain), "<description>plain text</description>") {
+		t.Errorf("Expected plain description without CDATA, got %s", plain)
+	}
+}
+
+func TestGenerateDescriptionTableLocale(t *testing.T) {
+	pm := &Placemark{ExtendedData: &ExtendedData{Data: []Data{
+		{Name: "population", Value: "1234567"},
+		{Name: "founded", Value: "1850-04-15"},
+		{Name: "code", Value: "00123"},
+		{Name: "area", Value: "1234.5"},
+		{Name: "id", Value: "12345"},
+		{Name: "zip", Value: "02134"},
+		{Name: "agent", Value: "007"},
+	}}}
+	pm.GenerateDescriptionTable(DescriptionTableOptions{
+		Locale:  &LocaleDeDE,
+		Numeric: []string{"population"},
+		Format:  map[string]func(string) string{"code": func(s string) string { return s }},
+	})
+
+	for _, want := range []string{"1.234.567", "15.04.1850 00:00", "00123", "1.234,5", ">12345<", ">02134<", ">007<"} {
+		if !strings.Contains(pm.Description, want) {
+			t.Errorf("Expected description to contain %q, got %s", want, pm.Description)
+		}
+	}
+}
@@ -8,6 +8,14 @@ import (
 // Feature interface represents anything that can be contained in a Document/Folder
 type Feature interface {
 	featureType() string
+
+	// Hash returns a stable content hash of the feature, covering its
+	// metadata, styles, geometry and any child features.
+	Hash() string
+
+	// Accept calls the method of v for the feature's type, then visits its
+	// child features or geometry. See Visitor.
+	Accept(v Visitor) error
 }
 
 // Document represents a KML Document element
@@ -17,9 +25,17 @@ type Document struct {
 	Description string     `xml:"description,omitempty"`
 	Open        bool       `xml:"open,omitempty"`
 	Visibility  *bool      `xml:"visibility,omitempty"`
+	LookAt      *LookAt    `xml:"LookAt,omitempty"`
+	Camera      *Camera    `xml:"Camera,omitempty"`
+	StyleURL    string     `xml:"styleUrl,omitempty"`
 	Styles      []Style    `xml:"Style,omitempty"`
 	StyleMaps   []StyleMap `xml:"StyleMap,omitempty"`
+	Region      *Region    `xml:"Region,omitempty"`
+	Schemas     []Schema   `xml:"Schema,omitempty"`
 	Features    []Feature  `xml:"-"` // Custom unmarshaling required
+	Comments    []string   `xml:"-"` // See PreserveComments
+
+	span *Span // See RecordSpans
 }
 
 // featureType implements the Feature interface
@@ -35,6 +51,9 @@ func (d *Document) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
 		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "id"}, Value: d.ID})
 	}
 
+	if err := encodeComments(e, d.Comments); err != nil {
+		return err
+	}
 	if err := e.EncodeToken(start); err != nil {
 		return err
 	}
@@ -46,8 +65,12 @@ func (d *Document) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
 		}
 	}
 
-	if d.Description != "" {
-		if err := e.EncodeElement(d.Description, xml.StartElement{Name: xml.Name{Local: "description"}}); err != nil {
+	if d.Visibility != nil {
+		vis := 0
+		if *d.Visibility {
+			vis = 1
+		}
+		if err := e.EncodeElement(vis, xml.StartElement{Name: xml.Name{Local: "visibility"}}); err != nil {
 			return err
 		}
 	}
@@ -58,12 +81,26 @@ func (d *Document) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
 		}
 	}
 
-	if d.Visibility != nil {
-		vis := 0
-		if *d.Visibility {
-			vis = 1
+	if d.Description != "" {
+		if err := encodeDescription(e, d.Description); err != nil {
+			return err
 		}
-		if err := e.EncodeElement(vis, xml.StartElement{Name: xml.Name{Local: "visibility"}}); err != nil {
+	}
+
+	if d.LookAt != nil {
+		if err := e.Encode(d.LookAt); err != nil {
+			return err
+		}
+	}
+
+	if d.Camera != nil {
+		if err := e.Encode(d.Camera); err != nil {
+			return err
+		}
+	}
+
+	if d.StyleURL != "" {
+		if err := e.EncodeElement(d.StyleURL, xml.StartElement{Name: xml.Name{Local: "styleUrl"}}); err != nil {
 			return err
 		}
 	}
@@ -82,6 +119,19 @@ func (d *Document) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
 		}
 	}
 
+	if d.Region != nil {
+		if err := e.Encode(d.Region); err != nil {
+			return err
+		}
+	}
+
+	// Encode Schemas
+	for _, schema := range d.Schemas {
+		if err := e.EncodeElement(&schema, xml.StartElement{Name: xml.Name{Local: "Schema"}}); err != nil {
+			return err
+		}
+	}
+
 	// Encode Features based on their concrete type
 	for _, feature := range d.Features {
 		switch f := feature.(type) {
@@ -97,14 +147,42 @@ func (d *Document) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
 			if err := e.EncodeElement(f, xml.StartElement{Name: xml.Name{Local: "Placemark"}}); err != nil {
 				return err
 			}
+		case *GroundOverlay:
+			if err := e.EncodeElement(f, xml.StartElement{Name: xml.Name{Local: "GroundOverlay"}}); err != nil {
+				return err
+			}
+		case *NetworkLink:
+			if err := e.EncodeElement(f, xml.StartElement{Name: xml.Name{Local: "NetworkLink"}}); err != nil {
+				return err
+			}
+		case *ScreenOverlay:
+			if err := e.EncodeElement(f, xml.StartElement{Name: xml.Name{Local: "ScreenOverlay"}}); err != nil {
+				return err
+			}
+		case *Tour:
+			if err := e.EncodeElement(f, xml.StartElement{Name: xml.Name{Local: "Tour"}}); err != nil {
+				return err
+			}
 		}
 	}
 
-	return e.EncodeToken(xml.EndElement{Name: start.Name})
+	if err := e.EncodeToken(xml.EndElement{Name: start.Name}); err != nil {
+		return err
+	}
+	featureWritten(e)
+	return nil
 }
 
 // UnmarshalXML implements custom XML unmarshaling for Document
 func (d *Document) UnmarshalXML(decoder *xml.Decoder, start xml.StartElement) error {
+	leave, err := enterNested(decoder, start)
+	if err != nil {
+		return err
+	}
+	defer leave()
+	d.Comments = takeComments(decoder)
+	beginSpan(decoder)
+
 	// Process attributes
 	for _, attr := range start.Attr {
 		if attr.Name.Local == "id" {
@@ -114,6 +192,7 @@ func (d *Document) UnmarshalXML(decoder *xml.Decoder, start xml.StartElement) er
 
 	// Process child elements
 	for {
+		markToken(decoder)
 		token, err := decoder.Token()
 		if err != nil {
 			if err == io.EOF {
@@ -123,7 +202,10 @@ func (d *Document) UnmarshalXML(decoder *xml.Decoder, start xml.StartElement) er
 		}
 
 		switch tok := token.(type) {
+		case xml.Comment:
+			keepComment(decoder, tok)
 		case xml.StartElement:
+			dropComments(decoder, tok)
 			switch tok.Name.Local {
 			case "name":
 				if err := decoder.DecodeElement(&d.Name, &tok); err != nil {
@@ -138,14 +220,30 @@ func (d *Document) UnmarshalXML(decoder *xml.Decoder, start xml.StartElement) er
 				if err := decoder.DecodeElement(&open, &tok); err != nil {
 					return err
 				}
-				d.Open = open != 0
+				d.Open = flagValue(decoder, tok, open)
 			case "visibility":
 				var vis int
 				if err := decoder.DecodeElement(&vis, &tok); err != nil {
 					return err
 				}
-				visibility := vis != 0
+				visibility := flagValue(decoder, tok, vis)
 				d.Visibility = &visibility
+			case "LookAt":
+				var lookAt LookAt
+				if err := decoder.DecodeElement(&lookAt, &tok); err != nil {
+					return err
+				}
+				d.LookAt = &lookAt
+			case "Camera":
+				var camera Camera
+				if err := decoder.DecodeElement(&camera, &tok); err != nil {
+					return err
+				}
+				d.Camera = &camera
+			case "styleUrl":
+				if err := decoder.DecodeElement(&d.StyleURL, &tok); err != nil {
+					return err
+				}
 			case "Style":
 				var style Style
 				if err := decoder.DecodeElement(&style, &tok); err != nil {
@@ -158,6 +256,18 @@ func (d *Document) UnmarshalXML(decoder *xml.Decoder, start xml.StartElement) er
 					return err
 				}
 				d.StyleMaps = append(d.StyleMaps, styleMap)
+			case "Region":
+				var region Region
+				if err := decoder.DecodeElement(&region, &tok); err != nil {
+					return err
+				}
+				d.Region = &region
+			case "Schema":
+				var schema Schema
+				if err := decoder.DecodeElement(&schema, &tok); err != nil {
+					return err
+				}
+				d.Schemas = append(d.Schemas, schema)
 			case "Document":
 				var doc Document
 				if err := decoder.DecodeElement(&doc, &tok); err != nil {
@@ -176,14 +286,38 @@ func (d *Document) UnmarshalXML(decoder *xml.Decoder, start xml.StartElement) er
 					return err
 				}
 				d.Features = append(d.Features, &placemark)
+			case "GroundOverlay":
+				var overlay GroundOverlay
+				if err := decoder.DecodeElement(&overlay, &tok); err != nil {
+					return err
+				}
+				d.Features = append(d.Features, &overlay)
+			case "NetworkLink":
+				var link NetworkLink
+				if err := decoder.DecodeElement(&link, &tok); err != nil {
+					return err
+				}
+				d.Features = append(d.Features, &link)
+			case "ScreenOverlay":
+				var overlay ScreenOverlay
+				if err := decoder.DecodeElement(&overlay, &tok); err != nil {
+					return err
+				}
+				d.Features = append(d.Features, &overlay)
+			case "Tour":
+				var tour Tour
+				if err := decoder.DecodeElement(&tour, &tok); err != nil {
+					return err
+				}
+				d.Features = append(d.Features, &tour)
 			default:
-				// Skip unknown elements
-				if err := decoder.Skip(); err != nil {
+				if err := skipElement(decoder, tok); err != nil {
 					return err
 				}
 			}
 		case xml.EndElement:
 			if tok.Name.Local == "Document" {
+				featureParsed(decoder, d)
 				return nil
 			}
 		}
@@ -194,12 +328,21 @@ func (d *Document) UnmarshalXML(decoder *xml.Decoder, start xml.StartElement) er
 
 // Folder represents a KML Folder element
 type Folder struct {
-	ID          string    `xml:"id,attr,omitempty"`
-	Name        string    `xml:"name,omitempty"`
-	Description string    `xml:"description,omitempty"`
-	Open        bool      `xml:"open,omitempty"`
-	Visibility  *bool     `xml:"visibility,omitempty"`
-	Features    []Feature `xml:"-"`
+	ID          string     `xml:"id,attr,omitempty"`
+	Name        string     `xml:"name,omitempty"`
+	Description string     `xml:"description,omitempty"`
+	Open        bool       `xml:"open,omitempty"`
+	Visibility  *bool      `xml:"visibility,omitempty"`
+	LookAt      *LookAt    `xml:"LookAt,omitempty"`
+	Camera      *Camera    `xml:"Camera,omitempty"`
+	TimeStamp   *TimeStamp `xml:"TimeStamp,omitempty"`
+	TimeSpan    *TimeSpan  `xml:"TimeSpan,omitempty"`
+	StyleURL    string     `xml:"styleUrl,omitempty"`
+	Region      *Region    `xml:"Region,omitempty"`
+	Features    []Feature  `xml:"-"`
+	Comments    []string   `xml:"-"` // See PreserveComments
+
+	span *Span // See RecordSpans
 }
 
 // featureType implements the Feature interface
@@ -215,6 +358,9 @@ func (f *Folder) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
 		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "id"}, Value: f.ID})
 	}
 
+	if err := encodeComments(e, f.Comments); err != nil {
+		return err
+	}
 	if err := e.EncodeToken(start); err != nil {
 		return err
 	}
@@ -226,8 +372,12 @@ func (f *Folder) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
 		}
 	}
 
-	if f.Description != "" {
-		if err := e.EncodeElement(f.Description, xml.StartElement{Name: xml.Name{Local: "description"}}); err != nil {
+	if f.Visibility != nil {
+		vis := 0
+		if *f.Visibility {
+			vis = 1
+		}
+		if err := e.EncodeElement(vis, xml.StartElement{Name: xml.Name{Local: "visibility"}}); err != nil {
 			return err
 		}
 	}
@@ -238,12 +388,44 @@ func (f *Folder) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
 		}
 	}
 
-	if f.Visibility != nil {
-		vis := 0
-		if *f.Visibility {
-			vis = 1
+	if f.Description != "" {
+		if err := encodeDescription(e, f.Description); err != nil {
+			return err
 		}
-		if err := e.EncodeElement(vis, xml.StartElement{Name: xml.Name{Local: "visibility"}}); err != nil {
+	}
+
+	if f.LookAt != nil {
+		if err := e.Encode(f.LookAt); err != nil {
+			return err
+		}
+	}
+
+	if f.Camera != nil {
+		if err := e.Encode(f.Camera); err != nil {
+			return err
+		}
+	}
+
+	if f.TimeStamp != nil {
+		if err := e.Encode(f.TimeStamp); err != nil {
+			return err
+		}
+	}
+
+	if f.TimeSpan != nil {
+		if err := e.Encode(f.TimeSpan); err != nil {
+			return err
+		}
+	}
+
+	if f.StyleURL != "" {
+		if err := e.EncodeElement(f.StyleURL, xml.StartElement{Name: xml.Name{Local: "styleUrl"}}); err != nil {
+			return err
+		}
+	}
+
+	if f.Region != nil {
+		if err := e.Encode(f.Region); err != nil {
 			return err
 		}
 	}
@@ -263,14 +445,42 @@ func (f *Folder) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
 			if err := e.EncodeElement(feat, xml.StartElement{Name: xml.Name{Local: "Placemark"}}); err != nil {
 				return err
 			}
+		case *GroundOverlay:
+			if err := e.EncodeElement(feat, xml.StartElement{Name: xml.Name{Local: "GroundOverlay"}}); err != nil {
+				return err
+			}
+		case *NetworkLink:
+			if err := e.EncodeElement(feat, xml.StartElement{Name: xml.Name{Local: "NetworkLink"}}); err != nil {
+				return err
+			}
+		case *ScreenOverlay:
+			if err := e.EncodeElement(feat, xml.StartElement{Name: xml.Name{Local: "ScreenOverlay"}}); err != nil {
+				return err
+			}
+		case *Tour:
+			if err := e.EncodeElement(feat, xml.StartElement{Name: xml.Name{Local: "Tour"}}); err != nil {
+				return err
+			}
 		}
 	}
 
-	return e.EncodeToken(xml.EndElement{Name: start.Name})
+	if err := e.EncodeToken(xml.EndElement{Name: start.Name}); err != nil {
+		return err
+	}
+	featureWritten(e)
+	return nil
 }
 
 // UnmarshalXML implements custom XML unmarshaling for Folder
 func (f *Folder) UnmarshalXML(decoder *xml.Decoder, start xml.StartElement) error {
+	leave, err := enterNested(decoder, start)
+	if err != nil {
+		return err
+	}
+	defer leave()
+	f.Comments = takeComments(decoder)
+	beginSpan(decoder)
+
 	// Process attributes
 	for _, attr := range start.Attr {
 		if attr.Name.Local == "id" {
@@ -280,6 +490,7 @@ func (f *Folder) UnmarshalXML(decoder *xml.Decoder, start xml.StartElement) erro
 
 	// Process child elements
 	for {
+		markToken(decoder)
 		token, err := decoder.Token()
 		if err != nil {
 			if err == io.EOF {
@@ -289,7 +500,10 @@ func (f *Folder) UnmarshalXML(decoder *xml.Decoder, start xml.StartElement) erro
 		}
 
 		switch tok := token.(type) {
+		case xml.Comment:
+			keepComment(decoder, tok)
 		case xml.StartElement:
+			dropComments(decoder, tok)
 			switch tok.Name.Local {
 			case "name":
 				if err := decoder.DecodeElement(&f.Name, &tok); err != nil {
@@ -304,14 +518,48 @@ func (f *Folder) UnmarshalXML(decoder *xml.Decoder, start xml.StartElement) erro
 				if err := decoder.DecodeElement(&open, &tok); err != nil {
 					return err
 				}
-				f.Open = open != 0
+				f.Open = flagValue(decoder, tok, open)
 			case "visibility":
 				var vis int
 				if err := decoder.DecodeElement(&vis, &tok); err != nil {
 					return err
 				}
-				visibility := vis != 0
+				visibility := flagValue(decoder, tok, vis)
 				f.Visibility = &visibility
+			case "TimeStamp":
+				var ts TimeStamp
+				if err := decoder.DecodeElement(&ts, &tok); err != nil {
+					return err
+				}
+				f.TimeStamp = &ts
+			case "TimeSpan":
+				var ts TimeSpan
+				if err := decoder.DecodeElement(&ts, &tok); err != nil {
+					return err
+				}
+				f.TimeSpan = &ts
+			case "LookAt":
+				var lookAt LookAt
+				if err := decoder.DecodeElement(&lookAt, &tok); err != nil {
+					return err
+				}
+				f.LookAt = &lookAt
+			case "Camera":
+				var camera Camera
+				if err := decoder.DecodeElement(&camera, &tok); err != nil {
+					return err
+				}
+				f.Camera = &camera
+			case "styleUrl":
+				if err := decoder.DecodeElement(&f.StyleURL, &tok); err != nil {
+					return err
+				}
+			case "Region":
+				var region Region
+				if err := decoder.DecodeElement(&region, &tok); err != nil {
+					return err
+				}
+				f.Region = &region
 			case "Document":
 				var doc Document
 				if err := decoder.DecodeElement(&doc, &tok); err != nil {
@@ -330,14 +578,38 @@ func (f *Folder) UnmarshalXML(decoder *xml.Decoder, start xml.StartElement) erro
 					return err
 				}
 				f.Features = append(f.Features, &placemark)
+			case "GroundOverlay":
+				var overlay GroundOverlay
+				if err := decoder.DecodeElement(&overlay, &tok); err != nil {
+					return err
+				}
+				f.Features = append(f.Features, &overlay)
+			case "NetworkLink":
+				var link NetworkLink
+				if err := decoder.DecodeElement(&link, &tok); err != nil {
+					return err
+				}
+				f.Features = append(f.Features, &link)
+			case "ScreenOverlay":
+				var overlay ScreenOverlay
+				if err := decoder.DecodeElement(&overlay, &tok); err != nil {
+					return err
+				}
+				f.Features = append(f.Features, &overlay)
+			case "Tour":
+				var tour Tour
+				if err := decoder.DecodeElement(&tour, &tok); err != nil {
+					return err
+				}
+				f.Features = append(f.Features, &tour)
 			default:
-				// Skip unknown elements
-				if err := decoder.Skip(); err != nil {
+				if err := skipElement(decoder, tok); err != nil {
 					return err
 				}
 			}
 		case xml.EndElement:
 			if tok.Name.Local == "Folder" {
+				featureParsed(decoder, f)
 				return nil
 			}
 		}
@@ -345,3 +617,30 @@ func (f *Folder) UnmarshalXML(decoder *xml.Decoder, start xml.StartElement) erro
 
 	return nil
 }
+
+// encodeFeature writes a feature as the element matching its concrete type.
+func encodeFeature(e *xml.Encoder, f Feature) error {
+	return e.EncodeElement(f, xml.StartElement{Name: xml.Name{Local: f.featureType()}})
+}
+
+// newFeature returns an empty feature for a KML feature element name, or
+// nil if name is not a supported feature element.
+func newFeature(name string) Feature {
+	switch name {
+	case "Document":
+		return &Document{}
+	case "Folder":
+		return &Folder{}
+	case "Placemark":
+		return &Placemark{}
+	case "GroundOverlay":
+		return &GroundOverlay{}
+	case "NetworkLink":
+		return &NetworkLink{}
+	case "ScreenOverlay":
+		return &ScreenOverlay{}
+	case "Tour":
+		return &Tour{}
+	}
+	return nil
+}
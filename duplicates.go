@@ -0,0 +1,229 @@
+package kml
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// DuplicatePolicy decides what parsing does with IDs used by more than one
+// feature, or by more than one shared Style or StyleMap. Features and
+// styles are checked separately, as FindByID and styleUrl resolution look
+// them up separately. Elements are taken in document order, with the
+// Styles of a Document before its StyleMaps, the order they are written in.
+type DuplicatePolicy int
+
+const (
+	// DuplicateAllow keeps duplicate IDs as parsed. FindByID and styleUrls
+	// then find the first element with an ID.
+	DuplicateAllow DuplicatePolicy = iota
+	// DuplicateError fails parsing with a *ValidationError naming the
+	// first repeated ID.
+	DuplicateError
+	// DuplicateKeepFirst keeps the first element with an ID. Later
+	// features with the ID lose it and later styles with it are removed.
+	DuplicateKeepFirst
+	// DuplicateKeepLast keeps the last element with an ID. Earlier
+	// features with the ID lose it and earlier styles with it are removed.
+	DuplicateKeepLast
+	// DuplicateRename keeps every element, giving the second and later
+	// ones with an ID a new ID with a numeric suffix, such as "pin-2".
+	// StyleUrls continue to refer to the first style.
+	DuplicateRename
+)
+
+// String returns the name of the policy.
+func (p DuplicatePolicy) String() string {
+	switch p {
+	case DuplicateAllow:
+		return "allow"
+	case DuplicateError:
+		return "error"
+	case DuplicateKeepFirst:
+		return "keep first"
+	case DuplicateKeepLast:
+		return "keep last"
+	case DuplicateRename:
+		return "rename"
+	}
+	return "unknown"
+}
+
+// DuplicateID reports an element whose repeated ID was changed by a
+// DuplicatePolicy.
+type DuplicateID struct {
+	ID      string // The repeated ID
+	Element string // The element type, such as "Placemark" or "StyleMap"
+	NewID   string // The ID given by DuplicateRename; "" if it was cleared or the style removed
+}
+
+// String returns a one-line description of the change.
+func (d DuplicateID) String() string {
+	if d.NewID != "" {
+		return fmt.Sprintf("%s %q renamed to %q", d.Element, d.ID, d.NewID)
+	}
+	return fmt.Sprintf("%s %q dropped", d.Element, d.ID)
+}
+
+// DuplicateIDs applies policy to repeated IDs once the document has been
+// parsed. Unless report is nil, each element changed is appended to it.
+// Without this option duplicates are allowed.
+func DuplicateIDs(policy DuplicatePolicy, report *[]DuplicateID) ParseOption {
+	return func(c *parseConfig) {
+		c.duplicates = &duplicateConfig{policy: policy, report: report}
+	}
+}
+
+// duplicateConfig holds the settings of DuplicateIDs.
+type duplicateConfig struct {
+	policy DuplicatePolicy
+	report *[]DuplicateID
+}
+
+// idSlot is an element with an ID.
+type idSlot struct {
+	element string
+	id      *string
+	dropped bool
+}
+
+// applyDuplicates resolves the repeated IDs of k according to cfg.
+func applyDuplicates(k *KML, cfg *duplicateConfig) error {
+	if cfg.policy == DuplicateAllow {
+		return nil
+	}
+
+	var features, styles []idSlot
+	k.Walk(func(f Feature) error {
+		if id := featureIDField(f); id != nil && *id != "" {
+			features = append(features, idSlot{element: f.featureType(), id: id})
+		}
+		if doc, ok := f.(*Document); ok {
+			for i := range doc.Styles {
+				if doc.Styles[i].ID != "" {
+					styles = append(styles, idSlot{element: "Style", id: &doc.Styles[i].ID})
+				}
+			}
+			for i := range doc.StyleMaps {
+				if doc.StyleMaps[i].ID != "" {
+					styles = append(styles, idSlot{element: "StyleMap", id: &doc.StyleMaps[i].ID})
+				}
+			}
+		}
+		return nil
+	})
+
+	var changed []DuplicateID
+	for _, slots := range [][]idSlot{features, styles} {
+		c, err := resolveDuplicates(slots, cfg.policy)
+		if err != nil {
+			return err
+		}
+		changed = append(changed, c...)
+	}
+
+	dropped := make(map[*string]bool)
+	for _, s := range styles {
+		if s.dropped {
+			dropped[s.id] = true
+		}
+	}
+	if len(dropped) > 0 {
+		k.Walk(func(f Feature) error {
+			if doc, ok := f.(*Document); ok {
+				doc.Styles = removeStyles(doc.Styles, func(s *Style) bool { return dropped[&s.ID] })
+				doc.StyleMaps = removeStyles(doc.StyleMaps, func(sm *StyleMap) bool { return dropped[&sm.ID] })
+			}
+			return nil
+		})
+	}
+
+	if cfg.report != nil {
+		*cfg.report = append(*cfg.report, changed...)
+	}
+	return nil
+}
+
+// resolveDuplicates applies policy to slots sharing an ID namespace, in
+// document order, clearing the IDs of dropped elements and marking them.
+func resolveDuplicates(slots []idSlot, policy DuplicatePolicy) ([]DuplicateID, error) {
+	groups := make(map[string][]int)
+	var order []string
+	for i, s := range slots {
+		if _, ok := groups[*s.id]; !ok {
+			order = append(order, *s.id)
+		}
+		groups[*s.id] = append(groups[*s.id], i)
+	}
+
+	var changed []DuplicateID
+	for _, id := range order {
+		group := groups[id]
+		if len(group) < 2 {
+			continue
+		}
+		switch policy {
+		case DuplicateError:
+			s := slots[group[1]]
+			return nil, &ValidationError{Element: s.element, Field: "id", Message: fmt.Sprintf("duplicate id %q", id)}
+		case DuplicateKeepFirst:
+			group = group[1:]
+		case DuplicateKeepLast:
+			group = group[:len(group)-1]
+		case DuplicateRename:
+			n := 1
+			for _, i := range group[1:] {
+				var newID string
+				for {
+					n++
+					newID = id + "-" + strconv.Itoa(n)
+					if _, taken := groups[newID]; !taken {
+						break
+					}
+				}
+				groups[newID] = []int{i}
+				*slots[i].id = newID
+				changed = append(changed, DuplicateID{ID: id, Element: slots[i].element, NewID: newID})
+			}
+			continue
+		}
+		for _, i := range group {
+			*slots[i].id = ""
+			slots[i].dropped = true
+			changed = append(changed, DuplicateID{ID: id, Element: slots[i].element})
+		}
+	}
+	return changed, nil
+}
+
+// removeStyles returns styles without those for which drop reports true,
+// reusing the backing array.
+func removeStyles[T any](styles []T, drop func(*T) bool) []T {
+	kept := styles[:0]
+	for i := range styles {
+		if !drop(&styles[i]) {
+			kept = append(kept, styles[i])
+		}
+	}
+	return kept
+}
+
+// featureIDField returns the ID field of f.
+func featureIDField(f Feature) *string {
+	switch feature := f.(type) {
+	case *Document:
+		return &feature.ID
+	case *Folder:
+		return &feature.ID
+	case *Placemark:
+		return &feature.ID
+	case *GroundOverlay:
+		return &feature.ID
+	case *NetworkLink:
+		return &feature.ID
+	case *ScreenOverlay:
+		return &feature.ID
+	case *Tour:
+		return &feature.ID
+	}
+	return nil
+}
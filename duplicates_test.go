@@ -0,0 +1,120 @@
+package kml
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// duplicatesKML has repeated placemark, folder and style IDs, an ID-less
+// style and an ID already taken by a would-be rename
+const duplicatesKML = `<kml xmlns="http://www.opengis.net/kml/2.2"><Document>
+<Style id="pin"><IconStyle><scale>1</scale></IconStyle></Style>
+<Style><IconStyle><scale>9</scale></IconStyle></Style>
+<StyleMap id="pin"><Pair><key>normal</key><styleUrl>#x</styleUrl></Pair></StyleMap>
+<Style id="pin"><IconStyle><scale>3</scale></IconStyle></Style>
+<Placemark id="a"><name>first</name></Placemark>
+<Placemark id="a-2"><name>taken</name></Placemark>
+<Folder id="a"><name>second</name>
+  <Placemark id="a"><name>third</name></Placemark>
+</Folder>
+</Document></kml>`
+
+// featureIDs returns the IDs of the features in document order
+func featureIDs(k *KML) []string {
+	var ids []string
+	k.Walk(func(f Feature) error {
+		ids = append(ids, featureID(f))
+		return nil
+	})
+	return ids
+}
+
+// styleSummary describes the shared styles of the root document
+func styleSummary(k *KML) []string {
+	doc := k.Feature.(*Document)
+	var out []string
+	for _, s := range doc.Styles {
+		out = append(out, fmt.Sprintf("Style %s %g", s.ID, s.IconStyle.Scale))
+	}
+	for _, sm := range doc.StyleMaps {
+		out = append(out, "StyleMap "+sm.ID)
+	}
+	return out
+}
+
+// TestDuplicateIDs tests each policy on features and styles
+func TestDuplicateIDs(t *testing.T) {
+	tests := []struct {
+		policy     DuplicatePolicy
+		wantIDs    []string
+		wantStyles []string
+		wantReport []string
+	}{
+		{
+			DuplicateAllow,
+			[]string{"", "a", "a-2", "a", "a"},
+			[]string{"Style pin 1", "Style  9", "Style pin 3", "StyleMap pin"},
+			nil,
+		},
+		{
+			DuplicateKeepFirst,
+			[]string{"", "a", "a-2", "", ""},
+			[]string{"Style pin 1", "Style  9"},
+			[]string{`Folder "a" dropped`, `Placemark "a" dropped`, `Style "pin" dropped`, `StyleMap "pin" dropped`},
+		},
+		{
+			DuplicateKeepLast,
+			[]string{"", "", "a-2", "", "a"},
+			[]string{"Style  9", "StyleMap pin"},
+			[]string{`Placemark "a" dropped`, `Folder "a" dropped`, `Style "pin" dropped`, `Style "pin" dropped`},
+		},
+		{
+			DuplicateRename,
+			[]string{"", "a", "a-2", "a-3", "a-4"},
+			[]string{"Style pin 1", "Style  9", "Style pin-2 3", "StyleMap pin-3"},
+			[]string{`Folder "a" renamed to "a-3"`, `Placemark "a" renamed to "a-4"`, `Style "pin" renamed to "pin-2"`, `StyleMap "pin" renamed to "pin-3"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy.String(), func(t *testing.T) {
+			var report []DuplicateID
+			k, err := ParseBytes([]byte(duplicatesKML), DuplicateIDs(tt.policy, &report))
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if got := featureIDs(k); !reflect.DeepEqual(got, tt.wantIDs) {
+				t.Errorf("Expected feature IDs %q, got %q", tt.wantIDs, got)
+			}
+			if got := styleSummary(k); !reflect.DeepEqual(got, tt.wantStyles) {
+				t.Errorf("Expected styles %q, got %q", tt.wantStyles, got)
+			}
+			var got []string
+			for _, d := range report {
+				got = append(got, d.String())
+			}
+			if !reflect.DeepEqual(got, tt.wantReport) {
+				t.Errorf("Expected report %q, got %q", tt.wantReport, got)
+			}
+		})
+	}
+}
+
+// TestDuplicateIDsError tests the strict policy
+func TestDuplicateIDsError(t *testing.T) {
+	_, err := ParseBytes([]byte(duplicatesKML), DuplicateIDs(DuplicateError, nil))
+	var ve *ValidationError
+	if !errors.As(err, &ve) {
+		t.Fatalf("Expected a ValidationError, got %v", err)
+	}
+	if ve.Element != "Folder" || ve.Field != "id" || ve.Message != `duplicate id "a"` {
+		t.Errorf("Expected the folder's duplicate id, got %+v", ve)
+	}
+
+	unique := `<kml><Document><Placemark id="a"/><Placemark id="b"/><Style id="a"/></Document></kml>`
+	if _, err := ParseBytes([]byte(unique), DuplicateIDs(DuplicateError, nil)); err != nil {
+		t.Errorf("Expected features and styles to be checked separately, got %v", err)
+	}
+}
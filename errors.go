@@ -63,4 +63,26 @@ var (
 	// ErrMissingGeometry indicates that a Placemark has no associated geometry.
 	// Every Placemark should have at least one geometry element (Point, LineString, etc.).
 	ErrMissingGeometry = errors.New("kml: placemark has no geometry")
+
+	// ErrNoKMLInArchive indicates that a KMZ or zip archive contains no .kml file.
+	ErrNoKMLInArchive = errors.New("kml: archive contains no .kml file")
+
+	// ErrTooDeep indicates that Documents, Folders or MultiGeometries are
+	// nested more deeply than the MaxDepth limit.
+	ErrTooDeep = errors.New("kml: elements nested too deeply")
+
+	// ErrNoImage indicates that an overlay has no Icon href to load an
+	// image from.
+	ErrNoImage = errors.New("kml: overlay has no image")
+
+	// ErrImageNotFound indicates that an overlay image is neither in the
+	// KMZ archive the document was parsed from nor available from a Fetcher.
+	ErrImageNotFound = errors.New("kml: image not found")
+
+	// ErrNoHistory indicates that a Session has no step to undo or redo.
+	ErrNoHistory = errors.New("kml: no edit to undo or redo")
+
+	// ErrMissingKey indicates that a placemark given to Upsert has no
+	// value for the key field.
+	ErrMissingKey = errors.New("kml: placemark has no key field")
 )
@@ -0,0 +1,161 @@
+package kml
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Fix applies the fixes of the fixable findings, as returned by Lint for
+// doc, and returns how many findings were fixed. Each rule's fix runs once
+// per feature, resolving all of that rule's findings in it; findings for
+// features no longer in doc are skipped. Run Lint again afterwards to see
+// what remains.
+func Fix(doc *KML, findings []Finding) int {
+	inDoc := make(map[Feature]bool)
+	doc.Walk(func(f Feature) error {
+		inDoc[f] = true
+		return nil
+	})
+
+	type key struct {
+		rule    string
+		feature Feature
+	}
+	fixed := make(map[key]bool)
+	n := 0
+	for _, finding := range findings {
+		if finding.fix == nil || !inDoc[finding.Feature] {
+			continue
+		}
+		k := key{finding.Rule, finding.Feature}
+		ok, tried := fixed[k]
+		if !tried {
+			ok = finding.fix(finding.Feature)
+			fixed[k] = ok
+		}
+		if ok {
+			n++
+		}
+	}
+	return n
+}
+
+// DescriptionWhitespaceRule reports descriptions in which indentation and
+// runs of whitespace make up a tenth or more of the text, as is common for
+// balloon HTML pasted from templates. The fix collapses each run to a
+// single space and removes whitespace between tags, which HTML rendering
+// ignores; descriptions containing a pre element are left alone.
+func DescriptionWhitespaceRule() Rule {
+	return Rule{
+		Name:     "description-whitespace",
+		Severity: SeverityInfo,
+		Check: func(f Feature) []string {
+			desc := featureDescription(f)
+			if desc == nil || !compressible(*desc) {
+				return nil
+			}
+			saved := len(*desc) - len(compressDescription(*desc))
+			if saved*10 < len(*desc) {
+				return nil
+			}
+			return []string{strconv.Itoa(saved) + " of " + strconv.Itoa(len(*desc)) + " description bytes are redundant whitespace"}
+		},
+		Fix: func(f Feature) bool {
+			desc := featureDescription(f)
+			if desc == nil || !compressible(*desc) {
+				return false
+			}
+			*desc = compressDescription(*desc)
+			return true
+		},
+	}
+}
+
+// featureDescription returns a pointer to the description of f.
+func featureDescription(f Feature) *string {
+	switch feature := f.(type) {
+	case *Document:
+		return &feature.Description
+	case *Folder:
+		return &feature.Description
+	case *Placemark:
+		return &feature.Description
+	case *GroundOverlay:
+		return &feature.Description
+	case *NetworkLink:
+		return &feature.Description
+	case *ScreenOverlay:
+		return &feature.Description
+	case *Tour:
+		return &feature.Description
+	}
+	return nil
+}
+
+var (
+	preElement     = regexp.MustCompile(`(?i)<pre[\s>]`)
+	whitespaceRun  = regexp.MustCompile(`\s+`)
+	betweenTagsRun = regexp.MustCompile(`>\s+<`)
+)
+
+// compressible reports whether whitespace in desc can be collapsed without
+// changing how it renders.
+func compressible(desc string) bool {
+	return desc != "" && !preElement.MatchString(desc)
+}
+
+// compressDescription collapses the whitespace in desc.
+func compressDescription(desc string) string {
+	desc = betweenTagsRun.ReplaceAllString(desc, "><")
+	return strings.TrimSpace(whitespaceRun.ReplaceAllString(desc, " "))
+}
+
+// nameFromExtendedData returns the value of the first name, title or label
+// field of data, in that order of preference, or "".
+func nameFromExtendedData(data *ExtendedData) string {
+	if data == nil {
+		return ""
+	}
+	for _, field := range []string{"name", "title", "label"} {
+		for _, d := range data.Data {
+			if strings.EqualFold(d.Name, field) && strings.TrimSpace(d.Value) != "" {
+				return strings.TrimSpace(d.Value)
+			}
+		}
+		for _, sd := range data.SchemaData {
+			for _, d := range sd.SimpleData {
+				if strings.EqualFold(d.Name, field) && strings.TrimSpace(d.Value) != "" {
+					return strings.TrimSpace(d.Value)
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// roundGeometry rounds the coordinates of g to digits decimal places.
+func roundGeometry(g Geometry, digits int) {
+	round := func(v float64) float64 {
+		r, _ := strconv.ParseFloat(strconv.FormatFloat(v, 'f', digits, 64), 64)
+		return r
+	}
+	roundAll := func(c *Coordinate) {
+		c.Lon, c.Lat, c.Alt = round(c.Lon), round(c.Lat), round(c.Alt)
+	}
+
+	switch geom := g.(type) {
+	case *Point:
+		roundAll(&geom.Coordinates)
+	case *MultiGeometry:
+		for _, child := range geom.Geometries {
+			roundGeometry(child, digits)
+		}
+	default:
+		for _, s := range appendCoordSlices(nil, g) {
+			for i := range *s {
+				roundAll(&(*s)[i])
+			}
+		}
+	}
+}
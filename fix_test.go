@@ -0,0 +1,111 @@
+package kml
+
+import "testing"
+
+// TestFix tests applying the fixes of lint findings
+func TestFix(t *testing.T) {
+	named := &Placemark{
+		ExtendedData: &ExtendedData{SchemaData: []SchemaData{{SimpleData: []SimpleData{{Name: "Title", Value: " Well 7 "}}}}},
+		Geometry: &MultiGeometry{Geometries: []Geometry{
+			&Point{Coordinates: Coordinate{Lon: 1.123456789, Lat: 2.5}},
+			&LineString{Coordinates: []Coordinate{{Lon: 3.00000049, Lat: 4.1234567}, {Lon: 5, Lat: 6}}},
+		}},
+	}
+	unnamed := &Placemark{Geometry: &Point{}}
+	doc := &Document{
+		Name:        "Wells",
+		Description: "<table>\n    <tr>\n        <td>Depth</td>\n        <td>30   m</td>\n    </tr>\n</table>\n",
+		Features:    []Feature{named, unnamed},
+	}
+	k := NewKML()
+	k.Feature = doc
+
+	findings := Lint(k)
+	if n := Fix(k, findings); n != 3 {
+		t.Errorf("Expected 3 fixed findings, got %d", n)
+	}
+
+	if want := "<table><tr><td>Depth</td><td>30 m</td></tr></table>"; doc.Description != want {
+		t.Errorf("Expected description %q, got %q", want, doc.Description)
+	}
+	if named.Name != "Well 7" {
+		t.Errorf("Expected name from ExtendedData, got %q", named.Name)
+	}
+	geoms := named.Geometry.(*MultiGeometry).Geometries
+	if got := geoms[0].(*Point).Coordinates.Lon; got != 1.123457 {
+		t.Errorf("Expected rounded point longitude 1.123457, got %v", got)
+	}
+	if got := geoms[1].(*LineString).Coordinates[0]; got.Lon != 3 || got.Lat != 4.123457 {
+		t.Errorf("Expected rounded line coordinate 3,4.123457, got %v", got)
+	}
+
+	remaining := Lint(k)
+	if len(remaining) != 1 || remaining[0].Feature != unnamed || remaining[0].Rule != "missing-name" {
+		t.Errorf("Expected only the unnamed placemark to remain, got %v", remaining)
+	}
+}
+
+// TestFixableFindings tests marking only the findings a rule can fix
+func TestFixableFindings(t *testing.T) {
+	titled := &Placemark{ExtendedData: &ExtendedData{Data: []Data{{Name: "name", Value: "Well 7"}}}}
+	bare := &Placemark{}
+	folder := &Folder{Features: []Feature{titled, bare}}
+	k := NewKML()
+	k.Feature = folder
+
+	findings := Lint(k, MissingNameRule())
+	if len(findings) != 3 {
+		t.Fatalf("Expected 3 findings, got %v", findings)
+	}
+	for _, f := range findings {
+		if want := f.Feature == Feature(titled); f.Fixable != want {
+			t.Errorf("Expected %s fixable %v, got %v", f, want, f.Fixable)
+		}
+	}
+	if n := Fix(k, findings); n != 1 {
+		t.Errorf("Expected 1 fixed finding, got %d", n)
+	}
+	if titled.Name != "Well 7" || bare.Name != "" || folder.Name != "" {
+		t.Errorf("Expected only the placemark with a name field named, got %q, %q, %q", titled.Name, bare.Name, folder.Name)
+	}
+}
+
+// TestFixSkipsForeignFindings tests ignoring findings from other documents
+func TestFixSkipsForeignFindings(t *testing.T) {
+	other := NewKML()
+	other.Feature = &Placemark{Geometry: &Point{Coordinates: Coordinate{Lon: 1.123456789}}}
+	findings := Lint(other, CoordinatePrecisionRule(6))
+
+	k := NewKML()
+	k.Feature = &Document{Name: "D"}
+	if n := Fix(k, findings); n != 0 {
+		t.Errorf("Expected no fixes, got %d", n)
+	}
+	if got := other.Feature.(*Placemark).Geometry.(*Point).Coordinates.Lon; got != 1.123456789 {
+		t.Errorf("Expected the other document to be unchanged, got %v", got)
+	}
+}
+
+// TestDescriptionWhitespaceRule tests which descriptions are reported
+func TestDescriptionWhitespaceRule(t *testing.T) {
+	tests := []struct {
+		name string
+		desc string
+		want bool
+	}{
+		{"tidy", "<b>Depth</b> 30 m", false},
+		{"indented", "<div>\n      <b>Depth</b>\n      30 m\n</div>", true},
+		{"minor", "A long sentence describing the well in plain prose, with  one double space.", false},
+		{"pre", "<pre>\n    keep\n        this\n</pre>", false},
+	}
+
+	rule := DescriptionWhitespaceRule()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := len(rule.Check(&Placemark{Description: tt.desc})) > 0
+			if got != tt.want {
+				t.Errorf("Expected reported %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,243 @@
+package kml
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Format reindents a KML (or any XML) document without decoding it into
+// the package's types, so elements the package does not support, comments,
+// CDATA sections and processing instructions are kept byte for byte. Each
+// element, comment and processing instruction starts on its own line,
+// indented by one copy of indent per level of nesting. Whitespace between
+// elements is replaced; the content of elements holding only text and
+// CDATA, such as coordinates and descriptions, is kept exactly, as is any
+// element mixing text with child elements.
+//
+// Format returns an error if the input is not well-formed: an unterminated
+// construct, or an end tag that does not match its start tag.
+func Format(in []byte, indent string) ([]byte, error) {
+	tokens, err := scanXML(in)
+	if err != nil {
+		return nil, err
+	}
+	root, err := buildXMLTree(tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &formatter{src: in, indent: indent}
+	for _, n := range root.children {
+		if n.kind == xmlText && isSpace(n.raw) {
+			continue
+		}
+		f.node(n, 0)
+	}
+	return f.out.Bytes(), nil
+}
+
+// xmlKind classifies the lexical constructs of an XML document.
+type xmlKind int
+
+const (
+	xmlText xmlKind = iota
+	xmlCDATA
+	xmlComment
+	xmlProcInst // Processing instruction or XML declaration
+	xmlDirective
+	xmlStart
+	xmlEnd
+	xmlEmpty // Self-closing element
+)
+
+// xmlToken is one construct of the input, with its position.
+type xmlToken struct {
+	kind       xmlKind
+	raw        []byte
+	name       string // Element name for start, end and empty tags
+	start, end int
+}
+
+// scanXML splits in into constructs.
+func scanXML(in []byte) ([]xmlToken, error) {
+	var tokens []xmlToken
+	for i := 0; i < len(in); {
+		if in[i] != '<' {
+			j := bytes.IndexByte(in[i:], '<')
+			if j < 0 {
+				j = len(in) - i
+			}
+			tokens = append(tokens, xmlToken{kind: xmlText, raw: in[i : i+j], start: i, end: i + j})
+			i += j
+			continue
+		}
+
+		var kind xmlKind
+		var end int
+		rest := in[i:]
+		switch {
+		case bytes.HasPrefix(rest, []byte("<!--")):
+			kind, end = xmlComment, closeAfter(rest, "-->")
+		case bytes.HasPrefix(rest, []byte("<![CDATA[")):
+			kind, end = xmlCDATA, closeAfter(rest, "]]>")
+		case bytes.HasPrefix(rest, []byte("<?")):
+			kind, end = xmlProcInst, closeAfter(rest, "?>")
+		case bytes.HasPrefix(rest, []byte("<!")):
+			kind, end = xmlDirective, tagEnd(rest)
+		case bytes.HasPrefix(rest, []byte("</")):
+			kind, end = xmlEnd, tagEnd(rest)
+		default:
+			kind, end = xmlStart, tagEnd(rest)
+		}
+		if end < 0 {
+			line := bytes.Count(in[:i], []byte("\n")) + 1
+			return nil, fmt.Errorf("kml: unterminated markup at line %d", line)
+		}
+
+		tok := xmlToken{kind: kind, raw: rest[:end], start: i, end: i + end}
+		if kind == xmlStart || kind == xmlEnd {
+			if kind == xmlStart && bytes.HasSuffix(tok.raw, []byte("/>")) {
+				tok.kind = xmlEmpty
+			}
+			tok.name = tagName(tok.raw)
+		}
+		tokens = append(tokens, tok)
+		i += end
+	}
+	return tokens, nil
+}
+
+// closeAfter returns the length of s up to and including the first
+// occurrence of delim, or -1.
+func closeAfter(s []byte, delim string) int {
+	if j := bytes.Index(s, []byte(delim)); j >= 0 {
+		return j + len(delim)
+	}
+	return -1
+}
+
+// tagEnd returns the length of the tag at the start of s, skipping '>'
+// inside quoted attribute values, or -1.
+func tagEnd(s []byte) int {
+	var quote byte
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '>':
+			return i + 1
+		}
+	}
+	return -1
+}
+
+// tagName returns the qualified name of a start or end tag.
+func tagName(tag []byte) string {
+	name := bytes.TrimLeft(tag, "</")
+	end := bytes.IndexAny(name, " \t\r\n/>")
+	if end >= 0 {
+		name = name[:end]
+	}
+	return string(name)
+}
+
+// xmlNode is an element or other construct in the document tree.
+type xmlNode struct {
+	xmlToken
+	endTag   *xmlToken
+	children []*xmlNode
+}
+
+// buildXMLTree nests the tokens into elements and returns a root node
+// holding the top-level constructs.
+func buildXMLTree(tokens []xmlToken) (*xmlNode, error) {
+	root := &xmlNode{}
+	stack := []*xmlNode{root}
+	for i := range tokens {
+		tok := tokens[i]
+		parent := stack[len(stack)-1]
+		switch tok.kind {
+		case xmlStart:
+			n := &xmlNode{xmlToken: tok}
+			parent.children = append(parent.children, n)
+			stack = append(stack, n)
+		case xmlEnd:
+			if len(stack) == 1 || parent.name != tok.name {
+				return nil, fmt.Errorf("kml: unexpected end tag </%s>", tok.name)
+			}
+			parent.endTag = &tokens[i]
+			stack = stack[:len(stack)-1]
+		default:
+			parent.children = append(parent.children, &xmlNode{xmlToken: tok})
+		}
+	}
+	if len(stack) > 1 {
+		return nil, fmt.Errorf("kml: element <%s> is not closed", stack[len(stack)-1].name)
+	}
+	return root, nil
+}
+
+// formatter writes the reindented tree.
+type formatter struct {
+	src    []byte
+	indent string
+	out    bytes.Buffer
+}
+
+// line writes raw on its own line at the given depth.
+func (f *formatter) line(depth int, raw ...[]byte) {
+	for i := 0; i < depth; i++ {
+		f.out.WriteString(f.indent)
+	}
+	for _, r := range raw {
+		f.out.Write(r)
+	}
+	f.out.WriteByte('\n')
+}
+
+// node writes one construct and, for elements, its content.
+func (f *formatter) node(n *xmlNode, depth int) {
+	if n.kind != xmlStart {
+		f.line(depth, n.raw)
+		return
+	}
+
+	textOnly, mixed := true, false
+	for _, c := range n.children {
+		switch c.kind {
+		case xmlText:
+			if !isSpace(c.raw) {
+				mixed = true
+			}
+		case xmlCDATA:
+		default:
+			textOnly = false
+		}
+	}
+
+	switch {
+	case textOnly:
+		f.line(depth, f.src[n.start:n.endTag.end])
+	case mixed:
+		// Text alongside child elements is significant; keep it as written
+		f.line(depth, f.src[n.start:n.endTag.end])
+	default:
+		f.line(depth, n.raw)
+		for _, c := range n.children {
+			if c.kind == xmlText {
+				continue
+			}
+			f.node(c, depth+1)
+		}
+		f.line(depth, n.endTag.raw)
+	}
+}
+
+// isSpace reports whether b holds only XML whitespace.
+func isSpace(b []byte) bool {
+	return len(bytes.Trim(b, " \t\r\n")) == 0
+}
@@ -0,0 +1,70 @@
+package kml
+
+import (
+	"testing"
+)
+
+// TestFormat tests reindenting at the token level
+func TestFormat(t *testing.T) {
+	in := `<?xml version="1.0" encoding="UTF-8"?>
+<!-- survey export --><kml xmlns="http://www.opengis.net/kml/2.2" xmlns:x="urn:x"><Document>
+<name>Sites</name><x:custom a="1 > 0"><x:inner/></x:custom>
+        <Placemark><!-- checked 2024 --><description><![CDATA[<b>bold</b>]]></description>
+<Point><coordinates>
+  1,2,0
+</coordinates></Point><Snippet maxLines="2">Mixed <b>text</b> here</Snippet></Placemark></Document></kml>`
+
+	want := `<?xml version="1.0" encoding="UTF-8"?>
+<!-- survey export -->
+<kml xmlns="http://www.opengis.net/kml/2.2" xmlns:x="urn:x">
+  <Document>
+    <name>Sites</name>
+    <x:custom a="1 > 0">
+      <x:inner/>
+    </x:custom>
+    <Placemark>
+      <!-- checked 2024 -->
+      <description><![CDATA[<b>bold</b>]]></description>
+      <Point>
+        <coordinates>
+  1,2,0
+</coordinates>
+      </Point>
+      <Snippet maxLines="2">Mixed <b>text</b> here</Snippet>
+    </Placemark>
+  </Document>
+</kml>
+`
+
+	got, err := Format([]byte(in), "  ")
+	if err != nil {
+		t.Fatalf("Format failed: %v", err)
+	}
+	if string(got) != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s", want, got)
+	}
+
+	again, err := Format(got, "  ")
+	if err != nil {
+		t.Fatalf("Format failed: %v", err)
+	}
+	if string(again) != string(got) {
+		t.Errorf("Expected formatting to be idempotent, got:\n%s", again)
+	}
+}
+
+// TestFormatErrors tests malformed input
+func TestFormatErrors(t *testing.T) {
+	tests := map[string]string{
+		"unterminated comment": `<kml><!-- never closed</kml>`,
+		"unterminated tag":     `<kml><Placemark name="a`,
+		"mismatched end tag":   `<kml><Document></Folder></kml>`,
+		"unclosed element":     `<kml><Document>`,
+		"stray end tag":        `</kml>`,
+	}
+	for name, in := range tests {
+		if _, err := Format([]byte(in), "  "); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package kml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ParseFragment parses a single KML element that is not wrapped in a kml
+// root, as found in API payloads and NetworkLinkControl Update blocks. It
+// returns the typed object for the element:
+//
+//   - *Document, *Folder, *Placemark, *GroundOverlay, *ScreenOverlay,
+//     *NetworkLink or *Tour for features
+//   - *Point, *LineString, *LinearRing, *Polygon, *MultiGeometry or *Track
+//     for geometries
+//   - *Style or *StyleMap for shared styles
+//   - *LookAt or *Camera for views
+//   - *KML if the input is a complete document
+//
+// The fragment must contain exactly one root element; use ParseFragments
+// for inputs with several.
+func ParseFragment(data []byte, opts ...ParseOption) (any, error) {
+	objs, err := ParseFragments(data, opts...)
+	if err != nil {
+		return nil, err
+	}
+	switch len(objs) {
+	case 0:
+		return nil, ErrEmptyDocument
+	case 1:
+		return objs[0], nil
+	}
+	return nil, fmt.Errorf("kml: fragment has %d root elements, expected 1", len(objs))
+}
+
+// ParseFragments parses a sequence of sibling KML elements without a kml
+// root and returns their typed objects in document order. See ParseFragment
+// for the supported elements.
+func ParseFragments(data []byte, opts ...ParseOption) ([]any, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	defer withParseConfig(d, opts)()
+
+	var objs []any
+	for {
+		markToken(d)
+		tok, err := d.Token()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return objs, nil
+			}
+			line, col := d.InputPos()
+			return nil, &ParseError{Line: line, Column: col, Message: "error reading KML fragment", Cause: err}
+		}
+
+		if c, ok := tok.(xml.Comment); ok {
+			keepComment(d, c)
+			continue
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		dropComments(d, start)
+
+		line, col := d.InputPos()
+		obj := newFragmentObject(start.Name.Local)
+		if obj == nil {
+			return nil, &ParseError{Line: line, Column: col, Message: "unsupported fragment element " + start.Name.Local}
+		}
+		if err := d.DecodeElement(obj, &start); err != nil {
+			return nil, &ParseError{Line: line, Column: col, Message: "error parsing " + start.Name.Local + " element", Cause: err}
+		}
+		objs = append(objs, obj)
+	}
+}
+
+// newFragmentObject returns an empty object for a fragment root element,
+// or nil if the element is not supported.
+func newFragmentObject(name string) any {
+	if f := newFeature(name); f != nil {
+		return f
+	}
+	if g := newGeometry(name); g != nil {
+		return g
+	}
+	switch name {
+	case "Style":
+		return &Style{}
+	case "StyleMap":
+		return &StyleMap{}
+	case "LookAt":
+		return &LookAt{}
+	case "Camera":
+		return &Camera{}
+	case "kml":
+		return &KML{}
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package kml
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestParseFragment(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		wantType string
+	}{
+		{"placemark", `<Placemark><name>A</name><Point><coordinates>1,2</coordinates></Point></Placemark>`, "*kml.Placemark"},
+		{"folder", `<Folder><name>F</name><Placemark/></Folder>`, "*kml.Folder"},
+		{"document", `<Document><name>D</name></Document>`, "*kml.Document"},
+		{"point", `<Point><coordinates>1,2,3</coordinates></Point>`, "*kml.Point"},
+		{"polygon", `<Polygon><outerBoundaryIs><LinearRing><coordinates>0,0 1,0 1,1 0,0</coordinates></LinearRing></outerBoundaryIs></Polygon>`, "*kml.Polygon"},
+		{"style", `<Style id="s"><LineStyle><width>2</width></LineStyle></Style>`, "*kml.Style"},
+		{"styleMap", `<StyleMap id="m"><Pair><key>normal</key><styleUrl>#s</styleUrl></Pair></StyleMap>`, "*kml.StyleMap"},
+		{"full document", `<?xml version="1.0"?><kml xmlns="http://www.opengis.net/kml/2.2"><Placemark/></kml>`, "*kml.KML"},
+		{"namespaced", `<Placemark xmlns="http://www.opengis.net/kml/2.2"><name>NS</name></Placemark>`, "*kml.Placemark"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj, err := ParseFragment([]byte(tt.data))
+			if err != nil {
+				t.Fatalf("ParseFragment failed: %v", err)
+			}
+			if got := fmt.Sprintf("%T", obj); got != tt.wantType {
+				t.Errorf("Expected %s, got %s", tt.wantType, got)
+			}
+		})
+	}
+}
+
+func TestParseFragmentContents(t *testing.T) {
+	obj, err := ParseFragment([]byte(`
+		<Placemark id="pm1">
+			<name>Office</name>
+			<Point><coordinates>-122.08,37.42,0</coordinates></Point>
+		</Placemark>`))
+	if err != nil {
+		t.Fatalf("ParseFragment failed: %v", err)
+	}
+	pm := obj.(*Placemark)
+	if pm.ID != "pm1" || pm.Name != "Office" {
+		t.Errorf("Expected placemark pm1 'Office', got %q %q", pm.ID, pm.Name)
+	}
+	if pt, ok := pm.Geometry.(*Point); !ok || pt.Coordinates.Lon != -122.08 {
+		t.Errorf("Expected point geometry, got %#v", pm.Geometry)
+	}
+}
+
+func TestParseFragments(t *testing.T) {
+	objs, err := ParseFragments([]byte(`<Placemark><name>A</name></Placemark>
+		<Style id="s"/>
+		<Placemark><name>B</name></Placemark>`))
+	if err != nil {
+		t.Fatalf("ParseFragments failed: %v", err)
+	}
+	if len(objs) != 3 {
+		t.Fatalf("Expected 3 objects, got %d", len(objs))
+	}
+	if pm, ok := objs[2].(*Placemark); !ok || pm.Name != "B" {
+		t.Errorf("Expected third object to be placemark B, got %#v", objs[2])
+	}
+}
+
+func TestParseFragmentErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"empty", ""},
+		{"multiple roots", `<Placemark/><Placemark/>`},
+		{"unsupported", `<Model/>`},
+		{"malformed", `<Placemark><name>A</Placemark>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseFragment([]byte(tt.data)); err == nil {
+				t.Error("Expected error")
+			}
+		})
+	}
+
+	_, err := ParseFragment([]byte("\n\n<Model/>"))
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("Expected *ParseError, got %T", err)
+	}
+	if parseErr.Line != 3 {
+		t.Errorf("Expected error on line 3, got %d", parseErr.Line)
+	}
+}
@@ -0,0 +1,53 @@
+package kml
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Geocoder resolves a free-form address to a geographic coordinate.
+type Geocoder interface {
+	Geocode(ctx context.Context, address string) (Coordinate, error)
+}
+
+// GeocoderFunc adapts an ordinary function to the Geocoder interface.
+type GeocoderFunc func(ctx context.Context, address string) (Coordinate, error)
+
+// Geocode calls f(ctx, address).
+func (f GeocoderFunc) Geocode(ctx context.Context, address string) (Coordinate, error) {
+	return f(ctx, address)
+}
+
+// Geocode resolves every placemark that has an address but no geometry into
+// a Point, using g. The KML specification allows such address-only
+// placemarks; after this pass they become spatially usable.
+//
+// Placemarks are geocoded in document order. Geocode stops at the first
+// error, including cancellation of ctx, and returns it together with the
+// number of placemarks resolved so far.
+func (k *KML) Geocode(ctx context.Context, g Geocoder) (int, error) {
+	k.ResetBounds()
+	resolved := 0
+
+	err := k.Walk(func(f Feature) error {
+		pm, ok := f.(*Placemark)
+		if !ok || pm.Geometry != nil || strings.TrimSpace(pm.Address) == "" {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		c, err := g.Geocode(ctx, pm.Address)
+		if err != nil {
+			return fmt.Errorf("kml: error geocoding address %q: %w", pm.Address, err)
+		}
+
+		pm.Geometry = &Point{Coordinates: c}
+		resolved++
+		return nil
+	})
+
+	return resolved, err
+}
@@ -0,0 +1,115 @@
+package kml
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// TestGeocode tests resolving address-only placemarks into Points
+func TestGeocode(t *testing.T) {
+	kmlData := `<?xml version="1.0" encoding="UTF-8"?>
+<kml xmlns="http://www.opengis.net/kml/2.2">
+  <Document>
+    <Placemark>
+      <name>Google</name>
+      <address>1600 Amphitheatre Pkwy, Mountain View, CA</address>
+    </Placemark>
+    <Placemark>
+      <name>Has Geometry</name>
+      <address>Somewhere else</address>
+      <Point><coordinates>1,2</coordinates></Point>
+    </Placemark>
+    <Placemark>
+      <name>No Address</name>
+    </Placemark>
+  </Document>
+</kml>`
+
+	k, err := ParseBytes([]byte(kmlData))
+	if err != nil {
+		t.Fatalf("Failed to parse KML: %v", err)
+	}
+
+	var calls []string
+	g := GeocoderFunc(func(ctx context.Context, address string) (Coordinate, error) {
+		calls = append(calls, address)
+		return Coord(-122.084, 37.422), nil
+	})
+
+	n, err := k.Geocode(context.Background(), g)
+	if err != nil {
+		t.Fatalf("Geocode() error: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("Expected 1 resolved placemark, got %d", n)
+	}
+	if len(calls) != 1 || calls[0] != "1600 Amphitheatre Pkwy, Mountain View, CA" {
+		t.Errorf("Unexpected geocoder calls: %v", calls)
+	}
+
+	pms := k.Placemarks()
+	pt, ok := pms[0].Geometry.(*Point)
+	if !ok {
+		t.Fatalf("Expected Point geometry, got %T", pms[0].Geometry)
+	}
+	if pt.Coordinates != Coord(-122.084, 37.422) {
+		t.Errorf("Unexpected coordinates: %v", pt.Coordinates)
+	}
+	if got := pms[1].Geometry.(*Point).Coordinates; got != Coord(1, 2) {
+		t.Errorf("Expected existing geometry to be kept, got %v", got)
+	}
+	if pms[2].Geometry != nil {
+		t.Errorf("Expected placemark without address to stay empty, got %T", pms[2].Geometry)
+	}
+}
+
+// TestGeocodeError tests that geocoding stops at the first failure
+func TestGeocodeError(t *testing.T) {
+	k := NewKML()
+	k.Feature = &Document{Features: []Feature{
+		&Placemark{Address: "first"},
+		&Placemark{Address: "second"},
+	}}
+
+	errNotFound := errors.New("not found")
+	calls := 0
+	g := GeocoderFunc(func(ctx context.Context, address string) (Coordinate, error) {
+		calls++
+		return Coordinate{}, errNotFound
+	})
+
+	n, err := k.Geocode(context.Background(), g)
+	if !errors.Is(err, errNotFound) {
+		t.Errorf("Expected errNotFound, got %v", err)
+	}
+	if n != 0 || calls != 1 {
+		t.Errorf("Expected to stop after first call, got n=%d calls=%d", n, calls)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := k.Geocode(ctx, g); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+// TestAddressRoundTrip tests that the address element survives a write/parse cycle
+func TestAddressRoundTrip(t *testing.T) {
+	k := NewKML()
+	k.Feature = &Placemark{Name: "HQ", Address: "1 Main St"}
+
+	data, err := k.Bytes()
+	if err != nil {
+		t.Fatalf("Failed to write KML: %v", err)
+	}
+
+	parsed, err := ParseBytes(data)
+	if err != nil {
+		t.Fatalf("Failed to parse KML: %v", err)
+	}
+
+	if got := parsed.Feature.(*Placemark).Address; got != "1 Main St" {
+		t.Errorf("Expected address '1 Main St', got %q", got)
+	}
+}
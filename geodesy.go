@@ -0,0 +1,118 @@
+package kml
+
+import "math"
+
+// earthRadius is the mean radius of the Earth in meters, used for spherical
+// (great-circle) calculations.
+const earthRadius = 6371008.8
+
+// toRadians converts degrees to radians.
+func toRadians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+// toDegrees converts radians to degrees.
+func toDegrees(rad float64) float64 {
+	return rad * 180 / math.Pi
+}
+
+// haversine returns the great-circle distance in meters between a and b.
+// Altitude is ignored.
+func haversine(a, b Coordinate) float64 {
+	return earthRadius * angularDistance(a, b)
+}
+
+// angularDistance returns the central angle in radians between a and b.
+func angularDistance(a, b Coordinate) float64 {
+	lat1, lat2 := toRadians(a.Lat), toRadians(b.Lat)
+	dLat := lat2 - lat1
+	dLon := toRadians(b.Lon - a.Lon)
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * math.Atan2(math.Sqrt(h), math.Sqrt(1-h))
+}
+
+// intermediatePoint returns the point at fraction f (0..1) along the great
+// circle from a to b. Altitude is interpolated linearly. Antipodal points
+// are joined by every great circle through them, so for those, and points
+// too close to antipodal for the interpolation to be accurate, the path
+// leaves a due north.
+func intermediatePoint(a, b Coordinate, f float64) Coordinate {
+	d := angularDistance(a, b)
+	alt := a.Alt + (b.Alt-a.Alt)*f
+	if d == 0 {
+		return Coordinate{Lon: a.Lon, Lat: a.Lat, Alt: alt}
+	}
+
+	lat1, lon1 := toRadians(a.Lat), toRadians(a.Lon)
+	lat2, lon2 := toRadians(b.Lat), toRadians(b.Lon)
+
+	var x, y, z float64
+	if math.Pi-d < 1e-9 {
+		// Rotate a by f*d toward the unit vector pointing north from it.
+		ca, sa := math.Cos(f*d), math.Sin(f*d)
+		x = ca*math.Cos(lat1)*math.Cos(lon1) - sa*math.Sin(lat1)*math.Cos(lon1)
+		y = ca*math.Cos(lat1)*math.Sin(lon1) - sa*math.Sin(lat1)*math.Sin(lon1)
+		z = ca*math.Sin(lat1) + sa*math.Cos(lat1)
+	} else {
+		wa := math.Sin((1-f)*d) / math.Sin(d)
+		wb := math.Sin(f*d) / math.Sin(d)
+
+		x = wa*math.Cos(lat1)*math.Cos(lon1) + wb*math.Cos(lat2)*math.Cos(lon2)
+		y = wa*math.Cos(lat1)*math.Sin(lon1) + wb*math.Cos(lat2)*math.Sin(lon2)
+		z = wa*math.Sin(lat1) + wb*math.Sin(lat2)
+	}
+
+	return Coordinate{
+		Lon: toDegrees(math.Atan2(y, x)),
+		Lat: toDegrees(math.Atan2(z, math.Sqrt(x*x+y*y))),
+		Alt: alt,
+	}
+}
+
+// Densify returns a copy of the LineString with intermediate great-circle
+// points inserted so that no segment is longer than maxSegmentMeters.
+// Long segments then follow the geodesic when rendered clamped to ground
+// instead of cutting across the globe as straight chords.
+//
+// Original vertices are kept. If maxSegmentMeters is not positive, the copy
+// has the same coordinates as the original.
+func (ls *LineString) Densify(maxSegmentMeters float64) *LineString {
+	out := *ls
+	out.Coordinates = densifyCoordinates(ls.Coordinates, maxSegmentMeters)
+	return &out
+}
+
+// densifyCoordinates implements Densify on a coordinate slice.
+func densifyCoordinates(coords []Coordinate, maxSegmentMeters float64) []Coordinate {
+	if maxSegmentMeters <= 0 || len(coords) < 2 {
+		return append([]Coordinate(nil), coords...)
+	}
+
+	result := []Coordinate{coords[0]}
+	for i := 1; i < len(coords); i++ {
+		a, b := coords[i-1], coords[i]
+		n := int(math.Ceil(haversine(a, b) / maxSegmentMeters))
+		for j := 1; j < n; j++ {
+			result = append(result, intermediatePoint(a, b, float64(j)/float64(n)))
+		}
+		result = append(result, b)
+	}
+
+	return result
+}
+
+// Circle returns a polygon approximating the circle of radius meters around
+// center with the given number of segments, at least three. Vertices are
+// placed along WGS84 geodesics, starting due north and running clockwise,
+// and the ring is closed; they keep the altitude of center.
+func Circle(center Coordinate, radius float64, segments int) *Polygon {
+	segments = max(segments, 3)
+	ring := make([]Coordinate, segments+1)
+	for i := range segments {
+		ring[i] = center.Destination(360*float64(i)/float64(segments), radius)
+	}
+	ring[segments] = ring[0]
+	return &Polygon{OuterBoundary: LinearRing{Coordinates: ring}}
+}
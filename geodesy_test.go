@@ -0,0 +1,153 @@
+package kml
+
+import (
+	"math"
+	"testing"
+)
+
+// TestHaversine tests great-circle distances against known values
+func TestHaversine(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b Coordinate
+		want float64 // meters
+		tol  float64
+	}{
+		{"same point", Coord(10, 20), Coord(10, 20), 0, 1e-9},
+		{"one degree of latitude", Coord(0, 0), Coord(0, 1), 111195, 1},
+		{"quarter of equator", Coord(0, 0), Coord(90, 0), math.Pi / 2 * earthRadius, 1e-6},
+		{"antipodal", Coord(0, 0), Coord(180, 0), math.Pi * earthRadius, 1e-6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := haversine(tt.a, tt.b); math.Abs(got-tt.want) > tt.tol {
+				t.Errorf("haversine() = %f, want %f", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestLineStringDensify tests insertion of great-circle points into long segments
+func TestLineStringDensify(t *testing.T) {
+	ls := &LineString{
+		Tessellate:  true,
+		Coordinates: []Coordinate{Coord(0, 0, 0), Coord(90, 0, 900)},
+	}
+
+	// Quarter of the equator split into segments of at most 1000 km
+	dense := ls.Densify(1000000)
+
+	if !dense.Tessellate {
+		t.Error("Expected LineString attributes to be preserved")
+	}
+	if len(ls.Coordinates) != 2 {
+		t.Error("Expected original LineString to be unchanged")
+	}
+
+	n := len(dense.Coordinates)
+	if n != 12 {
+		t.Fatalf("Expected 12 coordinates, got %d", n)
+	}
+	if dense.Coordinates[0] != ls.Coordinates[0] || dense.Coordinates[n-1] != ls.Coordinates[1] {
+		t.Error("Expected original endpoints to be kept")
+	}
+
+	for i := 1; i < n; i++ {
+		a, b := dense.Coordinates[i-1], dense.Coordinates[i]
+		if d := haversine(a, b); d > 1000000+1e-6 {
+			t.Errorf("segment %d is %f m long", i, d)
+		}
+		if math.Abs(b.Lat) > 1e-9 {
+			t.Errorf("point %d left the equator: %v", i, b)
+		}
+		if b.Alt < a.Alt {
+			t.Errorf("point %d altitude not interpolated: %v", i, b)
+		}
+	}
+}
+
+// TestLineStringDensifyGreatCircle tests that inserted points follow the geodesic
+func TestLineStringDensifyGreatCircle(t *testing.T) {
+	// A great circle between two points at 60N bulges toward the pole.
+	ls := &LineString{Coordinates: []Coordinate{Coord(-60, 60), Coord(60, 60)}}
+
+	dense := ls.Densify(100000)
+	mid := dense.Coordinates[len(dense.Coordinates)/2]
+
+	if mid.Lat <= 60 {
+		t.Errorf("Expected midpoint latitude above 60, got %f", mid.Lat)
+	}
+}
+
+// TestLineStringDensifyAntipodal tests densifying between antipodal points
+func TestLineStringDensifyAntipodal(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b Coordinate
+	}{
+		{"equator", Coord(0, 0), Coord(180, 0)},
+		{"poles", Coord(0, 90), Coord(0, -90)},
+		{"offset", Coord(-75, 40), Coord(105, -40)},
+	}
+
+	max := math.Pi * earthRadius / 4
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dense := (&LineString{Coordinates: []Coordinate{tt.a, tt.b}}).Densify(max)
+			if len(dense.Coordinates) != 5 {
+				t.Fatalf("Expected 5 coordinates, got %v", dense.Coordinates)
+			}
+			for i := 1; i < len(dense.Coordinates); i++ {
+				a, b := dense.Coordinates[i-1], dense.Coordinates[i]
+				if math.IsNaN(b.Lon) || math.IsNaN(b.Lat) {
+					t.Fatalf("Expected finite coordinates, got %v", b)
+				}
+				if d := haversine(a, b); math.Abs(d-max) > 1 {
+					t.Errorf("Expected segment %d of %.0f m, got %.0f m", i, max, d)
+				}
+			}
+		})
+	}
+}
+
+// TestLineStringDensifyNoop tests that non-positive limits leave the coordinates unchanged
+func TestLineStringDensifyNoop(t *testing.T) {
+	ls := &LineString{Coordinates: []Coordinate{Coord(0, 0), Coord(10, 10)}}
+
+	for _, max := range []float64{0, -1} {
+		dense := ls.Densify(max)
+		if !coordSliceEqual(dense.Coordinates, ls.Coordinates) {
+			t.Errorf("Densify(%v) = %v, want %v", max, dense.Coordinates, ls.Coordinates)
+		}
+	}
+}
+
+// TestCircle tests approximating a circle with a polygon
+func TestCircle(t *testing.T) {
+	center := Coordinate{Lon: -122.4, Lat: 37.8, Alt: 10}
+	circle := Circle(center, 500, 36)
+
+	ring := circle.OuterBoundary.Coordinates
+	if len(ring) != 37 {
+		t.Fatalf("Expected 37 coordinates, got %d", len(ring))
+	}
+	if ring[0] != ring[36] {
+		t.Error("Expected a closed ring")
+	}
+	if ring[0].Lat <= center.Lat || !floatNear(ring[0].Lon, center.Lon, 1e-9) {
+		t.Errorf("Expected the first vertex due north, got %v", ring[0])
+	}
+	for i, c := range ring {
+		if d := center.DistanceTo(c); !floatNear(d, 500, 0.01) {
+			t.Errorf("Vertex %d: expected distance 500, got %v", i, d)
+		}
+		if c.Alt != 10 {
+			t.Errorf("Vertex %d: expected altitude 10, got %v", i, c.Alt)
+		}
+	}
+
+	if n := len(Circle(center, 500, 1).OuterBoundary.Coordinates); n != 4 {
+		t.Errorf("Expected at least 3 segments, got %d coordinates", n)
+	}
+}
@@ -0,0 +1,168 @@
+package kml
+
+// EventType identifies a geofence transition.
+type EventType int
+
+const (
+	// EventEnter reports a move from outside a region to inside it.
+	EventEnter EventType = iota
+	// EventExit reports a move from inside a region to outside it.
+	EventExit
+	// EventInside reports that both positions are inside a region.
+	EventInside
+)
+
+// String returns the lowercase name of the event type.
+func (t EventType) String() string {
+	switch t {
+	case EventEnter:
+		return "enter"
+	case EventExit:
+		return "exit"
+	case EventInside:
+		return "inside"
+	}
+	return "unknown"
+}
+
+// Event is a geofence transition for one region.
+type Event struct {
+	Type      EventType
+	Region    string     // Name of the region's placemark, or its ID if unnamed
+	Placemark *Placemark // Placemark defining the region
+}
+
+// Geofence evaluates positions against the polygon regions of a document.
+type Geofence struct {
+	regions []geofenceRegion
+}
+
+// geofenceRegion is one placemark's polygons with their combined bounds.
+type geofenceRegion struct {
+	name      string
+	placemark *Placemark
+	polygons  []*Polygon
+	sw, ne    Coordinate
+}
+
+// NewGeofence builds a geofence with one region per placemark in k whose
+// geometry is a Polygon, or a MultiGeometry containing Polygons. Regions
+// are evaluated in document order.
+func NewGeofence(k *KML) *Geofence {
+	g := &Geofence{}
+	for _, pm := range k.Placemarks() {
+		var polygons []*Polygon
+		switch geom := pm.Geometry.(type) {
+		case *Polygon:
+			polygons = append(polygons, geom)
+		case *MultiGeometry:
+			for _, child := range geom.Flatten() {
+				if poly, ok := child.(*Polygon); ok {
+					polygons = append(polygons, poly)
+				}
+			}
+		}
+		if len(polygons) == 0 {
+			continue
+		}
+
+		region := geofenceRegion{
+			name:      pm.Name,
+			placemark: pm,
+			polygons:  polygons,
+		}
+		if region.name == "" {
+			region.name = pm.ID
+		}
+		region.sw, region.ne = ringBounds(polygons[0].OuterBoundary.Coordinates)
+		for _, poly := range polygons[1:] {
+			sw, ne := ringBounds(poly.OuterBoundary.Coordinates)
+			region.sw.Lon = min(region.sw.Lon, sw.Lon)
+			region.sw.Lat = min(region.sw.Lat, sw.Lat)
+			region.ne.Lon = max(region.ne.Lon, ne.Lon)
+			region.ne.Lat = max(region.ne.Lat, ne.Lat)
+		}
+		g.regions = append(g.regions, region)
+	}
+	return g
+}
+
+// Len returns the number of regions in the geofence.
+func (g *Geofence) Len() int {
+	return len(g.regions)
+}
+
+// Evaluate compares a previous and current position against every region
+// and returns the resulting events in region order: EventEnter or EventExit
+// when the position crossed a region's boundary, and EventInside when both
+// positions are within it. Regions containing neither position produce no
+// event.
+func (g *Geofence) Evaluate(prev, curr Coordinate) []Event {
+	var events []Event
+	for i := range g.regions {
+		r := &g.regions[i]
+		was, is := r.contains(prev), r.contains(curr)
+
+		var typ EventType
+		switch {
+		case !was && is:
+			typ = EventEnter
+		case was && !is:
+			typ = EventExit
+		case was && is:
+			typ = EventInside
+		default:
+			continue
+		}
+		events = append(events, Event{Type: typ, Region: r.name, Placemark: r.placemark})
+	}
+	return events
+}
+
+// contains reports whether c is inside any of the region's polygons.
+func (r *geofenceRegion) contains(c Coordinate) bool {
+	if c.Lon < r.sw.Lon || c.Lon > r.ne.Lon || c.Lat < r.sw.Lat || c.Lat > r.ne.Lat {
+		return false
+	}
+	for _, poly := range r.polygons {
+		if poly.Contains(c) {
+			return true
+		}
+	}
+	return false
+}
+
+// Contains reports whether c lies inside the polygon's outer boundary and
+// outside all of its holes. Coordinates are treated as planar
+// longitude/latitude, which is accurate for polygons that do not cross the
+// antimeridian or enclose a pole. Altitude is ignored.
+func (p *Polygon) Contains(c Coordinate) bool {
+	if !ringContains(p.OuterBoundary.Coordinates, c) {
+		return false
+	}
+	for _, hole := range p.InnerBoundaries {
+		if ringContains(hole.Coordinates, c) {
+			return false
+		}
+	}
+	return true
+}
+
+// ringContains reports whether c is inside the ring using the even-odd
+// ray casting rule. The ring need not be explicitly closed.
+func ringContains(ring []Coordinate, c Coordinate) bool {
+	inside := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		a, b := ring[i], ring[j]
+		if (a.Lat > c.Lat) != (b.Lat > c.Lat) &&
+			c.Lon < (b.Lon-a.Lon)*(c.Lat-a.Lat)/(b.Lat-a.Lat)+a.Lon {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// ringBounds returns the southwest and northeast corners of coords.
+func ringBounds(coords []Coordinate) (sw, ne Coordinate) {
+	return BoundsOf(coords)
+}
@@ -0,0 +1,121 @@
+package kml
+
+import "testing"
+
+func square(minLon, minLat, maxLon, maxLat float64) LinearRing {
+	return LinearRing{Coordinates: []Coordinate{
+		{Lon: minLon, Lat: minLat},
+		{Lon: maxLon, Lat: minLat},
+		{Lon: maxLon, Lat: maxLat},
+		{Lon: minLon, Lat: maxLat},
+		{Lon: minLon, Lat: minLat},
+	}}
+}
+
+func TestPolygonContains(t *testing.T) {
+	poly := &Polygon{
+		OuterBoundary:   square(0, 0, 10, 10),
+		InnerBoundaries: []LinearRing{square(4, 4, 6, 6)},
+	}
+
+	tests := []struct {
+		name string
+		c    Coordinate
+		want bool
+	}{
+		{"inside", Coord(2, 2), true},
+		{"in hole", Coord(5, 5), false},
+		{"outside", Coord(11, 5), false},
+		{"below", Coord(5, -1), false},
+		{"between hole and shell", Coord(8, 5), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := poly.Contains(tt.c); got != tt.want {
+				t.Errorf("Expected Contains(%v) = %v, got %v", tt.c, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPolygonContainsConcave(t *testing.T) {
+	// U shape opening to the north.
+	poly := &Polygon{OuterBoundary: LinearRing{Coordinates: []Coordinate{
+		{0, 0, 0}, {3, 0, 0}, {3, 3, 0}, {2, 3, 0}, {2, 1, 0}, {1, 1, 0}, {1, 3, 0}, {0, 3, 0}, {0, 0, 0},
+	}}}
+
+	if !poly.Contains(Coord(0.5, 2)) {
+		t.Error("Expected left arm to contain point")
+	}
+	if poly.Contains(Coord(1.5, 2)) {
+		t.Error("Expected notch to not contain point")
+	}
+}
+
+func geofenceTestKML() *KML {
+	k := NewKML()
+	k.Feature = &Document{Features: []Feature{
+		&Placemark{Name: "Zone A", Geometry: &Polygon{OuterBoundary: square(0, 0, 10, 10)}},
+		&Placemark{ID: "zone-b", Geometry: &MultiGeometry{Geometries: []Geometry{
+			&Polygon{OuterBoundary: square(20, 0, 30, 10)},
+			&Point{Coordinates: Coord(50, 50)},
+			&Polygon{OuterBoundary: square(40, 0, 45, 5)},
+		}}},
+		&Placemark{Name: "Not a region", Geometry: &Point{Coordinates: Coord(5, 5)}},
+	}}
+	return k
+}
+
+func TestNewGeofence(t *testing.T) {
+	g := NewGeofence(geofenceTestKML())
+	if g.Len() != 2 {
+		t.Fatalf("Expected 2 regions, got %d", g.Len())
+	}
+}
+
+func TestGeofenceEvaluate(t *testing.T) {
+	g := NewGeofence(geofenceTestKML())
+
+	tests := []struct {
+		name       string
+		prev, curr Coordinate
+		want       []Event
+	}{
+		{"enter A", Coord(-1, 5), Coord(1, 5), []Event{{Type: EventEnter, Region: "Zone A"}}},
+		{"exit A", Coord(1, 5), Coord(-1, 5), []Event{{Type: EventExit, Region: "Zone A"}}},
+		{"inside A", Coord(1, 5), Coord(2, 5), []Event{{Type: EventInside, Region: "Zone A"}}},
+		{"outside all", Coord(-1, 5), Coord(-2, 5), nil},
+		{"A to B second polygon", Coord(5, 5), Coord(42, 2), []Event{
+			{Type: EventExit, Region: "Zone A"},
+			{Type: EventEnter, Region: "zone-b"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := g.Evaluate(tt.prev, tt.curr)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Expected %d events, got %d: %v", len(tt.want), len(got), got)
+			}
+			for i := range got {
+				if got[i].Type != tt.want[i].Type || got[i].Region != tt.want[i].Region {
+					t.Errorf("Event %d: expected %s %s, got %s %s", i,
+						tt.want[i].Type, tt.want[i].Region, got[i].Type, got[i].Region)
+				}
+				if got[i].Placemark == nil {
+					t.Errorf("Event %d: expected placemark to be set", i)
+				}
+			}
+		})
+	}
+}
+
+func TestEventTypeString(t *testing.T) {
+	if EventEnter.String() != "enter" || EventExit.String() != "exit" || EventInside.String() != "inside" {
+		t.Error("Unexpected event type names")
+	}
+	if EventType(99).String() != "unknown" {
+		t.Errorf("Expected 'unknown', got %q", EventType(99).String())
+	}
+}
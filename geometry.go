@@ -57,13 +57,13 @@ func (p *Point) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
 	}
 
 	if p.AltitudeMode != "" {
-		if err := e.EncodeElement(p.AltitudeMode, xml.StartElement{Name: xml.Name{Local: "altitudeMode"}}); err != nil {
+		if err := encodeAltitudeMode(e, p.AltitudeMode); err != nil {
 			return err
 		}
 	}
 
 	// Encode coordinates as a single coordinate string
-	coordStr := p.Coordinates.String()
+	coordStr := encodeCoordinates(e, []Coordinate{p.Coordinates})
 	if err := e.EncodeElement(coordStr, xml.StartElement{Name: xml.Name{Local: "coordinates"}}); err != nil {
 		return err
 	}
@@ -93,7 +93,7 @@ func (p *Point) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 				if err := d.DecodeElement(&v, &el); err != nil {
 					return err
 				}
-				p.Extrude = v != 0
+				p.Extrude = flagValue(d, el, v)
 			case "altitudeMode", "gx:altitudeMode":
 				var mode string
 				if err := d.DecodeElement(&mode, &el); err != nil {
@@ -105,7 +105,7 @@ func (p *Point) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 				if err := d.DecodeElement(&coordStr, &el); err != nil {
 					return err
 				}
-				coords, err := parseCoordinates(coordStr)
+				coords, err := decodeCoordinates(d, coordStr)
 				if err != nil {
 					return err
 				}
@@ -113,8 +113,7 @@ func (p *Point) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 					p.Coordinates = coords[0]
 				}
 			default:
-				// Skip unknown elements
-				if err := d.Skip(); err != nil {
+				if err := skipElement(d, el); err != nil {
 					return err
 				}
 			}
@@ -164,13 +163,13 @@ func (ls *LineString) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
 	}
 
 	if ls.AltitudeMode != "" {
-		if err := e.EncodeElement(ls.AltitudeMode, xml.StartElement{Name: xml.Name{Local: "altitudeMode"}}); err != nil {
+		if err := encodeAltitudeMode(e, ls.AltitudeMode); err != nil {
 			return err
 		}
 	}
 
 	// Encode coordinates
-	coordStr := coordinatesToString(ls.Coordinates)
+	coordStr := encodeCoordinates(e, ls.Coordinates)
 	if err := e.EncodeElement(coordStr, xml.StartElement{Name: xml.Name{Local: "coordinates"}}); err != nil {
 		return err
 	}
@@ -200,13 +199,13 @@ func (ls *LineString) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error
 				if err := d.DecodeElement(&v, &el); err != nil {
 					return err
 				}
-				ls.Extrude = v != 0
+				ls.Extrude = flagValue(d, el, v)
 			case "tessellate":
 				var v int
 				if err := d.DecodeElement(&v, &el); err != nil {
 					return err
 				}
-				ls.Tessellate = v != 0
+				ls.Tessellate = flagValue(d, el, v)
 			case "altitudeMode", "gx:altitudeMode":
 				var mode string
 				if err := d.DecodeElement(&mode, &el); err != nil {
@@ -218,14 +217,13 @@ func (ls *LineString) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error
 				if err := d.DecodeElement(&coordStr, &el); err != nil {
 					return err
 				}
-				coords, err := parseCoordinates(coordStr)
+				coords, err := decodeCoordinates(d, coordStr)
 				if err != nil {
 					return err
 				}
 				ls.Coordinates = coords
 			default:
-				// Skip unknown elements
-				if err := d.Skip(); err != nil {
+				if err := skipElement(d, el); err != nil {
 					return err
 				}
 			}
@@ -275,13 +273,13 @@ func (lr *LinearRing) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
 	}
 
 	if lr.AltitudeMode != "" {
-		if err := e.EncodeElement(lr.AltitudeMode, xml.StartElement{Name: xml.Name{Local: "altitudeMode"}}); err != nil {
+		if err := encodeAltitudeMode(e, lr.AltitudeMode); err != nil {
 			return err
 		}
 	}
 
 	// Encode coordinates
-	coordStr := coordinatesToString(lr.Coordinates)
+	coordStr := encodeCoordinates(e, lr.Coordinates)
 	if err := e.EncodeElement(coordStr, xml.StartElement{Name: xml.Name{Local: "coordinates"}}); err != nil {
 		return err
 	}
@@ -311,13 +309,13 @@ func (lr *LinearRing) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error
 				if err := d.DecodeElement(&v, &el); err != nil {
 					return err
 				}
-				lr.Extrude = v != 0
+				lr.Extrude = flagValue(d, el, v)
 			case "tessellate":
 				var v int
 				if err := d.DecodeElement(&v, &el); err != nil {
 					return err
 				}
-				lr.Tessellate = v != 0
+				lr.Tessellate = flagValue(d, el, v)
 			case "altitudeMode", "gx:altitudeMode":
 				var mode string
 				if err := d.DecodeElement(&mode, &el); err != nil {
@@ -329,14 +327,13 @@ func (lr *LinearRing) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error
 				if err := d.DecodeElement(&coordStr, &el); err != nil {
 					return err
 				}
-				coords, err := parseCoordinates(coordStr)
+				coords, err := decodeCoordinates(d, coordStr)
 				if err != nil {
 					return err
 				}
 				lr.Coordinates = coords
 			default:
-				// Skip unknown elements
-				if err := d.Skip(); err != nil {
+				if err := skipElement(d, el); err != nil {
 					return err
 				}
 			}
@@ -387,7 +384,7 @@ func (p *Polygon) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
 	}
 
 	if p.AltitudeMode != "" {
-		if err := e.EncodeElement(p.AltitudeMode, xml.StartElement{Name: xml.Name{Local: "altitudeMode"}}); err != nil {
+		if err := encodeAltitudeMode(e, p.AltitudeMode); err != nil {
 			return err
 		}
 	}
@@ -443,13 +440,13 @@ func (p *Polygon) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 				if err := d.DecodeElement(&v, &el); err != nil {
 					return err
 				}
-				p.Extrude = v != 0
+				p.Extrude = flagValue(d, el, v)
 			case "tessellate":
 				var v int
 				if err := d.DecodeElement(&v, &el); err != nil {
 					return err
 				}
-				p.Tessellate = v != 0
+				p.Tessellate = flagValue(d, el, v)
 			case "altitudeMode", "gx:altitudeMode":
 				var mode string
 				if err := d.DecodeElement(&mode, &el); err != nil {
@@ -473,7 +470,7 @@ func (p *Polygon) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 							}
 							p.OuterBoundary = ring
 						} else {
-							if err := d.Skip(); err != nil {
+							if err := skipElement(d, inner); err != nil {
 								return err
 							}
 						}
@@ -498,7 +495,7 @@ func (p *Polygon) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 							}
 							p.InnerBoundaries = append(p.InnerBoundaries, ring)
 						} else {
-							if err := d.Skip(); err != nil {
+							if err := skipElement(d, inner); err != nil {
 								return err
 							}
 						}
@@ -507,8 +504,7 @@ func (p *Polygon) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 					}
 				}
 			default:
-				// Skip unknown elements
-				if err := d.Skip(); err != nil {
+				if err := skipElement(d, el); err != nil {
 					return err
 				}
 			}
@@ -554,6 +550,11 @@ func (mg *MultiGeometry) MarshalXML(e *xml.Encoder, start xml.StartElement) erro
 
 // UnmarshalXML implements custom XML unmarshaling for MultiGeometry.
 func (mg *MultiGeometry) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	leave, err := enterNested(d, start)
+	if err != nil {
+		return err
+	}
+	defer leave()
 	for _, attr := range start.Attr {
 		if attr.Name.Local == "id" {
 			mg.ID = attr.Value
@@ -600,9 +601,14 @@ func (mg *MultiGeometry) UnmarshalXML(d *xml.Decoder, start xml.StartElement) er
 					return err
 				}
 				geom = &mg2
+			case "Track":
+				var track Track
+				if err := d.DecodeElement(&track, &el); err != nil {
+					return err
+				}
+				geom = &track
 			default:
-				// Skip unknown elements
-				if err := d.Skip(); err != nil {
+				if err := skipElement(d, el); err != nil {
 					return err
 				}
 				continue
@@ -628,34 +634,65 @@ func parseCoordinates(s string) ([]Coordinate, error) {
 	tuples := strings.Fields(s)
 
 	for _, tuple := range tuples {
-		parts := strings.Split(tuple, ",")
-		if len(parts) < 2 {
-			return nil, fmt.Errorf("invalid coordinate tuple: %s", tuple)
-		}
-
-		lon, err := strconv.ParseFloat(parts[0], 64)
+		coord, err := parseCoordinateTuple(tuple)
 		if err != nil {
-			return nil, fmt.Errorf("invalid longitude in tuple %s: %w", tuple, err)
+			return nil, err
 		}
+		coords = append(coords, coord)
+	}
+
+	return coords, nil
+}
 
-		lat, err := strconv.ParseFloat(parts[1], 64)
+// parseCoordinateTuple parses a single "lon,lat[,alt]" tuple.
+func parseCoordinateTuple(tuple string) (Coordinate, error) {
+	parts := strings.Split(tuple, ",")
+	if len(parts) < 2 {
+		return Coordinate{}, fmt.Errorf("invalid coordinate tuple: %s", tuple)
+	}
+
+	lon, err := strconv.ParseFloat(parts[0], 64)
+	if err != nil {
+		return Coordinate{}, fmt.Errorf("invalid longitude in tuple %s: %w", tuple, err)
+	}
+
+	lat, err := strconv.ParseFloat(parts[1], 64)
+	if err != nil {
+		return Coordinate{}, fmt.Errorf("invalid latitude in tuple %s: %w", tuple, err)
+	}
+
+	coord := Coordinate{Lon: lon, Lat: lat}
+
+	if len(parts) >= 3 && parts[2] != "" {
+		alt, err := strconv.ParseFloat(parts[2], 64)
 		if err != nil {
-			return nil, fmt.Errorf("invalid latitude in tuple %s: %w", tuple, err)
+			return Coordinate{}, fmt.Errorf("invalid altitude in tuple %s: %w", tuple, err)
 		}
+		coord.Alt = alt
+	}
 
-		coord := Coordinate{Lon: lon, Lat: lat}
+	return coord, nil
+}
 
-		if len(parts) >= 3 && parts[2] != "" {
-			alt, err := strconv.ParseFloat(parts[2], 64)
-			if err != nil {
-				return nil, fmt.Errorf("invalid altitude in tuple %s: %w", tuple, err)
+// decodeCoordinates parses a coordinates element's text for d. When d is
+// collecting errors, malformed tuples are reported and skipped; otherwise
+// the first one fails the parse.
+func decodeCoordinates(d *xml.Decoder, s string) ([]Coordinate, error) {
+	if cfg := parseConfigFor(d); cfg == nil || cfg.errs == nil {
+		return parseCoordinates(s)
+	}
+
+	var coords []Coordinate
+	for _, tuple := range strings.Fields(s) {
+		coord, err := parseCoordinateTuple(tuple)
+		if err != nil {
+			if err := recoverable(d, "invalid coordinates", err); err != nil {
+				return nil, err
 			}
-			coord.Alt = alt
+			continue
 		}
-
 		coords = append(coords, coord)
 	}
-
 	return coords, nil
 }
 
@@ -672,3 +709,82 @@ func coordinatesToString(coords []Coordinate) string {
 
 	return strings.Join(parts, " ")
 }
+
+// encodeCoordinates formats coordinates for e, applying its FormatFloat,
+// Precision and DedupCoordinates options.
+func encodeCoordinates(e *xml.Encoder, coords []Coordinate) string {
+	cfg := writeConfigFor(e)
+	if cfg == nil {
+		return coordinatesToString(coords)
+	}
+
+	parts := make([]string, 0, len(coords))
+	for _, c := range coords {
+		tuple := cfg.formatTuple(c)
+		if cfg.dedup && len(parts) > 0 && parts[len(parts)-1] == tuple {
+			continue
+		}
+		parts = append(parts, tuple)
+	}
+	return strings.Join(parts, " ")
+}
+
+// formatTuple formats c as a coordinates tuple, applying the FormatFloat
+// and Precision options of cfg, which may be nil.
+func (cfg *writeConfig) formatTuple(c Coordinate) string {
+	switch {
+	case cfg == nil:
+		return c.String()
+	case cfg.format != nil:
+		if c.Alt == 0 {
+			return cfg.format(c.Lon) + "," + cfg.format(c.Lat)
+		}
+		return cfg.format(c.Lon) + "," + cfg.format(c.Lat) + "," + cfg.format(c.Alt)
+	case cfg.precision >= 0:
+		return formatCoordinate(c, cfg.precision)
+	}
+	return c.String()
+}
+
+// formatCoordinate formats c with values rounded to digits decimal places.
+func formatCoordinate(c Coordinate, digits int) string {
+	lon := formatFixed(c.Lon, digits)
+	lat := formatFixed(c.Lat, digits)
+	alt := formatFixed(c.Alt, digits)
+	if alt == "0" {
+		return lon + "," + lat
+	}
+	return lon + "," + lat + "," + alt
+}
+
+// formatFixed formats v with digits decimal places, trimming trailing zeros.
+func formatFixed(v float64, digits int) string {
+	s := strconv.FormatFloat(v, 'f', digits, 64)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	if s == "-0" {
+		s = "0"
+	}
+	return s
+}
+
+// newGeometry returns an empty geometry for a KML geometry element name,
+// or nil if name is not a supported geometry element.
+func newGeometry(name string) Geometry {
+	switch name {
+	case "Point":
+		return &Point{}
+	case "LineString":
+		return &LineString{}
+	case "LinearRing":
+		return &LinearRing{}
+	case "Polygon":
+		return &Polygon{}
+	case "MultiGeometry":
+		return &MultiGeometry{}
+	case "Track":
+		return &Track{}
+	}
+	return nil
+}
@@ -0,0 +1,15 @@
+// Package geomkml converts between the geometries of package kml and those
+// of github.com/twpayne/go-geom, so documents parsed with kml can be used
+// with go-geom's encoders and algorithms and go-geom geometries written as
+// KML.
+//
+// The package is a module of its own, so go-geom is a dependency only of
+// programs that require github.com/robert-malhotra/go-kml/geomkml, and the
+// converters are built only with the geom build tag:
+//
+//	go get github.com/robert-malhotra/go-kml/geomkml
+//	go build -tags geom
+//
+// Geometries with any nonzero altitude convert to the XYZ layout and others
+// to XY. Measures of XYM and XYZM geometries are dropped.
+package geomkml
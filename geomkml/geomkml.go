@@ -0,0 +1,229 @@
+//go:build geom
+
+package geomkml
+
+import (
+	"fmt"
+
+	"github.com/twpayne/go-geom"
+
+	kml "github.com/robert-malhotra/go-kml"
+)
+
+// ToGeom converts a KML geometry to a go-geom geometry. Points,
+// LineStrings, LinearRings and Polygons become their go-geom counterparts,
+// and a gx:Track becomes a *geom.LineString of its coordinates. A
+// MultiGeometry whose children are all Points, all LineStrings or all
+// Polygons becomes a *geom.MultiPoint, *geom.MultiLineString or
+// *geom.MultiPolygon; any other MultiGeometry becomes a
+// *geom.GeometryCollection.
+func ToGeom(g kml.Geometry) (geom.T, error) {
+	switch kg := g.(type) {
+	case *kml.Point:
+		layout := layoutOf(kg.Coordinates)
+		return geom.NewPoint(layout).SetCoords(coord(kg.Coordinates, layout))
+	case *kml.LineString:
+		layout := layoutOf(kg.Coordinates...)
+		return geom.NewLineString(layout).SetCoords(coords(kg.Coordinates, layout))
+	case *kml.LinearRing:
+		layout := layoutOf(kg.Coordinates...)
+		return geom.NewLinearRing(layout).SetCoords(coords(kg.Coordinates, layout))
+	case *kml.Polygon:
+		layout := layoutOf(polygonCoordinates(kg)...)
+		return geom.NewPolygon(layout).SetCoords(polygonCoords(kg, layout))
+	case *kml.Track:
+		layout := layoutOf(kg.Coords...)
+		return geom.NewLineString(layout).SetCoords(coords(kg.Coords, layout))
+	case *kml.MultiGeometry:
+		return multiGeometry(kg)
+	}
+	return nil, fmt.Errorf("geomkml: unsupported geometry %T", g)
+}
+
+// FromGeom converts a go-geom geometry to a KML geometry.
+// *geom.MultiPoint, *geom.MultiLineString, *geom.MultiPolygon and
+// *geom.GeometryCollection become MultiGeometries.
+func FromGeom(g geom.T) (kml.Geometry, error) {
+	switch gg := g.(type) {
+	case *geom.Point:
+		c := gg.Coords()
+		if len(c) < 2 {
+			return nil, fmt.Errorf("geomkml: empty point")
+		}
+		return &kml.Point{Coordinates: coordinate(c, gg.Layout())}, nil
+	case *geom.LineString:
+		return &kml.LineString{Coordinates: coordinates(gg.Coords(), gg.Layout())}, nil
+	case *geom.LinearRing:
+		return &kml.LinearRing{Coordinates: coordinates(gg.Coords(), gg.Layout())}, nil
+	case *geom.Polygon:
+		return fromPolygon(gg.Coords(), gg.Layout()), nil
+	case *geom.MultiPoint:
+		mg := &kml.MultiGeometry{}
+		for _, c := range gg.Coords() {
+			if len(c) >= 2 {
+				mg.Geometries = append(mg.Geometries, &kml.Point{Coordinates: coordinate(c, gg.Layout())})
+			}
+		}
+		return mg, nil
+	case *geom.MultiLineString:
+		mg := &kml.MultiGeometry{}
+		for _, ls := range gg.Coords() {
+			mg.Geometries = append(mg.Geometries, &kml.LineString{Coordinates: coordinates(ls, gg.Layout())})
+		}
+		return mg, nil
+	case *geom.MultiPolygon:
+		mg := &kml.MultiGeometry{}
+		for _, p := range gg.Coords() {
+			mg.Geometries = append(mg.Geometries, fromPolygon(p, gg.Layout()))
+		}
+		return mg, nil
+	case *geom.GeometryCollection:
+		mg := &kml.MultiGeometry{}
+		for _, child := range gg.Geoms() {
+			converted, err := FromGeom(child)
+			if err != nil {
+				return nil, err
+			}
+			mg.Geometries = append(mg.Geometries, converted)
+		}
+		return mg, nil
+	}
+	return nil, fmt.Errorf("geomkml: unsupported geometry %T", g)
+}
+
+// multiGeometry converts mg to the most specific go-geom geometry holding
+// its children.
+func multiGeometry(mg *kml.MultiGeometry) (geom.T, error) {
+	var all []kml.Coordinate
+	kind := ""
+	for i, child := range mg.Geometries {
+		var name string
+		switch c := child.(type) {
+		case *kml.Point:
+			name = "Point"
+			all = append(all, c.Coordinates)
+		case *kml.LineString:
+			name = "LineString"
+			all = append(all, c.Coordinates...)
+		case *kml.Polygon:
+			name = "Polygon"
+			all = append(all, polygonCoordinates(c)...)
+		}
+		if i == 0 {
+			kind = name
+		} else if name != kind {
+			kind = ""
+		}
+	}
+	layout := layoutOf(all...)
+
+	switch kind {
+	case "Point":
+		var cs []geom.Coord
+		for _, child := range mg.Geometries {
+			cs = append(cs, coord(child.(*kml.Point).Coordinates, layout))
+		}
+		return geom.NewMultiPoint(layout).SetCoords(cs)
+	case "LineString":
+		var cs [][]geom.Coord
+		for _, child := range mg.Geometries {
+			cs = append(cs, coords(child.(*kml.LineString).Coordinates, layout))
+		}
+		return geom.NewMultiLineString(layout).SetCoords(cs)
+	case "Polygon":
+		var cs [][][]geom.Coord
+		for _, child := range mg.Geometries {
+			cs = append(cs, polygonCoords(child.(*kml.Polygon), layout))
+		}
+		return geom.NewMultiPolygon(layout).SetCoords(cs)
+	}
+
+	collection := geom.NewGeometryCollection()
+	for _, child := range mg.Geometries {
+		converted, err := ToGeom(child)
+		if err != nil {
+			return nil, err
+		}
+		if err := collection.Push(converted); err != nil {
+			return nil, err
+		}
+	}
+	return collection, nil
+}
+
+// layoutOf returns XYZ if any of coords has an altitude and XY otherwise.
+func layoutOf(coords ...kml.Coordinate) geom.Layout {
+	for _, c := range coords {
+		if c.Alt != 0 {
+			return geom.XYZ
+		}
+	}
+	return geom.XY
+}
+
+// polygonCoordinates returns the coordinates of every ring of p.
+func polygonCoordinates(p *kml.Polygon) []kml.Coordinate {
+	all := append([]kml.Coordinate(nil), p.OuterBoundary.Coordinates...)
+	for _, inner := range p.InnerBoundaries {
+		all = append(all, inner.Coordinates...)
+	}
+	return all
+}
+
+// coord converts c to a go-geom coordinate in layout.
+func coord(c kml.Coordinate, layout geom.Layout) geom.Coord {
+	if layout == geom.XYZ {
+		return geom.Coord{c.Lon, c.Lat, c.Alt}
+	}
+	return geom.Coord{c.Lon, c.Lat}
+}
+
+// coords converts cs to go-geom coordinates in layout.
+func coords(cs []kml.Coordinate, layout geom.Layout) []geom.Coord {
+	out := make([]geom.Coord, len(cs))
+	for i, c := range cs {
+		out[i] = coord(c, layout)
+	}
+	return out
+}
+
+// polygonCoords converts the rings of p to go-geom coordinates, outer ring
+// first.
+func polygonCoords(p *kml.Polygon, layout geom.Layout) [][]geom.Coord {
+	rings := [][]geom.Coord{coords(p.OuterBoundary.Coordinates, layout)}
+	for _, inner := range p.InnerBoundaries {
+		rings = append(rings, coords(inner.Coordinates, layout))
+	}
+	return rings
+}
+
+// coordinate converts c, in layout, to a KML coordinate.
+func coordinate(c geom.Coord, layout geom.Layout) kml.Coordinate {
+	if z := layout.ZIndex(); z >= 0 && z < len(c) {
+		return kml.Coord(c[0], c[1], c[z])
+	}
+	return kml.Coord(c[0], c[1])
+}
+
+// coordinates converts cs, in layout, to KML coordinates.
+func coordinates(cs []geom.Coord, layout geom.Layout) []kml.Coordinate {
+	out := make([]kml.Coordinate, len(cs))
+	for i, c := range cs {
+		out[i] = coordinate(c, layout)
+	}
+	return out
+}
+
+// fromPolygon converts the rings of a go-geom polygon to a KML Polygon.
+func fromPolygon(rings [][]geom.Coord, layout geom.Layout) *kml.Polygon {
+	out := &kml.Polygon{}
+	for i, ring := range rings {
+		lr := kml.LinearRing{Coordinates: coordinates(ring, layout)}
+		if i == 0 {
+			out.OuterBoundary = lr
+		} else {
+			out.InnerBoundaries = append(out.InnerBoundaries, lr)
+		}
+	}
+	return out
+}
@@ -0,0 +1,100 @@
+//go:build geom
+
+package geomkml
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/twpayne/go-geom"
+
+	kml "github.com/robert-malhotra/go-kml"
+)
+
+// TestToGeom tests converting KML geometries to go-geom geometries
+func TestToGeom(t *testing.T) {
+	square := []kml.Coordinate{kml.Coord(0, 0), kml.Coord(1, 0), kml.Coord(1, 1), kml.Coord(0, 0)}
+	tests := []struct {
+		name   string
+		geom   kml.Geometry
+		layout geom.Layout
+		want   []float64
+	}{
+		{"point", &kml.Point{Coordinates: kml.Coord(1, 2)}, geom.XY, []float64{1, 2}},
+		{"point with altitude", &kml.Point{Coordinates: kml.Coord(1, 2, 30)}, geom.XYZ, []float64{1, 2, 30}},
+		{"line", &kml.LineString{Coordinates: square[:2]}, geom.XY, []float64{0, 0, 1, 0}},
+		{"track", &kml.Track{Coords: []kml.Coordinate{kml.Coord(0, 0, 5), kml.Coord(1, 0)}}, geom.XYZ, []float64{0, 0, 5, 1, 0, 0}},
+		{"polygon", &kml.Polygon{OuterBoundary: kml.LinearRing{Coordinates: square}}, geom.XY, []float64{0, 0, 1, 0, 1, 1, 0, 0}},
+		{
+			"points",
+			&kml.MultiGeometry{Geometries: []kml.Geometry{&kml.Point{Coordinates: kml.Coord(1, 2)}, &kml.Point{Coordinates: kml.Coord(3, 4, 5)}}},
+			geom.XYZ,
+			[]float64{1, 2, 0, 3, 4, 5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ToGeom(tt.geom)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got.Layout() != tt.layout {
+				t.Errorf("Expected layout %v, got %v", tt.layout, got.Layout())
+			}
+			if !reflect.DeepEqual(got.FlatCoords(), tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got.FlatCoords())
+			}
+		})
+	}
+
+	mixed := &kml.MultiGeometry{Geometries: []kml.Geometry{&kml.Point{Coordinates: kml.Coord(1, 2)}, &kml.LineString{Coordinates: square[:2]}}}
+	got, err := ToGeom(mixed)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if gc, ok := got.(*geom.GeometryCollection); !ok || gc.NumGeoms() != 2 {
+		t.Errorf("Expected a collection of two geometries, got %T", got)
+	}
+}
+
+// TestFromGeom tests converting KML geometries to go-geom and back
+func TestFromGeom(t *testing.T) {
+	square := []kml.Coordinate{kml.Coord(0, 0, 10), kml.Coord(1, 0, 10), kml.Coord(1, 1, 10), kml.Coord(0, 0, 10)}
+	tests := []struct {
+		name string
+		geom kml.Geometry
+	}{
+		{"point", &kml.Point{Coordinates: kml.Coord(1, 2, 3)}},
+		{"line", &kml.LineString{Coordinates: square[:2]}},
+		{"ring", &kml.LinearRing{Coordinates: square}},
+		{"polygon with hole", &kml.Polygon{OuterBoundary: kml.LinearRing{Coordinates: square}, InnerBoundaries: []kml.LinearRing{{Coordinates: square}}}},
+		{"lines", &kml.MultiGeometry{Geometries: []kml.Geometry{&kml.LineString{Coordinates: square[:2]}, &kml.LineString{Coordinates: square[2:]}}}},
+		{"polygons", &kml.MultiGeometry{Geometries: []kml.Geometry{&kml.Polygon{OuterBoundary: kml.LinearRing{Coordinates: square}}}}},
+		{"mixed", &kml.MultiGeometry{Geometries: []kml.Geometry{&kml.Point{Coordinates: kml.Coord(1, 2, 3)}, &kml.LineString{Coordinates: square[:2]}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := ToGeom(tt.geom)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			got, err := FromGeom(g)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.geom) {
+				t.Errorf("Expected %+v, got %+v", tt.geom, got)
+			}
+		})
+	}
+
+	xym, err := geom.NewPoint(geom.XYM).SetCoords(geom.Coord{1, 2, 99})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got, _ := FromGeom(xym); got.(*kml.Point).Coordinates != kml.Coord(1, 2) {
+		t.Errorf("Expected the measure dropped, got %+v", got)
+	}
+}
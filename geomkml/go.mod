@@ -0,0 +1,10 @@
+module github.com/robert-malhotra/go-kml/geomkml
+
+go 1.25
+
+replace github.com/robert-malhotra/go-kml => ../
+
+require (
+	github.com/robert-malhotra/go-kml v0.0.0-00010101000000-000000000000
+	github.com/twpayne/go-geom v1.6.1
+)
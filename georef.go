@@ -0,0 +1,364 @@
+package kml
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"  // register GIF for image.DecodeConfig
+	_ "image/jpeg" // register JPEG for image.DecodeConfig
+	_ "image/png"  // register PNG for image.DecodeConfig
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ErrNotGeoreferenced indicates that a GeoTIFF has no tie points or
+// transformation, or uses a projection that cannot be converted to
+// longitude and latitude.
+var ErrNotGeoreferenced = errors.New("kml: image is not georeferenced")
+
+// WorldFile is the affine transformation of an ESRI world file (.pgw, .jgw,
+// .tfw, ...), mapping pixel column and row to map x and y:
+//
+//	x = A*col + B*row + C
+//	y = D*col + E*row + F
+//
+// C and F locate the center of the upper-left pixel.
+type WorldFile struct {
+	A, D, B, E, C, F float64 // In file order
+}
+
+// ParseWorldFile reads the six lines of a world file.
+func ParseWorldFile(r io.Reader) (WorldFile, error) {
+	var vals []float64
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(line, 64)
+		if err != nil {
+			return WorldFile{}, fmt.Errorf("kml: invalid world file value %q", line)
+		}
+		vals = append(vals, v)
+	}
+	if err := scanner.Err(); err != nil {
+		return WorldFile{}, fmt.Errorf("kml: error reading world file: %w", err)
+	}
+	if len(vals) != 6 {
+		return WorldFile{}, fmt.Errorf("kml: world file must have 6 values, got %d", len(vals))
+	}
+	return WorldFile{A: vals[0], D: vals[1], B: vals[2], E: vals[3], C: vals[4], F: vals[5]}, nil
+}
+
+// Apply returns the map position of a pixel position. Integer positions are
+// pixel centers.
+func (w WorldFile) Apply(col, row float64) (x, y float64) {
+	return w.A*col + w.B*row + w.C, w.D*col + w.E*row + w.F
+}
+
+// GeoreferenceOptions configures the GroundOverlay built from a
+// georeferenced image.
+type GeoreferenceOptions struct {
+	// Name of the overlay; defaults to the image file name without its
+	// extension.
+	Name string
+
+	// Href of the overlay image; defaults to the image file name, which
+	// suits packaging the image next to the KML or inside a KMZ.
+	Href string
+
+	// Unproject converts map x and y to a coordinate. It is required for
+	// world files in a projected system; by default map positions are
+	// taken to be longitude and latitude. GeoTIFFs in WGS84 UTM zones are
+	// converted automatically.
+	Unproject func(x, y float64) Coordinate
+}
+
+// NewGroundOverlay builds a GroundOverlay for an image of the given pixel
+// size georeferenced by w. The LatLonBox bounds the image; if the image is
+// rotated or skewed on the map, the exact corners are also set as a
+// LatLonQuad, which Google Earth uses in preference to the box.
+func NewGroundOverlay(width, height int, w WorldFile, opts GeoreferenceOptions) *GroundOverlay {
+	unproject := opts.Unproject
+	if unproject == nil {
+		unproject = func(x, y float64) Coordinate { return Coordinate{Lon: x, Lat: y} }
+	}
+
+	// Image edges lie half a pixel outside the outermost pixel centers.
+	left, top := -0.5, -0.5
+	right, bottom := float64(width)-0.5, float64(height)-0.5
+	pixels := [][2]float64{{left, bottom}, {right, bottom}, {right, top}, {left, top}}
+	corners := make([]Coordinate, len(pixels))
+	for i, p := range pixels {
+		corners[i] = unproject(w.Apply(p[0], p[1]))
+	}
+
+	sw, ne := ringBounds(corners)
+	g := &GroundOverlay{
+		Name:      opts.Name,
+		Icon:      &Icon{Href: opts.Href},
+		LatLonBox: &LatLonBox{North: ne.Lat, South: sw.Lat, East: ne.Lon, West: sw.Lon},
+	}
+
+	const eps = 1e-9
+	aligned := math.Abs(corners[0].Lon-corners[3].Lon) < eps && math.Abs(corners[1].Lon-corners[2].Lon) < eps &&
+		math.Abs(corners[0].Lat-corners[1].Lat) < eps && math.Abs(corners[2].Lat-corners[3].Lat) < eps &&
+		corners[0].Lon < corners[1].Lon && corners[0].Lat < corners[3].Lat
+	if !aligned {
+		g.LatLonQuad = &LatLonQuad{Coordinates: corners}
+	}
+	return g
+}
+
+// GroundOverlayFromWorldFile builds a GroundOverlay for a PNG, JPEG or GIF
+// image georeferenced by the world file at worldPath. The image is read
+// only to find its pixel size.
+func GroundOverlayFromWorldFile(imagePath, worldPath string, opts GeoreferenceOptions) (*GroundOverlay, error) {
+	f, err := os.Open(imagePath)
+	if err != nil {
+		return nil, fmt.Errorf("kml: error opening image: %w", err)
+	}
+	defer f.Close()
+
+	cfg, _, err := image.DecodeConfig(f)
+	if err != nil {
+		return nil, fmt.Errorf("kml: error reading image %s: %w", imagePath, err)
+	}
+
+	wf, err := os.Open(worldPath)
+	if err != nil {
+		return nil, fmt.Errorf("kml: error opening world file: %w", err)
+	}
+	defer wf.Close()
+
+	w, err := ParseWorldFile(wf)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewGroundOverlay(cfg.Width, cfg.Height, w, imageDefaults(imagePath, opts)), nil
+}
+
+// GroundOverlayFromGeoTIFF builds a GroundOverlay for a GeoTIFF, using its
+// tie point and pixel scale or its model transformation. Images in a
+// geographic system or a WGS84 UTM zone (EPSG 32601–32660, 32701–32760) are
+// handled directly; other projections need opts.Unproject.
+//
+// Google Earth does not display TIFF images, so the overlay's image will
+// usually need converting; set opts.Href to the converted file.
+func GroundOverlayFromGeoTIFF(path string, opts GeoreferenceOptions) (*GroundOverlay, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("kml: error reading GeoTIFF: %w", err)
+	}
+
+	gt, err := parseGeoTIFF(data)
+	if err != nil {
+		return nil, fmt.Errorf("kml: error reading GeoTIFF %s: %w", path, err)
+	}
+
+	if opts.Unproject == nil && gt.projected {
+		zone, north := gt.epsg%100, gt.epsg/100 == 326
+		if (gt.epsg/100 != 326 && gt.epsg/100 != 327) || zone < 1 || zone > 60 {
+			return nil, fmt.Errorf("%w: unsupported projection EPSG:%d", ErrNotGeoreferenced, gt.epsg)
+		}
+		opts.Unproject = func(x, y float64) Coordinate {
+			lat, lon := utmInverse(x, y, zone, north)
+			return Coordinate{Lon: lon, Lat: lat}
+		}
+	}
+
+	return NewGroundOverlay(gt.width, gt.height, gt.world, imageDefaults(path, opts)), nil
+}
+
+// imageDefaults fills in the name and href defaults from an image path.
+func imageDefaults(path string, opts GeoreferenceOptions) GeoreferenceOptions {
+	base := filepath.Base(path)
+	if opts.Href == "" {
+		opts.Href = base
+	}
+	if opts.Name == "" {
+		opts.Name = strings.TrimSuffix(base, filepath.Ext(base))
+	}
+	return opts
+}
+
+// TIFF and GeoTIFF tags and keys read by parseGeoTIFF.
+const (
+	tiffImageWidth        = 256
+	tiffImageLength       = 257
+	geoTIFFPixelScale     = 33550
+	geoTIFFTiepoint       = 33922
+	geoTIFFTransformation = 34264
+	geoTIFFKeyDirectory   = 34735
+	geoKeyModelType       = 1024
+	geoKeyRasterType      = 1025
+	geoKeyProjectedCSType = 3072
+	geoModelTypeProjected = 1
+	geoRasterPixelIsPoint = 2
+	tiffTypeShort         = 3
+	tiffTypeLong          = 4
+	tiffTypeDouble        = 12
+)
+
+// geoTIFF is the georeferencing of a GeoTIFF image.
+type geoTIFF struct {
+	width, height int
+	world         WorldFile
+	projected     bool
+	epsg          int
+}
+
+// parseGeoTIFF reads the georeferencing tags of the first image in a
+// classic (non-BigTIFF) TIFF file.
+func parseGeoTIFF(data []byte) (geoTIFF, error) {
+	if len(data) < 8 {
+		return geoTIFF{}, errors.New("not a TIFF file")
+	}
+	var order binary.ByteOrder
+	switch string(data[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return geoTIFF{}, errors.New("not a TIFF file")
+	}
+	if order.Uint16(data[2:]) != 42 {
+		return geoTIFF{}, errors.New("unsupported TIFF variant")
+	}
+
+	ifd := int(order.Uint32(data[4:]))
+	if ifd+2 > len(data) {
+		return geoTIFF{}, errors.New("truncated TIFF file")
+	}
+	n := int(order.Uint16(data[ifd:]))
+	if ifd+2+12*n > len(data) {
+		return geoTIFF{}, errors.New("truncated TIFF file")
+	}
+
+	var gt geoTIFF
+	var scale, tiepoint, transform []float64
+	var keys []int
+	for i := 0; i < n; i++ {
+		entry := data[ifd+2+12*i:]
+		tag := order.Uint16(entry)
+		typ := order.Uint16(entry[2:])
+		count := int(order.Uint32(entry[4:]))
+
+		switch tag {
+		case tiffImageWidth, tiffImageLength:
+			var v int
+			switch typ {
+			case tiffTypeShort:
+				v = int(order.Uint16(entry[8:]))
+			case tiffTypeLong:
+				v = int(order.Uint32(entry[8:]))
+			}
+			if tag == tiffImageWidth {
+				gt.width = v
+			} else {
+				gt.height = v
+			}
+		case geoTIFFPixelScale, geoTIFFTiepoint, geoTIFFTransformation:
+			vals, err := tiffDoubles(data, order, typ, count, entry[8:])
+			if err != nil {
+				return geoTIFF{}, err
+			}
+			switch tag {
+			case geoTIFFPixelScale:
+				scale = vals
+			case geoTIFFTiepoint:
+				tiepoint = vals
+			default:
+				transform = vals
+			}
+		case geoTIFFKeyDirectory:
+			if typ != tiffTypeShort {
+				return geoTIFF{}, errors.New("invalid GeoKeyDirectory")
+			}
+			off := int(order.Uint32(entry[8:]))
+			if count <= 2 {
+				off = ifd + 2 + 12*i + 8
+			}
+			if off+2*count > len(data) {
+				return geoTIFF{}, errors.New("truncated GeoKeyDirectory")
+			}
+			keys = make([]int, count)
+			for j := range keys {
+				keys[j] = int(order.Uint16(data[off+2*j:]))
+			}
+		}
+	}
+
+	if gt.width == 0 || gt.height == 0 {
+		return geoTIFF{}, errors.New("missing image dimensions")
+	}
+
+	// The key directory is a 4-value header followed by 4-value entries of
+	// key ID, location, count and a value stored inline when location is 0.
+	pixelIsPoint := false
+	for j := 4; j+3 < len(keys); j += 4 {
+		if keys[j+1] != 0 {
+			continue
+		}
+		switch keys[j] {
+		case geoKeyModelType:
+			gt.projected = keys[j+3] == geoModelTypeProjected
+		case geoKeyRasterType:
+			pixelIsPoint = keys[j+3] == geoRasterPixelIsPoint
+		case geoKeyProjectedCSType:
+			gt.epsg = keys[j+3]
+		}
+	}
+
+	// Raster positions refer to pixel corners unless the raster type is
+	// PixelIsPoint; world file positions always refer to pixel centers.
+	shift := 0.5
+	if pixelIsPoint {
+		shift = 0
+	}
+	switch {
+	case len(transform) >= 16:
+		m := transform
+		gt.world = WorldFile{
+			A: m[0], B: m[1], C: m[3] + (m[0]+m[1])*shift,
+			D: m[4], E: m[5], F: m[7] + (m[4]+m[5])*shift,
+		}
+	case len(tiepoint) >= 6 && len(scale) >= 2:
+		i, j, x, y := tiepoint[0], tiepoint[1], tiepoint[3], tiepoint[4]
+		gt.world = WorldFile{
+			A: scale[0],
+			E: -scale[1],
+			C: x + (shift-i)*scale[0],
+			F: y - (shift-j)*scale[1],
+		}
+	default:
+		return geoTIFF{}, ErrNotGeoreferenced
+	}
+	return gt, nil
+}
+
+// tiffDoubles reads a DOUBLE array tag value, which is always stored at an
+// offset because it exceeds the 4-byte inline value field.
+func tiffDoubles(data []byte, order binary.ByteOrder, typ uint16, count int, value []byte) ([]float64, error) {
+	if typ != tiffTypeDouble {
+		return nil, fmt.Errorf("unexpected TIFF field type %d", typ)
+	}
+	off := int(order.Uint32(value))
+	if off < 0 || off+8*count > len(data) {
+		return nil, errors.New("truncated TIFF field")
+	}
+	vals := make([]float64, count)
+	for i := range vals {
+		vals[i] = math.Float64frombits(order.Uint64(data[off+8*i:]))
+	}
+	return vals, nil
+}
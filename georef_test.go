@@ -0,0 +1,267 @@
+package kml
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// geoTIFFBuilder assembles a minimal little-endian GeoTIFF header for tests.
+type geoTIFFBuilder struct {
+	width, height uint32
+	scale         []float64
+	tiepoint      []float64
+	transform     []float64
+	keys          []uint16
+}
+
+func (b geoTIFFBuilder) bytes() []byte {
+	type entry struct {
+		tag, typ uint16
+		count    uint32
+		inline   uint32
+		payload  []byte
+	}
+	doubles := func(vals []float64) []byte {
+		out := make([]byte, 8*len(vals))
+		for i, v := range vals {
+			binary.LittleEndian.PutUint64(out[8*i:], math.Float64bits(v))
+		}
+		return out
+	}
+
+	entries := []entry{
+		{tag: tiffImageWidth, typ: tiffTypeLong, count: 1, inline: b.width},
+		{tag: tiffImageLength, typ: tiffTypeShort, count: 1, inline: b.height},
+	}
+	if b.scale != nil {
+		entries = append(entries, entry{tag: geoTIFFPixelScale, typ: tiffTypeDouble, count: uint32(len(b.scale)), payload: doubles(b.scale)})
+	}
+	if b.tiepoint != nil {
+		entries = append(entries, entry{tag: geoTIFFTiepoint, typ: tiffTypeDouble, count: uint32(len(b.tiepoint)), payload: doubles(b.tiepoint)})
+	}
+	if b.transform != nil {
+		entries = append(entries, entry{tag: geoTIFFTransformation, typ: tiffTypeDouble, count: uint32(len(b.transform)), payload: doubles(b.transform)})
+	}
+	if b.keys != nil {
+		payload := make([]byte, 2*len(b.keys))
+		for i, k := range b.keys {
+			binary.LittleEndian.PutUint16(payload[2*i:], k)
+		}
+		entries = append(entries, entry{tag: geoTIFFKeyDirectory, typ: tiffTypeShort, count: uint32(len(b.keys)), payload: payload})
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("II")
+	binary.Write(&buf, binary.LittleEndian, uint16(42))
+	binary.Write(&buf, binary.LittleEndian, uint32(8))
+	binary.Write(&buf, binary.LittleEndian, uint16(len(entries)))
+
+	offset := uint32(8 + 2 + 12*len(entries) + 4)
+	var tail bytes.Buffer
+	for _, e := range entries {
+		binary.Write(&buf, binary.LittleEndian, e.tag)
+		binary.Write(&buf, binary.LittleEndian, e.typ)
+		binary.Write(&buf, binary.LittleEndian, e.count)
+		if e.payload == nil {
+			binary.Write(&buf, binary.LittleEndian, e.inline)
+			continue
+		}
+		binary.Write(&buf, binary.LittleEndian, offset+uint32(tail.Len()))
+		tail.Write(e.payload)
+	}
+	binary.Write(&buf, binary.LittleEndian, uint32(0))
+	buf.Write(tail.Bytes())
+	return buf.Bytes()
+}
+
+// TestParseWorldFile tests reading world files
+func TestParseWorldFile(t *testing.T) {
+	w, err := ParseWorldFile(strings.NewReader("0.5\n0.0\n0.0\n-0.25\n\n-10.25\n50.125\n"))
+	if err != nil {
+		t.Fatalf("ParseWorldFile() error: %v", err)
+	}
+	want := WorldFile{A: 0.5, D: 0, B: 0, E: -0.25, C: -10.25, F: 50.125}
+	if w != want {
+		t.Errorf("Expected %+v, got %+v", want, w)
+	}
+	if x, y := w.Apply(1, 2); x != -9.75 || y != 49.625 {
+		t.Errorf("Expected (-9.75, 49.625), got (%v, %v)", x, y)
+	}
+
+	for _, s := range []string{"1\n2\n3", "1\n2\n3\n4\n5\nx", "1\n2\n3\n4\n5\n6\n7"} {
+		if _, err := ParseWorldFile(strings.NewReader(s)); err == nil {
+			t.Errorf("Expected error for %q", s)
+		}
+	}
+}
+
+// TestNewGroundOverlay tests LatLonBox and LatLonQuad computation
+func TestNewGroundOverlay(t *testing.T) {
+	// 40x20 pixels of 0.5° with the upper-left pixel centered at (-10.25, 50.25)
+	g := NewGroundOverlay(40, 20, WorldFile{A: 0.5, E: -0.5, C: -10.25, F: 50.25}, GeoreferenceOptions{Name: "n", Href: "h.png"})
+	want := LatLonBox{North: 50.5, South: 40.5, East: 9.5, West: -10.5}
+	if *g.LatLonBox != want {
+		t.Errorf("Expected %+v, got %+v", want, *g.LatLonBox)
+	}
+	if g.LatLonQuad != nil {
+		t.Error("Expected no LatLonQuad for a north-up image")
+	}
+	if g.Name != "n" || g.Icon.Href != "h.png" {
+		t.Errorf("Unexpected name or href: %q %q", g.Name, g.Icon.Href)
+	}
+
+	// A skew term tilts the image, so the corners no longer form a box.
+	skewed := NewGroundOverlay(10, 10, WorldFile{A: 0.1, B: 0.02, E: -0.1, C: 0, F: 1}, GeoreferenceOptions{})
+	if skewed.LatLonQuad == nil || len(skewed.LatLonQuad.Coordinates) != 4 {
+		t.Fatalf("Expected LatLonQuad for a skewed image, got %+v", skewed.LatLonQuad)
+	}
+	ll := skewed.LatLonQuad.Coordinates[0]
+	if !floatNear(ll.Lon, -0.05+0.02*9.5, 1e-9) || !floatNear(ll.Lat, 1.05-1, 1e-9) {
+		t.Errorf("Unexpected lower-left corner %v", ll)
+	}
+	sw, ne := ringBounds(skewed.LatLonQuad.Coordinates)
+	if skewed.LatLonBox.West != sw.Lon || skewed.LatLonBox.North != ne.Lat {
+		t.Errorf("Expected LatLonBox to bound the quad, got %+v", skewed.LatLonBox)
+	}
+
+	// Projected world files are unprojected per corner.
+	utm := NewGroundOverlay(100, 100, WorldFile{A: 10, E: -10, C: 500005, F: 4500995}, GeoreferenceOptions{
+		Unproject: func(x, y float64) Coordinate { return UTM{Zone: 33, Band: 'T', Easting: x, Northing: y}.Coordinate() },
+	})
+	if utm.LatLonQuad == nil {
+		t.Error("Expected LatLonQuad for a UTM image, whose grid is not aligned with meridians")
+	}
+	if !floatNear(utm.LatLonBox.West, 15, 1e-6) {
+		t.Errorf("Expected west edge on the central meridian, got %v", utm.LatLonBox.West)
+	}
+}
+
+// TestGroundOverlayFromWorldFile tests building an overlay from an image and world file
+func TestGroundOverlayFromWorldFile(t *testing.T) {
+	dir := t.TempDir()
+	imgPath := filepath.Join(dir, "scan.png")
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 8, 4))); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(imgPath, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	pgw := filepath.Join(dir, "scan.pgw")
+	if err := os.WriteFile(pgw, []byte("0.25\n0\n0\n-0.25\n1.125\n1.875\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	g, err := GroundOverlayFromWorldFile(imgPath, pgw, GeoreferenceOptions{})
+	if err != nil {
+		t.Fatalf("GroundOverlayFromWorldFile() error: %v", err)
+	}
+	want := LatLonBox{North: 2, South: 1, East: 3, West: 1}
+	if *g.LatLonBox != want {
+		t.Errorf("Expected %+v, got %+v", want, *g.LatLonBox)
+	}
+	if g.Name != "scan" || g.Icon.Href != "scan.png" {
+		t.Errorf("Expected defaults from the file name, got %q %q", g.Name, g.Icon.Href)
+	}
+
+	if _, err := GroundOverlayFromWorldFile(pgw, pgw, GeoreferenceOptions{}); err == nil {
+		t.Error("Expected error for a non-image file")
+	}
+	if _, err := GroundOverlayFromWorldFile(imgPath, filepath.Join(dir, "missing.pgw"), GeoreferenceOptions{}); err == nil {
+		t.Error("Expected error for a missing world file")
+	}
+}
+
+// TestGroundOverlayFromGeoTIFF tests tie point, transformation and UTM GeoTIFFs
+func TestGroundOverlayFromGeoTIFF(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, b geoTIFFBuilder) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, b.bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	geographic := []uint16{1, 1, 0, 1, geoKeyModelType, 0, 1, 2}
+
+	tie := write("tie.tif", geoTIFFBuilder{
+		width: 200, height: 100,
+		scale:    []float64{0.01, 0.01, 0},
+		tiepoint: []float64{0, 0, 0, -5, 51, 0},
+		keys:     geographic,
+	})
+	g, err := GroundOverlayFromGeoTIFF(tie, GeoreferenceOptions{Href: "tie.png"})
+	if err != nil {
+		t.Fatalf("GroundOverlayFromGeoTIFF() error: %v", err)
+	}
+	box := *g.LatLonBox
+	if !floatNear(box.West, -5, 1e-9) || !floatNear(box.East, -3, 1e-9) || !floatNear(box.North, 51, 1e-9) || !floatNear(box.South, 50, 1e-9) {
+		t.Errorf("Unexpected box from tie point: %+v", box)
+	}
+	if g.Name != "tie" || g.Icon.Href != "tie.png" {
+		t.Errorf("Unexpected name or href: %q %q", g.Name, g.Icon.Href)
+	}
+
+	// PixelIsPoint places the tie point on the first pixel center.
+	point := write("point.tif", geoTIFFBuilder{
+		width: 200, height: 100,
+		scale:    []float64{0.01, 0.01, 0},
+		tiepoint: []float64{0, 0, 0, -5, 51, 0},
+		keys:     []uint16{1, 1, 0, 2, geoKeyModelType, 0, 1, 2, geoKeyRasterType, 0, 1, 2},
+	})
+	if g, err := GroundOverlayFromGeoTIFF(point, GeoreferenceOptions{}); err != nil || !floatNear(g.LatLonBox.West, -5.005, 1e-9) {
+		t.Errorf("Expected half-pixel shift for PixelIsPoint, got %+v, %v", g, err)
+	}
+
+	transform := write("matrix.tif", geoTIFFBuilder{
+		width: 10, height: 10,
+		transform: []float64{0.1, 0, 0, 2, 0, -0.1, 0, 3, 0, 0, 0, 0, 0, 0, 0, 1},
+	})
+	if g, err := GroundOverlayFromGeoTIFF(transform, GeoreferenceOptions{}); err != nil || !floatNear(g.LatLonBox.East, 3, 1e-9) || !floatNear(g.LatLonBox.South, 2, 1e-9) {
+		t.Errorf("Unexpected overlay from transformation: %+v, %v", g, err)
+	}
+
+	utm := write("utm.tif", geoTIFFBuilder{
+		width: 100, height: 100,
+		scale:    []float64{10, 10, 0},
+		tiepoint: []float64{0, 0, 0, 500000, 4501000, 0},
+		keys:     []uint16{1, 1, 0, 2, geoKeyModelType, 0, 1, 1, geoKeyProjectedCSType, 0, 1, 32633},
+	})
+	g, err = GroundOverlayFromGeoTIFF(utm, GeoreferenceOptions{})
+	if err != nil {
+		t.Fatalf("GroundOverlayFromGeoTIFF() error for UTM: %v", err)
+	}
+	if !floatNear(g.LatLonBox.West, 15, 1e-6) || g.LatLonQuad == nil {
+		t.Errorf("Unexpected UTM overlay: %+v", g.LatLonBox)
+	}
+
+	other := write("lambert.tif", geoTIFFBuilder{
+		width: 10, height: 10,
+		scale:    []float64{1, 1, 0},
+		tiepoint: []float64{0, 0, 0, 0, 0, 0},
+		keys:     []uint16{1, 1, 0, 2, geoKeyModelType, 0, 1, 1, geoKeyProjectedCSType, 0, 1, 2154},
+	})
+	if _, err := GroundOverlayFromGeoTIFF(other, GeoreferenceOptions{}); !errors.Is(err, ErrNotGeoreferenced) {
+		t.Errorf("Expected ErrNotGeoreferenced for unsupported projection, got %v", err)
+	}
+
+	plain := write("plain.tif", geoTIFFBuilder{width: 10, height: 10})
+	if _, err := GroundOverlayFromGeoTIFF(plain, GeoreferenceOptions{}); !errors.Is(err, ErrNotGeoreferenced) {
+		t.Errorf("Expected ErrNotGeoreferenced, got %v", err)
+	}
+
+	junk := filepath.Join(dir, "junk.tif")
+	os.WriteFile(junk, []byte("not a tiff"), 0644)
+	if _, err := GroundOverlayFromGeoTIFF(junk, GeoreferenceOptions{}); err == nil {
+		t.Error("Expected error for a non-TIFF file")
+	}
+}
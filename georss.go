@@ -0,0 +1,166 @@
+package kml
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// XML namespaces for GeoRSS Simple and the W3C Basic Geo vocabulary.
+const (
+	GeoRSSNamespace = "http://www.georss.org/georss"
+	W3CGeoNamespace = "http://www.w3.org/2003/01/geo/wgs84_pos#"
+)
+
+// GeoRSS holds the location elements of a feed item or entry in GeoRSS
+// Simple (georss:point, georss:line, georss:polygon, georss:box) and W3C
+// Basic Geo (geo:lat, geo:long) form. It can be embedded in an RSS item or
+// Atom entry struct for use with encoding/xml.
+//
+// GeoRSS lists coordinates latitude first, separated by whitespace, unlike
+// KML's "lon,lat" tuples.
+type GeoRSS struct {
+	Point   string `xml:"http://www.georss.org/georss point,omitempty"`
+	Line    string `xml:"http://www.georss.org/georss line,omitempty"`
+	Polygon string `xml:"http://www.georss.org/georss polygon,omitempty"`
+	Box     string `xml:"http://www.georss.org/georss box,omitempty"`
+	Lat     string `xml:"http://www.w3.org/2003/01/geo/wgs84_pos# lat,omitempty"`
+	Long    string `xml:"http://www.w3.org/2003/01/geo/wgs84_pos# long,omitempty"`
+}
+
+// errNoGeoRSS is returned when a GeoRSS value has no location elements.
+var errNoGeoRSS = errors.New("kml: no GeoRSS location present")
+
+// Geometry converts the GeoRSS location to a KML geometry. The first
+// populated element wins, in the order point, line, polygon, box, then
+// W3C lat/long. A box becomes a closed rectangular Polygon.
+func (g GeoRSS) Geometry() (Geometry, error) {
+	switch {
+	case strings.TrimSpace(g.Point) != "":
+		coords, err := parseGeoRSSCoordinates(g.Point)
+		if err != nil {
+			return nil, err
+		}
+		if len(coords) != 1 {
+			return nil, fmt.Errorf("kml: georss:point must have 1 position, got %d", len(coords))
+		}
+		return &Point{Coordinates: coords[0]}, nil
+
+	case strings.TrimSpace(g.Line) != "":
+		coords, err := parseGeoRSSCoordinates(g.Line)
+		if err != nil {
+			return nil, err
+		}
+		if len(coords) < 2 {
+			return nil, fmt.Errorf("kml: georss:line must have at least 2 positions, got %d", len(coords))
+		}
+		return &LineString{Coordinates: coords}, nil
+
+	case strings.TrimSpace(g.Polygon) != "":
+		coords, err := parseGeoRSSCoordinates(g.Polygon)
+		if err != nil {
+			return nil, err
+		}
+		if len(coords) < 4 {
+			return nil, fmt.Errorf("kml: georss:polygon must have at least 4 positions, got %d", len(coords))
+		}
+		if coords[0] != coords[len(coords)-1] {
+			coords = append(coords, coords[0])
+		}
+		return &Polygon{OuterBoundary: LinearRing{Coordinates: coords}}, nil
+
+	case strings.TrimSpace(g.Box) != "":
+		coords, err := parseGeoRSSCoordinates(g.Box)
+		if err != nil {
+			return nil, err
+		}
+		if len(coords) != 2 {
+			return nil, fmt.Errorf("kml: georss:box must have 2 positions, got %d", len(coords))
+		}
+		sw, ne := coords[0], coords[1]
+		return &Polygon{OuterBoundary: LinearRing{Coordinates: []Coordinate{
+			{Lon: sw.Lon, Lat: sw.Lat},
+			{Lon: ne.Lon, Lat: sw.Lat},
+			{Lon: ne.Lon, Lat: ne.Lat},
+			{Lon: sw.Lon, Lat: ne.Lat},
+			{Lon: sw.Lon, Lat: sw.Lat},
+		}}}, nil
+
+	case strings.TrimSpace(g.Lat) != "" || strings.TrimSpace(g.Long) != "":
+		lat, err := strconv.ParseFloat(strings.TrimSpace(g.Lat), 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid geo:lat %q", ErrInvalidCoordinate, g.Lat)
+		}
+		lon, err := strconv.ParseFloat(strings.TrimSpace(g.Long), 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid geo:long %q", ErrInvalidCoordinate, g.Long)
+		}
+		return &Point{Coordinates: Coordinate{Lon: lon, Lat: lat}}, nil
+	}
+
+	return nil, errNoGeoRSS
+}
+
+// GeoRSSFromGeometry converts a KML geometry to GeoRSS Simple. Points,
+// LineStrings, gx:Tracks and the outer boundary of Polygons map directly;
+// altitudes, track times and Polygon holes have no GeoRSS Simple equivalent
+// and are dropped. A
+// MultiGeometry is accepted only if it holds exactly one geometry, since a
+// GeoRSS entry carries a single location.
+func GeoRSSFromGeometry(geom Geometry) (GeoRSS, error) {
+	switch g := geom.(type) {
+	case *Point:
+		return GeoRSS{Point: formatGeoRSSCoordinates([]Coordinate{g.Coordinates})}, nil
+	case *LineString:
+		return GeoRSS{Line: formatGeoRSSCoordinates(g.Coordinates)}, nil
+	case *Track:
+		return GeoRSS{Line: formatGeoRSSCoordinates(g.Coords)}, nil
+	case *LinearRing:
+		return GeoRSS{Polygon: formatGeoRSSCoordinates(g.Coordinates)}, nil
+	case *Polygon:
+		return GeoRSS{Polygon: formatGeoRSSCoordinates(g.OuterBoundary.Coordinates)}, nil
+	case *MultiGeometry:
+		if len(g.Geometries) == 1 {
+			return GeoRSSFromGeometry(g.Geometries[0])
+		}
+		return GeoRSS{}, fmt.Errorf("kml: cannot represent MultiGeometry of %d geometries in GeoRSS", len(g.Geometries))
+	case nil:
+		return GeoRSS{}, ErrMissingGeometry
+	}
+	return GeoRSS{}, fmt.Errorf("kml: unsupported geometry type %s for GeoRSS", geom.geometryType())
+}
+
+// parseGeoRSSCoordinates parses a whitespace-separated "lat lon lat lon ..."
+// list.
+func parseGeoRSSCoordinates(s string) ([]Coordinate, error) {
+	fields := strings.Fields(s)
+	if len(fields)%2 != 0 {
+		return nil, fmt.Errorf("%w: odd number of values in GeoRSS %q", ErrInvalidCoordinate, s)
+	}
+
+	coords := make([]Coordinate, 0, len(fields)/2)
+	for i := 0; i < len(fields); i += 2 {
+		lat, err := strconv.ParseFloat(fields[i], 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid latitude %q", ErrInvalidCoordinate, fields[i])
+		}
+		lon, err := strconv.ParseFloat(fields[i+1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid longitude %q", ErrInvalidCoordinate, fields[i+1])
+		}
+		coords = append(coords, Coordinate{Lon: lon, Lat: lat})
+	}
+	return coords, nil
+}
+
+// formatGeoRSSCoordinates formats coordinates as "lat lon lat lon ...".
+func formatGeoRSSCoordinates(coords []Coordinate) string {
+	parts := make([]string, 0, len(coords)*2)
+	for _, c := range coords {
+		parts = append(parts,
+			strconv.FormatFloat(c.Lat, 'f', -1, 64),
+			strconv.FormatFloat(c.Lon, 'f', -1, 64))
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,149 @@
+package kml
+
+import (
+	"encoding/xml"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestGeoRSSGeometry(t *testing.T) {
+	tests := []struct {
+		name     string
+		geo      GeoRSS
+		wantType string
+		wantLen  int
+		first    Coordinate
+	}{
+		{"point", GeoRSS{Point: "45.256 -71.92"}, "Point", 1, Coordinate{Lon: -71.92, Lat: 45.256}},
+		{"line", GeoRSS{Line: "45.256 -110.45 46.46 -109.48 43.84 -109.86"}, "LineString", 3, Coordinate{Lon: -110.45, Lat: 45.256}},
+		{"open polygon is closed", GeoRSS{Polygon: "0 0 0 1 1 1 1 0"}, "Polygon", 5, Coordinate{}},
+		{"closed polygon", GeoRSS{Polygon: "0 0 0 1 1 1 0 0"}, "Polygon", 4, Coordinate{}},
+		{"box", GeoRSS{Box: "42.943 -71.032 43.039 -69.856"}, "Polygon", 5, Coordinate{Lon: -71.032, Lat: 42.943}},
+		{"w3c geo", GeoRSS{Lat: " 55.701 ", Long: "12.552"}, "Point", 1, Coordinate{Lon: 12.552, Lat: 55.701}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := tt.geo.Geometry()
+			if err != nil {
+				t.Fatalf("Geometry failed: %v", err)
+			}
+			if g.geometryType() != tt.wantType {
+				t.Fatalf("Expected %s, got %s", tt.wantType, g.geometryType())
+			}
+
+			var coords []Coordinate
+			switch v := g.(type) {
+			case *Point:
+				coords = []Coordinate{v.Coordinates}
+			case *LineString:
+				coords = v.Coordinates
+			case *Polygon:
+				coords = v.OuterBoundary.Coordinates
+			}
+			if len(coords) != tt.wantLen {
+				t.Fatalf("Expected %d coordinates, got %d", tt.wantLen, len(coords))
+			}
+			if coords[0] != tt.first {
+				t.Errorf("Expected first coordinate %v, got %v", tt.first, coords[0])
+			}
+		})
+	}
+}
+
+func TestGeoRSSGeometryErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		geo  GeoRSS
+	}{
+		{"empty", GeoRSS{}},
+		{"odd values", GeoRSS{Point: "45.0"}},
+		{"bad number", GeoRSS{Point: "north -71"}},
+		{"two point positions", GeoRSS{Point: "1 2 3 4"}},
+		{"short line", GeoRSS{Line: "1 2"}},
+		{"short polygon", GeoRSS{Polygon: "0 0 1 1 0 0"}},
+		{"bad box", GeoRSS{Box: "0 0"}},
+		{"missing long", GeoRSS{Lat: "1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.geo.Geometry(); err == nil {
+				t.Error("Expected error")
+			}
+		})
+	}
+
+	if _, err := (GeoRSS{Point: "x y"}).Geometry(); !errors.Is(err, ErrInvalidCoordinate) {
+		t.Errorf("Expected ErrInvalidCoordinate, got %v", err)
+	}
+}
+
+func TestGeoRSSFromGeometry(t *testing.T) {
+	tests := []struct {
+		name string
+		geom Geometry
+		want GeoRSS
+	}{
+		{"point", &Point{Coordinates: Coordinate{Lon: -71.92, Lat: 45.256, Alt: 100}}, GeoRSS{Point: "45.256 -71.92"}},
+		{"line", &LineString{Coordinates: []Coordinate{{Lon: 1, Lat: 2}, {Lon: 3, Lat: 4}}}, GeoRSS{Line: "2 1 4 3"}},
+		{"polygon", &Polygon{
+			OuterBoundary:   LinearRing{Coordinates: []Coordinate{{0, 0, 0}, {1, 0, 0}, {1, 1, 0}, {0, 0, 0}}},
+			InnerBoundaries: []LinearRing{{Coordinates: []Coordinate{{0.1, 0.1, 0}}}},
+		}, GeoRSS{Polygon: "0 0 0 1 1 1 0 0"}},
+		{"single multigeometry", &MultiGeometry{Geometries: []Geometry{&Point{Coordinates: Coordinate{Lon: 1, Lat: 2}}}}, GeoRSS{Point: "2 1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GeoRSSFromGeometry(tt.geom)
+			if err != nil {
+				t.Fatalf("GeoRSSFromGeometry failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+
+	if _, err := GeoRSSFromGeometry(&MultiGeometry{Geometries: []Geometry{&Point{}, &Point{}}}); err == nil {
+		t.Error("Expected error for MultiGeometry with 2 geometries")
+	}
+	if _, err := GeoRSSFromGeometry(nil); !errors.Is(err, ErrMissingGeometry) {
+		t.Errorf("Expected ErrMissingGeometry, got %v", err)
+	}
+}
+
+func TestGeoRSSFeedItem(t *testing.T) {
+	type item struct {
+		XMLName xml.Name `xml:"item"`
+		Title   string   `xml:"title"`
+		GeoRSS
+	}
+
+	src := `<item xmlns:georss="http://www.georss.org/georss">
+		<title>Office</title>
+		<georss:point>37.42 -122.08</georss:point>
+	</item>`
+
+	var it item
+	if err := xml.Unmarshal([]byte(src), &it); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	g, err := it.Geometry()
+	if err != nil {
+		t.Fatalf("Geometry failed: %v", err)
+	}
+	if c := g.(*Point).Coordinates; c.Lon != -122.08 || c.Lat != 37.42 {
+		t.Errorf("Expected (-122.08, 37.42), got %v", c)
+	}
+
+	out, err := xml.Marshal(it)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if !strings.Contains(string(out), GeoRSSNamespace) || !strings.Contains(string(out), "37.42 -122.08") {
+		t.Errorf("Expected GeoRSS point in output, got %s", out)
+	}
+}
@@ -0,0 +1,68 @@
+package kml
+
+// AnnotateGrid stores a grid reference for every placemark in an
+// ExtendedData field with the given name, replacing any existing value.
+// encode converts the placemark's anchor point, which is the Point
+// coordinate or the bounding box center of other geometries, to the
+// reference string. Placemarks without geometry, or whose anchor encode
+// rejects, are left unchanged. It returns the number of placemarks
+// annotated.
+func (k *KML) AnnotateGrid(name string, encode func(Coordinate) (string, error)) int {
+	return k.annotate(func(pm *Placemark, c Coordinate) bool {
+		ref, err := encode(c)
+		if err != nil {
+			return false
+		}
+		setData(pm, name, ref)
+		return true
+	})
+}
+
+// annotate calls fn with every placemark that has an anchor point and
+// returns the number of calls that reported true.
+func (k *KML) annotate(fn func(pm *Placemark, anchor Coordinate) bool) int {
+	annotated := 0
+
+	k.Walk(func(f Feature) error {
+		pm, ok := f.(*Placemark)
+		if !ok {
+			return nil
+		}
+		c, ok := anchorPoint(pm.Geometry)
+		if ok && fn(pm, c) {
+			annotated++
+		}
+		return nil
+	})
+
+	return annotated
+}
+
+// anchorPoint returns the Point coordinate of g, or the center of the
+// bounding box of its coordinates for other geometry types.
+func anchorPoint(g Geometry) (Coordinate, bool) {
+	if pt, ok := g.(*Point); ok {
+		return pt.Coordinates, true
+	}
+	coords := getGeometryCoordinates(g)
+	if len(coords) == 0 {
+		return Coordinate{}, false
+	}
+	sw, ne := ringBounds(coords)
+	return Coordinate{Lon: (sw.Lon + ne.Lon) / 2, Lat: (sw.Lat + ne.Lat) / 2}, true
+}
+
+// setData sets the named ExtendedData Data value on pm, replacing the
+// first existing entry with that name or appending a new one.
+func setData(pm *Placemark, name, value string) {
+	if pm.ExtendedData == nil {
+		pm.ExtendedData = &ExtendedData{}
+	}
+	for i := range pm.ExtendedData.Data {
+		if pm.ExtendedData.Data[i].Name == name {
+			pm.ExtendedData.Data[i].Value = value
+			return
+		}
+	}
+	pm.ExtendedData.Data = append(pm.ExtendedData.Data, Data{Name: name, Value: value})
+}
@@ -0,0 +1,39 @@
+package kml
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+)
+
+// hashFeature returns the hex SHA-256 of a feature's XML encoding. Encoding
+// is deterministic, so equal features hash equally across runs and
+// processes. It returns "" if the feature cannot be encoded.
+func hashFeature(f Feature) string {
+	h := sha256.New()
+	enc := xml.NewEncoder(h)
+	if err := encodeFeature(enc, f); err != nil {
+		return ""
+	}
+	if err := enc.Flush(); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Hash implements the Feature interface.
+func (d *Document) Hash() string {
+	return hashFeature(d)
+}
+
+// Hash implements the Feature interface.
+func (f *Folder) Hash() string {
+	return hashFeature(f)
+}
+
+// Hash implements the Feature interface.
+// Placemarks with the same ID but different content hash differently, so
+// comparing hashes keyed by ID between runs reveals which placemarks changed.
+func (p *Placemark) Hash() string {
+	return hashFeature(p)
+}
@@ -0,0 +1,87 @@
+package kml
+
+import "testing"
+
+func hashTestPlacemark() *Placemark {
+	return &Placemark{
+		ID:       "pm1",
+		Name:     "Office",
+		StyleURL: "#s",
+		Geometry: &Point{Coordinates: Coord(-122.08, 37.42)},
+		ExtendedData: &ExtendedData{
+			Data: []Data{{Name: "floor", Value: "3"}},
+		},
+	}
+}
+
+func TestPlacemarkHash(t *testing.T) {
+	a := hashTestPlacemark()
+	b := hashTestPlacemark()
+
+	if len(a.Hash()) != 64 {
+		t.Fatalf("Expected 64-character hex hash, got %q", a.Hash())
+	}
+	if a.Hash() != b.Hash() {
+		t.Error("Expected equal placemarks to hash equally")
+	}
+
+	tests := []struct {
+		name   string
+		change func(*Placemark)
+	}{
+		{"name", func(p *Placemark) { p.Name = "Home" }},
+		{"style", func(p *Placemark) { p.StyleURL = "#t" }},
+		{"geometry", func(p *Placemark) { p.Geometry = &Point{Coordinates: Coord(-122.08, 37.43)} }},
+		{"data", func(p *Placemark) { p.ExtendedData.Data[0].Value = "4" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pm := hashTestPlacemark()
+			tt.change(pm)
+			if pm.Hash() == a.Hash() {
+				t.Errorf("Expected hash to change when %s changes", tt.name)
+			}
+		})
+	}
+}
+
+func TestContainerHash(t *testing.T) {
+	folder := &Folder{Name: "F", Features: []Feature{hashTestPlacemark()}}
+	doc := &Document{Name: "D", Features: []Feature{folder}}
+
+	before := doc.Hash()
+	if before == "" || folder.Hash() == "" {
+		t.Fatal("Expected non-empty hashes")
+	}
+
+	folder.Features[0].(*Placemark).Name = "Changed"
+	if doc.Hash() == before {
+		t.Error("Expected document hash to change when a nested placemark changes")
+	}
+}
+
+func TestHashStableAcrossRoundTrip(t *testing.T) {
+	k := NewKML()
+	k.Feature = hashTestPlacemark()
+	want := k.Feature.Hash()
+
+	data, err := k.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes failed: %v", err)
+	}
+	parsed, err := ParseBytes(data)
+	if err != nil {
+		t.Fatalf("ParseBytes failed: %v", err)
+	}
+	if got := parsed.Feature.Hash(); got != want {
+		t.Errorf("Expected hash %s after round trip, got %s", want, got)
+	}
+}
+
+func TestHashUnencodable(t *testing.T) {
+	pm := &Placemark{Geometry: failingGeometry{}}
+	if h := pm.Hash(); h != "" {
+		t.Errorf("Expected empty hash for unencodable feature, got %q", h)
+	}
+}
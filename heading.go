@@ -0,0 +1,220 @@
+package kml
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ArrowIconHref is the default icon of StyleByHeading, the name under which
+// ArrowIcon is meant to be stored in a KMZ archive.
+const ArrowIconHref = "files/arrow.png"
+
+// HeadingOptions configures StyleByHeading.
+type HeadingOptions struct {
+	// Field is the ExtendedData name holding each placemark's heading, in
+	// degrees clockwise from north. The default is "heading".
+	Field string
+
+	// Step rounds headings to multiples of Step degrees, so placemarks
+	// with similar headings share a style. The default is 10.
+	Step float64
+
+	// Icon is the href of the icon, which should point north. The default
+	// is ArrowIconHref.
+	Icon string
+
+	// Color tints the icon; the zero value leaves it untinted.
+	Color Color
+
+	// Scale is the icon scale; zero leaves the default.
+	Scale float64
+
+	// Prefix is prepended to the heading to form style IDs. The default is
+	// "heading-".
+	Prefix string
+
+	// HashIDs derives style IDs from the content of each style, as
+	// "heading-3fa2bc", so styles differing in color or icon do not share
+	// an ID across files.
+	HashIDs bool
+}
+
+// defaults returns o with zero fields set to their defaults.
+func (o HeadingOptions) defaults() HeadingOptions {
+	if o.Field == "" {
+		o.Field = "heading"
+	}
+	if o.Step <= 0 {
+		o.Step = 10
+	}
+	if o.Icon == "" {
+		o.Icon = ArrowIconHref
+	}
+	if o.Prefix == "" {
+		o.Prefix = "heading-"
+	}
+	return o
+}
+
+// StyleByHeading points each placemark with a numeric heading in its
+// ExtendedData at a style whose IconStyle is rotated to match, as for
+// vehicle and vessel positions. Headings are normalized to [0, 360) and
+// rounded to opts.Step; placemarks without a valid heading are left
+// unchanged. StyleByHeading returns the styles used, in heading order, to
+// be added to the Document. To ship the default arrow icon, write the
+// document as a KMZ with KMZFile(ArrowIconHref, ArrowIcon(32)).
+func StyleByHeading(pms []*Placemark, opts HeadingOptions) []Style {
+	opts = opts.defaults()
+	value := NumericData(opts.Field)
+
+	used := make(map[float64]bool)
+	var styled []Feature
+	for _, pm := range pms {
+		h := value(pm)
+		if math.IsNaN(h) || math.IsInf(h, 0) {
+			continue
+		}
+		h = math.Round(normalizeHeading(h)/opts.Step) * opts.Step
+		h = normalizeHeading(h)
+		used[h] = true
+		pm.StyleURL = "#" + headingStyleID(opts.Prefix, h)
+		styled = append(styled, pm)
+	}
+
+	headings := make([]float64, 0, len(used))
+	for h := range used {
+		headings = append(headings, h)
+	}
+	sort.Float64s(headings)
+
+	styles := make([]Style, len(headings))
+	for i, h := range headings {
+		styles[i] = Style{
+			ID: headingStyleID(opts.Prefix, h),
+			IconStyle: &IconStyle{
+				Color:   opts.Color,
+				Scale:   opts.Scale,
+				Heading: h,
+				Icon:    &Icon{Href: opts.Icon},
+			},
+		}
+	}
+	if opts.HashIDs {
+		styles = hashStyleIDs(styles, opts.Prefix, nil, styled...)
+	}
+	return styles
+}
+
+// normalizeHeading wraps h into [0, 360).
+func normalizeHeading(h float64) float64 {
+	h = math.Mod(h, 360)
+	if h < 0 {
+		h += 360
+	}
+	if h >= 360 {
+		h = 0
+	}
+	return h
+}
+
+// headingStyleID returns the style ID for heading h, with any fraction
+// written using "_" so the ID stays a valid XML name.
+func headingStyleID(prefix string, h float64) string {
+	return prefix + strings.ReplaceAll(strconv.FormatFloat(h, 'f', -1, 64), ".", "_")
+}
+
+// ArrowIcon renders a north-pointing arrowhead as a PNG of size by size
+// pixels, at least 8. The arrow is white with a dark outline, so an
+// IconStyle color tints it.
+func ArrowIcon(size int) []byte {
+	size = max(8, size)
+	outer := []iconPoint{{0.5, 0.04}, {0.9, 0.94}, {0.5, 0.74}, {0.1, 0.94}}
+	inner := insetPolygon(outer, 0.07)
+
+	img := image.NewNRGBA(image.Rect(0, 0, size, size))
+	const samples = 4
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			var in, fill int
+			for sy := 0; sy < samples; sy++ {
+				for sx := 0; sx < samples; sx++ {
+					p := iconPoint{
+						(float64(x) + (float64(sx)+0.5)/samples) / float64(size),
+						(float64(y) + (float64(sy)+0.5)/samples) / float64(size),
+					}
+					if pointInIcon(p, outer) {
+						in++
+						if pointInIcon(p, inner) {
+							fill++
+						}
+					}
+				}
+			}
+			if in == 0 {
+				continue
+			}
+			// Blend white fill over the dark outline, then apply coverage.
+			v := uint8(0x30 + (0xff-0x30)*fill/in)
+			img.SetNRGBA(x, y, color.NRGBA{v, v, v, uint8(255 * in / (samples * samples))})
+		}
+	}
+
+	var buf bytes.Buffer
+	png.Encode(&buf, img) // Encoding to a buffer cannot fail
+	return buf.Bytes()
+}
+
+// iconPoint is a point in the unit square, used to draw icons.
+type iconPoint struct{ X, Y float64 }
+
+// pointInIcon reports whether p lies inside the polygon poly.
+func pointInIcon(p iconPoint, poly []iconPoint) bool {
+	in := false
+	for i, j := 0, len(poly)-1; i < len(poly); j, i = i, i+1 {
+		a, b := poly[i], poly[j]
+		if (a.Y > p.Y) != (b.Y > p.Y) && p.X < a.X+(p.Y-a.Y)*(b.X-a.X)/(b.Y-a.Y) {
+			in = !in
+		}
+	}
+	return in
+}
+
+// insetPolygon moves each edge of poly inward by d and returns the polygon
+// formed by the moved edges.
+func insetPolygon(poly []iconPoint, d float64) []iconPoint {
+	n := len(poly)
+	area := 0.0
+	for i := range poly {
+		a, b := poly[i], poly[(i+1)%n]
+		area += a.X*b.Y - b.X*a.Y
+	}
+	sign := 1.0
+	if area < 0 {
+		sign = -1
+	}
+
+	type line struct{ p, dir iconPoint }
+	lines := make([]line, n)
+	for i := range poly {
+		a, b := poly[i], poly[(i+1)%n]
+		dx, dy := b.X-a.X, b.Y-a.Y
+		l := math.Hypot(dx, dy)
+		nx, ny := -dy/l*sign, dx/l*sign
+		lines[i] = line{iconPoint{a.X + nx*d, a.Y + ny*d}, iconPoint{dx, dy}}
+	}
+
+	out := make([]iconPoint, n)
+	for i := range lines {
+		l1, l2 := lines[(i+n-1)%n], lines[i]
+		den := l1.dir.X*l2.dir.Y - l1.dir.Y*l2.dir.X
+		t := ((l2.p.X-l1.p.X)*l2.dir.Y - (l2.p.Y-l1.p.Y)*l2.dir.X) / den
+		out[i] = iconPoint{l1.p.X + t*l1.dir.X, l1.p.Y + t*l1.dir.Y}
+	}
+	return out
+}
@@ -0,0 +1,106 @@
+package kml
+
+import (
+	"bytes"
+	"image/png"
+	"testing"
+)
+
+// headingPlacemark returns a placemark with a heading data value
+func headingPlacemark(heading string) *Placemark {
+	return &Placemark{
+		Geometry:     &Point{},
+		ExtendedData: &ExtendedData{Data: []Data{{Name: "heading", Value: heading}}},
+	}
+}
+
+// TestStyleByHeading tests rotated icon styles from heading data
+func TestStyleByHeading(t *testing.T) {
+	pms := []*Placemark{
+		headingPlacemark("92"),
+		headingPlacemark("-90"),
+		headingPlacemark("357"),
+		headingPlacemark("88.4"),
+		headingPlacemark("north"),
+		{Geometry: &Point{}, StyleURL: "#keep"},
+	}
+
+	styles := StyleByHeading(pms, HeadingOptions{Color: Red, Scale: 1.5})
+
+	wantURLs := []string{"#heading-90", "#heading-270", "#heading-0", "#heading-90", "", "#keep"}
+	for i, pm := range pms {
+		if pm.StyleURL != wantURLs[i] {
+			t.Errorf("Placemark %d: expected styleUrl %q, got %q", i, wantURLs[i], pm.StyleURL)
+		}
+	}
+
+	wantHeadings := []float64{0, 90, 270}
+	if len(styles) != len(wantHeadings) {
+		t.Fatalf("Expected %d styles, got %d", len(wantHeadings), len(styles))
+	}
+	for i, s := range styles {
+		is := s.IconStyle
+		if is == nil || is.Heading != wantHeadings[i] {
+			t.Errorf("Style %d: expected heading %v, got %+v", i, wantHeadings[i], is)
+			continue
+		}
+		if is.Color != Red || is.Scale != 1.5 || is.Icon == nil || is.Icon.Href != ArrowIconHref {
+			t.Errorf("Style %d: expected red arrow at scale 1.5, got %+v", i, is)
+		}
+	}
+}
+
+// TestStyleByHeadingOptions tests the field, step, icon and prefix options
+func TestStyleByHeadingOptions(t *testing.T) {
+	pm := &Placemark{ExtendedData: &ExtendedData{Data: []Data{{Name: "cog", Value: "47.6"}}}}
+	styles := StyleByHeading([]*Placemark{pm}, HeadingOptions{Field: "cog", Step: 2.5, Icon: "boat.png", Prefix: "cog"})
+
+	if pm.StyleURL != "#cog47_5" {
+		t.Errorf("Expected styleUrl #cog47_5, got %q", pm.StyleURL)
+	}
+	if len(styles) != 1 || styles[0].IconStyle.Heading != 47.5 || styles[0].IconStyle.Icon.Href != "boat.png" {
+		t.Errorf("Expected one boat style at 47.5, got %+v", styles)
+	}
+}
+
+// TestArrowIcon tests the rendered arrow image
+func TestArrowIcon(t *testing.T) {
+	img, err := png.Decode(bytes.NewReader(ArrowIcon(32)))
+	if err != nil {
+		t.Fatalf("Expected a PNG: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 32 || b.Dy() != 32 {
+		t.Errorf("Expected 32x32, got %v", b)
+	}
+
+	alpha := func(x, y int) uint32 {
+		_, _, _, a := img.At(x, y).RGBA()
+		return a
+	}
+	if alpha(0, 0) != 0 || alpha(31, 0) != 0 {
+		t.Error("Expected transparent corners")
+	}
+	if alpha(16, 12) != 0xffff {
+		t.Error("Expected an opaque arrow body")
+	}
+	if r, _, _, _ := img.At(16, 12).RGBA(); r != 0xffff {
+		t.Errorf("Expected a white arrow body, got red %#x", r)
+	}
+	// The arrow points up: the top row is narrower than the row above the notch.
+	width := func(y int) int {
+		n := 0
+		for x := 0; x < 32; x++ {
+			if alpha(x, y) > 0 {
+				n++
+			}
+		}
+		return n
+	}
+	if width(4) >= width(20) {
+		t.Errorf("Expected the arrow to widen downward, got widths %d and %d", width(4), width(20))
+	}
+
+	if small, _ := png.Decode(bytes.NewReader(ArrowIcon(1))); small.Bounds().Dx() != 8 {
+		t.Errorf("Expected minimum size 8, got %d", small.Bounds().Dx())
+	}
+}
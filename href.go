@@ -0,0 +1,161 @@
+package kml
+
+import (
+	"net/url"
+	"path"
+	"strings"
+)
+
+// RewriteHrefs replaces every resource reference in the document with the
+// result of fn: Icon and ItemIcon hrefs in shared and inline styles,
+// overlay image hrefs, NetworkLink hrefs, the styleUrls of every feature,
+// and the styleUrls of StyleMap pairs. Empty references are left untouched.
+//
+// This makes it possible to rebase absolute URLs onto a CDN, convert them to
+// KMZ-relative paths, or upgrade them to https in a single pass.
+func (k *KML) RewriteHrefs(fn func(string) string) {
+	k.Walk(func(f Feature) error {
+		eachHref(f, func(s *string) {
+			*s = fn(*s)
+		})
+		return nil
+	})
+}
+
+// ResolveHrefs makes the relative references of the document absolute by
+// resolving them against base, the location the document is read from, as
+// a browser resolves links: Icon and ItemIcon hrefs, overlay image hrefs,
+// NetworkLink hrefs and styleUrls naming other files. References to styles
+// within the document, such as "#shared", and references that are already
+// absolute are left as they are. base may be a URL or a path, such as
+// "/srv/layers/roads.kml"; resolving against a directory needs a trailing
+// slash. Documents extracted from a KMZ or moved to another host keep
+// working once their references are resolved against their old location.
+func (k *KML) ResolveHrefs(base url.URL) {
+	k.RewriteHrefs(func(href string) string {
+		if strings.HasPrefix(href, "#") {
+			return href
+		}
+		ref, err := url.Parse(href)
+		if err != nil || ref.IsAbs() {
+			return href
+		}
+		return base.ResolveReference(ref).String()
+	})
+}
+
+// Relativize is the inverse of ResolveHrefs: it rewrites the references of
+// the document that are under the scheme and host of base as paths
+// relative to the directory of base, such as "icons/a.png" in a document
+// at "https://example.com/maps/doc.kml" for
+// "https://example.com/maps/icons/a.png", or "../shared/styles.kml#s" for
+// "https://example.com/shared/styles.kml#s". Relative references and those
+// on other hosts are left as they are. Relativizing a document before
+// moving it, together with the files beside it, keeps it working at its
+// new location.
+func (k *KML) Relativize(base url.URL) {
+	k.RewriteHrefs(func(href string) string {
+		if strings.HasPrefix(href, "#") {
+			return href
+		}
+		ref, err := url.Parse(href)
+		if err != nil || ref.Opaque != "" || !strings.HasPrefix(ref.EscapedPath(), "/") {
+			return href
+		}
+		if ref.Scheme == "" && ref.Host == "" && (base.Scheme != "" || base.Host != "") {
+			return href
+		}
+		if !strings.EqualFold(ref.Scheme, base.Scheme) || !strings.EqualFold(ref.Host, base.Host) || ref.User.String() != base.User.String() {
+			return href
+		}
+
+		rel := relativePath(baseDir(base.EscapedPath()), ref.EscapedPath())
+		if ref.RawQuery != "" || ref.ForceQuery {
+			rel += "?" + ref.RawQuery
+		}
+		if ref.Fragment != "" {
+			rel += "#" + ref.EscapedFragment()
+		}
+		return rel
+	})
+}
+
+// baseDir returns the directory of a URL path, ending in a slash.
+func baseDir(p string) string {
+	return p[:strings.LastIndex(p, "/")+1]
+}
+
+// relativePath returns the path of target, an absolute URL path, relative
+// to dir, an absolute URL path ending in a slash.
+func relativePath(dir, target string) string {
+	from := strings.Split(strings.TrimSuffix(dir, "/"), "/")
+	to := strings.Split(target, "/")
+	common := 0
+	for common < len(from) && common < len(to)-1 && from[common] == to[common] {
+		common++
+	}
+
+	parts := make([]string, 0, len(from)-common+len(to)-common)
+	for range from[common:] {
+		parts = append(parts, "..")
+	}
+	parts = append(parts, to[common:]...)
+	rel := path.Join(parts...)
+	if rel == "" {
+		rel = "."
+	}
+	if strings.HasSuffix(target, "/") && rel != "." {
+		rel += "/"
+	}
+	// A first segment holding a colon would be read as a scheme.
+	if rel == "." || strings.Contains(strings.SplitN(rel, "/", 2)[0], ":") {
+		rel = "./" + strings.TrimPrefix(rel, ".")
+	}
+	return rel
+}
+
+// eachHref calls fn with a pointer to each non-empty resource reference
+// held directly by f, as described for RewriteHrefs.
+func eachHref(f Feature, fn func(*string)) {
+	visit := func(s *string) {
+		if *s != "" {
+			fn(s)
+		}
+	}
+
+	visitStyle := func(s *Style) {
+		eachIconHref(s, fn)
+	}
+
+	switch feature := f.(type) {
+	case *Document:
+		visit(&feature.StyleURL)
+		for i := range feature.Styles {
+			visitStyle(&feature.Styles[i])
+		}
+		for i := range feature.StyleMaps {
+			pairs := feature.StyleMaps[i].Pairs
+			for j := range pairs {
+				visit(&pairs[j].StyleURL)
+			}
+		}
+	case *Folder:
+		visit(&feature.StyleURL)
+	case *Placemark:
+		visit(&feature.StyleURL)
+		visitStyle(feature.Style)
+	case *GroundOverlay:
+		visit(&feature.StyleURL)
+		if feature.Icon != nil {
+			visit(&feature.Icon.Href)
+		}
+	case *ScreenOverlay:
+		if feature.Icon != nil {
+			visit(&feature.Icon.Href)
+		}
+	case *NetworkLink:
+		if feature.Link != nil {
+			visit(&feature.Link.Href)
+		}
+	}
+}
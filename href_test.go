@@ -0,0 +1,168 @@
+package kml
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestRewriteHrefs tests that every href and styleUrl is passed through the rewrite function
+func TestRewriteHrefs(t *testing.T) {
+	kmlData := `<?xml version="1.0" encoding="UTF-8"?>
+<kml xmlns="http://www.opengis.net/kml/2.2">
+  <Document>
+    <Style id="shared">
+      <IconStyle><Icon><href>http://example.com/icons/a.png</href></Icon></IconStyle>
+      <ListStyle><ItemIcon><state>open</state><href>http://example.com/icons/open.png</href></ItemIcon></ListStyle>
+    </Style>
+    <StyleMap id="map">
+      <Pair><key>normal</key><styleUrl>http://example.com/styles.kml#n</styleUrl></Pair>
+      <Pair><key>highlight</key><styleUrl>#shared</styleUrl></Pair>
+    </StyleMap>
+    <Placemark>
+      <styleUrl>http://example.com/styles.kml#p</styleUrl>
+      <Style>
+        <IconStyle><Icon><href>http://example.com/icons/b.png</href></Icon></IconStyle>
+      </Style>
+      <Point><coordinates>0,0</coordinates></Point>
+    </Placemark>
+    <Placemark>
+      <Point><coordinates>1,1</coordinates></Point>
+    </Placemark>
+  </Document>
+</kml>`
+
+	k, err := ParseBytes([]byte(kmlData))
+	if err != nil {
+		t.Fatalf("Failed to parse KML: %v", err)
+	}
+
+	var visited []string
+	k.RewriteHrefs(func(href string) string {
+		visited = append(visited, href)
+		return strings.Replace(href, "http://", "https://", 1)
+	})
+
+	if len(visited) != 6 {
+		t.Fatalf("Expected 6 references to be visited, got %d: %v", len(visited), visited)
+	}
+
+	doc := k.Feature.(*Document)
+	if got := doc.Styles[0].IconStyle.Icon.Href; got != "https://example.com/icons/a.png" {
+		t.Errorf("Expected shared icon href to be rewritten, got %q", got)
+	}
+	if got := doc.Styles[0].ListStyle.ItemIcons[0].Href; got != "https://example.com/icons/open.png" {
+		t.Errorf("Expected item icon href to be rewritten, got %q", got)
+	}
+	if got := doc.StyleMaps[0].Pairs[0].StyleURL; got != "https://example.com/styles.kml#n" {
+		t.Errorf("Expected pair styleUrl to be rewritten, got %q", got)
+	}
+	if got := doc.StyleMaps[0].Pairs[1].StyleURL; got != "#shared" {
+		t.Errorf("Expected local pair styleUrl to be unchanged, got %q", got)
+	}
+
+	pm := doc.Features[0].(*Placemark)
+	if pm.StyleURL != "https://example.com/styles.kml#p" {
+		t.Errorf("Expected placemark styleUrl to be rewritten, got %q", pm.StyleURL)
+	}
+	if got := pm.Style.IconStyle.Icon.Href; got != "https://example.com/icons/b.png" {
+		t.Errorf("Expected inline icon href to be rewritten, got %q", got)
+	}
+
+	if empty := doc.Features[1].(*Placemark); empty.StyleURL != "" {
+		t.Errorf("Expected empty styleUrl to stay empty, got %q", empty.StyleURL)
+	}
+}
+
+// TestResolveHrefs tests making relative references absolute and back
+func TestResolveHrefs(t *testing.T) {
+	newKML := func() *KML {
+		k := NewKML()
+		k.Feature = &Document{
+			Styles: []Style{{ID: "s", IconStyle: &IconStyle{Icon: &Icon{Href: "icons/a%20b.png"}}}},
+			StyleMaps: []StyleMap{{ID: "m", Pairs: []Pair{
+				{Key: "normal", StyleURL: "#s"},
+				{Key: "highlight", StyleURL: "../shared/styles.kml#hi"},
+			}}},
+			Features: []Feature{
+				&Placemark{StyleURL: "#m"},
+				&GroundOverlay{Icon: &Icon{Href: "http://tiles.example.org/t.png"}},
+				&NetworkLink{Link: &Link{Href: "feeds/live.kml?since=1"}},
+			},
+		}
+		return k
+	}
+	hrefs := func(k *KML) []string {
+		var got []string
+		k.RewriteHrefs(func(href string) string {
+			got = append(got, href)
+			return href
+		})
+		return got
+	}
+	original := hrefs(newKML())
+
+	tests := []struct {
+		base string
+		want []string
+	}{
+		{"https://example.com/maps/doc.kml", []string{
+			"https://example.com/maps/icons/a%20b.png",
+			"#s",
+			"https://example.com/shared/styles.kml#hi",
+			"#m",
+			"http://tiles.example.org/t.png",
+			"https://example.com/maps/feeds/live.kml?since=1",
+		}},
+		{"/srv/layers/", []string{
+			"/srv/layers/icons/a%20b.png",
+			"#s",
+			"/srv/shared/styles.kml#hi",
+			"#m",
+			"http://tiles.example.org/t.png",
+			"/srv/layers/feeds/live.kml?since=1",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.base, func(t *testing.T) {
+			base, err := url.Parse(tt.base)
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			k := newKML()
+			k.ResolveHrefs(*base)
+			if got := hrefs(k); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+			k.Relativize(*base)
+			if got := hrefs(k); !reflect.DeepEqual(got, original) {
+				t.Errorf("Expected %v after Relativize, got %v", original, got)
+			}
+		})
+	}
+}
+
+// TestRelativePath tests paths relative to a directory
+func TestRelativePath(t *testing.T) {
+	tests := []struct {
+		dir    string
+		target string
+		want   string
+	}{
+		{"/maps/", "/maps/icons/a.png", "icons/a.png"},
+		{"/maps/", "/shared/styles.kml", "../shared/styles.kml"},
+		{"/a/b/c/", "/a/x", "../../x"},
+		{"/", "/a.png", "a.png"},
+		{"/maps/", "/maps/", "./"},
+		{"/maps/", "/maps/sub/", "sub/"},
+		{"/maps/", "/maps/a:b.png", "./a:b.png"},
+	}
+
+	for _, tt := range tests {
+		if got := relativePath(tt.dir, tt.target); got != tt.want {
+			t.Errorf("relativePath(%q, %q): expected %q, got %q", tt.dir, tt.target, tt.want, got)
+		}
+	}
+}
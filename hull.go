@@ -0,0 +1,197 @@
+package kml
+
+import (
+	"math"
+	"sort"
+)
+
+// ConvexHull returns the convex hull of coords as a Polygon whose outer
+// boundary is closed and counter-clockwise, computed in planar
+// longitude/latitude with Andrew's monotone chain. Altitudes are dropped.
+// It returns nil if coords has fewer than three distinct, non-collinear
+// positions.
+func ConvexHull(coords []Coordinate) *Polygon {
+	pts := make([]Coordinate, 0, len(coords))
+	for _, c := range coords {
+		pts = append(pts, Coordinate{Lon: c.Lon, Lat: c.Lat})
+	}
+	sort.Slice(pts, func(i, j int) bool {
+		if pts[i].Lon != pts[j].Lon {
+			return pts[i].Lon < pts[j].Lon
+		}
+		return pts[i].Lat < pts[j].Lat
+	})
+
+	hull := make([]Coordinate, 0, 2*len(pts))
+	// Lower hull.
+	for _, p := range pts {
+		for len(hull) >= 2 && orientation(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+	// Upper hull.
+	lower := len(hull) + 1
+	for i := len(pts) - 2; i >= 0; i-- {
+		p := pts[i]
+		for len(hull) >= lower && orientation(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+
+	// The last point repeats the first, closing the ring.
+	if len(hull) < 4 {
+		return nil
+	}
+	return &Polygon{OuterBoundary: LinearRing{Coordinates: hull}}
+}
+
+// ConcaveHull returns an alpha shape of coords: the union of the Delaunay
+// triangles whose circumradius is at most alpha meters. Smaller values of
+// alpha follow the points more tightly; a large alpha yields the convex
+// hull. Enclosed gaps become holes. If the shape falls apart into several
+// pieces, only the one with the largest area is returned. It returns nil if
+// no triangle qualifies.
+func ConcaveHull(coords []Coordinate, alpha float64) *Polygon {
+	tris := triangulate(coords)
+
+	edgeCount := make(map[edge]int)
+	for _, t := range tris {
+		if math.Sqrt(t.r2) > alpha {
+			continue
+		}
+		edgeCount[newEdge(t.a, t.b)]++
+		edgeCount[newEdge(t.b, t.c)]++
+		edgeCount[newEdge(t.c, t.a)]++
+	}
+
+	// Boundary edges belong to exactly one kept triangle.
+	adj := make(map[int][]int)
+	var boundary []edge
+	for e, n := range edgeCount {
+		if n == 1 {
+			boundary = append(boundary, e)
+			adj[e.a] = append(adj[e.a], e.b)
+			adj[e.b] = append(adj[e.b], e.a)
+		}
+	}
+	if len(boundary) == 0 {
+		return nil
+	}
+	sort.Slice(boundary, func(i, j int) bool {
+		if boundary[i].a != boundary[j].a {
+			return boundary[i].a < boundary[j].a
+		}
+		return boundary[i].b < boundary[j].b
+	})
+	for v := range adj {
+		sort.Ints(adj[v])
+	}
+
+	// Chain boundary edges into closed rings.
+	used := make(map[edge]bool)
+	var rings [][]Coordinate
+	for _, start := range boundary {
+		if used[start] {
+			continue
+		}
+		used[start] = true
+		ring := []Coordinate{coords[start.a], coords[start.b]}
+		prev, cur := start.a, start.b
+		for cur != start.a {
+			next := -1
+			for _, n := range adj[cur] {
+				if e := newEdge(cur, n); !used[e] && n != prev {
+					next = n
+					break
+				}
+			}
+			if next < 0 {
+				break
+			}
+			used[newEdge(cur, next)] = true
+			prev, cur = cur, next
+			ring = append(ring, coords[cur])
+		}
+		if len(ring) >= 4 && cur == start.a {
+			rings = append(rings, flatRing(ring))
+		}
+	}
+	if len(rings) == 0 {
+		return nil
+	}
+
+	outer := 0
+	for i := range rings {
+		if math.Abs(signedArea(rings[i])) > math.Abs(signedArea(rings[outer])) {
+			outer = i
+		}
+	}
+
+	poly := &Polygon{OuterBoundary: LinearRing{Coordinates: orientRing(rings[outer], true)}}
+	for i, r := range rings {
+		if i != outer && ringContainsRing(rings[outer], r) {
+			poly.InnerBoundaries = append(poly.InnerBoundaries, LinearRing{Coordinates: orientRing(r, false)})
+		}
+	}
+	return poly
+}
+
+// Hull returns the convex hull of every coordinate in the document's
+// placemark geometries, or nil if there are fewer than three
+// non-collinear coordinates.
+func (k *KML) Hull() *Polygon {
+	var coords []Coordinate
+	for _, pm := range k.Placemarks() {
+		if pm.Geometry != nil {
+			coords = append(coords, getGeometryCoordinates(pm.Geometry)...)
+		}
+	}
+	return ConvexHull(coords)
+}
+
+// ringContainsRing reports whether every vertex of inner is inside or on
+// outer, other than vertices shared with it.
+func ringContainsRing(outer, inner []Coordinate) bool {
+	shared := make(map[Coordinate]bool, len(outer))
+	for _, c := range outer {
+		shared[c] = true
+	}
+	for _, c := range inner {
+		if !shared[c] && !ringContains(outer, c) {
+			return false
+		}
+	}
+	return true
+}
+
+// flatRing returns a copy of ring without altitudes.
+func flatRing(ring []Coordinate) []Coordinate {
+	out := make([]Coordinate, len(ring))
+	for i, c := range ring {
+		out[i] = Coordinate{Lon: c.Lon, Lat: c.Lat}
+	}
+	return out
+}
+
+// signedArea returns the planar signed area of a closed ring: positive when
+// counter-clockwise.
+func signedArea(ring []Coordinate) float64 {
+	var a float64
+	for i := 1; i < len(ring); i++ {
+		a += ring[i-1].Lon*ring[i].Lat - ring[i].Lon*ring[i-1].Lat
+	}
+	return a / 2
+}
+
+// orientRing returns ring ordered counter-clockwise if ccw is true, and
+// clockwise otherwise, reversing it in place if needed.
+func orientRing(ring []Coordinate, ccw bool) []Coordinate {
+	if (signedArea(ring) > 0) != ccw {
+		for i, j := 0, len(ring)-1; i < j; i, j = i+1, j-1 {
+			ring[i], ring[j] = ring[j], ring[i]
+		}
+	}
+	return ring
+}
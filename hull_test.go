@@ -0,0 +1,170 @@
+package kml
+
+import (
+	"math"
+	"testing"
+)
+
+func TestConvexHull(t *testing.T) {
+	coords := []Coordinate{
+		Coord(0, 0), Coord(2, 0), Coord(2, 2), Coord(0, 2),
+		Coord(1, 1), Coord(0.5, 1.5), Coord(1, 0), // interior and edge points
+		Coord(2, 2), // duplicate
+	}
+
+	hull := ConvexHull(coords)
+	if hull == nil {
+		t.Fatal("Expected hull")
+	}
+	ring := hull.OuterBoundary.Coordinates
+	if len(ring) != 5 {
+		t.Fatalf("Expected 4 corners plus closing point, got %v", ring)
+	}
+	if ring[0] != ring[len(ring)-1] {
+		t.Error("Expected closed ring")
+	}
+	if signedArea(ring) <= 0 {
+		t.Error("Expected counter-clockwise ring")
+	}
+	if a := signedArea(ring); a != 4 {
+		t.Errorf("Expected area 4, got %v", a)
+	}
+	for _, c := range []Coordinate{Coord(1, 1), Coord(0.5, 1.5)} {
+		if !hull.Contains(c) {
+			t.Errorf("Expected hull to contain %v", c)
+		}
+	}
+}
+
+func TestConvexHullDegenerate(t *testing.T) {
+	tests := []struct {
+		name   string
+		coords []Coordinate
+	}{
+		{"empty", nil},
+		{"two points", []Coordinate{Coord(0, 0), Coord(1, 1)}},
+		{"collinear", []Coordinate{Coord(0, 0), Coord(1, 1), Coord(2, 2)}},
+		{"same point", []Coordinate{Coord(1, 1), Coord(1, 1), Coord(1, 1)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if hull := ConvexHull(tt.coords); hull != nil {
+				t.Errorf("Expected nil, got %v", hull.OuterBoundary.Coordinates)
+			}
+		})
+	}
+}
+
+// lShapePoints returns a grid of points filling an L shape with 0.01°
+// spacing, so a concave hull should follow the inner corner.
+func lShapePoints() []Coordinate {
+	var coords []Coordinate
+	for i := 0; i <= 10; i++ {
+		for j := 0; j <= 10; j++ {
+			if i > 4 && j > 4 {
+				continue
+			}
+			coords = append(coords, Coord(float64(i)*0.01, float64(j)*0.01))
+		}
+	}
+	return coords
+}
+
+func TestConcaveHull(t *testing.T) {
+	coords := lShapePoints()
+
+	convex := ConvexHull(coords)
+	concave := ConcaveHull(coords, 1500)
+	if concave == nil {
+		t.Fatal("Expected concave hull")
+	}
+
+	notch := Coord(0.065, 0.065)
+	if !convex.Contains(notch) {
+		t.Error("Expected convex hull to cover the notch")
+	}
+	if concave.Contains(notch) {
+		t.Error("Expected concave hull to exclude the notch")
+	}
+	for _, c := range []Coordinate{Coord(0.02, 0.08), Coord(0.08, 0.02)} {
+		if !concave.Contains(c) {
+			t.Errorf("Expected concave hull to contain %v", c)
+		}
+	}
+	if math.Abs(signedArea(concave.OuterBoundary.Coordinates)) >= math.Abs(signedArea(convex.OuterBoundary.Coordinates)) {
+		t.Error("Expected concave hull to be smaller than convex hull")
+	}
+
+	large := ConcaveHull(coords, 1e9)
+	if large == nil || !large.Contains(notch) {
+		t.Error("Expected a very large alpha to approach the convex hull")
+	}
+
+	if h := ConcaveHull(coords, 1); h != nil {
+		t.Errorf("Expected nil for tiny alpha, got %v", h.OuterBoundary.Coordinates)
+	}
+}
+
+func TestConcaveHullHole(t *testing.T) {
+	// Square ring of points with an empty middle.
+	var coords []Coordinate
+	for i := 0; i <= 10; i++ {
+		for j := 0; j <= 10; j++ {
+			if i >= 3 && i <= 7 && j >= 3 && j <= 7 {
+				continue
+			}
+			coords = append(coords, Coord(float64(i)*0.01, float64(j)*0.01))
+		}
+	}
+
+	hull := ConcaveHull(coords, 1000)
+	if hull == nil {
+		t.Fatal("Expected concave hull")
+	}
+	if len(hull.InnerBoundaries) != 1 {
+		t.Fatalf("Expected 1 hole, got %d", len(hull.InnerBoundaries))
+	}
+	if hull.Contains(Coord(0.05, 0.05)) {
+		t.Error("Expected center to fall in the hole")
+	}
+	if !hull.IsValid() {
+		t.Errorf("Expected valid polygon, got %v", hull.ValidityIssues())
+	}
+}
+
+func TestKMLHull(t *testing.T) {
+	k := NewKML()
+	k.Feature = &Document{Features: []Feature{
+		pointPlacemark("a", 0, 0),
+		pointPlacemark("b", 1, 0),
+		&Placemark{Geometry: &LineString{Coordinates: []Coordinate{Coord(1, 1), Coord(0, 1)}}},
+		&Placemark{Name: "no geometry"},
+	}}
+
+	hull := k.Hull()
+	if hull == nil {
+		t.Fatal("Expected hull")
+	}
+	if a := signedArea(hull.OuterBoundary.Coordinates); a != 1 {
+		t.Errorf("Expected unit square hull, got area %v", a)
+	}
+}
+
+func TestTriangulate(t *testing.T) {
+	// A square with a center point triangulates into 4 triangles.
+	coords := []Coordinate{Coord(0, 0), Coord(1, 0), Coord(1, 1), Coord(0, 1), Coord(0.5, 0.5), Coord(0, 0)}
+	tris := triangulate(coords)
+	if len(tris) != 4 {
+		t.Fatalf("Expected 4 triangles, got %d", len(tris))
+	}
+	for _, tri := range tris {
+		if tri.a == 5 || tri.b == 5 || tri.c == 5 {
+			t.Error("Expected duplicate point to map to its first index")
+		}
+	}
+
+	if tris := triangulate([]Coordinate{Coord(0, 0), Coord(1, 1), Coord(2, 2)}); len(tris) != 0 {
+		t.Errorf("Expected no triangles for collinear points, got %d", len(tris))
+	}
+}
@@ -0,0 +1,228 @@
+package kml
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// InferSchemaOptions configures InferSchema.
+type InferSchemaOptions struct {
+	// ID is the ID of the Schema. If a Schema of the document already has
+	// it, the first free ID made by adding a number from 2 is used. The
+	// default is "schema".
+	ID string
+
+	// Name is the name of the Schema. The default is the ID.
+	Name string
+
+	// Rewrite moves the fields into SchemaData elements referencing the
+	// Schema, which is added to the top-level Document. Without it the
+	// document is left unchanged.
+	Rewrite bool
+}
+
+// defaults returns o with zero fields set to their defaults.
+func (o InferSchemaOptions) defaults() InferSchemaOptions {
+	if o.ID == "" {
+		o.ID = "schema"
+	}
+	return o
+}
+
+// InferSchema declares the ad hoc attributes of the placemarks as typed
+// fields: the names of their Data elements, and of the SimpleData in
+// SchemaData whose schemaUrl names no Schema of the document, in the order
+// they first appear. A field is typed "bool" if every value is true or
+// false, "int" if every value is a 32-bit integer written without leading
+// zeros, so that codes such as "02134" stay strings, "double" if every
+// value is a finite number, and "string" otherwise; empty values are
+// ignored. A field's display name is the first its Data elements give.
+//
+// With opts.Rewrite, the values of each placemark are moved into one
+// SchemaData element referencing the Schema, in the order of its fields,
+// replacing the Data and untyped SchemaData they came from; a name
+// repeated within a placemark keeps its later values as Data. The Schema
+// is then added to the top-level Document, which is added around its
+// feature if it has none. InferSchema returns the Schema, which has no
+// fields if the placemarks have no attributes.
+func (k *KML) InferSchema(opts InferSchemaOptions) Schema {
+	opts = opts.defaults()
+
+	declared := make(map[string]bool)
+	k.Walk(func(f Feature) error {
+		if doc, ok := f.(*Document); ok {
+			for _, s := range doc.Schemas {
+				declared[s.ID] = true
+			}
+		}
+		return nil
+	})
+	untyped := func(sd SchemaData) bool {
+		id, ok := localFragment(sd.SchemaURL)
+		return sd.SchemaURL == "" || ok && !declared[id]
+	}
+
+	var fields []*inferredField
+	byName := make(map[string]*inferredField)
+	observe := func(name, displayName, value string) {
+		field, ok := byName[name]
+		if !ok {
+			field = &inferredField{name: name, kinds: inferAll}
+			byName[name] = field
+			fields = append(fields, field)
+		}
+		if field.displayName == "" {
+			field.displayName = displayName
+		}
+		field.observe(value)
+	}
+	pms := k.Placemarks()
+	for _, pm := range pms {
+		if pm.ExtendedData == nil {
+			continue
+		}
+		for _, d := range pm.ExtendedData.Data {
+			observe(d.Name, d.DisplayName, d.Value)
+		}
+		for _, sd := range pm.ExtendedData.SchemaData {
+			if untyped(sd) {
+				for _, d := range sd.SimpleData {
+					observe(d.Name, "", d.Value)
+				}
+			}
+		}
+	}
+
+	id := opts.ID
+	for n := 2; declared[id]; n++ {
+		id = opts.ID + strconv.Itoa(n)
+	}
+	schema := Schema{ID: id, Name: opts.Name}
+	if schema.Name == "" {
+		schema.Name = id
+	}
+	for _, field := range fields {
+		schema.SimpleFields = append(schema.SimpleFields, SimpleField{Type: field.typ(), Name: field.name, DisplayName: field.displayName})
+	}
+	if !opts.Rewrite || len(fields) == 0 {
+		return schema
+	}
+
+	order := make(map[string]int, len(fields))
+	for i, field := range fields {
+		order[field.name] = i
+	}
+	for _, pm := range pms {
+		ed := pm.ExtendedData
+		if ed == nil {
+			continue
+		}
+		values := make([]*SimpleData, len(fields))
+		take := func(name, value string) bool {
+			i := order[name]
+			if values[i] != nil {
+				return false
+			}
+			values[i] = &SimpleData{Name: name, Value: value}
+			return true
+		}
+
+		var data []Data
+		for _, d := range ed.Data {
+			if !take(d.Name, d.Value) {
+				data = append(data, d)
+			}
+		}
+		var schemaData []SchemaData
+		for _, sd := range ed.SchemaData {
+			if !untyped(sd) {
+				schemaData = append(schemaData, sd)
+				continue
+			}
+			for _, d := range sd.SimpleData {
+				if !take(d.Name, d.Value) {
+					data = append(data, Data{Name: d.Name, Value: d.Value})
+				}
+			}
+		}
+
+		typed := SchemaData{SchemaURL: "#" + id}
+		for _, v := range values {
+			if v != nil {
+				typed.SimpleData = append(typed.SimpleData, *v)
+			}
+		}
+		if len(typed.SimpleData) == 0 {
+			continue
+		}
+		ed.Data, ed.SchemaData = data, append(schemaData, typed)
+	}
+
+	root, ok := k.Feature.(*Document)
+	if !ok {
+		root = &Document{}
+		if k.Feature != nil {
+			root.Features = []Feature{k.Feature}
+		}
+		k.Feature = root
+	}
+	root.Schemas = append(root.Schemas, schema)
+	return schema
+}
+
+// Kinds of value a field may hold, as a bit set.
+const (
+	inferBool = 1 << iota
+	inferInt
+	inferDouble
+	inferAll = inferBool | inferInt | inferDouble
+)
+
+// inferredField collects the values of a field for InferSchema.
+type inferredField struct {
+	name        string
+	displayName string
+	kinds       int // Kinds every value so far fits
+	seen        bool
+}
+
+// observe narrows the kinds of the field to those value fits.
+func (f *inferredField) observe(value string) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return
+	}
+	f.seen = true
+	if v := strings.ToLower(value); v != "true" && v != "false" {
+		f.kinds &^= inferBool
+	}
+	if _, err := strconv.ParseInt(value, 10, 32); err != nil || hasLeadingZero(value) {
+		f.kinds &^= inferInt
+	}
+	if v, err := strconv.ParseFloat(value, 64); err != nil || math.IsInf(v, 0) || math.IsNaN(v) || hasLeadingZero(value) {
+		f.kinds &^= inferDouble
+	}
+}
+
+// typ returns the SimpleField type of the field.
+func (f *inferredField) typ() string {
+	switch {
+	case !f.seen:
+		return "string"
+	case f.kinds&inferBool != 0:
+		return "bool"
+	case f.kinds&inferInt != 0:
+		return "int"
+	case f.kinds&inferDouble != 0:
+		return "double"
+	}
+	return "string"
+}
+
+// hasLeadingZero reports whether a number is written with a zero before
+// its first significant digit, as codes are.
+func hasLeadingZero(s string) bool {
+	s = strings.TrimLeft(s, "+-")
+	return len(s) > 1 && s[0] == '0' && s[1] >= '0' && s[1] <= '9'
+}
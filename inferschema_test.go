@@ -0,0 +1,124 @@
+package kml
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestInferSchemaTypes tests inferring field types from values
+func TestInferSchemaTypes(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []string
+		want   string
+	}{
+		{"bool", []string{"true", "FALSE", ""}, "bool"},
+		{"int", []string{"12", "-3", "0"}, "int"},
+		{"double", []string{"1.5", "2", "-0.25", "1e3"}, "double"},
+		{"leading zero", []string{"02134", "10001"}, "string"},
+		{"too large for int", []string{"1", "3000000000"}, "double"},
+		{"not finite", []string{"1", "Inf"}, "string"},
+		{"text", []string{"12", "n/a"}, "string"},
+		{"empty", []string{"", " "}, "string"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			field := &inferredField{kinds: inferAll}
+			for _, v := range tt.values {
+				field.observe(v)
+			}
+			if got := field.typ(); got != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+// inferSchemaKML returns a document whose placemarks have ad hoc attributes
+func inferSchemaKML() *KML {
+	k := NewKML()
+	k.Feature = &Document{
+		Schemas: []Schema{{ID: "schema", Name: "existing"}},
+		Features: []Feature{
+			&Placemark{Name: "a", ExtendedData: &ExtendedData{Data: []Data{
+				{Name: "pop", DisplayName: "Population", Value: "1200"},
+				{Name: "open", Value: "true"},
+			}}},
+			&Placemark{Name: "b", ExtendedData: &ExtendedData{
+				Data: []Data{{Name: "pop", Value: "800"}, {Name: "pop", Value: "810"}},
+				SchemaData: []SchemaData{
+					{SchemaURL: "#undeclared", SimpleData: []SimpleData{{Name: "area", Value: "3.5"}}},
+					{SchemaURL: "#schema", SimpleData: []SimpleData{{Name: "typed", Value: "x"}}},
+				},
+			}},
+			&Placemark{Name: "c"},
+		},
+	}
+	return k
+}
+
+// TestInferSchema tests inferring a schema without rewriting
+func TestInferSchema(t *testing.T) {
+	k := inferSchemaKML()
+	schema := k.InferSchema(InferSchemaOptions{})
+
+	want := Schema{ID: "schema2", Name: "schema2", SimpleFields: []SimpleField{
+		{Type: "int", Name: "pop", DisplayName: "Population"},
+		{Type: "bool", Name: "open"},
+		{Type: "double", Name: "area"},
+	}}
+	if !reflect.DeepEqual(schema, want) {
+		t.Errorf("Expected %+v, got %+v", want, schema)
+	}
+	if got := len(k.Feature.(*Document).Schemas); got != 1 {
+		t.Errorf("Expected the document unchanged, got %d schemas", got)
+	}
+}
+
+// TestInferSchemaRewrite tests moving ad hoc attributes into SchemaData
+func TestInferSchemaRewrite(t *testing.T) {
+	k := inferSchemaKML()
+	schema := k.InferSchema(InferSchemaOptions{ID: "places", Rewrite: true})
+
+	doc := k.Feature.(*Document)
+	if len(doc.Schemas) != 2 || doc.Schemas[1].ID != "places" || schema.Name != "places" {
+		t.Fatalf("Expected the places schema added, got %+v", doc.Schemas)
+	}
+	pms := k.Placemarks()
+
+	a := pms[0].ExtendedData
+	wantA := []SchemaData{{SchemaURL: "#places", SimpleData: []SimpleData{{Name: "pop", Value: "1200"}, {Name: "open", Value: "true"}}}}
+	if a.Data != nil || !reflect.DeepEqual(a.SchemaData, wantA) {
+		t.Errorf("Expected %+v, got %+v and %+v", wantA, a.Data, a.SchemaData)
+	}
+
+	b := pms[1].ExtendedData
+	wantData := []Data{{Name: "pop", Value: "810"}}
+	wantB := []SchemaData{
+		{SchemaURL: "#schema", SimpleData: []SimpleData{{Name: "typed", Value: "x"}}},
+		{SchemaURL: "#places", SimpleData: []SimpleData{{Name: "pop", Value: "800"}, {Name: "area", Value: "3.5"}}},
+	}
+	if !reflect.DeepEqual(b.Data, wantData) || !reflect.DeepEqual(b.SchemaData, wantB) {
+		t.Errorf("Expected %+v and %+v, got %+v and %+v", wantData, wantB, b.Data, b.SchemaData)
+	}
+	if pms[2].ExtendedData != nil {
+		t.Errorf("Expected placemark without attributes unchanged")
+	}
+}
+
+// TestInferSchemaWrapsRoot tests adding a Document around a placemark root
+func TestInferSchemaWrapsRoot(t *testing.T) {
+	k := NewKML()
+	pm := &Placemark{ExtendedData: &ExtendedData{Data: []Data{{Name: "n", Value: "1"}}}}
+	k.Feature = pm
+	k.InferSchema(InferSchemaOptions{Rewrite: true})
+
+	doc, ok := k.Feature.(*Document)
+	if !ok || len(doc.Schemas) != 1 || len(doc.Features) != 1 || doc.Features[0] != pm {
+		t.Fatalf("Expected a Document around the placemark, got %+v", k.Feature)
+	}
+	if got := pm.ExtendedData.SchemaData[0].SchemaURL; got != "#schema" {
+		t.Errorf("Expected #schema, got %s", got)
+	}
+}
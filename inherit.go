@@ -0,0 +1,146 @@
+package kml
+
+// EffectiveStyle returns the style Google Earth draws f with in the given
+// state, "normal" or "highlight" ("" means normal). Sub-styles are merged
+// from these sources, in order of precedence:
+//
+//  1. the inline Style of f (Placemarks only)
+//  2. the shared style referenced by the styleUrl of f; for a StyleMap, the
+//     style of the pair matching state
+//  3. the styles referenced by the styleUrls of the Folders and Documents
+//     containing f, nearest first
+//
+// Each of IconStyle, LabelStyle, LineStyle, PolyStyle and BalloonStyle is
+// taken whole from the first source that defines it; they are not merged
+// field by field. ListStyle, which describes f's own entry in the list
+// view, is taken only from the first two sources. The result is a copy
+// with no ID. It is nil if no source defines any sub-style or f is not
+// part of the document.
+func (k *KML) EffectiveStyle(f Feature, state string) *Style {
+	if state == "" {
+		state = "normal"
+	}
+
+	path := featurePath(k.Feature, f)
+	if path == nil {
+		return nil
+	}
+
+	idx := newStyleIndex(k)
+	var sources []*Style
+	if pm, ok := f.(*Placemark); ok && pm.Style != nil {
+		sources = append(sources, pm.Style)
+	}
+	own := len(sources)
+	for i := len(path) - 1; i >= 0; i-- {
+		if s := idx.resolveState(styleURLOf(path[i]), state); s != nil {
+			sources = append(sources, s)
+			if i == len(path)-1 {
+				own++
+			}
+		}
+	}
+
+	var merged Style
+	for i, s := range sources {
+		if merged.IconStyle == nil && s.IconStyle != nil {
+			icon := *s.IconStyle
+			merged.IconStyle = &icon
+		}
+		if merged.LabelStyle == nil && s.LabelStyle != nil {
+			label := *s.LabelStyle
+			merged.LabelStyle = &label
+		}
+		if merged.LineStyle == nil && s.LineStyle != nil {
+			line := *s.LineStyle
+			merged.LineStyle = &line
+		}
+		if merged.PolyStyle == nil && s.PolyStyle != nil {
+			poly := *s.PolyStyle
+			merged.PolyStyle = &poly
+		}
+		if merged.BalloonStyle == nil && s.BalloonStyle != nil {
+			balloon := *s.BalloonStyle
+			merged.BalloonStyle = &balloon
+		}
+		if merged.ListStyle == nil && s.ListStyle != nil && i < own {
+			list := *s.ListStyle
+			merged.ListStyle = &list
+		}
+	}
+
+	if merged == (Style{}) {
+		return nil
+	}
+	return &merged
+}
+
+// resolveState returns the shared style a document-local style URL
+// resolves to in the given StyleMap state, following StyleMaps that point
+// at other StyleMaps. External and unresolved URLs yield nil.
+func (idx *styleIndex) resolveState(url, state string) *Style {
+	seen := make(map[string]bool)
+	for {
+		id, ok := localFragment(url)
+		if !ok || seen[id] {
+			return nil
+		}
+		seen[id] = true
+
+		if s, ok := idx.styles[id]; ok {
+			return s
+		}
+		sm, ok := idx.styleMaps[id]
+		if !ok {
+			return nil
+		}
+
+		url = ""
+		for _, pair := range sm.Pairs {
+			if pair.Key == state {
+				url = pair.StyleURL
+				break
+			}
+		}
+	}
+}
+
+// styleURLOf returns the styleUrl of a feature, or "" if it has none.
+func styleURLOf(f Feature) string {
+	switch feature := f.(type) {
+	case *Document:
+		return feature.StyleURL
+	case *Folder:
+		return feature.StyleURL
+	case *Placemark:
+		return feature.StyleURL
+	case *GroundOverlay:
+		return feature.StyleURL
+	}
+	return ""
+}
+
+// featurePath returns the features from root down to and including
+// target, or nil if target is not in the tree.
+func featurePath(root, target Feature) []Feature {
+	if root == nil {
+		return nil
+	}
+	if root == target {
+		return []Feature{root}
+	}
+
+	var children []Feature
+	switch feature := root.(type) {
+	case *Document:
+		children = feature.Features
+	case *Folder:
+		children = feature.Features
+	}
+	for _, child := range children {
+		if path := featurePath(child, target); path != nil {
+			return append([]Feature{root}, path...)
+		}
+	}
+	return nil
+}
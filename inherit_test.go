@@ -0,0 +1,154 @@
+package kml
+
+import "testing"
+
+// inheritanceKML builds a document with a styled folder hierarchy:
+// Document (#docStyle) > Folder (#folderMap) > Placemark (#pinStyle, inline LabelStyle)
+func inheritanceKML() (*KML, *Folder, *Placemark, *Placemark) {
+	pin := &Placemark{
+		Name:     "pin",
+		StyleURL: "#pinStyle",
+		Style:    &Style{LabelStyle: &LabelStyle{Scale: 2}},
+	}
+	plain := &Placemark{Name: "plain"}
+	folder := &Folder{Name: "layer", StyleURL: "#folderMap", Features: []Feature{pin, plain}}
+
+	k := NewKML()
+	k.Feature = &Document{
+		StyleURL: "#docStyle",
+		Styles: []Style{
+			{ID: "docStyle", LineStyle: &LineStyle{Width: 1}, BalloonStyle: &BalloonStyle{Text: "doc"}, LabelStyle: &LabelStyle{Scale: 0.5}},
+			{ID: "folderNormal", LineStyle: &LineStyle{Width: 3}, PolyStyle: &PolyStyle{Color: Red}},
+			{ID: "folderHighlight", LineStyle: &LineStyle{Width: 6}},
+			{ID: "pinStyle", IconStyle: &IconStyle{Scale: 1.5}, LineStyle: &LineStyle{Width: 9}},
+		},
+		StyleMaps: []StyleMap{{ID: "folderMap", Pairs: []Pair{
+			{Key: "normal", StyleURL: "#folderNormal"},
+			{Key: "highlight", StyleURL: "#folderHighlight"},
+		}}},
+		Features: []Feature{folder},
+	}
+	return k, folder, pin, plain
+}
+
+// TestEffectiveStylePrecedence tests merging inline, own and inherited styles
+func TestEffectiveStylePrecedence(t *testing.T) {
+	k, folder, pin, plain := inheritanceKML()
+
+	s := k.EffectiveStyle(pin, "")
+	if s == nil {
+		t.Fatal("Expected an effective style")
+	}
+	if s.LabelStyle == nil || s.LabelStyle.Scale != 2 {
+		t.Errorf("Expected inline LabelStyle to win, got %+v", s.LabelStyle)
+	}
+	if s.IconStyle == nil || s.IconStyle.Scale != 1.5 || s.LineStyle.Width != 9 {
+		t.Errorf("Expected own styleUrl sub-styles, got %+v %+v", s.IconStyle, s.LineStyle)
+	}
+	if s.PolyStyle == nil || s.PolyStyle.Color != Red {
+		t.Errorf("Expected PolyStyle inherited from the folder, got %+v", s.PolyStyle)
+	}
+	if s.BalloonStyle == nil || s.BalloonStyle.Text != "doc" {
+		t.Errorf("Expected BalloonStyle inherited from the document, got %+v", s.BalloonStyle)
+	}
+	if s.ID != "" {
+		t.Errorf("Expected merged style without ID, got %q", s.ID)
+	}
+
+	p := k.EffectiveStyle(plain, "normal")
+	if p.LineStyle.Width != 3 || p.LabelStyle.Scale != 0.5 || p.IconStyle != nil {
+		t.Errorf("Expected folder over document for unstyled placemark, got %+v", p)
+	}
+
+	h := k.EffectiveStyle(plain, "highlight")
+	if h.LineStyle.Width != 6 || h.PolyStyle != nil {
+		t.Errorf("Expected highlight pair of the folder StyleMap, got %+v", h)
+	}
+
+	if f := k.EffectiveStyle(folder, ""); f.LineStyle.Width != 3 {
+		t.Errorf("Expected folder's own style, got %+v", f.LineStyle)
+	}
+}
+
+// TestEffectiveStyleListStyle tests that list styles are not inherited
+func TestEffectiveStyleListStyle(t *testing.T) {
+	k, folder, pin, plain := inheritanceKML()
+	doc := k.Feature.(*Document)
+	doc.Styles[1].ListStyle = &ListStyle{ListItemType: ListItemRadioFolder}
+	doc.Styles[3].ListStyle = &ListStyle{ItemIcons: []ItemIcon{{Href: "pin.png"}}}
+
+	if s := k.EffectiveStyle(folder, ""); s.ListStyle == nil || s.ListStyle.ListItemType != ListItemRadioFolder {
+		t.Errorf("Expected the folder's own ListStyle, got %+v", s.ListStyle)
+	}
+	if s := k.EffectiveStyle(pin, ""); s.ListStyle == nil || s.ListStyle.ItemIcons[0].Href != "pin.png" {
+		t.Errorf("Expected the placemark's own ListStyle, got %+v", s.ListStyle)
+	}
+	if s := k.EffectiveStyle(plain, ""); s.ListStyle != nil {
+		t.Errorf("Expected no ListStyle from the folder, got %+v", s.ListStyle)
+	}
+}
+
+// TestEffectiveStyleCopies tests that the result does not alias shared styles
+func TestEffectiveStyleCopies(t *testing.T) {
+	k, _, pin, _ := inheritanceKML()
+
+	s := k.EffectiveStyle(pin, "")
+	s.IconStyle.Scale = 99
+	if k.Feature.(*Document).Styles[3].IconStyle.Scale != 1.5 {
+		t.Error("Expected shared style to be unchanged")
+	}
+}
+
+// TestEffectiveStyleNone tests features without styles or outside the document
+func TestEffectiveStyleNone(t *testing.T) {
+	pm := &Placemark{Name: "alone"}
+	k := NewKML()
+	k.Feature = &Folder{Features: []Feature{pm}}
+
+	if s := k.EffectiveStyle(pm, ""); s != nil {
+		t.Errorf("Expected nil style, got %+v", s)
+	}
+	if s := k.EffectiveStyle(&Placemark{}, ""); s != nil {
+		t.Errorf("Expected nil style for a foreign feature, got %+v", s)
+	}
+
+	cyclic := NewKML()
+	cyclePM := &Placemark{StyleURL: "#a"}
+	cyclic.Feature = &Document{
+		StyleMaps: []StyleMap{
+			{ID: "a", Pairs: []Pair{{Key: "normal", StyleURL: "#b"}}},
+			{ID: "b", Pairs: []Pair{{Key: "normal", StyleURL: "#a"}}},
+		},
+		Features: []Feature{cyclePM},
+	}
+	if s := cyclic.EffectiveStyle(cyclePM, ""); s != nil {
+		t.Errorf("Expected nil style for cyclic StyleMaps, got %+v", s)
+	}
+}
+
+// TestContainerStyleURLRoundTrip tests styleUrl on Documents and Folders
+func TestContainerStyleURLRoundTrip(t *testing.T) {
+	k, _, _, _ := inheritanceKML()
+	data, err := k.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes() error: %v", err)
+	}
+	again, err := ParseBytes(data)
+	if err != nil {
+		t.Fatalf("ParseBytes() error: %v", err)
+	}
+
+	doc := again.Feature.(*Document)
+	if doc.StyleURL != "#docStyle" {
+		t.Errorf("Expected document styleUrl, got %q", doc.StyleURL)
+	}
+	if folder := doc.Features[0].(*Folder); folder.StyleURL != "#folderMap" {
+		t.Errorf("Expected folder styleUrl, got %q", folder.StyleURL)
+	}
+
+	doc.StyleURL = "#gone"
+	refs := again.CheckReferences()
+	if len(refs) != 1 || refs[0].Element != "Document" {
+		t.Errorf("Expected dangling Document styleUrl, got %+v", refs)
+	}
+}
@@ -0,0 +1,335 @@
+package kml
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// The KML tree encodes to JSON with the Go field names as keys, so a web
+// frontend or configuration system can read and edit the whole model and
+// hand it back for conversion to KML. Features and geometries carry a
+// "type" key naming their KML element, which selects the concrete type when
+// Document.Features, Folder.Features, Placemark.Geometry,
+// MultiGeometry.Geometries and KML.Feature are decoded; the steps of
+// Tour.Playlist carry one too. Colors encode as
+// their AABBGGRR hex string.
+
+// MarshalJSON implements json.Marshaler for KML.
+func (k *KML) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Xmlns   string
+		Feature Feature
+	}{k.Xmlns, k.Feature})
+}
+
+// UnmarshalJSON implements json.Unmarshaler for KML.
+func (k *KML) UnmarshalJSON(data []byte) error {
+	var aux struct {
+		Xmlns   string
+		Feature json.RawMessage
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	f, err := unmarshalFeatureJSON(aux.Feature)
+	if err != nil {
+		return err
+	}
+	k.Xmlns = aux.Xmlns
+	k.Feature = f
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler for Document.
+func (d *Document) MarshalJSON() ([]byte, error) {
+	type plain Document
+	return marshalTypedJSON("Document", (*plain)(d))
+}
+
+// UnmarshalJSON implements json.Unmarshaler for Document.
+func (d *Document) UnmarshalJSON(data []byte) error {
+	type plain Document
+	aux := struct {
+		*plain
+		Features []json.RawMessage
+	}{plain: (*plain)(d)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	features, err := unmarshalFeaturesJSON(aux.Features)
+	if err != nil {
+		return err
+	}
+	d.Features = features
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler for Folder.
+func (f *Folder) MarshalJSON() ([]byte, error) {
+	type plain Folder
+	return marshalTypedJSON("Folder", (*plain)(f))
+}
+
+// UnmarshalJSON implements json.Unmarshaler for Folder.
+func (f *Folder) UnmarshalJSON(data []byte) error {
+	type plain Folder
+	aux := struct {
+		*plain
+		Features []json.RawMessage
+	}{plain: (*plain)(f)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	features, err := unmarshalFeaturesJSON(aux.Features)
+	if err != nil {
+		return err
+	}
+	f.Features = features
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler for Placemark.
+func (p *Placemark) MarshalJSON() ([]byte, error) {
+	type plain Placemark
+	return marshalTypedJSON("Placemark", (*plain)(p))
+}
+
+// UnmarshalJSON implements json.Unmarshaler for Placemark.
+func (p *Placemark) UnmarshalJSON(data []byte) error {
+	type plain Placemark
+	aux := struct {
+		*plain
+		Geometry json.RawMessage
+	}{plain: (*plain)(p)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	g, err := unmarshalGeometryJSON(aux.Geometry)
+	if err != nil {
+		return err
+	}
+	p.Geometry = g
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler for GroundOverlay.
+func (g *GroundOverlay) MarshalJSON() ([]byte, error) {
+	type plain GroundOverlay
+	return marshalTypedJSON("GroundOverlay", (*plain)(g))
+}
+
+// MarshalJSON implements json.Marshaler for ScreenOverlay.
+func (s *ScreenOverlay) MarshalJSON() ([]byte, error) {
+	type plain ScreenOverlay
+	return marshalTypedJSON("ScreenOverlay", (*plain)(s))
+}
+
+// MarshalJSON implements json.Marshaler for NetworkLink.
+func (n *NetworkLink) MarshalJSON() ([]byte, error) {
+	type plain NetworkLink
+	return marshalTypedJSON("NetworkLink", (*plain)(n))
+}
+
+// MarshalJSON implements json.Marshaler for Tour.
+func (t *Tour) MarshalJSON() ([]byte, error) {
+	type plain Tour
+	return marshalTypedJSON("Tour", (*plain)(t))
+}
+
+// UnmarshalJSON implements json.Unmarshaler for Tour.
+func (t *Tour) UnmarshalJSON(data []byte) error {
+	type plain Tour
+	aux := struct {
+		*plain
+		Playlist []json.RawMessage
+	}{plain: (*plain)(t)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	t.Playlist = nil
+	for _, raw := range aux.Playlist {
+		typ, err := jsonType(raw)
+		if err != nil {
+			return err
+		}
+		var p TourPrimitive
+		switch typ {
+		case "FlyTo":
+			p = &FlyTo{}
+		case "Wait":
+			p = &Wait{}
+		default:
+			return fmt.Errorf("kml: unknown tour primitive type %q", typ)
+		}
+		if err := json.Unmarshal(raw, p); err != nil {
+			return err
+		}
+		t.Playlist = append(t.Playlist, p)
+	}
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler for FlyTo.
+func (f *FlyTo) MarshalJSON() ([]byte, error) {
+	type plain FlyTo
+	return marshalTypedJSON("FlyTo", (*plain)(f))
+}
+
+// MarshalJSON implements json.Marshaler for Wait.
+func (w *Wait) MarshalJSON() ([]byte, error) {
+	type plain Wait
+	return marshalTypedJSON("Wait", (*plain)(w))
+}
+
+// MarshalJSON implements json.Marshaler for Point.
+func (p *Point) MarshalJSON() ([]byte, error) {
+	type plain Point
+	return marshalTypedJSON("Point", (*plain)(p))
+}
+
+// MarshalJSON implements json.Marshaler for LineString.
+func (ls *LineString) MarshalJSON() ([]byte, error) {
+	type plain LineString
+	return marshalTypedJSON("LineString", (*plain)(ls))
+}
+
+// MarshalJSON implements json.Marshaler for LinearRing.
+func (lr *LinearRing) MarshalJSON() ([]byte, error) {
+	type plain LinearRing
+	return marshalTypedJSON("LinearRing", (*plain)(lr))
+}
+
+// MarshalJSON implements json.Marshaler for Polygon.
+func (p *Polygon) MarshalJSON() ([]byte, error) {
+	type plain Polygon
+	return marshalTypedJSON("Polygon", (*plain)(p))
+}
+
+// MarshalJSON implements json.Marshaler for Track.
+func (t *Track) MarshalJSON() ([]byte, error) {
+	type plain Track
+	return marshalTypedJSON("Track", (*plain)(t))
+}
+
+// MarshalJSON implements json.Marshaler for MultiGeometry.
+func (mg *MultiGeometry) MarshalJSON() ([]byte, error) {
+	type plain MultiGeometry
+	return marshalTypedJSON("MultiGeometry", (*plain)(mg))
+}
+
+// UnmarshalJSON implements json.Unmarshaler for MultiGeometry.
+func (mg *MultiGeometry) UnmarshalJSON(data []byte) error {
+	type plain MultiGeometry
+	aux := struct {
+		*plain
+		Geometries []json.RawMessage
+	}{plain: (*plain)(mg)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	mg.Geometries = nil
+	for _, raw := range aux.Geometries {
+		g, err := unmarshalGeometryJSON(raw)
+		if err != nil {
+			return err
+		}
+		if g == nil {
+			return errors.New("kml: null geometry in MultiGeometry")
+		}
+		mg.Geometries = append(mg.Geometries, g)
+	}
+	return nil
+}
+
+// marshalTypedJSON encodes v, which must encode as a JSON object, with a
+// leading "type" key.
+func marshalTypedJSON(typ string, v any) ([]byte, error) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	buf.WriteString(`{"type":`)
+	name, _ := json.Marshal(typ)
+	buf.Write(name)
+	if len(body) > 2 {
+		buf.WriteByte(',')
+	}
+	buf.Write(body[1:])
+	return buf.Bytes(), nil
+}
+
+// jsonType returns the "type" key of a JSON object.
+func jsonType(data json.RawMessage) (string, error) {
+	var head struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &head); err != nil {
+		return "", err
+	}
+	return head.Type, nil
+}
+
+// isJSONNull reports whether data is absent or the JSON null.
+func isJSONNull(data json.RawMessage) bool {
+	data = bytes.TrimSpace(data)
+	return len(data) == 0 || bytes.Equal(data, []byte("null"))
+}
+
+// unmarshalFeatureJSON decodes a feature by its "type" key. It returns nil
+// for null.
+func unmarshalFeatureJSON(data json.RawMessage) (Feature, error) {
+	if isJSONNull(data) {
+		return nil, nil
+	}
+	typ, err := jsonType(data)
+	if err != nil {
+		return nil, err
+	}
+	f := newFeature(typ)
+	if f == nil {
+		return nil, fmt.Errorf("kml: unknown feature type %q", typ)
+	}
+	if err := json.Unmarshal(data, f); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// unmarshalFeaturesJSON decodes the children of a container.
+func unmarshalFeaturesJSON(list []json.RawMessage) ([]Feature, error) {
+	var features []Feature
+	for _, raw := range list {
+		f, err := unmarshalFeatureJSON(raw)
+		if err != nil {
+			return nil, err
+		}
+		if f == nil {
+			return nil, errors.New("kml: null feature in container")
+		}
+		features = append(features, f)
+	}
+	return features, nil
+}
+
+// unmarshalGeometryJSON decodes a geometry by its "type" key. It returns nil
+// for null.
+func unmarshalGeometryJSON(data json.RawMessage) (Geometry, error) {
+	if isJSONNull(data) {
+		return nil, nil
+	}
+	typ, err := jsonType(data)
+	if err != nil {
+		return nil, err
+	}
+	g := newGeometry(typ)
+	if g == nil {
+		return nil, fmt.Errorf("kml: unknown geometry type %q", typ)
+	}
+	if err := json.Unmarshal(data, g); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
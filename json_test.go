@@ -0,0 +1,164 @@
+package kml
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestJSONRoundTrip tests that documents survive conversion to JSON and back
+func TestJSONRoundTrip(t *testing.T) {
+	files, err := filepath.Glob("testdata/*.kml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("Expected testdata files")
+	}
+
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			k, err := ParseFile(file)
+			if err != nil {
+				t.Skipf("Skipping unparsable file: %v", err)
+			}
+			want, err := k.Bytes()
+			if err != nil {
+				t.Fatalf("Bytes failed: %v", err)
+			}
+
+			data, err := json.Marshal(k)
+			if err != nil {
+				t.Fatalf("Marshal failed: %v", err)
+			}
+			var back KML
+			if err := json.Unmarshal(data, &back); err != nil {
+				t.Fatalf("Unmarshal failed: %v", err)
+			}
+			got, err := back.Bytes()
+			if err != nil {
+				t.Fatalf("Bytes failed: %v", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("Expected KML after JSON round trip:\n%s\ngot:\n%s", want, got)
+			}
+		})
+	}
+}
+
+// TestJSONAllTypes tests the round trip of every feature and geometry type
+func TestJSONAllTypes(t *testing.T) {
+	when := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	k := NewKML()
+	k.Feature = &Document{Name: "All", Styles: []Style{{ID: "s", LineStyle: &LineStyle{Color: Red, Width: 2}}}, Features: []Feature{
+		&Folder{Name: "Shapes", Features: []Feature{
+			&Placemark{Name: "point", Geometry: &Point{Coordinates: Coordinate{Lon: 1, Lat: 2, Alt: 3}}},
+			&Placemark{Name: "ring", Geometry: &LinearRing{Coordinates: []Coordinate{{Lon: 0, Lat: 0}, {Lon: 1, Lat: 0}, {Lon: 0, Lat: 1}, {Lon: 0, Lat: 0}}}},
+			&Placemark{Name: "multi", Geometry: &MultiGeometry{Geometries: []Geometry{
+				&LineString{Coordinates: []Coordinate{{Lon: 0, Lat: 0}, {Lon: 1, Lat: 1}}},
+				&Polygon{OuterBoundary: LinearRing{Coordinates: []Coordinate{{Lon: 0, Lat: 0}, {Lon: 1, Lat: 0}, {Lon: 0, Lat: 1}, {Lon: 0, Lat: 0}}}},
+			}}},
+			&Placemark{Name: "track", Geometry: &Track{When: []time.Time{when}, Coords: []Coordinate{{Lon: 5, Lat: 6}}}},
+			&Placemark{Name: "empty"},
+		}},
+		&GroundOverlay{Name: "image", Color: RGBA(0, 0, 255, 128), Icon: &Icon{Href: "map.png"}},
+		&ScreenOverlay{Name: "logo", Icon: &Icon{Href: "logo.png"}},
+		&NetworkLink{Name: "tiles", Link: &Link{Href: "tiles.kml"}},
+	}}
+
+	want, err := k.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes failed: %v", err)
+	}
+	data, err := json.Marshal(k)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	var back KML
+	if err := json.Unmarshal(data, &back); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	got, err := back.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes failed: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("Expected KML after JSON round trip:\n%s\ngot:\n%s", want, got)
+	}
+
+	for _, s := range []string{`"type":"Document"`, `"type":"Track"`, `"type":"MultiGeometry"`, `"Color":"80ff0000"`} {
+		if !bytes.Contains(data, []byte(s)) {
+			t.Errorf("Expected JSON to contain %s, got %s", s, data)
+		}
+	}
+}
+
+// TestJSONTypeFirst tests that the type key leads each object
+func TestJSONTypeFirst(t *testing.T) {
+	data, err := json.Marshal(&Placemark{Name: "a", Geometry: &Point{}})
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if !strings.HasPrefix(string(data), `{"type":"Placemark","ID":""`) {
+		t.Errorf("Expected type key first, got %s", data)
+	}
+	if !strings.Contains(string(data), `"Geometry":{"type":"Point",`) {
+		t.Errorf("Expected typed geometry, got %s", data)
+	}
+}
+
+// TestJSONUnmarshalErrors tests rejection of unknown and missing types
+func TestJSONUnmarshalErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want string
+	}{
+		{"unknown feature", `{"Feature":{"type":"Region"}}`, `unknown feature type "Region"`},
+		{"missing feature type", `{"Feature":{"type":"Folder","Features":[{"Name":"x"}]}}`, `unknown feature type ""`},
+		{"null child", `{"Feature":{"type":"Document","Features":[null]}}`, "null feature"},
+		{"unknown geometry", `{"Feature":{"type":"Placemark","Geometry":{"type":"Circle"}}}`, `unknown geometry type "Circle"`},
+		{"null part", `{"Feature":{"type":"Placemark","Geometry":{"type":"MultiGeometry","Geometries":[null]}}}`, "null geometry"},
+		{"bad color", `{"Feature":{"type":"GroundOverlay","Color":"red"}}`, "8 hexadecimal"},
+		{"not an object", `{"Feature":[1]}`, "cannot unmarshal"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var k KML
+			err := json.Unmarshal([]byte(tt.json), &k)
+			if err == nil {
+				t.Fatal("Expected an error, got nil")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+// TestJSONNullFeature tests that null root features and geometries decode as nil
+func TestJSONNullFeature(t *testing.T) {
+	var k KML
+	if err := json.Unmarshal([]byte(`{"Xmlns":"x","Feature":{"type":"Placemark","Geometry":null}}`), &k); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	p, ok := k.Feature.(*Placemark)
+	if !ok {
+		t.Fatalf("Expected *Placemark, got %T", k.Feature)
+	}
+	if p.Geometry != nil {
+		t.Errorf("Expected nil geometry, got %T", p.Geometry)
+	}
+
+	var empty KML
+	if err := json.Unmarshal([]byte(`{"Xmlns":"x"}`), &empty); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if empty.Feature != nil {
+		t.Errorf("Expected nil feature, got %T", empty.Feature)
+	}
+}
@@ -1,11 +1,21 @@
 package kml
 
 import (
+	"archive/zip"
 	"bytes"
+	"compress/gzip"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
+	"math/rand/v2"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
 const (
@@ -17,11 +27,15 @@ const (
 )
 
 // KML represents the root element of a KML document.
-// The Feature field can contain a Document, Folder, or Placemark.
+// The Feature field can contain a Document, Folder, Placemark, overlay or NetworkLink.
 type KML struct {
 	XMLName xml.Name `xml:"kml"`
 	Xmlns   string   `xml:"xmlns,attr"`
-	Feature Feature  `xml:"-"` // Document, Folder, or Placemark - custom marshaling
+	Feature Feature  `xml:"-"` // Document, Folder, Placemark, overlay or NetworkLink - custom marshaling
+
+	searchMu sync.Mutex   // Guards search
+	search   *textIndex   // See TextSearch
+	bounds   *boundsCache // See CacheBounds
 }
 
 // NewKML creates a new empty KML document with default namespace.
@@ -38,7 +52,9 @@ func (k *KML) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
 
 	// Add xmlns attribute
 	xmlns := k.Xmlns
-	if xmlns == "" {
+	if v := targetVersion(e); v != VersionUnknown {
+		xmlns = v.Namespace()
+	} else if xmlns == "" {
 		xmlns = DefaultNamespace
 	}
 	start.Attr = append(start.Attr, xml.Attr{
@@ -65,6 +81,22 @@ func (k *KML) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
 			if err := e.EncodeElement(f, xml.StartElement{Name: xml.Name{Local: "Placemark"}}); err != nil {
 				return err
 			}
+		case *GroundOverlay:
+			if err := e.EncodeElement(f, xml.StartElement{Name: xml.Name{Local: "GroundOverlay"}}); err != nil {
+				return err
+			}
+		case *NetworkLink:
+			if err := e.EncodeElement(f, xml.StartElement{Name: xml.Name{Local: "NetworkLink"}}); err != nil {
+				return err
+			}
+		case *ScreenOverlay:
+			if err := e.EncodeElement(f, xml.StartElement{Name: xml.Name{Local: "ScreenOverlay"}}); err != nil {
+				return err
+			}
+		case *Tour:
+			if err := e.EncodeElement(f, xml.StartElement{Name: xml.Name{Local: "Tour"}}); err != nil {
+				return err
+			}
 		}
 	}
 
@@ -72,7 +104,7 @@ func (k *KML) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
 }
 
 // UnmarshalXML implements custom XML unmarshaling for KML.
-// It reads the feature child (Document, Folder, or Placemark).
+// It reads the feature child (Document, Folder, Placemark, overlay or NetworkLink).
 func (k *KML) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 	// Process attributes
 	for _, attr := range start.Attr {
@@ -88,6 +120,7 @@ func (k *KML) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 
 	// Process child elements
 	for {
+		markToken(d)
 		token, err := d.Token()
 		if err != nil {
 			if err == io.EOF {
@@ -100,7 +133,10 @@ func (k *KML) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 		}
 
 		switch tok := token.(type) {
+		case xml.Comment:
+			keepComment(d, tok)
 		case xml.StartElement:
+			dropComments(d, tok)
 			switch tok.Name.Local {
 			case "Document":
 				var doc Document
@@ -129,9 +165,44 @@ func (k *KML) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 					}
 				}
 				k.Feature = &placemark
+			case "GroundOverlay":
+				var overlay GroundOverlay
+				if err := d.DecodeElement(&overlay, &tok); err != nil {
+					return &ParseError{
+						Message: "error parsing GroundOverlay element",
+						Cause:   err,
+					}
+				}
+				k.Feature = &overlay
+			case "NetworkLink":
+				var link NetworkLink
+				if err := d.DecodeElement(&link, &tok); err != nil {
+					return &ParseError{
+						Message: "error parsing NetworkLink element",
+						Cause:   err,
+					}
+				}
+				k.Feature = &link
+			case "ScreenOverlay":
+				var overlay ScreenOverlay
+				if err := d.DecodeElement(&overlay, &tok); err != nil {
+					return &ParseError{
+						Message: "error parsing ScreenOverlay element",
+						Cause:   err,
+					}
+				}
+				k.Feature = &overlay
+			case "Tour":
+				var tour Tour
+				if err := d.DecodeElement(&tour, &tok); err != nil {
+					return &ParseError{
+						Message: "error parsing Tour element",
+						Cause:   err,
+					}
+				}
+				k.Feature = &tour
 			default:
-				// Skip unknown elements
-				if err := d.Skip(); err != nil {
+				if err := skipElement(d, tok); err != nil {
 					return err
 				}
 			}
@@ -146,8 +217,42 @@ func (k *KML) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 }
 
 // Parse reads a KML document from an io.Reader.
-func Parse(r io.Reader) (*KML, error) {
-	decoder := xml.NewDecoder(r)
+// Gzip-compressed KML and KMZ (zip) archives are detected from their leading
+// bytes and decompressed transparently; from an archive, doc.kml or else the
+// first .kml file is parsed.
+func Parse(r io.Reader, opts ...ParseOption) (*KML, error) {
+	cfg := newParseConfig(opts)
+	if cfg.progress != nil {
+		cfg.progress.p.TotalBytes = inputSize(r)
+		r = &progressReader{r: r, t: cfg.progress}
+	}
+	if cfg.metrics == nil {
+		return parse(r, cfg)
+	}
+
+	start := time.Now()
+	counter := &countingReader{r: r}
+	cfg.stats = &ParseStats{Features: make(map[string]int), Invalid: make(map[string]int)}
+	k, err := parse(counter, cfg)
+	cfg.stats.Duration = time.Since(start)
+	cfg.stats.Bytes = counter.n
+	cfg.stats.Err = err
+	cfg.metrics.ObserveParse(*cfg.stats)
+	return k, err
+}
+
+// parse implements Parse with the options applied.
+func parse(r io.Reader, cfg *parseConfig) (*KML, error) {
+	rc, archive, err := decompressArchive(r)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	decoder := xml.NewDecoder(rc)
+	if *cfg != (parseConfig{}) {
+		defer registerParseConfig(decoder, cfg)()
+	}
 
 	var k KML
 	if err := decoder.Decode(&k); err != nil {
@@ -165,18 +270,38 @@ func Parse(r io.Reader) (*KML, error) {
 		return nil, ErrEmptyDocument
 	}
 
+	if cfg.duplicates != nil {
+		if err := applyDuplicates(&k, cfg.duplicates); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.order != nil {
+		if err := applyCoordinateOrder(&k, cfg.order, cfg.logger); err != nil {
+			return nil, err
+		}
+	}
+	if archive != nil || cfg.fetcher != nil {
+		k.attachImages(&imageSource{archive: archive, fetcher: cfg.fetcher})
+	}
+	if cfg.compact {
+		k.Compact()
+	}
+	if cfg.progress != nil {
+		cfg.progress.done()
+	}
+
 	return &k, nil
 }
 
 // ParseFile reads a KML document from a file path.
-func ParseFile(path string) (*KML, error) {
+func ParseFile(path string, opts ...ParseOption) (*KML, error) {
 	f, err := os.Open(path)
 	if err != nil {
 		return nil, fmt.Errorf("kml: error opening file: %w", err)
 	}
 	defer f.Close()
 
-	k, err := Parse(f)
+	k, err := Parse(f, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("kml: error parsing file %s: %w", path, err)
 	}
@@ -185,19 +310,26 @@ func ParseFile(path string) (*KML, error) {
 }
 
 // ParseBytes reads a KML document from a byte slice.
-func ParseBytes(data []byte) (*KML, error) {
-	return Parse(bytes.NewReader(data))
+func ParseBytes(data []byte, opts ...ParseOption) (*KML, error) {
+	return Parse(bytes.NewReader(data), opts...)
 }
 
 // Write writes a KML document to an io.Writer.
 // It outputs the XML declaration before the KML content.
-func (k *KML) Write(w io.Writer) error {
+func (k *KML) Write(w io.Writer, opts ...WriteOption) error {
+	cfg := newWriteConfig(opts)
+	w = k.trackWrite(w, cfg)
+
 	// Write XML declaration
 	if _, err := io.WriteString(w, XMLHeader); err != nil {
 		return fmt.Errorf("kml: error writing XML header: %w", err)
 	}
 
 	encoder := xml.NewEncoder(w)
+	if len(opts) > 0 {
+		defer registerWriteConfig(encoder, cfg)()
+	}
+
 	if err := encoder.Encode(k); err != nil {
 		return fmt.Errorf("kml: error encoding KML document: %w", err)
 	}
@@ -213,7 +345,10 @@ func (k *KML) Write(w io.Writer) error {
 // WriteIndent writes a KML document with indentation.
 // The prefix is written at the beginning of each line, and indent
 // specifies the indentation string for each level.
-func (k *KML) WriteIndent(w io.Writer, prefix, indent string) error {
+func (k *KML) WriteIndent(w io.Writer, prefix, indent string, opts ...WriteOption) error {
+	cfg := newWriteConfig(opts)
+	w = k.trackWrite(w, cfg)
+
 	// Write XML declaration
 	if _, err := io.WriteString(w, XMLHeader); err != nil {
 		return fmt.Errorf("kml: error writing XML header: %w", err)
@@ -221,6 +356,9 @@ func (k *KML) WriteIndent(w io.Writer, prefix, indent string) error {
 
 	encoder := xml.NewEncoder(w)
 	encoder.Indent(prefix, indent)
+	if len(opts) > 0 {
+		defer registerWriteConfig(encoder, cfg)()
+	}
 
 	if err := encoder.Encode(k); err != nil {
 		return fmt.Errorf("kml: error encoding KML document: %w", err)
@@ -234,26 +372,126 @@ func (k *KML) WriteIndent(w io.Writer, prefix, indent string) error {
 	return nil
 }
 
+// trackWrite prepares the progress hook of cfg, if any, for writing the
+// document to w and returns the writer to use.
+func (k *KML) trackWrite(w io.Writer, cfg *writeConfig) io.Writer {
+	if cfg.progress == nil {
+		return w
+	}
+	k.Walk(func(Feature) error {
+		cfg.progress.p.TotalFeatures++
+		return nil
+	})
+	return &progressWriter{w: w, t: cfg.progress}
+}
+
 // WriteFile writes a KML document to a file.
-// The file is created with permissions 0644.
-func (k *KML) WriteFile(path string) error {
-	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+// The document is written to a temporary file in the same directory, synced
+// to disk and renamed into place, so path is never left partially written.
+// A path ending in .kmz produces a KMZ archive holding doc.kml, and one
+// ending in .gz is gzip-compressed; files given with KMZFile are added to a
+// KMZ archive. A file that is replaced keeps its permissions, and a new one
+// is created with permissions 0644 less the umask, unless the FileMode
+// option is given.
+func (k *KML) WriteFile(path string, opts ...WriteOption) error {
+	cfg := newWriteConfig(opts)
+
+	f, err := createTempFile(path)
 	if err != nil {
 		return fmt.Errorf("kml: error creating file: %w", err)
 	}
-	defer f.Close()
+	tmp := f.Name()
+	defer os.Remove(tmp) // no-op once renamed
 
-	if err := k.WriteIndent(f, "", "  "); err != nil {
+	if err := k.writeFileContents(f, path, cfg, opts); err != nil {
+		f.Close()
 		return fmt.Errorf("kml: error writing to file %s: %w", path, err)
 	}
+	return commitTempFile(f, path, cfg.perm)
+}
+
+// createTempFile creates a temporary file beside path to be renamed over
+// it, with permissions 0644 less the umask.
+func createTempFile(path string) (*os.File, error) {
+	dir, base := filepath.Split(path)
+	for {
+		name := filepath.Join(dir, "."+base+".tmp"+strconv.FormatUint(uint64(rand.Uint32()), 10))
+		f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+		if !errors.Is(err, fs.ErrExist) {
+			return f, err
+		}
+	}
+}
 
+// commitTempFile sets the permissions of f, a temporary file made by
+// createTempFile, syncs and closes it, and renames it to path. Without
+// perm, f takes the permissions of the file it replaces, if any.
+func commitTempFile(f *os.File, path string, perm *os.FileMode) error {
+	if perm == nil {
+		if info, err := os.Stat(path); err == nil {
+			mode := info.Mode().Perm()
+			perm = &mode
+		}
+	}
+	if perm != nil {
+		if err := f.Chmod(*perm); err != nil {
+			f.Close()
+			return fmt.Errorf("kml: error setting permissions on %s: %w", path, err)
+		}
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("kml: error writing to file %s: %w", path, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("kml: error writing to file %s: %w", path, err)
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		return fmt.Errorf("kml: error creating file: %w", err)
+	}
 	return nil
 }
 
+// writeFileContents writes the document to w in the format implied by the
+// extension of path.
+func (k *KML) writeFileContents(w io.Writer, path string, cfg *writeConfig, opts []WriteOption) error {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".kmz":
+		zw := zip.NewWriter(w)
+		doc, err := zw.Create("doc.kml")
+		if err != nil {
+			return err
+		}
+		if err := k.WriteIndent(doc, "", "  ", opts...); err != nil {
+			return err
+		}
+		for _, file := range cfg.files {
+			if file.name == "doc.kml" {
+				return errors.New("kml: doc.kml cannot be added with KMZFile")
+			}
+			fw, err := zw.Create(file.name)
+			if err != nil {
+				return err
+			}
+			if _, err := fw.Write(file.data); err != nil {
+				return err
+			}
+		}
+		return zw.Close()
+	case ".gz":
+		zw := gzip.NewWriter(w)
+		if err := k.WriteIndent(zw, "", "  ", opts...); err != nil {
+			return err
+		}
+		return zw.Close()
+	}
+	return k.WriteIndent(w, "", "  ", opts...)
+}
+
 // Bytes returns the KML document as a byte slice.
-func (k *KML) Bytes() ([]byte, error) {
+func (k *KML) Bytes(opts ...WriteOption) ([]byte, error) {
 	var buf bytes.Buffer
-	if err := k.Write(&buf); err != nil {
+	if err := k.Write(&buf, opts...); err != nil {
 		return nil, err
 	}
 	return buf.Bytes(), nil
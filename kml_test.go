@@ -1,8 +1,13 @@
 package kml
 
 import (
+	"archive/zip"
 	"bytes"
 	"encoding/xml"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
 )
@@ -649,3 +654,189 @@ func createTestKML() *KML {
 	k.Feature = doc
 	return k
 }
+
+// failingGeometry is a geometry whose encoding always fails.
+type failingGeometry struct{}
+
+func (failingGeometry) geometryType() string       { return "Failing" }
+func (failingGeometry) ToGeoJSON() GeoJSONGeometry { return GeoJSONGeometry{} }
+func (failingGeometry) MarshalXML(*xml.Encoder, xml.StartElement) error {
+	return errors.New("encoding failed")
+}
+
+// TestWriteFileFormats tests writing plain, gzip and KMZ files by extension
+func TestWriteFileFormats(t *testing.T) {
+	dir := t.TempDir()
+
+	for _, name := range []string{"out.kml", "out.kml.gz", "out.kmz"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, name)
+			if err := createTestKML().WriteFile(path); err != nil {
+				t.Fatalf("WriteFile failed: %v", err)
+			}
+
+			k, err := ParseFile(path)
+			if err != nil {
+				t.Fatalf("ParseFile failed: %v", err)
+			}
+			if doc := k.Feature.(*Document); doc.Name != "Test Document" {
+				t.Errorf("Expected 'Test Document', got %q", doc.Name)
+			}
+		})
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "out.kmz"))
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Expected a zip archive: %v", err)
+	}
+	if len(zr.File) != 1 || zr.File[0].Name != "doc.kml" {
+		t.Errorf("Expected KMZ with doc.kml, got %v", zr.File)
+	}
+}
+
+// TestWriteFileAtomic tests that a failed write leaves the existing file intact
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "out.kml")
+	if err := os.WriteFile(path, []byte("original"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	k := NewKML()
+	k.Feature = &Folder{Features: []Feature{
+		&Placemark{Name: "ok", Geometry: &Point{}},
+		&Placemark{Name: "bad", Geometry: failingGeometry{}},
+	}}
+	if err := k.WriteFile(path); err == nil {
+		t.Fatal("Expected error from failing geometry")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if string(data) != "original" {
+		t.Errorf("Expected original content to be preserved, got %q", data)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir failed: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Expected temporary file to be removed, found %d entries", len(entries))
+	}
+}
+
+// TestWriteFileMode tests the FileMode option
+func TestWriteFileMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file permissions are not supported on Windows")
+	}
+
+	dir := t.TempDir()
+	tests := []struct {
+		name string
+		opts []WriteOption
+		want os.FileMode
+	}{
+		{"default.kml", nil, 0644 &^ umask(t, dir)},
+		{"private.kml", []WriteOption{FileMode(0600)}, 0600},
+		{"wide.kml", []WriteOption{FileMode(0666)}, 0666},
+	}
+
+	for _, tt := range tests {
+		path := filepath.Join(dir, tt.name)
+		if err := createTestKML().WriteFile(path, tt.opts...); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("Stat failed: %v", err)
+		}
+		if info.Mode().Perm() != tt.want {
+			t.Errorf("%s: expected mode %v, got %v", tt.name, tt.want, info.Mode().Perm())
+		}
+	}
+}
+
+// TestWriteFileKeepsMode tests that replacing a file keeps its permissions
+func TestWriteFileKeepsMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file permissions are not supported on Windows")
+	}
+
+	path := filepath.Join(t.TempDir(), "out.kml")
+	if err := os.WriteFile(path, []byte("original"), 0600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if err := os.Chmod(path, 0640); err != nil {
+		t.Fatalf("Chmod failed: %v", err)
+	}
+	if err := createTestKML().WriteFile(path); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if info.Mode().Perm() != 0640 {
+		t.Errorf("Expected mode 0640 to be kept, got %v", info.Mode().Perm())
+	}
+}
+
+// umask returns the permission bits the process umask clears, found by
+// creating a file in dir.
+func umask(t *testing.T, dir string) os.FileMode {
+	t.Helper()
+	f, err := os.OpenFile(filepath.Join(dir, "umask-probe"), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0777)
+	if err != nil {
+		t.Fatalf("OpenFile failed: %v", err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	return 0777 &^ info.Mode().Perm()
+}
+
+// TestWriteFileKMZFile tests adding extra files to a KMZ archive
+func TestWriteFileKMZFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "out.kmz")
+	if err := createTestKML().WriteFile(path, KMZFile("files/icon.png", []byte("png"))); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatalf("Expected a zip archive: %v", err)
+	}
+	defer zr.Close()
+	if len(zr.File) != 2 || zr.File[0].Name != "doc.kml" || zr.File[1].Name != "files/icon.png" {
+		t.Fatalf("Expected doc.kml and files/icon.png, got %v", zr.File)
+	}
+	rc, err := zr.File[1].Open()
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer rc.Close()
+	var buf bytes.Buffer
+	buf.ReadFrom(rc)
+	if buf.String() != "png" {
+		t.Errorf("Expected file contents png, got %q", buf.String())
+	}
+
+	if err := createTestKML().WriteFile(filepath.Join(dir, "bad.kmz"), KMZFile("doc.kml", nil)); err == nil {
+		t.Error("Expected an error adding doc.kml")
+	}
+	if err := createTestKML().WriteFile(filepath.Join(dir, "plain.kml"), KMZFile("files/icon.png", nil)); err != nil {
+		t.Errorf("Expected KMZFile to be ignored for .kml, got %v", err)
+	}
+}
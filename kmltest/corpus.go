@@ -0,0 +1,279 @@
+package kmltest
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	kml "github.com/robert-malhotra/go-kml"
+)
+
+// FileResult is the outcome of round-tripping one corpus file.
+type FileResult struct {
+	Name   string
+	Err    error          // Reading, parsing or writing failed
+	Stable bool           // A second round trip reproduces the first exactly
+	Input  map[string]int // Element counts by local name in the file
+	Output map[string]int // Element counts after parsing and writing it
+}
+
+// ElementStats totals the occurrences of one element across a corpus.
+type ElementStats struct {
+	Name   string
+	Files  int // Files containing the element
+	Input  int // Occurrences in the files
+	Output int // Occurrences after round trips
+}
+
+// Fidelity returns the fraction of input occurrences that survive a round
+// trip. Elements the package does not support score 0.
+func (s ElementStats) Fidelity() float64 {
+	if s.Input == 0 {
+		return 1
+	}
+	return float64(min(s.Input, s.Output)) / float64(s.Input)
+}
+
+// Report summarizes a corpus run.
+type Report struct {
+	Files []FileResult
+}
+
+// CheckFile parses data, which may be KML, gzipped KML or KMZ, writes it
+// back out and counts the elements before and after.
+func CheckFile(name string, data []byte) FileResult {
+	res := FileResult{Name: name}
+
+	raw, err := rawKML(data)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	if res.Input, err = countElements(raw); err != nil {
+		res.Err = err
+		return res
+	}
+
+	k, err := kml.ParseBytes(data)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	out, err := k.Bytes()
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	if res.Output, err = countElements(out); err != nil {
+		res.Err = err
+		return res
+	}
+
+	again, err := kml.ParseBytes(out)
+	if err != nil {
+		res.Err = fmt.Errorf("re-parsing output: %w", err)
+		return res
+	}
+	out2, err := again.Bytes()
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	res.Stable = bytes.Equal(out, out2)
+	return res
+}
+
+// RunCorpus checks every .kml, .kmz and .kml.gz file in dir, which may be
+// empty to skip it, and every document fetched from urls with client, or
+// http.DefaultClient if client is nil. Failures to read a file or fetch a
+// URL are recorded in its FileResult rather than stopping the run.
+func RunCorpus(dir string, urls []string, client *http.Client) (*Report, error) {
+	report := &Report{}
+
+	if dir != "" {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			name := strings.ToLower(e.Name())
+			if e.IsDir() || !(strings.HasSuffix(name, ".kml") || strings.HasSuffix(name, ".kmz") || strings.HasSuffix(name, ".kml.gz")) {
+				continue
+			}
+			data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+			if err != nil {
+				report.Files = append(report.Files, FileResult{Name: e.Name(), Err: err})
+				continue
+			}
+			report.Files = append(report.Files, CheckFile(e.Name(), data))
+		}
+	}
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+	for _, url := range urls {
+		data, err := fetch(client, url)
+		if err != nil {
+			report.Files = append(report.Files, FileResult{Name: url, Err: err})
+			continue
+		}
+		report.Files = append(report.Files, CheckFile(url, data))
+	}
+
+	return report, nil
+}
+
+// Failed returns the files that could not be round-tripped or whose output
+// changes on a second round trip.
+func (r *Report) Failed() []FileResult {
+	var failed []FileResult
+	for _, f := range r.Files {
+		if f.Err != nil || !f.Stable {
+			failed = append(failed, f)
+		}
+	}
+	return failed
+}
+
+// Elements returns the per-element totals across the successfully parsed
+// files, sorted by element name.
+func (r *Report) Elements() []ElementStats {
+	stats := make(map[string]*ElementStats)
+	get := func(name string) *ElementStats {
+		s, ok := stats[name]
+		if !ok {
+			s = &ElementStats{Name: name}
+			stats[name] = s
+		}
+		return s
+	}
+	for _, f := range r.Files {
+		if f.Err != nil {
+			continue
+		}
+		for name, n := range f.Input {
+			s := get(name)
+			s.Files++
+			s.Input += n
+		}
+		for name, n := range f.Output {
+			get(name).Output += n
+		}
+	}
+
+	list := make([]ElementStats, 0, len(stats))
+	for _, s := range stats {
+		list = append(list, *s)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// WriteTo writes the report as a plain-text dashboard: one line per file,
+// then the fidelity of each element, lowest first.
+func (r *Report) WriteTo(w io.Writer) (int64, error) {
+	var buf bytes.Buffer
+	tw := tabwriter.NewWriter(&buf, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "FILE\tSTATUS")
+	for _, f := range r.Files {
+		status := "ok"
+		switch {
+		case f.Err != nil:
+			status = "error: " + f.Err.Error()
+		case !f.Stable:
+			status = "unstable"
+		}
+		fmt.Fprintf(tw, "%s\t%s\n", f.Name, status)
+	}
+
+	elements := r.Elements()
+	sort.SliceStable(elements, func(i, j int) bool { return elements[i].Fidelity() < elements[j].Fidelity() })
+	fmt.Fprintln(tw, "\nELEMENT\tFILES\tIN\tOUT\tFIDELITY")
+	for _, s := range elements {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%.0f%%\n", s.Name, s.Files, s.Input, s.Output, 100*s.Fidelity())
+	}
+	if err := tw.Flush(); err != nil {
+		return 0, err
+	}
+	return buf.WriteTo(w)
+}
+
+// fetch downloads a document.
+func fetch(client *http.Client, url string) ([]byte, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: %s", url, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// rawKML returns the KML markup of data, unpacking gzip and KMZ archives.
+func rawKML(data []byte) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte{0x1f, 0x8b}):
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		return io.ReadAll(zr)
+	case bytes.HasPrefix(data, []byte("PK\x03\x04")):
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, err
+		}
+		var first *zip.File
+		for _, f := range zr.File {
+			if f.Name == "doc.kml" {
+				first = f
+				break
+			}
+			if first == nil && strings.EqualFold(filepath.Ext(f.Name), ".kml") {
+				first = f
+			}
+		}
+		if first == nil {
+			return nil, kml.ErrNoKMLInArchive
+		}
+		rc, err := first.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return io.ReadAll(rc)
+	}
+	return data, nil
+}
+
+// countElements counts the elements of an XML document by local name.
+func countElements(data []byte) (map[string]int, error) {
+	counts := make(map[string]int)
+	d := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := d.Token()
+		if errors.Is(err, io.EOF) {
+			return counts, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			counts[start.Name.Local]++
+		}
+	}
+}
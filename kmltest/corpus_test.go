@@ -0,0 +1,102 @@
+package kmltest
+
+import (
+	"bytes"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var corpusURLs = flag.String("kmltest.corpus", "", "comma-separated URLs of extra KML files for TestCorpus")
+
+// TestCorpus round-trips the vendored kml-samples files, and any given
+// with -kmltest.corpus, and logs the fidelity dashboard shown by go test -v
+func TestCorpus(t *testing.T) {
+	var urls []string
+	if *corpusURLs != "" {
+		urls = strings.Split(*corpusURLs, ",")
+	}
+
+	report, err := RunCorpus("../testdata", urls, nil)
+	if err != nil {
+		t.Fatalf("RunCorpus failed: %v", err)
+	}
+	if len(report.Files) == 0 {
+		t.Skip("no corpus files found")
+	}
+
+	var buf bytes.Buffer
+	if _, err := report.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo failed: %v", err)
+	}
+	t.Logf("\n%s", buf.String())
+
+	// Files that fail to parse are reported in the dashboard only, as some
+	// corpora carry placeholders such as saved 404 pages; an unstable
+	// round trip is a bug in the writer.
+	for _, f := range report.Failed() {
+		if f.Err == nil {
+			t.Errorf("%s: output changes on a second round trip", f.Name)
+		}
+	}
+}
+
+// TestCheckFile tests element counting across a round trip
+func TestCheckFile(t *testing.T) {
+	data := `<kml xmlns="http://www.opengis.net/kml/2.2"><Placemark>
+  <name>A</name>
+  <Snippet>unsupported</Snippet>
+  <Point><coordinates>1,2</coordinates></Point>
+</Placemark></kml>`
+
+	res := CheckFile("a.kml", []byte(data))
+	if res.Err != nil {
+		t.Fatalf("CheckFile failed: %v", res.Err)
+	}
+	if !res.Stable {
+		t.Error("Expected a stable round trip")
+	}
+	if res.Input["Snippet"] != 1 || res.Output["Snippet"] != 0 {
+		t.Errorf("Expected Snippet to be dropped, got %d in and %d out", res.Input["Snippet"], res.Output["Snippet"])
+	}
+	if res.Input["Point"] != 1 || res.Output["Point"] != 1 {
+		t.Errorf("Expected Point to survive, got %d in and %d out", res.Input["Point"], res.Output["Point"])
+	}
+
+	if res := CheckFile("bad.kml", []byte("<kml><Placemark>")); res.Err == nil {
+		t.Error("Expected error for malformed file")
+	}
+}
+
+// TestRunCorpusURLs tests fetching extra corpus files
+func TestRunCorpusURLs(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ok.kml" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`<kml><Folder><name>x</name><Snippet>s</Snippet></Folder></kml>`))
+	}))
+	defer srv.Close()
+
+	report, err := RunCorpus("", []string{srv.URL + "/ok.kml", srv.URL + "/missing.kml"}, srv.Client())
+	if err != nil {
+		t.Fatalf("RunCorpus failed: %v", err)
+	}
+	if len(report.Files) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(report.Files))
+	}
+	if failed := report.Failed(); len(failed) != 1 || !strings.HasSuffix(failed[0].Name, "/missing.kml") {
+		t.Errorf("Expected only the missing URL to fail, got %v", failed)
+	}
+
+	stats := make(map[string]ElementStats)
+	for _, s := range report.Elements() {
+		stats[s.Name] = s
+	}
+	if stats["Snippet"].Fidelity() != 0 || stats["Folder"].Fidelity() != 1 {
+		t.Errorf("Unexpected fidelity: Snippet %v, Folder %v", stats["Snippet"].Fidelity(), stats["Folder"].Fidelity())
+	}
+}
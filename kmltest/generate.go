@@ -0,0 +1,297 @@
+package kmltest
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"time"
+
+	kml "github.com/robert-malhotra/go-kml"
+)
+
+// GenerateOptions bounds the documents produced by Generate.
+type GenerateOptions struct {
+	MaxDepth    int // Deepest nesting of Folders below the Document (default 3)
+	MaxFeatures int // Most features in one container (default 5)
+	MaxCoords   int // Most coordinates in one path or ring (default 8)
+	MaxStyles   int // Most shared styles in the Document (default 3)
+}
+
+// defaults returns a copy of the options with zero values replaced by defaults.
+func (o GenerateOptions) defaults() GenerateOptions {
+	if o.MaxDepth <= 0 {
+		o.MaxDepth = 3
+	}
+	if o.MaxFeatures <= 0 {
+		o.MaxFeatures = 5
+	}
+	if o.MaxCoords < 2 {
+		o.MaxCoords = 8
+	}
+	if o.MaxStyles <= 0 {
+		o.MaxStyles = 3
+	}
+	return o
+}
+
+// Generate returns a random, valid KML document for property-based tests.
+// The root is a Document holding shared styles and a tree of Folders,
+// Placemarks of every geometry type, overlays and NetworkLinks. Every
+// styleUrl resolves, rings are closed, and values are chosen to survive a
+// write and parse unchanged: coordinates have at most six decimal places
+// and times are whole seconds in UTC. The same rng state always yields the
+// same document.
+func Generate(rng *rand.Rand, opts GenerateOptions) *kml.KML {
+	g := &generator{rng: rng, opts: opts.defaults()}
+
+	doc := &kml.Document{ID: "doc", Name: g.text()}
+	for i := 0; i < 1+rng.Intn(g.opts.MaxStyles); i++ {
+		doc.Styles = append(doc.Styles, g.style(fmt.Sprintf("style-%d", i)))
+	}
+	g.styleIDs = make([]string, len(doc.Styles))
+	for i, s := range doc.Styles {
+		g.styleIDs[i] = s.ID
+	}
+	if rng.Intn(2) == 0 {
+		doc.StyleMaps = []kml.StyleMap{{
+			ID: "map",
+			Pairs: []kml.Pair{
+				{Key: "normal", StyleURL: "#" + g.pickStyle()},
+				{Key: "highlight", StyleURL: "#" + g.pickStyle()},
+			},
+		}}
+		g.styleIDs = append(g.styleIDs, "map")
+	}
+	doc.Features = g.features(0)
+
+	k := kml.NewKML()
+	k.Feature = doc
+	return k
+}
+
+// Doc is a generated document that implements quick.Generator, so that
+// testing/quick can pass random documents to property functions. The
+// number of features per container grows with the size hint, one for every
+// ten.
+type Doc struct {
+	*kml.KML
+}
+
+// Generate implements quick.Generator.
+func (Doc) Generate(rng *rand.Rand, size int) reflect.Value {
+	return reflect.ValueOf(Doc{Generate(rng, GenerateOptions{MaxFeatures: 1 + size/10})})
+}
+
+// generator carries the state of one Generate call.
+type generator struct {
+	rng      *rand.Rand
+	opts     GenerateOptions
+	styleIDs []string
+	nextID   int
+}
+
+// textRunes are the characters of generated text, including the XML
+// special characters so that escaping is exercised.
+var textRunes = []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789 &<>'\"éø東京")
+
+// text returns a random non-empty string without leading or trailing
+// spaces.
+func (g *generator) text() string {
+	n := 1 + g.rng.Intn(12)
+	runes := make([]rune, n)
+	for i := range runes {
+		runes[i] = textRunes[g.rng.Intn(len(textRunes))]
+	}
+	if runes[0] == ' ' {
+		runes[0] = '_'
+	}
+	if runes[n-1] == ' ' {
+		runes[n-1] = '_'
+	}
+	return string(runes)
+}
+
+// id returns a unique element ID.
+func (g *generator) id() string {
+	g.nextID++
+	return fmt.Sprintf("id-%d", g.nextID)
+}
+
+// round rounds v to six decimal places.
+func round(v float64) float64 {
+	return math.Round(v*1e6) / 1e6
+}
+
+func (g *generator) coord() kml.Coordinate {
+	c := kml.Coord(round(g.rng.Float64()*360-180), round(g.rng.Float64()*180-90))
+	if g.rng.Intn(3) == 0 {
+		c.Alt = round(g.rng.Float64() * 1000)
+	}
+	return c
+}
+
+func (g *generator) coords(min int) []kml.Coordinate {
+	n := min
+	if g.opts.MaxCoords > min {
+		n += g.rng.Intn(g.opts.MaxCoords - min + 1)
+	}
+	coords := make([]kml.Coordinate, n)
+	for i := range coords {
+		coords[i] = g.coord()
+	}
+	return coords
+}
+
+// ring returns a closed ring around a random center.
+func (g *generator) ring() kml.LinearRing {
+	center := g.coord()
+	n := 3 + g.rng.Intn(int(math.Max(1, float64(g.opts.MaxCoords-3))))
+	coords := make([]kml.Coordinate, 0, n+1)
+	for i := 0; i < n; i++ {
+		angle := 2 * math.Pi * float64(i) / float64(n)
+		coords = append(coords, kml.Coord(
+			round(math.Max(-180, math.Min(180, center.Lon+math.Cos(angle)))),
+			round(math.Max(-90, math.Min(90, center.Lat+math.Sin(angle)))),
+		))
+	}
+	return kml.LinearRing{Coordinates: append(coords, coords[0])}
+}
+
+func (g *generator) altitudeMode() kml.AltitudeMode {
+	modes := []kml.AltitudeMode{"", kml.AltitudeModeClampToGround, kml.AltitudeModeRelativeToGround, kml.AltitudeModeAbsolute}
+	return modes[g.rng.Intn(len(modes))]
+}
+
+func (g *generator) when() time.Time {
+	return time.Unix(g.rng.Int63n(4e9), 0).UTC()
+}
+
+func (g *generator) color() kml.Color {
+	return kml.RGBA(uint8(g.rng.Intn(256)), uint8(g.rng.Intn(256)), uint8(g.rng.Intn(256)), uint8(g.rng.Intn(256)))
+}
+
+func (g *generator) style(id string) kml.Style {
+	s := kml.Style{ID: id}
+	if g.rng.Intn(2) == 0 {
+		s.IconStyle = &kml.IconStyle{Color: g.color(), Scale: float64(1+g.rng.Intn(20)) / 10}
+	}
+	if g.rng.Intn(2) == 0 {
+		s.LabelStyle = &kml.LabelStyle{Color: g.color(), Scale: float64(1+g.rng.Intn(20)) / 10}
+	}
+	if g.rng.Intn(2) == 0 {
+		s.LineStyle = &kml.LineStyle{Color: g.color(), Width: float64(1 + g.rng.Intn(8))}
+	}
+	if g.rng.Intn(2) == 0 {
+		s.PolyStyle = &kml.PolyStyle{Color: g.color()}
+	}
+	return s
+}
+
+func (g *generator) pickStyle() string {
+	return g.styleIDs[g.rng.Intn(len(g.styleIDs))]
+}
+
+// geometry returns a random geometry; MultiGeometries nest at most depth
+// levels.
+func (g *generator) geometry(depth int) kml.Geometry {
+	kinds := 6
+	if depth <= 0 {
+		kinds = 5
+	}
+	switch g.rng.Intn(kinds) {
+	case 0:
+		return &kml.Point{Coordinates: g.coord()}
+	case 1:
+		return &kml.LineString{Tessellate: g.rng.Intn(2) == 0, AltitudeMode: g.altitudeMode(), Coordinates: g.coords(2)}
+	case 2:
+		ring := g.ring()
+		return &ring
+	case 3:
+		p := &kml.Polygon{OuterBoundary: g.ring()}
+		for i := g.rng.Intn(3); i > 0; i-- {
+			p.InnerBoundaries = append(p.InnerBoundaries, g.ring())
+		}
+		return p
+	case 4:
+		coords := g.coords(2)
+		track := &kml.Track{AltitudeMode: g.altitudeMode(), Coords: coords}
+		start := g.when()
+		for i := range coords {
+			track.When = append(track.When, start.Add(time.Duration(i)*time.Minute))
+		}
+		return track
+	}
+	mg := &kml.MultiGeometry{}
+	for i := 1 + g.rng.Intn(3); i > 0; i-- {
+		mg.Geometries = append(mg.Geometries, g.geometry(depth-1))
+	}
+	return mg
+}
+
+func (g *generator) placemark() *kml.Placemark {
+	pm := &kml.Placemark{ID: g.id(), Name: g.text(), Geometry: g.geometry(2)}
+	if g.rng.Intn(2) == 0 {
+		pm.Description = g.text()
+	}
+	if g.rng.Intn(2) == 0 {
+		pm.StyleURL = "#" + g.pickStyle()
+	}
+	if g.rng.Intn(3) == 0 {
+		vis := g.rng.Intn(2) == 0
+		pm.Visibility = &vis
+	}
+	if g.rng.Intn(3) == 0 {
+		pm.TimeStamp = &kml.TimeStamp{When: g.when()}
+	}
+	if g.rng.Intn(2) == 0 {
+		ed := &kml.ExtendedData{}
+		for i := 1 + g.rng.Intn(3); i > 0; i-- {
+			ed.Data = append(ed.Data, kml.Data{Name: fmt.Sprintf("field%d", i), Value: g.text()})
+		}
+		pm.ExtendedData = ed
+	}
+	return pm
+}
+
+func (g *generator) overlay() kml.Feature {
+	switch g.rng.Intn(3) {
+	case 0:
+		south := round(g.rng.Float64()*170 - 85)
+		west := round(g.rng.Float64()*350 - 175)
+		return &kml.GroundOverlay{
+			ID:        g.id(),
+			Name:      g.text(),
+			Icon:      &kml.Icon{Href: "images/" + g.id() + ".png"},
+			LatLonBox: &kml.LatLonBox{North: south + 1, South: south, East: west + 1, West: west},
+		}
+	case 1:
+		return &kml.ScreenOverlay{
+			ID:        g.id(),
+			Name:      g.text(),
+			Icon:      &kml.Icon{Href: "legend.png"},
+			OverlayXY: &kml.Vec2{X: 0, Y: 1, XUnits: kml.UnitsFraction, YUnits: kml.UnitsFraction},
+			ScreenXY:  &kml.Vec2{X: 10, Y: 10, XUnits: kml.UnitsPixels, YUnits: kml.UnitsInsetPixels},
+		}
+	}
+	return &kml.NetworkLink{ID: g.id(), Name: g.text(), Link: &kml.Link{Href: "https://example.com/" + g.id() + ".kml"}}
+}
+
+// features returns the children of a container at the given depth.
+func (g *generator) features(depth int) []kml.Feature {
+	n := g.rng.Intn(g.opts.MaxFeatures + 1)
+	features := make([]kml.Feature, 0, n)
+	for i := 0; i < n; i++ {
+		switch r := g.rng.Intn(10); {
+		case r < 2 && depth < g.opts.MaxDepth:
+			folder := &kml.Folder{ID: g.id(), Name: g.text(), Open: g.rng.Intn(2) == 0}
+			folder.Features = g.features(depth + 1)
+			features = append(features, folder)
+		case r == 2:
+			features = append(features, g.overlay())
+		default:
+			features = append(features, g.placemark())
+		}
+	}
+	return features
+}
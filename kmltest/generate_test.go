@@ -0,0 +1,99 @@
+package kmltest
+
+import (
+	"math/rand"
+	"testing"
+	"testing/quick"
+
+	kml "github.com/robert-malhotra/go-kml"
+)
+
+// TestGenerateDeterministic tests that a seed always yields the same document
+func TestGenerateDeterministic(t *testing.T) {
+	a, err := Generate(rand.New(rand.NewSource(7)), GenerateOptions{}).Bytes()
+	if err != nil {
+		t.Fatalf("Bytes failed: %v", err)
+	}
+	b, err := Generate(rand.New(rand.NewSource(7)), GenerateOptions{}).Bytes()
+	if err != nil {
+		t.Fatalf("Bytes failed: %v", err)
+	}
+	if string(a) != string(b) {
+		t.Error("Expected the same seed to generate the same document")
+	}
+}
+
+// TestGenerateValid tests that generated documents are well formed
+func TestGenerateValid(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	geometries := make(map[string]bool)
+
+	for i := 0; i < 50; i++ {
+		k := Generate(rng, GenerateOptions{MaxDepth: 2, MaxFeatures: 4})
+		if dangling := k.CheckReferences(); len(dangling) != 0 {
+			t.Fatalf("Expected all references to resolve, got %v", dangling)
+		}
+		doc := k.Feature.(*kml.Document)
+		if len(doc.Styles) == 0 {
+			t.Fatal("Expected shared styles")
+		}
+		for _, pm := range k.Placemarks() {
+			geometries[geometryName(pm.Geometry)] = true
+		}
+	}
+
+	for _, name := range []string{"Point", "LineString", "LinearRing", "Polygon", "Track", "MultiGeometry"} {
+		if !geometries[name] {
+			t.Errorf("Expected generated documents to include a %s", name)
+		}
+	}
+}
+
+// TestGeneratedRoundTrip property-tests write and parse of random documents
+func TestGeneratedRoundTrip(t *testing.T) {
+	roundTrip := func(d Doc) bool {
+		data, err := d.Bytes()
+		if err != nil {
+			t.Logf("Bytes failed: %v", err)
+			return false
+		}
+		parsed, err := kml.ParseBytes(data)
+		if err != nil {
+			t.Logf("Parse failed: %v", err)
+			return false
+		}
+		again, err := parsed.Bytes()
+		if err != nil {
+			return false
+		}
+		diffs, err := Diff(data, again, Options{})
+		if err != nil || len(diffs) != 0 {
+			t.Logf("Round trip differs: %v %v", err, diffs)
+			return false
+		}
+		return parsed.Feature.Hash() == d.Feature.Hash()
+	}
+
+	if err := quick.Check(roundTrip, &quick.Config{MaxCount: 100, Rand: rand.New(rand.NewSource(42))}); err != nil {
+		t.Error(err)
+	}
+}
+
+// geometryName returns the KML element name of a geometry's type.
+func geometryName(g kml.Geometry) string {
+	switch g.(type) {
+	case *kml.Point:
+		return "Point"
+	case *kml.LineString:
+		return "LineString"
+	case *kml.LinearRing:
+		return "LinearRing"
+	case *kml.Polygon:
+		return "Polygon"
+	case *kml.Track:
+		return "Track"
+	case *kml.MultiGeometry:
+		return "MultiGeometry"
+	}
+	return ""
+}
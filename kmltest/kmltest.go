@@ -0,0 +1,346 @@
+// Package kmltest provides helpers for testing code that generates KML.
+//
+// AssertEqualKML compares two documents tolerantly: whitespace in text is
+// ignored, numbers and coordinates are compared within an epsilon, and
+// optionally sibling elements may appear in any order. AssertGolden
+// compares output against a golden file, rewriting the file instead when
+// the test binary is run with -kmltest.update.
+package kmltest
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("kmltest.update", false, "rewrite kmltest golden files with the current output")
+
+// maxReported caps the number of differences AssertEqualKML reports.
+const maxReported = 10
+
+// Options configures the comparison.
+type Options struct {
+	// Epsilon is the largest difference at which two numbers, including
+	// the values in coordinate tuples, are considered equal
+	// (default 1e-9).
+	Epsilon float64
+
+	// IgnoreOrder lets sibling elements with different names appear in any
+	// order, as writers do not agree on the order of properties such as
+	// name, visibility and styleUrl. Siblings with the same name, such as
+	// the Placemarks of a Folder, must still appear in the same order.
+	IgnoreOrder bool
+}
+
+// defaults returns a copy of the options with zero values replaced by defaults.
+func (o Options) defaults() Options {
+	if o.Epsilon <= 0 {
+		o.Epsilon = 1e-9
+	}
+	return o
+}
+
+// AssertEqualKML reports a test error for each difference between the KML
+// documents want and got, or a fatal error if either does not parse as XML.
+func AssertEqualKML(t testing.TB, want, got []byte, opts Options) {
+	t.Helper()
+
+	diffs, err := Diff(want, got, opts)
+	if err != nil {
+		t.Fatalf("kmltest: %v", err)
+	}
+	for i, d := range diffs {
+		if i == maxReported {
+			t.Errorf("kmltest: ... and %d more differences", len(diffs)-maxReported)
+			break
+		}
+		t.Errorf("kmltest: %s", d)
+	}
+}
+
+// AssertGolden compares got with the contents of the golden file at path
+// as AssertEqualKML does. When the test binary is run with
+// -kmltest.update, the golden file is written with got instead, creating
+// its directory if needed.
+func AssertGolden(t testing.TB, path string, got []byte, opts Options) {
+	t.Helper()
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("kmltest: %v", err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatalf("kmltest: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("kmltest: %v (run with -kmltest.update to create it)", err)
+	}
+	AssertEqualKML(t, want, got, opts)
+}
+
+// Diff returns a description of each difference between the KML documents
+// want and got, each prefixed with the path of the element concerned, such
+// as "/kml/Document/Placemark[2]/name". It returns an error if either
+// document does not parse as XML.
+func Diff(want, got []byte, opts Options) ([]string, error) {
+	opts = opts.defaults()
+
+	w, err := parseTree(want)
+	if err != nil {
+		return nil, fmt.Errorf("parsing want: %w", err)
+	}
+	g, err := parseTree(got)
+	if err != nil {
+		return nil, fmt.Errorf("parsing got: %w", err)
+	}
+
+	c := &comparer{opts: opts}
+	c.compare("/"+w.name, w, g)
+	return c.diffs, nil
+}
+
+// node is an element of a parsed document.
+type node struct {
+	name     string
+	attrs    map[string]string
+	text     string
+	children []*node
+}
+
+// parseTree parses an XML document into its root element. Namespace
+// declarations are dropped and element and attribute names reduced to
+// their local part, so the same document written with different prefixes
+// compares equal.
+func parseTree(data []byte) (*node, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	var stack []*node
+	var root *node
+	for {
+		tok, err := d.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			n := &node{name: tok.Name.Local, attrs: make(map[string]string)}
+			for _, a := range tok.Attr {
+				if a.Name.Space == "xmlns" || a.Name.Local == "xmlns" {
+					continue
+				}
+				n.attrs[a.Name.Local] = a.Value
+			}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, n)
+			} else if root == nil {
+				root = n
+			}
+			stack = append(stack, n)
+		case xml.EndElement:
+			n := stack[len(stack)-1]
+			n.text = strings.Join(strings.Fields(n.text), " ")
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text += string(tok)
+			}
+		}
+	}
+	if root == nil {
+		return nil, errors.New("no root element")
+	}
+	return root, nil
+}
+
+// comparer accumulates differences between two trees.
+type comparer struct {
+	opts  Options
+	diffs []string
+}
+
+func (c *comparer) errorf(path, format string, args ...any) {
+	c.diffs = append(c.diffs, path+": "+fmt.Sprintf(format, args...))
+}
+
+// compare compares two elements found at path.
+func (c *comparer) compare(path string, want, got *node) {
+	if want.name != got.name {
+		c.errorf(path, "expected element <%s>, got <%s>", want.name, got.name)
+		return
+	}
+
+	for name, wv := range want.attrs {
+		gv, ok := got.attrs[name]
+		switch {
+		case !ok:
+			c.errorf(path, "missing attribute %s=%q", name, wv)
+		case !c.textEqual(want.name, wv, gv):
+			c.errorf(path, "attribute %s: expected %q, got %q", name, wv, gv)
+		}
+	}
+	for name, gv := range got.attrs {
+		if _, ok := want.attrs[name]; !ok {
+			c.errorf(path, "unexpected attribute %s=%q", name, gv)
+		}
+	}
+
+	if !c.textEqual(want.name, want.text, got.text) {
+		c.errorf(path, "expected text %q, got %q", want.text, got.text)
+	}
+
+	if c.opts.IgnoreOrder {
+		c.compareGrouped(path, want.children, got.children)
+	} else {
+		c.compareChildren(path, want.children, got.children)
+	}
+}
+
+// compareChildren compares two lists of children pairwise.
+func (c *comparer) compareChildren(path string, want, got []*node) {
+	counts := make(map[string]int)
+	for i := 0; i < len(want) || i < len(got); i++ {
+		switch {
+		case i >= len(got):
+			c.errorf(path, "missing element <%s>", want[i].name)
+		case i >= len(want):
+			c.errorf(path, "unexpected element <%s>", got[i].name)
+		default:
+			counts[want[i].name]++
+			c.compare(childPath(path, want[i].name, counts[want[i].name]), want[i], got[i])
+		}
+	}
+}
+
+// compareGrouped compares children name by name, keeping the order of
+// children with the same name.
+func (c *comparer) compareGrouped(path string, want, got []*node) {
+	wantGroups, order := groupByName(want)
+	gotGroups, gotOrder := groupByName(got)
+	for _, name := range gotOrder {
+		if _, ok := wantGroups[name]; !ok {
+			order = append(order, name)
+		}
+	}
+
+	for _, name := range order {
+		w, g := wantGroups[name], gotGroups[name]
+		for i := 0; i < len(w) || i < len(g); i++ {
+			switch {
+			case i >= len(g):
+				c.errorf(path, "missing element <%s>", name)
+			case i >= len(w):
+				c.errorf(path, "unexpected element <%s>", name)
+			default:
+				c.compare(childPath(path, name, i+1), w[i], g[i])
+			}
+		}
+	}
+}
+
+// groupByName groups nodes by name, returning the names in the order they
+// first appear.
+func groupByName(nodes []*node) (map[string][]*node, []string) {
+	groups := make(map[string][]*node)
+	var order []string
+	for _, n := range nodes {
+		if _, ok := groups[n.name]; !ok {
+			order = append(order, n.name)
+		}
+		groups[n.name] = append(groups[n.name], n)
+	}
+	return groups, order
+}
+
+// childPath returns the path of the index-th (1-based) child with the given
+// name. The index is omitted for the first child.
+func childPath(parent, name string, index int) string {
+	if index == 1 {
+		return parent + "/" + name
+	}
+	return fmt.Sprintf("%s/%s[%d]", parent, name, index)
+}
+
+// textEqual compares the whitespace-normalized text of an element. The
+// tuples of coordinates elements are compared value by value, and any other
+// text that parses as a number on both sides is compared numerically.
+func (c *comparer) textEqual(element, want, got string) bool {
+	if want == got {
+		return true
+	}
+	if element == "coordinates" {
+		return c.coordinatesEqual(want, got)
+	}
+	wf, err1 := strconv.ParseFloat(want, 64)
+	gf, err2 := strconv.ParseFloat(got, 64)
+	if err1 == nil && err2 == nil {
+		return c.near(wf, gf)
+	}
+	if element == "coord" {
+		return c.valuesEqual(strings.Fields(want), strings.Fields(got))
+	}
+	return false
+}
+
+// coordinatesEqual compares two KML coordinate strings tuple by tuple. A
+// missing altitude equals an altitude of zero.
+func (c *comparer) coordinatesEqual(want, got string) bool {
+	wt, gt := strings.Fields(want), strings.Fields(got)
+	if len(wt) != len(gt) {
+		return false
+	}
+	for i := range wt {
+		wv, gv := strings.Split(wt[i], ","), strings.Split(gt[i], ",")
+		for len(wv) < 3 {
+			wv = append(wv, "0")
+		}
+		for len(gv) < 3 {
+			gv = append(gv, "0")
+		}
+		if !c.valuesEqual(wv, gv) {
+			return false
+		}
+	}
+	return true
+}
+
+// valuesEqual compares two lists of numbers within the epsilon.
+func (c *comparer) valuesEqual(want, got []string) bool {
+	if len(want) != len(got) {
+		return false
+	}
+	for i := range want {
+		wf, err1 := strconv.ParseFloat(want[i], 64)
+		gf, err2 := strconv.ParseFloat(got[i], 64)
+		if err1 != nil || err2 != nil {
+			if want[i] != got[i] {
+				return false
+			}
+			continue
+		}
+		if !c.near(wf, gf) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *comparer) near(a, b float64) bool {
+	return math.Abs(a-b) <= c.opts.Epsilon
+}
@@ -0,0 +1,157 @@
+package kmltest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	kml "github.com/robert-malhotra/go-kml"
+)
+
+const base = `<kml xmlns="http://www.opengis.net/kml/2.2">
+  <Document>
+    <name>Sites</name>
+    <Placemark id="a">
+      <name>A</name>
+      <visibility>1</visibility>
+      <Point><coordinates>1.5,2.25</coordinates></Point>
+    </Placemark>
+    <Placemark id="b">
+      <name>B</name>
+      <description><![CDATA[<b>bold</b>]]></description>
+    </Placemark>
+  </Document>
+</kml>`
+
+// recorder captures the failures reported through testing.TB.
+type recorder struct {
+	testing.TB
+	errors []string
+	fatal  bool
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+	r.fatal = true
+}
+
+// TestDiffEqual tests documents that differ only in tolerated ways
+func TestDiffEqual(t *testing.T) {
+	tests := []struct {
+		name string
+		got  string
+		opts Options
+	}{
+		{"identical", base, Options{}},
+		{"whitespace", strings.NewReplacer("\n", "", "  ", "", "<name>Sites", "<name>\n  Sites  ").Replace(base), Options{}},
+		{"escaped description", strings.Replace(base, "<![CDATA[<b>bold</b>]]>", "&lt;b&gt;bold&lt;/b&gt;", 1), Options{}},
+		{"coordinate epsilon", strings.Replace(base, "1.5,2.25", "1.5000001,2.25,0", 1), Options{Epsilon: 1e-6}},
+		{"number format", strings.Replace(base, "<visibility>1<", "<visibility>1.0<", 1), Options{}},
+		{"namespace prefix", strings.Replace(strings.Replace(base, `<kml xmlns="http://www.opengis.net/kml/2.2">`, `<k:kml xmlns:k="http://www.opengis.net/kml/2.2">`, 1), "</kml>", "</k:kml>", 1), Options{}},
+		{"property order", strings.Replace(base, "<name>A</name>\n      <visibility>1</visibility>", "<visibility>1</visibility><name>A</name>", 1), Options{IgnoreOrder: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diffs, err := Diff([]byte(base), []byte(tt.got), tt.opts)
+			if err != nil {
+				t.Fatalf("Diff failed: %v", err)
+			}
+			if len(diffs) != 0 {
+				t.Errorf("Expected no differences, got %v", diffs)
+			}
+		})
+	}
+}
+
+// TestDiffDifferent tests the reported differences
+func TestDiffDifferent(t *testing.T) {
+	tests := []struct {
+		name string
+		got  string
+		opts Options
+		want string
+	}{
+		{"text", strings.Replace(base, "<name>B<", "<name>C<", 1), Options{}, `/kml/Document/Placemark[2]/name: expected text "B", got "C"`},
+		{"coordinates", strings.Replace(base, "1.5,2.25", "1.5001,2.25", 1), Options{Epsilon: 1e-6}, "/kml/Document/Placemark/Point/coordinates: expected text"},
+		{"attribute", strings.Replace(base, `id="b"`, `id="c"`, 1), Options{}, `/kml/Document/Placemark[2]: attribute id: expected "b", got "c"`},
+		{"missing element", strings.Replace(base, "<visibility>1</visibility>", "", 1), Options{}, "/kml/Document/Placemark: missing element <Point>"},
+		{"property order", strings.Replace(base, "<name>A</name>\n      <visibility>1</visibility>", "<visibility>1</visibility><name>A</name>", 1), Options{}, "/kml/Document/Placemark/name: expected element <name>, got <visibility>"},
+		{"feature order", strings.NewReplacer(`id="a"`, `id="b"`, `id="b"`, `id="a"`).Replace(base), Options{IgnoreOrder: true}, `/kml/Document/Placemark: attribute id: expected "a", got "b"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diffs, err := Diff([]byte(base), []byte(tt.got), tt.opts)
+			if err != nil {
+				t.Fatalf("Diff failed: %v", err)
+			}
+			found := false
+			for _, d := range diffs {
+				if strings.HasPrefix(d, tt.want) {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("Expected a difference starting %q, got %v", tt.want, diffs)
+			}
+		})
+	}
+
+	if _, err := Diff([]byte(base), []byte("<kml>"), Options{}); err == nil {
+		t.Error("Expected error for malformed XML")
+	}
+}
+
+// TestAssertEqualKML tests reporting through testing.TB
+func TestAssertEqualKML(t *testing.T) {
+	k := kml.NewKML()
+	k.Feature = &kml.Placemark{Name: "A", Geometry: &kml.Point{Coordinates: kml.Coord(1, 2)}}
+	got, err := k.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes failed: %v", err)
+	}
+
+	want := `<kml xmlns="http://www.opengis.net/kml/2.2"><Placemark><name>A</name><Point><coordinates>1,2,0</coordinates></Point></Placemark></kml>`
+	AssertEqualKML(t, []byte(want), got, Options{})
+
+	r := &recorder{}
+	AssertEqualKML(r, []byte(strings.Replace(want, ">A<", ">B<", 1)), got, Options{})
+	if len(r.errors) != 1 || r.fatal {
+		t.Errorf("Expected one non-fatal error, got %v", r.errors)
+	}
+
+	r = &recorder{}
+	AssertEqualKML(r, []byte("not xml"), got, Options{})
+	if !r.fatal {
+		t.Error("Expected fatal error for unparsable input")
+	}
+}
+
+// TestAssertGolden tests golden file comparison and update
+func TestAssertGolden(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "golden", "doc.kml")
+
+	r := &recorder{}
+	AssertGolden(r, path, []byte(base), Options{})
+	if !r.fatal || !strings.Contains(r.errors[0], "-kmltest.update") {
+		t.Errorf("Expected fatal error suggesting -kmltest.update, got %v", r.errors)
+	}
+
+	*update = true
+	AssertGolden(t, path, []byte(base), Options{})
+	*update = false
+
+	if data, err := os.ReadFile(path); err != nil || string(data) != base {
+		t.Fatalf("Expected golden file to be written, got %v", err)
+	}
+	AssertGolden(t, path, []byte(base), Options{})
+}
@@ -0,0 +1,191 @@
+package kml
+
+import (
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/draw"
+	"strings"
+)
+
+// LegendEntry is one row of a legend: a label and the style it explains.
+type LegendEntry struct {
+	Label string
+	Style *Style
+}
+
+// LegendEntries returns one entry per shared style that features reference,
+// in order of first use, labelled with the style's ID. A StyleMap
+// reference is labelled with the StyleMap ID and shows its normal style.
+// Labels can be edited before rendering the legend.
+func (k *KML) LegendEntries() []LegendEntry {
+	idx := newStyleIndex(k)
+	seen := make(map[string]bool)
+	var entries []LegendEntry
+
+	k.Walk(func(f Feature) error {
+		url := styleURLOf(f)
+		id, ok := localFragment(url)
+		if !ok || seen[id] {
+			return nil
+		}
+		if s := idx.resolveState(url, "normal"); s != nil {
+			seen[id] = true
+			entries = append(entries, LegendEntry{Label: id, Style: s})
+		}
+		return nil
+	})
+
+	return entries
+}
+
+// LegendHTML renders entries as an HTML table suitable for a description
+// balloon. Icons are shown by their href; line and polygon styles as
+// colored swatches.
+func LegendHTML(entries []LegendEntry) string {
+	var sb strings.Builder
+	sb.WriteString(`<table style="border-collapse:collapse">`)
+	for _, e := range entries {
+		sb.WriteString(`<tr><td style="padding:2px 6px">`)
+		sb.WriteString(legendSwatchHTML(e.Style))
+		sb.WriteString(`</td><td style="padding:2px 6px">`)
+		sb.WriteString(html.EscapeString(e.Label))
+		sb.WriteString(`</td></tr>`)
+	}
+	sb.WriteString(`</table>`)
+	return sb.String()
+}
+
+// legendSwatchHTML returns the HTML swatch for a style.
+func legendSwatchHTML(s *Style) string {
+	if s == nil {
+		return ""
+	}
+	if href := iconHref(s); href != "" {
+		return fmt.Sprintf(`<img src="%s" width="16" height="16"/>`, html.EscapeString(href))
+	}
+	fill, border, ok := legendColors(s)
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf(`<span style="display:inline-block;width:16px;height:12px;background:%s;border:2px solid %s"></span>`,
+		cssColor(fill), cssColor(border))
+}
+
+// legendColors returns the fill and border colors that represent a style:
+// the PolyStyle and LineStyle colors, or the icon or label color for
+// point styles. Unset colors default to opaque white, as in Google Earth.
+func legendColors(s *Style) (fill, border Color, ok bool) {
+	orWhite := func(c Color) Color {
+		if c == (Color{}) {
+			return White
+		}
+		return c
+	}
+	switch {
+	case s.PolyStyle != nil:
+		fill = orWhite(s.PolyStyle.Color)
+		border = fill
+		if s.LineStyle != nil {
+			border = orWhite(s.LineStyle.Color)
+		}
+		if s.PolyStyle.Fill != nil && !*s.PolyStyle.Fill {
+			fill = Transparent
+		}
+		return fill, border, true
+	case s.LineStyle != nil:
+		c := orWhite(s.LineStyle.Color)
+		return c, c, true
+	case s.IconStyle != nil:
+		c := orWhite(s.IconStyle.Color)
+		return c, c, true
+	case s.LabelStyle != nil:
+		c := orWhite(s.LabelStyle.Color)
+		return c, c, true
+	}
+	return Color{}, Color{}, false
+}
+
+// cssColor formats a KML color as a CSS rgba() value.
+func cssColor(c Color) string {
+	return fmt.Sprintf("rgba(%d,%d,%d,%.2f)", c.R, c.G, c.B, float64(c.A)/255)
+}
+
+// Legend image layout, in pixels before scaling.
+const (
+	legendPadding = 4
+	legendSwatch  = 10
+	legendRow     = 14
+)
+
+// LegendImage renders entries as an image with a swatch and label per row,
+// on an opaque white background, for use as a ScreenOverlay. Labels use a
+// built-in 5x7 pixel ASCII font; other characters are drawn as '?'. scale
+// enlarges the image by an integer factor and is at least 1.
+func LegendImage(entries []LegendEntry, scale int) *image.RGBA {
+	scale = max(1, scale)
+	width := 0
+	for _, e := range entries {
+		width = max(width, len([]rune(e.Label)))
+	}
+	w := 2*legendPadding + legendSwatch + legendPadding + 6*width
+	h := 2*legendPadding + legendRow*len(entries)
+
+	img := image.NewRGBA(image.Rect(0, 0, w*scale, h*scale))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+
+	rect := func(x0, y0, x1, y1 int, c color.Color) {
+		r := image.Rect(x0*scale, y0*scale, x1*scale, y1*scale)
+		draw.Draw(img, r, image.NewUniform(c), image.Point{}, draw.Over)
+	}
+
+	for i, e := range entries {
+		top := legendPadding + i*legendRow
+		x := legendPadding
+		if e.Style != nil {
+			if fill, border, ok := legendColors(e.Style); ok {
+				rect(x, top, x+legendSwatch, top+legendSwatch, rgbaColor(border))
+				rect(x+2, top+2, x+legendSwatch-2, top+legendSwatch-2, color.White)
+				rect(x+2, top+2, x+legendSwatch-2, top+legendSwatch-2, rgbaColor(fill))
+			}
+		}
+
+		x += legendSwatch + legendPadding
+		for _, r := range e.Label {
+			if r < 0x20 || r > 0x7E {
+				r = '?'
+			}
+			glyph := font5x7[r-0x20]
+			for col, bits := range glyph {
+				for row := 0; row < 8; row++ {
+					if bits>>row&1 == 1 {
+						rect(x+col, top+1+row, x+col+1, top+2+row, color.Black)
+					}
+				}
+			}
+			x += 6
+		}
+	}
+	return img
+}
+
+// rgbaColor converts a KML color to a non-premultiplied color.Color.
+func rgbaColor(c Color) color.Color {
+	return color.NRGBA{R: c.R, G: c.G, B: c.B, A: c.A}
+}
+
+// NewLegendOverlay returns a ScreenOverlay showing the legend image at
+// href in the lower left corner of the screen. Its description holds the
+// same legend as HTML, shown when the overlay is clicked in the places
+// panel.
+func NewLegendOverlay(href string, entries []LegendEntry) *ScreenOverlay {
+	return &ScreenOverlay{
+		Name:        "Legend",
+		Description: LegendHTML(entries),
+		Icon:        &Icon{Href: href},
+		OverlayXY:   &Vec2{X: 0, Y: 0, XUnits: UnitsFraction, YUnits: UnitsFraction},
+		ScreenXY:    &Vec2{X: 10, Y: 30, XUnits: UnitsPixels, YUnits: UnitsPixels},
+		Size:        &Vec2{X: -1, Y: -1, XUnits: UnitsPixels, YUnits: UnitsPixels},
+	}
+}
@@ -0,0 +1,120 @@
+package kml
+
+import (
+	"image/color"
+	"strings"
+	"testing"
+)
+
+func legendKML() *KML {
+	noFill := false
+	k := NewKML()
+	k.Feature = &Document{
+		Styles: []Style{
+			{ID: "unused", LineStyle: &LineStyle{Color: Blue}},
+			{ID: "river", LineStyle: &LineStyle{Color: Blue, Width: 2}},
+			{ID: "park", PolyStyle: &PolyStyle{Color: Green}, LineStyle: &LineStyle{Color: Black}},
+			{ID: "pin", IconStyle: &IconStyle{Icon: &Icon{Href: "pin.png"}}},
+			{ID: "outline", PolyStyle: &PolyStyle{Fill: &noFill}, LineStyle: &LineStyle{Color: Red}},
+		},
+		StyleMaps: []StyleMap{{ID: "pinMap", Pairs: []Pair{{Key: "normal", StyleURL: "#pin"}}}},
+		Features: []Feature{
+			&Placemark{StyleURL: "#river"},
+			&Folder{Features: []Feature{
+				&Placemark{StyleURL: "#park"},
+				&Placemark{StyleURL: "#river"},
+				&Placemark{StyleURL: "#pinMap"},
+				&Placemark{StyleURL: "#missing"},
+				&Placemark{StyleURL: "#outline"},
+			}},
+		},
+	}
+	return k
+}
+
+// TestLegendEntries tests collecting referenced styles in order of first use
+func TestLegendEntries(t *testing.T) {
+	entries := legendKML().LegendEntries()
+
+	var labels []string
+	for _, e := range entries {
+		labels = append(labels, e.Label)
+	}
+	if got := strings.Join(labels, ","); got != "river,park,pinMap,outline" {
+		t.Errorf("Expected river,park,pinMap,outline, got %s", got)
+	}
+	if entries[2].Style.IconStyle == nil {
+		t.Error("Expected StyleMap entry to show its normal style")
+	}
+}
+
+// TestLegendHTML tests the HTML balloon legend
+func TestLegendHTML(t *testing.T) {
+	entries := legendKML().LegendEntries()
+	entries[0].Label = "Rivers & streams"
+
+	out := LegendHTML(entries)
+	for _, want := range []string{
+		"Rivers &amp; streams",
+		"background:rgba(0,0,255,1.00)",
+		"background:rgba(0,255,0,1.00);border:2px solid rgba(0,0,0,1.00)",
+		`<img src="pin.png"`,
+		"background:rgba(255,255,255,0.00);border:2px solid rgba(255,0,0,1.00)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected legend to contain %q, got:\n%s", want, out)
+		}
+	}
+}
+
+// TestLegendImage tests the rendered legend layout
+func TestLegendImage(t *testing.T) {
+	entries := []LegendEntry{
+		{Label: "Park", Style: &Style{PolyStyle: &PolyStyle{Color: Green}}},
+		{Label: "é", Style: nil},
+	}
+
+	img := LegendImage(entries, 2)
+	wantW := (2*legendPadding + legendSwatch + legendPadding + 6*4) * 2
+	wantH := (2*legendPadding + 2*legendRow) * 2
+	if img.Bounds().Dx() != wantW || img.Bounds().Dy() != wantH {
+		t.Fatalf("Expected %dx%d image, got %v", wantW, wantH, img.Bounds())
+	}
+
+	// Swatch center is the fill color.
+	cx, cy := (legendPadding+legendSwatch/2)*2, (legendPadding+legendSwatch/2)*2
+	if c := img.RGBAAt(cx, cy); c != (color.RGBA{G: 255, A: 255}) {
+		t.Errorf("Expected green swatch, got %v", c)
+	}
+
+	// The label column contains black glyph pixels.
+	dark := 0
+	x0 := (legendPadding + legendSwatch + legendPadding) * 2
+	for y := 0; y < legendRow*2; y++ {
+		for x := x0; x < img.Bounds().Dx(); x++ {
+			if img.RGBAAt(x, y+legendPadding*2) == (color.RGBA{A: 255}) {
+				dark++
+			}
+		}
+	}
+	if dark == 0 {
+		t.Error("Expected label glyphs to be drawn")
+	}
+
+	if empty := LegendImage(nil, 0); empty.Bounds().Dx() != 3*legendPadding+legendSwatch {
+		t.Errorf("Unexpected empty legend size %v", empty.Bounds())
+	}
+}
+
+// TestNewLegendOverlay tests the legend ScreenOverlay placement and balloon
+func TestNewLegendOverlay(t *testing.T) {
+	entries := legendKML().LegendEntries()
+	o := NewLegendOverlay("legend.png", entries)
+
+	if o.Icon.Href != "legend.png" || o.OverlayXY.X != 0 || o.ScreenXY.XUnits != UnitsPixels {
+		t.Errorf("Unexpected overlay: %+v", o)
+	}
+	if o.Description != LegendHTML(entries) {
+		t.Error("Expected HTML legend as the description")
+	}
+}
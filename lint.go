@@ -0,0 +1,371 @@
+package kml
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Severity ranks how serious a lint finding is.
+type Severity int
+
+const (
+	SeverityInfo    Severity = iota // Worth knowing; the document is fine
+	SeverityWarning                 // Likely to degrade performance or usability
+	SeverityError                   // Likely to break the document for its readers
+)
+
+// String returns the lowercase name of the severity.
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	}
+	return "Severity(" + strconv.Itoa(int(s)) + ")"
+}
+
+// MarshalText implements encoding.TextMarshaler, so findings encode their
+// severity by name.
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// Finding is a problem reported by a lint rule.
+type Finding struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+	Feature  Feature  `json:"-"`              // The feature the problem was found in
+	ID       string   `json:"id,omitempty"`   // The feature's id, if any
+	Span     *Span    `json:"span,omitempty"` // Set for documents parsed with RecordSpans
+	Fixable  bool     `json:"fixable,omitempty"`
+
+	fix func(Feature) bool
+}
+
+// String returns the finding as "line:column: severity: rule: message",
+// omitting the position when the span is unknown.
+func (f Finding) String() string {
+	s := fmt.Sprintf("%s: %s: %s", f.Severity, f.Rule, f.Message)
+	if f.Span != nil {
+		s = f.Span.Start.String() + ": " + s
+	}
+	return s
+}
+
+// Rule is a lint check. Check is called with each feature of the document
+// and returns a message for every problem it finds in that feature. Rules
+// that can repair what they find also set Fix, which corrects every problem
+// of the rule in f and reports whether it changed anything; see Fix. Rules
+// that can repair only some features also set CanFix, which reports
+// whether Fix would change f; without it, every finding of a rule with Fix
+// is Fixable.
+type Rule struct {
+	Name     string
+	Severity Severity
+	Check    func(f Feature) []string
+	Fix      func(f Feature) bool
+	CanFix   func(f Feature) bool
+}
+
+var (
+	rulesMu sync.RWMutex
+	rules   = []Rule{
+		CoordinatePrecisionRule(6),
+		MissingNameRule(),
+		LocalHrefRule(),
+		PolygonVerticesRule(10000),
+		MixedAltitudeModesRule(),
+		DescriptionWhitespaceRule(),
+	}
+)
+
+// RegisterRule adds r to the rules Lint runs by default. It panics if r has
+// no name or Check function, or if a rule with the same name is already
+// registered.
+func RegisterRule(r Rule) {
+	if r.Name == "" || r.Check == nil {
+		panic("kml: RegisterRule requires a name and a Check function")
+	}
+	rulesMu.Lock()
+	defer rulesMu.Unlock()
+	for _, existing := range rules {
+		if existing.Name == r.Name {
+			panic("kml: RegisterRule called twice for rule " + r.Name)
+		}
+	}
+	rules = append(rules, r)
+}
+
+// RegisteredRules returns the rules Lint runs by default: the built-in
+// rules followed by those added with RegisterRule.
+func RegisteredRules() []Rule {
+	rulesMu.RLock()
+	defer rulesMu.RUnlock()
+	return append([]Rule(nil), rules...)
+}
+
+// Lint checks the document against rules, or against RegisteredRules if
+// none are given, and returns the findings in document order.
+func Lint(k *KML, rules ...Rule) []Finding {
+	if len(rules) == 0 {
+		rules = RegisteredRules()
+	}
+
+	var findings []Finding
+	k.Walk(func(f Feature) error {
+		var span *Span
+		if s, ok := SourceSpan(f); ok {
+			span = &s
+		}
+		for _, r := range rules {
+			msgs := r.Check(f)
+			if len(msgs) == 0 {
+				continue
+			}
+			fix := r.Fix
+			if fix != nil && r.CanFix != nil && !r.CanFix(f) {
+				fix = nil
+			}
+			for _, msg := range msgs {
+				findings = append(findings, Finding{
+					Rule:     r.Name,
+					Severity: r.Severity,
+					Message:  msg,
+					Feature:  f,
+					ID:       featureID(f),
+					Span:     span,
+					Fixable:  fix != nil,
+					fix:      fix,
+				})
+			}
+		}
+		return nil
+	})
+	return findings
+}
+
+// CoordinatePrecisionRule reports geometries whose coordinates carry more
+// than digits decimal places. The extra digits describe less than the
+// width of a hair yet make up much of an uncompressed file; the fix rounds
+// them away, as the Precision write option does on output. It is
+// registered with six digits.
+func CoordinatePrecisionRule(digits int) Rule {
+	return Rule{
+		Name:     "coordinate-precision",
+		Severity: SeverityWarning,
+		Check: func(f Feature) []string {
+			pm, ok := f.(*Placemark)
+			if !ok || pm.Geometry == nil {
+				return nil
+			}
+			n := 0
+			for _, c := range getGeometryCoordinates(pm.Geometry) {
+				if decimals(c.Lon) > digits || decimals(c.Lat) > digits || decimals(c.Alt) > digits {
+					n++
+				}
+			}
+			if n == 0 {
+				return nil
+			}
+			return []string{fmt.Sprintf("%d coordinates have more than %d decimal places", n, digits)}
+		},
+		Fix: func(f Feature) bool {
+			pm, ok := f.(*Placemark)
+			if !ok || pm.Geometry == nil {
+				return false
+			}
+			roundGeometry(pm.Geometry, digits)
+			return true
+		},
+	}
+}
+
+// MissingNameRule reports features without a name, which appear as blank
+// entries in the places panel of most viewers. The fix names placemarks
+// from an ExtendedData field called name, title or label, matched without
+// regard to case; findings for other features are not Fixable.
+func MissingNameRule() Rule {
+	return Rule{
+		Name:     "missing-name",
+		Severity: SeverityInfo,
+		Check: func(f Feature) []string {
+			if featureName(f) != "" {
+				return nil
+			}
+			return []string{strings.ToLower(f.featureType()) + " has no name"}
+		},
+		Fix: func(f Feature) bool {
+			pm, ok := f.(*Placemark)
+			if !ok || pm.Name != "" {
+				return false
+			}
+			pm.Name = nameFromExtendedData(pm.ExtendedData)
+			return pm.Name != ""
+		},
+		CanFix: func(f Feature) bool {
+			pm, ok := f.(*Placemark)
+			return ok && pm.Name == "" && nameFromExtendedData(pm.ExtendedData) != ""
+		},
+	}
+}
+
+// localPath matches references to the author's file system: file URLs,
+// Windows drive and UNC paths, and home-relative paths.
+var localPath = regexp.MustCompile(`^(?i:file:|[a-z]:[\\/]|\\\\|~[\\/])`)
+
+// LocalHrefRule reports hrefs and styleUrls that point at an absolute path
+// on the author's machine, which do not resolve for anyone else.
+func LocalHrefRule() Rule {
+	return Rule{
+		Name:     "local-href",
+		Severity: SeverityError,
+		Check: func(f Feature) []string {
+			var msgs []string
+			eachHref(f, func(href *string) {
+				if localPath.MatchString(*href) {
+					msgs = append(msgs, fmt.Sprintf("%q is a local file path", *href))
+				}
+			})
+			return msgs
+		},
+	}
+}
+
+// PolygonVerticesRule reports polygons with more than max vertices across
+// their boundaries, which render slowly and are better simplified or split.
+// It is registered with a limit of 10000.
+func PolygonVerticesRule(max int) Rule {
+	return Rule{
+		Name:     "polygon-vertices",
+		Severity: SeverityWarning,
+		Check: func(f Feature) []string {
+			pm, ok := f.(*Placemark)
+			if !ok {
+				return nil
+			}
+			var msgs []string
+			for _, poly := range polygonsOf(pm.Geometry) {
+				n := len(poly.OuterBoundary.Coordinates)
+				for _, ring := range poly.InnerBoundaries {
+					n += len(ring.Coordinates)
+				}
+				if n > max {
+					msgs = append(msgs, fmt.Sprintf("polygon has %d vertices, more than %d", n, max))
+				}
+			}
+			return msgs
+		},
+	}
+}
+
+// MixedAltitudeModesRule reports MultiGeometries whose parts, and
+// containers whose direct children, use different altitude modes, which
+// usually means some of them float or sink unintentionally.
+func MixedAltitudeModesRule() Rule {
+	return Rule{
+		Name:     "mixed-altitude-modes",
+		Severity: SeverityWarning,
+		Check: func(f Feature) []string {
+			modes := make(map[AltitudeMode]bool)
+			switch feature := f.(type) {
+			case *Placemark:
+				if _, ok := feature.Geometry.(*MultiGeometry); !ok {
+					return nil
+				}
+				altitudeModes(feature.Geometry, modes)
+			case *Document:
+				childAltitudeModes(feature.Features, modes)
+			case *Folder:
+				childAltitudeModes(feature.Features, modes)
+			}
+			if len(modes) < 2 {
+				return nil
+			}
+			names := make([]string, 0, len(modes))
+			for _, m := range []AltitudeMode{AltitudeModeClampToGround, AltitudeModeRelativeToGround, AltitudeModeAbsolute, AltitudeModeClampToSeaFloor, AltitudeModeRelativeToSeaFloor} {
+				if modes[m] {
+					names = append(names, string(m))
+					delete(modes, m)
+				}
+			}
+			for m := range modes {
+				names = append(names, string(m))
+			}
+			return []string{strings.ToLower(f.featureType()) + " mixes altitude modes " + strings.Join(names, ", ")}
+		},
+	}
+}
+
+// childAltitudeModes adds the altitude modes of the placemarks and ground
+// overlays among features to modes.
+func childAltitudeModes(features []Feature, modes map[AltitudeMode]bool) {
+	for _, child := range features {
+		switch c := child.(type) {
+		case *Placemark:
+			altitudeModes(c.Geometry, modes)
+		case *GroundOverlay:
+			modes[effectiveAltitudeMode(c.AltitudeMode)] = true
+		}
+	}
+}
+
+// altitudeModes adds the altitude modes used by g to modes.
+func altitudeModes(g Geometry, modes map[AltitudeMode]bool) {
+	switch geom := g.(type) {
+	case *Point:
+		modes[effectiveAltitudeMode(geom.AltitudeMode)] = true
+	case *LineString:
+		modes[effectiveAltitudeMode(geom.AltitudeMode)] = true
+	case *LinearRing:
+		modes[effectiveAltitudeMode(geom.AltitudeMode)] = true
+	case *Polygon:
+		modes[effectiveAltitudeMode(geom.AltitudeMode)] = true
+	case *Track:
+		modes[effectiveAltitudeMode(geom.AltitudeMode)] = true
+	case *MultiGeometry:
+		for _, child := range geom.Geometries {
+			altitudeModes(child, modes)
+		}
+	}
+}
+
+// effectiveAltitudeMode returns m, or clampToGround, the KML default, if m
+// is unset.
+func effectiveAltitudeMode(m AltitudeMode) AltitudeMode {
+	if m == "" {
+		return AltitudeModeClampToGround
+	}
+	return m
+}
+
+// polygonsOf returns the polygons in g.
+func polygonsOf(g Geometry) []*Polygon {
+	switch geom := g.(type) {
+	case *Polygon:
+		return []*Polygon{geom}
+	case *MultiGeometry:
+		var polys []*Polygon
+		for _, child := range geom.Geometries {
+			polys = append(polys, polygonsOf(child)...)
+		}
+		return polys
+	}
+	return nil
+}
+
+// decimals returns the number of decimal places in the shortest
+// representation of v.
+func decimals(v float64) int {
+	s := strconv.FormatFloat(v, 'f', -1, 64)
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		return len(s) - i - 1
+	}
+	return 0
+}
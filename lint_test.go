@@ -0,0 +1,121 @@
+package kml
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// TestLintRules tests each built-in rule
+func TestLintRules(t *testing.T) {
+	square := func(n int) *Polygon {
+		coords := make([]Coordinate, n)
+		for i := range coords {
+			coords[i] = Coordinate{Lon: float64(i % 2), Lat: float64(i / 2 % 2)}
+		}
+		return &Polygon{OuterBoundary: LinearRing{Coordinates: coords}}
+	}
+
+	tests := []struct {
+		name    string
+		rule    Rule
+		feature Feature
+		want    []string
+	}{
+		{"precise", CoordinatePrecisionRule(6), &Placemark{Geometry: &LineString{Coordinates: []Coordinate{{Lon: 1.1234567, Lat: 2}, {Lon: 1.123456, Lat: 2.5}}}}, []string{"1 coordinates have more than 6 decimal places"}},
+		{"rounded", CoordinatePrecisionRule(6), &Placemark{Geometry: &Point{Coordinates: Coordinate{Lon: 1.123456, Lat: 2}}}, nil},
+		{"unnamed placemark", MissingNameRule(), &Placemark{}, []string{"placemark has no name"}},
+		{"named folder", MissingNameRule(), &Folder{Name: "F"}, nil},
+		{"file url", LocalHrefRule(), &GroundOverlay{Name: "G", Icon: &Icon{Href: "file:///C:/scan.png"}}, []string{`"file:///C:/scan.png" is a local file path`}},
+		{"windows path", LocalHrefRule(), &Placemark{Style: &Style{IconStyle: &IconStyle{Icon: &Icon{Href: `C:\icons\pin.png`}}}}, []string{`"C:\\icons\\pin.png" is a local file path`}},
+		{"relative href", LocalHrefRule(), &NetworkLink{Link: &Link{Href: "tiles/0.kml"}}, nil},
+		{"http href", LocalHrefRule(), &NetworkLink{Link: &Link{Href: "https://example.com/a.kml"}}, nil},
+		{"large polygon", PolygonVerticesRule(4), &Placemark{Geometry: &MultiGeometry{Geometries: []Geometry{square(4), square(5)}}}, []string{"polygon has 5 vertices, more than 4"}},
+		{"mixed multigeometry", MixedAltitudeModesRule(), &Placemark{Geometry: &MultiGeometry{Geometries: []Geometry{&Point{}, &Point{AltitudeMode: AltitudeModeAbsolute}}}}, []string{"placemark mixes altitude modes clampToGround, absolute"}},
+		{"uniform folder", MixedAltitudeModesRule(), &Folder{Features: []Feature{&Placemark{Geometry: &Point{}}, &GroundOverlay{AltitudeMode: AltitudeModeClampToGround}}}, nil},
+		{"mixed folder", MixedAltitudeModesRule(), &Folder{Features: []Feature{&Placemark{Geometry: &Point{AltitudeMode: AltitudeModeRelativeToGround}}, &GroundOverlay{}}}, []string{"folder mixes altitude modes clampToGround, relativeToGround"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.rule.Check(tt.feature)
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestLint tests running the registered rules over a parsed document
+func TestLint(t *testing.T) {
+	data := `<kml xmlns="http://www.opengis.net/kml/2.2">
+<Document>
+  <name>Survey</name>
+  <Placemark>
+    <Point><coordinates>1.123456789,2</coordinates></Point>
+  </Placemark>
+</Document>
+</kml>`
+	k, err := ParseBytes([]byte(data), RecordSpans())
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	findings := Lint(k)
+	if len(findings) != 2 {
+		t.Fatalf("Expected 2 findings, got %v", findings)
+	}
+	if got := findings[0].String(); got != "4:3: warning: coordinate-precision: 1 coordinates have more than 6 decimal places" {
+		t.Errorf("Expected precision finding, got %q", got)
+	}
+	if got := findings[1].String(); got != "4:3: info: missing-name: placemark has no name" {
+		t.Errorf("Expected missing name finding, got %q", got)
+	}
+	if findings[1].Feature != k.Placemarks()[0] {
+		t.Error("Expected the finding to reference the placemark")
+	}
+
+	out, err := json.Marshal(findings[1])
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	want := `{"rule":"missing-name","severity":"info","message":"placemark has no name","span":{"start":{"offset":80,"line":4,"column":3},"end":{"offset":166,"line":6,"column":15}}}`
+	if string(out) != want {
+		t.Errorf("Expected JSON %s, got %s", want, out)
+	}
+}
+
+// TestRegisterRule tests adding custom rules to the default set
+func TestRegisterRule(t *testing.T) {
+	saved := rules
+	defer func() { rules = saved }()
+
+	RegisterRule(Rule{
+		Name:     "untitled-document",
+		Severity: SeverityError,
+		Check: func(f Feature) []string {
+			if doc, ok := f.(*Document); ok && doc.Name == "Untitled" {
+				return []string{"document still has its template name"}
+			}
+			return nil
+		},
+	})
+
+	k := NewKML()
+	k.Feature = &Document{Name: "Untitled"}
+	findings := Lint(k)
+	if len(findings) != 1 || findings[0].Rule != "untitled-document" || findings[0].Severity != SeverityError {
+		t.Errorf("Expected the custom finding, got %v", findings)
+	}
+
+	if got := Lint(k, MissingNameRule()); len(got) != 0 {
+		t.Errorf("Expected only the given rules to run, got %v", got)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected a panic registering a duplicate rule")
+		}
+	}()
+	RegisterRule(Rule{Name: "missing-name", Check: func(Feature) []string { return nil }})
+}
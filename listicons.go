@@ -0,0 +1,156 @@
+package kml
+
+import "strconv"
+
+// FolderIconOptions configures FolderIcons.
+type FolderIconOptions struct {
+	// Prefix is prepended to a number to form style IDs. The default is
+	// "folder-icon-".
+	Prefix string
+
+	// HashIDs derives style IDs from the content of each style instead,
+	// as "folder-icon-3fa2bc", so the same style gets the same ID across
+	// runs and files. A style equal to a shared style of the document
+	// with that ID is not returned again.
+	HashIDs bool
+}
+
+// defaults returns o with zero fields set to their defaults.
+func (o FolderIconOptions) defaults() FolderIconOptions {
+	if o.Prefix == "" {
+		o.Prefix = "folder-icon-"
+	}
+	return o
+}
+
+// FolderIcons gives each Folder the icon most of the placemarks within it
+// are drawn with as its list item icon, so the Places panel shows what a
+// folder holds. Ties go to the icon that appears first. Placemark icons
+// are resolved as by EffectiveStyle in the normal state.
+//
+// A folder is pointed at a new style holding a ListStyle with the icon; a
+// folder with a shared Style of its own gets a copy of it, sharing its
+// sub-styles, with the ListStyle added. Folders whose style already has
+// item icons, or whose styleUrl refers to a StyleMap or another file, are
+// left unchanged, as are folders without icons inside them. Folders with
+// the same icon and original style share a new style. FolderIcons returns
+// the styles used, to be added to the Document.
+func (k *KML) FolderIcons(opts FolderIconOptions) []Style {
+	opts = opts.defaults()
+	idx := newStyleIndex(k)
+
+	var styles []Style
+	ids := make(map[[2]string]string) // Base style URL and icon to style ID
+	n := 0
+	var visit func(f Feature, inherited string) *iconTally
+	visit = func(f Feature, inherited string) *iconTally {
+		tally := &iconTally{count: make(map[string]int)}
+		var children []Feature
+		switch feature := f.(type) {
+		case *Placemark:
+			if href := placemarkIcon(idx, feature, inherited); href != "" {
+				tally.add(href, 1)
+			}
+			return tally
+		case *Document:
+			children = feature.Features
+		case *Folder:
+			children = feature.Features
+		default:
+			return tally
+		}
+
+		if s := idx.resolveState(styleURLOf(f), "normal"); s != nil && s.IconStyle != nil {
+			inherited = iconHref(s)
+		}
+		for _, child := range children {
+			tally.merge(visit(child, inherited))
+		}
+
+		folder, ok := f.(*Folder)
+		href := tally.dominant()
+		if !ok || href == "" {
+			return tally
+		}
+		base := Style{}
+		if folder.StyleURL != "" {
+			id, local := localFragment(folder.StyleURL)
+			s, shared := idx.styles[id]
+			if !local || !shared || (s.ListStyle != nil && len(s.ListStyle.ItemIcons) > 0) {
+				return tally
+			}
+			base = *s
+		}
+
+		key := [2]string{folder.StyleURL, href}
+		id, ok := ids[key]
+		if !ok {
+			for {
+				n++
+				id = opts.Prefix + strconv.Itoa(n)
+				if !idx.has(id) {
+					break
+				}
+			}
+			ids[key] = id
+			list := ListStyle{}
+			if base.ListStyle != nil {
+				list = *base.ListStyle
+			}
+			list.ItemIcons = []ItemIcon{{Href: href}}
+			base.ID, base.ListStyle = id, &list
+			styles = append(styles, base)
+		}
+		folder.StyleURL = "#" + id
+		return tally
+	}
+	visit(k.Feature, "")
+	if opts.HashIDs {
+		styles = hashStyleIDs(styles, opts.Prefix, idx, k.Feature)
+	}
+	return styles
+}
+
+// placemarkIcon returns the icon pm is drawn with in the normal state,
+// given the icon inherited from its containers.
+func placemarkIcon(idx *styleIndex, pm *Placemark, inherited string) string {
+	if pm.Style != nil && pm.Style.IconStyle != nil {
+		return iconHref(pm.Style)
+	}
+	if s := idx.resolveState(pm.StyleURL, "normal"); s != nil && s.IconStyle != nil {
+		return iconHref(s)
+	}
+	return inherited
+}
+
+// iconTally counts uses of icons, remembering the order they first appear.
+type iconTally struct {
+	order []string
+	count map[string]int
+}
+
+// add counts n uses of href.
+func (t *iconTally) add(href string, n int) {
+	if _, ok := t.count[href]; !ok {
+		t.order = append(t.order, href)
+	}
+	t.count[href] += n
+}
+
+// merge adds the counts of other.
+func (t *iconTally) merge(other *iconTally) {
+	for _, href := range other.order {
+		t.add(href, other.count[href])
+	}
+}
+
+// dominant returns the most used icon, or "" if there is none.
+func (t *iconTally) dominant() string {
+	best := ""
+	for _, href := range t.order {
+		if t.count[href] > t.count[best] {
+			best = href
+		}
+	}
+	return best
+}
@@ -0,0 +1,141 @@
+package kml
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestListStyleRoundTrip tests parsing and writing list styles
+func TestListStyleRoundTrip(t *testing.T) {
+	data := `<kml xmlns="http://www.opengis.net/kml/2.2"><Document>
+<Style id="radio"><ListStyle><listItemType>radioFolder</listItemType><bgColor>ff00ff00</bgColor>
+<ItemIcon><state>open</state><href>open.png</href></ItemIcon><ItemIcon><state>closed error</state><href>closed.png</href></ItemIcon>
+<maxSnippetLines>0</maxSnippetLines></ListStyle></Style>
+<Placemark><name>a</name></Placemark></Document></kml>`
+
+	k, err := ParseBytes([]byte(data))
+	if err != nil {
+		t.Fatalf("Failed to parse KML: %v", err)
+	}
+	zero := 0
+	want := &ListStyle{
+		ListItemType:    ListItemRadioFolder,
+		BgColor:         &Green,
+		ItemIcons:       []ItemIcon{{State: "open", Href: "open.png"}, {State: "closed error", Href: "closed.png"}},
+		MaxSnippetLines: &zero,
+	}
+	got := k.Feature.(*Document).Styles[0].ListStyle
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Expected %+v, got %+v", want, got)
+	}
+
+	out, err := k.Bytes()
+	if err != nil {
+		t.Fatalf("Failed to write KML: %v", err)
+	}
+	for _, s := range []string{"<listItemType>radioFolder</listItemType>", "<state>closed error</state>", "<maxSnippetLines>0</maxSnippetLines>"} {
+		if !strings.Contains(string(out), s) {
+			t.Errorf("Expected %s in output, got %s", s, out)
+		}
+	}
+
+	k.Feature.(*Document).Styles[0].ListStyle = &ListStyle{}
+	if out, _ := k.Bytes(); strings.Contains(string(out), "bgColor") || strings.Contains(string(out), "maxSnippetLines") {
+		t.Errorf("Expected unset fields to be omitted, got %s", out)
+	}
+}
+
+// folderIconsKML returns folders whose placemarks use shared, inline and
+// inherited icons
+func folderIconsKML() *KML {
+	pm := func(styleURL string, style *Style) *Placemark {
+		return &Placemark{StyleURL: styleURL, Style: style}
+	}
+	inline := &Style{IconStyle: &IconStyle{Icon: &Icon{Href: "bus.png"}}}
+
+	k := NewKML()
+	k.Feature = &Document{
+		Styles: []Style{
+			{ID: "rail", IconStyle: &IconStyle{Icon: &Icon{Href: "rail.png"}}},
+			{ID: "layer", LineStyle: &LineStyle{Width: 2}},
+			{ID: "ferry-layer", IconStyle: &IconStyle{Icon: &Icon{Href: "ferry.png"}}},
+			{ID: "manual", ListStyle: &ListStyle{ItemIcons: []ItemIcon{{Href: "mine.png"}}}},
+			{ID: "folder-icon-1"},
+		},
+		StyleMaps: []StyleMap{{ID: "map", Pairs: []Pair{{Key: "normal", StyleURL: "#rail"}}}},
+		Features: []Feature{
+			&Folder{Name: "Transit", Features: []Feature{
+				&Folder{Name: "Rail", Features: []Feature{pm("#rail", nil), pm("#rail", nil)}},
+				&Folder{Name: "Buses", StyleURL: "#layer", Features: []Feature{pm("", inline), pm("", inline), pm("#rail", nil)}},
+				&Folder{Name: "Ferries", StyleURL: "#ferry-layer", Features: []Feature{pm("", nil)}},
+			}},
+			&Folder{Name: "Manual", StyleURL: "#manual", Features: []Feature{pm("#rail", nil)}},
+			&Folder{Name: "Mapped", StyleURL: "#map", Features: []Feature{pm("#rail", nil)}},
+			&Folder{Name: "Empty", Features: []Feature{pm("", nil)}},
+		},
+	}
+	return k
+}
+
+// TestFolderIcons tests choosing and assigning folder list icons
+func TestFolderIcons(t *testing.T) {
+	k := folderIconsKML()
+	styles := k.FolderIcons(FolderIconOptions{})
+
+	urls := make(map[string]string)
+	k.Walk(func(f Feature) error {
+		if folder, ok := f.(*Folder); ok {
+			urls[folder.Name] = folder.StyleURL
+		}
+		return nil
+	})
+	wantURLs := map[string]string{
+		"Transit": "#folder-icon-2", // Three rail, two bus, one ferry
+		"Rail":    "#folder-icon-2",
+		"Buses":   "#folder-icon-3",
+		"Ferries": "#folder-icon-4",
+		"Manual":  "#manual",
+		"Mapped":  "#map",
+		"Empty":   "",
+	}
+	if !reflect.DeepEqual(urls, wantURLs) {
+		t.Errorf("Expected style URLs %v, got %v", wantURLs, urls)
+	}
+
+	byID := make(map[string]Style)
+	var ids []string
+	for _, s := range styles {
+		byID[s.ID] = s
+		ids = append(ids, s.ID)
+	}
+	if want := []string{"folder-icon-2", "folder-icon-3", "folder-icon-4"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("Expected styles %v, got %v", want, ids)
+	}
+	for id, href := range map[string]string{"folder-icon-2": "rail.png", "folder-icon-3": "bus.png", "folder-icon-4": "ferry.png"} {
+		if s := byID[id]; s.ListStyle == nil || !reflect.DeepEqual(s.ListStyle.ItemIcons, []ItemIcon{{Href: href}}) {
+			t.Errorf("Expected %s to show %s, got %+v", id, href, s.ListStyle)
+		}
+	}
+	if s := byID["folder-icon-3"]; s.LineStyle == nil || s.LineStyle.Width != 2 {
+		t.Errorf("Expected the copy to keep the folder's LineStyle, got %+v", s)
+	}
+	if s := byID["folder-icon-4"]; s.IconStyle == nil || s.IconStyle.Icon.Href != "ferry.png" {
+		t.Errorf("Expected the copy to keep the folder's IconStyle, got %+v", s)
+	}
+	if k.Feature.(*Document).Styles[1].ListStyle != nil {
+		t.Errorf("Expected the shared folder style to be unchanged")
+	}
+}
+
+// TestFolderIconsPrefix tests the style ID prefix
+func TestFolderIconsPrefix(t *testing.T) {
+	k := NewKML()
+	k.Feature = &Document{Features: []Feature{&Folder{Features: []Feature{
+		&Placemark{Style: &Style{IconStyle: &IconStyle{Icon: &Icon{Href: "a.png"}}}},
+	}}}}
+	styles := k.FolderIcons(FolderIconOptions{Prefix: "list-"})
+	if len(styles) != 1 || styles[0].ID != "list-1" {
+		t.Errorf("Expected one style list-1, got %+v", styles)
+	}
+}
@@ -0,0 +1,138 @@
+package kml
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Locale describes number and date conventions applied by output helpers
+// such as GenerateDescriptionTable and FromSlice name templates.
+type Locale struct {
+	// Precision is the number of digits after the decimal separator.
+	// A negative value uses the fewest digits that represent the value
+	// exactly.
+	Precision int
+
+	// DecimalSeparator separates the integer and fractional parts.
+	// It defaults to ".".
+	DecimalSeparator string
+
+	// ThousandsSeparator, if set, groups integer digits in threes.
+	ThousandsSeparator string
+
+	// TimeLayout is the time.Format layout for dates and times. If empty,
+	// time values are left as they are.
+	TimeLayout string
+
+	// Location, if set, converts times to this zone before formatting.
+	Location *time.Location
+}
+
+// Common locales.
+var (
+	LocaleEnUS = Locale{Precision: -1, DecimalSeparator: ".", ThousandsSeparator: ",", TimeLayout: "Jan 2, 2006 3:04 PM"}
+	LocaleEnGB = Locale{Precision: -1, DecimalSeparator: ".", ThousandsSeparator: ",", TimeLayout: "2 Jan 2006 15:04"}
+	LocaleDeDE = Locale{Precision: -1, DecimalSeparator: ",", ThousandsSeparator: ".", TimeLayout: "02.01.2006 15:04"}
+	LocaleFrFR = Locale{Precision: -1, DecimalSeparator: ",", ThousandsSeparator: " ", TimeLayout: "02/01/2006 15:04"}
+)
+
+// FormatNumber formats f using the locale's precision and separators.
+// NaN and infinities are formatted as by strconv.
+func (l Locale) FormatNumber(f float64) string {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+
+	s := strconv.FormatFloat(f, 'f', l.Precision, 64)
+
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	intPart, fracPart, hasFrac := strings.Cut(s, ".")
+
+	if l.ThousandsSeparator != "" && len(intPart) > 3 {
+		var b strings.Builder
+		lead := len(intPart) % 3
+		if lead > 0 {
+			b.WriteString(intPart[:lead])
+		}
+		for i := lead; i < len(intPart); i += 3 {
+			if b.Len() > 0 {
+				b.WriteString(l.ThousandsSeparator)
+			}
+			b.WriteString(intPart[i : i+3])
+		}
+		intPart = b.String()
+	}
+
+	if !hasFrac {
+		return sign + intPart
+	}
+	return sign + intPart + defaultIfEmpty(l.DecimalSeparator, ".") + fracPart
+}
+
+// FormatTime formats t using the locale's layout and location. Without a
+// TimeLayout, RFC 3339 is used.
+func (l Locale) FormatTime(t time.Time) string {
+	if l.Location != nil {
+		t = t.In(l.Location)
+	}
+	return t.Format(defaultIfEmpty(l.TimeLayout, time.RFC3339))
+}
+
+// FormatValue reformats a raw ExtendedData value that holds a number with
+// a fractional part, such as "1234.5", or an RFC 3339 / ISO 8601 date.
+// Integers and numbers written with leading zeros are returned unchanged,
+// since they are as often identifiers, ZIP codes or phone numbers as
+// quantities; format those known to be quantities with FormatNumber.
+// Other values, and dates when the locale has no TimeLayout, are returned
+// unchanged.
+func (l Locale) FormatValue(s string) string {
+	v := strings.TrimSpace(s)
+	if isDecimal(v) && strings.Contains(v, ".") && !hasLeadingZero(v) {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return l.FormatNumber(f)
+		}
+	}
+	if l.TimeLayout != "" {
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", time.DateOnly} {
+			if t, err := time.Parse(layout, v); err == nil {
+				return l.FormatTime(t)
+			}
+		}
+	}
+	return s
+}
+
+// formatNumberValue reformats a raw value holding a decimal number with
+// FormatNumber, returning other values unchanged.
+func (l Locale) formatNumberValue(s string) string {
+	v := strings.TrimSpace(s)
+	if isDecimal(v) {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return l.FormatNumber(f)
+		}
+	}
+	return s
+}
+
+// isDecimal reports whether s is a plain decimal number such as "-12.5",
+// excluding forms like "1e3", "0x1F", "Inf" and "NaN" that strconv accepts.
+func isDecimal(s string) bool {
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+")
+	digits, dot := 0, false
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			digits++
+		case r == '.' && !dot:
+			dot = true
+		default:
+			return false
+		}
+	}
+	return digits > 0
+}
@@ -0,0 +1,86 @@
+package kml
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestLocaleFormatNumber(t *testing.T) {
+	tests := []struct {
+		name   string
+		locale Locale
+		value  float64
+		want   string
+	}{
+		{"zero value rounds to integer", Locale{}, 1234.56, "1235"},
+		{"shortest", Locale{Precision: -1}, 1234.5, "1234.5"},
+		{"en-US", LocaleEnUS, 1234567.891, "1,234,567.891"},
+		{"de-DE", LocaleDeDE, 1234567.891, "1.234.567,891"},
+		{"fr-FR fixed", Locale{Precision: 2, DecimalSeparator: ",", ThousandsSeparator: " "}, 9876.5, "9 876,50"},
+		{"negative grouping", LocaleEnUS, -123456, "-123,456"},
+		{"three digits", LocaleEnUS, 999, "999"},
+		{"NaN", LocaleEnUS, math.NaN(), "NaN"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.locale.FormatNumber(tt.value); got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestLocaleFormatTime(t *testing.T) {
+	ts := time.Date(2024, 3, 5, 14, 30, 0, 0, time.UTC)
+
+	if got := LocaleDeDE.FormatTime(ts); got != "05.03.2024 14:30" {
+		t.Errorf("Expected '05.03.2024 14:30', got %q", got)
+	}
+	if got := (Locale{}).FormatTime(ts); got != "2024-03-05T14:30:00Z" {
+		t.Errorf("Expected RFC 3339 default, got %q", got)
+	}
+
+	tokyo := time.FixedZone("JST", 9*60*60)
+	l := Locale{TimeLayout: "2006-01-02 15:04 MST", Location: tokyo}
+	if got := l.FormatTime(ts); got != "2024-03-05 23:30 JST" {
+		t.Errorf("Expected '2024-03-05 23:30 JST', got %q", got)
+	}
+}
+
+func TestLocaleFormatValue(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"1234.5", "1,234.5"},
+		{" 42.0 ", "42"},
+		{"42", "42"},
+		{"12345", "12345"},
+		{"-1234567", "-1234567"},
+		{"007", "007"},
+		{"02134", "02134"},
+		{"007.5", "007.5"},
+		{"0.25", "0.25"},
+		{"2024-03-05T14:30:00Z", "Mar 5, 2024 2:30 PM"},
+		{"2024-03-05", "Mar 5, 2024 12:00 AM"},
+		{"1e6", "1e6"},
+		{"Inf", "Inf"},
+		{"0x1F", "0x1F"},
+		{"hello", "hello"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := LocaleEnUS.FormatValue(tt.value); got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+
+	if got := (Locale{Precision: -1}).FormatValue("2024-03-05"); got != "2024-03-05" {
+		t.Errorf("Expected dates unchanged without TimeLayout, got %q", got)
+	}
+}
@@ -0,0 +1,152 @@
+package kml
+
+import (
+	"sort"
+	"strings"
+)
+
+// Placemarks carry translations of their name and description as
+// ExtendedData named "name:<lang>" and "description:<lang>", where lang is
+// a language tag such as "fr" or "pt-BR", following the OpenStreetMap
+// convention. The variants are valid KML that viewers show as data, and
+// Localize turns a multilingual document into a single-language one for
+// publishing. Containers and overlays have no ExtendedData and keep their
+// one name and description.
+
+// SetLocalized stores the name and description of p in lang, replacing
+// any earlier variants in that language. An empty name or description
+// leaves that variant unset.
+func (p *Placemark) SetLocalized(lang, name, description string) {
+	for _, v := range []struct{ field, value string }{{"name", name}, {"description", description}} {
+		if p.ExtendedData != nil {
+			data := p.ExtendedData.Data[:0]
+			for _, d := range p.ExtendedData.Data {
+				if f, tag, ok := localizedKey(d.Name); !ok || f != v.field || !strings.EqualFold(tag, lang) {
+					data = append(data, d)
+				}
+			}
+			p.ExtendedData.Data = data
+		}
+		if v.value == "" {
+			continue
+		}
+		if p.ExtendedData == nil {
+			p.ExtendedData = &ExtendedData{}
+		}
+		p.ExtendedData.Data = append(p.ExtendedData.Data, Data{Name: v.field + ":" + lang, Value: v.value})
+	}
+}
+
+// Localized returns the name and description of p in lang, falling back
+// from a regional tag such as "pt-BR" to its language "pt", and then to
+// the untranslated Name and Description. Tags match ignoring case.
+func (p *Placemark) Localized(lang string) (name, description string) {
+	name, description = p.Name, p.Description
+	for _, tag := range languageFallbacks(lang) {
+		if v, ok := p.localizedValue("name", tag); ok {
+			name = v
+			break
+		}
+	}
+	for _, tag := range languageFallbacks(lang) {
+		if v, ok := p.localizedValue("description", tag); ok {
+			description = v
+			break
+		}
+	}
+	return name, description
+}
+
+// localizedValue returns the variant of field in exactly lang.
+func (p *Placemark) localizedValue(field, lang string) (string, bool) {
+	if p.ExtendedData == nil {
+		return "", false
+	}
+	for _, d := range p.ExtendedData.Data {
+		if f, tag, ok := localizedKey(d.Name); ok && f == field && strings.EqualFold(tag, lang) {
+			return d.Value, true
+		}
+	}
+	return "", false
+}
+
+// localizedKey splits an ExtendedData name of the form "name:<lang>" or
+// "description:<lang>".
+func localizedKey(key string) (field, lang string, ok bool) {
+	field, lang, ok = strings.Cut(key, ":")
+	if !ok || lang == "" || (field != "name" && field != "description") {
+		return "", "", false
+	}
+	return field, lang, true
+}
+
+// languageFallbacks returns lang and the shorter tags it falls back to,
+// longest first: "zh-Hant-TW", "zh-Hant", "zh".
+func languageFallbacks(lang string) []string {
+	var tags []string
+	for lang != "" {
+		tags = append(tags, lang)
+		i := strings.LastIndexAny(lang, "-_")
+		if i < 0 {
+			break
+		}
+		lang = lang[:i]
+	}
+	return tags
+}
+
+// Languages returns the language tags of the translated names and
+// descriptions in the document, sorted and without duplicates.
+func (k *KML) Languages() []string {
+	seen := make(map[string]bool)
+	for _, pm := range k.Placemarks() {
+		if pm.ExtendedData == nil {
+			continue
+		}
+		for _, d := range pm.ExtendedData.Data {
+			if _, lang, ok := localizedKey(d.Name); ok {
+				seen[lang] = true
+			}
+		}
+	}
+	langs := make([]string, 0, len(seen))
+	for lang := range seen {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// Localize makes the document single-language: each placemark takes its
+// name and description in lang, as returned by Localized, and loses all
+// its translations, along with its ExtendedData if nothing else is left in
+// it. An empty lang keeps the untranslated text. Localize returns the
+// number of placemarks whose name or description changed.
+func (k *KML) Localize(lang string) int {
+	changed := 0
+	for _, pm := range k.Placemarks() {
+		if pm.ExtendedData == nil {
+			continue
+		}
+		name, description := pm.Name, pm.Description
+		if lang != "" {
+			name, description = pm.Localized(lang)
+		}
+		if name != pm.Name || description != pm.Description {
+			pm.Name, pm.Description = name, description
+			changed++
+		}
+
+		data := pm.ExtendedData.Data[:0]
+		for _, d := range pm.ExtendedData.Data {
+			if _, _, ok := localizedKey(d.Name); !ok {
+				data = append(data, d)
+			}
+		}
+		pm.ExtendedData.Data = data
+		if len(data) == 0 && len(pm.ExtendedData.SchemaData) == 0 {
+			pm.ExtendedData = nil
+		}
+	}
+	return changed
+}
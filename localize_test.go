@@ -0,0 +1,107 @@
+package kml
+
+import (
+	"reflect"
+	"testing"
+)
+
+// multilingualKML returns placemarks with French and Brazilian Portuguese
+// variants
+func multilingualKML() *KML {
+	station := &Placemark{Name: "Station", Description: "Main station"}
+	station.SetLocalized("fr", "Gare", "Gare centrale")
+	station.SetLocalized("pt-BR", "Estação", "")
+	station.ExtendedData.Data = append(station.ExtendedData.Data, Data{Name: "platforms", Value: "12"})
+
+	museum := &Placemark{Name: "Museum"}
+	museum.SetLocalized("pt", "Museu", "")
+
+	k := NewKML()
+	k.Feature = &Document{Features: []Feature{
+		station,
+		&Folder{Name: "Culture", Features: []Feature{museum, &Placemark{Name: "Park"}}},
+	}}
+	return k
+}
+
+// TestLocalized tests choosing a placemark's variant with fallbacks
+func TestLocalized(t *testing.T) {
+	k := multilingualKML()
+	station := k.Feature.(*Document).Features[0].(*Placemark)
+	museum := k.Feature.(*Document).Features[1].(*Folder).Features[0].(*Placemark)
+
+	tests := []struct {
+		name     string
+		pm       *Placemark
+		lang     string
+		wantName string
+		wantDesc string
+	}{
+		{"exact", station, "fr", "Gare", "Gare centrale"},
+		{"region to language", station, "fr-CA", "Gare", "Gare centrale"},
+		{"ignoring case", station, "PT-br", "Estação", "Main station"},
+		{"language does not match region", station, "pt", "Station", "Main station"},
+		{"region falls back", museum, "pt-BR", "Museu", ""},
+		{"missing", station, "de", "Station", "Main station"},
+		{"empty", station, "", "Station", "Main station"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, desc := tt.pm.Localized(tt.lang)
+			if name != tt.wantName || desc != tt.wantDesc {
+				t.Errorf("Expected %q and %q, got %q and %q", tt.wantName, tt.wantDesc, name, desc)
+			}
+		})
+	}
+}
+
+// TestSetLocalized tests replacing and clearing variants
+func TestSetLocalized(t *testing.T) {
+	pm := &Placemark{Name: "Station"}
+	pm.SetLocalized("fr", "Gare", "Gare centrale")
+	pm.SetLocalized("FR", "Gare du Nord", "")
+	want := []Data{{Name: "name:FR", Value: "Gare du Nord"}}
+	if !reflect.DeepEqual(pm.ExtendedData.Data, want) {
+		t.Errorf("Expected %v, got %v", want, pm.ExtendedData.Data)
+	}
+}
+
+// TestLanguages tests listing the languages of a document
+func TestLanguages(t *testing.T) {
+	want := []string{"fr", "pt", "pt-BR"}
+	if got := multilingualKML().Languages(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+// TestLocalize tests producing a single-language document
+func TestLocalize(t *testing.T) {
+	k := multilingualKML()
+	if n := k.Localize("pt-BR"); n != 2 {
+		t.Errorf("Expected 2 placemarks changed, got %d", n)
+	}
+	if len(k.Languages()) != 0 {
+		t.Errorf("Expected no translations left, got %v", k.Languages())
+	}
+
+	pms := k.Placemarks()
+	var names []string
+	for _, pm := range pms {
+		names = append(names, pm.Name)
+	}
+	if want := []string{"Estação", "Museu", "Park"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Expected names %v, got %v", want, names)
+	}
+	if want := []Data{{Name: "platforms", Value: "12"}}; pms[0].ExtendedData == nil || !reflect.DeepEqual(pms[0].ExtendedData.Data, want) {
+		t.Errorf("Expected other data to be kept, got %+v", pms[0].ExtendedData)
+	}
+	if pms[1].ExtendedData != nil {
+		t.Errorf("Expected empty ExtendedData to be removed, got %+v", pms[1].ExtendedData)
+	}
+
+	k = multilingualKML()
+	if n := k.Localize(""); n != 0 || len(k.Languages()) != 0 {
+		t.Errorf("Expected translations stripped without changes, got %d changes and %v", n, k.Languages())
+	}
+}
@@ -0,0 +1,280 @@
+package kml
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+const (
+	geohashAlphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+	olcAlphabet     = "23456789CFGHJMPQRVWX"
+	olcSeparator    = '+'
+	olcPadding      = '0'
+	olcPairLength   = 10
+	olcMaxLength    = 15
+	olcLatPrecision = 8000 * 3125 // 1/8000° pair resolution refined by 5^5 grid rows
+	olcLonPrecision = 8000 * 1024 // and 4^5 grid columns
+)
+
+// Geohash encodes c as a geohash of the given number of characters,
+// clamped to 1–12.
+func (c Coordinate) Geohash(precision int) string {
+	precision = max(1, min(12, precision))
+	latLo, latHi := -90.0, 90.0
+	lonLo, lonHi := -180.0, 180.0
+
+	var sb strings.Builder
+	bits, ch := 0, 0
+	even := true
+	for sb.Len() < precision {
+		if even {
+			mid := (lonLo + lonHi) / 2
+			ch <<= 1
+			if c.Lon >= mid {
+				ch |= 1
+				lonLo = mid
+			} else {
+				lonHi = mid
+			}
+		} else {
+			mid := (latLo + latHi) / 2
+			ch <<= 1
+			if c.Lat >= mid {
+				ch |= 1
+				latLo = mid
+			} else {
+				latHi = mid
+			}
+		}
+		even = !even
+		if bits++; bits == 5 {
+			sb.WriteByte(geohashAlphabet[ch])
+			bits, ch = 0, 0
+		}
+	}
+	return sb.String()
+}
+
+// DecodeGeohash returns the center of the cell denoted by a geohash.
+func DecodeGeohash(s string) (Coordinate, error) {
+	if s == "" {
+		return Coordinate{}, fmt.Errorf("kml: empty geohash")
+	}
+	latLo, latHi := -90.0, 90.0
+	lonLo, lonHi := -180.0, 180.0
+	even := true
+	for _, r := range strings.ToLower(s) {
+		idx := strings.IndexRune(geohashAlphabet, r)
+		if idx < 0 {
+			return Coordinate{}, fmt.Errorf("kml: invalid geohash %q", s)
+		}
+		for bit := 4; bit >= 0; bit-- {
+			on := idx>>bit&1 == 1
+			if even {
+				mid := (lonLo + lonHi) / 2
+				if on {
+					lonLo = mid
+				} else {
+					lonHi = mid
+				}
+			} else {
+				mid := (latLo + latHi) / 2
+				if on {
+					latLo = mid
+				} else {
+					latHi = mid
+				}
+			}
+			even = !even
+		}
+	}
+	return Coordinate{Lon: (lonLo + lonHi) / 2, Lat: (latLo + latHi) / 2}, nil
+}
+
+// PlusCode encodes c as a full Open Location Code ("plus code") such as
+// "7FG49QCJ+2V". length is the number of code digits: values below 10 are
+// rounded up to an even number and padded, and values are clamped to
+// 2–15.
+func (c Coordinate) PlusCode(length int) string {
+	length = max(2, min(olcMaxLength, length))
+	if length < olcPairLength && length%2 == 1 {
+		length++
+	}
+
+	latVal := int64(math.Round((c.Lat + 90) * olcLatPrecision))
+	latVal = max(0, min(180*olcLatPrecision-1, latVal))
+	lonVal := int64(math.Round((c.Lon + 180) * olcLonPrecision))
+	lonVal %= 360 * olcLonPrecision
+	if lonVal < 0 {
+		lonVal += 360 * olcLonPrecision
+	}
+
+	digits := make([]byte, olcMaxLength)
+	for i := olcMaxLength - 1; i >= olcPairLength; i-- {
+		digits[i] = olcAlphabet[(latVal%5)*4+lonVal%4]
+		latVal /= 5
+		lonVal /= 4
+	}
+	for i := olcPairLength - 2; i >= 0; i -= 2 {
+		digits[i] = olcAlphabet[latVal%20]
+		digits[i+1] = olcAlphabet[lonVal%20]
+		latVal /= 20
+		lonVal /= 20
+	}
+
+	code := []byte(string(digits[:length]))
+	for len(code) < 8 {
+		code = append(code, olcPadding)
+	}
+	code = append(code[:8], append([]byte{olcSeparator}, code[8:]...)...)
+	return string(code)
+}
+
+// DecodePlusCode returns the center of the area denoted by a full Open
+// Location Code. Short codes, which need a reference location to recover,
+// are rejected.
+func DecodePlusCode(s string) (Coordinate, error) {
+	code := strings.ToUpper(s)
+	sep := strings.IndexByte(code, olcSeparator)
+	if sep != 8 || strings.Count(code, string(olcSeparator)) != 1 {
+		return Coordinate{}, fmt.Errorf("kml: invalid or short plus code %q", s)
+	}
+	digits := code[:sep] + code[sep+1:]
+	if pad := strings.IndexByte(digits, olcPadding); pad >= 0 {
+		if pad == 0 || pad%2 == 1 || strings.TrimRight(digits[pad:], string(olcPadding)) != "" {
+			return Coordinate{}, fmt.Errorf("kml: invalid plus code padding %q", s)
+		}
+		digits = digits[:pad]
+	}
+	if len(digits) < 2 || len(digits) > olcMaxLength {
+		return Coordinate{}, fmt.Errorf("kml: invalid plus code %q", s)
+	}
+
+	var lat, lon float64
+	latRes, lonRes := 400.0, 400.0
+	for i, r := range digits {
+		idx := strings.IndexRune(olcAlphabet, r)
+		if idx < 0 {
+			return Coordinate{}, fmt.Errorf("kml: invalid plus code %q", s)
+		}
+		switch {
+		case i < olcPairLength && i%2 == 0:
+			latRes /= 20
+			lat += float64(idx) * latRes
+		case i < olcPairLength:
+			lonRes /= 20
+			lon += float64(idx) * lonRes
+		default:
+			latRes /= 5
+			lonRes /= 4
+			lat += float64(idx/4) * latRes
+			lon += float64(idx%4) * lonRes
+		}
+	}
+	if len(digits)%2 == 1 && len(digits) < olcPairLength {
+		return Coordinate{}, fmt.Errorf("kml: invalid plus code %q", s)
+	}
+
+	return Coordinate{Lon: lon - 180 + lonRes/2, Lat: math.Min(lat-90+latRes/2, 90)}, nil
+}
+
+// maidenheadLevels holds the longitude and latitude extent in degrees and
+// the number of divisions of each Maidenhead locator pair.
+var maidenheadLevels = []struct {
+	lon, lat float64
+	base     int
+}{
+	{20, 10, 18},               // field, A-R
+	{2, 1, 10},                 // square, 0-9
+	{2.0 / 24, 1.0 / 24, 24},   // subsquare, a-x
+	{2.0 / 240, 1.0 / 240, 10}, // extended square, 0-9
+}
+
+// Maidenhead encodes c as a Maidenhead locator such as "FN31pr" with the
+// given number of character pairs, clamped to 1–4.
+func (c Coordinate) Maidenhead(pairs int) string {
+	pairs = max(1, min(len(maidenheadLevels), pairs))
+	lon := math.Mod(c.Lon+540, 360)
+	lat := max(0, min(180-1e-9, c.Lat+90))
+
+	var sb strings.Builder
+	for i, lvl := range maidenheadLevels[:pairs] {
+		x := min(lvl.base-1, int(lon/lvl.lon))
+		y := min(lvl.base-1, int(lat/lvl.lat))
+		lon -= float64(x) * lvl.lon
+		lat -= float64(y) * lvl.lat
+		switch lvl.base {
+		case 10:
+			sb.WriteByte(byte('0' + x))
+			sb.WriteByte(byte('0' + y))
+		default:
+			first := byte('A')
+			if i > 0 {
+				first = 'a'
+			}
+			sb.WriteByte(first + byte(x))
+			sb.WriteByte(first + byte(y))
+		}
+	}
+	return sb.String()
+}
+
+// DecodeMaidenhead returns the center of the square denoted by a
+// Maidenhead locator. Letters are accepted in either case.
+func DecodeMaidenhead(s string) (Coordinate, error) {
+	if len(s) == 0 || len(s)%2 == 1 || len(s) > 2*len(maidenheadLevels) {
+		return Coordinate{}, fmt.Errorf("kml: invalid Maidenhead locator %q", s)
+	}
+	locator := strings.ToUpper(s)
+
+	var lon, lat float64
+	var lvl int
+	for lvl = 0; lvl < len(locator)/2; lvl++ {
+		level := maidenheadLevels[lvl]
+		var x, y int
+		if level.base == 10 {
+			x, y = int(locator[2*lvl])-'0', int(locator[2*lvl+1])-'0'
+		} else {
+			x, y = int(locator[2*lvl])-'A', int(locator[2*lvl+1])-'A'
+		}
+		if x < 0 || x >= level.base || y < 0 || y >= level.base {
+			return Coordinate{}, fmt.Errorf("kml: invalid Maidenhead locator %q", s)
+		}
+		lon += float64(x) * level.lon
+		lat += float64(y) * level.lat
+	}
+	last := maidenheadLevels[lvl-1]
+	return Coordinate{Lon: lon - 180 + last.lon/2, Lat: lat - 90 + last.lat/2}, nil
+}
+
+// LocationCodes selects the codes written by AnnotateLocationCodes. Each
+// field is the code length passed to the matching encoder; zero skips
+// that code.
+type LocationCodes struct {
+	Geohash    int // characters, stored as "geohash"
+	PlusCode   int // digits, stored as "pluscode"
+	Maidenhead int // character pairs, stored as "maidenhead"
+}
+
+// AnnotateLocationCodes stamps every placemark with the selected location
+// codes of its anchor point, as ExtendedData fields, in a single pass. See
+// AnnotateGrid for how the anchor is chosen. It returns the number of
+// placemarks annotated.
+func (k *KML) AnnotateLocationCodes(codes LocationCodes) int {
+	if codes == (LocationCodes{}) {
+		return 0
+	}
+	return k.annotate(func(pm *Placemark, c Coordinate) bool {
+		if codes.Geohash > 0 {
+			setData(pm, "geohash", c.Geohash(codes.Geohash))
+		}
+		if codes.PlusCode > 0 {
+			setData(pm, "pluscode", c.PlusCode(codes.PlusCode))
+		}
+		if codes.Maidenhead > 0 {
+			setData(pm, "maidenhead", c.Maidenhead(codes.Maidenhead))
+		}
+		return true
+	})
+}
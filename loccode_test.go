@@ -0,0 +1,141 @@
+package kml
+
+import "testing"
+
+// TestGeohash tests geohash encoding and decoding
+func TestGeohash(t *testing.T) {
+	tests := []struct {
+		c         Coordinate
+		precision int
+		want      string
+	}{
+		{Coord(10.40744, 57.64911), 11, "u4pruydqqvj"},
+		{Coord(-5.6, 42.6), 5, "ezs42"},
+		{Coord(-5.6, 42.6), 0, "e"},
+		{Coord(-5.6, 42.6), 20, "ezs42e44yx96"},
+	}
+	for _, tt := range tests {
+		if got := tt.c.Geohash(tt.precision); got != tt.want {
+			t.Errorf("Geohash(%d) of %v: expected %q, got %q", tt.precision, tt.c, tt.want, got)
+		}
+	}
+
+	c, err := DecodeGeohash("EZS42")
+	if err != nil {
+		t.Fatalf("DecodeGeohash() error: %v", err)
+	}
+	if !floatNear(c.Lon, -5.60302734375, 1e-9) || !floatNear(c.Lat, 42.60498046875, 1e-9) {
+		t.Errorf("Expected cell center, got %v", c)
+	}
+
+	for _, s := range []string{"", "ezs4a", "u4pr o"} {
+		if _, err := DecodeGeohash(s); err == nil {
+			t.Errorf("Expected error for %q", s)
+		}
+	}
+}
+
+// TestPlusCode tests Open Location Code encoding and decoding
+func TestPlusCode(t *testing.T) {
+	tests := []struct {
+		c      Coordinate
+		length int
+		want   string
+	}{
+		{Coord(2.7821875, 20.3700625), 10, "7FG49QCJ+2V"},
+		{Coord(2.7821875, 20.3700625), 15, "7FG49QCJ+2VGCCCJ"},
+		{Coord(2.775, 20.375), 6, "7FG49Q00+"},
+		{Coord(2.775, 20.375), 5, "7FG49Q00+"},
+		{Coord(180, 0), 4, "62G20000+"},
+		{Coord(0, 90), 10, "CFX2X2X2+X2"},
+	}
+	for _, tt := range tests {
+		if got := tt.c.PlusCode(tt.length); got != tt.want {
+			t.Errorf("PlusCode(%d) of %v: expected %q, got %q", tt.length, tt.c, tt.want, got)
+		}
+	}
+
+	decoded := []struct {
+		code string
+		want Coordinate
+	}{
+		{"7FG49QCJ+2V", Coord(2.7821875, 20.3700625)},
+		{"7fg49q00+", Coord(2.775, 20.375)},
+		{"7FG49QCJ+2VGCCCJ", Coord(2.7821875, 20.3700625)},
+	}
+	for _, tt := range decoded {
+		c, err := DecodePlusCode(tt.code)
+		if err != nil {
+			t.Errorf("DecodePlusCode(%q) error: %v", tt.code, err)
+			continue
+		}
+		if !floatNear(c.Lon, tt.want.Lon, 1e-6) || !floatNear(c.Lat, tt.want.Lat, 1e-6) {
+			t.Errorf("DecodePlusCode(%q): expected %v, got %v", tt.code, tt.want, c)
+		}
+	}
+
+	for _, s := range []string{"", "9QCJ+2V", "7FG49QCJ2V", "7FG49Q0J+", "7FG4900+", "7FG49QCJ+2A", "7FG49QCJ+2V+"} {
+		if _, err := DecodePlusCode(s); err == nil {
+			t.Errorf("Expected error for %q", s)
+		}
+	}
+}
+
+// TestMaidenhead tests Maidenhead locator encoding and decoding
+func TestMaidenhead(t *testing.T) {
+	tests := []struct {
+		c     Coordinate
+		pairs int
+		want  string
+	}{
+		{Coord(11.60833, 48.14666), 3, "JN58td"},
+		{Coord(-72.727260, 41.714775), 4, "FN31pr21"},
+		{Coord(-72.727260, 41.714775), 0, "FN"},
+		{Coord(180, 90), 4, "AR09ax09"},
+	}
+	for _, tt := range tests {
+		if got := tt.c.Maidenhead(tt.pairs); got != tt.want {
+			t.Errorf("Maidenhead(%d) of %v: expected %q, got %q", tt.pairs, tt.c, tt.want, got)
+		}
+	}
+
+	c, err := DecodeMaidenhead("fn31PR")
+	if err != nil {
+		t.Fatalf("DecodeMaidenhead() error: %v", err)
+	}
+	if !floatNear(c.Lon, -72.708333, 1e-5) || !floatNear(c.Lat, 41.729167, 1e-5) {
+		t.Errorf("Expected subsquare center, got %v", c)
+	}
+
+	for _, s := range []string{"", "F", "SN31", "FNA1", "FN31pz", "FN31pr21xx"} {
+		if _, err := DecodeMaidenhead(s); err == nil {
+			t.Errorf("Expected error for %q", s)
+		}
+	}
+}
+
+// TestAnnotateLocationCodes tests the bulk location code pass
+func TestAnnotateLocationCodes(t *testing.T) {
+	pm := pointPlacemark("w1aw", -72.727260, 41.714775)
+	k := NewKML()
+	k.Feature = &Document{Features: []Feature{pm, &Placemark{Name: "no geometry"}}}
+
+	if n := k.AnnotateLocationCodes(LocationCodes{}); n != 0 || pm.ExtendedData != nil {
+		t.Errorf("Expected no annotation without codes, got %d", n)
+	}
+
+	if n := k.AnnotateLocationCodes(LocationCodes{Geohash: 7, PlusCode: 10, Maidenhead: 3}); n != 1 {
+		t.Errorf("Expected 1 annotated placemark, got %d", n)
+	}
+
+	want := map[string]string{
+		"geohash":    Coord(-72.727260, 41.714775).Geohash(7),
+		"pluscode":   Coord(-72.727260, 41.714775).PlusCode(10),
+		"maidenhead": "FN31pr",
+	}
+	for name, value := range want {
+		if got, ok := pm.dataValue(name); !ok || got != value {
+			t.Errorf("Expected %s=%q, got %q", name, value, got)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package kml
+
+import "encoding/xml"
+
+// Logger receives warnings about data the parser did not keep as written.
+// *slog.Logger satisfies it, so a document can be parsed with
+//
+//	kml.Parse(r, kml.WithLogger(slog.Default()))
+//
+// Arguments are alternating keys and values, as for slog.
+type Logger interface {
+	Warn(msg string, args ...any)
+}
+
+// WithLogger makes parsing report to l each element it skipped because the
+// package does not support it, each value it coerced, such as a visibility
+// of 2 read as true, and, when collecting errors with CollectErrors, each
+// invalid value it dropped. Every warning carries the line where the
+// decoder was when it was found.
+func WithLogger(l Logger) ParseOption {
+	return func(c *parseConfig) {
+		c.logger = l
+	}
+}
+
+// warn reports a warning about element to the logger of d, if any.
+func warn(d *xml.Decoder, msg, element string, args ...any) {
+	cfg := parseConfigFor(d)
+	if cfg == nil || cfg.logger == nil {
+		return
+	}
+	line, _ := d.InputPos()
+	cfg.logger.Warn(msg, append([]any{"element", element, "line", line}, args...)...)
+}
+
+// skipElement skips the unsupported element start, reporting it to the
+// logger of d.
+func skipElement(d *xml.Decoder, start xml.StartElement) error {
+	warn(d, "kml: skipped unsupported element", start.Name.Local)
+	return d.Skip()
+}
+
+// flagValue converts the value of a KML boolean element, which should be 0
+// or 1. Any other value is read as true and reported to the logger of d.
+func flagValue(d *xml.Decoder, start xml.StartElement, v int) bool {
+	if v != 0 && v != 1 {
+		warn(d, "kml: coerced boolean value", start.Name.Local, "value", v)
+	}
+	return v != 0
+}
@@ -0,0 +1,71 @@
+package kml
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// recordingLogger records each warning as its message and arguments
+type recordingLogger struct {
+	warnings []string
+}
+
+func (l *recordingLogger) Warn(msg string, args ...any) {
+	l.warnings = append(l.warnings, strings.TrimSpace(fmt.Sprintln(append([]any{msg}, args...)...)))
+}
+
+// TestWithLogger tests warnings about skipped elements and coerced values
+func TestWithLogger(t *testing.T) {
+	data := `<kml><Document>
+  <Placemark>
+    <Snippet>ignored</Snippet>
+    <visibility>2</visibility>
+    <Point><coordinates>1,2</coordinates></Point>
+  </Placemark>
+  <Placemark>
+    <Style><LineStyle><color>zz</color></LineStyle></Style>
+  </Placemark>
+</Document></kml>`
+
+	logger := &recordingLogger{}
+	var errs []error
+	k, err := ParseBytes([]byte(data), WithLogger(logger), CollectErrors(&errs))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if pm := k.Placemarks()[0]; pm.Visibility == nil || !*pm.Visibility {
+		t.Error("Expected visibility 2 to be read as true")
+	}
+
+	want := []string{
+		"kml: skipped unsupported element element Snippet line 3",
+		"kml: coerced boolean value element visibility line 4 value 2",
+		"kml: dropped invalid color line 8",
+	}
+	if len(logger.warnings) != len(want) {
+		t.Fatalf("Expected %d warnings, got %d: %q", len(want), len(logger.warnings), logger.warnings)
+	}
+	for i, w := range want {
+		if !strings.HasPrefix(logger.warnings[i], w) {
+			t.Errorf("Expected warning %q, got %q", w, logger.warnings[i])
+		}
+	}
+}
+
+// TestWithLoggerDropped tests warnings about values dropped while collecting errors
+func TestWithLoggerDropped(t *testing.T) {
+	data := `<kml><Placemark><LineString><coordinates>0,0 bad 1,1</coordinates></LineString></Placemark></kml>`
+
+	var buf bytes.Buffer
+	var errs []error
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	if _, err := ParseBytes([]byte(data), WithLogger(logger), CollectErrors(&errs)); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), `msg="kml: dropped invalid coordinates"`) {
+		t.Errorf("Expected dropped coordinates warning, got %q", buf.String())
+	}
+}
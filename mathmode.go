@@ -0,0 +1,183 @@
+package kml
+
+import (
+	"math"
+	"strconv"
+	"sync/atomic"
+)
+
+// MathMode selects the formulas distances, lengths, areas and circles are
+// computed with, trading accuracy for speed.
+type MathMode int
+
+const (
+	// MathSpherical treats the Earth as a sphere of its mean radius, using
+	// great-circle distances, which are within 0.5% of the ellipsoid's.
+	MathSpherical MathMode = iota
+	// MathGeodesic follows the WGS84 ellipsoid for distances and circles,
+	// with Vincenty's formulas accurate to a millimeter. Areas are taken on
+	// the sphere with the surface area of the ellipsoid, which is exact for
+	// the globe as a whole and within 0.5% for smaller regions.
+	MathGeodesic
+	// MathPlanar projects coordinates onto a plane with an equirectangular
+	// projection centered on the latitude of the points measured. It is
+	// several times faster than MathSpherical and within 0.1% of it for
+	// distances of up to a few tens of kilometers away from the poles, but
+	// grows worse with distance.
+	MathPlanar
+)
+
+// authalicRadius is the radius in meters of the sphere with the surface
+// area of the WGS84 ellipsoid.
+const authalicRadius = 6371007.2
+
+// mathMode holds the mode set with SetMathMode.
+var mathMode atomic.Int32
+
+// SetMathMode sets the mode used by the functions that measure distances
+// without naming a formula: ClusterPoints, DistanceMatrix, NearestMatches,
+// NearestPlacemark, NearestPointOnLine and LengthOf. The default is
+// MathSpherical. Coordinate.DistanceTo and Circle always follow the WGS84
+// ellipsoid; a MathMode's own methods measure in that mode whatever is
+// set. SetMathMode is safe to call concurrently with measurements, which
+// see either mode.
+func SetMathMode(m MathMode) {
+	mathMode.Store(int32(m))
+}
+
+// CurrentMathMode returns the mode set with SetMathMode.
+func CurrentMathMode() MathMode {
+	return MathMode(mathMode.Load())
+}
+
+// String returns the name of the mode.
+func (m MathMode) String() string {
+	switch m {
+	case MathSpherical:
+		return "spherical"
+	case MathGeodesic:
+		return "geodesic"
+	case MathPlanar:
+		return "planar"
+	}
+	return "MathMode(" + strconv.Itoa(int(m)) + ")"
+}
+
+// distance returns the distance in meters between a and b in the mode set
+// with SetMathMode.
+func distance(a, b Coordinate) float64 {
+	return CurrentMathMode().Distance(a, b)
+}
+
+// Distance returns the distance in meters between a and b. Altitude is
+// ignored.
+func (m MathMode) Distance(a, b Coordinate) float64 {
+	switch m {
+	case MathGeodesic:
+		return a.DistanceTo(b)
+	case MathPlanar:
+		x := toRadians(wrapLongitude(b.Lon-a.Lon)) * math.Cos(toRadians(a.Lat+b.Lat)/2)
+		y := toRadians(b.Lat - a.Lat)
+		return earthRadius * math.Hypot(x, y)
+	}
+	return haversine(a, b)
+}
+
+// Length returns the length in meters of the path through coords.
+func (m MathMode) Length(coords []Coordinate) float64 {
+	if len(coords) < 2 {
+		return 0
+	}
+	if m == MathSpherical {
+		return lengthOf(coords)
+	}
+	total := 0.0
+	for i := 1; i < len(coords); i++ {
+		total += m.Distance(coords[i-1], coords[i])
+	}
+	return total
+}
+
+// Area returns the area in square meters of p: that of its outer boundary
+// less those of its holes. Rings may run in either direction.
+func (m MathMode) Area(p *Polygon) float64 {
+	area := m.ringArea(p.OuterBoundary.Coordinates)
+	for _, hole := range p.InnerBoundaries {
+		area -= m.ringArea(hole.Coordinates)
+	}
+	return math.Max(area, 0)
+}
+
+// ringArea returns the unsigned area in square meters enclosed by ring.
+func (m MathMode) ringArea(ring []Coordinate) float64 {
+	if len(ring) < 3 {
+		return 0
+	}
+	sum := 0.0
+	if m == MathPlanar {
+		lat0 := 0.0
+		for _, c := range ring {
+			lat0 += c.Lat
+		}
+		scale := math.Cos(toRadians(lat0 / float64(len(ring))))
+		for i := range ring {
+			a, b := ring[i], ring[(i+1)%len(ring)]
+			dx := wrapLongitude(b.Lon-ring[0].Lon)*scale + wrapLongitude(a.Lon-ring[0].Lon)*scale
+			sum += dx * (b.Lat - a.Lat) / 2
+		}
+		r := toRadians(earthRadius)
+		return math.Abs(sum) * r * r
+	}
+
+	// Spherical excess of the ring, as by the formula of Chamberlain and
+	// Duquette.
+	radius := earthRadius
+	if m == MathGeodesic {
+		radius = authalicRadius
+	}
+	for i := range ring {
+		a, b := ring[i], ring[(i+1)%len(ring)]
+		sum += toRadians(wrapLongitude(b.Lon-a.Lon)) * (2 + math.Sin(toRadians(a.Lat)) + math.Sin(toRadians(b.Lat)))
+	}
+	return math.Abs(sum) * radius * radius / 2
+}
+
+// Circle returns a polygon approximating the circle of radius meters around
+// center, as the package-level Circle does, with its vertices placed in
+// mode m.
+func (m MathMode) Circle(center Coordinate, radius float64, segments int) *Polygon {
+	segments = max(segments, 3)
+	ring := make([]Coordinate, segments+1)
+	for i := range segments {
+		bearing := 360 * float64(i) / float64(segments)
+		switch m {
+		case MathGeodesic:
+			ring[i] = center.Destination(bearing, radius)
+		case MathPlanar:
+			theta := toRadians(bearing)
+			lat := center.Lat + toDegrees(radius*math.Cos(theta)/earthRadius)
+			lon := center.Lon + toDegrees(radius*math.Sin(theta)/(earthRadius*math.Cos(toRadians(center.Lat))))
+			ring[i] = Coordinate{Lon: lon, Lat: lat, Alt: center.Alt}
+		default:
+			ring[i] = sphericalDestination(center, bearing, radius)
+		}
+	}
+	ring[segments] = ring[0]
+	return &Polygon{OuterBoundary: LinearRing{Coordinates: ring}}
+}
+
+// sphericalDestination returns the point reached by travelling meters along
+// a great circle from c with the given initial bearing in degrees. The
+// altitude of c is kept.
+func sphericalDestination(c Coordinate, bearing, meters float64) Coordinate {
+	lat1, lon1 := toRadians(c.Lat), toRadians(c.Lon)
+	theta, delta := toRadians(bearing), meters/earthRadius
+	lat2 := math.Asin(math.Sin(lat1)*math.Cos(delta) + math.Cos(lat1)*math.Sin(delta)*math.Cos(theta))
+	lon2 := lon1 + math.Atan2(math.Sin(theta)*math.Sin(delta)*math.Cos(lat1), math.Cos(delta)-math.Sin(lat1)*math.Sin(lat2))
+	return Coordinate{Lon: wrapLongitude(toDegrees(lon2)), Lat: toDegrees(lat2), Alt: c.Alt}
+}
+
+// wrapLongitude returns lon in degrees normalized to [-180, 180).
+func wrapLongitude(lon float64) float64 {
+	return math.Mod(lon+540, 360) - 180
+}
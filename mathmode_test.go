@@ -0,0 +1,77 @@
+package kml
+
+import (
+	"math"
+	"testing"
+)
+
+// TestMathModeDistance tests distances in each mode
+func TestMathModeDistance(t *testing.T) {
+	tests := []struct {
+		mode MathMode
+		a, b Coordinate
+		want float64
+	}{
+		{MathSpherical, Coord(0, 0), Coord(0, 1), 111195},
+		{MathGeodesic, Coord(0, 0), Coord(0, 1), 110574},
+		{MathPlanar, Coord(0, 0), Coord(0, 1), 111195},
+		{MathPlanar, Coord(179.9, 60), Coord(-179.9, 60), 11119.5},
+		{MathSpherical, Coord(-0.1278, 51.5074), Coord(2.3522, 48.8566), 343556},
+		{MathPlanar, Coord(-0.1278, 51.5074), Coord(2.3522, 48.8566), 343556},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode.String(), func(t *testing.T) {
+			if got := tt.mode.Distance(tt.a, tt.b); math.Abs(got-tt.want) > tt.want*0.001 {
+				t.Errorf("Expected about %g m, got %g", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestMathModeArea tests areas and circles in each mode
+func TestMathModeArea(t *testing.T) {
+	square := &Polygon{
+		OuterBoundary:   LinearRing{Coordinates: []Coordinate{Coord(0, 0), Coord(1, 0), Coord(1, 1), Coord(0, 1), Coord(0, 0)}},
+		InnerBoundaries: []LinearRing{{Coordinates: []Coordinate{Coord(0.25, 0.25), Coord(0.25, 0.75), Coord(0.75, 0.75), Coord(0.75, 0.25), Coord(0.25, 0.25)}}},
+	}
+	// A degree square at the equator less a hole a quarter of its size
+	want := 0.75 * 1.2364e10
+
+	for _, mode := range []MathMode{MathSpherical, MathGeodesic, MathPlanar} {
+		t.Run(mode.String(), func(t *testing.T) {
+			if got := mode.Area(square); math.Abs(got-want) > want*0.005 {
+				t.Errorf("Expected about %g m², got %g", want, got)
+			}
+
+			circle := mode.Circle(Coord(10, 45), 1000, 256)
+			if got, want := mode.Area(circle), math.Pi*1000*1000; math.Abs(got-want) > want*0.005 {
+				t.Errorf("Expected a circle of about %g m², got %g", want, got)
+			}
+			ring := circle.OuterBoundary.Coordinates
+			for _, c := range ring[:4] {
+				if d := mode.Distance(Coord(10, 45), c); math.Abs(d-1000) > 1 {
+					t.Errorf("Expected vertices 1000 m from the center, got %g", d)
+				}
+			}
+		})
+	}
+}
+
+// TestSetMathMode tests the package-level mode
+func TestSetMathMode(t *testing.T) {
+	defer SetMathMode(CurrentMathMode())
+
+	path := []Coordinate{Coord(0, 0), Coord(0, 1), Coord(1, 1)}
+	spherical := LengthOf(path)
+	SetMathMode(MathGeodesic)
+	if got := LengthOf(path); got == spherical || math.Abs(got-MathGeodesic.Length(path)) > 1e-9 {
+		t.Errorf("Expected the geodesic length %g, got %g", MathGeodesic.Length(path), got)
+	}
+
+	a := &Placemark{Geometry: &Point{Coordinates: Coord(0, 0)}}
+	b := &Placemark{Geometry: &Point{Coordinates: Coord(0, 1)}}
+	if got := DistanceMatrix([]*Placemark{a}, []*Placemark{b})[0][0]; got != MathGeodesic.Distance(Coord(0, 0), Coord(0, 1)) {
+		t.Errorf("Expected the geodesic distance, got %g", got)
+	}
+}
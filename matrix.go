@@ -0,0 +1,115 @@
+package kml
+
+import (
+	"math"
+	"sort"
+)
+
+// DistanceMatrix returns the distance in meters between every source and
+// target placemark, great-circle unless changed with SetMathMode:
+// result[i][j] is the distance from sources[i] to targets[j]. Entries
+// involving a placemark without a Point geometry are NaN.
+func DistanceMatrix(sources, targets []*Placemark) [][]float64 {
+	tpts := pointsOf(targets)
+
+	matrix := make([][]float64, len(sources))
+	for i, src := range sources {
+		row := make([]float64, len(targets))
+		sp, ok := pointOf(src)
+		for j := range targets {
+			if !ok || tpts[j] == nil {
+				row[j] = math.NaN()
+				continue
+			}
+			row[j] = distance(sp, *tpts[j])
+		}
+		matrix[i] = row
+	}
+	return matrix
+}
+
+// MatchOptions limits the results of NearestMatches.
+type MatchOptions struct {
+	// MaxDistance, if positive, drops targets farther than this many meters.
+	MaxDistance float64
+
+	// K, if positive, keeps only the K nearest targets of each source.
+	K int
+}
+
+// Match pairs a source placemark with a target and the distance between
+// them in meters.
+type Match struct {
+	Source      *Placemark
+	Target      *Placemark
+	SourceIndex int
+	TargetIndex int
+	Distance    float64
+}
+
+// NearestMatches returns, for each source placemark, the target placemarks
+// ordered from nearest to farthest, filtered by opts. result[i] holds the
+// matches of sources[i]; it is empty if sources[i] has no Point geometry.
+// Targets without a Point geometry are ignored. Ties keep target order.
+func NearestMatches(sources, targets []*Placemark, opts MatchOptions) [][]Match {
+	tpts := pointsOf(targets)
+
+	result := make([][]Match, len(sources))
+	for i, src := range sources {
+		sp, ok := pointOf(src)
+		if !ok {
+			continue
+		}
+
+		var matches []Match
+		for j, tp := range tpts {
+			if tp == nil {
+				continue
+			}
+			dist := distance(sp, *tp)
+			if opts.MaxDistance > 0 && dist > opts.MaxDistance {
+				continue
+			}
+			matches = append(matches, Match{
+				Source:      src,
+				Target:      targets[j],
+				SourceIndex: i,
+				TargetIndex: j,
+				Distance:    dist,
+			})
+		}
+
+		sort.SliceStable(matches, func(a, b int) bool {
+			return matches[a].Distance < matches[b].Distance
+		})
+		if opts.K > 0 && len(matches) > opts.K {
+			matches = matches[:opts.K]
+		}
+		result[i] = matches
+	}
+	return result
+}
+
+// pointOf returns the coordinate of a placemark's Point geometry.
+func pointOf(pm *Placemark) (Coordinate, bool) {
+	if pm == nil {
+		return Coordinate{}, false
+	}
+	pt, ok := pm.Geometry.(*Point)
+	if !ok {
+		return Coordinate{}, false
+	}
+	return pt.Coordinates, true
+}
+
+// pointsOf returns the Point coordinates of pms, with nil entries for
+// placemarks without a Point geometry.
+func pointsOf(pms []*Placemark) []*Coordinate {
+	pts := make([]*Coordinate, len(pms))
+	for i, pm := range pms {
+		if c, ok := pointOf(pm); ok {
+			pts[i] = &c
+		}
+	}
+	return pts
+}
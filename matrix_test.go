@@ -0,0 +1,83 @@
+package kml
+
+import (
+	"math"
+	"testing"
+)
+
+func pointPlacemark(name string, lon, lat float64) *Placemark {
+	return &Placemark{Name: name, Geometry: &Point{Coordinates: Coord(lon, lat)}}
+}
+
+func TestDistanceMatrix(t *testing.T) {
+	sources := []*Placemark{
+		pointPlacemark("s0", 0, 0),
+		{Name: "no geometry"},
+	}
+	targets := []*Placemark{
+		pointPlacemark("t0", 0, 1),
+		pointPlacemark("t1", 0, 0),
+		{Name: "line", Geometry: &LineString{}},
+	}
+
+	m := DistanceMatrix(sources, targets)
+	if len(m) != 2 || len(m[0]) != 3 {
+		t.Fatalf("Expected 2x3 matrix, got %dx%d", len(m), len(m[0]))
+	}
+	if !floatNear(m[0][0], 111195, 10) {
+		t.Errorf("Expected ~111195m for one degree of latitude, got %.0f", m[0][0])
+	}
+	if m[0][1] != 0 {
+		t.Errorf("Expected 0 for identical points, got %v", m[0][1])
+	}
+	if !math.IsNaN(m[0][2]) || !math.IsNaN(m[1][0]) {
+		t.Error("Expected NaN for entries without point geometry")
+	}
+}
+
+func TestNearestMatches(t *testing.T) {
+	sources := []*Placemark{pointPlacemark("obs", 0, 0), {Name: "no geometry"}}
+	targets := []*Placemark{
+		pointPlacemark("far", 0, 2),
+		pointPlacemark("near", 0, 0.1),
+		pointPlacemark("mid", 0, 1),
+		{Name: "unlocated"},
+	}
+
+	tests := []struct {
+		name  string
+		opts  MatchOptions
+		names []string
+	}{
+		{"all sorted", MatchOptions{}, []string{"near", "mid", "far"}},
+		{"k nearest", MatchOptions{K: 2}, []string{"near", "mid"}},
+		{"cutoff", MatchOptions{MaxDistance: 150000}, []string{"near", "mid"}},
+		{"cutoff and k", MatchOptions{MaxDistance: 150000, K: 1}, []string{"near"}},
+		{"nothing in range", MatchOptions{MaxDistance: 100}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := NearestMatches(sources, targets, tt.opts)
+			if len(result) != 2 {
+				t.Fatalf("Expected 2 rows, got %d", len(result))
+			}
+			if len(result[1]) != 0 {
+				t.Errorf("Expected no matches for source without geometry, got %d", len(result[1]))
+			}
+
+			row := result[0]
+			if len(row) != len(tt.names) {
+				t.Fatalf("Expected %d matches, got %d", len(tt.names), len(row))
+			}
+			for i, m := range row {
+				if m.Target.Name != tt.names[i] {
+					t.Errorf("Match %d: expected %q, got %q", i, tt.names[i], m.Target.Name)
+				}
+				if m.Source != sources[0] || m.SourceIndex != 0 || targets[m.TargetIndex] != m.Target {
+					t.Errorf("Match %d: inconsistent source/target indices", i)
+				}
+			}
+		})
+	}
+}
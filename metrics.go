@@ -0,0 +1,44 @@
+package kml
+
+import (
+	"io"
+	"time"
+)
+
+// Metrics receives a summary of each parse, for services that export
+// ingestion metrics. An adapter for a metrics library such as Prometheus
+// typically observes Duration and Bytes in histograms and adds Features
+// and Invalid to counters labelled by element.
+type Metrics interface {
+	ObserveParse(ParseStats)
+}
+
+// ParseStats summarizes one call to Parse, ParseBytes or ParseFile.
+type ParseStats struct {
+	Duration time.Duration
+	Bytes    int64          // Input read, before any decompression
+	Features map[string]int // Features parsed, by element name
+	Invalid  map[string]int // Invalid values found, by problem, such as "invalid coordinates"
+	Err      error          // Error returned by the parse, or nil
+}
+
+// WithMetrics makes parsing report its ParseStats to m once it completes,
+// successfully or not. Invalid values are counted whether they fail the
+// parse or, with CollectErrors, are dropped.
+func WithMetrics(m Metrics) ParseOption {
+	return func(c *parseConfig) {
+		c.metrics = m
+	}
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,62 @@
+package kml
+
+import (
+	"errors"
+	"testing"
+)
+
+// metricsRecorder records the stats of each parse
+type metricsRecorder struct {
+	stats []ParseStats
+}
+
+func (m *metricsRecorder) ObserveParse(s ParseStats) {
+	m.stats = append(m.stats, s)
+}
+
+// TestWithMetrics tests the stats reported for a successful parse
+func TestWithMetrics(t *testing.T) {
+	data := `<kml><Document>
+  <Placemark><LineString><coordinates>0,0 bad 1,1</coordinates></LineString></Placemark>
+  <Folder><Placemark/><Placemark/></Folder>
+  <NetworkLink/>
+</Document></kml>`
+
+	m := &metricsRecorder{}
+	var errs []error
+	if _, err := ParseBytes([]byte(data), WithMetrics(m), CollectErrors(&errs)); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(m.stats) != 1 {
+		t.Fatalf("Expected one observation, got %d", len(m.stats))
+	}
+
+	s := m.stats[0]
+	if s.Bytes != int64(len(data)) {
+		t.Errorf("Expected %d bytes, got %d", len(data), s.Bytes)
+	}
+	want := map[string]int{"Document": 1, "Folder": 1, "Placemark": 3, "NetworkLink": 1}
+	for name, n := range want {
+		if s.Features[name] != n {
+			t.Errorf("Expected %d %s features, got %d", n, name, s.Features[name])
+		}
+	}
+	if s.Invalid["invalid coordinates"] != 1 {
+		t.Errorf("Expected one invalid coordinates value, got %v", s.Invalid)
+	}
+	if s.Err != nil || s.Duration <= 0 {
+		t.Errorf("Expected no error and a positive duration, got %v and %v", s.Err, s.Duration)
+	}
+}
+
+// TestWithMetricsError tests the stats reported for a failed parse
+func TestWithMetricsError(t *testing.T) {
+	m := &metricsRecorder{}
+	_, err := ParseBytes([]byte(`<kml></kml>`), WithMetrics(m))
+	if !errors.Is(err, ErrEmptyDocument) {
+		t.Fatalf("Expected ErrEmptyDocument, got %v", err)
+	}
+	if len(m.stats) != 1 || !errors.Is(m.stats[0].Err, ErrEmptyDocument) {
+		t.Errorf("Expected the error to be observed, got %+v", m.stats)
+	}
+}
@@ -0,0 +1,137 @@
+package kml
+
+import (
+	"math"
+	"sync"
+)
+
+// MonitorOptions configures NewMonitor.
+type MonitorOptions struct {
+	// Radius is the distance in meters within which a position is near a
+	// Point placemark. The default is 100.
+	Radius float64
+
+	// RadiusField is the ExtendedData name of a per-placemark radius in
+	// meters, which overrides Radius. The default is "radius".
+	RadiusField string
+}
+
+// defaults returns o with zero fields set to their defaults.
+func (o MonitorOptions) defaults() MonitorOptions {
+	if o.Radius <= 0 {
+		o.Radius = 100
+	}
+	if o.RadiusField == "" {
+		o.RadiusField = "radius"
+	}
+	return o
+}
+
+// Alert is a change in a subject's relation to a region of a Monitor:
+// EventEnter for entering a polygon or coming within the radius of a
+// point, EventExit for the reverse.
+type Alert struct {
+	Event
+	Subject  string  // The subject passed to Update
+	Distance float64 // Meters from a point region; zero for polygons
+}
+
+// Monitor turns a stream of positions of moving subjects, such as vehicles
+// reporting over MQTT, into alerts against the regions of a document: its
+// polygon placemarks, as for Geofence, and its named Point placemarks,
+// each the circle within a radius of it. A Monitor may be updated from
+// several goroutines.
+type Monitor struct {
+	fence  *Geofence
+	points []monitorPoint
+
+	mu   sync.Mutex
+	last map[string]Coordinate
+}
+
+// monitorPoint is a Point placemark watched within a radius.
+type monitorPoint struct {
+	name      string
+	placemark *Placemark
+	at        Coordinate
+	radius    float64
+}
+
+// NewMonitor builds a monitor for the regions of k.
+func NewMonitor(k *KML, opts MonitorOptions) *Monitor {
+	opts = opts.defaults()
+	radius := NumericData(opts.RadiusField)
+	m := &Monitor{fence: NewGeofence(k), last: make(map[string]Coordinate)}
+	for _, pm := range k.Placemarks() {
+		pt, ok := pm.Geometry.(*Point)
+		if !ok {
+			continue
+		}
+		p := monitorPoint{name: pm.Name, placemark: pm, at: pt.Coordinates, radius: opts.Radius}
+		if p.name == "" {
+			p.name = pm.ID
+		}
+		if p.name == "" {
+			continue
+		}
+		if r := radius(pm); r > 0 && !math.IsInf(r, 0) {
+			p.radius = r
+		}
+		m.points = append(m.points, p)
+	}
+	return m
+}
+
+// Len returns the number of regions the monitor watches.
+func (m *Monitor) Len() int {
+	return m.fence.Len() + len(m.points)
+}
+
+// Update records the position of subject and returns the alerts since its
+// last position: polygon regions first, then point regions, each in
+// document order. The first position of a subject alerts for every region
+// it is in.
+func (m *Monitor) Update(subject string, c Coordinate) []Alert {
+	m.mu.Lock()
+	prev, seen := m.last[subject]
+	m.last[subject] = c
+	m.mu.Unlock()
+
+	var alerts []Alert
+	if seen {
+		for _, ev := range m.fence.Evaluate(prev, c) {
+			if ev.Type != EventInside {
+				alerts = append(alerts, Alert{Event: ev, Subject: subject})
+			}
+		}
+	} else {
+		for i := range m.fence.regions {
+			if r := &m.fence.regions[i]; r.contains(c) {
+				alerts = append(alerts, Alert{Event: Event{Type: EventEnter, Region: r.name, Placemark: r.placemark}, Subject: subject})
+			}
+		}
+	}
+
+	for _, p := range m.points {
+		d := c.DistanceTo(p.at)
+		was := seen && prev.DistanceTo(p.at) <= p.radius
+		is := d <= p.radius
+		if was == is {
+			continue
+		}
+		typ := EventEnter
+		if was {
+			typ = EventExit
+		}
+		alerts = append(alerts, Alert{Event: Event{Type: typ, Region: p.name, Placemark: p.placemark}, Subject: subject, Distance: d})
+	}
+	return alerts
+}
+
+// Forget drops the last position of subject, so its next position is
+// treated as its first.
+func (m *Monitor) Forget(subject string) {
+	m.mu.Lock()
+	delete(m.last, subject)
+	m.mu.Unlock()
+}
@@ -0,0 +1,108 @@
+package kml
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// monitorTestKML returns a dock polygon and two gates, one with its own
+// radius
+func monitorTestKML() *KML {
+	k := NewKML()
+	k.Feature = &Document{Features: []Feature{
+		&Placemark{Name: "Dock 3", Geometry: &Polygon{OuterBoundary: square(0, 0, 0.01, 0.01)}},
+		&Placemark{Name: "Gate A", Geometry: &Point{Coordinates: Coord(0.02, 0)}},
+		&Placemark{ID: "gate-b", Geometry: &Point{Coordinates: Coord(0.05, 0)},
+			ExtendedData: &ExtendedData{Data: []Data{{Name: "radius", Value: "500"}}}},
+		&Placemark{Geometry: &Point{Coordinates: Coord(0.08, 0)}},
+	}}
+	return k
+}
+
+// alertStrings describes alerts as "subject type region"
+func alertStrings(alerts []Alert) []string {
+	var out []string
+	for _, a := range alerts {
+		out = append(out, a.Subject+" "+a.Type.String()+" "+a.Region)
+	}
+	return out
+}
+
+// TestMonitor tests the alerts for a subject moving past the regions
+func TestMonitor(t *testing.T) {
+	m := NewMonitor(monitorTestKML(), MonitorOptions{})
+	if m.Len() != 3 {
+		t.Errorf("Expected 3 regions, got %d", m.Len())
+	}
+
+	steps := []struct {
+		at   Coordinate
+		want []string
+	}{
+		{Coord(0.005, 0.005), []string{"truck enter Dock 3"}},
+		{Coord(0.006, 0.005), nil},
+		{Coord(0.0195, 0), []string{"truck exit Dock 3", "truck enter Gate A"}},
+		{Coord(0.03, 0), []string{"truck exit Gate A"}},
+		{Coord(0.04, 0), nil},                             // 1.1 km from gate B
+		{Coord(0.046, 0), []string{"truck enter gate-b"}}, // 445 m, within its own radius
+		{Coord(0.08, 0), []string{"truck exit gate-b"}},   // unnamed points are not watched
+	}
+
+	for i, s := range steps {
+		got := alertStrings(m.Update("truck", s.at))
+		if !reflect.DeepEqual(got, s.want) {
+			t.Errorf("Step %d: expected %v, got %v", i, s.want, got)
+		}
+	}
+}
+
+// TestMonitorFirstPosition tests alerts for a subject first seen inside
+func TestMonitorFirstPosition(t *testing.T) {
+	m := NewMonitor(monitorTestKML(), MonitorOptions{Radius: 2000})
+	got := m.Update("van", Coord(0.009, 0.001))
+	if want := []string{"van enter Dock 3", "van enter Gate A"}; !reflect.DeepEqual(alertStrings(got), want) {
+		t.Errorf("Expected %v, got %v", want, alertStrings(got))
+	}
+	if got[0].Distance != 0 || got[0].Placemark.Name != "Dock 3" {
+		t.Errorf("Expected polygon alert without distance, got %+v", got[0])
+	}
+	if d := got[1].Distance; !floatNear(d, Coord(0.009, 0.001).DistanceTo(Coord(0.02, 0)), 1e-6) {
+		t.Errorf("Expected distance to Gate A, got %v", d)
+	}
+
+	if got := m.Update("van", Coord(0.009, 0.001)); len(got) != 0 {
+		t.Errorf("Expected no alerts without movement, got %v", alertStrings(got))
+	}
+	m.Forget("van")
+	if got := m.Update("van", Coord(0.009, 0.001)); len(got) != 2 {
+		t.Errorf("Expected alerts again after Forget, got %v", alertStrings(got))
+	}
+}
+
+// TestMonitorSubjects tests that subjects are tracked separately and safely
+func TestMonitorSubjects(t *testing.T) {
+	m := NewMonitor(monitorTestKML(), MonitorOptions{})
+	var wg sync.WaitGroup
+	counts := make([]int, 8)
+	for i := range counts {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			subject := string(rune('a' + i))
+			for j := 0; j < 50; j++ {
+				at := Coord(0.005, 0.005)
+				if j%2 == 1 {
+					at = Coord(0.5, 0.5)
+				}
+				counts[i] += len(m.Update(subject, at))
+			}
+		}(i)
+	}
+	wg.Wait()
+	for i, n := range counts {
+		if n != 50 {
+			t.Errorf("Subject %d: expected 50 alerts, got %d", i, n)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package kml
+
+import "math"
+
+// NearestPointOnLine returns the point on ls closest to c and its distance
+// from c in meters. Each segment is projected in a local equirectangular
+// frame centered on c, which is accurate for segments up to a few hundred
+// kilometers long. The returned point's altitude is interpolated along the
+// segment. For a LineString without coordinates, it returns c and +Inf.
+func NearestPointOnLine(ls *LineString, c Coordinate) (Coordinate, float64) {
+	return nearestOnPath(ls.Coordinates, c)
+}
+
+// NearestPlacemark returns the placemark whose geometry is closest to c,
+// together with the closest point on that geometry and its distance in
+// meters. Points are measured directly, lines to their nearest segment,
+// and polygons to their boundary, or zero if c lies inside. It returns nil
+// if no placemark has geometry.
+func (k *KML) NearestPlacemark(c Coordinate) (*Placemark, Coordinate, float64) {
+	var (
+		best     *Placemark
+		bestPt   Coordinate
+		bestDist = math.Inf(1)
+	)
+	for _, pm := range k.Placemarks() {
+		pt, dist, ok := nearestOnGeometry(pm.Geometry, c)
+		if ok && dist < bestDist {
+			best, bestPt, bestDist = pm, pt, dist
+		}
+	}
+	return best, bestPt, bestDist
+}
+
+// nearestOnGeometry returns the closest point of g to c and its distance.
+// It reports false for geometries without coordinates.
+func nearestOnGeometry(g Geometry, c Coordinate) (Coordinate, float64, bool) {
+	switch geom := g.(type) {
+	case *Point:
+		return geom.Coordinates, distance(geom.Coordinates, c), true
+	case *LineString:
+		return nearestOnPathOK(geom.Coordinates, c)
+	case *LinearRing:
+		return nearestOnPathOK(geom.Coordinates, c)
+	case *Track:
+		return nearestOnPathOK(geom.Coords, c)
+	case *Polygon:
+		if geom.Contains(c) {
+			return c, 0, true
+		}
+		pt, dist, ok := nearestOnPathOK(geom.OuterBoundary.Coordinates, c)
+		for _, hole := range geom.InnerBoundaries {
+			if hpt, hdist, hok := nearestOnPathOK(hole.Coordinates, c); hok && (!ok || hdist < dist) {
+				pt, dist, ok = hpt, hdist, true
+			}
+		}
+		return pt, dist, ok
+	case *MultiGeometry:
+		var (
+			bestPt   Coordinate
+			bestDist = math.Inf(1)
+			found    bool
+		)
+		for _, child := range geom.Geometries {
+			if pt, dist, ok := nearestOnGeometry(child, c); ok && dist < bestDist {
+				bestPt, bestDist, found = pt, dist, true
+			}
+		}
+		return bestPt, bestDist, found
+	}
+	return Coordinate{}, 0, false
+}
+
+// nearestOnPathOK is nearestOnPath reporting whether coords was non-empty.
+func nearestOnPathOK(coords []Coordinate, c Coordinate) (Coordinate, float64, bool) {
+	if len(coords) == 0 {
+		return Coordinate{}, 0, false
+	}
+	pt, dist := nearestOnPath(coords, c)
+	return pt, dist, true
+}
+
+// nearestOnPath returns the closest point to c on the polyline coords.
+func nearestOnPath(coords []Coordinate, c Coordinate) (Coordinate, float64) {
+	switch len(coords) {
+	case 0:
+		return c, math.Inf(1)
+	case 1:
+		return coords[0], distance(coords[0], c)
+	}
+
+	bestPt := coords[0]
+	bestDist := math.Inf(1)
+	for i := 1; i < len(coords); i++ {
+		pt := projectOnSegment(coords[i-1], coords[i], c)
+		if dist := distance(pt, c); dist < bestDist {
+			bestPt, bestDist = pt, dist
+		}
+	}
+	return bestPt, bestDist
+}
+
+// projectOnSegment returns the point of segment a-b closest to c, using an
+// equirectangular projection centered on c.
+func projectOnSegment(a, b, c Coordinate) Coordinate {
+	scale := math.Cos(toRadians(c.Lat))
+	ax, ay := wrapLon(a.Lon-c.Lon)*scale, a.Lat-c.Lat
+	bx, by := wrapLon(b.Lon-c.Lon)*scale, b.Lat-c.Lat
+	dx, dy := bx-ax, by-ay
+
+	t := 0.0
+	if lenSq := dx*dx + dy*dy; lenSq > 0 {
+		t = math.Max(0, math.Min(1, -(ax*dx+ay*dy)/lenSq))
+	}
+
+	return Coordinate{
+		Lon: a.Lon + wrapLon(b.Lon-a.Lon)*t,
+		Lat: a.Lat + (b.Lat-a.Lat)*t,
+		Alt: a.Alt + (b.Alt-a.Alt)*t,
+	}
+}
+
+// wrapLon normalizes a longitude difference to the range [-180, 180]. It
+// returns NaN for infinite or NaN differences.
+func wrapLon(d float64) float64 {
+	return math.Remainder(d, 360)
+}
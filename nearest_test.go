@@ -0,0 +1,130 @@
+package kml
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNearestPointOnLine(t *testing.T) {
+	ls := &LineString{Coordinates: []Coordinate{
+		{Lon: 0, Lat: 0, Alt: 0},
+		{Lon: 1, Lat: 0, Alt: 100},
+		{Lon: 1, Lat: 1, Alt: 100},
+	}}
+
+	tests := []struct {
+		name     string
+		c        Coordinate
+		wantPt   Coordinate
+		wantDist float64
+	}{
+		{"above first segment", Coord(0.5, 0.01), Coordinate{Lon: 0.5, Lat: 0, Alt: 50}, haversine(Coord(0.5, 0.01), Coord(0.5, 0))},
+		{"before start", Coord(-0.1, 0), Coordinate{Lon: 0, Lat: 0}, haversine(Coord(-0.1, 0), Coord(0, 0))},
+		{"beside second segment", Coord(1.02, 0.5), Coordinate{Lon: 1, Lat: 0.5, Alt: 100}, haversine(Coord(1.02, 0.5), Coord(1, 0.5))},
+		{"on the line", Coord(1, 0.25), Coordinate{Lon: 1, Lat: 0.25, Alt: 100}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pt, dist := NearestPointOnLine(ls, tt.c)
+			if !floatNear(pt.Lon, tt.wantPt.Lon, 1e-6) || !floatNear(pt.Lat, tt.wantPt.Lat, 1e-6) || !floatNear(pt.Alt, tt.wantPt.Alt, 1e-3) {
+				t.Errorf("Expected point %v, got %v", tt.wantPt, pt)
+			}
+			if !floatNear(dist, tt.wantDist, 1) {
+				t.Errorf("Expected distance %.1f, got %.1f", tt.wantDist, dist)
+			}
+		})
+	}
+
+	if _, dist := NearestPointOnLine(&LineString{}, Coord(0, 0)); !math.IsInf(dist, 1) {
+		t.Errorf("Expected +Inf for empty line, got %v", dist)
+	}
+}
+
+func TestNearestPointOnLineAntimeridian(t *testing.T) {
+	ls := &LineString{Coordinates: []Coordinate{Coord(179.5, 0), Coord(-179.5, 0)}}
+	pt, dist := NearestPointOnLine(ls, Coord(180, 0.01))
+	if dist > 1200 {
+		t.Errorf("Expected segment crossing the antimeridian to be ~1.1km away, got %.0fm at %v", dist, pt)
+	}
+}
+
+func TestNearestPlacemark(t *testing.T) {
+	k := NewKML()
+	k.Feature = &Document{Features: []Feature{
+		&Placemark{Name: "trail", Geometry: &LineString{Coordinates: []Coordinate{Coord(0, 0), Coord(0, 1)}}},
+		&Placemark{Name: "hut", Geometry: &Point{Coordinates: Coord(0.05, 0.5)}},
+		&Placemark{Name: "lake", Geometry: &Polygon{OuterBoundary: square(1, 0, 2, 1)}},
+		&Placemark{Name: "empty"},
+	}}
+
+	tests := []struct {
+		c        Coordinate
+		want     string
+		wantZero bool
+	}{
+		{Coord(0.01, 0.2), "trail", false},
+		{Coord(0.045, 0.5), "hut", false},
+		{Coord(1.5, 0.5), "lake", true},
+		{Coord(0.9, 0.5), "lake", false},
+	}
+
+	for _, tt := range tests {
+		pm, _, dist := k.NearestPlacemark(tt.c)
+		if pm == nil || pm.Name != tt.want {
+			t.Errorf("NearestPlacemark(%v): expected %q, got %v", tt.c, tt.want, pm)
+			continue
+		}
+		if tt.wantZero != (dist == 0) {
+			t.Errorf("NearestPlacemark(%v): unexpected distance %v", tt.c, dist)
+		}
+	}
+
+	empty := NewKML()
+	empty.Feature = &Folder{}
+	if pm, _, _ := empty.NearestPlacemark(Coord(0, 0)); pm != nil {
+		t.Errorf("Expected nil for document without geometry, got %v", pm)
+	}
+}
+
+func TestWrapLon(t *testing.T) {
+	tests := []struct {
+		d    float64
+		want float64
+	}{
+		{0, 0},
+		{190, -170},
+		{-190, 170},
+		{180, 180},
+		{-180, -180},
+		{725, 5},
+	}
+
+	for _, tt := range tests {
+		if got := wrapLon(tt.d); !floatNear(got, tt.want, 1e-9) {
+			t.Errorf("wrapLon(%v): expected %v, got %v", tt.d, tt.want, got)
+		}
+	}
+	for _, d := range []float64{math.Inf(1), math.Inf(-1), math.NaN()} {
+		if got := wrapLon(d); !math.IsNaN(got) {
+			t.Errorf("wrapLon(%v): expected NaN, got %v", d, got)
+		}
+	}
+	if got := wrapLon(1e300); got < -180 || got > 180 {
+		t.Errorf("wrapLon(1e300): expected a value in [-180, 180], got %v", got)
+	}
+}
+
+func TestNearestPlacemarkNonFinite(t *testing.T) {
+	k := NewKML()
+	k.Feature = &Placemark{Geometry: &LineString{Coordinates: []Coordinate{Coord(0, 0), Coord(0, 1)}}}
+
+	for _, lon := range []float64{math.Inf(1), math.Inf(-1)} {
+		if pm, _, _ := k.NearestPlacemark(Coord(lon, 0)); pm != nil {
+			t.Errorf("Expected no placemark near longitude %v, got %v", lon, pm)
+		}
+	}
+	if _, _, dist := k.NearestPlacemark(Coord(1e300, 0)); math.IsInf(dist, 0) {
+		t.Errorf("Expected a finite distance for longitude 1e300, got %v", dist)
+	}
+}
@@ -0,0 +1,245 @@
+package kml
+
+import "encoding/xml"
+
+// RefreshMode specifies when a NetworkLink fetches its target.
+type RefreshMode string
+
+// RefreshMode values.
+const (
+	RefreshOnChange   RefreshMode = "onChange"
+	RefreshOnInterval RefreshMode = "onInterval"
+	RefreshOnExpire   RefreshMode = "onExpire"
+)
+
+// ViewRefreshMode specifies how a NetworkLink responds to camera changes.
+type ViewRefreshMode string
+
+// ViewRefreshMode values.
+const (
+	ViewRefreshNever     ViewRefreshMode = "never"
+	ViewRefreshOnStop    ViewRefreshMode = "onStop"
+	ViewRefreshOnRequest ViewRefreshMode = "onRequest"
+	ViewRefreshOnRegion  ViewRefreshMode = "onRegion"
+)
+
+// Link specifies the location of a file loaded by a NetworkLink.
+type Link struct {
+	Href            string          `xml:"href,omitempty"`
+	RefreshMode     RefreshMode     `xml:"refreshMode,omitempty"`
+	RefreshInterval float64         `xml:"refreshInterval,omitempty"`
+	ViewRefreshMode ViewRefreshMode `xml:"viewRefreshMode,omitempty"`
+	ViewRefreshTime float64         `xml:"viewRefreshTime,omitempty"` // Seconds after the camera stops, for onStop
+	ViewFormat      string          `xml:"viewFormat,omitempty"`      // Query parameters describing the view, such as DefaultViewFormat
+}
+
+// NetworkLink references a KML file, local or remote, whose features are
+// loaded into the document. Combined with a Region, it loads the file only
+// when the Region is active, which is how SuperOverlays stream imagery.
+// It implements the Feature interface.
+type NetworkLink struct {
+	ID                string
+	Name              string
+	Description       string
+	Visibility        *bool
+	Open              bool
+	LookAt            *LookAt
+	Camera            *Camera
+	Region            *Region
+	RefreshVisibility bool
+	FlyToView         bool
+	Link              *Link
+	Comments          []string // See PreserveComments
+
+	span *Span // See RecordSpans
+}
+
+// featureType implements the Feature interface.
+func (n *NetworkLink) featureType() string {
+	return "NetworkLink"
+}
+
+// Hash implements the Feature interface.
+func (n *NetworkLink) Hash() string {
+	return hashFeature(n)
+}
+
+// MarshalXML implements custom XML marshaling for NetworkLink.
+func (n *NetworkLink) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	start.Name.Local = "NetworkLink"
+
+	if n.ID != "" {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "id"}, Value: n.ID})
+	}
+
+	if err := encodeComments(e, n.Comments); err != nil {
+		return err
+	}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+
+	if n.Name != "" {
+		if err := e.EncodeElement(n.Name, xml.StartElement{Name: xml.Name{Local: "name"}}); err != nil {
+			return err
+		}
+	}
+
+	if n.Visibility != nil {
+		vis := 0
+		if *n.Visibility {
+			vis = 1
+		}
+		if err := e.EncodeElement(vis, xml.StartElement{Name: xml.Name{Local: "visibility"}}); err != nil {
+			return err
+		}
+	}
+
+	if n.Open {
+		if err := e.EncodeElement(1, xml.StartElement{Name: xml.Name{Local: "open"}}); err != nil {
+			return err
+		}
+	}
+
+	if n.Description != "" {
+		if err := encodeDescription(e, n.Description); err != nil {
+			return err
+		}
+	}
+
+	if n.LookAt != nil {
+		if err := e.Encode(n.LookAt); err != nil {
+			return err
+		}
+	}
+
+	if n.Camera != nil {
+		if err := e.Encode(n.Camera); err != nil {
+			return err
+		}
+	}
+
+	if n.Region != nil {
+		if err := e.Encode(n.Region); err != nil {
+			return err
+		}
+	}
+
+	if n.RefreshVisibility {
+		if err := e.EncodeElement(1, xml.StartElement{Name: xml.Name{Local: "refreshVisibility"}}); err != nil {
+			return err
+		}
+	}
+
+	if n.FlyToView {
+		if err := e.EncodeElement(1, xml.StartElement{Name: xml.Name{Local: "flyToView"}}); err != nil {
+			return err
+		}
+	}
+
+	if n.Link != nil {
+		name := "Link"
+		if targetVersion(e) == Version20 {
+			name = "Url"
+		}
+		if err := e.EncodeElement(n.Link, xml.StartElement{Name: xml.Name{Local: name}}); err != nil {
+			return err
+		}
+	}
+
+	if err := e.EncodeToken(start.End()); err != nil {
+		return err
+	}
+	featureWritten(e)
+	return nil
+}
+
+// UnmarshalXML implements custom XML unmarshaling for NetworkLink.
+// The KML 2.0 Url element is accepted as a synonym for Link.
+func (n *NetworkLink) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	n.Comments = takeComments(d)
+	beginSpan(d)
+
+	for _, attr := range start.Attr {
+		if attr.Name.Local == "id" {
+			n.ID = attr.Value
+		}
+	}
+
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return err
+		}
+
+		switch el := token.(type) {
+		case xml.StartElement:
+			switch el.Name.Local {
+			case "name":
+				if err := d.DecodeElement(&n.Name, &el); err != nil {
+					return err
+				}
+			case "description":
+				if err := d.DecodeElement(&n.Description, &el); err != nil {
+					return err
+				}
+			case "visibility":
+				var v int
+				if err := d.DecodeElement(&v, &el); err != nil {
+					return err
+				}
+				vis := flagValue(d, el, v)
+				n.Visibility = &vis
+			case "open":
+				var v int
+				if err := d.DecodeElement(&v, &el); err != nil {
+					return err
+				}
+				n.Open = flagValue(d, el, v)
+			case "LookAt":
+				var lookAt LookAt
+				if err := d.DecodeElement(&lookAt, &el); err != nil {
+					return err
+				}
+				n.LookAt = &lookAt
+			case "Camera":
+				var camera Camera
+				if err := d.DecodeElement(&camera, &el); err != nil {
+					return err
+				}
+				n.Camera = &camera
+			case "Region":
+				var region Region
+				if err := d.DecodeElement(&region, &el); err != nil {
+					return err
+				}
+				n.Region = &region
+			case "refreshVisibility":
+				var v int
+				if err := d.DecodeElement(&v, &el); err != nil {
+					return err
+				}
+				n.RefreshVisibility = flagValue(d, el, v)
+			case "flyToView":
+				var v int
+				if err := d.DecodeElement(&v, &el); err != nil {
+					return err
+				}
+				n.FlyToView = flagValue(d, el, v)
+			case "Link", "Url":
+				var link Link
+				if err := d.DecodeElement(&link, &el); err != nil {
+					return err
+				}
+				n.Link = &link
+			default:
+				if err := skipElement(d, el); err != nil {
+					return err
+				}
+			}
+		case xml.EndElement:
+			featureParsed(d, n)
+			return nil
+		}
+	}
+}
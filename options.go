@@ -0,0 +1,201 @@
+package kml
+
+import (
+	"encoding/xml"
+	"os"
+	"sync"
+)
+
+// ParseOption configures Parse, ParseBytes, ParseFile and ParseFragments.
+type ParseOption func(*parseConfig)
+
+// parseConfig holds the settings for a single parse.
+type parseConfig struct {
+	errs       *[]error
+	compact    bool
+	progress   *progressTracker
+	logger     Logger
+	metrics    Metrics
+	stats      *ParseStats // Accumulated for metrics
+	comments   *commentState
+	spans      *spanState
+	maxDepth   int                    // See MaxDepth
+	duplicates *duplicateConfig       // See DuplicateIDs
+	order      *coordinateOrderConfig // See CoordinateOrder
+	fetcher    Fetcher                // See WithFetcher
+}
+
+// CollectErrors makes parsing tolerate recoverable errors, such as
+// malformed coordinate tuples and invalid colors, instead of failing on the
+// first one. Each is appended to errs as a *ParseError and the offending
+// value is dropped, so the best-effort document is still returned. XML
+// syntax errors remain fatal.
+func CollectErrors(errs *[]error) ParseOption {
+	return func(c *parseConfig) {
+		c.errs = errs
+	}
+}
+
+// decoderConfigs maps an active *xml.Decoder to its parse configuration,
+// letting UnmarshalXML methods deep in the tree see the options passed to
+// Parse without changing their signatures.
+var decoderConfigs sync.Map
+
+// withParseConfig registers the options for d and returns a function that
+// unregisters them.
+func withParseConfig(d *xml.Decoder, opts []ParseOption) func() {
+	if len(opts) == 0 {
+		return func() {}
+	}
+	return registerParseConfig(d, newParseConfig(opts))
+}
+
+// newParseConfig applies opts to the default configuration.
+func newParseConfig(opts []ParseOption) *parseConfig {
+	cfg := &parseConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// registerParseConfig registers cfg for d and returns a function that
+// unregisters it.
+func registerParseConfig(d *xml.Decoder, cfg *parseConfig) func() {
+	decoderConfigs.Store(d, cfg)
+	return func() { decoderConfigs.Delete(d) }
+}
+
+// parseConfigFor returns the configuration registered for d, or nil.
+func parseConfigFor(d *xml.Decoder) *parseConfig {
+	if cfg, ok := decoderConfigs.Load(d); ok {
+		return cfg.(*parseConfig)
+	}
+	return nil
+}
+
+// recoverable reports a recoverable error found while decoding. If d is
+// collecting errors, err is recorded with the decoder's current position and
+// nil is returned so decoding continues; otherwise err is returned.
+func recoverable(d *xml.Decoder, msg string, err error) error {
+	cfg := parseConfigFor(d)
+	if cfg != nil && cfg.stats != nil {
+		cfg.stats.Invalid[msg]++
+	}
+	if cfg == nil || cfg.errs == nil {
+		return err
+	}
+	line, col := d.InputPos()
+	*cfg.errs = append(*cfg.errs, &ParseError{Line: line, Column: col, Message: msg, Cause: err})
+	if cfg.logger != nil {
+		cfg.logger.Warn("kml: dropped "+msg, "line", line, "error", err)
+	}
+	return nil
+}
+
+// WriteOption configures Write, WriteIndent, WriteFile, Bytes and
+// StreamEncoder output.
+type WriteOption func(*writeConfig)
+
+// writeConfig holds the settings for a single write.
+type writeConfig struct {
+	precision int                  // decimal places for coordinates; negative for shortest
+	format    func(float64) string // See FormatFloat
+	dedup     bool
+	perm      *os.FileMode // nil unless FileMode was given
+	progress  *progressTracker
+	comments  bool
+	version   Version
+	files     []archiveFile // Extra KMZ entries; see KMZFile
+	appendTo  string        // See AppendTo
+}
+
+// archiveFile is a file added to a KMZ archive alongside doc.kml.
+type archiveFile struct {
+	name string
+	data []byte
+}
+
+// Precision rounds coordinate values to digits decimal places on output,
+// trimming trailing zeros. Six digits is roughly 0.1 m at the equator.
+func Precision(digits int) WriteOption {
+	return func(c *writeConfig) {
+		c.precision = digits
+	}
+}
+
+// FormatFloat formats every coordinate value with fn instead of the
+// default shortest representation, such as to write numbers exactly as a
+// legacy system does so that its output diffs byte for byte. It applies to
+// coordinates, gx:LatLonQuad corners and gx:coord values, and takes the
+// place of Precision. A coordinate whose altitude is zero is still written
+// as "lon,lat" in coordinates elements.
+func FormatFloat(fn func(float64) string) WriteOption {
+	return func(c *writeConfig) {
+		c.format = fn
+	}
+}
+
+// DedupCoordinates drops coordinates that are identical to the preceding
+// one as written, removing the zero-length segments that reduced Precision
+// can create. A LinearRing keeps its closing coordinate, but a heavily
+// rounded ring may still collapse below four positions.
+func DedupCoordinates() WriteOption {
+	return func(c *writeConfig) {
+		c.dedup = true
+	}
+}
+
+// FileMode sets the permissions of files written by WriteFile, regardless
+// of the umask. Without it, a file that is replaced keeps its permissions
+// and a new one is created with 0644 less the umask, as os.Create does.
+func FileMode(perm os.FileMode) WriteOption {
+	return func(c *writeConfig) {
+		c.perm = &perm
+	}
+}
+
+// KMZFile adds a file to the archive when WriteFile writes a .kmz path,
+// such as an icon the document references by the relative href name. It
+// has no effect on other output.
+func KMZFile(name string, data []byte) WriteOption {
+	return func(c *writeConfig) {
+		c.files = append(c.files, archiveFile{name: name, data: data})
+	}
+}
+
+// encoderConfigs maps an active *xml.Encoder to its write configuration.
+var encoderConfigs sync.Map
+
+// withWriteConfig registers the options for e and returns a function that
+// unregisters them.
+func withWriteConfig(e *xml.Encoder, opts []WriteOption) func() {
+	if len(opts) == 0 {
+		return func() {}
+	}
+	return registerWriteConfig(e, newWriteConfig(opts))
+}
+
+// registerWriteConfig registers cfg for e and returns a function that
+// unregisters it.
+func registerWriteConfig(e *xml.Encoder, cfg *writeConfig) func() {
+	encoderConfigs.Store(e, cfg)
+	return func() { encoderConfigs.Delete(e) }
+}
+
+// newWriteConfig applies opts to the default configuration.
+func newWriteConfig(opts []WriteOption) *writeConfig {
+	cfg := &writeConfig{precision: -1}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// writeConfigFor returns the configuration registered for e, or nil.
+func writeConfigFor(e *xml.Encoder) *writeConfig {
+	if cfg, ok := encoderConfigs.Load(e); ok {
+		return cfg.(*writeConfig)
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+package kml
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const malformedKML = `<?xml version="1.0" encoding="UTF-8"?>
+<kml xmlns="http://www.opengis.net/kml/2.2">
+<Document>
+  <Style id="bad">
+    <LineStyle><color>not-a-color</color></LineStyle>
+  </Style>
+  <Placemark>
+    <name>Line</name>
+    <LineString>
+      <coordinates>0,0 1,x 2,2 3</coordinates>
+    </LineString>
+  </Placemark>
+  <Placemark>
+    <name>Good</name>
+    <Point><coordinates>5,5</coordinates></Point>
+  </Placemark>
+</Document>
+</kml>`
+
+func TestParseFailsOnFirstError(t *testing.T) {
+	if _, err := ParseBytes([]byte(malformedKML)); err == nil {
+		t.Error("Expected error without CollectErrors")
+	}
+}
+
+func TestParseCollectErrors(t *testing.T) {
+	var errs []error
+	k, err := ParseBytes([]byte(malformedKML), CollectErrors(&errs))
+	if err != nil {
+		t.Fatalf("ParseBytes failed: %v", err)
+	}
+
+	if len(errs) != 3 {
+		t.Fatalf("Expected 3 collected errors, got %d: %v", len(errs), errs)
+	}
+	for _, e := range errs {
+		var parseErr *ParseError
+		if !errors.As(e, &parseErr) {
+			t.Fatalf("Expected *ParseError, got %T", e)
+		}
+		if parseErr.Line == 0 {
+			t.Errorf("Expected line information in %v", e)
+		}
+	}
+	if !strings.Contains(errs[0].Error(), "invalid color") {
+		t.Errorf("Expected first error to be an invalid color, got %v", errs[0])
+	}
+	if !strings.Contains(errs[1].Error(), "1,x") {
+		t.Errorf("Expected error to mention bad tuple, got %v", errs[1])
+	}
+
+	pms := k.Placemarks()
+	if len(pms) != 2 {
+		t.Fatalf("Expected 2 placemarks, got %d", len(pms))
+	}
+	ls := pms[0].Geometry.(*LineString)
+	if len(ls.Coordinates) != 2 {
+		t.Errorf("Expected 2 valid coordinates kept, got %d", len(ls.Coordinates))
+	}
+	if pt := pms[1].Geometry.(*Point); pt.Coordinates.Lon != 5 {
+		t.Errorf("Expected later placemark to parse, got %v", pt.Coordinates)
+	}
+}
+
+func TestParseCollectErrorsSyntaxErrorIsFatal(t *testing.T) {
+	var errs []error
+	_, err := ParseBytes([]byte(`<kml><Placemark><name>x</Placemark></kml>`), CollectErrors(&errs))
+	if err == nil {
+		t.Error("Expected XML syntax error to be fatal")
+	}
+}
+
+func TestParseFragmentCollectErrors(t *testing.T) {
+	var errs []error
+	obj, err := ParseFragment([]byte(`<Point><coordinates>bad</coordinates></Point>`), CollectErrors(&errs))
+	if err != nil {
+		t.Fatalf("ParseFragment failed: %v", err)
+	}
+	if len(errs) != 1 {
+		t.Errorf("Expected 1 collected error, got %d", len(errs))
+	}
+	if _, ok := obj.(*Point); !ok {
+		t.Errorf("Expected *Point, got %T", obj)
+	}
+}
+
+func TestWritePrecision(t *testing.T) {
+	k := NewKML()
+	k.Feature = &Placemark{Geometry: &Point{Coordinates: Coordinate{Lon: -122.0822035425683, Lat: 37.42228990140251, Alt: 0.000004}}}
+
+	data, err := k.Bytes(Precision(5))
+	if err != nil {
+		t.Fatalf("Bytes failed: %v", err)
+	}
+	if !strings.Contains(string(data), "<coordinates>-122.0822,37.42229</coordinates>") {
+		t.Errorf("Expected rounded coordinates, got %s", data)
+	}
+
+	data, err = k.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes failed: %v", err)
+	}
+	if !strings.Contains(string(data), "-122.0822035425683") {
+		t.Errorf("Expected full precision without options, got %s", data)
+	}
+}
+
+func TestFormatFixed(t *testing.T) {
+	tests := []struct {
+		v      float64
+		digits int
+		want   string
+	}{
+		{1.23456, 2, "1.23"},
+		{1.2, 4, "1.2"},
+		{3, 2, "3"},
+		{-0.0001, 2, "0"},
+		{0.1 + 0.2, 6, "0.3"},
+		{1234.6, 0, "1235"},
+	}
+
+	for _, tt := range tests {
+		if got := formatFixed(tt.v, tt.digits); got != tt.want {
+			t.Errorf("formatFixed(%v, %d): expected %q, got %q", tt.v, tt.digits, tt.want, got)
+		}
+	}
+}
+
+func TestWriteDedupCoordinates(t *testing.T) {
+	ls := &LineString{Coordinates: []Coordinate{
+		{Lon: 0.00001, Lat: 0.00001},
+		{Lon: 0.00002, Lat: 0.00002},
+		{Lon: 1, Lat: 1},
+		{Lon: 1.000001, Lat: 1},
+		{Lon: 0, Lat: 0},
+	}}
+	k := NewKML()
+	k.Feature = &Placemark{Geometry: ls}
+
+	data, err := k.Bytes(Precision(3), DedupCoordinates())
+	if err != nil {
+		t.Fatalf("Bytes failed: %v", err)
+	}
+	if !strings.Contains(string(data), "<coordinates>0,0 1,1 0,0</coordinates>") {
+		t.Errorf("Expected deduplicated coordinates, got %s", data)
+	}
+
+	data, err = k.Bytes(Precision(3))
+	if err != nil {
+		t.Fatalf("Bytes failed: %v", err)
+	}
+	if !strings.Contains(string(data), "<coordinates>0,0 0,0 1,1 1,1 0,0</coordinates>") {
+		t.Errorf("Expected duplicates kept without DedupCoordinates, got %s", data)
+	}
+
+	if len(ls.Coordinates) != 5 {
+		t.Errorf("Expected source geometry to be unchanged, got %d coordinates", len(ls.Coordinates))
+	}
+}
+
+func TestStreamEncoderPrecision(t *testing.T) {
+	var sb strings.Builder
+	enc := NewStreamEncoder(&sb, "", Precision(1))
+	if err := enc.Encode(&Placemark{Geometry: &Point{Coordinates: Coordinate{Lon: 1.26, Lat: 2.04}}}); err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if !strings.Contains(sb.String(), "<coordinates>1.3,2</coordinates>") {
+		t.Errorf("Expected rounded coordinates, got %s", sb.String())
+	}
+}
+
+func TestWriteFormatFloat(t *testing.T) {
+	format := func(v float64) string { return strconv.FormatFloat(v, 'f', 8, 64) }
+	k := NewKML()
+	k.Feature = &Document{Features: []Feature{
+		&Placemark{Geometry: &Point{Coordinates: Coordinate{Lon: -122.5, Lat: 37.25}}},
+		&Placemark{Geometry: &LineString{Coordinates: []Coordinate{{Lon: 1, Lat: 2, Alt: 3}, {Lon: 1, Lat: 2, Alt: 3}}}},
+		&Placemark{Geometry: &Track{Coords: []Coordinate{{Lon: 1.5, Lat: 2}}}},
+		&GroundOverlay{LatLonQuad: &LatLonQuad{Coordinates: Coordinates{{Lon: 0, Lat: 0}, {Lon: 1, Lat: 0}, {Lon: 1, Lat: 1}, {Lon: 0, Lat: 1}}}},
+	}}
+
+	data, err := k.Bytes(FormatFloat(format), Precision(2), DedupCoordinates())
+	if err != nil {
+		t.Fatalf("Bytes failed: %v", err)
+	}
+	for _, want := range []string{
+		"<coordinates>-122.50000000,37.25000000</coordinates>",
+		"<coordinates>1.00000000,2.00000000,3.00000000</coordinates>",
+		"<gx:coord>1.50000000 2.00000000 0.00000000</gx:coord>",
+		"<coordinates>0.00000000,0.00000000 1.00000000,0.00000000 1.00000000,1.00000000 0.00000000,1.00000000</coordinates>",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("Expected %s, got %s", want, data)
+		}
+	}
+
+	data, err = k.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes failed: %v", err)
+	}
+	if !strings.Contains(string(data), "<coordinates>-122.5,37.25</coordinates>") || !strings.Contains(string(data), "<gx:coord>1.5 2 0</gx:coord>") {
+		t.Errorf("Expected the default formatting without options, got %s", data)
+	}
+}
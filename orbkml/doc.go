@@ -0,0 +1,14 @@
+// Package orbkml converts between the geometries of package kml and those
+// of github.com/paulmach/orb, so documents parsed with kml can be used with
+// orb's planar and geo algorithms and orb geometries written as KML.
+//
+// The package is a module of its own, so orb is a dependency only of
+// programs that require github.com/robert-malhotra/go-kml/orbkml, and the
+// converters are built only with the orb build tag:
+//
+//	go get github.com/robert-malhotra/go-kml/orbkml
+//	go build -tags orb
+//
+// orb geometries are two-dimensional: altitudes are dropped by ToOrb and
+// are zero after FromOrb.
+package orbkml
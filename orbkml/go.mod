@@ -0,0 +1,10 @@
+module github.com/robert-malhotra/go-kml/orbkml
+
+go 1.25
+
+replace github.com/robert-malhotra/go-kml => ../
+
+require (
+	github.com/paulmach/orb v0.13.0
+	github.com/robert-malhotra/go-kml v0.0.0-00010101000000-000000000000
+)
@@ -0,0 +1,183 @@
+//go:build orb
+
+package orbkml
+
+import (
+	"fmt"
+
+	"github.com/paulmach/orb"
+
+	kml "github.com/robert-malhotra/go-kml"
+)
+
+// ToOrb converts a KML geometry to an orb geometry. Points, LineStrings,
+// LinearRings and Polygons become their orb counterparts, and a gx:Track
+// becomes an orb.LineString of its coordinates. A MultiGeometry whose
+// children are all Points, all LineStrings or all Polygons becomes an
+// orb.MultiPoint, orb.MultiLineString or orb.MultiPolygon; any other
+// MultiGeometry becomes an orb.Collection.
+func ToOrb(g kml.Geometry) (orb.Geometry, error) {
+	switch geom := g.(type) {
+	case *kml.Point:
+		return point(geom.Coordinates), nil
+	case *kml.LineString:
+		return orb.LineString(points(geom.Coordinates)), nil
+	case *kml.LinearRing:
+		return orb.Ring(points(geom.Coordinates)), nil
+	case *kml.Polygon:
+		return polygon(geom), nil
+	case *kml.Track:
+		return orb.LineString(points(geom.Coords)), nil
+	case *kml.MultiGeometry:
+		return multiGeometry(geom)
+	}
+	return nil, fmt.Errorf("orbkml: unsupported geometry %T", g)
+}
+
+// FromOrb converts an orb geometry to a KML geometry. orb.MultiPoint,
+// orb.MultiLineString, orb.MultiPolygon and orb.Collection become
+// MultiGeometries, and an orb.Bound becomes the Polygon of its corners.
+func FromOrb(g orb.Geometry) (kml.Geometry, error) {
+	switch geom := g.(type) {
+	case orb.Point:
+		return &kml.Point{Coordinates: coordinate(geom)}, nil
+	case orb.LineString:
+		return &kml.LineString{Coordinates: coordinates(geom)}, nil
+	case orb.Ring:
+		return &kml.LinearRing{Coordinates: coordinates(geom)}, nil
+	case orb.Polygon:
+		return fromPolygon(geom), nil
+	case orb.Bound:
+		return fromPolygon(geom.ToPolygon()), nil
+	case orb.MultiPoint:
+		mg := &kml.MultiGeometry{}
+		for _, p := range geom {
+			mg.Geometries = append(mg.Geometries, &kml.Point{Coordinates: coordinate(p)})
+		}
+		return mg, nil
+	case orb.MultiLineString:
+		mg := &kml.MultiGeometry{}
+		for _, ls := range geom {
+			mg.Geometries = append(mg.Geometries, &kml.LineString{Coordinates: coordinates(ls)})
+		}
+		return mg, nil
+	case orb.MultiPolygon:
+		mg := &kml.MultiGeometry{}
+		for _, p := range geom {
+			mg.Geometries = append(mg.Geometries, fromPolygon(p))
+		}
+		return mg, nil
+	case orb.Collection:
+		mg := &kml.MultiGeometry{}
+		for _, child := range geom {
+			converted, err := FromOrb(child)
+			if err != nil {
+				return nil, err
+			}
+			mg.Geometries = append(mg.Geometries, converted)
+		}
+		return mg, nil
+	}
+	return nil, fmt.Errorf("orbkml: unsupported geometry %T", g)
+}
+
+// multiGeometry converts mg to the most specific orb geometry holding its
+// children.
+func multiGeometry(mg *kml.MultiGeometry) (orb.Geometry, error) {
+	children := make([]orb.Geometry, 0, len(mg.Geometries))
+	for _, child := range mg.Geometries {
+		converted, err := ToOrb(child)
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, converted)
+	}
+	if len(children) == 0 {
+		return orb.Collection{}, nil
+	}
+
+	switch children[0].(type) {
+	case orb.Point:
+		var mp orb.MultiPoint
+		for _, child := range children {
+			p, ok := child.(orb.Point)
+			if !ok {
+				return orb.Collection(children), nil
+			}
+			mp = append(mp, p)
+		}
+		return mp, nil
+	case orb.LineString:
+		var mls orb.MultiLineString
+		for _, child := range children {
+			ls, ok := child.(orb.LineString)
+			if !ok {
+				return orb.Collection(children), nil
+			}
+			mls = append(mls, ls)
+		}
+		return mls, nil
+	case orb.Polygon:
+		var mp orb.MultiPolygon
+		for _, child := range children {
+			p, ok := child.(orb.Polygon)
+			if !ok {
+				return orb.Collection(children), nil
+			}
+			mp = append(mp, p)
+		}
+		return mp, nil
+	}
+	return orb.Collection(children), nil
+}
+
+// polygon converts p to an orb.Polygon, outer ring first.
+func polygon(p *kml.Polygon) orb.Polygon {
+	rings := orb.Polygon{orb.Ring(points(p.OuterBoundary.Coordinates))}
+	for _, inner := range p.InnerBoundaries {
+		rings = append(rings, orb.Ring(points(inner.Coordinates)))
+	}
+	return rings
+}
+
+// fromPolygon converts p to a KML Polygon.
+func fromPolygon(p orb.Polygon) *kml.Polygon {
+	out := &kml.Polygon{}
+	for i, ring := range p {
+		lr := kml.LinearRing{Coordinates: coordinates(ring)}
+		if i == 0 {
+			out.OuterBoundary = lr
+		} else {
+			out.InnerBoundaries = append(out.InnerBoundaries, lr)
+		}
+	}
+	return out
+}
+
+// point converts c to an orb.Point, dropping its altitude.
+func point(c kml.Coordinate) orb.Point {
+	return orb.Point{c.Lon, c.Lat}
+}
+
+// points converts coords to orb points.
+func points(coords []kml.Coordinate) []orb.Point {
+	out := make([]orb.Point, len(coords))
+	for i, c := range coords {
+		out[i] = point(c)
+	}
+	return out
+}
+
+// coordinate converts p to a KML coordinate.
+func coordinate(p orb.Point) kml.Coordinate {
+	return kml.Coord(p.Lon(), p.Lat())
+}
+
+// coordinates converts ps to KML coordinates.
+func coordinates[P ~[]orb.Point](ps P) []kml.Coordinate {
+	out := make([]kml.Coordinate, len(ps))
+	for i, p := range ps {
+		out[i] = coordinate(p)
+	}
+	return out
+}
@@ -0,0 +1,90 @@
+//go:build orb
+
+package orbkml
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/paulmach/orb"
+
+	kml "github.com/robert-malhotra/go-kml"
+)
+
+// TestToOrb tests converting KML geometries to orb geometries
+func TestToOrb(t *testing.T) {
+	square := []kml.Coordinate{kml.Coord(0, 0), kml.Coord(1, 0), kml.Coord(1, 1), kml.Coord(0, 0)}
+	tests := []struct {
+		name string
+		geom kml.Geometry
+		want orb.Geometry
+	}{
+		{"point", &kml.Point{Coordinates: kml.Coord(1, 2, 30)}, orb.Point{1, 2}},
+		{"line", &kml.LineString{Coordinates: square[:2]}, orb.LineString{{0, 0}, {1, 0}}},
+		{"track", &kml.Track{Coords: square[:2]}, orb.LineString{{0, 0}, {1, 0}}},
+		{
+			"polygon with hole",
+			&kml.Polygon{OuterBoundary: kml.LinearRing{Coordinates: square}, InnerBoundaries: []kml.LinearRing{{Coordinates: square}}},
+			orb.Polygon{{{0, 0}, {1, 0}, {1, 1}, {0, 0}}, {{0, 0}, {1, 0}, {1, 1}, {0, 0}}},
+		},
+		{
+			"points",
+			&kml.MultiGeometry{Geometries: []kml.Geometry{&kml.Point{Coordinates: kml.Coord(1, 2)}, &kml.Point{Coordinates: kml.Coord(3, 4)}}},
+			orb.MultiPoint{{1, 2}, {3, 4}},
+		},
+		{
+			"mixed",
+			&kml.MultiGeometry{Geometries: []kml.Geometry{&kml.Point{Coordinates: kml.Coord(1, 2)}, &kml.LineString{Coordinates: square[:2]}}},
+			orb.Collection{orb.Point{1, 2}, orb.LineString{{0, 0}, {1, 0}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ToOrb(tt.geom)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestFromOrb tests converting orb geometries to KML geometries and back
+func TestFromOrb(t *testing.T) {
+	tests := []orb.Geometry{
+		orb.Point{1, 2},
+		orb.LineString{{0, 0}, {1, 0}},
+		orb.Ring{{0, 0}, {1, 0}, {1, 1}, {0, 0}},
+		orb.Polygon{{{0, 0}, {1, 0}, {1, 1}, {0, 0}}},
+		orb.MultiLineString{{{0, 0}, {1, 0}}, {{2, 2}, {3, 3}}},
+		orb.MultiPolygon{{{{0, 0}, {1, 0}, {1, 1}, {0, 0}}}},
+		orb.Collection{orb.Point{1, 2}, orb.LineString{{0, 0}, {1, 0}}},
+	}
+
+	for _, want := range tests {
+		t.Run(want.GeoJSONType(), func(t *testing.T) {
+			g, err := FromOrb(want)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			got, err := ToOrb(g)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Expected %v, got %v", want, got)
+			}
+		})
+	}
+
+	g, err := FromOrb(orb.Bound{Min: orb.Point{0, 0}, Max: orb.Point{2, 1}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if p, ok := g.(*kml.Polygon); !ok || len(p.OuterBoundary.Coordinates) != 5 {
+		t.Errorf("Expected a closed polygon of the bound, got %+v", g)
+	}
+}
@@ -0,0 +1,171 @@
+package kml
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// OutlineOptions configures Outline.
+type OutlineOptions struct {
+	JSON     bool   // Write the tree as JSON instead of indented text
+	Bounds   bool   // Include the bounding box of each feature
+	MaxDepth int    // Omit features nested deeper than this; 0 for no limit
+	Indent   string // Indentation per level; two spaces if empty
+}
+
+// defaults fills in unset options.
+func (o OutlineOptions) defaults() OutlineOptions {
+	if o.Indent == "" {
+		o.Indent = "  "
+	}
+	return o
+}
+
+// OutlineNode is one feature in the tree returned by OutlineTree.
+type OutlineNode struct {
+	Type        string         `json:"type"`
+	Name        string         `json:"name,omitempty"`
+	ID          string         `json:"id,omitempty"`
+	Geometry    string         `json:"geometry,omitempty"`    // Geometry element of a Placemark
+	Coordinates int            `json:"coordinates,omitempty"` // Coordinates in a Placemark's geometry
+	Counts      map[string]int `json:"counts,omitempty"`      // Features within a container, by type
+	BBox        []float64      `json:"bbox,omitempty"`        // West, south, east, north, with OutlineOptions.Bounds
+	Children    []*OutlineNode `json:"children,omitempty"`
+}
+
+// OutlineTree summarizes the document's feature tree. Containers count all
+// the features beneath them, including those past MaxDepth.
+func (k *KML) OutlineTree(opts OutlineOptions) *OutlineNode {
+	if k.Feature == nil {
+		return nil
+	}
+	node, _, _ := outlineNode(k.Feature, opts.defaults(), 1)
+	return node
+}
+
+// Outline writes the document's feature tree to w with each feature's type,
+// name, id, geometry and counts, as indented text or, with JSON set, as the
+// JSON encoding of OutlineTree:
+//
+//	Document "Survey" id=doc (1 Folder, 3 Placemark)
+//	  Folder "Wells" (2 Placemark)
+//	    Placemark "Well 1" id=w1 Point (1 coordinate)
+func (k *KML) Outline(w io.Writer, opts OutlineOptions) error {
+	opts = opts.defaults()
+	tree := k.OutlineTree(opts)
+	if opts.JSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", opts.Indent)
+		return enc.Encode(tree)
+	}
+
+	var b strings.Builder
+	if tree != nil {
+		writeOutline(&b, tree, opts, 0)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// outlineNode builds the node for f at depth, returning also its bounds
+// and whether it has any.
+func outlineNode(f Feature, opts OutlineOptions, depth int) (*OutlineNode, [4]float64, bool) {
+	node := &OutlineNode{Type: f.featureType(), Name: featureName(f), ID: featureID(f)}
+
+	var bbox [4]float64
+	var hasBounds bool
+	extend := func(b [4]float64) {
+		if !hasBounds {
+			bbox, hasBounds = b, true
+			return
+		}
+		bbox[0], bbox[1] = min(bbox[0], b[0]), min(bbox[1], b[1])
+		bbox[2], bbox[3] = max(bbox[2], b[2]), max(bbox[3], b[3])
+	}
+
+	var children []Feature
+	switch feature := f.(type) {
+	case *Document:
+		children = feature.Features
+	case *Folder:
+		children = feature.Features
+	case *Placemark:
+		if feature.Geometry != nil {
+			node.Geometry = feature.Geometry.geometryType()
+			node.Coordinates = len(getGeometryCoordinates(feature.Geometry))
+		}
+	}
+	if coords := collectCoordinates(f); len(coords) > 0 {
+		sw, ne := BoundsOf(coords)
+		extend([4]float64{sw.Lon, sw.Lat, ne.Lon, ne.Lat})
+	}
+
+	if children != nil {
+		node.Counts = make(map[string]int)
+	}
+	for _, child := range children {
+		c, b, ok := outlineNode(child, opts, depth+1)
+		if ok {
+			extend(b)
+		}
+		node.Counts[c.Type]++
+		for t, n := range c.Counts {
+			node.Counts[t] += n
+		}
+		if opts.MaxDepth <= 0 || depth < opts.MaxDepth {
+			node.Children = append(node.Children, c)
+		}
+	}
+
+	if opts.Bounds && hasBounds {
+		node.BBox = bbox[:]
+	}
+	return node, bbox, hasBounds
+}
+
+// writeOutline writes node and its children as indented text lines.
+func writeOutline(b *strings.Builder, node *OutlineNode, opts OutlineOptions, level int) {
+	b.WriteString(strings.Repeat(opts.Indent, level))
+	b.WriteString(node.Type)
+	if node.Name != "" {
+		b.WriteString(" " + strconv.Quote(node.Name))
+	}
+	if node.ID != "" {
+		b.WriteString(" id=" + node.ID)
+	}
+	if node.Geometry != "" {
+		fmt.Fprintf(b, " %s (%d %s)", node.Geometry, node.Coordinates, plural(node.Coordinates, "coordinate", "coordinates"))
+	}
+	if len(node.Counts) > 0 {
+		types := make([]string, 0, len(node.Counts))
+		for t := range node.Counts {
+			types = append(types, t)
+		}
+		sort.Strings(types)
+		counts := make([]string, len(types))
+		for i, t := range types {
+			counts[i] = strconv.Itoa(node.Counts[t]) + " " + t
+		}
+		b.WriteString(" (" + strings.Join(counts, ", ") + ")")
+	}
+	if node.BBox != nil {
+		fmt.Fprintf(b, " [%g,%g %g,%g]", node.BBox[0], node.BBox[1], node.BBox[2], node.BBox[3])
+	}
+	b.WriteByte('\n')
+
+	for _, child := range node.Children {
+		writeOutline(b, child, opts, level+1)
+	}
+}
+
+// plural returns one if n is 1 and other otherwise.
+func plural(n int, one, other string) string {
+	if n == 1 {
+		return one
+	}
+	return other
+}
@@ -0,0 +1,99 @@
+package kml
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+// outlineDocument returns a small document for outline tests
+func outlineDocument() *KML {
+	k := NewKML()
+	k.Feature = &Document{Name: "Survey", ID: "doc", Features: []Feature{
+		&Folder{Name: "Wells", Features: []Feature{
+			&Placemark{Name: "Well 1", ID: "w1", Geometry: &Point{Coordinates: Coordinate{Lon: 1, Lat: 2}}},
+			&Folder{Name: "Old", Features: []Feature{
+				&Placemark{Name: "Well 0", Geometry: &Point{Coordinates: Coordinate{Lon: -3, Lat: 5}}},
+			}},
+		}},
+		&Placemark{Name: "Road", Geometry: &LineString{Coordinates: []Coordinate{{Lon: 0, Lat: 0}, {Lon: 4, Lat: 1}}}},
+		&NetworkLink{Name: "Tiles"},
+	}}
+	return k
+}
+
+// TestOutline tests the indented text outline
+func TestOutline(t *testing.T) {
+	tests := []struct {
+		name string
+		opts OutlineOptions
+		want string
+	}{
+		{
+			name: "default",
+			want: `Document "Survey" id=doc (2 Folder, 1 NetworkLink, 3 Placemark)
+  Folder "Wells" (1 Folder, 2 Placemark)
+    Placemark "Well 1" id=w1 Point (1 coordinate)
+    Folder "Old" (1 Placemark)
+      Placemark "Well 0" Point (1 coordinate)
+  Placemark "Road" LineString (2 coordinates)
+  NetworkLink "Tiles"
+`,
+		},
+		{
+			name: "bounds and depth",
+			opts: OutlineOptions{Bounds: true, MaxDepth: 2, Indent: "\t"},
+			want: `Document "Survey" id=doc (2 Folder, 1 NetworkLink, 3 Placemark) [-3,0 4,5]
+	Folder "Wells" (1 Folder, 2 Placemark) [-3,2 1,5]
+	Placemark "Road" LineString (2 coordinates) [0,0 4,1]
+	NetworkLink "Tiles"
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := outlineDocument().Outline(&buf, tt.opts); err != nil {
+				t.Fatalf("Outline failed: %v", err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("Expected:\n%s\ngot:\n%s", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestOutlineJSON tests the JSON outline
+func TestOutlineJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := outlineDocument().Outline(&buf, OutlineOptions{JSON: true, Bounds: true}); err != nil {
+		t.Fatalf("Outline failed: %v", err)
+	}
+
+	var tree OutlineNode
+	if err := json.Unmarshal(buf.Bytes(), &tree); err != nil {
+		t.Fatalf("Expected valid JSON, got %v: %s", err, buf.String())
+	}
+	if tree.Type != "Document" || tree.Counts["Placemark"] != 3 || len(tree.Children) != 3 {
+		t.Errorf("Expected the document node, got %+v", tree)
+	}
+	well := tree.Children[0].Children[0]
+	if well.ID != "w1" || well.Geometry != "Point" || well.Coordinates != 1 {
+		t.Errorf("Expected the Well 1 node, got %+v", well)
+	}
+	if want := []float64{1, 2, 1, 2}; len(well.BBox) != 4 || well.BBox[0] != want[0] || well.BBox[3] != want[3] {
+		t.Errorf("Expected bbox %v, got %v", want, well.BBox)
+	}
+	if tree.Children[2].BBox != nil {
+		t.Errorf("Expected no bbox for a NetworkLink, got %v", tree.Children[2].BBox)
+	}
+}
+
+// TestOutlineEmpty tests outlining a document without features
+func TestOutlineEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewKML().Outline(&buf, OutlineOptions{}); err != nil || buf.Len() != 0 {
+		t.Errorf("Expected no output, got %q, %v", buf.String(), err)
+	}
+}
@@ -0,0 +1,317 @@
+package kml
+
+import (
+	"encoding/xml"
+	"math"
+	"strconv"
+)
+
+// GxNamespace is the namespace of Google's gx: KML extensions.
+const GxNamespace = "http://www.google.com/kml/ext/2.2"
+
+// GroundOverlay drapes an image onto the terrain.
+// The image is placed either by a LatLonBox or, for rotated or skewed
+// imagery, by the four corners of a gx:LatLonQuad. When both are set, the
+// LatLonQuad is written and LatLonBox is ignored by Google Earth.
+// It implements the Feature interface.
+type GroundOverlay struct {
+	ID           string
+	Name         string
+	Description  string
+	Visibility   *bool
+	LookAt       *LookAt
+	Camera       *Camera
+	StyleURL     string
+	Region       *Region
+	Color        Color // Tint applied to the image; omitted when zero
+	DrawOrder    int
+	Icon         *Icon
+	Altitude     float64
+	AltitudeMode AltitudeMode
+	LatLonBox    *LatLonBox
+	LatLonQuad   *LatLonQuad
+	Comments     []string // See PreserveComments
+
+	span   *Span        // See RecordSpans
+	images *imageSource // See Image
+}
+
+// LatLonBox bounds a GroundOverlay image. Rotation is the counter-clockwise
+// rotation of the image about its center, in degrees.
+type LatLonBox struct {
+	North    float64 `xml:"north"`
+	South    float64 `xml:"south"`
+	East     float64 `xml:"east"`
+	West     float64 `xml:"west"`
+	Rotation float64 `xml:"rotation,omitempty"`
+}
+
+// LatLonQuad places a GroundOverlay image by its four corners, given
+// counter-clockwise starting with the lower-left corner of the image.
+type LatLonQuad struct {
+	Coordinates Coordinates `xml:"coordinates"`
+}
+
+// featureType implements the Feature interface.
+func (g *GroundOverlay) featureType() string {
+	return "GroundOverlay"
+}
+
+// Hash implements the Feature interface.
+func (g *GroundOverlay) Hash() string {
+	return hashFeature(g)
+}
+
+// MarshalXML implements custom XML marshaling for GroundOverlay.
+func (g *GroundOverlay) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	start.Name.Local = "GroundOverlay"
+
+	if g.ID != "" {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "id"}, Value: g.ID})
+	}
+
+	if err := encodeComments(e, g.Comments); err != nil {
+		return err
+	}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+
+	if g.Name != "" {
+		if err := e.EncodeElement(g.Name, xml.StartElement{Name: xml.Name{Local: "name"}}); err != nil {
+			return err
+		}
+	}
+
+	if g.Visibility != nil {
+		vis := 0
+		if *g.Visibility {
+			vis = 1
+		}
+		if err := e.EncodeElement(vis, xml.StartElement{Name: xml.Name{Local: "visibility"}}); err != nil {
+			return err
+		}
+	}
+
+	if g.Description != "" {
+		if err := encodeDescription(e, g.Description); err != nil {
+			return err
+		}
+	}
+
+	if g.LookAt != nil {
+		if err := e.Encode(g.LookAt); err != nil {
+			return err
+		}
+	}
+
+	if g.Camera != nil {
+		if err := e.Encode(g.Camera); err != nil {
+			return err
+		}
+	}
+
+	if g.StyleURL != "" {
+		if err := e.EncodeElement(g.StyleURL, xml.StartElement{Name: xml.Name{Local: "styleUrl"}}); err != nil {
+			return err
+		}
+	}
+
+	if g.Region != nil {
+		if err := e.Encode(g.Region); err != nil {
+			return err
+		}
+	}
+
+	if g.Color != (Color{}) {
+		if err := e.EncodeElement(g.Color, xml.StartElement{Name: xml.Name{Local: "color"}}); err != nil {
+			return err
+		}
+	}
+
+	if g.DrawOrder != 0 {
+		if err := e.EncodeElement(g.DrawOrder, xml.StartElement{Name: xml.Name{Local: "drawOrder"}}); err != nil {
+			return err
+		}
+	}
+
+	if g.Icon != nil {
+		if err := e.EncodeElement(g.Icon, xml.StartElement{Name: xml.Name{Local: "Icon"}}); err != nil {
+			return err
+		}
+	}
+
+	if g.Altitude != 0 {
+		alt := strconv.FormatFloat(g.Altitude, 'f', -1, 64)
+		if err := e.EncodeElement(alt, xml.StartElement{Name: xml.Name{Local: "altitude"}}); err != nil {
+			return err
+		}
+	}
+
+	if g.AltitudeMode != "" {
+		if err := encodeAltitudeMode(e, g.AltitudeMode); err != nil {
+			return err
+		}
+	}
+
+	if g.LatLonBox != nil {
+		if err := e.EncodeElement(g.LatLonBox, xml.StartElement{Name: xml.Name{Local: "LatLonBox"}}); err != nil {
+			return err
+		}
+	}
+
+	if g.LatLonQuad != nil && !targetVersion(e).preGx() {
+		// The gx prefix is declared on the element itself so the output is
+		// well-formed whatever the root element declares.
+		if err := e.EncodeElement(g.LatLonQuad, gxElement(e, "LatLonQuad")); err != nil {
+			return err
+		}
+	}
+
+	if err := e.EncodeToken(start.End()); err != nil {
+		return err
+	}
+	featureWritten(e)
+	return nil
+}
+
+// UnmarshalXML implements custom XML unmarshaling for GroundOverlay.
+func (g *GroundOverlay) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	g.Comments = takeComments(d)
+	beginSpan(d)
+
+	for _, attr := range start.Attr {
+		if attr.Name.Local == "id" {
+			g.ID = attr.Value
+		}
+	}
+
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return err
+		}
+
+		switch el := token.(type) {
+		case xml.StartElement:
+			switch el.Name.Local {
+			case "name":
+				if err := d.DecodeElement(&g.Name, &el); err != nil {
+					return err
+				}
+			case "description":
+				if err := d.DecodeElement(&g.Description, &el); err != nil {
+					return err
+				}
+			case "visibility":
+				var v int
+				if err := d.DecodeElement(&v, &el); err != nil {
+					return err
+				}
+				vis := flagValue(d, el, v)
+				g.Visibility = &vis
+			case "LookAt":
+				var lookAt LookAt
+				if err := d.DecodeElement(&lookAt, &el); err != nil {
+					return err
+				}
+				g.LookAt = &lookAt
+			case "Camera":
+				var camera Camera
+				if err := d.DecodeElement(&camera, &el); err != nil {
+					return err
+				}
+				g.Camera = &camera
+			case "styleUrl":
+				if err := d.DecodeElement(&g.StyleURL, &el); err != nil {
+					return err
+				}
+			case "Region":
+				var region Region
+				if err := d.DecodeElement(&region, &el); err != nil {
+					return err
+				}
+				g.Region = &region
+			case "color":
+				if err := d.DecodeElement(&g.Color, &el); err != nil {
+					return err
+				}
+			case "drawOrder":
+				if err := d.DecodeElement(&g.DrawOrder, &el); err != nil {
+					return err
+				}
+			case "Icon":
+				var icon Icon
+				if err := d.DecodeElement(&icon, &el); err != nil {
+					return err
+				}
+				g.Icon = &icon
+			case "altitude":
+				if err := d.DecodeElement(&g.Altitude, &el); err != nil {
+					return err
+				}
+			case "altitudeMode":
+				if err := d.DecodeElement(&g.AltitudeMode, &el); err != nil {
+					return err
+				}
+			case "LatLonBox":
+				var box LatLonBox
+				if err := d.DecodeElement(&box, &el); err != nil {
+					return err
+				}
+				g.LatLonBox = &box
+			case "LatLonQuad":
+				var quad LatLonQuad
+				if err := d.DecodeElement(&quad, &el); err != nil {
+					return err
+				}
+				g.LatLonQuad = &quad
+			default:
+				if err := skipElement(d, el); err != nil {
+					return err
+				}
+			}
+		case xml.EndElement:
+			featureParsed(d, g)
+			return nil
+		}
+	}
+}
+
+// Corners returns the corners of the area the overlay image covers,
+// counter-clockwise from the lower-left corner of the image: the
+// LatLonQuad coordinates if set, otherwise the LatLonBox corners rotated
+// by its Rotation. It returns nil if neither is set.
+func (g *GroundOverlay) Corners() []Coordinate {
+	if g.LatLonQuad != nil {
+		return append([]Coordinate(nil), g.LatLonQuad.Coordinates...)
+	}
+	if g.LatLonBox == nil {
+		return nil
+	}
+
+	b := g.LatLonBox
+	corners := []Coordinate{
+		{Lon: b.West, Lat: b.South},
+		{Lon: b.East, Lat: b.South},
+		{Lon: b.East, Lat: b.North},
+		{Lon: b.West, Lat: b.North},
+	}
+	if b.Rotation == 0 {
+		return corners
+	}
+
+	cx, cy := (b.East+b.West)/2, (b.North+b.South)/2
+	proj := newLocalProjection(corners)
+	center := proj.forward(Coordinate{Lon: cx, Lat: cy})
+	sin, cos := math.Sincos(toRadians(b.Rotation))
+	for i, c := range corners {
+		p := proj.forward(c)
+		dx, dy := p.x-center.x, p.y-center.y
+		corners[i] = proj.inverse(planarPoint{
+			x: center.x + dx*cos - dy*sin,
+			y: center.y + dx*sin + dy*cos,
+		})
+	}
+	return corners
+}
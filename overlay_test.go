@@ -0,0 +1,175 @@
+package kml
+
+import (
+	"strings"
+	"testing"
+)
+
+const groundOverlayKML = `<?xml version="1.0" encoding="UTF-8"?>
+<kml xmlns="http://www.opengis.net/kml/2.2" xmlns:gx="http://www.google.com/kml/ext/2.2">
+  <Document>
+    <GroundOverlay id="survey">
+      <name>Drone survey</name>
+      <color>80ffffff</color>
+      <drawOrder>2</drawOrder>
+      <Icon><href>files/stitched.jpg</href></Icon>
+      <gx:LatLonQuad>
+        <coordinates>
+          -122.10,37.40 -122.05,37.41 -122.06,37.45 -122.11,37.44
+        </coordinates>
+      </gx:LatLonQuad>
+    </GroundOverlay>
+    <GroundOverlay>
+      <name>Basemap</name>
+      <Icon><href>http://example.com/map.png</href></Icon>
+      <altitude>120.5</altitude>
+      <altitudeMode>absolute</altitudeMode>
+      <LatLonBox>
+        <north>38</north><south>37</south><east>-121</east><west>-123</west>
+        <rotation>30</rotation>
+      </LatLonBox>
+    </GroundOverlay>
+  </Document>
+</kml>`
+
+// TestGroundOverlayParse tests parsing LatLonBox and gx:LatLonQuad overlays
+func TestGroundOverlayParse(t *testing.T) {
+	k, err := Parse(strings.NewReader(groundOverlayKML))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	doc := k.Feature.(*Document)
+	if len(doc.Features) != 2 {
+		t.Fatalf("Expected 2 overlays, got %d features", len(doc.Features))
+	}
+
+	quad := doc.Features[0].(*GroundOverlay)
+	if quad.ID != "survey" || quad.Name != "Drone survey" || quad.DrawOrder != 2 {
+		t.Errorf("Unexpected overlay metadata: %+v", quad)
+	}
+	if quad.Color != RGBA(255, 255, 255, 128) {
+		t.Errorf("Expected half-transparent tint, got %v", quad.Color.Hex())
+	}
+	if quad.Icon == nil || quad.Icon.Href != "files/stitched.jpg" {
+		t.Errorf("Unexpected icon: %+v", quad.Icon)
+	}
+	if quad.LatLonQuad == nil || len(quad.LatLonQuad.Coordinates) != 4 {
+		t.Fatalf("Expected 4 quad corners, got %+v", quad.LatLonQuad)
+	}
+	if quad.LatLonBox != nil {
+		t.Error("Expected no LatLonBox")
+	}
+
+	box := doc.Features[1].(*GroundOverlay)
+	if box.LatLonBox == nil || box.LatLonBox.North != 38 || box.LatLonBox.West != -123 || box.LatLonBox.Rotation != 30 {
+		t.Errorf("Unexpected LatLonBox: %+v", box.LatLonBox)
+	}
+	if box.Altitude != 120.5 || box.AltitudeMode != AltitudeModeAbsolute {
+		t.Errorf("Unexpected altitude: %v %v", box.Altitude, box.AltitudeMode)
+	}
+}
+
+// TestGroundOverlayRoundTrip tests that overlays survive writing and re-parsing
+func TestGroundOverlayRoundTrip(t *testing.T) {
+	k, err := Parse(strings.NewReader(groundOverlayKML))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+
+	data, err := k.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes() error: %v", err)
+	}
+	out := string(data)
+	if !strings.Contains(out, `<gx:LatLonQuad xmlns:gx="http://www.google.com/kml/ext/2.2">`) {
+		t.Errorf("Expected self-declaring gx:LatLonQuad, got:\n%s", out)
+	}
+
+	again, err := ParseBytes(data)
+	if err != nil {
+		t.Fatalf("ParseBytes() error: %v", err)
+	}
+	doc := again.Feature.(*Document)
+	if len(doc.Features) != 2 {
+		t.Fatalf("Expected 2 overlays after round trip, got %d", len(doc.Features))
+	}
+	if doc.Features[0].Hash() != k.Feature.(*Document).Features[0].Hash() {
+		t.Error("Expected quad overlay to round-trip unchanged")
+	}
+	if doc.Features[1].Hash() != k.Feature.(*Document).Features[1].Hash() {
+		t.Error("Expected box overlay to round-trip unchanged")
+	}
+}
+
+// TestGroundOverlayCorners tests corner computation for boxes and quads
+func TestGroundOverlayCorners(t *testing.T) {
+	if c := (&GroundOverlay{}).Corners(); c != nil {
+		t.Errorf("Expected no corners, got %v", c)
+	}
+
+	quad := &GroundOverlay{LatLonQuad: &LatLonQuad{Coordinates: []Coordinate{Coord(0, 0), Coord(1, 0), Coord(1, 1), Coord(0, 1)}}}
+	if c := quad.Corners(); len(c) != 4 || c[2] != Coord(1, 1) {
+		t.Errorf("Expected quad corners, got %v", c)
+	}
+
+	box := &GroundOverlay{LatLonBox: &LatLonBox{North: 1, South: -1, East: 1, West: -1}}
+	want := []Coordinate{Coord(-1, -1), Coord(1, -1), Coord(1, 1), Coord(-1, 1)}
+	got := box.Corners()
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Corner %d: expected %v, got %v", i, want[i], got[i])
+		}
+	}
+
+	box.LatLonBox.Rotation = 90
+	got = box.Corners()
+	// Rotating counter-clockwise by 90° moves the lower-left corner to the lower right.
+	if !floatNear(got[0].Lon, 1, 1e-2) || !floatNear(got[0].Lat, -1, 1e-2) {
+		t.Errorf("Expected rotated lower-left corner near (1,-1), got %v", got[0])
+	}
+}
+
+// TestGroundOverlayIntegration tests overlays in traversal and reference helpers
+func TestGroundOverlayIntegration(t *testing.T) {
+	overlay := &GroundOverlay{
+		ID:        "img",
+		StyleURL:  "#missing",
+		Icon:      &Icon{Href: "http://example.com/a.png"},
+		LatLonBox: &LatLonBox{North: 10, South: 5, East: 20, West: 15},
+	}
+	k := NewKML()
+	k.Feature = &Folder{Features: []Feature{overlay, pointPlacemark("p", 0, 0)}}
+
+	if k.FindByID("img") != overlay {
+		t.Error("Expected FindByID to find the overlay")
+	}
+
+	sw, ne := k.Bounds()
+	if sw != Coord(0, 0) || ne != Coord(20, 10) {
+		t.Errorf("Expected bounds to include the overlay, got %v %v", sw, ne)
+	}
+
+	assets := k.Assets()
+	if len(assets) != 1 || assets[0].Kind != AssetOverlay || assets[0].Features[0] != overlay {
+		t.Errorf("Expected overlay asset, got %+v", assets)
+	}
+
+	refs := k.CheckReferences()
+	if len(refs) != 1 || refs[0].Element != "GroundOverlay" {
+		t.Errorf("Expected dangling GroundOverlay styleUrl, got %+v", refs)
+	}
+
+	k.RewriteHrefs(func(s string) string { return strings.Replace(s, "http:", "https:", 1) })
+	if overlay.Icon.Href != "https://example.com/a.png" {
+		t.Errorf("Expected rewritten image href, got %q", overlay.Icon.Href)
+	}
+
+	obj, err := ParseFragment([]byte(`<GroundOverlay><name>x</name></GroundOverlay>`))
+	if err != nil {
+		t.Fatalf("ParseFragment() error: %v", err)
+	}
+	if g, ok := obj.(*GroundOverlay); !ok || g.Name != "x" {
+		t.Errorf("Expected *GroundOverlay fragment, got %T", obj)
+	}
+}
@@ -0,0 +1,140 @@
+package kml
+
+import (
+	"fmt"
+	"image"
+	_ "image/gif"  // register GIF for image.Decode
+	_ "image/jpeg" // register JPEG for image.Decode
+	_ "image/png"  // register PNG for image.Decode
+	"io"
+	"io/fs"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// Fetcher loads the resource an href refers to. It is given the href as it
+// appears in the document.
+type Fetcher interface {
+	Fetch(href string) (io.ReadCloser, error)
+}
+
+// FetcherFunc adapts an ordinary function to the Fetcher interface.
+type FetcherFunc func(href string) (io.ReadCloser, error)
+
+// Fetch calls f(href).
+func (f FetcherFunc) Fetch(href string) (io.ReadCloser, error) {
+	return f(href)
+}
+
+// FSFetcher returns a Fetcher that opens relative hrefs in fsys. Images
+// kept beside a KML file can be loaded with
+//
+//	kml.ParseFile("site/doc.kml", kml.WithFetcher(kml.FSFetcher(os.DirFS("site"))))
+func FSFetcher(fsys fs.FS) Fetcher {
+	return FetcherFunc(func(href string) (io.ReadCloser, error) {
+		return fsys.Open(path.Clean(unescapeHref(href)))
+	})
+}
+
+// WithFetcher makes the Image methods of parsed overlays load images with
+// f, for images that are not packaged in the KMZ archive being parsed or
+// when the document is not a KMZ at all. It might read from disk or
+// download over HTTP; the package does neither on its own.
+func WithFetcher(f Fetcher) ParseOption {
+	return func(c *parseConfig) {
+		c.fetcher = f
+	}
+}
+
+// imageSource is where the overlays of a parsed document load images from.
+type imageSource struct {
+	archive *kmzArchive // nil unless the document came from a KMZ
+	fetcher Fetcher     // nil unless WithFetcher was given
+}
+
+// attachImages lets the overlays of k load their images from src.
+func (k *KML) attachImages(src *imageSource) {
+	k.Walk(func(f Feature) error {
+		switch overlay := f.(type) {
+		case *GroundOverlay:
+			overlay.images = src
+		case *ScreenOverlay:
+			overlay.images = src
+		}
+		return nil
+	})
+}
+
+// Image decodes the image of the overlay: the file its Icon refers to in
+// the KMZ archive it was parsed from, resolved relative to the document
+// within the archive, or else whatever the Fetcher given with WithFetcher
+// returns for the href. PNG, JPEG and GIF images are supported. It fails
+// with ErrNoImage if the overlay has no Icon href, and with
+// ErrImageNotFound if neither source has the image.
+func (g *GroundOverlay) Image() (image.Image, error) {
+	return g.images.decode(g.Icon)
+}
+
+// Image decodes the image of the overlay, as GroundOverlay.Image does.
+func (s *ScreenOverlay) Image() (image.Image, error) {
+	return s.images.decode(s.Icon)
+}
+
+// decode loads and decodes the image of icon.
+func (src *imageSource) decode(icon *Icon) (image.Image, error) {
+	if icon == nil || icon.Href == "" {
+		return nil, ErrNoImage
+	}
+	rc, err := src.open(icon.Href)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	img, _, err := image.Decode(rc)
+	if err != nil {
+		return nil, fmt.Errorf("kml: error decoding image %s: %w", icon.Href, err)
+	}
+	return img, nil
+}
+
+// open opens href in the archive, or else with the fetcher.
+func (src *imageSource) open(href string) (io.ReadCloser, error) {
+	if src == nil {
+		return nil, fmt.Errorf("%w: %s", ErrImageNotFound, href)
+	}
+	if src.archive != nil {
+		if f, err := src.archive.open(href); err == nil {
+			return f, nil
+		}
+	}
+	if src.fetcher == nil {
+		return nil, fmt.Errorf("%w: %s", ErrImageNotFound, href)
+	}
+	rc, err := src.fetcher.Fetch(href)
+	if err != nil {
+		return nil, fmt.Errorf("kml: error fetching image %s: %w", href, err)
+	}
+	return rc, nil
+}
+
+// open opens the archive file a relative href in the document refers to.
+// Hrefs with a scheme, such as http: URLs, are never in the archive.
+func (a *kmzArchive) open(href string) (io.ReadCloser, error) {
+	if u, err := url.Parse(href); err != nil || u.Scheme != "" || strings.HasPrefix(href, "/") {
+		return nil, fs.ErrNotExist
+	}
+	name := path.Join(path.Dir(a.doc), unescapeHref(href))
+	return a.zr.Open(name)
+}
+
+// unescapeHref decodes the percent escapes of a relative href, such as the
+// %20 of a file name with spaces, leaving it as written if it has none
+// that are valid.
+func unescapeHref(href string) string {
+	if s, err := url.PathUnescape(href); err == nil {
+		return s
+	}
+	return href
+}
@@ -0,0 +1,171 @@
+package kml
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// pngBytes encodes a w×h image filled with c as PNG.
+func pngBytes(t *testing.T, w, h int, c color.Color) string {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("png encode failed: %v", err)
+	}
+	return buf.String()
+}
+
+// overlayKML returns a document with a GroundOverlay and a ScreenOverlay
+// showing the given images.
+func overlayKML(ground, screen string) string {
+	return `<kml xmlns="http://www.opengis.net/kml/2.2"><Document>
+<GroundOverlay><name>ground</name><Icon><href>` + ground + `</href></Icon>
+<LatLonBox><north>1</north><south>0</south><east>1</east><west>0</west></LatLonBox></GroundOverlay>
+<ScreenOverlay><name>screen</name><Icon><href>` + screen + `</href></Icon></ScreenOverlay>
+</Document></kml>`
+}
+
+// parsedOverlays returns the two overlays of a document from overlayKML.
+func parsedOverlays(t *testing.T, data []byte, opts ...ParseOption) (*GroundOverlay, *ScreenOverlay) {
+	t.Helper()
+	k, err := ParseBytes(data, opts...)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	doc := k.Feature.(*Document)
+	return doc.Features[0].(*GroundOverlay), doc.Features[1].(*ScreenOverlay)
+}
+
+// TestOverlayImageFromArchive tests loading overlay images packaged in a KMZ
+func TestOverlayImageFromArchive(t *testing.T) {
+	red := pngBytes(t, 4, 2, color.RGBA{255, 0, 0, 255})
+	blue := pngBytes(t, 1, 3, color.RGBA{0, 0, 255, 255})
+
+	tests := []struct {
+		name  string
+		files []string
+	}{
+		{"root", []string{
+			"doc.kml", overlayKML("files/red.png", "blue%20logo.png"),
+			"files/red.png", red,
+			"blue logo.png", blue,
+		}},
+		{"nested document", []string{
+			"site/map.kml", overlayKML("./files/red.png", "../blue logo.png"),
+			"site/files/red.png", red,
+			"blue logo.png", blue,
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ground, screen := parsedOverlays(t, zipBytes(t, tt.files...))
+
+			img, err := ground.Image()
+			if err != nil {
+				t.Fatalf("GroundOverlay.Image failed: %v", err)
+			}
+			if b := img.Bounds(); b.Dx() != 4 || b.Dy() != 2 {
+				t.Errorf("Expected 4x2 ground image, got %dx%d", b.Dx(), b.Dy())
+			}
+			if r, _, _, _ := img.At(0, 0).RGBA(); r != 0xffff {
+				t.Errorf("Expected red pixel, got %v", img.At(0, 0))
+			}
+
+			img, err = screen.Image()
+			if err != nil {
+				t.Fatalf("ScreenOverlay.Image failed: %v", err)
+			}
+			if b := img.Bounds(); b.Dx() != 1 || b.Dy() != 3 {
+				t.Errorf("Expected 1x3 screen image, got %dx%d", b.Dx(), b.Dy())
+			}
+		})
+	}
+}
+
+// TestOverlayImageFetcher tests falling back to the Fetcher given with WithFetcher
+func TestOverlayImageFetcher(t *testing.T) {
+	red := pngBytes(t, 2, 2, color.RGBA{255, 0, 0, 255})
+	var fetched []string
+	fetcher := FetcherFunc(func(href string) (io.ReadCloser, error) {
+		fetched = append(fetched, href)
+		if href == "missing.png" {
+			return nil, errors.New("404")
+		}
+		return io.NopCloser(strings.NewReader(red)), nil
+	})
+
+	data := zipBytes(t,
+		"doc.kml", overlayKML("http://example.com/red.png", "missing.png"),
+		"http:/example.com/red.png", "not an image",
+	)
+	ground, screen := parsedOverlays(t, data, WithFetcher(fetcher))
+
+	if _, err := ground.Image(); err != nil {
+		t.Errorf("Expected image from fetcher, got %v", err)
+	}
+	if _, err := screen.Image(); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("Expected fetch error, got %v", err)
+	}
+	if len(fetched) != 2 || fetched[0] != "http://example.com/red.png" || fetched[1] != "missing.png" {
+		t.Errorf("Expected both hrefs fetched, got %v", fetched)
+	}
+}
+
+// TestOverlayImageFSFetcher tests loading images beside a plain KML file
+func TestOverlayImageFSFetcher(t *testing.T) {
+	fsys := fstest.MapFS{
+		"img/red.png": {Data: []byte(pngBytes(t, 3, 3, color.RGBA{255, 0, 0, 255}))},
+	}
+	ground, screen := parsedOverlays(t, []byte(overlayKML("./img/red.png", "img/none.png")), WithFetcher(FSFetcher(fsys)))
+
+	img, err := ground.Image()
+	if err != nil {
+		t.Fatalf("Image failed: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 3 {
+		t.Errorf("Expected 3 pixel wide image, got %d", b.Dx())
+	}
+	if _, err := screen.Image(); err == nil {
+		t.Error("Expected error for missing file")
+	}
+}
+
+// TestOverlayImageErrors tests the errors of overlays without a loadable image
+func TestOverlayImageErrors(t *testing.T) {
+	ground, screen := parsedOverlays(t, zipBytes(t,
+		"doc.kml", overlayKML("bad.png", "absent.png"),
+		"bad.png", "not an image",
+	))
+	if _, err := ground.Image(); err == nil || !strings.Contains(err.Error(), "decoding image bad.png") {
+		t.Errorf("Expected decode error, got %v", err)
+	}
+	if _, err := screen.Image(); !errors.Is(err, ErrImageNotFound) {
+		t.Errorf("Expected ErrImageNotFound, got %v", err)
+	}
+
+	ground, _ = parsedOverlays(t, []byte(overlayKML("red.png", "red.png")))
+	if _, err := ground.Image(); !errors.Is(err, ErrImageNotFound) {
+		t.Errorf("Expected ErrImageNotFound without archive or fetcher, got %v", err)
+	}
+
+	if _, err := (&GroundOverlay{}).Image(); !errors.Is(err, ErrNoImage) {
+		t.Errorf("Expected ErrNoImage, got %v", err)
+	}
+	if _, err := (&ScreenOverlay{Icon: &Icon{}}).Image(); !errors.Is(err, ErrNoImage) {
+		t.Errorf("Expected ErrNoImage for empty href, got %v", err)
+	}
+}
@@ -0,0 +1,140 @@
+package kml
+
+// Page returns a document holding the placemarks of k from offset up to
+// offset+limit, in the order Placemarks returns them, for serving large
+// datasets to clients a page at a time. If limit is not positive, every
+// placemark from offset on is included. An offset past the last placemark
+// yields a Document with no features.
+//
+// The Folders and Documents containing the selected placemarks are kept,
+// with their other children removed, so styles inherited from containers
+// still apply. Every Style and StyleMap the page references, directly or
+// through a StyleMap, and every Schema its SchemaData references is copied
+// to the root Document, and nested Documents are emptied of theirs. The
+// containers are copies, but the placemarks are shared with k.
+func (k *KML) Page(offset, limit int) *KML {
+	pms := k.Placemarks()
+	offset = max(offset, 0)
+	end := len(pms)
+	if limit > 0 && limit < end-offset {
+		end = offset + limit
+	}
+	selected := make(map[*Placemark]bool)
+	for _, pm := range pms[min(offset, end):end] {
+		selected[pm] = true
+	}
+
+	var root *Document
+	switch f := pruneFeature(k.Feature, selected).(type) {
+	case *Document:
+		root = f
+	case nil:
+		root = &Document{}
+		if src, ok := k.Feature.(*Document); ok {
+			root.ID, root.Name, root.Description = src.ID, src.Name, src.Description
+		}
+	default:
+		root = &Document{Features: []Feature{f}}
+	}
+
+	page := NewKML()
+	page.Feature = root
+	root.Styles, root.StyleMaps, root.Schemas = k.referencedDefinitions(page)
+	return page
+}
+
+// pruneFeature returns f with only the selected placemarks and the
+// containers leading to them, or nil if none are below f. Containers are
+// copied; Documents lose their shared styles and schemas.
+func pruneFeature(f Feature, selected map[*Placemark]bool) Feature {
+	switch feature := f.(type) {
+	case *Placemark:
+		if selected[feature] {
+			return feature
+		}
+	case *Document:
+		children := pruneFeatures(feature.Features, selected)
+		if children == nil {
+			return nil
+		}
+		doc := *feature
+		doc.Styles, doc.StyleMaps, doc.Schemas = nil, nil, nil
+		doc.Features = children
+		return &doc
+	case *Folder:
+		children := pruneFeatures(feature.Features, selected)
+		if children == nil {
+			return nil
+		}
+		folder := *feature
+		folder.Features = children
+		return &folder
+	}
+	return nil
+}
+
+// pruneFeatures applies pruneFeature to each feature, returning nil if
+// none remain.
+func pruneFeatures(features []Feature, selected map[*Placemark]bool) []Feature {
+	var kept []Feature
+	for _, f := range features {
+		if pruned := pruneFeature(f, selected); pruned != nil {
+			kept = append(kept, pruned)
+		}
+	}
+	return kept
+}
+
+// referencedDefinitions returns copies of the shared styles, style maps and
+// schemas of k that the features of page reference, in the order their
+// references are first found.
+func (k *KML) referencedDefinitions(page *KML) ([]Style, []StyleMap, []Schema) {
+	idx := newStyleIndex(k)
+	schemas := make(map[string]*Schema)
+	k.Walk(func(f Feature) error {
+		if doc, ok := f.(*Document); ok {
+			for i := range doc.Schemas {
+				if id := doc.Schemas[i].ID; id != "" && schemas[id] == nil {
+					schemas[id] = &doc.Schemas[i]
+				}
+			}
+		}
+		return nil
+	})
+
+	var styles []Style
+	var styleMaps []StyleMap
+	seen := make(map[string]bool)
+	var addStyle func(url string)
+	addStyle = func(url string) {
+		id, ok := localFragment(url)
+		if !ok || seen[id] {
+			return
+		}
+		seen[id] = true
+		if s, ok := idx.styles[id]; ok {
+			styles = append(styles, *s)
+		} else if sm, ok := idx.styleMaps[id]; ok {
+			styleMaps = append(styleMaps, *sm)
+			for _, pair := range sm.Pairs {
+				addStyle(pair.StyleURL)
+			}
+		}
+	}
+
+	var schemaList []Schema
+	seenSchemas := make(map[string]bool)
+	page.Walk(func(f Feature) error {
+		addStyle(styleURLOf(f))
+		if pm, ok := f.(*Placemark); ok && pm.ExtendedData != nil {
+			for _, sd := range pm.ExtendedData.SchemaData {
+				if id, ok := localFragment(sd.SchemaURL); ok && !seenSchemas[id] && schemas[id] != nil {
+					seenSchemas[id] = true
+					schemaList = append(schemaList, *schemas[id])
+				}
+			}
+		}
+		return nil
+	})
+	return styles, styleMaps, schemaList
+}
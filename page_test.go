@@ -0,0 +1,114 @@
+package kml
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+// pagedDocument returns a document of ten placemarks split across two
+// folders, styled directly, through a StyleMap and through their folder
+func pagedDocument() *KML {
+	doc := &Document{
+		Name:   "Sites",
+		Styles: []Style{{ID: "red"}, {ID: "blue"}, {ID: "green"}, {ID: "unused"}},
+		StyleMaps: []StyleMap{{ID: "map", Pairs: []Pair{
+			{Key: "normal", StyleURL: "#blue"},
+			{Key: "highlight", StyleURL: "#red"},
+		}}},
+		Schemas: []Schema{{ID: "site"}},
+	}
+	first := &Folder{Name: "First", StyleURL: "#green"}
+	second := &Folder{Name: "Second"}
+	for i := 0; i < 10; i++ {
+		pm := &Placemark{Name: fmt.Sprintf("P%d", i)}
+		switch i {
+		case 2:
+			pm.StyleURL = "#red"
+		case 6:
+			pm.StyleURL = "#map"
+			pm.ExtendedData = &ExtendedData{SchemaData: []SchemaData{{SchemaURL: "#site"}}}
+		}
+		if i < 5 {
+			first.Features = append(first.Features, pm)
+		} else {
+			second.Features = append(second.Features, pm)
+		}
+	}
+	doc.Features = []Feature{first, second}
+
+	k := NewKML()
+	k.Feature = doc
+	return k
+}
+
+// TestPage tests selecting windows of placemarks
+func TestPage(t *testing.T) {
+	tests := []struct {
+		name          string
+		offset, limit int
+		placemarks    []string
+		styles        []string
+		styleMaps     int
+		schemas       int
+	}{
+		{"first page", 0, 3, []string{"P0", "P1", "P2"}, []string{"green", "red"}, 0, 0},
+		{"across folders", 4, 3, []string{"P4", "P5", "P6"}, []string{"green", "blue", "red"}, 1, 1},
+		{"last partial page", 8, 5, []string{"P8", "P9"}, nil, 0, 0},
+		{"no limit", 7, 0, []string{"P7", "P8", "P9"}, nil, 0, 0},
+		{"huge limit", 7, math.MaxInt, []string{"P7", "P8", "P9"}, nil, 0, 0},
+		{"past end", 20, 5, nil, nil, 0, 0},
+	}
+
+	k := pagedDocument()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := k.Page(tt.offset, tt.limit)
+			doc := page.Feature.(*Document)
+			if doc.Name != "Sites" {
+				t.Errorf("Expected document name Sites, got %q", doc.Name)
+			}
+
+			pms := page.Placemarks()
+			if len(pms) != len(tt.placemarks) {
+				t.Fatalf("Expected %d placemarks, got %d", len(tt.placemarks), len(pms))
+			}
+			for i, pm := range pms {
+				if pm.Name != tt.placemarks[i] {
+					t.Errorf("Expected placemark %s, got %s", tt.placemarks[i], pm.Name)
+				}
+			}
+
+			if len(doc.Styles) != len(tt.styles) {
+				t.Fatalf("Expected styles %v, got %d styles", tt.styles, len(doc.Styles))
+			}
+			for i, s := range doc.Styles {
+				if s.ID != tt.styles[i] {
+					t.Errorf("Expected style %s, got %s", tt.styles[i], s.ID)
+				}
+			}
+			if len(doc.StyleMaps) != tt.styleMaps || len(doc.Schemas) != tt.schemas {
+				t.Errorf("Expected %d style maps and %d schemas, got %d and %d", tt.styleMaps, tt.schemas, len(doc.StyleMaps), len(doc.Schemas))
+			}
+			if refs := page.CheckReferences(); refs != nil {
+				t.Errorf("Expected all references to resolve, got %v", refs)
+			}
+		})
+	}
+
+	if len(k.Placemarks()) != 10 || len(k.Feature.(*Document).Styles) != 4 {
+		t.Error("Expected the source document to be unchanged")
+	}
+}
+
+// TestPageInheritedStyle tests that container styles still apply on a page
+func TestPageInheritedStyle(t *testing.T) {
+	k := pagedDocument()
+	k.Feature.(*Document).Styles[2].LineStyle = &LineStyle{Width: 3}
+
+	page := k.Page(1, 1)
+	pm := page.Placemarks()[0]
+	if s := page.EffectiveStyle(pm, ""); s == nil || s.LineStyle == nil || s.LineStyle.Width != 3 {
+		t.Errorf("Expected the folder style to apply, got %+v", s)
+	}
+}
@@ -0,0 +1,417 @@
+package kml
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+// WriteParquet writes the placemarks of the document as a GeoParquet file
+// with one row per placemark, in document order: a string column for each
+// of columns, as produced by Records, followed by a "geometry" column
+// holding each placemark's geometry as WKB, or null for placemarks
+// without one. The file needs no further conversion for pandas, GeoPandas,
+// pyarrow, DuckDB or Spark:
+//
+//	err := k.WriteParquet(f, kml.ColumnName(), kml.ColumnData("population"))
+//
+//	-- DuckDB
+//	SELECT name, ST_GeomFromWKB(geometry) FROM 'cities.parquet';
+//
+// The file holds a single uncompressed row group, and its GeoParquet
+// metadata records the geometry types and bounding box of the data in
+// WGS84 longitude and latitude.
+func (k *KML) WriteParquet(w io.Writer, columns ...ColumnSpec) error {
+	header, err := columnHeaders(columns)
+	if err != nil {
+		return err
+	}
+	seen := map[string]bool{parquetGeometry: true}
+	for _, h := range header {
+		if seen[h] {
+			return fmt.Errorf("kml: duplicate column %q", h)
+		}
+		seen[h] = true
+	}
+
+	values := make([][][]byte, len(columns)+1)
+	var geo geoColumn
+	var encodeErr error
+	err = k.eachRecord(columns, func(pm *Placemark, row []string) {
+		for i, v := range row {
+			values[i] = append(values[i], []byte(v))
+		}
+		var wkb []byte
+		if pm.Geometry != nil && encodeErr == nil {
+			if wkb, encodeErr = encodeWKB(pm.Geometry); encodeErr == nil {
+				geo.add(pm.Geometry, wkb)
+			}
+		}
+		values[len(columns)] = append(values[len(columns)], wkb)
+	})
+	if err != nil {
+		return err
+	}
+	if encodeErr != nil {
+		return encodeErr
+	}
+
+	pw := &parquetWriter{w: w}
+	pw.write([]byte(parquetMagic))
+	rows := len(values[len(columns)])
+	var chunks []parquetChunk
+	if rows > 0 {
+		for i, col := range values {
+			chunks = append(chunks, pw.chunk(col, i == len(columns)))
+		}
+	}
+
+	meta, err := json.Marshal(geoMetadata{
+		Version:       "1.1.0",
+		PrimaryColumn: parquetGeometry,
+		Columns:       map[string]geoColumn{parquetGeometry: geo.finish()},
+	})
+	if err != nil {
+		return fmt.Errorf("kml: error encoding GeoParquet metadata: %w", err)
+	}
+	footer := parquetFooter(append(header, parquetGeometry), rows, chunks, meta)
+	pw.write(footer)
+	pw.write(binary.LittleEndian.AppendUint32(nil, uint32(len(footer))))
+	pw.write([]byte(parquetMagic))
+	if pw.err != nil {
+		return fmt.Errorf("kml: error writing Parquet: %w", pw.err)
+	}
+	return nil
+}
+
+const (
+	parquetMagic    = "PAR1"
+	parquetGeometry = "geometry"
+)
+
+// Parquet enumerations, from the parquet.thrift definitions.
+const (
+	parquetByteArray     = 6 // Type BYTE_ARRAY
+	parquetRequired      = 0 // FieldRepetitionType REQUIRED
+	parquetOptional      = 1 // FieldRepetitionType OPTIONAL
+	parquetUTF8          = 0 // ConvertedType UTF8
+	parquetPlain         = 0 // Encoding PLAIN
+	parquetRLE           = 3 // Encoding RLE
+	parquetUncompressed  = 0 // CompressionCodec UNCOMPRESSED
+	parquetDataPage      = 0 // PageType DATA_PAGE
+	parquetFormatVersion = 1
+	parquetCreatedBy     = "go-kml"
+)
+
+// parquetWriter writes a Parquet file, tracking the offset and the first
+// error.
+type parquetWriter struct {
+	w      io.Writer
+	offset int64
+	err    error
+}
+
+// write writes data unless an earlier write failed.
+func (pw *parquetWriter) write(data []byte) {
+	if pw.err != nil {
+		return
+	}
+	n, err := pw.w.Write(data)
+	pw.offset += int64(n)
+	pw.err = err
+}
+
+// parquetChunk locates a written column chunk.
+type parquetChunk struct {
+	offset int64
+	size   int64
+	values int
+}
+
+// chunk writes a column chunk of one PLAIN-encoded data page. Nil values
+// of an optional column are nulls.
+func (pw *parquetWriter) chunk(values [][]byte, optional bool) parquetChunk {
+	var page []byte
+	if optional {
+		// Definition levels: 1 for a value, 0 for a null, as RLE runs of
+		// one-byte values after the length of the runs.
+		var levels []byte
+		for i := 0; i < len(values); {
+			j := i
+			for j < len(values) && (values[j] == nil) == (values[i] == nil) {
+				j++
+			}
+			levels = binary.AppendUvarint(levels, uint64(j-i)<<1)
+			if values[i] == nil {
+				levels = append(levels, 0)
+			} else {
+				levels = append(levels, 1)
+			}
+			i = j
+		}
+		page = binary.LittleEndian.AppendUint32(page, uint32(len(levels)))
+		page = append(page, levels...)
+	}
+	for _, v := range values {
+		if optional && v == nil {
+			continue
+		}
+		page = binary.LittleEndian.AppendUint32(page, uint32(len(v)))
+		page = append(page, v...)
+	}
+
+	var t thriftWriter
+	t.i32(1, parquetDataPage)
+	t.i32(2, int32(len(page)))
+	t.i32(3, int32(len(page)))
+	t.beginStruct(5)
+	t.i32(1, int32(len(values)))
+	t.i32(2, parquetPlain)
+	t.i32(3, parquetRLE)
+	t.i32(4, parquetRLE)
+	t.endStruct()
+	t.stop()
+
+	c := parquetChunk{offset: pw.offset, values: len(values)}
+	pw.write(t.buf)
+	pw.write(page)
+	c.size = pw.offset - c.offset
+	return c
+}
+
+// parquetFooter encodes the FileMetaData of a file with one row group of
+// the given string columns, the last being the WKB geometry.
+func parquetFooter(names []string, rows int, chunks []parquetChunk, geo []byte) []byte {
+	var t thriftWriter
+	t.i32(1, parquetFormatVersion)
+
+	t.list(2, thriftStruct, len(names)+1)
+	t.beginElement()
+	t.binary(4, "schema")
+	t.i32(5, int32(len(names)))
+	t.endStruct()
+	for i, name := range names {
+		geometry := i == len(names)-1
+		t.beginElement()
+		t.i32(1, parquetByteArray)
+		if geometry {
+			t.i32(3, parquetOptional)
+		} else {
+			t.i32(3, parquetRequired)
+		}
+		t.binary(4, name)
+		if !geometry {
+			t.i32(6, parquetUTF8)
+			t.beginStruct(10) // LogicalType
+			t.beginStruct(1)  // StringType
+			t.endStruct()
+			t.endStruct()
+		}
+		t.endStruct()
+	}
+
+	t.i64(3, int64(rows))
+
+	t.list(4, thriftStruct, min(len(chunks), 1))
+	if len(chunks) > 0 {
+		var total int64
+		for _, c := range chunks {
+			total += c.size
+		}
+		t.beginElement()
+		t.list(1, thriftStruct, len(chunks))
+		for i, c := range chunks {
+			encodings := []int32{parquetPlain, parquetRLE}
+			t.beginElement()
+			t.i64(2, c.offset)
+			t.beginStruct(3) // ColumnMetaData
+			t.i32(1, parquetByteArray)
+			t.list(2, thriftI32, len(encodings))
+			for _, e := range encodings {
+				t.varint(int64(e))
+			}
+			t.list(3, thriftBinary, 1)
+			t.uvarint(uint64(len(names[i])))
+			t.buf = append(t.buf, names[i]...)
+			t.i32(4, parquetUncompressed)
+			t.i64(5, int64(c.values))
+			t.i64(6, c.size)
+			t.i64(7, c.size)
+			t.i64(9, c.offset)
+			t.endStruct()
+			t.endStruct()
+		}
+		t.i64(2, total)
+		t.i64(3, int64(rows))
+		t.endStruct()
+	}
+
+	t.list(5, thriftStruct, 1)
+	t.beginElement()
+	t.binary(1, "geo")
+	t.binary(2, string(geo))
+	t.endStruct()
+
+	t.binary(6, parquetCreatedBy)
+	t.stop()
+	return t.buf
+}
+
+// geoMetadata is the GeoParquet "geo" file metadata.
+type geoMetadata struct {
+	Version       string               `json:"version"`
+	PrimaryColumn string               `json:"primary_column"`
+	Columns       map[string]geoColumn `json:"columns"`
+}
+
+// geoColumn is the GeoParquet metadata of a geometry column. Without a
+// crs the coordinates are WGS84 longitude and latitude, as in KML.
+type geoColumn struct {
+	Encoding      string    `json:"encoding"`
+	GeometryTypes []string  `json:"geometry_types"`
+	BBox          []float64 `json:"bbox,omitempty"`
+
+	types  map[string]bool
+	coords bool
+	sw, ne Coordinate
+}
+
+// wkbTypeNames are the GeoParquet names of the WKB geometry types.
+var wkbTypeNames = map[uint32]string{
+	wkbPoint:              "Point",
+	wkbLineString:         "LineString",
+	wkbPolygon:            "Polygon",
+	wkbMultiPoint:         "MultiPoint",
+	wkbMultiLineString:    "MultiLineString",
+	wkbMultiPolygon:       "MultiPolygon",
+	wkbGeometryCollection: "GeometryCollection",
+}
+
+// add records the type and extent of a geometry and its WKB encoding.
+func (gc *geoColumn) add(g Geometry, wkb []byte) {
+	typ := binary.LittleEndian.Uint32(wkb[1:])
+	name := wkbTypeNames[typ%1000]
+	if typ >= 1000 {
+		name += " Z"
+	}
+	if gc.types == nil {
+		gc.types = make(map[string]bool)
+	}
+	gc.types[name] = true
+
+	for _, c := range getGeometryCoordinates(g) {
+		if !gc.coords {
+			gc.sw, gc.ne, gc.coords = c, c, true
+			continue
+		}
+		gc.sw.Lon, gc.sw.Lat = math.Min(gc.sw.Lon, c.Lon), math.Min(gc.sw.Lat, c.Lat)
+		gc.ne.Lon, gc.ne.Lat = math.Max(gc.ne.Lon, c.Lon), math.Max(gc.ne.Lat, c.Lat)
+	}
+}
+
+// finish fills in the exported fields from the geometries added.
+func (gc geoColumn) finish() geoColumn {
+	gc.Encoding = "WKB"
+	gc.GeometryTypes = []string{}
+	for name := range gc.types {
+		gc.GeometryTypes = append(gc.GeometryTypes, name)
+	}
+	sort.Strings(gc.GeometryTypes)
+	if gc.coords {
+		gc.BBox = []float64{gc.sw.Lon, gc.sw.Lat, gc.ne.Lon, gc.ne.Lat}
+	}
+	return gc
+}
+
+// Thrift compact protocol type codes.
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter encodes the Thrift compact protocol used by Parquet
+// metadata. Field IDs are written as deltas from the previous field of the
+// enclosing struct.
+type thriftWriter struct {
+	buf   []byte
+	last  int16
+	stack []int16
+}
+
+// field writes a field header.
+func (t *thriftWriter) field(id int16, typ byte) {
+	if d := id - t.last; d > 0 && d <= 15 {
+		t.buf = append(t.buf, byte(d)<<4|typ)
+	} else {
+		t.buf = append(t.buf, typ)
+		t.varint(int64(id))
+	}
+	t.last = id
+}
+
+// varint writes a zigzag-encoded integer.
+func (t *thriftWriter) varint(v int64) {
+	t.uvarint(uint64(v<<1) ^ uint64(v>>63))
+}
+
+// uvarint writes an unsigned integer in base-128.
+func (t *thriftWriter) uvarint(v uint64) {
+	t.buf = binary.AppendUvarint(t.buf, v)
+}
+
+func (t *thriftWriter) i32(id int16, v int32) {
+	t.field(id, thriftI32)
+	t.varint(int64(v))
+}
+
+func (t *thriftWriter) i64(id int16, v int64) {
+	t.field(id, thriftI64)
+	t.varint(v)
+}
+
+func (t *thriftWriter) binary(id int16, s string) {
+	t.field(id, thriftBinary)
+	t.uvarint(uint64(len(s)))
+	t.buf = append(t.buf, s...)
+}
+
+// list writes the header of a list field of n elements.
+func (t *thriftWriter) list(id int16, elem byte, n int) {
+	t.field(id, thriftList)
+	if n < 15 {
+		t.buf = append(t.buf, byte(n)<<4|elem)
+	} else {
+		t.buf = append(t.buf, 0xf0|elem)
+		t.uvarint(uint64(n))
+	}
+}
+
+// beginStruct starts a struct field, ended by endStruct.
+func (t *thriftWriter) beginStruct(id int16) {
+	t.field(id, thriftStruct)
+	t.beginElement()
+}
+
+// beginElement starts a struct element of a list, ended by endStruct.
+func (t *thriftWriter) beginElement() {
+	t.stack = append(t.stack, t.last)
+	t.last = 0
+}
+
+// endStruct ends the innermost struct.
+func (t *thriftWriter) endStruct() {
+	t.stop()
+	t.last = t.stack[len(t.stack)-1]
+	t.stack = t.stack[:len(t.stack)-1]
+}
+
+// stop writes the end of a struct's fields.
+func (t *thriftWriter) stop() {
+	t.buf = append(t.buf, 0)
+}
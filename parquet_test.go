@@ -0,0 +1,92 @@
+package kml
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+// TestWriteParquet tests the layout and contents of a GeoParquet file
+func TestWriteParquet(t *testing.T) {
+	k := recordsTestKML()
+	doc := k.Feature.(*Document)
+	doc.Features = append(doc.Features, &Placemark{Name: "Atlantis"})
+
+	var buf bytes.Buffer
+	if err := k.WriteParquet(&buf, ColumnName(), ColumnData("population")); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	data := buf.Bytes()
+	if !bytes.HasPrefix(data, []byte("PAR1")) || !bytes.HasSuffix(data, []byte("PAR1")) {
+		t.Fatalf("Expected PAR1 at both ends")
+	}
+	footer := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	if footer <= 0 || footer > len(data)-12 {
+		t.Fatalf("Expected a footer within the file, got length %d", footer)
+	}
+	meta := data[len(data)-8-footer : len(data)-8]
+
+	paris, _ := encodeWKB(&Point{Coordinates: Coord(2.3522, 48.8566)})
+	tests := []struct {
+		name string
+		in   []byte
+		want string
+	}{
+		{"name values", data, "\x05\x00\x00\x00Paris\x05\x00\x00\x00Rhine\x04\x00\x00\x00Lima\x08\x00\x00\x00Atlantis"},
+		{"missing data is empty", data, "\x07\x00\x00\x002102650\x00\x00\x00\x00\x08\x00\x00\x0010092000"},
+		{"geometry", data, "\x15\x00\x00\x00" + string(paris)},
+		{"null geometry", data, "\x04\x00\x00\x00\x06\x01\x02\x00"},
+		{"column names", meta, "population"},
+		{"geometry types", meta, `"geometry_types":["LineString","Point","Point Z"]`},
+		{"bbox", meta, `"bbox":[-77.0428,-12.0464,7.6,51.8]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !bytes.Contains(tt.in, []byte(tt.want)) {
+				t.Errorf("Expected %q in the file", tt.want)
+			}
+		})
+	}
+}
+
+// TestWriteParquetEmpty tests a file without rows
+func TestWriteParquetEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewKML().WriteParquet(&buf, ColumnName()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte(`"geometry_types":[]`)) {
+		t.Errorf("Expected empty geometry types, got %q", buf.Bytes())
+	}
+}
+
+// failingWriter fails every write
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("disk full") }
+
+// TestWriteParquetErrors tests invalid columns and failed writes
+func TestWriteParquetErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		columns []ColumnSpec
+	}{
+		{"no columns", nil},
+		{"duplicate", []ColumnSpec{ColumnName(), ColumnName()}},
+		{"geometry", []ColumnSpec{{Header: "geometry", Value: ColumnName().Value}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := recordsTestKML().WriteParquet(&bytes.Buffer{}, tt.columns...); err == nil {
+				t.Errorf("Expected an error")
+			}
+		})
+	}
+
+	if err := recordsTestKML().WriteParquet(failingWriter{}, ColumnName()); err == nil {
+		t.Errorf("Expected the write error")
+	}
+}
@@ -0,0 +1,396 @@
+package kml
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// PatchOp is one operation of a patch, in the form of a JSON Patch (RFC
+// 6902) operation, so patches sent by a remote editor decode into it
+// directly.
+type PatchOp struct {
+	Op    string          `json:"op"`              // "add", "remove" or "replace"
+	Path  string          `json:"path"`            // See ApplyPatch
+	Value json.RawMessage `json:"value,omitempty"` // New value for add and replace, in the JSON form of the model
+}
+
+// ApplyPatch applies ops to the document in order. Paths are JSON
+// Pointers into the JSON form of the document, as encoding/json writes it,
+// so they use the Go field names: "/Feature/Features/0/Name" is the name
+// of the first child of the top-level container, and
+// "/Feature/Features/0/Geometry/Coordinates/3/Lat" the latitude of its
+// fourth vertex. A path may instead start with "#" and a feature ID, as in
+// "#well-7/Description", to address the feature FindByID returns.
+//
+// Adding to or replacing an element of a Features list, or replacing a
+// feature itself, takes a feature value with its "type" key; "-" as the
+// last segment of an add appends to the list, as in "/Feature/Features/-".
+// The features of a container that are not addressed are kept as they
+// are, including state such as the spans of RecordSpans. Lists that
+// encode as null, such as a placemark without Data, can be added to as if
+// they were empty. Replacing "/Feature" with null empties the document.
+//
+// Operations are applied one at a time. The first that fails stops the
+// patch with an error naming it, leaving the operations before it
+// applied. ApplyPatch also discards the TextSearch index and cached
+// bounds.
+func (k *KML) ApplyPatch(ops []PatchOp) error {
+	k.ResetTextSearch()
+	k.ResetBounds()
+	for i, op := range ops {
+		if err := k.applyPatchOp(op); err != nil {
+			return patchOpError(i, op, err)
+		}
+	}
+	return nil
+}
+
+// patchOpError wraps the error of operation i of a patch.
+func patchOpError(i int, op PatchOp, err error) error {
+	return fmt.Errorf("kml: patch operation %d (%s %s): %w", i, op.Op, op.Path, err)
+}
+
+// applyPatchOp applies one operation.
+func (k *KML) applyPatchOp(op PatchOp) error {
+	if err := checkPatchOp(op); err != nil {
+		return err
+	}
+	t, err := k.resolvePatchPath(op.Path)
+	if err != nil {
+		return err
+	}
+
+	// The addressed segment of a Features list.
+	if len(t.rest) == 2 && t.rest[0] == "Features" && childrenOf(t.feature) != nil {
+		return patchFeatureList(childrenOf(t.feature), op, t.rest[1])
+	}
+	// The feature itself.
+	if len(t.rest) == 0 {
+		if op.Op == "remove" {
+			if t.slot == nil {
+				return errors.New("cannot remove the top-level feature")
+			}
+			return patchFeatureList(t.slot.list, op, strconv.Itoa(t.slot.index))
+		}
+		if t.slot == nil && string(bytes.TrimSpace(op.Value)) == "null" {
+			k.Feature = nil
+			return nil
+		}
+		feature, err := unmarshalPatchFeature(op.Value)
+		if err != nil {
+			return err
+		}
+		if t.slot == nil {
+			k.Feature = feature
+		} else {
+			(*t.slot.list)[t.slot.index] = feature
+		}
+		return nil
+	}
+	return patchFeatureFields(t.feature, op, t.rest)
+}
+
+// checkPatchOp reports an operation that is invalid whatever its path.
+func checkPatchOp(op PatchOp) error {
+	switch op.Op {
+	case "add", "replace", "remove":
+	default:
+		return fmt.Errorf("unsupported op %q", op.Op)
+	}
+	if op.Op != "remove" && len(op.Value) == 0 {
+		return errors.New("missing value")
+	}
+	return nil
+}
+
+// featureSlot is the position of a feature in its container.
+type featureSlot struct {
+	list  *[]Feature
+	index int
+}
+
+// patchTarget is the feature a patch path addresses.
+type patchTarget struct {
+	feature Feature
+	slot    *featureSlot // nil for the top-level feature
+	path    []string     // Tree path of the feature, starting with "Feature"
+	rest    []string     // The rest of the path, within the feature
+}
+
+// resolvePatchPath finds the feature a path addresses, descending through
+// Features lists.
+func (k *KML) resolvePatchPath(path string) (patchTarget, error) {
+	t := patchTarget{path: []string{"Feature"}}
+	if ref, ok := strings.CutPrefix(path, "#"); ok {
+		id, rest := ref, ""
+		if i := strings.IndexByte(ref, '/'); i >= 0 {
+			id, rest = ref[:i], ref[i:]
+		}
+		if rest != "" {
+			var err error
+			if t.rest, err = splitPointer(rest); err != nil {
+				return t, err
+			}
+		}
+		if t.feature = k.FindByID(id); t.feature == nil {
+			return t, fmt.Errorf("no feature with id %q", id)
+		}
+		p := featurePath(k.Feature, t.feature)
+		for depth := 1; depth < len(p); depth++ {
+			list := childrenOf(p[depth-1])
+			for i, child := range *list {
+				if child == p[depth] {
+					t.slot = &featureSlot{list: list, index: i}
+					t.path = append(t.path, "Features", strconv.Itoa(i))
+				}
+			}
+		}
+	} else {
+		tokens, err := splitPointer(path)
+		if err != nil {
+			return t, err
+		}
+		if tokens[0] != "Feature" {
+			return t, errors.New("path must start with /Feature or #id")
+		}
+		t.feature, t.rest = k.Feature, tokens[1:]
+		if t.feature == nil && len(t.rest) > 0 {
+			return t, errors.New("document has no feature")
+		}
+	}
+
+	for len(t.rest) > 2 && t.rest[0] == "Features" && childrenOf(t.feature) != nil {
+		list := childrenOf(t.feature)
+		i, err := patchIndex(t.rest[1], len(*list))
+		if err != nil {
+			return t, err
+		}
+		t.feature, t.slot = (*list)[i], &featureSlot{list: list, index: i}
+		t.path, t.rest = append(t.path, t.rest[:2]...), t.rest[2:]
+	}
+	return t, nil
+}
+
+// childrenOf returns the Features list of a container, or nil.
+func childrenOf(f Feature) *[]Feature {
+	switch feature := f.(type) {
+	case *Document:
+		return &feature.Features
+	case *Folder:
+		return &feature.Features
+	}
+	return nil
+}
+
+// patchFeatureList applies op to the element token of a Features list.
+func patchFeatureList(list *[]Feature, op PatchOp, token string) error {
+	if op.Op == "add" {
+		i := len(*list)
+		if token != "-" {
+			var err error
+			if i, err = patchIndex(token, len(*list)+1); err != nil {
+				return err
+			}
+		}
+		feature, err := unmarshalPatchFeature(op.Value)
+		if err != nil {
+			return err
+		}
+		*list = append(*list, nil)
+		copy((*list)[i+1:], (*list)[i:])
+		(*list)[i] = feature
+		return nil
+	}
+
+	i, err := patchIndex(token, len(*list))
+	if err != nil {
+		return err
+	}
+	if op.Op == "remove" {
+		*list = append((*list)[:i], (*list)[i+1:]...)
+		return nil
+	}
+	feature, err := unmarshalPatchFeature(op.Value)
+	if err != nil {
+		return err
+	}
+	(*list)[i] = feature
+	return nil
+}
+
+// unmarshalPatchFeature decodes the feature value of an operation.
+func unmarshalPatchFeature(data json.RawMessage) (Feature, error) {
+	f, err := unmarshalFeatureJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	if f == nil {
+		return nil, errors.New("feature value is null")
+	}
+	return f, nil
+}
+
+// patchFeatureFields applies op to a field of f, given by the path tokens
+// within it, by editing its JSON form and decoding the result back into f.
+// Unexported state and, unless the path is within them, the children of a
+// container are kept.
+func patchFeatureFields(f Feature, op PatchOp, tokens []string) error {
+	doc, err := featureFieldsJSON(f, tokens)
+	if err != nil {
+		return err
+	}
+	var value any
+	if op.Op != "remove" {
+		if err := decodeJSONNumbers(op.Value, &value); err != nil {
+			return fmt.Errorf("invalid value: %w", err)
+		}
+	}
+	if doc, err = patchValue(doc, op.Op, tokens, value); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	patched := newFeature(f.featureType())
+	if err := json.Unmarshal(data, patched); err != nil {
+		return fmt.Errorf("invalid result: %w", err)
+	}
+
+	keep := childrenOf(f) != nil && tokens[0] != "Features"
+	dst, src := reflect.ValueOf(f).Elem(), reflect.ValueOf(patched).Elem()
+	for i := 0; i < dst.NumField(); i++ {
+		field := dst.Type().Field(i)
+		if field.IsExported() && !(keep && field.Name == "Features") {
+			dst.Field(i).Set(src.Field(i))
+		}
+	}
+	return nil
+}
+
+// featureFieldsJSON decodes the JSON form of f for editing at the path
+// tokens within it. The children of a container are left out unless the
+// path is within them.
+func featureFieldsJSON(f Feature, tokens []string) (any, error) {
+	if children := childrenOf(f); children != nil && tokens[0] != "Features" {
+		saved := *children
+		*children = nil
+		defer func() { *children = saved }()
+	}
+	data, err := json.Marshal(f)
+	if err != nil {
+		return nil, err
+	}
+	var doc any
+	err = decodeJSONNumbers(data, &doc)
+	return doc, err
+}
+
+// decodeJSONNumbers decodes JSON data into v, keeping numbers exact.
+func decodeJSONNumbers(data []byte, v *any) error {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	return d.Decode(v)
+}
+
+// patchValue applies op at the path tokens within v, a decoded JSON value,
+// and returns the result.
+func patchValue(v any, op string, tokens []string, value any) (any, error) {
+	token, last := tokens[0], len(tokens) == 1
+	switch node := v.(type) {
+	case map[string]any:
+		child, ok := node[token]
+		if !ok && !(last && op == "add") {
+			return nil, fmt.Errorf("no field %q", token)
+		}
+		if !last {
+			child, err := patchValue(child, op, tokens[1:], value)
+			if err != nil {
+				return nil, err
+			}
+			node[token] = child
+			return node, nil
+		}
+		if op == "remove" {
+			delete(node, token)
+		} else {
+			node[token] = value
+		}
+		return node, nil
+
+	case []any, nil:
+		list, _ := node.([]any)
+		if node == nil && !(last && op == "add") {
+			return nil, fmt.Errorf("no element %q", token)
+		}
+		if last && op == "add" {
+			i := len(list)
+			if token != "-" {
+				var err error
+				if i, err = patchIndex(token, len(list)+1); err != nil {
+					return nil, err
+				}
+			}
+			list = append(list, nil)
+			copy(list[i+1:], list[i:])
+			list[i] = value
+			return list, nil
+		}
+		i, err := patchIndex(token, len(list))
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case !last:
+			if list[i], err = patchValue(list[i], op, tokens[1:], value); err != nil {
+				return nil, err
+			}
+		case op == "remove":
+			list = append(list[:i], list[i+1:]...)
+		default:
+			list[i] = value
+		}
+		return list, nil
+	}
+	return nil, fmt.Errorf("cannot address %q within a %T", token, v)
+}
+
+// patchIndex parses a list index token, which must be below n.
+func patchIndex(token string, n int) (int, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || (token != "0" && strings.HasPrefix(token, "0")) {
+		return 0, fmt.Errorf("invalid index %q", token)
+	}
+	if i >= n {
+		return 0, fmt.Errorf("index %d out of range", i)
+	}
+	return i, nil
+}
+
+// splitPointer splits a JSON Pointer into its unescaped reference tokens.
+func splitPointer(pointer string) ([]string, error) {
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid path %q", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(t)
+	}
+	return tokens, nil
+}
+
+// joinPointer joins reference tokens into a JSON Pointer, escaping them.
+func joinPointer(tokens []string) string {
+	var b strings.Builder
+	for _, t := range tokens {
+		b.WriteByte('/')
+		b.WriteString(strings.NewReplacer("~", "~0", "/", "~1").Replace(t))
+	}
+	return b.String()
+}
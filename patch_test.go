@@ -0,0 +1,213 @@
+package kml
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// patchTestKML returns a document with a folder of two placemarks.
+func patchTestKML() *KML {
+	return &KML{Feature: &Document{
+		Name: "Sites",
+		Features: []Feature{
+			&Folder{ID: "wells", Name: "Wells", Features: []Feature{
+				&Placemark{ID: "w1", Name: "Well 1", Geometry: &Point{Coordinates: Coord(1, 2)}},
+				&Placemark{ID: "w2", Name: "Well 2", Geometry: &LineString{Coordinates: []Coordinate{Coord(0, 0), Coord(1, 1)}}},
+			}},
+		},
+	}}
+}
+
+// TestApplyPatch tests each kind of patch operation
+func TestApplyPatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		ops   string
+		check func(t *testing.T, k *KML)
+	}{
+		{
+			name: "replace field by tree path",
+			ops:  `[{"op":"replace","path":"/Feature/Features/0/Features/1/Name","value":"Renamed"}]`,
+			check: func(t *testing.T, k *KML) {
+				if name := k.FindByID("w2").(*Placemark).Name; name != "Renamed" {
+					t.Errorf("Expected Renamed, got %q", name)
+				}
+			},
+		},
+		{
+			name: "replace coordinate by id",
+			ops:  `[{"op":"replace","path":"#w2/Geometry/Coordinates/1/Lat","value":45.123456789012}]`,
+			check: func(t *testing.T, k *KML) {
+				c := k.FindByID("w2").(*Placemark).Geometry.(*LineString).Coordinates
+				if c[1] != Coord(1, 45.123456789012) || c[0] != Coord(0, 0) {
+					t.Errorf("Expected second vertex moved, got %v", c)
+				}
+			},
+		},
+		{
+			name: "add and remove vertices",
+			ops: `[{"op":"add","path":"#w2/Geometry/Coordinates/1","value":{"Lon":0.5,"Lat":0.5}},
+				{"op":"add","path":"#w2/Geometry/Coordinates/-","value":{"Lon":2,"Lat":2}},
+				{"op":"remove","path":"#w2/Geometry/Coordinates/0"}]`,
+			check: func(t *testing.T, k *KML) {
+				c := k.FindByID("w2").(*Placemark).Geometry.(*LineString).Coordinates
+				want := []Coordinate{Coord(0.5, 0.5), Coord(1, 1), Coord(2, 2)}
+				if len(c) != 3 || c[0] != want[0] || c[1] != want[1] || c[2] != want[2] {
+					t.Errorf("Expected %v, got %v", want, c)
+				}
+			},
+		},
+		{
+			name: "add to null list",
+			ops:  `[{"op":"add","path":"#w1/ExtendedData","value":{"Data":[]}},{"op":"add","path":"#w1/ExtendedData/SchemaData/-","value":{"SchemaURL":"#s"}}]`,
+			check: func(t *testing.T, k *KML) {
+				pm := k.FindByID("w1").(*Placemark)
+				if pm.ExtendedData == nil || len(pm.ExtendedData.SchemaData) != 1 || pm.ExtendedData.SchemaData[0].SchemaURL != "#s" {
+					t.Errorf("Expected SchemaData added, got %+v", pm.ExtendedData)
+				}
+			},
+		},
+		{
+			name: "remove field",
+			ops:  `[{"op":"remove","path":"#w1/Geometry"}]`,
+			check: func(t *testing.T, k *KML) {
+				if g := k.FindByID("w1").(*Placemark).Geometry; g != nil {
+					t.Errorf("Expected geometry removed, got %v", g)
+				}
+			},
+		},
+		{
+			name: "replace geometry",
+			ops:  `[{"op":"replace","path":"#w1/Geometry","value":{"type":"LineString","Coordinates":[{"Lon":3,"Lat":4},{"Lon":5,"Lat":6}]}}]`,
+			check: func(t *testing.T, k *KML) {
+				ls, ok := k.FindByID("w1").(*Placemark).Geometry.(*LineString)
+				if !ok || len(ls.Coordinates) != 2 {
+					t.Errorf("Expected LineString, got %+v", k.FindByID("w1").(*Placemark).Geometry)
+				}
+			},
+		},
+		{
+			name: "container field keeps children",
+			ops:  `[{"op":"replace","path":"#wells/Name","value":"Water wells"}]`,
+			check: func(t *testing.T, k *KML) {
+				folder := k.FindByID("wells").(*Folder)
+				if folder.Name != "Water wells" || len(folder.Features) != 2 {
+					t.Errorf("Expected renamed folder with 2 children, got %+v", folder)
+				}
+			},
+		},
+		{
+			name: "add features",
+			ops: `[{"op":"add","path":"#wells/Features/0","value":{"type":"Placemark","ID":"w0"}},
+				{"op":"add","path":"/Feature/Features/-","value":{"type":"Folder","Name":"Roads"}}]`,
+			check: func(t *testing.T, k *KML) {
+				doc := k.Feature.(*Document)
+				if len(doc.Features) != 2 || featureName(doc.Features[1]) != "Roads" {
+					t.Errorf("Expected Roads appended, got %v", doc.Features)
+				}
+				if ids := k.FindByID("wells").(*Folder).Features; len(ids) != 3 || featureID(ids[0]) != "w0" {
+					t.Errorf("Expected w0 inserted first, got %v", ids)
+				}
+			},
+		},
+		{
+			name: "remove and replace features",
+			ops: `[{"op":"remove","path":"#w1"},
+				{"op":"replace","path":"/Feature/Features/0/Features/0","value":{"type":"GroundOverlay","ID":"g"}}]`,
+			check: func(t *testing.T, k *KML) {
+				features := k.FindByID("wells").(*Folder).Features
+				if len(features) != 1 {
+					t.Fatalf("Expected 1 feature, got %d", len(features))
+				}
+				if _, ok := features[0].(*GroundOverlay); !ok {
+					t.Errorf("Expected GroundOverlay, got %T", features[0])
+				}
+			},
+		},
+		{
+			name: "replace top-level feature",
+			ops:  `[{"op":"replace","path":"/Feature","value":{"type":"Placemark","Name":"Only"}}]`,
+			check: func(t *testing.T, k *KML) {
+				if featureName(k.Feature) != "Only" {
+					t.Errorf("Expected new top-level placemark, got %+v", k.Feature)
+				}
+			},
+		},
+		{
+			name: "escaped pointer",
+			ops:  `[{"op":"add","path":"#w1/ExtendedData","value":{"Data":[{"Name":"a/b","Value":"1"}]}},{"op":"replace","path":"#w1/ExtendedData/Data/0/Value","value":"2"}]`,
+			check: func(t *testing.T, k *KML) {
+				if v, _ := dataNamed(k.FindByID("w1").(*Placemark), "a/b"); v != "2" {
+					t.Errorf("Expected 2, got %q", v)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ops []PatchOp
+			if err := json.Unmarshal([]byte(tt.ops), &ops); err != nil {
+				t.Fatalf("Unmarshal failed: %v", err)
+			}
+			k := patchTestKML()
+			if err := k.ApplyPatch(ops); err != nil {
+				t.Fatalf("ApplyPatch failed: %v", err)
+			}
+			tt.check(t, k)
+		})
+	}
+}
+
+// TestApplyPatchKeepsState tests that patching a field keeps unexported state
+func TestApplyPatchKeepsState(t *testing.T) {
+	k, err := ParseBytes([]byte(`<kml xmlns="http://www.opengis.net/kml/2.2"><Document>
+<Placemark id="p"><name>A</name></Placemark></Document></kml>`), RecordSpans())
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	pm := k.FindByID("p").(*Placemark)
+	span, _ := SourceSpan(pm)
+	if err := k.ApplyPatch([]PatchOp{{Op: "replace", Path: "#p/Name", Value: json.RawMessage(`"B"`)}}); err != nil {
+		t.Fatalf("ApplyPatch failed: %v", err)
+	}
+	if got, ok := SourceSpan(pm); k.FindByID("p") != pm || pm.Name != "B" || !ok || got != span {
+		t.Errorf("Expected placemark edited in place with its span, got %+v", pm)
+	}
+}
+
+// TestApplyPatchErrors tests invalid patch operations
+func TestApplyPatchErrors(t *testing.T) {
+	tests := []struct {
+		op   PatchOp
+		want string
+	}{
+		{PatchOp{Op: "move", Path: "/Feature"}, `unsupported op "move"`},
+		{PatchOp{Op: "replace", Path: "/Feature/Name"}, "missing value"},
+		{PatchOp{Op: "remove", Path: "Feature"}, "invalid path"},
+		{PatchOp{Op: "remove", Path: "/Xmlns"}, "must start with /Feature"},
+		{PatchOp{Op: "remove", Path: "#none/Name"}, `no feature with id "none"`},
+		{PatchOp{Op: "remove", Path: "/Feature"}, "cannot remove the top-level feature"},
+		{PatchOp{Op: "remove", Path: "/Feature/Features/3/Name"}, "index 3 out of range"},
+		{PatchOp{Op: "remove", Path: "/Feature/Features/01"}, `invalid index "01"`},
+		{PatchOp{Op: "replace", Path: "#w1/Nickname", Value: json.RawMessage(`"x"`)}, `no field "Nickname"`},
+		{PatchOp{Op: "replace", Path: "#w1/Name/0", Value: json.RawMessage(`"x"`)}, `cannot address "0"`},
+		{PatchOp{Op: "replace", Path: "#w1/Name", Value: json.RawMessage(`7`)}, "invalid result"},
+		{PatchOp{Op: "add", Path: "#wells/Features/-", Value: json.RawMessage(`{"type":"Blob"}`)}, `unknown feature type "Blob"`},
+		{PatchOp{Op: "add", Path: "#wells/Features/-", Value: json.RawMessage(`null`)}, "feature value is null"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.op.Op+" "+tt.op.Path, func(t *testing.T) {
+			k := patchTestKML()
+			ops := []PatchOp{{Op: "replace", Path: "/Feature/Name", Value: json.RawMessage(`"Edited"`)}, tt.op}
+			err := k.ApplyPatch(ops)
+			if err == nil || !strings.Contains(err.Error(), tt.want) || !strings.Contains(err.Error(), "operation 1") {
+				t.Errorf("Expected error containing %q, got %v", tt.want, err)
+			}
+			if featureName(k.Feature) != "Edited" {
+				t.Errorf("Expected earlier operation applied")
+			}
+		})
+	}
+}
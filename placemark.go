@@ -2,6 +2,7 @@ package kml
 
 import (
 	"encoding/xml"
+	"strings"
 )
 
 // Placemark represents a geographic feature with geometry.
@@ -11,10 +12,19 @@ type Placemark struct {
 	Name         string        `xml:"name,omitempty"`
 	Description  string        `xml:"description,omitempty"`
 	Visibility   *bool         `xml:"visibility,omitempty"`
+	Address      string        `xml:"address,omitempty"`
+	LookAt       *LookAt       `xml:"LookAt,omitempty"`
+	Camera       *Camera       `xml:"Camera,omitempty"`
+	TimeStamp    *TimeStamp    `xml:"TimeStamp,omitempty"`
+	TimeSpan     *TimeSpan     `xml:"TimeSpan,omitempty"`
 	StyleURL     string        `xml:"styleUrl,omitempty"`
 	Style        *Style        `xml:"Style,omitempty"`
+	Region       *Region       `xml:"Region,omitempty"`
 	Geometry     Geometry      `xml:"-"` // Point, LineString, Polygon, etc. - needs custom XML
 	ExtendedData *ExtendedData `xml:"ExtendedData,omitempty"`
+	Comments     []string      `xml:"-"` // See PreserveComments
+
+	span *Span // See RecordSpans
 }
 
 // featureType implements the Feature interface.
@@ -36,6 +46,9 @@ func (p *Placemark) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
 		})
 	}
 
+	if err := encodeComments(e, p.Comments); err != nil {
+		return err
+	}
 	if err := e.EncodeToken(start); err != nil {
 		return err
 	}
@@ -47,12 +60,6 @@ func (p *Placemark) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
 		}
 	}
 
-	if p.Description != "" {
-		if err := e.EncodeElement(p.Description, xml.StartElement{Name: xml.Name{Local: "description"}}); err != nil {
-			return err
-		}
-	}
-
 	if p.Visibility != nil {
 		v := 0
 		if *p.Visibility {
@@ -63,6 +70,42 @@ func (p *Placemark) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
 		}
 	}
 
+	if p.Address != "" {
+		if err := e.EncodeElement(p.Address, xml.StartElement{Name: xml.Name{Local: "address"}}); err != nil {
+			return err
+		}
+	}
+
+	if p.Description != "" {
+		if err := encodeDescription(e, p.Description); err != nil {
+			return err
+		}
+	}
+
+	if p.LookAt != nil {
+		if err := e.Encode(p.LookAt); err != nil {
+			return err
+		}
+	}
+
+	if p.Camera != nil {
+		if err := e.Encode(p.Camera); err != nil {
+			return err
+		}
+	}
+
+	if p.TimeStamp != nil {
+		if err := e.Encode(p.TimeStamp); err != nil {
+			return err
+		}
+	}
+
+	if p.TimeSpan != nil {
+		if err := e.Encode(p.TimeSpan); err != nil {
+			return err
+		}
+	}
+
 	if p.StyleURL != "" {
 		if err := e.EncodeElement(p.StyleURL, xml.StartElement{Name: xml.Name{Local: "styleUrl"}}); err != nil {
 			return err
@@ -75,26 +118,41 @@ func (p *Placemark) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
 		}
 	}
 
-	// Encode Geometry - type assert to determine the concrete type
-	if p.Geometry != nil {
-		if err := e.Encode(p.Geometry); err != nil {
+	if p.Region != nil {
+		if err := e.Encode(p.Region); err != nil {
 			return err
 		}
 	}
 
+	// ExtendedData ends the Feature elements, so the schema puts it before
+	// the geometry of a Placemark.
 	if p.ExtendedData != nil {
 		if err := e.Encode(p.ExtendedData); err != nil {
 			return err
 		}
 	}
 
-	return e.EncodeToken(start.End())
+	// Encode Geometry - type assert to determine the concrete type
+	if p.Geometry != nil {
+		if err := e.Encode(p.Geometry); err != nil {
+			return err
+		}
+	}
+
+	if err := e.EncodeToken(start.End()); err != nil {
+		return err
+	}
+	featureWritten(e)
+	return nil
 }
 
 // UnmarshalXML implements custom XML unmarshaling for Placemark.
 // This handles reading polymorphic geometry elements and assigning them
 // to the Geometry field.
 func (p *Placemark) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	p.Comments = takeComments(d)
+	beginSpan(d)
+
 	// Handle id attribute
 	for _, attr := range start.Attr {
 		if attr.Name.Local == "id" {
@@ -124,8 +182,36 @@ func (p *Placemark) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 				if err := d.DecodeElement(&v, &el); err != nil {
 					return err
 				}
-				vis := v != 0
+				vis := flagValue(d, el, v)
 				p.Visibility = &vis
+			case "address":
+				if err := d.DecodeElement(&p.Address, &el); err != nil {
+					return err
+				}
+			case "TimeStamp":
+				var ts TimeStamp
+				if err := d.DecodeElement(&ts, &el); err != nil {
+					return err
+				}
+				p.TimeStamp = &ts
+			case "TimeSpan":
+				var ts TimeSpan
+				if err := d.DecodeElement(&ts, &el); err != nil {
+					return err
+				}
+				p.TimeSpan = &ts
+			case "LookAt":
+				var lookAt LookAt
+				if err := d.DecodeElement(&lookAt, &el); err != nil {
+					return err
+				}
+				p.LookAt = &lookAt
+			case "Camera":
+				var camera Camera
+				if err := d.DecodeElement(&camera, &el); err != nil {
+					return err
+				}
+				p.Camera = &camera
 			case "styleUrl":
 				if err := d.DecodeElement(&p.StyleURL, &el); err != nil {
 					return err
@@ -136,6 +222,12 @@ func (p *Placemark) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 					return err
 				}
 				p.Style = &style
+			case "Region":
+				var region Region
+				if err := d.DecodeElement(&region, &el); err != nil {
+					return err
+				}
+				p.Region = &region
 			case "Point":
 				var point Point
 				if err := d.DecodeElement(&point, &el); err != nil {
@@ -166,6 +258,12 @@ func (p *Placemark) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 					return err
 				}
 				p.Geometry = &multiGeometry
+			case "Track":
+				var track Track
+				if err := d.DecodeElement(&track, &el); err != nil {
+					return err
+				}
+				p.Geometry = &track
 			case "ExtendedData":
 				var extendedData ExtendedData
 				if err := d.DecodeElement(&extendedData, &el); err != nil {
@@ -173,12 +271,12 @@ func (p *Placemark) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 				}
 				p.ExtendedData = &extendedData
 			default:
-				// Skip unknown elements
-				if err := d.Skip(); err != nil {
+				if err := skipElement(d, el); err != nil {
 					return err
 				}
 			}
 		case xml.EndElement:
+			featureParsed(d, p)
 			return nil
 		}
 	}
@@ -209,3 +307,30 @@ type SimpleData struct {
 	Name  string `xml:"name,attr"`
 	Value string `xml:",chardata"`
 }
+
+// Schema declares the typed fields referenced by SchemaData elements.
+type Schema struct {
+	ID           string        `xml:"id,attr,omitempty"`
+	Name         string        `xml:"name,attr,omitempty"`
+	SimpleFields []SimpleField `xml:"SimpleField,omitempty"`
+}
+
+// SimpleField declares a single named, typed field of a Schema.
+type SimpleField struct {
+	Type        string `xml:"type,attr"`
+	Name        string `xml:"name,attr"`
+	DisplayName string `xml:"displayName,omitempty"`
+}
+
+// encodeDescription writes a description element. Descriptions containing
+// markup are written as CDATA, as Google Earth does, so balloon HTML stays
+// readable in the output.
+func encodeDescription(e *xml.Encoder, desc string) error {
+	start := xml.StartElement{Name: xml.Name{Local: "description"}}
+	if !strings.ContainsAny(desc, "<&") {
+		return e.EncodeElement(desc, start)
+	}
+	return e.EncodeElement(struct {
+		Text string `xml:",cdata"`
+	}{desc}, start)
+}
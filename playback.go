@@ -0,0 +1,182 @@
+package kml
+
+import "math"
+
+// TourOptions configures TourFromTrack.
+type TourOptions struct {
+	// Speed is the playback rate as a multiple of the time the track took
+	// to record. The default is 1, real time.
+	Speed float64
+
+	// Pace is the playback speed of an untimed track in meters per
+	// second. The default is 100.
+	Pace float64
+
+	// Interval is the least playback time between keyframes in seconds;
+	// the points in between are passed over, so dense tracks play
+	// smoothly. The default, zero, makes every point a keyframe.
+	Interval float64
+
+	// Range is the distance of the camera from the current position in
+	// meters. The default is 500.
+	Range float64
+
+	// Tilt is the camera's angle from straight down in degrees. The
+	// default is 60.
+	Tilt float64
+
+	// Heading is added to the direction of travel to aim the camera: zero
+	// follows behind, and 90 watches from the left of the track.
+	Heading float64
+
+	// Intro is the time in seconds taken to fly from the viewer's current
+	// view to the start of the track. The default is 3.
+	Intro float64
+}
+
+// defaults returns o with zero fields set to their defaults.
+func (o TourOptions) defaults() TourOptions {
+	if o.Speed <= 0 {
+		o.Speed = 1
+	}
+	if o.Pace <= 0 {
+		o.Pace = 100
+	}
+	if o.Range <= 0 {
+		o.Range = 500
+	}
+	if o.Tilt == 0 {
+		o.Tilt = 60
+	}
+	if o.Intro <= 0 {
+		o.Intro = 3
+	}
+	return o
+}
+
+// TourFromTrack returns a tour that flies a camera along t, looking in the
+// direction of travel from opts.Range meters back. The first FlyTo bounces
+// to the start of the track and the rest move smoothly from point to point,
+// taking the recorded time between them divided by opts.Speed, or for an
+// untimed track the time to cover the distance at opts.Pace. Each FlyTo of
+// a timed track sets the time slider to the time of its point, so the
+// track draws itself as the tour plays. A track without points gives an
+// empty tour.
+func TourFromTrack(t *Track, opts TourOptions) *Tour {
+	opts = opts.defaults()
+	tour := &Tour{}
+	n := len(t.Coords)
+	if n == 0 {
+		return tour
+	}
+	timed := len(t.When) == n
+
+	// Choose keyframes at least opts.Interval apart, always ending on the
+	// last point.
+	keys := []int{0}
+	durations := []float64{opts.Intro}
+	elapsed := 0.0
+	for i := 1; i < n; i++ {
+		if timed {
+			elapsed += max(0, t.When[i].Sub(t.When[i-1]).Seconds()/opts.Speed)
+		} else {
+			elapsed += t.Coords[i-1].DistanceTo(t.Coords[i]) / opts.Pace
+		}
+		if elapsed >= opts.Interval || i == n-1 {
+			keys = append(keys, i)
+			durations = append(durations, elapsed)
+			elapsed = 0
+		}
+	}
+
+	// Face each keyframe towards the next; keyframes that do not move keep
+	// the heading of the one before.
+	headings := make([]float64, len(keys))
+	for k := range headings {
+		headings[k] = math.NaN()
+		if k+1 < len(keys) {
+			from, to := t.Coords[keys[k]], t.Coords[keys[k+1]]
+			if from.DistanceTo(to) > 0 {
+				headings[k] = from.BearingTo(to)
+			}
+		}
+	}
+	fillHeadings(headings)
+
+	mode, alt := tourAltitude(t.AltitudeMode)
+	for k, i := range keys {
+		c := t.Coords[i]
+		target := &LookAt{
+			Longitude:    c.Lon,
+			Latitude:     c.Lat,
+			Heading:      normalizeBearing(headings[k] + opts.Heading),
+			Tilt:         opts.Tilt,
+			Range:        opts.Range,
+			AltitudeMode: mode,
+		}
+		if alt {
+			target.Altitude = c.Alt
+		}
+		fly := &FlyTo{Duration: durations[k], Mode: FlyToSmooth, Camera: target.Camera()}
+		if k == 0 {
+			fly.Mode = FlyToBounce
+		}
+		if timed {
+			fly.When = t.When[i]
+		}
+		tour.Playlist = append(tour.Playlist, fly)
+	}
+	return tour
+}
+
+// fillHeadings replaces each NaN heading with the last heading before it,
+// or if there is none the first after it, or north.
+func fillHeadings(headings []float64) {
+	last := math.NaN()
+	for _, h := range headings {
+		if !math.IsNaN(h) {
+			last = h
+			break
+		}
+	}
+	if math.IsNaN(last) {
+		last = 0
+	}
+	for k, h := range headings {
+		if math.IsNaN(h) {
+			headings[k] = last
+		} else {
+			last = h
+		}
+	}
+}
+
+// tourAltitude returns the altitude mode of the camera target for a track
+// with mode m, and whether the target takes the altitude of the track.
+// Clamped tracks are watched from above the ground or sea floor.
+func tourAltitude(m AltitudeMode) (AltitudeMode, bool) {
+	switch m {
+	case AltitudeModeAbsolute, AltitudeModeRelativeToGround, AltitudeModeRelativeToSeaFloor:
+		return m, true
+	case AltitudeModeClampToSeaFloor:
+		return AltitudeModeRelativeToSeaFloor, false
+	}
+	return AltitudeModeRelativeToGround, false
+}
+
+// TrackPlayback returns a document holding t as a placemark and a tour
+// of it made by TourFromTrack, both named name. Written with WriteFile to
+// a ".kmz" path, it is a flight ready to play in Google Earth.
+func TrackPlayback(name string, t *Track, opts TourOptions) *KML {
+	tour := TourFromTrack(t, opts)
+	tour.Name = name
+	k := NewKML()
+	k.Feature = &Document{
+		Name: name,
+		Features: []Feature{
+			&Placemark{Name: name, Geometry: t},
+			tour,
+		},
+	}
+	return k
+}
@@ -0,0 +1,140 @@
+package kml
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// northTrack returns a timed track heading north, one point a minute
+func northTrack(n int) *Track {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tr := &Track{AltitudeMode: AltitudeModeAbsolute}
+	for i := 0; i < n; i++ {
+		tr.When = append(tr.When, start.Add(time.Duration(i)*time.Minute))
+		tr.Coords = append(tr.Coords, Coordinate{Lon: 10, Lat: 50 + float64(i)*0.01, Alt: 1000})
+	}
+	return tr
+}
+
+// TestTourFromTrack tests the keyframes of a timed track
+func TestTourFromTrack(t *testing.T) {
+	tr := northTrack(4)
+	tour := TourFromTrack(tr, TourOptions{Speed: 60, Range: 1000, Tilt: 90})
+
+	if len(tour.Playlist) != 4 {
+		t.Fatalf("Expected 4 keyframes, got %d", len(tour.Playlist))
+	}
+	for i, p := range tour.Playlist {
+		fly := p.(*FlyTo)
+		wantDur, wantMode := 1.0, FlyToSmooth
+		if i == 0 {
+			wantDur, wantMode = 3, FlyToBounce
+		}
+		if !floatNear(fly.Duration, wantDur, 1e-9) || fly.Mode != wantMode {
+			t.Errorf("Keyframe %d: expected %s for %v s, got %s for %v s", i, wantMode, wantDur, fly.Mode, fly.Duration)
+		}
+		if !fly.When.Equal(tr.When[i]) {
+			t.Errorf("Keyframe %d: expected time %v, got %v", i, tr.When[i], fly.When)
+		}
+
+		c := fly.Camera
+		if !floatNear(c.Heading, 0, 1e-6) || c.Tilt != 90 || c.AltitudeMode != AltitudeModeAbsolute {
+			t.Errorf("Keyframe %d: expected level absolute camera facing north, got %+v", i, c)
+		}
+		// Level with the track and 1 km south of the point
+		pos := Coordinate{Lon: c.Longitude, Lat: c.Latitude}
+		if d := pos.DistanceTo(Coordinate{Lon: 10, Lat: tr.Coords[i].Lat}); !floatNear(d, 1000, 1) {
+			t.Errorf("Keyframe %d: expected camera 1000 m back, got %v", i, d)
+		}
+		if c.Latitude >= tr.Coords[i].Lat || !floatNear(c.Altitude, 1000, 1e-6) {
+			t.Errorf("Keyframe %d: expected camera south of the point at 1000 m, got %+v", i, c)
+		}
+	}
+}
+
+// TestTourFromTrackOptions tests intervals, headings and untimed tracks
+func TestTourFromTrackOptions(t *testing.T) {
+	t.Run("interval", func(t *testing.T) {
+		tour := TourFromTrack(northTrack(10), TourOptions{Speed: 60, Interval: 2.5})
+		var durations []float64
+		for _, p := range tour.Playlist[1:] {
+			durations = append(durations, p.(*FlyTo).Duration)
+		}
+		// Points 3, 6 and 9, the last kept although closer
+		want := []float64{3, 3, 3}
+		if len(durations) != len(want) {
+			t.Fatalf("Expected durations %v, got %v", want, durations)
+		}
+		for i := range want {
+			if !floatNear(durations[i], want[i], 1e-9) {
+				t.Errorf("Expected durations %v, got %v", want, durations)
+			}
+		}
+	})
+
+	t.Run("heading offset", func(t *testing.T) {
+		tour := TourFromTrack(northTrack(2), TourOptions{Heading: 90})
+		c := tour.Playlist[0].(*FlyTo).Camera
+		if !floatNear(c.Heading, 90, 1e-6) || c.Longitude >= 10 {
+			t.Errorf("Expected camera west of the track facing east, got %+v", c)
+		}
+	})
+
+	t.Run("stationary points", func(t *testing.T) {
+		tr := &Track{Coords: []Coordinate{{Lon: 0, Lat: 0}, {Lon: 0, Lat: 0}, {Lon: 0.01, Lat: 0}, {Lon: 0.01, Lat: 0}}}
+		tour := TourFromTrack(tr, TourOptions{})
+		for i, p := range tour.Playlist {
+			if h := p.(*FlyTo).Camera.Heading; !floatNear(h, 90, 1e-6) {
+				t.Errorf("Keyframe %d: expected heading 90, got %v", i, h)
+			}
+		}
+	})
+
+	t.Run("untimed", func(t *testing.T) {
+		tr := &Track{Coords: []Coordinate{{Lon: 0, Lat: 0}, {Lon: 0, Lat: 0.01}}}
+		tour := TourFromTrack(tr, TourOptions{Pace: 50})
+		fly := tour.Playlist[1].(*FlyTo)
+		want := tr.Coords[0].DistanceTo(tr.Coords[1]) / 50
+		if !floatNear(fly.Duration, want, 1e-9) || !fly.When.IsZero() {
+			t.Errorf("Expected untimed FlyTo of %v s, got %+v", want, fly)
+		}
+		if fly.Camera.AltitudeMode != AltitudeModeRelativeToGround {
+			t.Errorf("Expected clamped track to be watched relative to ground, got %q", fly.Camera.AltitudeMode)
+		}
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		if tour := TourFromTrack(&Track{}, TourOptions{}); len(tour.Playlist) != 0 {
+			t.Errorf("Expected empty playlist, got %d steps", len(tour.Playlist))
+		}
+	})
+}
+
+// TestTrackPlayback tests writing and reading a playback KMZ
+func TestTrackPlayback(t *testing.T) {
+	k := TrackPlayback("Morning ride", northTrack(5), TourOptions{Speed: 30})
+	path := filepath.Join(t.TempDir(), "ride.kmz")
+	if err := k.WriteFile(path); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	back, err := ParseFile(path)
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+
+	doc := back.Feature.(*Document)
+	if doc.Name != "Morning ride" || len(doc.Features) != 2 {
+		t.Fatalf("Expected document with track and tour, got %+v", doc)
+	}
+	if pm, ok := doc.Features[0].(*Placemark); !ok || pm.Geometry.(*Track) == nil {
+		t.Errorf("Expected track placemark, got %+v", doc.Features[0])
+	}
+	tour, ok := doc.Features[1].(*Tour)
+	if !ok || tour.Name != "Morning ride" || len(tour.Playlist) != 5 {
+		t.Fatalf("Expected tour of 5 keyframes, got %+v", doc.Features[1])
+	}
+	if d := tour.Duration(); d != 11*time.Second {
+		t.Errorf("Expected 11s tour, got %v", d)
+	}
+}
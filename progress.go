@@ -0,0 +1,143 @@
+package kml
+
+import (
+	"encoding/xml"
+	"io"
+	"os"
+)
+
+// Progress describes how far a parse or write has got.
+type Progress struct {
+	Features      int   // Features parsed or written so far
+	TotalFeatures int   // Features in the document being written; 0 when parsing or streaming
+	Bytes         int64 // Bytes read or written so far
+	TotalBytes    int64 // Size of the input being parsed, if known; otherwise 0
+}
+
+// Percent returns how complete the operation is, from 0 to 100, measured in
+// bytes when the total is known and in features otherwise. It returns -1
+// if neither total is known.
+func (p Progress) Percent() float64 {
+	switch {
+	case p.TotalBytes > 0:
+		return 100 * float64(min(p.Bytes, p.TotalBytes)) / float64(p.TotalBytes)
+	case p.TotalFeatures > 0:
+		return 100 * float64(min(p.Features, p.TotalFeatures)) / float64(p.TotalFeatures)
+	}
+	return -1
+}
+
+// ProgressFunc receives progress updates. It is called synchronously from
+// the goroutine doing the work, so it should return quickly; callers that
+// redraw a progress bar typically throttle on time or on Percent.
+type ProgressFunc func(Progress)
+
+// ParseProgress makes Parse call fn each time input is read and each time a
+// feature has been parsed, and once more when parsing completes. Bytes
+// counts the input as given, before any gzip or KMZ decompression.
+// TotalBytes is known for ParseFile, ParseBytes and readers with a Len or
+// Stat method, such as *bytes.Reader and *os.File; once parsing completes
+// it is set to Bytes, so the final update reports 100 percent.
+func ParseProgress(fn ProgressFunc) ParseOption {
+	return func(c *parseConfig) {
+		c.progress = &progressTracker{fn: fn}
+	}
+}
+
+// WriteProgress makes Write, WriteIndent, WriteFile and Bytes call fn each
+// time output is written and each time a feature has been encoded.
+// Features are counted before writing starts, so Percent measures the
+// features written. With a StreamEncoder the total is unknown and only
+// Features and Bytes are reported.
+func WriteProgress(fn ProgressFunc) WriteOption {
+	return func(c *writeConfig) {
+		c.progress = &progressTracker{fn: fn}
+	}
+}
+
+// progressTracker accumulates the progress of one operation.
+type progressTracker struct {
+	fn ProgressFunc
+	p  Progress
+}
+
+func (t *progressTracker) addBytes(n int) {
+	if n > 0 {
+		t.p.Bytes += int64(n)
+		t.fn(t.p)
+	}
+}
+
+func (t *progressTracker) addFeature() {
+	t.p.Features++
+	t.fn(t.p)
+}
+
+// done sends the final update of a parse.
+func (t *progressTracker) done() {
+	t.p.TotalBytes = t.p.Bytes
+	t.fn(t.p)
+}
+
+// progressReader counts the bytes read through it.
+type progressReader struct {
+	r io.Reader
+	t *progressTracker
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.t.addBytes(n)
+	return n, err
+}
+
+// progressWriter counts the bytes written through it.
+type progressWriter struct {
+	w io.Writer
+	t *progressTracker
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.t.addBytes(n)
+	return n, err
+}
+
+// inputSize returns the number of bytes remaining in r, or 0 if unknown.
+func inputSize(r io.Reader) int64 {
+	switch r := r.(type) {
+	case interface{ Len() int }:
+		return int64(r.Len())
+	case interface{ Stat() (os.FileInfo, error) }:
+		if fi, err := r.Stat(); err == nil && fi.Mode().IsRegular() {
+			return fi.Size()
+		}
+	}
+	return 0
+}
+
+// featureParsed records a parsed feature with the progress hook and
+// metrics of d, if any.
+func featureParsed(d *xml.Decoder, f Feature) {
+	cfg := parseConfigFor(d)
+	if cfg == nil {
+		return
+	}
+	if cfg.progress != nil {
+		cfg.progress.addFeature()
+	}
+	if cfg.stats != nil {
+		cfg.stats.Features[f.featureType()]++
+	}
+	if cfg.spans != nil {
+		endSpan(d, cfg.spans, f)
+	}
+}
+
+// featureWritten records an encoded feature with the progress hook of e, if
+// any.
+func featureWritten(e *xml.Encoder) {
+	if cfg := writeConfigFor(e); cfg != nil && cfg.progress != nil {
+		cfg.progress.addFeature()
+	}
+}
@@ -0,0 +1,116 @@
+package kml
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const progressDoc = `<kml xmlns="http://www.opengis.net/kml/2.2"><Document>
+  <Folder><Placemark><name>A</name></Placemark><Placemark><name>B</name></Placemark></Folder>
+  <GroundOverlay><name>C</name></GroundOverlay>
+  <NetworkLink><name>D</name></NetworkLink>
+  <ScreenOverlay><name>E</name></ScreenOverlay>
+</Document></kml>`
+
+// TestParseProgress tests progress updates while parsing
+func TestParseProgress(t *testing.T) {
+	var updates []Progress
+	_, err := Parse(strings.NewReader(progressDoc), ParseProgress(func(p Progress) {
+		updates = append(updates, p)
+	}))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if len(updates) < 2 {
+		t.Fatalf("Expected several updates, got %d", len(updates))
+	}
+	for i := 1; i < len(updates); i++ {
+		if updates[i].Bytes < updates[i-1].Bytes || updates[i].Features < updates[i-1].Features {
+			t.Fatalf("Expected monotonic progress, got %+v after %+v", updates[i], updates[i-1])
+		}
+	}
+
+	last := updates[len(updates)-1]
+	if last.Features != 7 {
+		t.Errorf("Expected 7 features, got %d", last.Features)
+	}
+	if last.Bytes != int64(len(progressDoc)) || last.TotalBytes != int64(len(progressDoc)) {
+		t.Errorf("Expected %d of %d bytes, got %d of %d", len(progressDoc), len(progressDoc), last.Bytes, last.TotalBytes)
+	}
+	if last.Percent() != 100 {
+		t.Errorf("Expected 100 percent, got %f", last.Percent())
+	}
+}
+
+// TestParseFileProgress tests that the file size is used as the total
+func TestParseFileProgress(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "doc.kml")
+	if err := os.WriteFile(path, []byte(progressDoc), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var first *Progress
+	if _, err := ParseFile(path, ParseProgress(func(p Progress) {
+		if first == nil {
+			first = &p
+		}
+	})); err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+	if first == nil || first.TotalBytes != int64(len(progressDoc)) {
+		t.Errorf("Expected total of %d bytes from the first update, got %+v", len(progressDoc), first)
+	}
+}
+
+// TestWriteProgress tests progress updates while writing
+func TestWriteProgress(t *testing.T) {
+	k, err := ParseBytes([]byte(progressDoc))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	var last Progress
+	if err := k.WriteIndent(&buf, "", "  ", WriteProgress(func(p Progress) { last = p })); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	if last.Features != 7 || last.TotalFeatures != 7 {
+		t.Errorf("Expected 7 of 7 features, got %d of %d", last.Features, last.TotalFeatures)
+	}
+	if last.Bytes != int64(buf.Len()) {
+		t.Errorf("Expected %d bytes, got %d", buf.Len(), last.Bytes)
+	}
+	if last.Percent() != 100 {
+		t.Errorf("Expected 100 percent, got %f", last.Percent())
+	}
+}
+
+// TestStreamEncoderProgress tests progress updates while streaming
+func TestStreamEncoderProgress(t *testing.T) {
+	var buf bytes.Buffer
+	var last Progress
+	enc := NewStreamEncoder(&buf, "stream", WriteProgress(func(p Progress) { last = p }))
+	for i := 0; i < 3; i++ {
+		if err := enc.Encode(&Placemark{Name: "P"}); err != nil {
+			t.Fatalf("Encode failed: %v", err)
+		}
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	if last.Features != 3 {
+		t.Errorf("Expected 3 features, got %d", last.Features)
+	}
+	if last.Bytes != int64(buf.Len()) {
+		t.Errorf("Expected %d bytes, got %d", buf.Len(), last.Bytes)
+	}
+	if last.Percent() != -1 {
+		t.Errorf("Expected unknown percent, got %f", last.Percent())
+	}
+}
@@ -0,0 +1,176 @@
+package kml
+
+import (
+	"math"
+	"sort"
+)
+
+// ColorStop is a color at a position in [0, 1] along a Gradient.
+type ColorStop struct {
+	Pos   float64
+	Color Color
+}
+
+// Gradient is a ColorRamp through any number of color stops. Colors between
+// stops are interpolated in the OKLab color space, so lightness changes
+// evenly and blends avoid the muddy midpoints of channel-wise mixing. Alpha
+// is interpolated linearly.
+type Gradient struct {
+	stops []ColorStop
+}
+
+// NewGradient returns a Gradient through the colors, evenly spaced from the
+// start of the ramp to its end.
+func NewGradient(colors ...Color) *Gradient {
+	stops := make([]ColorStop, len(colors))
+	for i, c := range colors {
+		pos := 0.0
+		if len(colors) > 1 {
+			pos = float64(i) / float64(len(colors)-1)
+		}
+		stops[i] = ColorStop{Pos: pos, Color: c}
+	}
+	return &Gradient{stops: stops}
+}
+
+// NewGradientStops returns a Gradient through stops at custom positions.
+// The stops need not be sorted.
+func NewGradientStops(stops ...ColorStop) *Gradient {
+	sorted := append([]ColorStop(nil), stops...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Pos < sorted[j].Pos })
+	return &Gradient{stops: sorted}
+}
+
+// At implements ColorRamp. Positions outside the stops take the color of the
+// nearest end, and NaN, as NumericData gives for missing values, takes the
+// color of the first stop.
+func (g *Gradient) At(t float64) Color {
+	if len(g.stops) == 0 {
+		return Color{}
+	}
+	if t <= g.stops[0].Pos || math.IsNaN(t) {
+		return g.stops[0].Color
+	}
+	last := g.stops[len(g.stops)-1]
+	if t >= last.Pos {
+		return last.Color
+	}
+	i := sort.Search(len(g.stops), func(i int) bool { return g.stops[i].Pos > t })
+	a, b := g.stops[i-1], g.stops[i]
+	return mixOKLab(a.Color, b.Color, (t-a.Pos)/(b.Pos-a.Pos))
+}
+
+// Reverse returns the gradient running in the opposite direction.
+func (g *Gradient) Reverse() *Gradient {
+	stops := make([]ColorStop, len(g.stops))
+	for i, s := range g.stops {
+		stops[len(stops)-1-i] = ColorStop{Pos: 1 - s.Pos, Color: s.Color}
+	}
+	return &Gradient{stops: stops}
+}
+
+// Steps samples n evenly spaced colors from a ramp, including both ends.
+func Steps(ramp ColorRamp, n int) []Color {
+	colors := make([]Color, n)
+	for i := range colors {
+		t := 0.0
+		if n > 1 {
+			t = float64(i) / float64(n-1)
+		}
+		colors[i] = ramp.At(t)
+	}
+	return colors
+}
+
+// Predefined ramps.
+var (
+	// Viridis is the perceptually uniform dark-purple to yellow ramp from matplotlib.
+	Viridis = NewGradient(
+		RGBA(0x44, 0x01, 0x54, 255),
+		RGBA(0x47, 0x2d, 0x7b, 255),
+		RGBA(0x3b, 0x52, 0x8b, 255),
+		RGBA(0x2c, 0x72, 0x8e, 255),
+		RGBA(0x21, 0x91, 0x8c, 255),
+		RGBA(0x28, 0xae, 0x80, 255),
+		RGBA(0x5e, 0xc9, 0x62, 255),
+		RGBA(0xad, 0xdc, 0x30, 255),
+		RGBA(0xfd, 0xe7, 0x25, 255),
+	)
+
+	// RdYlGn is the diverging red-yellow-green ramp from ColorBrewer.
+	RdYlGn = NewGradient(
+		RGBA(0xd7, 0x30, 0x27, 255),
+		RGBA(0xf4, 0x6d, 0x43, 255),
+		RGBA(0xfd, 0xae, 0x61, 255),
+		RGBA(0xfe, 0xe0, 0x8b, 255),
+		RGBA(0xff, 0xff, 0xbf, 255),
+		RGBA(0xd9, 0xef, 0x8b, 255),
+		RGBA(0xa6, 0xd9, 0x6a, 255),
+		RGBA(0x66, 0xbd, 0x63, 255),
+		RGBA(0x1a, 0x98, 0x50, 255),
+	)
+)
+
+// oklab is a color in the OKLab perceptual color space.
+type oklab struct {
+	l, a, b float64
+}
+
+// mixOKLab interpolates between two colors in OKLab.
+func mixOKLab(x, y Color, t float64) Color {
+	p, q := toOKLab(x), toOKLab(y)
+	c := fromOKLab(oklab{
+		l: p.l + (q.l-p.l)*t,
+		a: p.a + (q.a-p.a)*t,
+		b: p.b + (q.b-p.b)*t,
+	})
+	c.A = uint8(float64(x.A) + (float64(y.A)-float64(x.A))*t + 0.5)
+	return c
+}
+
+// toOKLab converts the RGB channels of an sRGB color to OKLab.
+func toOKLab(c Color) oklab {
+	r, g, b := srgbToLinear(c.R), srgbToLinear(c.G), srgbToLinear(c.B)
+	l := math.Cbrt(0.4122214708*r + 0.5363325363*g + 0.0514459929*b)
+	m := math.Cbrt(0.2119034982*r + 0.6806995451*g + 0.1073969566*b)
+	s := math.Cbrt(0.0883024619*r + 0.2817188376*g + 0.6299787005*b)
+	return oklab{
+		l: 0.2104542553*l + 0.7936177850*m - 0.0040720468*s,
+		a: 1.9779984951*l - 2.4285922050*m + 0.4505937099*s,
+		b: 0.0259040371*l + 0.7827717662*m - 0.8086757660*s,
+	}
+}
+
+// fromOKLab converts an OKLab color to opaque sRGB, clamping colors outside
+// the sRGB gamut.
+func fromOKLab(c oklab) Color {
+	l := c.l + 0.3963377774*c.a + 0.2158037573*c.b
+	m := c.l - 0.1055613458*c.a - 0.0638541728*c.b
+	s := c.l - 0.0894841775*c.a - 1.2914855480*c.b
+	l, m, s = l*l*l, m*m*m, s*s*s
+	return Color{
+		A: 255,
+		R: linearToSRGB(4.0767416621*l - 3.3077115913*m + 0.2309699292*s),
+		G: linearToSRGB(-1.2684380046*l + 2.6097574011*m - 0.3413193965*s),
+		B: linearToSRGB(-0.0041960863*l - 0.7034186147*m + 1.7076147010*s),
+	}
+}
+
+// srgbToLinear converts a gamma-encoded sRGB channel to linear light in [0, 1].
+func srgbToLinear(v uint8) float64 {
+	x := float64(v) / 255
+	if x <= 0.04045 {
+		return x / 12.92
+	}
+	return math.Pow((x+0.055)/1.055, 2.4)
+}
+
+// linearToSRGB converts linear light to a gamma-encoded sRGB channel.
+func linearToSRGB(x float64) uint8 {
+	if x <= 0.0031308 {
+		x *= 12.92
+	} else {
+		x = 1.055*math.Pow(x, 1/2.4) - 0.055
+	}
+	return uint8(math.Max(0, math.Min(1, x))*255 + 0.5)
+}
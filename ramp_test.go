@@ -0,0 +1,96 @@
+package kml
+
+import (
+	"math"
+	"testing"
+)
+
+// TestOKLabRoundTrip tests conversion to OKLab and back
+func TestOKLabRoundTrip(t *testing.T) {
+	colors := []Color{White, Black, Red, Green, Blue, RGBA(12, 200, 77, 255), RGBA(250, 128, 3, 255)}
+
+	for _, c := range colors {
+		if got := fromOKLab(toOKLab(c)); got != c {
+			t.Errorf("Expected %s to round-trip, got %s", c.Hex(), got.Hex())
+		}
+	}
+
+	lab := toOKLab(White)
+	if !floatNear(lab.l, 1, 1e-6) || !floatNear(lab.a, 0, 1e-6) || !floatNear(lab.b, 0, 1e-6) {
+		t.Errorf("Expected white to be (1, 0, 0), got %+v", lab)
+	}
+}
+
+// TestGradient tests interpolation between gradient stops
+func TestGradient(t *testing.T) {
+	g := NewGradient(Black, White)
+
+	tests := []struct {
+		name string
+		t    float64
+		want Color
+	}{
+		{"start", 0, Black},
+		{"end", 1, White},
+		{"below range", -1, Black},
+		{"above range", 2, White},
+		{"NaN", math.NaN(), Black},
+		{"perceptual midpoint", 0.5, RGBA(99, 99, 99, 255)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := g.At(tt.t); got != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want.Hex(), got.Hex())
+			}
+		})
+	}
+
+	fade := NewGradient(RGBA(255, 0, 0, 0), RGBA(255, 0, 0, 255))
+	if got := fade.At(0.5).A; got != 128 {
+		t.Errorf("Expected alpha to interpolate linearly to 128, got %d", got)
+	}
+}
+
+// TestGradientStops tests custom stop positions and reversal
+func TestGradientStops(t *testing.T) {
+	g := NewGradientStops(
+		ColorStop{Pos: 1, Color: Blue},
+		ColorStop{Pos: 0, Color: Red},
+		ColorStop{Pos: 0.2, Color: Green},
+	)
+	if got := g.At(0.2); got != Green {
+		t.Errorf("Expected green at its stop, got %s", got.Hex())
+	}
+	if got := g.At(0.1); got == Red || got == Green {
+		t.Errorf("Expected a blend between red and green, got %s", got.Hex())
+	}
+
+	r := g.Reverse()
+	if r.At(0) != Blue || r.At(1) != Red || r.At(0.8) != Green {
+		t.Errorf("Unexpected reversed gradient: %s %s %s", r.At(0).Hex(), r.At(0.8).Hex(), r.At(1).Hex())
+	}
+
+	if got := (&Gradient{}).At(0.5); got != (Color{}) {
+		t.Errorf("Expected zero color from empty gradient, got %s", got.Hex())
+	}
+}
+
+// TestPredefinedRamps tests the endpoints of the built-in ramps
+func TestPredefinedRamps(t *testing.T) {
+	steps := Steps(Viridis, 3)
+	if len(steps) != 3 {
+		t.Fatalf("Expected 3 steps, got %d", len(steps))
+	}
+	if steps[0] != RGBA(0x44, 0x01, 0x54, 255) || steps[2] != RGBA(0xfd, 0xe7, 0x25, 255) {
+		t.Errorf("Unexpected viridis endpoints %s and %s", steps[0].Hex(), steps[2].Hex())
+	}
+	if steps[1] != RGBA(0x21, 0x91, 0x8c, 255) {
+		t.Errorf("Expected viridis midpoint to hit its stop, got %s", steps[1].Hex())
+	}
+
+	red, green := RdYlGn.At(0), RdYlGn.At(1)
+	if red.R <= red.G || green.G <= green.R {
+		t.Errorf("Expected RdYlGn to run red to green, got %s to %s", red.Hex(), green.Hex())
+	}
+}
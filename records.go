@@ -0,0 +1,157 @@
+package kml
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ColumnSpec is a column of the table produced by Records: a header and
+// the value of a placemark in that column. Value is given the placemark
+// and the names of the Documents and Folders containing it, outermost
+// first; an error from it stops Records.
+type ColumnSpec struct {
+	Header string
+	Value  func(pm *Placemark, folders []string) (string, error)
+}
+
+// ColumnName is a "name" column holding placemark names.
+func ColumnName() ColumnSpec {
+	return ColumnSpec{Header: "name", Value: func(pm *Placemark, _ []string) (string, error) {
+		return pm.Name, nil
+	}}
+}
+
+// ColumnID is an "id" column holding placemark IDs.
+func ColumnID() ColumnSpec {
+	return ColumnSpec{Header: "id", Value: func(pm *Placemark, _ []string) (string, error) {
+		return pm.ID, nil
+	}}
+}
+
+// ColumnLon is a "lon" column holding the longitude of Point placemarks,
+// and empty for other geometries.
+func ColumnLon() ColumnSpec {
+	return pointColumn("lon", func(c Coordinate) float64 { return c.Lon })
+}
+
+// ColumnLat is a "lat" column holding the latitude of Point placemarks,
+// and empty for other geometries.
+func ColumnLat() ColumnSpec {
+	return pointColumn("lat", func(c Coordinate) float64 { return c.Lat })
+}
+
+// ColumnAlt is an "alt" column holding the altitude of Point placemarks,
+// and empty for other geometries.
+func ColumnAlt() ColumnSpec {
+	return pointColumn("alt", func(c Coordinate) float64 { return c.Alt })
+}
+
+// pointColumn is a column holding a part of a placemark's Point.
+func pointColumn(header string, part func(Coordinate) float64) ColumnSpec {
+	return ColumnSpec{Header: header, Value: func(pm *Placemark, _ []string) (string, error) {
+		c, ok := pointOf(pm)
+		if !ok {
+			return "", nil
+		}
+		return strconv.FormatFloat(part(c), 'f', -1, 64), nil
+	}}
+}
+
+// ColumnData is a column headed name holding the ExtendedData value of
+// that name, from Data or SchemaData, and empty where it is missing.
+func ColumnData(name string) ColumnSpec {
+	return ColumnSpec{Header: name, Value: func(pm *Placemark, _ []string) (string, error) {
+		v, _ := pm.dataValue(name)
+		return v, nil
+	}}
+}
+
+// ColumnPath is a "folder" column holding the names of the containers of
+// a placemark joined by sep, such as "Harbour/Docks". Unnamed containers
+// are left out.
+func ColumnPath(sep string) ColumnSpec {
+	return ColumnSpec{Header: "folder", Value: func(_ *Placemark, folders []string) (string, error) {
+		return strings.Join(folders, sep), nil
+	}}
+}
+
+// Records flattens the placemarks of the document into a table with one
+// row per placemark, in document order, after a first row of the column
+// headers. The rows suit encoding/csv directly, or database inserts after
+// the header:
+//
+//	rows, err := k.Records(kml.ColumnName(), kml.ColumnLon(), kml.ColumnLat(),
+//		kml.ColumnData("population"), kml.ColumnPath("/"))
+//	csv.NewWriter(os.Stdout).WriteAll(rows)
+//
+// Placemarks inside NetworkLinks are not fetched.
+func (k *KML) Records(columns ...ColumnSpec) ([][]string, error) {
+	header, err := columnHeaders(columns)
+	if err != nil {
+		return nil, err
+	}
+	rows := [][]string{header}
+	err = k.eachRecord(columns, func(_ *Placemark, row []string) {
+		rows = append(rows, row)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// columnHeaders returns the headers of columns, checking that there are
+// columns and that each has a Value.
+func columnHeaders(columns []ColumnSpec) ([]string, error) {
+	if len(columns) == 0 {
+		return nil, errors.New("kml: no columns")
+	}
+	header := make([]string, len(columns))
+	for i, col := range columns {
+		if col.Value == nil {
+			return nil, fmt.Errorf("kml: column %q has no Value", col.Header)
+		}
+		header[i] = col.Header
+	}
+	return header, nil
+}
+
+// eachRecord calls fn with each placemark in document order and its row
+// of column values.
+func (k *KML) eachRecord(columns []ColumnSpec, fn func(pm *Placemark, row []string)) error {
+	var visit func(f Feature, folders []string) error
+	visit = func(f Feature, folders []string) error {
+		var children []Feature
+		switch feature := f.(type) {
+		case *Placemark:
+			row := make([]string, len(columns))
+			for i, col := range columns {
+				v, err := col.Value(feature, folders)
+				if err != nil {
+					return fmt.Errorf("kml: column %q of placemark %q: %w", col.Header, feature.Name, err)
+				}
+				row[i] = v
+			}
+			fn(feature, row)
+			return nil
+		case *Document:
+			children = feature.Features
+		case *Folder:
+			children = feature.Features
+		default:
+			return nil
+		}
+		if name := featureName(f); name != "" {
+			folders = append(folders[:len(folders):len(folders)], name)
+		}
+		for _, child := range children {
+			if err := visit(child, folders); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return visit(k.Feature, nil)
+}
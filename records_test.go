@@ -0,0 +1,101 @@
+package kml
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// recordsTestKML returns placemarks in nested and unnamed containers
+func recordsTestKML() *KML {
+	k := NewKML()
+	k.Feature = &Document{Name: "Cities", Features: []Feature{
+		&Folder{Name: "Europe", Features: []Feature{
+			&Folder{Name: "France", Features: []Feature{
+				&Placemark{ID: "paris", Name: "Paris", Geometry: &Point{Coordinates: Coord(2.3522, 48.8566)},
+					ExtendedData: &ExtendedData{Data: []Data{{Name: "population", Value: "2102650"}}}},
+			}},
+			&Folder{Features: []Feature{
+				&Placemark{Name: "Rhine", Geometry: &LineString{Coordinates: []Coordinate{Coord(7.6, 47.6), Coord(6.1, 51.8)}}},
+			}},
+		}},
+		&Placemark{Name: "Lima", Geometry: &Point{Coordinates: Coord(-77.0428, -12.0464, 154)},
+			ExtendedData: &ExtendedData{SchemaData: []SchemaData{{SimpleData: []SimpleData{{Name: "population", Value: "10092000"}}}}}},
+	}}
+	return k
+}
+
+// TestRecords tests flattening placemarks into rows
+func TestRecords(t *testing.T) {
+	rows, err := recordsTestKML().Records(ColumnID(), ColumnName(), ColumnLon(), ColumnLat(), ColumnAlt(),
+		ColumnData("population"), ColumnPath("/"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	want := [][]string{
+		{"id", "name", "lon", "lat", "alt", "population", "folder"},
+		{"paris", "Paris", "2.3522", "48.8566", "0", "2102650", "Cities/Europe/France"},
+		{"", "Rhine", "", "", "", "", "Cities/Europe"},
+		{"", "Lima", "-77.0428", "-12.0464", "154", "10092000", "Cities"},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("Expected %v, got %v", want, rows)
+	}
+}
+
+// TestRecordsCustomColumn tests a custom column and its errors
+func TestRecordsCustomColumn(t *testing.T) {
+	upper := ColumnSpec{Header: "NAME", Value: func(pm *Placemark, _ []string) (string, error) {
+		return strings.ToUpper(pm.Name), nil
+	}}
+	rows, err := recordsTestKML().Records(upper)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if want := [][]string{{"NAME"}, {"PARIS"}, {"RHINE"}, {"LIMA"}}; !reflect.DeepEqual(rows, want) {
+		t.Errorf("Expected %v, got %v", want, rows)
+	}
+
+	errNoGeometry := errors.New("not a point")
+	strict := ColumnSpec{Header: "point", Value: func(pm *Placemark, _ []string) (string, error) {
+		if _, ok := pm.Geometry.(*Point); !ok {
+			return "", errNoGeometry
+		}
+		return "yes", nil
+	}}
+	_, err = recordsTestKML().Records(strict)
+	if !errors.Is(err, errNoGeometry) || !strings.Contains(err.Error(), `"Rhine"`) {
+		t.Errorf("Expected an error naming Rhine, got %v", err)
+	}
+}
+
+// TestRecordsErrors tests invalid column lists
+func TestRecordsErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		columns []ColumnSpec
+	}{
+		{"no columns", nil},
+		{"no value", []ColumnSpec{ColumnName(), {Header: "empty"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := recordsTestKML().Records(tt.columns...); err == nil {
+				t.Errorf("Expected an error")
+			}
+		})
+	}
+}
+
+// TestRecordsEmpty tests a document without placemarks
+func TestRecordsEmpty(t *testing.T) {
+	rows, err := NewKML().Records(ColumnName())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if want := [][]string{{"name"}}; !reflect.DeepEqual(rows, want) {
+		t.Errorf("Expected %v, got %v", want, rows)
+	}
+}
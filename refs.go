@@ -0,0 +1,168 @@
+package kml
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ReferenceKind identifies which kind of element holds a reference.
+type ReferenceKind string
+
+const (
+	// ReferenceStyleURL is a styleUrl on a feature.
+	ReferenceStyleURL ReferenceKind = "styleUrl"
+
+	// ReferenceStyleMapPair is the styleUrl of a Pair inside a StyleMap.
+	ReferenceStyleMapPair ReferenceKind = "StyleMap.Pair"
+
+	// ReferenceSchemaURL is the schemaUrl attribute of a SchemaData element.
+	ReferenceSchemaURL ReferenceKind = "schemaUrl"
+
+	// ReferenceNetworkLink is the Link href of a NetworkLink.
+	ReferenceNetworkLink ReferenceKind = "NetworkLink.Link"
+)
+
+// DanglingReference describes a reference that does not resolve to any
+// element declared in the document.
+type DanglingReference struct {
+	Kind    ReferenceKind // Kind of reference that failed to resolve
+	Element string        // Element holding the reference (e.g., "Placemark", "StyleMap")
+	ID      string        // ID of the element holding the reference, if any
+	Name    string        // Name of the element holding the reference, if any
+	URL     string        // The unresolved reference value
+}
+
+// String returns a human-readable description of the dangling reference.
+func (r DanglingReference) String() string {
+	holder := r.Element
+	switch {
+	case r.ID != "":
+		holder = fmt.Sprintf("%s id=%q", r.Element, r.ID)
+	case r.Name != "":
+		holder = fmt.Sprintf("%s %q", r.Element, r.Name)
+	}
+	return fmt.Sprintf("%s: %s %q does not resolve", holder, r.Kind, r.URL)
+}
+
+// CheckReferences returns every reference that does not resolve:
+// styleUrls naming a missing Style or StyleMap, StyleMap pairs pointing
+// nowhere, schemaUrls naming a missing Schema, and NetworkLink hrefs that
+// are relative, which a document has no base to resolve against. Use
+// ResolveHrefs to make them absolute.
+//
+// Style and schema references into other files are not fetched, so only
+// their fragment references ("#id") are checked, and absolute NetworkLink
+// hrefs are taken to resolve. The result is nil when every reference
+// resolves.
+func (k *KML) CheckReferences() []DanglingReference {
+	styles := newStyleIndex(k)
+	schemas := make(map[string]bool)
+
+	k.Walk(func(f Feature) error {
+		if doc, ok := f.(*Document); ok {
+			for _, s := range doc.Schemas {
+				if s.ID != "" {
+					schemas[s.ID] = true
+				}
+			}
+		}
+		return nil
+	})
+
+	var refs []DanglingReference
+
+	k.Walk(func(f Feature) error {
+		switch feature := f.(type) {
+		case *Document:
+			if id, ok := localFragment(feature.StyleURL); ok && !styles.has(id) {
+				refs = append(refs, DanglingReference{
+					Kind:    ReferenceStyleURL,
+					Element: "Document",
+					ID:      feature.ID,
+					Name:    feature.Name,
+					URL:     feature.StyleURL,
+				})
+			}
+			for _, sm := range feature.StyleMaps {
+				for _, pair := range sm.Pairs {
+					if id, ok := localFragment(pair.StyleURL); ok && !styles.has(id) {
+						refs = append(refs, DanglingReference{
+							Kind:    ReferenceStyleMapPair,
+							Element: "StyleMap",
+							ID:      sm.ID,
+							URL:     pair.StyleURL,
+						})
+					}
+				}
+			}
+		case *Folder:
+			if id, ok := localFragment(feature.StyleURL); ok && !styles.has(id) {
+				refs = append(refs, DanglingReference{
+					Kind:    ReferenceStyleURL,
+					Element: "Folder",
+					ID:      feature.ID,
+					Name:    feature.Name,
+					URL:     feature.StyleURL,
+				})
+			}
+		case *GroundOverlay:
+			if id, ok := localFragment(feature.StyleURL); ok && !styles.has(id) {
+				refs = append(refs, DanglingReference{
+					Kind:    ReferenceStyleURL,
+					Element: "GroundOverlay",
+					ID:      feature.ID,
+					Name:    feature.Name,
+					URL:     feature.StyleURL,
+				})
+			}
+		case *NetworkLink:
+			if feature.Link != nil && isRelativeHref(feature.Link.Href) {
+				refs = append(refs, DanglingReference{
+					Kind:    ReferenceNetworkLink,
+					Element: "NetworkLink",
+					ID:      feature.ID,
+					Name:    feature.Name,
+					URL:     feature.Link.Href,
+				})
+			}
+		case *Placemark:
+			if id, ok := localFragment(feature.StyleURL); ok && !styles.has(id) {
+				refs = append(refs, DanglingReference{
+					Kind:    ReferenceStyleURL,
+					Element: "Placemark",
+					ID:      feature.ID,
+					Name:    feature.Name,
+					URL:     feature.StyleURL,
+				})
+			}
+			if feature.ExtendedData != nil {
+				for _, sd := range feature.ExtendedData.SchemaData {
+					if id, ok := localFragment(sd.SchemaURL); ok && !schemas[id] {
+						refs = append(refs, DanglingReference{
+							Kind:    ReferenceSchemaURL,
+							Element: "Placemark",
+							ID:      feature.ID,
+							Name:    feature.Name,
+							URL:     sd.SchemaURL,
+						})
+					}
+				}
+			}
+		}
+		return nil
+	})
+
+	return refs
+}
+
+// isRelativeHref reports whether href is a non-empty reference that is
+// neither absolute nor only a fragment.
+func isRelativeHref(href string) bool {
+	href = strings.TrimSpace(href)
+	if href == "" || strings.HasPrefix(href, "#") {
+		return false
+	}
+	u, err := url.Parse(href)
+	return err != nil || !u.IsAbs()
+}
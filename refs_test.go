@@ -0,0 +1,156 @@
+package kml
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// TestCheckReferences tests detection of dangling styleUrl, StyleMap and schemaUrl references
+func TestCheckReferences(t *testing.T) {
+	kmlData := `<?xml version="1.0" encoding="UTF-8"?>
+<kml xmlns="http://www.opengis.net/kml/2.2">
+  <Document>
+    <Style id="good"><LineStyle><width>2</width></LineStyle></Style>
+    <StyleMap id="map">
+      <Pair><key>normal</key><styleUrl>#good</styleUrl></Pair>
+      <Pair><key>highlight</key><styleUrl>#missingHighlight</styleUrl></Pair>
+    </StyleMap>
+    <Schema id="trail" name="Trail">
+      <SimpleField type="string" name="surface"/>
+    </Schema>
+    <Placemark id="ok">
+      <styleUrl>#map</styleUrl>
+      <ExtendedData>
+        <SchemaData schemaUrl="#trail"><SimpleData name="surface">dirt</SimpleData></SchemaData>
+      </ExtendedData>
+      <Point><coordinates>0,0</coordinates></Point>
+    </Placemark>
+    <Placemark id="badStyle">
+      <styleUrl>#nope</styleUrl>
+      <Point><coordinates>0,0</coordinates></Point>
+    </Placemark>
+    <Placemark>
+      <name>Bad Schema</name>
+      <styleUrl>http://example.com/styles.kml#remote</styleUrl>
+      <ExtendedData>
+        <SchemaData schemaUrl="#road"><SimpleData name="lanes">2</SimpleData></SchemaData>
+      </ExtendedData>
+      <Point><coordinates>0,0</coordinates></Point>
+    </Placemark>
+  </Document>
+</kml>`
+
+	k, err := ParseBytes([]byte(kmlData))
+	if err != nil {
+		t.Fatalf("Failed to parse KML: %v", err)
+	}
+
+	refs := k.CheckReferences()
+	if len(refs) != 3 {
+		t.Fatalf("Expected 3 dangling references, got %d: %v", len(refs), refs)
+	}
+
+	tests := []struct {
+		kind    ReferenceKind
+		element string
+		id      string
+		name    string
+		url     string
+	}{
+		{ReferenceStyleMapPair, "StyleMap", "map", "", "#missingHighlight"},
+		{ReferenceStyleURL, "Placemark", "badStyle", "", "#nope"},
+		{ReferenceSchemaURL, "Placemark", "", "Bad Schema", "#road"},
+	}
+
+	for i, tt := range tests {
+		got := refs[i]
+		if got.Kind != tt.kind || got.Element != tt.element || got.ID != tt.id || got.Name != tt.name || got.URL != tt.url {
+			t.Errorf("refs[%d] = %+v, want kind=%s element=%s id=%q name=%q url=%q",
+				i, got, tt.kind, tt.element, tt.id, tt.name, tt.url)
+		}
+	}
+
+	if s := refs[1].String(); !strings.Contains(s, `Placemark id="badStyle"`) || !strings.Contains(s, "#nope") {
+		t.Errorf("Unexpected String() output: %s", s)
+	}
+}
+
+// TestCheckReferencesNetworkLinks tests reporting relative NetworkLink hrefs
+func TestCheckReferencesNetworkLinks(t *testing.T) {
+	k := NewKML()
+	k.Feature = &Folder{Features: []Feature{
+		&NetworkLink{Name: "absolute", Link: &Link{Href: "https://example.com/live.kml"}},
+		&NetworkLink{Name: "relative", Link: &Link{Href: "tiles/0/0/0.kml"}},
+		&NetworkLink{ID: "rooted", Link: &Link{Href: "/feeds/live.kml"}},
+		&NetworkLink{Name: "fragment", Link: &Link{Href: "#doc"}},
+		&NetworkLink{Name: "no link"},
+		&NetworkLink{Name: "empty", Link: &Link{}},
+	}}
+
+	refs := k.CheckReferences()
+	if len(refs) != 2 {
+		t.Fatalf("Expected 2 dangling references, got %d: %v", len(refs), refs)
+	}
+	if refs[0].Kind != ReferenceNetworkLink || refs[0].Name != "relative" || refs[0].URL != "tiles/0/0/0.kml" {
+		t.Errorf("Unexpected reference %+v", refs[0])
+	}
+	if refs[1].ID != "rooted" || refs[1].URL != "/feeds/live.kml" {
+		t.Errorf("Unexpected reference %+v", refs[1])
+	}
+
+	base, _ := url.Parse("https://example.com/layers/doc.kml")
+	k.ResolveHrefs(*base)
+	if refs := k.CheckReferences(); refs != nil {
+		t.Errorf("Expected resolved hrefs to pass, got %v", refs)
+	}
+}
+
+// TestCheckReferencesClean tests that a fully resolved document reports nothing
+func TestCheckReferencesClean(t *testing.T) {
+	k, err := ParseFile("testdata/KML_Samples.kml")
+	if err != nil {
+		t.Fatalf("Failed to parse KML: %v", err)
+	}
+
+	if refs := k.CheckReferences(); refs != nil {
+		t.Errorf("Expected no dangling references, got %v", refs)
+	}
+}
+
+// TestSchemaRoundTrip tests that Schema declarations survive a write/parse cycle
+func TestSchemaRoundTrip(t *testing.T) {
+	k := NewKML()
+	k.Feature = &Document{
+		Schemas: []Schema{{
+			ID:   "s1",
+			Name: "Site",
+			SimpleFields: []SimpleField{
+				{Type: "int", Name: "elevation", DisplayName: "Elevation"},
+			},
+		}},
+		Features: []Feature{&Placemark{Name: "p"}},
+	}
+
+	data, err := k.Bytes()
+	if err != nil {
+		t.Fatalf("Failed to write KML: %v", err)
+	}
+
+	parsed, err := ParseBytes(data)
+	if err != nil {
+		t.Fatalf("Failed to parse KML: %v", err)
+	}
+
+	doc := parsed.Feature.(*Document)
+	if len(doc.Schemas) != 1 {
+		t.Fatalf("Expected 1 schema, got %d", len(doc.Schemas))
+	}
+	s := doc.Schemas[0]
+	if s.ID != "s1" || s.Name != "Site" || len(s.SimpleFields) != 1 {
+		t.Fatalf("Unexpected schema: %+v", s)
+	}
+	if f := s.SimpleFields[0]; f.Type != "int" || f.Name != "elevation" || f.DisplayName != "Elevation" {
+		t.Errorf("Unexpected simple field: %+v", f)
+	}
+}
@@ -0,0 +1,30 @@
+package kml
+
+// Region restricts when a feature is drawn to the times its bounding box is
+// in view and occupies a suitable number of screen pixels.
+type Region struct {
+	ID           string       `xml:"id,attr,omitempty"`
+	LatLonAltBox LatLonAltBox `xml:"LatLonAltBox"`
+	Lod          *Lod         `xml:"Lod,omitempty"`
+}
+
+// LatLonAltBox is the bounding box of a Region.
+type LatLonAltBox struct {
+	North        float64      `xml:"north"`
+	South        float64      `xml:"south"`
+	East         float64      `xml:"east"`
+	West         float64      `xml:"west"`
+	MinAltitude  float64      `xml:"minAltitude,omitempty"`
+	MaxAltitude  float64      `xml:"maxAltitude,omitempty"`
+	AltitudeMode AltitudeMode `xml:"altitudeMode,omitempty"`
+}
+
+// Lod (level of detail) specifies the projected screen size, in pixels, of
+// a Region's box at which the Region becomes active.
+// A MaxLodPixels of -1 means the Region stays active however large it gets.
+type Lod struct {
+	MinLodPixels  float64 `xml:"minLodPixels"`
+	MaxLodPixels  float64 `xml:"maxLodPixels"`
+	MinFadeExtent float64 `xml:"minFadeExtent,omitempty"`
+	MaxFadeExtent float64 `xml:"maxFadeExtent,omitempty"`
+}
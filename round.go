@@ -0,0 +1,91 @@
+package kml
+
+// RoundCoordinates rounds every coordinate of every Placemark geometry to
+// decimals decimal places, then repairs what rounding broke: consecutive
+// points that became equal are merged, in LineStrings and in rings, and
+// rings are closed again. gx:Track points are rounded but kept, as each
+// has its own time. Unlike the Precision write option, which only changes
+// how coordinates are written, it edits the document, so later Bounds,
+// validity checks and output all see the repaired geometry.
+//
+// A ring left with fewer than three distinct positions has collapsed. A
+// collapsed hole is removed from its Polygon; a collapsed outer boundary
+// or standalone LinearRing is kept, merged and closed, so that no
+// Placemark loses its geometry. RoundCoordinates returns the number of
+// rings that collapsed, which callers can check to choose more decimals.
+func (k *KML) RoundCoordinates(decimals int) int {
+	k.ResetBounds()
+	collapsed := 0
+	k.Walk(func(f Feature) error {
+		if pm, ok := f.(*Placemark); ok && pm.Geometry != nil {
+			roundGeometry(pm.Geometry, decimals)
+			collapsed += repairGeometry(pm.Geometry)
+		}
+		return nil
+	})
+	return collapsed
+}
+
+// repairGeometry merges repeated points of g and recloses its rings,
+// returning the number of rings that collapsed.
+func repairGeometry(g Geometry) int {
+	collapsed := 0
+	switch geom := g.(type) {
+	case *LineString:
+		geom.Coordinates = mergeRepeated(geom.Coordinates)
+	case *LinearRing:
+		if !repairRing(geom) {
+			collapsed++
+		}
+	case *Polygon:
+		if !repairRing(&geom.OuterBoundary) {
+			collapsed++
+		}
+		holes := geom.InnerBoundaries[:0]
+		for i := range geom.InnerBoundaries {
+			if repairRing(&geom.InnerBoundaries[i]) {
+				holes = append(holes, geom.InnerBoundaries[i])
+			} else {
+				collapsed++
+			}
+		}
+		clear(geom.InnerBoundaries[len(holes):])
+		geom.InnerBoundaries = holes
+	case *MultiGeometry:
+		for _, child := range geom.Geometries {
+			collapsed += repairGeometry(child)
+		}
+	}
+	return collapsed
+}
+
+// repairRing merges repeated points of lr and closes it, reporting whether
+// it still has at least three distinct positions. Empty rings are left
+// empty.
+func repairRing(lr *LinearRing) bool {
+	coords := mergeRepeated(lr.Coordinates)
+	if len(coords) == 0 {
+		lr.Coordinates = coords
+		return false
+	}
+	if len(coords) == 1 || coords[len(coords)-1] != coords[0] {
+		coords = append(coords, coords[0])
+	}
+	lr.Coordinates = coords
+	return len(coords) >= 4
+}
+
+// mergeRepeated removes points equal to the one before them, reusing the
+// backing array of coords.
+func mergeRepeated(coords []Coordinate) []Coordinate {
+	if len(coords) < 2 {
+		return coords
+	}
+	merged := coords[:1]
+	for _, c := range coords[1:] {
+		if c != merged[len(merged)-1] {
+			merged = append(merged, c)
+		}
+	}
+	return merged
+}
@@ -0,0 +1,142 @@
+package kml
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestRoundCoordinates tests rounding and the repair of the geometries it breaks
+func TestRoundCoordinates(t *testing.T) {
+	tests := []struct {
+		name      string
+		geometry  Geometry
+		decimals  int
+		want      Geometry
+		collapsed int
+	}{
+		{
+			name:     "point",
+			geometry: &Point{Coordinates: Coord(-122.084123, 37.422049, 10.55)},
+			decimals: 2,
+			want:     &Point{Coordinates: Coord(-122.08, 37.42, 10.55)},
+		},
+		{
+			name: "line merges repeated points",
+			geometry: &LineString{Coordinates: []Coordinate{
+				Coord(1.001, 2.001), Coord(1.002, 2.002), Coord(1.5, 2), Coord(1.004, 2),
+			}},
+			decimals: 2,
+			want: &LineString{Coordinates: []Coordinate{
+				Coord(1, 2), Coord(1.5, 2), Coord(1, 2),
+			}},
+		},
+		{
+			name: "ring reclosed",
+			geometry: &LinearRing{Coordinates: []Coordinate{
+				Coord(0, 0), Coord(1, 0), Coord(1, 1), Coord(0.0001, 0.0001), Coord(0, 0),
+			}},
+			decimals: 2,
+			want: &LinearRing{Coordinates: []Coordinate{
+				Coord(0, 0), Coord(1, 0), Coord(1, 1), Coord(0, 0),
+			}},
+		},
+		{
+			name: "unclosed ring closed",
+			geometry: &LinearRing{Coordinates: []Coordinate{
+				Coord(0, 0), Coord(1, 0), Coord(1, 1),
+			}},
+			decimals: 2,
+			want: &LinearRing{Coordinates: []Coordinate{
+				Coord(0, 0), Coord(1, 0), Coord(1, 1), Coord(0, 0),
+			}},
+		},
+		{
+			name: "collapsed hole removed",
+			geometry: &Polygon{
+				OuterBoundary: LinearRing{Coordinates: []Coordinate{
+					Coord(0, 0), Coord(10, 0), Coord(10, 10), Coord(0, 10), Coord(0, 0),
+				}},
+				InnerBoundaries: []LinearRing{
+					{Coordinates: []Coordinate{
+						Coord(5, 5), Coord(5.001, 5), Coord(5.001, 5.001), Coord(5, 5),
+					}},
+					{Coordinates: []Coordinate{
+						Coord(2, 2), Coord(3, 2), Coord(3, 3), Coord(2, 2),
+					}},
+				},
+			},
+			decimals: 1,
+			want: &Polygon{
+				OuterBoundary: LinearRing{Coordinates: []Coordinate{
+					Coord(0, 0), Coord(10, 0), Coord(10, 10), Coord(0, 10), Coord(0, 0),
+				}},
+				InnerBoundaries: []LinearRing{
+					{Coordinates: []Coordinate{
+						Coord(2, 2), Coord(3, 2), Coord(3, 3), Coord(2, 2),
+					}},
+				},
+			},
+			collapsed: 1,
+		},
+		{
+			name: "collapsed outer boundary kept",
+			geometry: &MultiGeometry{Geometries: []Geometry{
+				&Polygon{OuterBoundary: LinearRing{Coordinates: []Coordinate{
+					Coord(0, 0), Coord(0.01, 0), Coord(0.01, 0.01), Coord(0, 0),
+				}}},
+				&Point{Coordinates: Coord(0.04, 0.04)},
+			}},
+			decimals: 0,
+			want: &MultiGeometry{Geometries: []Geometry{
+				&Polygon{OuterBoundary: LinearRing{Coordinates: []Coordinate{
+					Coord(0, 0), Coord(0, 0),
+				}}},
+				&Point{Coordinates: Coord(0, 0)},
+			}},
+			collapsed: 1,
+		},
+		{
+			name: "track points kept",
+			geometry: &Track{Coords: []Coordinate{
+				Coord(1.001, 2), Coord(1.002, 2),
+			}},
+			decimals: 2,
+			want: &Track{Coords: []Coordinate{
+				Coord(1, 2), Coord(1, 2),
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := &KML{Feature: &Document{Features: []Feature{
+				&Folder{Features: []Feature{&Placemark{Geometry: tt.geometry}}},
+			}}}
+			if n := doc.RoundCoordinates(tt.decimals); n != tt.collapsed {
+				t.Errorf("Expected %d collapsed rings, got %d", tt.collapsed, n)
+			}
+			if !reflect.DeepEqual(tt.geometry, tt.want) {
+				t.Errorf("Expected %+v, got %+v", tt.want, tt.geometry)
+			}
+		})
+	}
+}
+
+// TestRoundCoordinatesCompacted tests that reclosing a ring in an arena leaves its neighbor alone
+func TestRoundCoordinatesCompacted(t *testing.T) {
+	ring := &LinearRing{Coordinates: []Coordinate{Coord(0, 0), Coord(1, 0), Coord(1, 1)}}
+	line := &LineString{Coordinates: []Coordinate{Coord(5, 5), Coord(6, 6)}}
+	doc := &KML{Feature: &Document{Features: []Feature{
+		&Placemark{Geometry: ring},
+		&Placemark{Geometry: line},
+	}}}
+	doc.Compact()
+
+	doc.RoundCoordinates(3)
+	if len(ring.Coordinates) != 4 || ring.Coordinates[3] != Coord(0, 0) {
+		t.Errorf("Expected closed ring, got %v", ring.Coordinates)
+	}
+	if !reflect.DeepEqual(line.Coordinates, []Coordinate{Coord(5, 5), Coord(6, 6)}) {
+		t.Errorf("Expected line unchanged, got %v", line.Coordinates)
+	}
+}
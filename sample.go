@@ -0,0 +1,70 @@
+package kml
+
+import (
+	"sort"
+	"time"
+)
+
+// DecimatePoints returns every nth placemark, starting with the first, to
+// thin dense telemetry such as one position a second. Placemarks keep
+// their input order, so a log should be in time order first. An n of one
+// or less keeps every placemark. The placemarks are not copied.
+func DecimatePoints(pms []*Placemark, n int) []*Placemark {
+	n = max(1, n)
+	out := make([]*Placemark, 0, (len(pms)+n-1)/n)
+	for i := 0; i < len(pms); i += n {
+		out = append(out, pms[i])
+	}
+	return out
+}
+
+// ResampleTrack returns a copy of t with points at regular intervals from
+// its first time, placed along the great circle between the recorded points
+// either side and with the altitude interpolated linearly. The last point
+// is always kept so the track ends where it did. Unlike simplification,
+// which keeps the points that shape the path, resampling evens out the
+// spacing in time, as animations and speed charts need. Gaps in recording
+// are bridged like any other segment.
+//
+// A track that is untimed, has fewer than two points or times out of order
+// is returned as an unchanged copy, as it is for an interval of zero or
+// less.
+func ResampleTrack(t *Track, interval time.Duration) *Track {
+	out := &Track{ID: t.ID, AltitudeMode: t.AltitudeMode}
+	n := len(t.Coords)
+	ordered := sort.SliceIsSorted(t.When, func(i, j int) bool { return t.When[i].Before(t.When[j]) })
+	if interval <= 0 || n < 2 || len(t.When) != n || !ordered {
+		out.When = append([]time.Time(nil), t.When...)
+		out.Coords = append([]Coordinate(nil), t.Coords...)
+		return out
+	}
+
+	end := t.When[n-1]
+	seg := 0
+	for when := t.When[0]; when.Before(end); when = when.Add(interval) {
+		for seg+2 < n && !when.Before(t.When[seg+1]) {
+			seg++
+		}
+		out.When = append(out.When, when)
+		out.Coords = append(out.Coords, interpolateCoordinate(t.Coords[seg], t.Coords[seg+1], t.When[seg], t.When[seg+1], when))
+	}
+	out.When = append(out.When, end)
+	out.Coords = append(out.Coords, t.Coords[n-1])
+	return out
+}
+
+// interpolateCoordinate returns the position at when between a, recorded
+// at ta, and b, recorded at tb.
+func interpolateCoordinate(a, b Coordinate, ta, tb, when time.Time) Coordinate {
+	span := tb.Sub(ta)
+	if span <= 0 {
+		return a
+	}
+	f := float64(when.Sub(ta)) / float64(span)
+	c := a
+	if d := a.DistanceTo(b); d > 0 {
+		c = a.Destination(a.BearingTo(b), f*d)
+	}
+	c.Alt = a.Alt + f*(b.Alt-a.Alt)
+	return c
+}
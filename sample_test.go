@@ -0,0 +1,108 @@
+package kml
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestDecimatePoints tests keeping every nth placemark
+func TestDecimatePoints(t *testing.T) {
+	var pms []*Placemark
+	for _, name := range []string{"a", "b", "c", "d", "e", "f", "g"} {
+		pms = append(pms, &Placemark{Name: name})
+	}
+
+	tests := []struct {
+		n    int
+		want []string
+	}{
+		{3, []string{"a", "d", "g"}},
+		{2, []string{"a", "c", "e", "g"}},
+		{10, []string{"a"}},
+		{1, []string{"a", "b", "c", "d", "e", "f", "g"}},
+		{0, []string{"a", "b", "c", "d", "e", "f", "g"}},
+	}
+
+	for _, tt := range tests {
+		var got []string
+		for _, pm := range DecimatePoints(pms, tt.n) {
+			got = append(got, pm.Name)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("n=%d: expected %v, got %v", tt.n, tt.want, got)
+		}
+	}
+
+	if got := DecimatePoints(nil, 3); len(got) != 0 {
+		t.Errorf("Expected no placemarks, got %d", len(got))
+	}
+}
+
+// TestResampleTrack tests resampling a track to regular times
+func TestResampleTrack(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	at := func(secs int) time.Time { return start.Add(time.Duration(secs) * time.Second) }
+	tr := &Track{
+		ID:           "run",
+		AltitudeMode: AltitudeModeAbsolute,
+		When:         []time.Time{at(0), at(1), at(2), at(10), at(25)},
+		Coords: []Coordinate{
+			{Lon: 0, Lat: 0, Alt: 0},
+			{Lon: 0, Lat: 0.0001, Alt: 1},
+			{Lon: 0, Lat: 0.0002, Alt: 2},
+			{Lon: 0, Lat: 0.001, Alt: 10},
+			{Lon: 0, Lat: 0.001, Alt: 10},
+		},
+	}
+
+	got := ResampleTrack(tr, 5*time.Second)
+	if got.ID != "run" || got.AltitudeMode != AltitudeModeAbsolute {
+		t.Errorf("Expected ID and altitude mode to be kept, got %q and %q", got.ID, got.AltitudeMode)
+	}
+	wantTimes := []time.Time{at(0), at(5), at(10), at(15), at(20), at(25)}
+	if !reflect.DeepEqual(got.When, wantTimes) {
+		t.Fatalf("Expected times %v, got %v", wantTimes, got.When)
+	}
+	wantLat := []float64{0, 0.0005, 0.001, 0.001, 0.001, 0.001}
+	wantAlt := []float64{0, 5, 10, 10, 10, 10}
+	for i, c := range got.Coords {
+		if !floatNear(c.Lat, wantLat[i], 1e-9) || !floatNear(c.Lon, 0, 1e-9) || !floatNear(c.Alt, wantAlt[i], 1e-9) {
+			t.Errorf("Point %d: expected lat %v alt %v, got %+v", i, wantLat[i], wantAlt[i], c)
+		}
+	}
+
+	// The last point is kept off the grid
+	got = ResampleTrack(tr, 10*time.Second)
+	if want := []time.Time{at(0), at(10), at(20), at(25)}; !reflect.DeepEqual(got.When, want) {
+		t.Errorf("Expected times %v, got %v", want, got.When)
+	}
+}
+
+// TestResampleTrackUnchanged tests tracks that are copied as they are
+func TestResampleTrackUnchanged(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	coords := []Coordinate{{Lon: 0, Lat: 0}, {Lon: 1, Lat: 1}}
+	tests := []struct {
+		name     string
+		track    *Track
+		interval time.Duration
+	}{
+		{"untimed", &Track{Coords: coords}, time.Second},
+		{"one point", &Track{When: []time.Time{start}, Coords: coords[:1]}, time.Second},
+		{"out of order", &Track{When: []time.Time{start.Add(time.Minute), start}, Coords: coords}, time.Second},
+		{"zero interval", &Track{When: []time.Time{start, start.Add(time.Minute)}, Coords: coords}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ResampleTrack(tt.track, tt.interval)
+			if !reflect.DeepEqual(got, tt.track) {
+				t.Errorf("Expected unchanged copy %+v, got %+v", tt.track, got)
+			}
+			if len(got.Coords) > 0 && &got.Coords[0] == &tt.track.Coords[0] {
+				t.Error("Expected coordinates to be copied")
+			}
+		})
+	}
+}
@@ -0,0 +1,276 @@
+package kml
+
+import (
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// SanitizePolicy selects what Sanitize removes from a document.
+type SanitizePolicy struct {
+	// StripActiveContent removes script, iframe, object, embed and applet
+	// elements, event handler attributes such as onclick, and javascript:
+	// URLs from descriptions and balloon text.
+	StripActiveContent bool
+
+	// RedactContacts replaces email addresses and phone numbers in names,
+	// descriptions, addresses, balloon text and ExtendedData values with
+	// Redaction. Phone numbers are recognized heuristically as three
+	// groups of digits, optionally prefixed with a country code, with at
+	// least one separator, country code or parenthesized area code, so
+	// that bare runs of digits such as IDs and timestamps are kept.
+	RedactContacts bool
+
+	// Redaction replaces each redacted contact (default "[redacted]").
+	Redaction string
+
+	// DenyFields lists ExtendedData Data and SimpleData names to remove,
+	// compared case-insensitively as path.Match patterns, such as
+	// "internal_*".
+	DenyFields []string
+
+	// NormalizeLinks rewrites absolute http and https URLs, in hrefs and
+	// in the href and src attributes of descriptions, to lowercase their
+	// scheme and host and drop user credentials and default ports.
+	NormalizeLinks bool
+
+	// RewriteLink, if set, maps each absolute URL after normalization, for
+	// example from internal hostnames to public ones. Returning "" removes
+	// the reference from a description, or clears the href.
+	RewriteLink func(string) string
+}
+
+// defaults returns a copy of the policy with zero values replaced by defaults.
+func (p SanitizePolicy) defaults() SanitizePolicy {
+	if p.Redaction == "" {
+		p.Redaction = "[redacted]"
+	}
+	return p
+}
+
+var (
+	activeElements = []*regexp.Regexp{
+		regexp.MustCompile(`(?is)<script\b.*?</script\s*>`),
+		regexp.MustCompile(`(?is)<iframe\b.*?</iframe\s*>`),
+		regexp.MustCompile(`(?is)<object\b.*?</object\s*>`),
+		regexp.MustCompile(`(?is)<applet\b.*?</applet\s*>`),
+		regexp.MustCompile(`(?is)</?(?:script|iframe|object|embed|applet)\b[^>]*>`),
+	}
+	htmlTag       = regexp.MustCompile(`(?s)<[a-zA-Z][^>]*>`)
+	eventHandler  = regexp.MustCompile(`(?is)\s+on[a-z]+\s*=\s*(?:"[^"]*"|'[^']*'|[^\s>]+)`)
+	linkAttribute = regexp.MustCompile(`(?is)(\s(?:href|src)\s*=\s*)("[^"]*"|'[^']*'|[^\s>]+)`)
+	emailAddress  = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	phoneNumber   = regexp.MustCompile(`(?:\+\d{1,3}[\s.-]?)?(?:\(\d{2,4}\)|\b\d{2,4})[\s.-]?\d{3,4}[\s.-]?\d{3,4}\b`)
+)
+
+// Sanitize scrubs the document for publishing according to policy and
+// returns the number of text values, fields and links it changed or
+// removed. It works on text with regular expressions rather than an HTML
+// parser, which suits the simple markup of KML balloons; it is not a
+// defense against deliberately obfuscated HTML.
+func (k *KML) Sanitize(policy SanitizePolicy) int {
+	s := &sanitizer{policy: policy.defaults()}
+
+	k.Walk(func(f Feature) error {
+		switch feature := f.(type) {
+		case *Document:
+			s.text(&feature.Name)
+			s.html(&feature.Description)
+			for i := range feature.Styles {
+				s.style(&feature.Styles[i])
+			}
+		case *Folder:
+			s.text(&feature.Name)
+			s.html(&feature.Description)
+		case *Placemark:
+			s.text(&feature.Name)
+			s.html(&feature.Description)
+			s.text(&feature.Address)
+			s.style(feature.Style)
+			feature.ExtendedData = s.extendedData(feature.ExtendedData)
+		case *GroundOverlay:
+			s.text(&feature.Name)
+			s.html(&feature.Description)
+		case *NetworkLink:
+			s.text(&feature.Name)
+			s.html(&feature.Description)
+		case *ScreenOverlay:
+			s.text(&feature.Name)
+			s.html(&feature.Description)
+		case *Tour:
+			s.text(&feature.Name)
+			s.html(&feature.Description)
+		}
+		return nil
+	})
+
+	if s.policy.NormalizeLinks || s.policy.RewriteLink != nil {
+		k.RewriteHrefs(func(href string) string {
+			out := s.link(href)
+			if out != href {
+				s.changes++
+			}
+			return out
+		})
+	}
+	return s.changes
+}
+
+// sanitizer applies a policy and counts the changes it makes.
+type sanitizer struct {
+	policy  SanitizePolicy
+	changes int
+}
+
+// set replaces *v with out, counting the change.
+func (s *sanitizer) set(v *string, out string) {
+	if out != *v {
+		*v = out
+		s.changes++
+	}
+}
+
+// text sanitizes a plain text value.
+func (s *sanitizer) text(v *string) {
+	if *v != "" {
+		s.set(v, s.redact(*v))
+	}
+}
+
+// html sanitizes a value that may contain HTML markup.
+func (s *sanitizer) html(v *string) {
+	if *v == "" {
+		return
+	}
+	out := *v
+	if s.policy.StripActiveContent {
+		for _, re := range activeElements {
+			out = re.ReplaceAllString(out, "")
+		}
+	}
+	if s.policy.StripActiveContent || s.policy.NormalizeLinks || s.policy.RewriteLink != nil {
+		out = htmlTag.ReplaceAllStringFunc(out, s.tag)
+	}
+	s.set(v, s.redact(out))
+}
+
+// tag sanitizes the attributes of one HTML start tag.
+func (s *sanitizer) tag(tag string) string {
+	if s.policy.StripActiveContent {
+		tag = eventHandler.ReplaceAllString(tag, "")
+	}
+	return linkAttribute.ReplaceAllStringFunc(tag, func(attr string) string {
+		m := linkAttribute.FindStringSubmatch(attr)
+		value := strings.Trim(m[2], `"'`)
+		if s.policy.StripActiveContent && strings.HasPrefix(strings.ToLower(strings.TrimSpace(value)), "javascript:") {
+			return ""
+		}
+		out := s.link(value)
+		if out == "" {
+			return ""
+		}
+		if out == value {
+			return attr
+		}
+		return m[1] + `"` + out + `"`
+	})
+}
+
+// redact replaces contacts in v if the policy asks for it.
+func (s *sanitizer) redact(v string) string {
+	if !s.policy.RedactContacts {
+		return v
+	}
+	v = emailAddress.ReplaceAllString(v, s.policy.Redaction)
+	return phoneNumber.ReplaceAllStringFunc(v, func(m string) string {
+		// A bare run of digits is as likely an ID, timestamp or count.
+		if !strings.ContainsAny(m, "+( .-") {
+			return m
+		}
+		return s.policy.Redaction
+	})
+}
+
+// link normalizes and rewrites an absolute http or https URL; other
+// references are returned unchanged.
+func (s *sanitizer) link(href string) string {
+	u, err := url.Parse(strings.TrimSpace(href))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return href
+	}
+	out := href
+	if s.policy.NormalizeLinks {
+		u.Host = strings.ToLower(u.Host)
+		if port := u.Port(); (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+			u.Host = u.Hostname()
+		}
+		u.User = nil
+		out = u.String()
+	}
+	if s.policy.RewriteLink != nil {
+		out = s.policy.RewriteLink(out)
+	}
+	return out
+}
+
+// style sanitizes the balloon text of a style.
+func (s *sanitizer) style(st *Style) {
+	if st != nil && st.BalloonStyle != nil {
+		s.html(&st.BalloonStyle.Text)
+	}
+}
+
+// extendedData removes denied fields and redacts values, returning nil if
+// nothing is left.
+func (s *sanitizer) extendedData(ed *ExtendedData) *ExtendedData {
+	if ed == nil {
+		return nil
+	}
+
+	data := ed.Data[:0]
+	for _, d := range ed.Data {
+		if s.denied(d.Name) {
+			s.changes++
+			continue
+		}
+		s.text(&d.DisplayName)
+		s.text(&d.Value)
+		data = append(data, d)
+	}
+	ed.Data = data
+
+	schemaData := ed.SchemaData[:0]
+	for _, sd := range ed.SchemaData {
+		simple := sd.SimpleData[:0]
+		for _, sv := range sd.SimpleData {
+			if s.denied(sv.Name) {
+				s.changes++
+				continue
+			}
+			s.text(&sv.Value)
+			simple = append(simple, sv)
+		}
+		sd.SimpleData = simple
+		if len(simple) > 0 || sd.SchemaURL != "" {
+			schemaData = append(schemaData, sd)
+		}
+	}
+	ed.SchemaData = schemaData
+
+	if len(ed.Data) == 0 && len(ed.SchemaData) == 0 {
+		return nil
+	}
+	return ed
+}
+
+// denied reports whether a field name matches the policy's deny list.
+func (s *sanitizer) denied(name string) bool {
+	name = strings.ToLower(name)
+	for _, pattern := range s.policy.DenyFields {
+		if ok, _ := path.Match(strings.ToLower(pattern), name); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,410 @@
+package kml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// SchemaViolation is a place where a document does not conform to the KML
+// schema.
+type SchemaViolation struct {
+	Line    int
+	Column  int
+	Element string
+	Message string
+}
+
+// Error returns the violation with its position.
+func (v SchemaViolation) Error() string {
+	return fmt.Sprintf("kml: schema violation at %s in %s: %s", v.Position(), v.Element, v.Message)
+}
+
+// Position returns the location of the violation as line:column, or just
+// the line if the validator did not report a column.
+func (v SchemaViolation) Position() string {
+	if v.Column == 0 {
+		return strconv.Itoa(v.Line)
+	}
+	return fmt.Sprintf("%d:%d", v.Line, v.Column)
+}
+
+// SchemaValidator validates raw KML markup against a schema.
+type SchemaValidator interface {
+	Validate(data []byte) ([]SchemaViolation, error)
+}
+
+// ValidateSchema reads a KML, gzipped KML or KMZ document from r and checks
+// it with validator, or with OGCSchema if validator is nil. Unlike Parse,
+// which accepts anything it can make sense of, it reports every departure
+// from the schema, for producers that must emit conforming files. The error
+// is for failures to read the input or run the validator.
+func ValidateSchema(r io.Reader, validator SchemaValidator) ([]SchemaViolation, error) {
+	rc, err := decompress(r)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, err
+	}
+	if validator == nil {
+		validator = OGCSchema
+	}
+	return validator.Validate(data)
+}
+
+// OGCSchema validates documents against the structure of the OGC KML 2.2
+// schema in pure Go: the root element and namespace, the children each
+// core element allows and their order, repetition, and the simple types of
+// values such as booleans, enumerations, colors, numbers and angles.
+// Elements in other namespaces, such as gx: and atom: extensions, are
+// accepted wherever they appear without being checked, and so are the
+// contents of KML elements it does not model, such as Model. For full XSD
+// conformance use XMLLint with ogckml22.xsd.
+var OGCSchema SchemaValidator = ogcSchema{}
+
+type ogcSchema struct{}
+
+// slot is a position in an element's content sequence, filled by any one
+// of names.
+type slot struct {
+	names []string
+	many  bool
+}
+
+func one(names ...string) slot  { return slot{names: names} }
+func many(names ...string) slot { return slot{names: names, many: true} }
+
+var (
+	featureNames  = []string{"Document", "Folder", "Placemark", "NetworkLink", "GroundOverlay", "ScreenOverlay", "PhotoOverlay"}
+	geometryNames = []string{"Point", "LineString", "LinearRing", "Polygon", "MultiGeometry", "Model"}
+
+	featureSlots = []slot{
+		one("name"), one("visibility"), one("open"), one("address"), one("phoneNumber"),
+		one("Snippet", "snippet"), one("description"), one("LookAt", "Camera"),
+		one("TimeStamp", "TimeSpan"), one("styleUrl"), many("Style", "StyleMap"),
+		one("Region"), one("Metadata", "ExtendedData"),
+	}
+	overlaySlots = seq(featureSlots, one("color"), one("drawOrder"), one("Icon"))
+	linkSlots    = []slot{
+		one("href"), one("refreshMode"), one("refreshInterval"), one("viewRefreshMode"),
+		one("viewRefreshTime"), one("viewBoundScale"), one("viewFormat"), one("httpQuery"),
+	}
+	colorStyleSlots = []slot{one("color"), one("colorMode")}
+
+	// contentModels maps each modeled element to the sequence of its
+	// children in the KML namespace.
+	contentModels = map[string][]slot{
+		"kml":             {one("NetworkLinkControl"), one(featureNames...)},
+		"Document":        seq(featureSlots, many("Schema"), many(featureNames...)),
+		"Folder":          seq(featureSlots, many(featureNames...)),
+		"Placemark":       seq(featureSlots, one(geometryNames...)),
+		"NetworkLink":     seq(featureSlots, one("refreshVisibility"), one("flyToView"), one("Url", "Link")),
+		"GroundOverlay":   seq(overlaySlots, one("altitude"), one("altitudeMode"), one("LatLonBox")),
+		"ScreenOverlay":   seq(overlaySlots, one("overlayXY"), one("screenXY"), one("rotationXY"), one("size"), one("rotation")),
+		"Point":           {one("extrude"), one("altitudeMode"), one("coordinates")},
+		"LineString":      {one("extrude"), one("tessellate"), one("altitudeMode"), one("coordinates")},
+		"LinearRing":      {one("extrude"), one("tessellate"), one("altitudeMode"), one("coordinates")},
+		"Polygon":         {one("extrude"), one("tessellate"), one("altitudeMode"), one("outerBoundaryIs"), many("innerBoundaryIs")},
+		"outerBoundaryIs": {one("LinearRing")},
+		"innerBoundaryIs": {one("LinearRing")},
+		"MultiGeometry":   {many(geometryNames...)},
+		"Style":           {one("IconStyle"), one("LabelStyle"), one("LineStyle"), one("PolyStyle"), one("BalloonStyle"), one("ListStyle")},
+		"IconStyle":       seq(colorStyleSlots, one("scale"), one("heading"), one("Icon"), one("hotSpot")),
+		"LabelStyle":      seq(colorStyleSlots, one("scale")),
+		"LineStyle":       seq(colorStyleSlots, one("width")),
+		"PolyStyle":       seq(colorStyleSlots, one("fill"), one("outline")),
+		"BalloonStyle":    {one("color", "bgColor"), one("textColor"), one("text"), one("displayMode")},
+		"ListStyle":       {one("listItemType"), one("bgColor"), many("ItemIcon"), one("maxSnippetLines")},
+		"ItemIcon":        {one("state"), one("href")},
+		"StyleMap":        {many("Pair")},
+		"Pair":            {one("key"), one("styleUrl"), one("Style", "StyleMap")},
+		"Icon":            linkSlots,
+		"Link":            linkSlots,
+		"Url":             linkSlots,
+		"Region":          {one("LatLonAltBox"), one("Lod")},
+		"LatLonAltBox":    {one("north"), one("south"), one("east"), one("west"), one("minAltitude"), one("maxAltitude"), one("altitudeMode")},
+		"Lod":             {one("minLodPixels"), one("maxLodPixels"), one("minFadeExtent"), one("maxFadeExtent")},
+		"LatLonBox":       {one("north"), one("south"), one("east"), one("west"), one("rotation")},
+		"LookAt":          {one("longitude"), one("latitude"), one("altitude"), one("heading"), one("tilt"), one("range"), one("altitudeMode")},
+		"Camera":          {one("longitude"), one("latitude"), one("altitude"), one("heading"), one("tilt"), one("roll"), one("altitudeMode")},
+		"TimeStamp":       {one("when")},
+		"TimeSpan":        {one("begin"), one("end")},
+		"ExtendedData":    {many("Data"), many("SchemaData")},
+		"Data":            {one("displayName"), one("value")},
+		"SchemaData":      {many("SimpleData")},
+		"Schema":          {many("SimpleField")},
+		"SimpleField":     {one("displayName")},
+	}
+
+	// valueTypes maps elements with simple content to a check of their
+	// value, which returns a description of the expected value if it fails.
+	valueTypes = map[string]func(string) string{
+		"visibility": isBoolean, "open": isBoolean, "extrude": isBoolean, "tessellate": isBoolean,
+		"fill": isBoolean, "outline": isBoolean, "refreshVisibility": isBoolean, "flyToView": isBoolean,
+		"altitudeMode":    isOneOf("clampToGround", "relativeToGround", "absolute"),
+		"colorMode":       isOneOf("normal", "random"),
+		"displayMode":     isOneOf("default", "hide"),
+		"refreshMode":     isOneOf("onChange", "onInterval", "onExpire"),
+		"viewRefreshMode": isOneOf("never", "onStop", "onRequest", "onRegion"),
+		"listItemType":    isOneOf("check", "radioFolder", "checkOffOnly", "checkHideChildren"),
+		"key":             isOneOf("normal", "highlight"),
+		"color":           isColor, "bgColor": isColor, "textColor": isColor,
+		"latitude": isAngle(90), "north": isAngle(180), "south": isAngle(180),
+		"longitude": isAngle(180), "east": isAngle(180), "west": isAngle(180),
+		"heading": isAngle(360), "rotation": isAngle(180), "roll": isAngle(180), "tilt": isAngle(180),
+		"altitude": isDouble, "range": isDouble, "scale": isDouble, "width": isDouble,
+		"minAltitude": isDouble, "maxAltitude": isDouble, "minLodPixels": isDouble, "maxLodPixels": isDouble,
+		"minFadeExtent": isDouble, "maxFadeExtent": isDouble, "refreshInterval": isDouble,
+		"viewRefreshTime": isDouble, "viewBoundScale": isDouble,
+		"drawOrder": isInt, "maxSnippetLines": isInt,
+	}
+)
+
+// seq returns base followed by rest.
+func seq(base []slot, rest ...slot) []slot {
+	return append(append([]slot(nil), base...), rest...)
+}
+
+func isBoolean(s string) string {
+	switch s {
+	case "0", "1", "true", "false":
+		return ""
+	}
+	return "a boolean (0, 1, true or false)"
+}
+
+func isOneOf(values ...string) func(string) string {
+	return func(s string) string {
+		for _, v := range values {
+			if s == v {
+				return ""
+			}
+		}
+		return "one of " + strings.Join(values, ", ")
+	}
+}
+
+func isColor(s string) string {
+	if _, err := ParseColor(s); err != nil {
+		return "an aabbggrr hex color"
+	}
+	return ""
+}
+
+func isDouble(s string) string {
+	if _, err := strconv.ParseFloat(s, 64); err != nil {
+		return "a number"
+	}
+	return ""
+}
+
+func isInt(s string) string {
+	if _, err := strconv.Atoi(s); err != nil {
+		return "an integer"
+	}
+	return ""
+}
+
+func isAngle(limit float64) func(string) string {
+	return func(s string) string {
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil || v < -limit || v > limit {
+			return fmt.Sprintf("an angle from -%g to %g", limit, limit)
+		}
+		return ""
+	}
+}
+
+// schemaFrame tracks an open element during validation.
+type schemaFrame struct {
+	name  string
+	model []slot // nil for elements whose children are not checked
+	pos   int    // Index of the last filled slot, or -1
+	count int    // Children filling slot pos
+	text  strings.Builder
+	line  int
+	col   int
+}
+
+// Validate implements SchemaValidator.
+func (ogcSchema) Validate(data []byte) ([]SchemaViolation, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	var violations []SchemaViolation
+	report := func(line, col int, element, format string, args ...any) {
+		violations = append(violations, SchemaViolation{Line: line, Column: col, Element: element, Message: fmt.Sprintf(format, args...)})
+	}
+
+	var stack []*schemaFrame
+	skip := 0 // Depth inside an element whose content is not checked
+	for {
+		line, col := d.InputPos()
+		tok, err := d.Token()
+		if errors.Is(err, io.EOF) {
+			return violations, nil
+		}
+		if err != nil {
+			report(line, col, "", "malformed XML: %v", err)
+			return violations, nil
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if skip > 0 {
+				skip++
+				continue
+			}
+			if len(stack) == 0 {
+				if t.Name.Local != "kml" {
+					report(line, col, t.Name.Local, "root element must be kml")
+				}
+				if VersionOf(t.Name.Space) != Version22 {
+					report(line, col, t.Name.Local, "namespace %q is not the KML 2.2 namespace %s", t.Name.Space, DefaultNamespace)
+				}
+				stack = append(stack, &schemaFrame{name: "kml", model: contentModels["kml"], pos: -1, line: line, col: col})
+				continue
+			}
+
+			parent := stack[len(stack)-1]
+			if VersionOf(t.Name.Space) != Version22 {
+				skip = 1 // Extension elements are accepted unchecked
+				continue
+			}
+			if parent.model != nil {
+				checkSlot(parent, t.Name.Local, func(format string, args ...any) {
+					report(line, col, t.Name.Local, format, args...)
+				})
+			}
+			model, modeled := contentModels[t.Name.Local]
+			if !modeled && valueTypes[t.Name.Local] == nil && !isLeaf(parent, t.Name.Local) {
+				skip = 1 // Unmodeled elements such as Model are not checked
+				continue
+			}
+			stack = append(stack, &schemaFrame{name: t.Name.Local, model: model, pos: -1, line: line, col: col})
+
+		case xml.EndElement:
+			if skip > 0 {
+				skip--
+				continue
+			}
+			if len(stack) == 0 {
+				continue
+			}
+			f := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if check := valueTypes[f.name]; check != nil {
+				if want := check(strings.TrimSpace(f.text.String())); want != "" {
+					report(f.line, f.col, f.name, "value %q is not %s", strings.TrimSpace(f.text.String()), want)
+				}
+			}
+
+		case xml.CharData:
+			if skip == 0 && len(stack) > 0 {
+				stack[len(stack)-1].text.Write(t)
+			}
+		}
+	}
+}
+
+// isLeaf reports whether name is a child parent's model allows, and so an
+// element with simple content such as name or href.
+func isLeaf(parent *schemaFrame, name string) bool {
+	for _, s := range parent.model {
+		for _, n := range s.names {
+			if n == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkSlot advances f past the child name, reporting a child its model
+// does not allow, allows only earlier, or allows only once.
+func checkSlot(f *schemaFrame, name string, report func(format string, args ...any)) {
+	first := -1
+	for i, s := range f.model {
+		for _, n := range s.names {
+			if n == name {
+				if first < 0 {
+					first = i
+				}
+				if i == f.pos && (s.many || f.count == 0) {
+					f.count++
+					return
+				}
+				if i > f.pos {
+					f.pos, f.count = i, 1
+					return
+				}
+			}
+		}
+	}
+
+	switch {
+	case first < 0:
+		report("element %s is not allowed in %s", name, f.name)
+	case first == f.pos:
+		report("element %s may appear only once in %s", name, f.name)
+	default:
+		report("element %s must come before %s in %s", name, slotName(f.model[f.pos]), f.name)
+	}
+}
+
+// slotName describes the elements that fill s.
+func slotName(s slot) string {
+	return strings.Join(s.names, " or ")
+}
+
+// XMLLint validates documents with the xmllint command from libxml2 against
+// an XSD, typically the official ogckml22.xsd, for full schema conformance.
+type XMLLint struct {
+	Command string // The xmllint executable; "xmllint" if empty
+	Schema  string // Path or URL of the XSD
+}
+
+// xmllintViolation matches an xmllint validity error.
+var xmllintViolation = regexp.MustCompile(`^-:(\d+): element (\S+): Schemas validity error : (.*)$`)
+
+// Validate implements SchemaValidator by running xmllint with the document
+// on standard input. Its validity errors become violations; any other
+// failure is returned as an error.
+func (x XMLLint) Validate(data []byte) ([]SchemaViolation, error) {
+	command := x.Command
+	if command == "" {
+		command = "xmllint"
+	}
+	cmd := exec.Command(command, "--noout", "--schema", x.Schema, "-")
+	cmd.Stdin = bytes.NewReader(data)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	runErr := cmd.Run()
+	var violations []SchemaViolation
+	for _, line := range strings.Split(stderr.String(), "\n") {
+		m := xmllintViolation.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		n, _ := strconv.Atoi(m[1])
+		element := m[2]
+		if i := strings.LastIndexByte(element, '}'); i >= 0 {
+			element = element[i+1:] // {namespace}local
+		}
+		violations = append(violations, SchemaViolation{Line: n, Element: element, Message: m[3]})
+	}
+
+	var exitErr *exec.ExitError
+	if runErr != nil && !(errors.As(runErr, &exitErr) && exitErr.ExitCode() == 3 && len(violations) > 0) {
+		return violations, fmt.Errorf("kml: xmllint failed: %w: %s", runErr, strings.TrimSpace(stderr.String()))
+	}
+	return violations, nil
+}
@@ -0,0 +1,197 @@
+package kml
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestOGCSchema tests structural schema validation
+func TestOGCSchema(t *testing.T) {
+	wrap := func(body string) string {
+		return `<kml xmlns="http://www.opengis.net/kml/2.2" xmlns:gx="http://www.google.com/kml/ext/2.2">` + body + `</kml>`
+	}
+
+	tests := []struct {
+		name string
+		data string
+		want []string
+	}{
+		{
+			name: "valid",
+			data: wrap(`<Document><name>D</name><open>1</open><Style id="s"><LineStyle><color>ff0000ff</color><width>2</width></LineStyle></Style>
+<Placemark><name>P</name><styleUrl>#s</styleUrl><ExtendedData><Data name="a"><value>1</value></Data></ExtendedData>
+<LineString><tessellate>1</tessellate><coordinates>1,2 3,4</coordinates></LineString></Placemark>
+<Placemark><gx:balloonVisibility>1</gx:balloonVisibility><gx:Track><gx:coord>1 2 3</gx:coord></gx:Track></Placemark></Document>`),
+		},
+		{
+			name: "wrong root",
+			data: `<Document xmlns="http://www.opengis.net/kml/2.2"/>`,
+			want: []string{"1:1 Document: root element must be kml"},
+		},
+		{
+			name: "wrong namespace",
+			data: `<kml xmlns="http://earth.google.com/kml/2.1"><Placemark/></kml>`,
+			want: []string{`1:1 kml: namespace "http://earth.google.com/kml/2.1" is not the KML 2.2 namespace http://www.opengis.net/kml/2.2`},
+		},
+		{
+			name: "out of order",
+			data: wrap(`<Placemark><description>d</description><name>P</name></Placemark>`),
+			want: []string{"1:129 name: element name must come before description in Placemark"},
+		},
+		{
+			name: "repeated",
+			data: wrap(`<Placemark><Point><coordinates>1,2</coordinates></Point><Point><coordinates>1,2</coordinates></Point></Placemark>`),
+			want: []string{"1:146 Point: element Point may appear only once in Placemark"},
+		},
+		{
+			name: "not allowed",
+			data: wrap(`<Folder><Point/><Placemark><LineString><outerBoundaryIs/></LineString></Placemark></Folder>`),
+			want: []string{
+				"1:98 Point: element Point is not allowed in Folder",
+				"1:129 outerBoundaryIs: element outerBoundaryIs is not allowed in LineString",
+			},
+		},
+		{
+			name: "values",
+			data: wrap(`<Placemark><visibility>yes</visibility><LookAt><latitude>95</latitude></LookAt><Style><PolyStyle><color>red</color></PolyStyle></Style><Point><altitudeMode>clampToSeaFloor</altitudeMode><coordinates>1,2</coordinates></Point></Placemark>`),
+			want: []string{
+				`1:101 visibility: value "yes" is not a boolean (0, 1, true or false)`,
+				`1:137 latitude: value "95" is not an angle from -90 to 90`,
+				`1:187 color: value "red" is not an aabbggrr hex color`,
+				`1:232 altitudeMode: value "clampToSeaFloor" is not one of clampToGround, relativeToGround, absolute`,
+			},
+		},
+		{
+			name: "malformed",
+			data: wrap(`<Placemark>`),
+			want: []string{"1:101 : malformed XML: XML syntax error on line 1: element <Placemark> closed by </kml>"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations, err := ValidateSchema(strings.NewReader(tt.data), nil)
+			if err != nil {
+				t.Fatalf("ValidateSchema failed: %v", err)
+			}
+			var got []string
+			for _, v := range violations {
+				got = append(got, v.Position()+" "+v.Element+": "+v.Message)
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("Expected violations:\n%s\ngot:\n%s", strings.Join(tt.want, "\n"), strings.Join(got, "\n"))
+			}
+		})
+	}
+}
+
+// TestOGCSchemaWrittenOutput tests that written documents conform
+func TestOGCSchemaWrittenOutput(t *testing.T) {
+	k, err := ParseFile("testdata/polygon-inner.kml")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	out, err := k.Bytes()
+	if err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	violations, err := ValidateSchema(bytes.NewReader(out), OGCSchema)
+	if err != nil {
+		t.Fatalf("ValidateSchema failed: %v", err)
+	}
+	if len(violations) != 0 {
+		t.Errorf("Expected no violations, got %v", violations)
+	}
+}
+
+// TestOGCSchemaFeatureOrder tests that written features, including placemarks with ExtendedData, conform
+func TestOGCSchemaFeatureOrder(t *testing.T) {
+	visible := true
+	k := NewKML()
+	k.Feature = &Document{
+		Schemas: []Schema{{ID: "site", SimpleFields: []SimpleField{{Type: "int", Name: "pop"}}}},
+		Features: []Feature{
+			&Placemark{
+				Name:        "full",
+				Description: "All the Feature elements",
+				Visibility:  &visible,
+				TimeStamp:   &TimeStamp{When: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)},
+				StyleURL:    "#s",
+				Style:       &Style{LineStyle: &LineStyle{Width: 2}},
+				ExtendedData: &ExtendedData{
+					Data:       []Data{{Name: "kind", Value: "hut"}},
+					SchemaData: []SchemaData{{SchemaURL: "#site", SimpleData: []SimpleData{{Name: "pop", Value: "4"}}}},
+				},
+				Geometry: &Point{Coordinates: Coord(8.5, 47.3)},
+			},
+			&Placemark{
+				ExtendedData: &ExtendedData{Data: []Data{{Name: "kind", Value: "trail"}}},
+				Geometry:     &LineString{Coordinates: []Coordinate{Coord(0, 0), Coord(1, 1)}},
+			},
+			&Folder{Name: "folder", Description: "d", Visibility: &visible, Open: true},
+			&NetworkLink{Name: "link", Description: "d", Visibility: &visible, Open: true, Link: &Link{Href: "https://example.com/a.kml"}},
+			&GroundOverlay{Name: "ground", Description: "d", Visibility: &visible},
+			&ScreenOverlay{Name: "screen", Description: "d", Visibility: &visible},
+		},
+		Name:        "doc",
+		Description: "d",
+		Visibility:  &visible,
+		Open:        true,
+	}
+
+	out, err := k.Bytes()
+	if err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	violations, err := ValidateSchema(bytes.NewReader(out), OGCSchema)
+	if err != nil {
+		t.Fatalf("ValidateSchema failed: %v", err)
+	}
+	if len(violations) != 0 {
+		t.Errorf("Expected no violations, got %v", violations)
+	}
+
+	again, err := ParseBytes(out)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	pm := again.Placemarks()[0]
+	if v, _ := pm.dataValue("pop"); v != "4" || pm.Geometry == nil {
+		t.Errorf("Expected ExtendedData and geometry to round-trip, got %+v", pm)
+	}
+}
+
+// TestXMLLint tests running an external validator
+func TestXMLLint(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script in place of xmllint")
+	}
+	dir := t.TempDir()
+	script := filepath.Join(dir, "xmllint")
+	err := os.WriteFile(script, []byte(`#!/bin/sh
+cat >/dev/null
+echo "-:3: element {http://www.opengis.net/kml/2.2}Snippet: Schemas validity error : Element 'Snippet': This element is not expected." >&2
+echo "- fails to validate" >&2
+exit 3
+`), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	violations, err := ValidateSchema(strings.NewReader(`<kml/>`), XMLLint{Command: script, Schema: "ogckml22.xsd"})
+	if err != nil {
+		t.Fatalf("ValidateSchema failed: %v", err)
+	}
+	if len(violations) != 1 || violations[0].Line != 3 || violations[0].Element != "Snippet" || violations[0].Message != "Element 'Snippet': This element is not expected." {
+		t.Errorf("Expected the Snippet violation, got %v", violations)
+	}
+
+	if _, err := ValidateSchema(strings.NewReader(`<kml/>`), XMLLint{Command: filepath.Join(dir, "missing")}); err == nil {
+		t.Error("Expected an error when xmllint cannot run")
+	}
+}
@@ -0,0 +1,187 @@
+package kml
+
+import "encoding/xml"
+
+// Units of a Vec2 coordinate.
+const (
+	UnitsFraction    = "fraction"    // Fraction of the image or screen size
+	UnitsPixels      = "pixels"      // Pixels from the origin
+	UnitsInsetPixels = "insetPixels" // Pixels from the upper right corner
+)
+
+// Vec2 is a point in an image or on the screen, measured from the lower
+// left corner, as used by ScreenOverlay.
+type Vec2 struct {
+	X      float64 `xml:"x,attr"`
+	Y      float64 `xml:"y,attr"`
+	XUnits string  `xml:"xunits,attr,omitempty"`
+	YUnits string  `xml:"yunits,attr,omitempty"`
+}
+
+// ScreenOverlay draws an image fixed to the screen, such as a legend or
+// logo. OverlayXY is the point of the image placed at ScreenXY on the
+// screen; Size scales the image, with zero or -1 keeping its native size.
+// It implements the Feature interface.
+type ScreenOverlay struct {
+	ID          string
+	Name        string
+	Description string
+	Visibility  *bool
+	DrawOrder   int
+	Icon        *Icon
+	OverlayXY   *Vec2
+	ScreenXY    *Vec2
+	Size        *Vec2
+	Comments    []string // See PreserveComments
+
+	span   *Span        // See RecordSpans
+	images *imageSource // See Image
+}
+
+// featureType implements the Feature interface.
+func (s *ScreenOverlay) featureType() string {
+	return "ScreenOverlay"
+}
+
+// Hash implements the Feature interface.
+func (s *ScreenOverlay) Hash() string {
+	return hashFeature(s)
+}
+
+// MarshalXML implements custom XML marshaling for ScreenOverlay.
+func (s *ScreenOverlay) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	start.Name.Local = "ScreenOverlay"
+
+	if s.ID != "" {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "id"}, Value: s.ID})
+	}
+
+	if err := encodeComments(e, s.Comments); err != nil {
+		return err
+	}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+
+	if s.Name != "" {
+		if err := e.EncodeElement(s.Name, xml.StartElement{Name: xml.Name{Local: "name"}}); err != nil {
+			return err
+		}
+	}
+
+	if s.Visibility != nil {
+		vis := 0
+		if *s.Visibility {
+			vis = 1
+		}
+		if err := e.EncodeElement(vis, xml.StartElement{Name: xml.Name{Local: "visibility"}}); err != nil {
+			return err
+		}
+	}
+
+	if s.Description != "" {
+		if err := encodeDescription(e, s.Description); err != nil {
+			return err
+		}
+	}
+
+	if s.DrawOrder != 0 {
+		if err := e.EncodeElement(s.DrawOrder, xml.StartElement{Name: xml.Name{Local: "drawOrder"}}); err != nil {
+			return err
+		}
+	}
+
+	if s.Icon != nil {
+		if err := e.EncodeElement(s.Icon, xml.StartElement{Name: xml.Name{Local: "Icon"}}); err != nil {
+			return err
+		}
+	}
+
+	for _, v := range []struct {
+		name string
+		vec  *Vec2
+	}{{"overlayXY", s.OverlayXY}, {"screenXY", s.ScreenXY}, {"size", s.Size}} {
+		if v.vec == nil {
+			continue
+		}
+		if err := e.EncodeElement(v.vec, xml.StartElement{Name: xml.Name{Local: v.name}}); err != nil {
+			return err
+		}
+	}
+
+	if err := e.EncodeToken(start.End()); err != nil {
+		return err
+	}
+	featureWritten(e)
+	return nil
+}
+
+// UnmarshalXML implements custom XML unmarshaling for ScreenOverlay.
+func (s *ScreenOverlay) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	s.Comments = takeComments(d)
+	beginSpan(d)
+
+	for _, attr := range start.Attr {
+		if attr.Name.Local == "id" {
+			s.ID = attr.Value
+		}
+	}
+
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return err
+		}
+
+		switch el := token.(type) {
+		case xml.StartElement:
+			switch el.Name.Local {
+			case "name":
+				if err := d.DecodeElement(&s.Name, &el); err != nil {
+					return err
+				}
+			case "description":
+				if err := d.DecodeElement(&s.Description, &el); err != nil {
+					return err
+				}
+			case "visibility":
+				var v int
+				if err := d.DecodeElement(&v, &el); err != nil {
+					return err
+				}
+				vis := flagValue(d, el, v)
+				s.Visibility = &vis
+			case "drawOrder":
+				if err := d.DecodeElement(&s.DrawOrder, &el); err != nil {
+					return err
+				}
+			case "Icon":
+				var icon Icon
+				if err := d.DecodeElement(&icon, &el); err != nil {
+					return err
+				}
+				s.Icon = &icon
+			case "overlayXY", "screenXY", "size":
+				var v Vec2
+				if err := d.DecodeElement(&v, &el); err != nil {
+					return err
+				}
+				switch el.Name.Local {
+				case "overlayXY":
+					s.OverlayXY = &v
+				case "screenXY":
+					s.ScreenXY = &v
+				default:
+					s.Size = &v
+				}
+			default:
+				if err := skipElement(d, el); err != nil {
+					return err
+				}
+			}
+		case xml.EndElement:
+			featureParsed(d, s)
+			return nil
+		}
+	}
+}
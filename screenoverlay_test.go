@@ -0,0 +1,53 @@
+package kml
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestScreenOverlayRoundTrip tests parsing, writing and traversing ScreenOverlays
+func TestScreenOverlayRoundTrip(t *testing.T) {
+	input := `<kml xmlns="http://www.opengis.net/kml/2.2"><Document>
+	<ScreenOverlay id="logo">
+		<name>Logo</name>
+		<drawOrder>3</drawOrder>
+		<Icon><href>http://example.com/logo.png</href></Icon>
+		<overlayXY x="1" y="1" xunits="fraction" yunits="fraction"/>
+		<screenXY x="10" y="10" xunits="insetPixels" yunits="insetPixels"/>
+		<size x="0.2" y="0" xunits="fraction" yunits="fraction"/>
+	</ScreenOverlay></Document></kml>`
+
+	k, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	so, ok := k.FindByID("logo").(*ScreenOverlay)
+	if !ok {
+		t.Fatalf("Expected ScreenOverlay, got %T", k.FindByID("logo"))
+	}
+	if so.Name != "Logo" || so.DrawOrder != 3 || so.Icon.Href != "http://example.com/logo.png" {
+		t.Errorf("Unexpected overlay: %+v", so)
+	}
+	if *so.OverlayXY != (Vec2{X: 1, Y: 1, XUnits: UnitsFraction, YUnits: UnitsFraction}) {
+		t.Errorf("Unexpected overlayXY: %+v", so.OverlayXY)
+	}
+	if so.ScreenXY.XUnits != UnitsInsetPixels || so.Size.X != 0.2 {
+		t.Errorf("Unexpected screenXY/size: %+v %+v", so.ScreenXY, so.Size)
+	}
+
+	data, err := k.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes() error: %v", err)
+	}
+	again, err := ParseBytes(data)
+	if err != nil {
+		t.Fatalf("ParseBytes() error: %v", err)
+	}
+	if again.FindByID("logo").Hash() != so.Hash() {
+		t.Errorf("Expected ScreenOverlay to round-trip unchanged, got:\n%s", data)
+	}
+
+	if assets := k.Assets(); len(assets) != 1 || assets[0].Kind != AssetOverlay {
+		t.Errorf("Expected overlay asset, got %+v", assets)
+	}
+}
@@ -0,0 +1,246 @@
+package kml
+
+import (
+	"html"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// SearchResult is a feature found by TextSearch. Score ranks the results:
+// exact words score above words a term begins, which score above misspelt
+// words, and matches in names count three times and in ExtendedData twice
+// as much as matches in descriptions. Words are the indexed words that
+// matched, for highlighting.
+type SearchResult struct {
+	Feature Feature
+	Score   int
+	Words   []string
+}
+
+// textIndex is an inverted index from words to the features using them.
+type textIndex struct {
+	features []Feature
+	words    []string // Sorted vocabulary
+	postings map[string][]textPosting
+}
+
+// textPosting is a use of a word in a feature, weighted by the field it
+// is in.
+type textPosting struct {
+	feature int
+	weight  int
+}
+
+// Weights of the fields a word appears in.
+const (
+	weightDescription = 1
+	weightData        = 2
+	weightName        = 3
+)
+
+var searchMarkup = regexp.MustCompile(`(?s)<[^>]*>`)
+
+// TextSearch finds the features whose names, descriptions or placemark
+// ExtendedData values contain every word of query, best first and then in
+// document order. Matching ignores case and markup and suits find-as-you-
+// type: each term also matches the words it begins, and terms of four
+// letters or more match words one edit away, two for eight or more.
+//
+// The index is built on the first search and reused, so call
+// ResetTextSearch after changing the document. TextSearch is safe to call
+// from several goroutines. An empty query finds nothing.
+func (k *KML) TextSearch(query string) []SearchResult {
+	k.searchMu.Lock()
+	if k.search == nil {
+		k.search = newTextIndex(k)
+	}
+	ix := k.search
+	k.searchMu.Unlock()
+	return ix.find(query)
+}
+
+// ResetTextSearch discards the index built by TextSearch, so the next
+// search sees changes to the document.
+func (k *KML) ResetTextSearch() {
+	k.searchMu.Lock()
+	k.search = nil
+	k.searchMu.Unlock()
+}
+
+// newTextIndex indexes the text of the features of k.
+func newTextIndex(k *KML) *textIndex {
+	ix := &textIndex{postings: make(map[string][]textPosting)}
+	k.Walk(func(f Feature) error {
+		i := len(ix.features)
+		ix.features = append(ix.features, f)
+		weights := make(map[string]int)
+		add := func(text string, weight int) {
+			for _, w := range searchWords(text) {
+				weights[w] = max(weights[w], weight)
+			}
+		}
+		add(featureName(f), weightName)
+		if desc := featureDescription(f); desc != nil {
+			add(html.UnescapeString(searchMarkup.ReplaceAllString(*desc, " ")), weightDescription)
+		}
+		if pm, ok := f.(*Placemark); ok && pm.ExtendedData != nil {
+			for _, d := range pm.ExtendedData.Data {
+				add(d.Value, weightData)
+			}
+			for _, sd := range pm.ExtendedData.SchemaData {
+				for _, d := range sd.SimpleData {
+					add(d.Value, weightData)
+				}
+			}
+		}
+		for w, weight := range weights {
+			ix.postings[w] = append(ix.postings[w], textPosting{i, weight})
+		}
+		return nil
+	})
+	for w := range ix.postings {
+		ix.words = append(ix.words, w)
+	}
+	sort.Strings(ix.words)
+	return ix
+}
+
+// find returns the features matching every term of query.
+func (ix *textIndex) find(query string) []SearchResult {
+	terms := searchWords(query)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	type hit struct {
+		score int
+		words []string
+	}
+	var hits map[int]*hit
+	for _, term := range terms {
+		// Score each feature by its best match for the term.
+		best := make(map[int]int)
+		bestWord := make(map[int]string)
+		for word, quality := range ix.matches(term) {
+			for _, p := range ix.postings[word] {
+				if s := quality * p.weight; s > best[p.feature] {
+					best[p.feature], bestWord[p.feature] = s, word
+				}
+			}
+		}
+
+		next := make(map[int]*hit)
+		for f, s := range best {
+			h := &hit{}
+			if hits != nil {
+				prev, ok := hits[f]
+				if !ok {
+					continue
+				}
+				h = prev
+			}
+			h.score += s
+			h.words = append(h.words, bestWord[f])
+			next[f] = h
+		}
+		hits = next
+		if len(hits) == 0 {
+			return nil
+		}
+	}
+
+	order := make([]int, 0, len(hits))
+	for f := range hits {
+		order = append(order, f)
+	}
+	sort.Slice(order, func(i, j int) bool {
+		a, b := order[i], order[j]
+		if hits[a].score != hits[b].score {
+			return hits[a].score > hits[b].score
+		}
+		return a < b
+	})
+	results := make([]SearchResult, len(order))
+	for i, f := range order {
+		results[i] = SearchResult{Feature: ix.features[f], Score: hits[f].score, Words: hits[f].words}
+	}
+	return results
+}
+
+// Qualities of a word's match with a search term.
+const (
+	matchFuzzy  = 1
+	matchPrefix = 2
+	matchExact  = 4
+)
+
+// matches returns the indexed words matching term with the quality of
+// each match.
+func (ix *textIndex) matches(term string) map[string]int {
+	found := make(map[string]int)
+	for i := sort.SearchStrings(ix.words, term); i < len(ix.words) && strings.HasPrefix(ix.words[i], term); i++ {
+		found[ix.words[i]] = matchPrefix
+	}
+	if _, ok := ix.postings[term]; ok {
+		found[term] = matchExact
+	}
+
+	edits := 0
+	switch n := len([]rune(term)); {
+	case n >= 8:
+		edits = 2
+	case n >= 4:
+		edits = 1
+	}
+	if edits > 0 {
+		t := []rune(term)
+		for _, w := range ix.words {
+			if _, ok := found[w]; ok {
+				continue
+			}
+			if withinEdits(t, []rune(w), edits) {
+				found[w] = matchFuzzy
+			}
+		}
+	}
+	return found
+}
+
+// withinEdits reports whether a and b are at most limit insertions,
+// deletions or substitutions apart.
+func withinEdits(a, b []rune, limit int) bool {
+	if d := len(a) - len(b); d > limit || -d > limit {
+		return false
+	}
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		rowMin := curr[0]
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			rowMin = min(rowMin, curr[j])
+		}
+		if rowMin > limit {
+			return false
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)] <= limit
+}
+
+// searchWords splits text into lowercase words of letters and digits.
+func searchWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
@@ -0,0 +1,111 @@
+package kml
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// searchTestKML returns features with text in names, descriptions and data
+func searchTestKML() *KML {
+	k := NewKML()
+	k.Feature = &Document{Name: "Harbour", Features: []Feature{
+		&Folder{Name: "Docks", Description: "Loading <b>docks</b> &amp; cranes", Features: []Feature{
+			&Placemark{Name: "Dock 3", Description: "Container terminal"},
+			&Placemark{Name: "Dock 4", ExtendedData: &ExtendedData{Data: []Data{{Name: "operator", Value: "Meridian Shipping"}}}},
+		}},
+		&Placemark{Name: "Harbour master", Description: "Office of the harbour master"},
+		&Placemark{Name: "Lighthouse", ExtendedData: &ExtendedData{SchemaData: []SchemaData{{SimpleData: []SimpleData{{Name: "built", Value: "1887"}}}}}},
+	}}
+	return k
+}
+
+// searchNames returns the names of the features found
+func searchNames(results []SearchResult) []string {
+	var names []string
+	for _, r := range results {
+		names = append(names, featureName(r.Feature))
+	}
+	return names
+}
+
+// TestTextSearch tests exact, prefix and fuzzy matching and ranking
+func TestTextSearch(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{"exact ranks above prefix", "dock", []string{"Dock 3", "Dock 4", "Docks"}},
+		{"all terms", "dock 3", []string{"Dock 3"}},
+		{"prefix", "ligh", []string{"Lighthouse"}},
+		{"case and punctuation", "DOCK-4!", []string{"Dock 4"}},
+		{"description", "container", []string{"Dock 3"}},
+		{"ties in document order", "harbour", []string{"Harbour", "Harbour master"}},
+		{"markup and entities", "cranes", []string{"Docks"}},
+		{"tags are not text", "b", nil},
+		{"extended data", "meridian", []string{"Dock 4"}},
+		{"schema data", "1887", []string{"Lighthouse"}},
+		{"typo", "harbor", []string{"Harbour", "Harbour master"}},
+		{"two typos in a long word", "lihgthuose", nil},
+		{"two edits in a long word", "lighthuse", []string{"Lighthouse"}},
+		{"one edit in four letters", "dick", []string{"Dock 3", "Dock 4"}},
+		{"terms across fields", "shipping dock", []string{"Dock 4"}},
+		{"no fuzzy for three letters", "dok", nil},
+		{"no match", "airport", nil},
+		{"empty", "  ", nil},
+	}
+
+	k := searchTestKML()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := searchNames(k.TextSearch(tt.query)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestTextSearchResults tests the scores and words of results and
+// rebuilding the index
+func TestTextSearchResults(t *testing.T) {
+	k := searchTestKML()
+	results := k.TextSearch("dock merid")
+	if len(results) != 1 {
+		t.Fatalf("Expected 1 result, got %d", len(results))
+	}
+	if want := []string{"dock", "meridian"}; !reflect.DeepEqual(results[0].Words, want) {
+		t.Errorf("Expected words %v, got %v", want, results[0].Words)
+	}
+	if want := matchExact*weightName + matchPrefix*weightData; results[0].Score != want {
+		t.Errorf("Expected score %d, got %d", want, results[0].Score)
+	}
+
+	pm := k.Placemarks()[0]
+	pm.Name = "Quay 3"
+	if got := searchNames(k.TextSearch("quay")); got != nil {
+		t.Errorf("Expected the index to be reused, got %v", got)
+	}
+	k.ResetTextSearch()
+	if got := searchNames(k.TextSearch("quay")); !reflect.DeepEqual(got, []string{"Quay 3"}) {
+		t.Errorf("Expected [Quay 3] after reset, got %v", got)
+	}
+}
+
+// TestTextSearchConcurrent tests searching from several goroutines
+func TestTextSearchConcurrent(t *testing.T) {
+	k := searchTestKML()
+	want := searchNames(searchTestKML().TextSearch("dock"))
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if got := searchNames(k.TextSearch("dock")); !reflect.DeepEqual(got, want) {
+				t.Errorf("Expected %v, got %v", want, got)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,227 @@
+package kml
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// Session records the edits made to a document through it so they can be
+// undone and redone, as an interactive editor needs. Each call of Apply is
+// one step of the history. Edits made to the document other than through
+// the session invalidate its history.
+//
+// Features restored by Undo or Redo are decoded from their JSON form, so
+// lose state such as the spans of RecordSpans. A Session is not safe for
+// concurrent use.
+type Session struct {
+	k    *KML
+	undo []sessionStep
+	redo []sessionStep
+}
+
+// sessionStep is one step of a session's history.
+type sessionStep struct {
+	ops     []PatchOp // As passed to Apply
+	inverse []PatchOp // The operations that undo ops, in the order to apply them
+}
+
+// NewSession starts an editing session on k with an empty history.
+func NewSession(k *KML) *Session {
+	return &Session{k: k}
+}
+
+// Apply applies ops to the document, as ApplyPatch does, and records them
+// as a step that Undo reverts. Unlike ApplyPatch, a failing operation
+// reverts those before it, leaving the document and history unchanged.
+// Apply discards the steps that Redo would have reapplied.
+func (s *Session) Apply(ops []PatchOp) error {
+	s.k.ResetTextSearch()
+	s.k.ResetBounds()
+	step := sessionStep{ops: ops}
+	for i, op := range ops {
+		inverse, err := s.k.inversePatchOp(op)
+		if err == nil {
+			err = s.k.applyPatchOp(op)
+		}
+		if err != nil {
+			s.k.ApplyPatch(step.inverse)
+			return patchOpError(i, op, err)
+		}
+		step.inverse = append([]PatchOp{inverse}, step.inverse...)
+	}
+	s.undo = append(s.undo, step)
+	s.redo = nil
+	return nil
+}
+
+// CanUndo reports whether there is a step to undo.
+func (s *Session) CanUndo() bool {
+	return len(s.undo) > 0
+}
+
+// CanRedo reports whether there is an undone step to redo.
+func (s *Session) CanRedo() bool {
+	return len(s.redo) > 0
+}
+
+// Undo reverts the last step applied or redone. It returns ErrNoHistory if
+// there is none.
+func (s *Session) Undo() error {
+	if len(s.undo) == 0 {
+		return ErrNoHistory
+	}
+	step := s.undo[len(s.undo)-1]
+	if err := s.k.ApplyPatch(step.inverse); err != nil {
+		return err
+	}
+	s.undo = s.undo[:len(s.undo)-1]
+	s.redo = append(s.redo, step)
+	return nil
+}
+
+// Redo reapplies the last step undone. It returns ErrNoHistory if there is
+// none.
+func (s *Session) Redo() error {
+	if len(s.redo) == 0 {
+		return ErrNoHistory
+	}
+	step := s.redo[len(s.redo)-1]
+	if err := s.k.ApplyPatch(step.ops); err != nil {
+		return err
+	}
+	s.redo = s.redo[:len(s.redo)-1]
+	s.undo = append(s.undo, step)
+	return nil
+}
+
+// Commit clears the history, making the current document the one later
+// steps are undone back to, and returns the operations of the steps it
+// held in order. Applied to a copy of the document as it was at the start
+// of the session or the previous Commit, they give the current document,
+// so an editor can send them to a server as one change.
+func (s *Session) Commit() []PatchOp {
+	var ops []PatchOp
+	for _, step := range s.undo {
+		ops = append(ops, step.ops...)
+	}
+	s.undo, s.redo = nil, nil
+	return ops
+}
+
+// inversePatchOp returns the operation that undoes op, which is about to be
+// applied to k. Its path is a tree path, so it still applies if op changes
+// feature IDs.
+func (k *KML) inversePatchOp(op PatchOp) (PatchOp, error) {
+	if err := checkPatchOp(op); err != nil {
+		return PatchOp{}, err
+	}
+	t, err := k.resolvePatchPath(op.Path)
+	if err != nil {
+		return PatchOp{}, err
+	}
+	path := append(t.path, t.rest...)
+
+	// The feature itself, restored whole.
+	if len(t.rest) == 0 {
+		old, err := json.Marshal(t.feature)
+		if err != nil {
+			return PatchOp{}, err
+		}
+		if op.Op == "remove" {
+			return PatchOp{Op: "add", Path: joinPointer(path), Value: old}, nil
+		}
+		return PatchOp{Op: "replace", Path: joinPointer(path), Value: old}, nil
+	}
+
+	// Otherwise the element of a list or field of an object given by the
+	// last token, within the JSON form of a feature or its Features list.
+	var parent any
+	if children := childrenOf(t.feature); children != nil && len(t.rest) == 2 && t.rest[0] == "Features" {
+		list := make([]any, len(*children))
+		for i, child := range *children {
+			list[i] = child
+		}
+		parent = list
+	} else {
+		doc, err := featureFieldsJSON(t.feature, t.rest)
+		if err != nil {
+			return PatchOp{}, err
+		}
+		if parent, err = jsonAt(doc, t.rest[:len(t.rest)-1]); err != nil {
+			return PatchOp{}, err
+		}
+	}
+
+	last := t.rest[len(t.rest)-1]
+	var old any
+	switch node := parent.(type) {
+	case map[string]any:
+		value, ok := node[last]
+		if !ok {
+			if op.Op == "add" {
+				return PatchOp{Op: "remove", Path: joinPointer(path)}, nil
+			}
+			return PatchOp{}, fmt.Errorf("no field %q", last)
+		}
+		old = value
+		if op.Op == "add" {
+			op.Op = "replace"
+		}
+	case []any, nil:
+		list, _ := node.([]any)
+		if node == nil && op.Op != "add" {
+			return PatchOp{}, fmt.Errorf("no element %q", last)
+		}
+		if op.Op == "add" {
+			i := len(list)
+			if last != "-" {
+				if i, err = patchIndex(last, len(list)+1); err != nil {
+					return PatchOp{}, err
+				}
+			}
+			path[len(path)-1] = strconv.Itoa(i)
+			return PatchOp{Op: "remove", Path: joinPointer(path)}, nil
+		}
+		i, err := patchIndex(last, len(list))
+		if err != nil {
+			return PatchOp{}, err
+		}
+		old = list[i]
+	default:
+		return PatchOp{}, fmt.Errorf("cannot address %q within a %T", last, parent)
+	}
+
+	value, err := json.Marshal(old)
+	if err != nil {
+		return PatchOp{}, err
+	}
+	if op.Op == "remove" {
+		return PatchOp{Op: "add", Path: joinPointer(path), Value: value}, nil
+	}
+	return PatchOp{Op: "replace", Path: joinPointer(path), Value: value}, nil
+}
+
+// jsonAt returns the value at the path tokens within v, a decoded JSON
+// value.
+func jsonAt(v any, tokens []string) (any, error) {
+	for _, token := range tokens {
+		switch node := v.(type) {
+		case map[string]any:
+			child, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("no field %q", token)
+			}
+			v = child
+		case []any:
+			i, err := patchIndex(token, len(node))
+			if err != nil {
+				return nil, err
+			}
+			v = node[i]
+		default:
+			return nil, fmt.Errorf("cannot address %q within a %T", token, v)
+		}
+	}
+	return v, nil
+}
@@ -0,0 +1,145 @@
+package kml
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+// sessionJSON returns the JSON form of k for comparing states.
+func sessionJSON(t *testing.T, k *KML) string {
+	t.Helper()
+	data, err := json.Marshal(k)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	return string(data)
+}
+
+// sessionOps decodes a JSON Patch.
+func sessionOps(t *testing.T, data string) []PatchOp {
+	t.Helper()
+	var ops []PatchOp
+	if err := json.Unmarshal([]byte(data), &ops); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	return ops
+}
+
+// TestSessionUndoRedo tests undoing and redoing each kind of step
+func TestSessionUndoRedo(t *testing.T) {
+	steps := []string{
+		`[{"op":"replace","path":"#w1/Name","value":"Renamed"},{"op":"replace","path":"#w1/ID","value":"w9"}]`,
+		`[{"op":"replace","path":"#w9/Name","value":"Again"}]`,
+		`[{"op":"add","path":"#w2/Geometry/Coordinates/-","value":{"Lon":2,"Lat":2}},{"op":"remove","path":"#w2/Geometry/Coordinates/0"}]`,
+		`[{"op":"add","path":"#w9/ExtendedData","value":{"Data":null}},{"op":"add","path":"#w9/ExtendedData/Data/0","value":{"Name":"depth","Value":"40"}}]`,
+		`[{"op":"remove","path":"#w9/Geometry"}]`,
+		`[{"op":"add","path":"#wells/Features/1","value":{"type":"Placemark","ID":"w3"}},{"op":"add","path":"/Feature/Features/-","value":{"type":"Folder","ID":"roads"}}]`,
+		`[{"op":"remove","path":"#w2"},{"op":"replace","path":"#roads","value":{"type":"Folder","ID":"tracks"}}]`,
+		`[{"op":"replace","path":"/Feature/Name","value":"Edited"}]`,
+		`[{"op":"replace","path":"/Feature","value":{"type":"Placemark","Name":"Only"}}]`,
+	}
+
+	k := patchTestKML()
+	s := NewSession(k)
+	states := []string{sessionJSON(t, k)}
+	for i, step := range steps {
+		if err := s.Apply(sessionOps(t, step)); err != nil {
+			t.Fatalf("Step %d failed: %v", i, err)
+		}
+		states = append(states, sessionJSON(t, k))
+	}
+
+	for i := len(steps) - 1; i >= 0; i-- {
+		if err := s.Undo(); err != nil {
+			t.Fatalf("Undo of step %d failed: %v", i, err)
+		}
+		if got := sessionJSON(t, k); got != states[i] {
+			t.Errorf("Expected state %d after undo, got %s", i, got)
+		}
+	}
+	if s.CanUndo() || !errors.Is(s.Undo(), ErrNoHistory) {
+		t.Errorf("Expected nothing to undo")
+	}
+
+	for i := range steps {
+		if err := s.Redo(); err != nil {
+			t.Fatalf("Redo of step %d failed: %v", i, err)
+		}
+		if got := sessionJSON(t, k); got != states[i+1] {
+			t.Errorf("Expected state %d after redo, got %s", i+1, got)
+		}
+	}
+	if s.CanRedo() || !errors.Is(s.Redo(), ErrNoHistory) {
+		t.Errorf("Expected nothing to redo")
+	}
+}
+
+// TestSessionApplyFailure tests that a failing step leaves no trace
+func TestSessionApplyFailure(t *testing.T) {
+	k := patchTestKML()
+	s := NewSession(k)
+	before := sessionJSON(t, k)
+	err := s.Apply(sessionOps(t, `[
+		{"op":"replace","path":"#w1/Name","value":"Renamed"},
+		{"op":"remove","path":"#wells/Features/0"},
+		{"op":"remove","path":"#w1/Name"}]`))
+	if err == nil {
+		t.Fatal("Expected error for removed feature")
+	}
+	if got := sessionJSON(t, k); got != before {
+		t.Errorf("Expected document unchanged, got %s", got)
+	}
+	if s.CanUndo() {
+		t.Errorf("Expected no step recorded")
+	}
+}
+
+// TestSessionCommit tests the operations returned by Commit
+func TestSessionCommit(t *testing.T) {
+	k := patchTestKML()
+	s := NewSession(k)
+	for _, step := range []string{
+		`[{"op":"replace","path":"#w1/Name","value":"One"}]`,
+		`[{"op":"replace","path":"#w2/Name","value":"Two"}]`,
+		`[{"op":"remove","path":"#w2"}]`,
+	} {
+		if err := s.Apply(sessionOps(t, step)); err != nil {
+			t.Fatalf("Apply failed: %v", err)
+		}
+	}
+	if err := s.Undo(); err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+
+	ops := s.Commit()
+	if len(ops) != 2 {
+		t.Fatalf("Expected 2 operations, got %d", len(ops))
+	}
+	if s.CanUndo() || s.CanRedo() {
+		t.Errorf("Expected empty history after Commit")
+	}
+
+	replica := patchTestKML()
+	if err := replica.ApplyPatch(ops); err != nil {
+		t.Fatalf("ApplyPatch failed: %v", err)
+	}
+	if got, want := sessionJSON(t, replica), sessionJSON(t, k); got != want {
+		t.Errorf("Expected replica %s, got %s", want, got)
+	}
+}
+
+// TestSessionEmptyDocument tests undoing the first feature of a document
+func TestSessionEmptyDocument(t *testing.T) {
+	k := NewKML()
+	s := NewSession(k)
+	if err := s.Apply(sessionOps(t, `[{"op":"add","path":"/Feature","value":{"type":"Document","Name":"New"}}]`)); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if err := s.Undo(); err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+	if k.Feature != nil {
+		t.Errorf("Expected no feature, got %+v", k.Feature)
+	}
+}
@@ -0,0 +1,131 @@
+package kml
+
+import (
+	"cmp"
+	"encoding/xml"
+	"slices"
+)
+
+// FeatureSize is the share of a written document taken by one feature and
+// everything inside it, with the parts most worth shrinking broken out.
+// Sizes are in bytes, as written by Write with no options.
+type FeatureSize struct {
+	Feature      Feature
+	Path         []string // Names of the containers above the feature
+	Bytes        int      // The whole feature, as EstimateSize reports
+	Coordinates  int      // Coordinate text of geometries
+	Descriptions int      // Description elements
+	Styles       int      // Inline Styles, and the shared styles of Documents
+}
+
+// Other returns the bytes not counted as coordinates, descriptions or
+// styles: names, ExtendedData, markup and the like.
+func (s FeatureSize) Other() int {
+	return s.Bytes - s.Coordinates - s.Descriptions - s.Styles
+}
+
+// EstimateSize returns the number of bytes f takes up when written by
+// Write with no options, including the features inside it.
+func EstimateSize(f Feature) int {
+	return encodedSize(func(e *xml.Encoder) error {
+		return e.EncodeElement(f, xml.StartElement{Name: xml.Name{Local: f.featureType()}})
+	})
+}
+
+// SizeBreakdown measures every feature of the document, largest first,
+// with features of equal size in document order. Containers include
+// everything inside them, so the first entries show which folders
+// dominate the file and the Placemarks among the entries show which
+// features within them do. Files too large for an importer can be shrunk
+// where it pays most: with RoundCoordinates where coordinates dominate,
+// and by shortening descriptions or sharing inline styles elsewhere.
+func (k *KML) SizeBreakdown() []FeatureSize {
+	var sizes []FeatureSize
+	var visit func(f Feature, path []string) FeatureSize
+	visit = func(f Feature, path []string) FeatureSize {
+		i := len(sizes)
+		sizes = append(sizes, FeatureSize{Feature: f, Path: path, Bytes: EstimateSize(f)})
+		s := FeatureSize{}
+		if desc := featureDescription(f); desc != nil && *desc != "" {
+			s.Descriptions = encodedSize(func(e *xml.Encoder) error { return encodeDescription(e, *desc) })
+		}
+
+		var children []Feature
+		switch feature := f.(type) {
+		case *Document:
+			for j := range feature.Styles {
+				s.Styles += styleSize(&feature.Styles[j])
+			}
+			for j := range feature.StyleMaps {
+				s.Styles += encodedSize(func(e *xml.Encoder) error {
+					return e.EncodeElement(&feature.StyleMaps[j], xml.StartElement{Name: xml.Name{Local: "StyleMap"}})
+				})
+			}
+			children = feature.Features
+		case *Folder:
+			children = feature.Features
+		case *Placemark:
+			if feature.Style != nil {
+				s.Styles += styleSize(feature.Style)
+			}
+			if feature.Geometry != nil {
+				s.Coordinates = coordinateSize(feature.Geometry)
+			}
+		}
+
+		childPath := append(slices.Clip(path), featureName(f))
+		for _, child := range children {
+			c := visit(child, childPath)
+			s.Coordinates += c.Coordinates
+			s.Descriptions += c.Descriptions
+			s.Styles += c.Styles
+		}
+		sizes[i].Coordinates, sizes[i].Descriptions, sizes[i].Styles = s.Coordinates, s.Descriptions, s.Styles
+		return sizes[i]
+	}
+	if k.Feature != nil {
+		visit(k.Feature, nil)
+	}
+
+	slices.SortStableFunc(sizes, func(a, b FeatureSize) int {
+		return cmp.Compare(b.Bytes, a.Bytes)
+	})
+	return sizes
+}
+
+// styleSize returns the written size of s.
+func styleSize(s *Style) int {
+	return encodedSize(func(e *xml.Encoder) error {
+		return e.EncodeElement(s, xml.StartElement{Name: xml.Name{Local: "Style"}})
+	})
+}
+
+// coordinateSize returns the length of the coordinate text of g: each
+// coordinate tuple and the separator after it.
+func coordinateSize(g Geometry) int {
+	n := 0
+	for _, c := range getGeometryCoordinates(g) {
+		n += len(c.String()) + 1
+	}
+	return n
+}
+
+// encodedSize returns the number of bytes encode writes.
+func encodedSize(encode func(e *xml.Encoder) error) int {
+	var n byteCounter
+	e := xml.NewEncoder(&n)
+	// An error leaves only what was written before it, which is the best
+	// estimate there is.
+	_ = encode(e)
+	_ = e.Flush()
+	return int(n)
+}
+
+// byteCounter is an io.Writer that counts the bytes written to it.
+type byteCounter int
+
+// Write implements io.Writer.
+func (c *byteCounter) Write(p []byte) (int, error) {
+	*c += byteCounter(len(p))
+	return len(p), nil
+}
@@ -0,0 +1,113 @@
+package kml
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// sizeTestKML returns a document with a folder of long lines and a folder
+// of placemarks with long descriptions.
+func sizeTestKML() *KML {
+	line := make([]Coordinate, 200)
+	for i := range line {
+		line[i] = Coord(-122.123456+float64(i)*0.001, 37.654321)
+	}
+	return &KML{Feature: &Document{
+		Name:   "Survey",
+		Styles: []Style{{ID: "red", LineStyle: &LineStyle{Color: Red, Width: 2}}},
+		Features: []Feature{
+			&Folder{Name: "Roads", Features: []Feature{
+				&Placemark{Name: "Main", Geometry: &LineString{Coordinates: line}},
+				&Placemark{Name: "Side", Geometry: &LineString{Coordinates: line[:50]}},
+			}},
+			&Folder{Name: "Notes", Features: []Feature{
+				&Placemark{
+					Name:        "Note",
+					Description: "<p>" + strings.Repeat("lorem ipsum ", 100) + "</p>",
+					Style:       &Style{IconStyle: &IconStyle{Scale: 2}},
+					Geometry:    &Point{Coordinates: Coord(1, 2)},
+				},
+			}},
+		},
+	}}
+}
+
+// TestEstimateSize tests that estimates match the written document
+func TestEstimateSize(t *testing.T) {
+	k := sizeTestKML()
+	var buf bytes.Buffer
+	if err := k.Write(&buf); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	written := buf.String()
+	start := strings.Index(written, "<Document>")
+	end := strings.LastIndex(written, "</kml>")
+	if got, want := EstimateSize(k.Feature), end-start; got != want {
+		t.Errorf("Expected Document size %d, got %d", want, got)
+	}
+
+	pm := &Placemark{Name: "a&b"}
+	if got, want := EstimateSize(pm), len("<Placemark><name>a&amp;b</name></Placemark>"); got != want {
+		t.Errorf("Expected Placemark size %d, got %d", want, got)
+	}
+}
+
+// TestSizeBreakdown tests the per-feature breakdown of a document
+func TestSizeBreakdown(t *testing.T) {
+	k := sizeTestKML()
+	sizes := k.SizeBreakdown()
+	if len(sizes) != 6 {
+		t.Fatalf("Expected 6 features, got %d", len(sizes))
+	}
+
+	for i := 1; i < len(sizes); i++ {
+		if sizes[i].Bytes > sizes[i-1].Bytes {
+			t.Errorf("Expected sizes in decreasing order, got %d before %d", sizes[i-1].Bytes, sizes[i].Bytes)
+		}
+	}
+	for _, s := range sizes {
+		if s.Other() < 0 {
+			t.Errorf("Expected parts of %q to fit in its size, got %+v", featureName(s.Feature), s)
+		}
+	}
+
+	byName := make(map[string]FeatureSize)
+	for _, s := range sizes {
+		byName[featureName(s.Feature)] = s
+	}
+
+	doc := byName["Survey"]
+	if sizes[0].Feature != k.Feature || doc.Bytes != EstimateSize(k.Feature) {
+		t.Errorf("Expected document first with its full size, got %+v", sizes[0])
+	}
+	if names := []string{featureName(sizes[1].Feature), featureName(sizes[2].Feature)}; names[0] != "Roads" || names[1] != "Main" {
+		t.Errorf("Expected Roads then Main to dominate, got %v", names)
+	}
+
+	roads, main, side := byName["Roads"], byName["Main"], byName["Side"]
+	if roads.Coordinates != main.Coordinates+side.Coordinates {
+		t.Errorf("Expected folder coordinates %d, got %d", main.Coordinates+side.Coordinates, roads.Coordinates)
+	}
+	if main.Coordinates < main.Bytes*9/10 {
+		t.Errorf("Expected coordinates to dominate Main, got %+v", main)
+	}
+	if len(main.Path) != 2 || main.Path[0] != "Survey" || main.Path[1] != "Roads" {
+		t.Errorf("Expected path [Survey Roads], got %v", main.Path)
+	}
+
+	note := byName["Note"]
+	if note.Descriptions < 1200 || note.Styles == 0 || note.Coordinates != len("1,2 ") {
+		t.Errorf("Expected description, style and coordinates of Note, got %+v", note)
+	}
+	if doc.Styles <= note.Styles || doc.Descriptions != note.Descriptions {
+		t.Errorf("Expected document to add shared styles to Note's, got %+v", doc)
+	}
+	if doc.Coordinates != roads.Coordinates+note.Coordinates {
+		t.Errorf("Expected document coordinates %d, got %d", roads.Coordinates+note.Coordinates, doc.Coordinates)
+	}
+
+	if sizes := (&KML{}).SizeBreakdown(); len(sizes) != 0 {
+		t.Errorf("Expected no sizes for empty document, got %d", len(sizes))
+	}
+}
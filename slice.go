@@ -0,0 +1,103 @@
+package kml
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// SliceOptions configures FromSlice.
+type SliceOptions[T any] struct {
+	// Name is the name of the generated Document.
+	Name string
+
+	// Styles are shared styles added to the Document, typically the targets
+	// of the styleUrls returned by StyleURL.
+	Styles []Style
+
+	// GroupBy, if set, returns the name of the Folder an element is placed
+	// in. Folders are created in the order their names first appear;
+	// elements for which it returns "" are placed directly in the Document.
+	GroupBy func(T) string
+
+	// NameTemplate, if set, is a text/template executed with each element
+	// to produce its placemark name, overriding any kml:"name" field. The
+	// template may call number and time to format float64 and time.Time
+	// values using Locale.
+	NameTemplate string
+
+	// Locale is used by the NameTemplate formatting functions and defaults
+	// to LocaleEnUS.
+	Locale *Locale
+
+	// StyleURL, if set, returns the styleUrl of an element's placemark.
+	StyleURL func(T) string
+}
+
+// FromSlice builds a KML document with one placemark per element of items.
+// Each placemark is produced by MarshalPlacemark, so T is described with
+// kml struct tags; opts control folder grouping, naming and styling.
+func FromSlice[T any](items []T, opts SliceOptions[T]) (*KML, error) {
+	var nameTmpl *template.Template
+	if opts.NameTemplate != "" {
+		var err error
+		locale := LocaleEnUS
+		if opts.Locale != nil {
+			locale = *opts.Locale
+		}
+		funcs := template.FuncMap{
+			"number": locale.FormatNumber,
+			"time":   locale.FormatTime,
+		}
+		nameTmpl, err = template.New("name").Funcs(funcs).Parse(opts.NameTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("kml: error parsing name template: %w", err)
+		}
+	}
+
+	doc := &Document{
+		Name:   opts.Name,
+		Styles: append([]Style(nil), opts.Styles...),
+	}
+	folders := make(map[string]*Folder)
+
+	for i, item := range items {
+		pm, err := MarshalPlacemark(item)
+		if err != nil {
+			return nil, fmt.Errorf("kml: item %d: %w", i, err)
+		}
+
+		if nameTmpl != nil {
+			var sb strings.Builder
+			if err := nameTmpl.Execute(&sb, item); err != nil {
+				return nil, fmt.Errorf("kml: item %d: error executing name template: %w", i, err)
+			}
+			pm.Name = sb.String()
+		}
+
+		if opts.StyleURL != nil {
+			pm.StyleURL = opts.StyleURL(item)
+		}
+
+		group := ""
+		if opts.GroupBy != nil {
+			group = opts.GroupBy(item)
+		}
+		if group == "" {
+			doc.Features = append(doc.Features, pm)
+			continue
+		}
+
+		folder, ok := folders[group]
+		if !ok {
+			folder = &Folder{Name: group}
+			folders[group] = folder
+			doc.Features = append(doc.Features, folder)
+		}
+		folder.Features = append(folder.Features, pm)
+	}
+
+	k := NewKML()
+	k.Feature = doc
+	return k, nil
+}
@@ -0,0 +1,115 @@
+package kml
+
+import (
+	"strings"
+	"testing"
+)
+
+type sliceRow struct {
+	Name   string     `kml:"name"`
+	Region string     `kml:"data=region"`
+	Level  int        `kml:"data=level"`
+	Pos    Coordinate `kml:"coordinates"`
+}
+
+// TestFromSlice tests building a grouped, styled document from a slice
+func TestFromSlice(t *testing.T) {
+	rows := []sliceRow{
+		{Name: "a", Region: "north", Level: 1, Pos: Coord(0, 1)},
+		{Name: "b", Region: "south", Level: 5, Pos: Coord(0, -1)},
+		{Name: "c", Region: "north", Level: 9, Pos: Coord(1, 1)},
+		{Name: "d", Region: "", Level: 2, Pos: Coord(2, 2)},
+	}
+
+	k, err := FromSlice(rows, SliceOptions[sliceRow]{
+		Name:         "Sensors",
+		Styles:       []Style{{ID: "low"}, {ID: "high"}},
+		GroupBy:      func(r sliceRow) string { return r.Region },
+		NameTemplate: "{{.Name}} (L{{.Level}})",
+		StyleURL: func(r sliceRow) string {
+			if r.Level > 4 {
+				return "#high"
+			}
+			return "#low"
+		},
+	})
+	if err != nil {
+		t.Fatalf("FromSlice() error: %v", err)
+	}
+
+	doc := k.Feature.(*Document)
+	if doc.Name != "Sensors" || len(doc.Styles) != 2 {
+		t.Errorf("Unexpected document: name=%q styles=%d", doc.Name, len(doc.Styles))
+	}
+	if len(doc.Features) != 3 {
+		t.Fatalf("Expected 2 folders and 1 placemark, got %d features", len(doc.Features))
+	}
+
+	north := doc.Features[0].(*Folder)
+	south := doc.Features[1].(*Folder)
+	loose := doc.Features[2].(*Placemark)
+
+	if north.Name != "north" || len(north.Features) != 2 {
+		t.Errorf("Unexpected north folder: %q with %d features", north.Name, len(north.Features))
+	}
+	if south.Name != "south" || len(south.Features) != 1 {
+		t.Errorf("Unexpected south folder: %q with %d features", south.Name, len(south.Features))
+	}
+
+	c := north.Features[1].(*Placemark)
+	if c.Name != "c (L9)" {
+		t.Errorf("Expected templated name 'c (L9)', got %q", c.Name)
+	}
+	if c.StyleURL != "#high" {
+		t.Errorf("Expected styleUrl '#high', got %q", c.StyleURL)
+	}
+	if loose.Name != "d (L2)" || loose.StyleURL != "#low" {
+		t.Errorf("Unexpected ungrouped placemark: %q %q", loose.Name, loose.StyleURL)
+	}
+
+	if refs := k.CheckReferences(); refs != nil {
+		t.Errorf("Expected all style references to resolve, got %v", refs)
+	}
+}
+
+// TestFromSliceErrors tests template and binding errors
+func TestFromSliceErrors(t *testing.T) {
+	if _, err := FromSlice([]sliceRow{{}}, SliceOptions[sliceRow]{NameTemplate: "{{.Name"}); err == nil {
+		t.Error("Expected template parse error")
+	}
+
+	if _, err := FromSlice([]sliceRow{{}}, SliceOptions[sliceRow]{NameTemplate: "{{.Missing}}"}); err == nil {
+		t.Error("Expected template execution error")
+	}
+
+	_, err := FromSlice([]int{1}, SliceOptions[int]{})
+	if err == nil || !strings.Contains(err.Error(), "item 0") {
+		t.Errorf("Expected binding error for item 0, got %v", err)
+	}
+}
+
+// TestFromSliceNameTemplateLocale tests the locale formatting template functions
+func TestFromSliceNameTemplateLocale(t *testing.T) {
+	type reading struct {
+		Value float64 `kml:"data=value"`
+	}
+
+	k, err := FromSlice([]reading{{Value: 12345.678}}, SliceOptions[reading]{
+		NameTemplate: "{{number .Value}}",
+		Locale:       &Locale{Precision: 1, DecimalSeparator: ",", ThousandsSeparator: "."},
+	})
+	if err != nil {
+		t.Fatalf("FromSlice failed: %v", err)
+	}
+	if name := k.Placemarks()[0].Name; name != "12.345,7" {
+		t.Errorf("Expected name '12.345,7', got %q", name)
+	}
+
+	k, err = FromSlice([]reading{{Value: 1500}}, SliceOptions[reading]{NameTemplate: "{{number .Value}}"})
+	if err != nil {
+		t.Fatalf("FromSlice failed: %v", err)
+	}
+	if name := k.Placemarks()[0].Name; name != "1,500" {
+		t.Errorf("Expected default en-US name '1,500', got %q", name)
+	}
+}
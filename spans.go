@@ -0,0 +1,113 @@
+package kml
+
+import (
+	"encoding/xml"
+	"fmt"
+)
+
+// Position is a location in a parsed KML document. Offset counts bytes from
+// the start of the uncompressed KML, so for KMZ and gzipped input it refers
+// to doc.kml rather than the archive. Line and Column are 1-based.
+type Position struct {
+	Offset int64 `json:"offset"`
+	Line   int   `json:"line"`
+	Column int   `json:"column"`
+}
+
+// String returns the position as line:column.
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+// Span is the source range of a parsed feature, from the opening angle
+// bracket of its start tag to just past its end tag.
+type Span struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// String returns the span as line:column-line:column.
+func (s Span) String() string {
+	return s.Start.String() + "-" + s.End.String()
+}
+
+// spanState tracks the feature spans of a parse.
+type spanState struct {
+	next  Position   // Where the token about to be read begins
+	stack []Position // Start positions of the features being decoded
+}
+
+// RecordSpans makes Parse record where each feature appears in the input,
+// so tools can point users at the source of a problem. Read a feature's
+// span with SourceSpan.
+func RecordSpans() ParseOption {
+	return func(c *parseConfig) {
+		c.spans = &spanState{}
+	}
+}
+
+// SourceSpan returns the source range of a feature parsed with RecordSpans.
+// It reports false for features that were built in code or parsed without
+// the option.
+func SourceSpan(f Feature) (Span, bool) {
+	if p := spanOf(f); p != nil && *p != nil {
+		return **p, true
+	}
+	return Span{}, false
+}
+
+// spanOf returns a pointer to the span field of f, or nil for an unknown
+// feature type.
+func spanOf(f Feature) **Span {
+	switch feat := f.(type) {
+	case *Document:
+		return &feat.span
+	case *Folder:
+		return &feat.span
+	case *Placemark:
+		return &feat.span
+	case *GroundOverlay:
+		return &feat.span
+	case *NetworkLink:
+		return &feat.span
+	case *ScreenOverlay:
+		return &feat.span
+	case *Tour:
+		return &feat.span
+	}
+	return nil
+}
+
+// markToken notes where the next token of a container read from d begins,
+// if spans are being recorded.
+func markToken(d *xml.Decoder) {
+	if cfg := parseConfigFor(d); cfg != nil && cfg.spans != nil {
+		line, col := d.InputPos()
+		cfg.spans.next = Position{Offset: d.InputOffset(), Line: line, Column: col}
+	}
+}
+
+// beginSpan starts the span of a feature whose start tag was just read from
+// d.
+func beginSpan(d *xml.Decoder) {
+	if cfg := parseConfigFor(d); cfg != nil && cfg.spans != nil {
+		cfg.spans.stack = append(cfg.spans.stack, cfg.spans.next)
+	}
+}
+
+// endSpan records the span of f, whose end tag was just read from d.
+func endSpan(d *xml.Decoder, spans *spanState, f Feature) {
+	n := len(spans.stack)
+	if n == 0 {
+		return
+	}
+	line, col := d.InputPos()
+	span := &Span{
+		Start: spans.stack[n-1],
+		End:   Position{Offset: d.InputOffset(), Line: line, Column: col},
+	}
+	spans.stack = spans.stack[:n-1]
+	if p := spanOf(f); p != nil {
+		*p = span
+	}
+}
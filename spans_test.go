@@ -0,0 +1,113 @@
+package kml
+
+import (
+	"bytes"
+	"compress/gzip"
+	"testing"
+)
+
+const spannedKML = `<kml xmlns="http://www.opengis.net/kml/2.2">
+<Document>
+  <name>Spans</name>
+  <Placemark>
+    <name>A</name>
+  </Placemark>
+  <Folder><Placemark><name>B</name></Placemark></Folder>
+</Document>
+</kml>`
+
+// TestSourceSpan tests recording the source range of each feature
+func TestSourceSpan(t *testing.T) {
+	k, err := ParseBytes([]byte(spannedKML), RecordSpans())
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	doc := k.Feature.(*Document)
+	folder := doc.Features[1].(*Folder)
+
+	tests := []struct {
+		name    string
+		feature Feature
+		text    string
+		start   string
+		end     string
+	}{
+		{"Document", doc, "<Document>", "2:1", "8:12"},
+		{"Placemark", doc.Features[0], "<Placemark>\n    <name>A</name>\n  </Placemark>", "4:3", "6:15"},
+		{"Folder", folder, "<Folder><Placemark><name>B</name></Placemark></Folder>", "7:3", "7:57"},
+		{"nested Placemark", folder.Features[0], "<Placemark><name>B</name></Placemark>", "7:11", "7:48"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			span, ok := SourceSpan(tt.feature)
+			if !ok {
+				t.Fatal("Expected a span")
+			}
+			if got := span.Start.String(); got != tt.start {
+				t.Errorf("Expected start %s, got %s", tt.start, got)
+			}
+			if got := span.End.String(); got != tt.end {
+				t.Errorf("Expected end %s, got %s", tt.end, got)
+			}
+			src := spannedKML[span.Start.Offset:span.End.Offset]
+			if tt.name == "Document" {
+				src = src[:len(tt.text)]
+			}
+			if src != tt.text {
+				t.Errorf("Expected source %q, got %q", tt.text, src)
+			}
+		})
+	}
+}
+
+// TestSourceSpanGzip tests that offsets refer to the uncompressed KML
+func TestSourceSpanGzip(t *testing.T) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write([]byte(spannedKML))
+	zw.Close()
+
+	k, err := ParseBytes(buf.Bytes(), RecordSpans())
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	span, ok := SourceSpan(k.Placemarks()[1])
+	if !ok {
+		t.Fatal("Expected a span")
+	}
+	if got := spannedKML[span.Start.Offset:span.End.Offset]; got != "<Placemark><name>B</name></Placemark>" {
+		t.Errorf("Expected the second Placemark, got %q", got)
+	}
+}
+
+// TestSourceSpanUnrecorded tests features without spans
+func TestSourceSpanUnrecorded(t *testing.T) {
+	k, err := ParseBytes([]byte(spannedKML))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if _, ok := SourceSpan(k.Feature); ok {
+		t.Error("Expected no span without RecordSpans")
+	}
+	if _, ok := SourceSpan(&Placemark{}); ok {
+		t.Error("Expected no span for a constructed feature")
+	}
+}
+
+// TestSourceSpanFragments tests spans of fragment features
+func TestSourceSpanFragments(t *testing.T) {
+	data := `<Point><coordinates>1,2</coordinates></Point>
+<Placemark><name>A</name></Placemark>`
+	objs, err := ParseFragments([]byte(data), RecordSpans())
+	if err != nil {
+		t.Fatalf("ParseFragments failed: %v", err)
+	}
+	span, ok := SourceSpan(objs[1].(*Placemark))
+	if !ok {
+		t.Fatal("Expected a span")
+	}
+	if got := span.String(); got != "2:1-2:38" {
+		t.Errorf("Expected span 2:1-2:38, got %s", got)
+	}
+}
@@ -0,0 +1,143 @@
+package kml
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// SQLGeometry adapts a Geometry for use with database/sql.
+//
+// As an sql.Scanner it accepts KML geometry markup, such as the output of
+// PostGIS ST_AsKML, as well as WKB and PostGIS EWKB in binary or hex form,
+// which is how PostGIS returns geometry columns. A NULL column scans to a
+// nil Geometry.
+//
+// As a driver.Valuer it produces KML geometry markup, which PostGIS reads
+// back with ST_GeomFromKML.
+type SQLGeometry struct {
+	Geometry Geometry
+}
+
+// Scan implements sql.Scanner.
+func (g *SQLGeometry) Scan(src any) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		g.Geometry = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("kml: cannot scan %T into SQLGeometry", src)
+	}
+
+	text := bytes.TrimSpace(data)
+	if len(text) > 0 && text[0] == '<' {
+		geom, err := decodeGeometryMarkup(text)
+		if err != nil {
+			return err
+		}
+		g.Geometry = geom
+		return nil
+	}
+
+	if isHex(text) {
+		decoded, err := hex.DecodeString(string(text))
+		if err != nil {
+			return fmt.Errorf("kml: invalid hex WKB: %w", err)
+		}
+		data = decoded
+	}
+
+	geom, err := decodeWKB(data)
+	if err != nil {
+		return err
+	}
+	g.Geometry = geom
+	return nil
+}
+
+// Value implements driver.Valuer.
+func (g SQLGeometry) Value() (driver.Value, error) {
+	if g.Geometry == nil {
+		return nil, nil
+	}
+	data, err := xml.Marshal(g.Geometry)
+	if err != nil {
+		return nil, fmt.Errorf("kml: error encoding geometry: %w", err)
+	}
+	return string(data), nil
+}
+
+// decodeGeometryMarkup decodes a single KML geometry element.
+func decodeGeometryMarkup(data []byte) (Geometry, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, fmt.Errorf("kml: no geometry element found: %w", err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		geom := newGeometry(start.Name.Local)
+		if geom == nil {
+			return nil, fmt.Errorf("kml: unsupported geometry element %s", start.Name.Local)
+		}
+		if err := d.DecodeElement(geom, &start); err != nil {
+			return nil, &ParseError{Message: "error parsing " + start.Name.Local + " element", Cause: err}
+		}
+		return geom, nil
+	}
+}
+
+// isHex reports whether b is a non-empty, even-length hexadecimal string.
+func isHex(b []byte) bool {
+	if len(b) == 0 || len(b)%2 != 0 {
+		return false
+	}
+	for _, c := range b {
+		switch {
+		case c >= '0' && c <= '9', c >= 'a' && c <= 'f', c >= 'A' && c <= 'F':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// WriteRows streams query results to w as a KML Document named name,
+// calling scan once per row to turn it into a Placemark. Rows for which scan
+// returns a nil Placemark are skipped. Each placemark is flushed as soon as it
+// is encoded, so arbitrarily large result sets use constant memory.
+//
+// WriteRows does not close rows; it reports rows.Err after iteration.
+func WriteRows(w io.Writer, name string, rows *sql.Rows, scan func(*sql.Rows) (*Placemark, error)) error {
+	enc := NewStreamEncoder(w, name)
+
+	for rows.Next() {
+		pm, err := scan(rows)
+		if err != nil {
+			return fmt.Errorf("kml: error scanning row: %w", err)
+		}
+		if pm == nil {
+			continue
+		}
+		if err := enc.Encode(pm); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("kml: error reading rows: %w", err)
+	}
+
+	return enc.Close()
+}
@@ -0,0 +1,273 @@
+package kml
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeDriver is a minimal database/sql driver that returns canned rows for
+// any query, used to exercise the SQL adapters without a database.
+type fakeDriver struct{}
+
+var (
+	fakeDriverOnce sync.Once
+	fakeTables     sync.Map // DSN -> *fakeTable
+)
+
+type fakeTable struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (fakeDriver) Open(dsn string) (driver.Conn, error) {
+	t, ok := fakeTables.Load(dsn)
+	if !ok {
+		return nil, fmt.Errorf("unknown fake table %q", dsn)
+	}
+	return &fakeConn{table: t.(*fakeTable)}, nil
+}
+
+type fakeConn struct{ table *fakeTable }
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) { return &fakeStmt{table: c.table}, nil }
+func (c *fakeConn) Close() error                              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error)                 { return nil, errors.New("not supported") }
+
+type fakeStmt struct{ table *fakeTable }
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, errors.New("not supported")
+}
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return &fakeRows{table: s.table}, nil
+}
+
+type fakeRows struct {
+	table *fakeTable
+	pos   int
+}
+
+func (r *fakeRows) Columns() []string { return r.table.columns }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.pos >= len(r.table.rows) {
+		return io.EOF
+	}
+	copy(dest, r.table.rows[r.pos])
+	r.pos++
+	return nil
+}
+
+// openFakeDB registers a table under name and opens a database serving it.
+func openFakeDB(name string, table *fakeTable) (*sql.DB, error) {
+	fakeDriverOnce.Do(func() { sql.Register("kmlfake", fakeDriver{}) })
+	fakeTables.Store(name, table)
+	return sql.Open("kmlfake", name)
+}
+
+func TestSQLGeometryScan(t *testing.T) {
+	pointWKB := (&wkbBuilder{}).header(wkbPoint).float(-122.08, 37.42).buf
+
+	tests := []struct {
+		name string
+		src  any
+		want Coordinate
+	}{
+		{"KML string", `<Point><coordinates>-122.08,37.42,0</coordinates></Point>`, Coordinate{Lon: -122.08, Lat: 37.42}},
+		{"KML bytes", []byte(" <Point><coordinates>1,2</coordinates></Point>"), Coordinate{Lon: 1, Lat: 2}},
+		{"WKB bytes", pointWKB, Coordinate{Lon: -122.08, Lat: 37.42}},
+		{"hex EWKB string", strings.ToUpper(hex.EncodeToString(pointWKB)), Coordinate{Lon: -122.08, Lat: 37.42}},
+		{"hex EWKB bytes", []byte(hex.EncodeToString(pointWKB)), Coordinate{Lon: -122.08, Lat: 37.42}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var g SQLGeometry
+			if err := g.Scan(tt.src); err != nil {
+				t.Fatalf("Scan failed: %v", err)
+			}
+			pt, ok := g.Geometry.(*Point)
+			if !ok {
+				t.Fatalf("Expected *Point, got %T", g.Geometry)
+			}
+			if pt.Coordinates != tt.want {
+				t.Errorf("Expected %v, got %v", tt.want, pt.Coordinates)
+			}
+		})
+	}
+}
+
+func TestSQLGeometryScanNull(t *testing.T) {
+	g := SQLGeometry{Geometry: &Point{}}
+	if err := g.Scan(nil); err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if g.Geometry != nil {
+		t.Errorf("Expected nil geometry, got %T", g.Geometry)
+	}
+}
+
+func TestSQLGeometryScanErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  any
+	}{
+		{"unsupported type", 42},
+		{"unknown element", "<Model/>"},
+		{"malformed KML", "<Point><coordinates>1,2"},
+		{"bad WKB", []byte{9, 9, 9}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var g SQLGeometry
+			if err := g.Scan(tt.src); err == nil {
+				t.Error("Expected error")
+			}
+		})
+	}
+}
+
+func TestSQLGeometryValue(t *testing.T) {
+	v, err := SQLGeometry{Geometry: &Point{Coordinates: Coordinate{Lon: 1, Lat: 2}}}.Value()
+	if err != nil {
+		t.Fatalf("Value failed: %v", err)
+	}
+	s, ok := v.(string)
+	if !ok {
+		t.Fatalf("Expected string value, got %T", v)
+	}
+
+	var g SQLGeometry
+	if err := g.Scan(s); err != nil {
+		t.Fatalf("Scan of Value output failed: %v", err)
+	}
+	if pt := g.Geometry.(*Point); pt.Coordinates.Lon != 1 || pt.Coordinates.Lat != 2 {
+		t.Errorf("Expected (1, 2), got %v", pt.Coordinates)
+	}
+
+	v, err = SQLGeometry{}.Value()
+	if err != nil || v != nil {
+		t.Errorf("Expected nil value for nil geometry, got %v, %v", v, err)
+	}
+}
+
+func TestWriteRows(t *testing.T) {
+	db, err := openFakeDB("TestWriteRows", &fakeTable{
+		columns: []string{"name", "geom"},
+		rows: [][]driver.Value{
+			{"HQ", "<Point><coordinates>-122.08,37.42</coordinates></Point>"},
+			{"skip", nil},
+			{"Depot", (&wkbBuilder{}).header(wkbPoint).float(-122.1, 37.4).buf},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	rows, err := db.Query("SELECT name, geom FROM sites")
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	defer rows.Close()
+
+	var buf bytes.Buffer
+	err = WriteRows(&buf, "Sites", rows, func(rows *sql.Rows) (*Placemark, error) {
+		var name string
+		var geom SQLGeometry
+		if err := rows.Scan(&name, &geom); err != nil {
+			return nil, err
+		}
+		if geom.Geometry == nil {
+			return nil, nil
+		}
+		return &Placemark{Name: name, Geometry: geom.Geometry}, nil
+	})
+	if err != nil {
+		t.Fatalf("WriteRows failed: %v", err)
+	}
+
+	k, err := ParseBytes(buf.Bytes())
+	if err != nil {
+		t.Fatalf("ParseBytes failed: %v", err)
+	}
+	doc := k.Feature.(*Document)
+	if len(doc.Features) != 2 {
+		t.Fatalf("Expected 2 placemarks, got %d", len(doc.Features))
+	}
+	if name := doc.Features[1].(*Placemark).Name; name != "Depot" {
+		t.Errorf("Expected second placemark 'Depot', got %q", name)
+	}
+}
+
+func TestWriteRowsScanError(t *testing.T) {
+	db, err := openFakeDB("TestWriteRowsScanError", &fakeTable{
+		columns: []string{"geom"},
+		rows:    [][]driver.Value{{"not a geometry"}},
+	})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	rows, err := db.Query("SELECT geom FROM sites")
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	defer rows.Close()
+
+	err = WriteRows(io.Discard, "", rows, func(rows *sql.Rows) (*Placemark, error) {
+		var geom SQLGeometry
+		return nil, rows.Scan(&geom)
+	})
+	if err == nil {
+		t.Error("Expected error from failing scan")
+	}
+}
+
+func ExampleWriteRows() {
+	db, err := openFakeDB("ExampleWriteRows", &fakeTable{
+		columns: []string{"name", "geom"},
+		rows: [][]driver.Value{
+			{"HQ", "<Point><coordinates>-122.08,37.42</coordinates></Point>"},
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+	defer db.Close()
+
+	// With PostGIS: SELECT name, ST_AsKML(geom) FROM sites
+	rows, err := db.Query("SELECT name, geom FROM sites")
+	if err != nil {
+		panic(err)
+	}
+	defer rows.Close()
+
+	err = WriteRows(os.Stdout, "Sites", rows, func(rows *sql.Rows) (*Placemark, error) {
+		var name string
+		var geom SQLGeometry
+		if err := rows.Scan(&name, &geom); err != nil {
+			return nil, err
+		}
+		return &Placemark{Name: name, Geometry: geom.Geometry}, nil
+	})
+	if err != nil {
+		panic(err)
+	}
+	// Output:
+	// <?xml version="1.0" encoding="UTF-8"?>
+	// <kml xmlns="http://www.opengis.net/kml/2.2"><Document><name>Sites</name><Placemark><name>HQ</name><Point><coordinates>-122.08,37.42</coordinates></Point></Placemark></Document></kml>
+}
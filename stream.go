@@ -0,0 +1,119 @@
+package kml
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// StreamEncoder writes a KML Document one feature at a time, so documents
+// far larger than memory can be produced from a stream of records.
+//
+// The XML declaration and the opening kml and Document elements are written
+// by the first call to Encode or Close; Close must be called to complete the
+// document.
+type StreamEncoder struct {
+	w       io.Writer
+	enc     *xml.Encoder
+	name    string
+	cfg     *writeConfig
+	started bool
+	closed  bool
+}
+
+// NewStreamEncoder returns a StreamEncoder that writes a Document with the
+// given name (which may be empty) to w. Write options such as Precision
+// apply to every encoded feature.
+func NewStreamEncoder(w io.Writer, name string, opts ...WriteOption) *StreamEncoder {
+	s := &StreamEncoder{
+		w:    w,
+		enc:  xml.NewEncoder(w),
+		name: name,
+	}
+	if len(opts) > 0 {
+		s.cfg = newWriteConfig(opts)
+		if s.cfg.progress != nil {
+			s.w = &progressWriter{w: w, t: s.cfg.progress}
+			s.enc = xml.NewEncoder(s.w)
+		}
+	}
+	return s
+}
+
+// start writes the document preamble if it has not been written yet.
+func (s *StreamEncoder) start() error {
+	if s.started {
+		return nil
+	}
+	s.started = true
+
+	if _, err := io.WriteString(s.w, XMLHeader); err != nil {
+		return fmt.Errorf("kml: error writing XML header: %w", err)
+	}
+
+	xmlns := DefaultNamespace
+	if s.cfg != nil && s.cfg.version != VersionUnknown {
+		xmlns = s.cfg.version.Namespace()
+	}
+	kmlStart := xml.StartElement{
+		Name: xml.Name{Local: "kml"},
+		Attr: []xml.Attr{{Name: xml.Name{Local: "xmlns"}, Value: xmlns}},
+	}
+	if err := s.enc.EncodeToken(kmlStart); err != nil {
+		return fmt.Errorf("kml: error encoding KML document: %w", err)
+	}
+	if err := s.enc.EncodeToken(xml.StartElement{Name: xml.Name{Local: "Document"}}); err != nil {
+		return fmt.Errorf("kml: error encoding KML document: %w", err)
+	}
+	if s.name != "" {
+		if err := s.enc.EncodeElement(s.name, xml.StartElement{Name: xml.Name{Local: "name"}}); err != nil {
+			return fmt.Errorf("kml: error encoding KML document: %w", err)
+		}
+	}
+	return nil
+}
+
+// Encode writes a feature to the document and flushes it to the underlying
+// writer.
+func (s *StreamEncoder) Encode(f Feature) error {
+	if s.closed {
+		return errors.New("kml: encode on closed StreamEncoder")
+	}
+	if err := s.start(); err != nil {
+		return err
+	}
+	if s.cfg != nil {
+		encoderConfigs.Store(s.enc, s.cfg)
+		defer encoderConfigs.Delete(s.enc)
+	}
+	if err := encodeFeature(s.enc, f); err != nil {
+		return fmt.Errorf("kml: error encoding %s: %w", f.featureType(), err)
+	}
+	return s.enc.Flush()
+}
+
+// Close completes the document. It does not close the underlying writer.
+func (s *StreamEncoder) Close() error {
+	if s.closed {
+		return nil
+	}
+	if err := s.start(); err != nil {
+		return err
+	}
+	s.closed = true
+
+	if err := s.enc.EncodeToken(xml.EndElement{Name: xml.Name{Local: "Document"}}); err != nil {
+		return fmt.Errorf("kml: error encoding KML document: %w", err)
+	}
+	if err := s.enc.EncodeToken(xml.EndElement{Name: xml.Name{Local: "kml"}}); err != nil {
+		return fmt.Errorf("kml: error encoding KML document: %w", err)
+	}
+	if err := s.enc.Flush(); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(s.w, "\n"); err != nil {
+		return fmt.Errorf("kml: error writing final newline: %w", err)
+	}
+	return nil
+}
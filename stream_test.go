@@ -0,0 +1,77 @@
+package kml
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestStreamEncoderRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewStreamEncoder(&buf, "Stream")
+
+	for i, name := range []string{"A", "B", "C"} {
+		pm := &Placemark{Name: name, Geometry: &Point{Coordinates: Coordinate{Lon: float64(i), Lat: float64(i)}}}
+		if err := enc.Encode(pm); err != nil {
+			t.Fatalf("Encode failed: %v", err)
+		}
+	}
+	if err := enc.Encode(&Folder{Name: "Empty"}); err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	if !strings.HasPrefix(buf.String(), XMLHeader) {
+		t.Error("Expected output to start with XML header")
+	}
+
+	k, err := ParseBytes(buf.Bytes())
+	if err != nil {
+		t.Fatalf("ParseBytes failed: %v", err)
+	}
+	doc, ok := k.Feature.(*Document)
+	if !ok {
+		t.Fatalf("Expected *Document, got %T", k.Feature)
+	}
+	if doc.Name != "Stream" {
+		t.Errorf("Expected name 'Stream', got %q", doc.Name)
+	}
+	if len(doc.Features) != 4 {
+		t.Fatalf("Expected 4 features, got %d", len(doc.Features))
+	}
+	if _, ok := doc.Features[3].(*Folder); !ok {
+		t.Errorf("Expected last feature to be *Folder, got %T", doc.Features[3])
+	}
+}
+
+func TestStreamEncoderEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewStreamEncoder(&buf, "")
+	if err := enc.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	k, err := ParseBytes(buf.Bytes())
+	if err != nil {
+		t.Fatalf("ParseBytes failed: %v", err)
+	}
+	if _, ok := k.Feature.(*Document); !ok {
+		t.Errorf("Expected *Document, got %T", k.Feature)
+	}
+}
+
+func TestStreamEncoderEncodeAfterClose(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewStreamEncoder(&buf, "")
+	if err := enc.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if err := enc.Encode(&Placemark{}); err == nil {
+		t.Error("Expected error encoding after Close")
+	}
+	if err := enc.Close(); err != nil {
+		t.Errorf("Expected second Close to be a no-op, got %v", err)
+	}
+}
@@ -8,6 +8,7 @@ type Style struct {
 	LineStyle    *LineStyle    `xml:"LineStyle,omitempty"`
 	PolyStyle    *PolyStyle    `xml:"PolyStyle,omitempty"`
 	BalloonStyle *BalloonStyle `xml:"BalloonStyle,omitempty"`
+	ListStyle    *ListStyle    `xml:"ListStyle,omitempty"`
 }
 
 // IconStyle specifies how icons are drawn.
@@ -58,6 +59,32 @@ type BalloonStyle struct {
 	Text      string `xml:"text,omitempty"`
 }
 
+// ListStyle specifies how a feature and its children appear in the list
+// view of Google Earth's Places panel.
+type ListStyle struct {
+	ListItemType    string     `xml:"listItemType,omitempty"` // One of the ListItem constants; "" means check
+	BgColor         *Color     `xml:"bgColor,omitempty"`
+	ItemIcons       []ItemIcon `xml:"ItemIcon,omitempty"`
+	MaxSnippetLines *int       `xml:"maxSnippetLines,omitempty"`
+}
+
+// List item types, setting how the visibility of a feature's children is
+// toggled in the list view.
+const (
+	ListItemCheck             = "check"
+	ListItemRadioFolder       = "radioFolder"
+	ListItemCheckOffOnly      = "checkOffOnly"
+	ListItemCheckHideChildren = "checkHideChildren"
+)
+
+// ItemIcon is the icon shown for a feature in the list view. State is a
+// space-separated list of the states it is used in, such as "open" or
+// "closed" for a folder; "" means every state.
+type ItemIcon struct {
+	State string `xml:"state,omitempty"`
+	Href  string `xml:"href,omitempty"`
+}
+
 // StyleMap maps between normal and highlight styles.
 type StyleMap struct {
 	ID    string `xml:"id,attr,omitempty"`
@@ -69,3 +96,89 @@ type Pair struct {
 	Key      string `xml:"key"`      // "normal" or "highlight"
 	StyleURL string `xml:"styleUrl"`
 }
+
+// styleIndex holds the shared styles declared across every Document in a
+// KML tree, keyed by ID.
+type styleIndex struct {
+	styles    map[string]*Style
+	styleMaps map[string]*StyleMap
+}
+
+// newStyleIndex collects the shared Style and StyleMap elements of k.
+// When several elements share an ID, the first one in document order wins.
+func newStyleIndex(k *KML) *styleIndex {
+	idx := &styleIndex{
+		styles:    make(map[string]*Style),
+		styleMaps: make(map[string]*StyleMap),
+	}
+
+	k.Walk(func(f Feature) error {
+		doc, ok := f.(*Document)
+		if !ok {
+			return nil
+		}
+		for i := range doc.Styles {
+			s := &doc.Styles[i]
+			if _, exists := idx.styles[s.ID]; s.ID != "" && !exists {
+				idx.styles[s.ID] = s
+			}
+		}
+		for i := range doc.StyleMaps {
+			sm := &doc.StyleMaps[i]
+			if _, exists := idx.styleMaps[sm.ID]; sm.ID != "" && !exists {
+				idx.styleMaps[sm.ID] = sm
+			}
+		}
+		return nil
+	})
+
+	return idx
+}
+
+// has reports whether id names a shared Style or StyleMap.
+func (idx *styleIndex) has(id string) bool {
+	_, isStyle := idx.styles[id]
+	_, isMap := idx.styleMaps[id]
+	return isStyle || isMap
+}
+
+// localFragment returns the ID referenced by a document-local URL such as
+// "#myStyle". The second result is false for external references.
+func localFragment(url string) (string, bool) {
+	if len(url) == 0 || url[0] != '#' {
+		return "", false
+	}
+	return url[1:], true
+}
+
+// resolve returns the shared styles reachable from a document-local style
+// URL. A Style reference yields that style; a StyleMap reference yields the
+// styles of all of its pairs. External and unresolved URLs yield nil.
+func (idx *styleIndex) resolve(url string) []*Style {
+	return idx.resolveSeen(url, make(map[string]bool))
+}
+
+// resolveSeen implements resolve, using seen to guard against StyleMaps that
+// reference each other in a cycle.
+func (idx *styleIndex) resolveSeen(url string, seen map[string]bool) []*Style {
+	id, ok := localFragment(url)
+	if !ok || seen[id] {
+		return nil
+	}
+	seen[id] = true
+
+	if s, ok := idx.styles[id]; ok {
+		return []*Style{s}
+	}
+
+	sm, ok := idx.styleMaps[id]
+	if !ok {
+		return nil
+	}
+
+	var styles []*Style
+	for _, pair := range sm.Pairs {
+		styles = append(styles, idx.resolveSeen(pair.StyleURL, seen)...)
+	}
+	return styles
+}
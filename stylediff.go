@@ -0,0 +1,321 @@
+package kml
+
+import (
+	"encoding/xml"
+	"fmt"
+	"sort"
+)
+
+// StyleChangeKind is the kind of a StyleChange.
+type StyleChangeKind string
+
+const (
+	// StyleAdded is a shared style only the second document declares.
+	StyleAdded StyleChangeKind = "added"
+
+	// StyleRemoved is a shared style only the first document declares.
+	StyleRemoved StyleChangeKind = "removed"
+
+	// StyleChanged is a shared style both documents declare differently.
+	StyleChanged StyleChangeKind = "changed"
+
+	// StyleRenamed is a shared style MigrateStyles replaced with an
+	// identical one of another ID.
+	StyleRenamed StyleChangeKind = "renamed"
+)
+
+// StyleChange describes a difference in the shared Style and StyleMap
+// elements of two documents, or a change MigrateStyles made.
+type StyleChange struct {
+	Kind    StyleChangeKind
+	Element string // "Style" or "StyleMap"
+	ID      string
+	NewID   string // For StyleRenamed, the ID now used in its place
+}
+
+// String returns a human-readable description of the change.
+func (c StyleChange) String() string {
+	if c.Kind == StyleRenamed {
+		return fmt.Sprintf("%s %q renamed to %q", c.Element, c.ID, c.NewID)
+	}
+	return fmt.Sprintf("%s %q %s", c.Element, c.ID, c.Kind)
+}
+
+// DiffStyles compares the shared styles of two documents, the Style and
+// StyleMap elements of their Documents, by ID. It reports the styles b
+// adds, those it removes and those it declares differently from a, sorted
+// by ID, or nil if the two are styled alike. Inline styles are part of
+// their features and not compared.
+func DiffStyles(a, b *KML) []StyleChange {
+	ia, ib := newStyleIndex(a), newStyleIndex(b)
+	var changes []StyleChange
+	for _, id := range styleIDs(ia, ib) {
+		elemA, contentA, inA := ia.encoded(id)
+		elemB, contentB, inB := ib.encoded(id)
+		switch {
+		case !inA:
+			changes = append(changes, StyleChange{Kind: StyleAdded, Element: elemB, ID: id})
+		case !inB:
+			changes = append(changes, StyleChange{Kind: StyleRemoved, Element: elemA, ID: id})
+		case elemA != elemB || contentA != contentB:
+			changes = append(changes, StyleChange{Kind: StyleChanged, Element: elemB, ID: id})
+		}
+	}
+	return changes
+}
+
+// MigrateStyles brings the shared styles of target in line with those of
+// source, so a family of layers published separately looks the same:
+//
+//   - A style of target that source declares differently under the same ID
+//     is replaced with the source's.
+//   - A style of target identical to a source style of another ID is
+//     removed, and the styleUrls and StyleMap pairs using it are remapped
+//     to the source's ID.
+//   - Source styles that target then references but does not declare,
+//     directly or through a StyleMap, are copied to its top-level
+//     Document, which is added around its feature if it has none.
+//
+// Styles only target declares are kept. MigrateStyles returns the changes
+// made, replacements and renames sorted by ID, then additions in the order
+// target references them.
+func MigrateStyles(target, source *KML) []StyleChange {
+	src, tgt := newStyleIndex(source), newStyleIndex(target)
+	var changes []StyleChange
+	renamed := make(map[string]string)
+	dropped := make(map[styleKey]bool)
+
+	// Styles come first so StyleMaps are compared with their pairs remapped.
+	styleByContent := make(map[string]string)
+	for _, id := range sortedKeys(src.styles) {
+		s := *src.styles[id]
+		s.ID = ""
+		if key := encodeStyle(s); styleByContent[key] == "" {
+			styleByContent[key] = id
+		}
+	}
+	for _, id := range sortedKeys(tgt.styles) {
+		s := tgt.styles[id]
+		if from, ok := src.styles[id]; ok {
+			if encodeStyle(*from) != encodeStyle(*s) {
+				*s = copyStyle(*from)
+				changes = append(changes, StyleChange{Kind: StyleChanged, Element: "Style", ID: id})
+			}
+			continue
+		}
+		if src.has(id) {
+			continue
+		}
+		bare := *s
+		bare.ID = ""
+		if newID, ok := styleByContent[encodeStyle(bare)]; ok {
+			renamed[id] = newID
+			dropped[styleKey{"Style", id}] = true
+			changes = append(changes, StyleChange{Kind: StyleRenamed, Element: "Style", ID: id, NewID: newID})
+		}
+	}
+
+	for _, sm := range tgt.styleMaps {
+		for i := range sm.Pairs {
+			remapStyleURL(&sm.Pairs[i].StyleURL, renamed)
+		}
+	}
+	mapByContent := make(map[string]string)
+	for _, id := range sortedKeys(src.styleMaps) {
+		sm := StyleMap{Pairs: src.styleMaps[id].Pairs}
+		if key := encodeStyle(sm); mapByContent[key] == "" {
+			mapByContent[key] = id
+		}
+	}
+	for _, id := range sortedKeys(tgt.styleMaps) {
+		sm := tgt.styleMaps[id]
+		if from, ok := src.styleMaps[id]; ok {
+			if encodeStyle(*from) != encodeStyle(*sm) {
+				sm.Pairs = append([]Pair(nil), from.Pairs...)
+				changes = append(changes, StyleChange{Kind: StyleChanged, Element: "StyleMap", ID: id})
+			}
+			continue
+		}
+		if src.has(id) {
+			continue
+		}
+		if newID, ok := mapByContent[encodeStyle(StyleMap{Pairs: sm.Pairs})]; ok {
+			renamed[id] = newID
+			dropped[styleKey{"StyleMap", id}] = true
+			changes = append(changes, StyleChange{Kind: StyleRenamed, Element: "StyleMap", ID: id, NewID: newID})
+		}
+	}
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].ID < changes[j].ID })
+
+	if len(renamed) > 0 {
+		target.Walk(func(f Feature) error {
+			if p := styleURLField(f); p != nil {
+				remapStyleURL(p, renamed)
+			}
+			if doc, ok := f.(*Document); ok {
+				doc.Styles = removeStyles(doc.Styles, func(s *Style) bool { return dropped[styleKey{"Style", s.ID}] })
+				doc.StyleMaps = removeStyles(doc.StyleMaps, func(sm *StyleMap) bool { return dropped[styleKey{"StyleMap", sm.ID}] })
+			}
+			return nil
+		})
+	}
+
+	// Copy the source styles target now references but lacks.
+	tgt = newStyleIndex(target)
+	var styles []Style
+	var styleMaps []StyleMap
+	seen := make(map[string]bool)
+	var add func(url string)
+	add = func(url string) {
+		id, ok := localFragment(url)
+		if !ok || seen[id] {
+			return
+		}
+		seen[id] = true
+		if tgt.has(id) {
+			if _, isStyle := tgt.styles[id]; !isStyle {
+				for _, pair := range tgt.styleMaps[id].Pairs {
+					add(pair.StyleURL)
+				}
+			}
+			return
+		}
+		if s, ok := src.styles[id]; ok {
+			styles = append(styles, copyStyle(*s))
+			changes = append(changes, StyleChange{Kind: StyleAdded, Element: "Style", ID: id})
+		} else if sm, ok := src.styleMaps[id]; ok {
+			copied := *sm
+			copied.Pairs = append([]Pair(nil), sm.Pairs...)
+			styleMaps = append(styleMaps, copied)
+			changes = append(changes, StyleChange{Kind: StyleAdded, Element: "StyleMap", ID: id})
+			for _, pair := range sm.Pairs {
+				add(pair.StyleURL)
+			}
+		}
+	}
+	target.Walk(func(f Feature) error {
+		add(styleURLOf(f))
+		return nil
+	})
+
+	if len(styles) > 0 || len(styleMaps) > 0 {
+		root, ok := target.Feature.(*Document)
+		if !ok {
+			root = &Document{}
+			if target.Feature != nil {
+				root.Features = []Feature{target.Feature}
+			}
+			target.Feature = root
+		}
+		root.Styles = append(root.Styles, styles...)
+		root.StyleMaps = append(root.StyleMaps, styleMaps...)
+	}
+	return changes
+}
+
+// copyStyle returns a copy of s sharing none of its substyles, so that
+// styles copied between documents can be edited independently.
+func copyStyle(s Style) Style {
+	s.IconStyle = copyPtr(s.IconStyle)
+	if s.IconStyle != nil {
+		s.IconStyle.Icon = copyPtr(s.IconStyle.Icon)
+		s.IconStyle.HotSpot = copyPtr(s.IconStyle.HotSpot)
+	}
+	s.LabelStyle = copyPtr(s.LabelStyle)
+	s.LineStyle = copyPtr(s.LineStyle)
+	s.PolyStyle = copyPtr(s.PolyStyle)
+	if s.PolyStyle != nil {
+		s.PolyStyle.Fill = copyPtr(s.PolyStyle.Fill)
+		s.PolyStyle.Outline = copyPtr(s.PolyStyle.Outline)
+	}
+	s.BalloonStyle = copyPtr(s.BalloonStyle)
+	s.ListStyle = copyPtr(s.ListStyle)
+	if s.ListStyle != nil {
+		s.ListStyle.BgColor = copyPtr(s.ListStyle.BgColor)
+		s.ListStyle.ItemIcons = append([]ItemIcon(nil), s.ListStyle.ItemIcons...)
+		s.ListStyle.MaxSnippetLines = copyPtr(s.ListStyle.MaxSnippetLines)
+	}
+	return s
+}
+
+// copyPtr returns a pointer to a copy of *p, or nil if p is nil.
+func copyPtr[T any](p *T) *T {
+	if p == nil {
+		return nil
+	}
+	v := *p
+	return &v
+}
+
+// styleKey identifies a shared Style or StyleMap.
+type styleKey struct {
+	element, id string
+}
+
+// encoded returns the element name and XML encoding of the shared style
+// id, preferring a Style to a StyleMap of the same ID as resolve does.
+func (idx *styleIndex) encoded(id string) (element, content string, ok bool) {
+	if s, ok := idx.styles[id]; ok {
+		return "Style", encodeStyle(*s), true
+	}
+	if sm, ok := idx.styleMaps[id]; ok {
+		return "StyleMap", encodeStyle(*sm), true
+	}
+	return "", "", false
+}
+
+// encodeStyle returns the XML encoding of a Style or StyleMap, which is
+// equal for equal styles.
+func encodeStyle(v any) string {
+	data, _ := xml.Marshal(v)
+	return string(data)
+}
+
+// styleIDs returns the IDs of the shared styles of either index, sorted.
+func styleIDs(a, b *styleIndex) []string {
+	set := make(map[string]bool)
+	for _, idx := range []*styleIndex{a, b} {
+		for id := range idx.styles {
+			set[id] = true
+		}
+		for id := range idx.styleMaps {
+			set[id] = true
+		}
+	}
+	return sortedKeys(set)
+}
+
+// sortedKeys returns the keys of m in order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// remapStyleURL points a document-local styleUrl at the new ID of a
+// renamed style.
+func remapStyleURL(url *string, renamed map[string]string) {
+	if id, ok := localFragment(*url); ok {
+		if newID, ok := renamed[id]; ok {
+			*url = "#" + newID
+		}
+	}
+}
+
+// styleURLField returns the styleUrl field of f, or nil if it has none.
+func styleURLField(f Feature) *string {
+	switch feature := f.(type) {
+	case *Document:
+		return &feature.StyleURL
+	case *Folder:
+		return &feature.StyleURL
+	case *Placemark:
+		return &feature.StyleURL
+	case *GroundOverlay:
+		return &feature.StyleURL
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package kml
+
+import (
+	"strings"
+	"testing"
+)
+
+// styleDiffKML parses a document for the style diff tests.
+func styleDiffKML(t *testing.T, body string) *KML {
+	t.Helper()
+	k, err := ParseBytes([]byte(`<kml xmlns="http://www.opengis.net/kml/2.2"><Document>` + body + `</Document></kml>`))
+	if err != nil {
+		t.Fatalf("Failed to parse KML: %v", err)
+	}
+	return k
+}
+
+// changeStrings formats style changes for comparison.
+func changeStrings(changes []StyleChange) string {
+	var s []string
+	for _, c := range changes {
+		s = append(s, c.String())
+	}
+	return strings.Join(s, "; ")
+}
+
+// TestDiffStyles tests reporting added, removed and changed shared styles
+func TestDiffStyles(t *testing.T) {
+	a := styleDiffKML(t, `
+<Style id="road"><LineStyle><color>ff0000ff</color></LineStyle></Style>
+<Style id="river"><LineStyle><color>ffff0000</color></LineStyle></Style>
+<Style id="same"><LineStyle><width>2</width></LineStyle></Style>
+<StyleMap id="pin"><Pair><key>normal</key><styleUrl>#road</styleUrl></Pair></StyleMap>`)
+	b := styleDiffKML(t, `
+<Style id="road"><LineStyle><color>ff00ff00</color></LineStyle></Style>
+<Style id="same"><LineStyle><width>2</width></LineStyle></Style>
+<Style id="trail"><LineStyle><width>1</width></LineStyle></Style>
+<Folder><Document><StyleMap id="pin"><Pair><key>normal</key><styleUrl>#road</styleUrl></Pair></StyleMap></Document></Folder>`)
+
+	want := `Style "river" removed; Style "road" changed; Style "trail" added`
+	if got := changeStrings(DiffStyles(a, b)); got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+	if changes := DiffStyles(a, a); changes != nil {
+		t.Errorf("Expected no changes, got %v", changes)
+	}
+}
+
+// TestMigrateStyles tests copying, replacing and remapping styles
+func TestMigrateStyles(t *testing.T) {
+	source := styleDiffKML(t, `
+<Style id="road"><LineStyle><color>ff0000ff</color><width>3</width></LineStyle></Style>
+<Style id="road-hl"><LineStyle><color>ff00ffff</color><width>5</width></LineStyle></Style>
+<StyleMap id="road-map">
+  <Pair><key>normal</key><styleUrl>#road</styleUrl></Pair>
+  <Pair><key>highlight</key><styleUrl>#road-hl</styleUrl></Pair>
+</StyleMap>
+<Style id="river"><LineStyle><color>ffff0000</color></LineStyle></Style>
+<Style id="unused"><LineStyle><width>9</width></LineStyle></Style>`)
+	target := styleDiffKML(t, `
+<Style id="river"><LineStyle><color>ffff8888</color></LineStyle></Style>
+<Style id="street"><LineStyle><color>ff0000ff</color><width>3</width></LineStyle></Style>
+<Style id="own"><LineStyle><width>1</width></LineStyle></Style>
+<StyleMap id="mine"><Pair><key>normal</key><styleUrl>#street</styleUrl></Pair></StyleMap>
+<Placemark id="a"><styleUrl>#street</styleUrl></Placemark>
+<Placemark id="b"><styleUrl>#road-map</styleUrl></Placemark>
+<Placemark id="c"><styleUrl>#river</styleUrl></Placemark>
+<Placemark id="d"><styleUrl>#own</styleUrl></Placemark>`)
+
+	want := `Style "river" changed; Style "street" renamed to "road"; Style "road" added; StyleMap "road-map" added; Style "road-hl" added`
+	if got := changeStrings(MigrateStyles(target, source)); got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+
+	urls := map[string]string{"a": "#road", "b": "#road-map", "c": "#river", "d": "#own"}
+	for id, url := range urls {
+		if got := target.FindByID(id).(*Placemark).StyleURL; got != url {
+			t.Errorf("Expected placemark %s to use %s, got %s", id, url, got)
+		}
+	}
+	doc := target.Feature.(*Document)
+	if got := doc.StyleMaps[0].Pairs[0].StyleURL; got != "#road" {
+		t.Errorf("Expected StyleMap pair remapped to #road, got %s", got)
+	}
+	if refs := target.CheckReferences(); refs != nil {
+		t.Errorf("Expected every reference to resolve, got %v", refs)
+	}
+
+	want = `StyleMap "mine" removed; Style "own" removed; Style "unused" added`
+	if got := changeStrings(DiffStyles(target, source)); got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+	if changes := MigrateStyles(target, source); changes != nil {
+		t.Errorf("Expected second migration to change nothing, got %v", changes)
+	}
+}
+
+// TestMigrateStylesWrapsFeature tests migrating into a document without a Document
+func TestMigrateStylesWrapsFeature(t *testing.T) {
+	source := styleDiffKML(t, `<Style id="s"><IconStyle><scale>2</scale></IconStyle></Style>`)
+	target := NewKML()
+	target.Feature = &Placemark{Name: "Lone", StyleURL: "#s"}
+
+	MigrateStyles(target, source)
+	doc, ok := target.Feature.(*Document)
+	if !ok || len(doc.Styles) != 1 || len(doc.Features) != 1 {
+		t.Fatalf("Expected Document holding the style and placemark, got %+v", target.Feature)
+	}
+	if doc.Styles[0].IconStyle.Scale != 2 {
+		t.Errorf("Expected copied style, got %+v", doc.Styles[0])
+	}
+}
+
+// TestMigrateStylesCopies tests that migrated styles do not share substyles with the source
+func TestMigrateStylesCopies(t *testing.T) {
+	source := styleDiffKML(t, `
+<Style id="river"><LineStyle><width>2</width></LineStyle></Style>
+<Style id="pin"><IconStyle><Icon><href>pin.png</href></Icon></IconStyle></Style>`)
+	target := styleDiffKML(t, `
+<Style id="river"><LineStyle><width>1</width></LineStyle></Style>
+<Placemark><styleUrl>#pin</styleUrl></Placemark>`)
+
+	MigrateStyles(target, source)
+	for _, s := range target.Feature.(*Document).Styles {
+		switch s.ID {
+		case "river":
+			s.LineStyle.Width = 8
+		case "pin":
+			s.IconStyle.Icon.Href = "edited.png"
+		}
+	}
+
+	styles := source.Feature.(*Document).Styles
+	if got := styles[0].LineStyle.Width; got != 2 {
+		t.Errorf("Expected the source line width kept, got %v", got)
+	}
+	if got := styles[1].IconStyle.Icon.Href; got != "pin.png" {
+		t.Errorf("Expected the source icon kept, got %s", got)
+	}
+}
@@ -0,0 +1,71 @@
+package kml
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// HashStyleIDs gives generated styles IDs derived from their content, such
+// as "class-3fa2bc" for prefix "class-", and points the styleUrls within
+// features at them, for generators without a HashIDs option:
+//
+//	styles, breaks := kml.StyleByValue(pms, value, ramp, 5)
+//	styles = kml.HashStyleIDs(styles, "class-", doc.Feature)
+//
+// The same style then gets the same ID on every run and in every file, so
+// output is stable and layers can share styles. Styles equal to an earlier
+// one are dropped, and their users pointed at it.
+func HashStyleIDs(styles []Style, prefix string, features ...Feature) []Style {
+	return hashStyleIDs(styles, prefix, nil, features...)
+}
+
+// hashStyleIDs gives generated styles IDs derived from their content, the
+// prefix followed by the start of the SHA-256 of their encoding, and points
+// the styleUrls within features at them. A style equal to an earlier one,
+// or to the shared style of existing with its ID, is dropped in favour of
+// it; a style whose ID another style already holds takes a longer part of
+// the hash. hashStyleIDs returns the styles left, in order, reusing the
+// backing array of styles.
+func hashStyleIDs(styles []Style, prefix string, existing *styleIndex, features ...Feature) []Style {
+	owners := make(map[string]string) // ID to the encoding of its style
+	renamed := make(map[string]string)
+	kept := styles[:0]
+	for _, s := range styles {
+		old := s.ID
+		s.ID = ""
+		content := encodeStyle(s)
+		sum := sha256.Sum256([]byte(content))
+		digest := hex.EncodeToString(sum[:])
+
+		for n := 6; n < len(digest); n += 2 {
+			s.ID = prefix + digest[:n]
+			if owner, ok := owners[s.ID]; ok {
+				if owner == content {
+					break
+				}
+				continue
+			}
+			if existing != nil && existing.has(s.ID) {
+				if other := existing.styles[s.ID]; other != nil && encodeStyle(*other) == encodeStyle(s) {
+					owners[s.ID] = content
+					break
+				}
+				continue
+			}
+			owners[s.ID] = content
+			kept = append(kept, s)
+			break
+		}
+		renamed[old] = s.ID
+	}
+
+	for _, f := range features {
+		walkFeature(f, func(f Feature) error {
+			if p := styleURLField(f); p != nil {
+				remapStyleURL(p, renamed)
+			}
+			return nil
+		})
+	}
+	return kept
+}
@@ -0,0 +1,128 @@
+package kml
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// TestHashStyleIDs tests deriving style IDs from content
+func TestHashStyleIDs(t *testing.T) {
+	red := Style{ID: "s-0", LineStyle: &LineStyle{Color: Red, Width: 2}}
+	blue := Style{ID: "s-1", LineStyle: &LineStyle{Color: Blue, Width: 2}}
+	redAgain := red
+	redAgain.ID = "s-2"
+	folder := &Folder{Features: []Feature{
+		&Placemark{StyleURL: "#s-0"},
+		&Placemark{StyleURL: "#s-1"},
+		&Placemark{StyleURL: "#s-2"},
+		&Placemark{StyleURL: "#other"},
+	}}
+
+	styles := hashStyleIDs([]Style{red, blue, redAgain}, "s-", nil, folder)
+	if len(styles) != 2 {
+		t.Fatalf("Expected the repeated style dropped, got %d styles", len(styles))
+	}
+	id := regexp.MustCompile(`^s-[0-9a-f]{6}$`)
+	for _, s := range styles {
+		if !id.MatchString(s.ID) {
+			t.Errorf("Expected a hashed ID, got %q", s.ID)
+		}
+	}
+	want := []string{"#" + styles[0].ID, "#" + styles[1].ID, "#" + styles[0].ID, "#other"}
+	for i, f := range folder.Features {
+		if got := f.(*Placemark).StyleURL; got != want[i] {
+			t.Errorf("Expected placemark %d to use %s, got %s", i, want[i], got)
+		}
+	}
+
+	again := hashStyleIDs([]Style{blue}, "s-", nil)
+	if again[0].ID != styles[1].ID {
+		t.Errorf("Expected the same ID on every run, got %s and %s", styles[1].ID, again[0].ID)
+	}
+}
+
+// TestHashStyleIDsExisting tests hashed IDs already used in a document
+func TestHashStyleIDsExisting(t *testing.T) {
+	s := Style{ID: "new", PolyStyle: &PolyStyle{Color: Green}}
+	hashed := hashStyleIDs([]Style{s}, "p-", nil)[0]
+
+	same := NewKML()
+	same.Feature = &Document{Styles: []Style{hashed}}
+	if styles := hashStyleIDs([]Style{s}, "p-", newStyleIndex(same)); len(styles) != 0 {
+		t.Errorf("Expected the document's equal style to be reused, got %+v", styles)
+	}
+
+	taken := NewKML()
+	taken.Feature = &Document{Styles: []Style{{ID: hashed.ID}}}
+	styles := hashStyleIDs([]Style{s}, "p-", newStyleIndex(taken))
+	if len(styles) != 1 || len(styles[0].ID) != len(hashed.ID)+2 || styles[0].ID[:len(hashed.ID)] != hashed.ID {
+		t.Errorf("Expected a longer ID than %s, got %+v", hashed.ID, styles)
+	}
+}
+
+// TestHashIDsOptions tests the HashIDs option of the style generators
+func TestHashIDsOptions(t *testing.T) {
+	line := &LineString{Coordinates: []Coordinate{Coord(0, 0), Coord(1, 0), Coord(2, 0), Coord(3, 0)}}
+	points := func() []*Placemark {
+		pms := make([]*Placemark, 4)
+		for i := range pms {
+			pms[i] = &Placemark{
+				Geometry:     &Point{Coordinates: Coord(float64(i), 0)},
+				ExtendedData: &ExtendedData{Data: []Data{{Name: "heading", Value: "45"}}},
+			}
+		}
+		return pms
+	}
+
+	tests := []struct {
+		name     string
+		prefix   string
+		generate func() (Feature, []Style)
+	}{
+		{"ColorizeLine", "colorize-", func() (Feature, []Style) {
+			return ColorizeLine(line, []float64{1, 2, 3, 4}, TwoColorRamp(Blue, Red), ColorizeOptions{Classes: 3, HashIDs: true})
+		}},
+		{"DensityGrid", "density-", func() (Feature, []Style) {
+			return DensityGrid(points(), GridOptions{HashIDs: true})
+		}},
+		{"StyleByHeading", "heading-", func() (Feature, []Style) {
+			pms := points()
+			return &Folder{Features: []Feature{pms[0], pms[1]}}, StyleByHeading(pms, HeadingOptions{HashIDs: true})
+		}},
+		{"HashStyleIDs", "class-", func() (Feature, []Style) {
+			pms := points()
+			folder := &Folder{Features: []Feature{pms[0], pms[1], pms[2], pms[3]}}
+			styles, _ := StyleByValue(pms, func(pm *Placemark) float64 { return pm.Geometry.(*Point).Coordinates.Lon }, TwoColorRamp(Blue, Red), 2)
+			return folder, HashStyleIDs(styles, "class-", folder)
+		}},
+		{"FolderIcons", "folder-icon-", func() (Feature, []Style) {
+			k := folderIconsKML()
+			return k.Feature, k.FolderIcons(FolderIconOptions{HashIDs: true})
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, styles := tt.generate()
+			_, again := tt.generate()
+			if len(styles) == 0 || len(styles) != len(again) {
+				t.Fatalf("Expected the same styles on every run, got %d and %d", len(styles), len(again))
+			}
+			id := regexp.MustCompile(`^` + tt.prefix + `[0-9a-f]{6}$`)
+			ids := make(map[string]bool)
+			for i, s := range styles {
+				if !id.MatchString(s.ID) || s.ID != again[i].ID {
+					t.Errorf("Expected stable hashed ID, got %q and %q", s.ID, again[i].ID)
+				}
+				ids["#"+s.ID] = true
+			}
+			walkFeature(root, func(f Feature) error {
+				if url := styleURLOf(f); strings.HasPrefix(url, "#"+tt.prefix) && !ids[url] {
+					t.Errorf("Expected %s to name a returned style", url)
+				}
+				return nil
+			})
+		})
+	}
+}
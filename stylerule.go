@@ -0,0 +1,62 @@
+package kml
+
+import "regexp"
+
+// StyleRule assigns a style to the placemarks it matches. A placemark
+// matches when every predicate that is set holds; a rule with none set
+// matches every placemark.
+type StyleRule struct {
+	Name     *regexp.Regexp            // Matches the placemark's name
+	Data     map[string]*regexp.Regexp // Match the values of these ExtendedData fields, which must be present
+	Geometry string                    // The geometry's element name, such as "Point" or "Polygon"
+	Match    func(*Placemark) bool     // Any further condition
+
+	StyleURL string // Assigned as the styleUrl, if set
+	Style    *Style // Copied, less its id, as the inline Style, if set
+}
+
+// matches reports whether p satisfies every predicate of r.
+func (r *StyleRule) matches(p *Placemark) bool {
+	if r.Name != nil && !r.Name.MatchString(p.Name) {
+		return false
+	}
+	for field, re := range r.Data {
+		v, ok := p.dataValue(field)
+		if !ok || !re.MatchString(v) {
+			return false
+		}
+	}
+	if r.Geometry != "" && (p.Geometry == nil || p.Geometry.geometryType() != r.Geometry) {
+		return false
+	}
+	return r.Match == nil || r.Match(p)
+}
+
+// ApplyStyleRules styles every placemark with the first of rules it
+// matches, setting its styleUrl, its inline Style or both as the rule
+// gives, and leaves placemarks that match no rule unchanged. Each placemark
+// gets its own copy of a rule's Style, without its id, though nested
+// substyles are shared.
+// It returns the number of placemarks styled.
+func (k *KML) ApplyStyleRules(rules []StyleRule) int {
+	n := 0
+	for _, p := range k.Placemarks() {
+		for i := range rules {
+			r := &rules[i]
+			if !r.matches(p) {
+				continue
+			}
+			if r.StyleURL != "" {
+				p.StyleURL = r.StyleURL
+			}
+			if r.Style != nil {
+				style := *r.Style
+				style.ID = "" // Inline styles with the rule's id would clash
+				p.Style = &style
+			}
+			n++
+			break
+		}
+	}
+	return n
+}
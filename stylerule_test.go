@@ -0,0 +1,54 @@
+package kml
+
+import (
+	"regexp"
+	"testing"
+)
+
+// TestApplyStyleRules tests styling placemarks by the first matching rule
+func TestApplyStyleRules(t *testing.T) {
+	withData := func(p *Placemark, name, value string) *Placemark {
+		p.ExtendedData = &ExtendedData{Data: []Data{{Name: name, Value: value}}}
+		return p
+	}
+	placemarks := []*Placemark{
+		{Name: "Hydrant 12", Geometry: &Point{}},
+		withData(&Placemark{Name: "Main St", Geometry: &LineString{}}, "class", "arterial"),
+		withData(&Placemark{Name: "Elm St", Geometry: &LineString{}}, "class", "residential"),
+		{Name: "Park", Geometry: &Polygon{}},
+		{Name: "Hydrant 13", Geometry: &Polygon{}},
+		{Name: "Unmatched"},
+	}
+	features := make([]Feature, len(placemarks))
+	for i, p := range placemarks {
+		features[i] = p
+	}
+	k := NewKML()
+	k.Feature = &Document{Features: features}
+
+	highlight := &Style{ID: "highlight", LineStyle: &LineStyle{Width: 4}}
+	n := k.ApplyStyleRules([]StyleRule{
+		{Name: regexp.MustCompile(`^Hydrant`), Geometry: "Point", StyleURL: "#hydrant"},
+		{Data: map[string]*regexp.Regexp{"class": regexp.MustCompile(`^arterial$`)}, StyleURL: "#road", Style: highlight},
+		{Geometry: "LineString", StyleURL: "#street"},
+		{Match: func(p *Placemark) bool { return p.Geometry != nil }, StyleURL: "#area"},
+	})
+
+	if n != 5 {
+		t.Errorf("Expected 5 placemarks styled, got %d", n)
+	}
+	want := []string{"#hydrant", "#road", "#street", "#area", "#area", ""}
+	for i, p := range placemarks {
+		if p.StyleURL != want[i] {
+			t.Errorf("%s: expected styleUrl %q, got %q", p.Name, want[i], p.StyleURL)
+		}
+	}
+
+	road := placemarks[1].Style
+	if road == nil || road == highlight || road.ID != "" || road.LineStyle.Width != 4 {
+		t.Errorf("Expected a copy of the rule's style, got %#v", road)
+	}
+	if placemarks[2].Style != nil {
+		t.Error("Expected no inline style from a rule without one")
+	}
+}
@@ -0,0 +1,306 @@
+package kml
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// SuperOverlayOptions configures WriteSuperOverlay.
+type SuperOverlayOptions struct {
+	// Name of the root Document and NetworkLink.
+	Name string
+
+	// TileSize is the width and height of tile images in pixels. It
+	// defaults to 256.
+	TileSize int
+
+	// JPEGQuality, if set, writes JPEG tiles with this quality (1–100)
+	// instead of PNG. JPEG tiles are much smaller for photographic imagery
+	// but cannot be transparent.
+	JPEGQuality int
+
+	// MinLodPixels is the screen size in pixels at which a tile's Region
+	// becomes active. It defaults to half the tile size.
+	MinLodPixels float64
+}
+
+// WriteSuperOverlay slices img, georeferenced by the north-up box, into a
+// pyramid of tiles linked by Regions and NetworkLinks, so Google Earth
+// loads only the tiles needed for the current view. Level 0 is a single
+// tile covering the whole image; each further level doubles the
+// resolution until the tiles reach the full image resolution.
+//
+// If path ends in .kmz, the root doc.kml and all tiles are written to a
+// KMZ archive, atomically as in WriteFile. Otherwise path is a directory,
+// created if needed, receiving doc.kml and a tiles/ tree laid out as
+// tiles/<level>/<x>/<y>.kml with its image alongside.
+func WriteSuperOverlay(path string, img image.Image, box LatLonBox, opts SuperOverlayOptions) error {
+	if box.North <= box.South || box.East <= box.West {
+		return errors.New("kml: super overlay box must have north > south and east > west")
+	}
+	if box.Rotation != 0 {
+		return errors.New("kml: super overlay box must not be rotated")
+	}
+	if img.Bounds().Empty() {
+		return errors.New("kml: super overlay image is empty")
+	}
+	if opts.TileSize <= 0 {
+		opts.TileSize = 256
+	}
+	if opts.MinLodPixels == 0 {
+		opts.MinLodPixels = float64(opts.TileSize) / 2
+	}
+
+	if strings.EqualFold(filepath.Ext(path), ".kmz") {
+		return writeSuperOverlayKMZ(path, img, box, opts)
+	}
+
+	put := func(name string, write func(io.Writer) error) error {
+		full := filepath.Join(path, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			return err
+		}
+		f, err := os.Create(full)
+		if err != nil {
+			return err
+		}
+		if err := write(f); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	}
+	if err := newTiler(img, box, opts, put).write(); err != nil {
+		return fmt.Errorf("kml: error writing super overlay %s: %w", path, err)
+	}
+	return nil
+}
+
+// writeSuperOverlayKMZ writes the pyramid to a temporary archive next to
+// path and renames it into place.
+func writeSuperOverlayKMZ(path string, img image.Image, box LatLonBox, opts SuperOverlayOptions) error {
+	f, err := createTempFile(path)
+	if err != nil {
+		return fmt.Errorf("kml: error creating file: %w", err)
+	}
+	defer os.Remove(f.Name()) // no-op once renamed
+
+	zw := zip.NewWriter(f)
+	put := func(name string, write func(io.Writer) error) error {
+		w, err := zw.Create(name)
+		if err != nil {
+			return err
+		}
+		return write(w)
+	}
+	if err := newTiler(img, box, opts, put).write(); err != nil {
+		f.Close()
+		return fmt.Errorf("kml: error writing super overlay %s: %w", path, err)
+	}
+	if err := zw.Close(); err != nil {
+		f.Close()
+		return fmt.Errorf("kml: error writing super overlay %s: %w", path, err)
+	}
+	return commitTempFile(f, path, nil)
+}
+
+// tiler renders and writes a super overlay tile pyramid.
+type tiler struct {
+	img      image.Image
+	box      LatLonBox
+	opts     SuperOverlayOptions
+	put      func(name string, write func(io.Writer) error) error
+	maxLevel int
+	ext      string
+}
+
+func newTiler(img image.Image, box LatLonBox, opts SuperOverlayOptions, put func(string, func(io.Writer) error) error) *tiler {
+	t := &tiler{img: img, box: box, opts: opts, put: put, ext: ".png"}
+	if opts.JPEGQuality > 0 {
+		t.ext = ".jpg"
+	}
+	size := max(img.Bounds().Dx(), img.Bounds().Dy())
+	for opts.TileSize<<t.maxLevel < size {
+		t.maxLevel++
+	}
+	return t
+}
+
+// write renders every tile, then the root document linking to level 0.
+func (t *tiler) write() error {
+	if _, err := t.render(0, 0, 0); err != nil {
+		return err
+	}
+
+	root := NewKML()
+	root.Feature = &Document{
+		Name: t.opts.Name,
+		Features: []Feature{&NetworkLink{
+			Name:   t.opts.Name,
+			Region: t.region(0, 0, 0),
+			Link:   &Link{Href: "tiles/0/0/0.kml", ViewRefreshMode: ViewRefreshOnRegion},
+		}},
+	}
+	return t.put("doc.kml", func(w io.Writer) error {
+		return root.WriteIndent(w, "", "  ")
+	})
+}
+
+// span returns the number of source pixels covered by one tile edge at
+// the given level.
+func (t *tiler) span(level int) int {
+	return t.opts.TileSize << (t.maxLevel - level)
+}
+
+// exists reports whether tile (x, y) at level covers any of the image.
+func (t *tiler) exists(level, x, y int) bool {
+	s := t.span(level)
+	return x*s < t.img.Bounds().Dx() && y*s < t.img.Bounds().Dy()
+}
+
+// tileBox returns the geographic extent of a tile, clipped to the image.
+func (t *tiler) tileBox(level, x, y int) LatLonBox {
+	s := t.span(level)
+	w, h := t.img.Bounds().Dx(), t.img.Bounds().Dy()
+	x0, y0 := x*s, y*s
+	x1, y1 := min(x0+s, w), min(y0+s, h)
+	lon := func(px int) float64 { return t.box.West + (t.box.East-t.box.West)*float64(px)/float64(w) }
+	lat := func(py int) float64 { return t.box.North - (t.box.North-t.box.South)*float64(py)/float64(h) }
+	return LatLonBox{North: lat(y0), South: lat(y1), East: lon(x1), West: lon(x0)}
+}
+
+func (t *tiler) region(level, x, y int) *Region {
+	b := t.tileBox(level, x, y)
+	return &Region{
+		LatLonAltBox: LatLonAltBox{North: b.North, South: b.South, East: b.East, West: b.West},
+		Lod:          &Lod{MinLodPixels: t.opts.MinLodPixels, MaxLodPixels: -1},
+	}
+}
+
+// render writes tile (x, y) at level and its descendants, returning the
+// tile image. Leaf tiles are cut from the source image; every other tile
+// is its children's images reduced by half.
+func (t *tiler) render(level, x, y int) (image.Image, error) {
+	var tile *image.RGBA
+	var links []Feature
+
+	if level == t.maxLevel {
+		s := t.span(level)
+		b := t.img.Bounds()
+		src := image.Rect(b.Min.X+x*s, b.Min.Y+y*s, min(b.Min.X+(x+1)*s, b.Max.X), min(b.Min.Y+(y+1)*s, b.Max.Y))
+		tile = image.NewRGBA(image.Rect(0, 0, src.Dx(), src.Dy()))
+		draw.Draw(tile, tile.Bounds(), t.img, src.Min, draw.Src)
+	} else {
+		size := t.opts.TileSize
+		var merged *image.RGBA
+		for dy := 0; dy < 2; dy++ {
+			for dx := 0; dx < 2; dx++ {
+				cx, cy := 2*x+dx, 2*y+dy
+				if !t.exists(level+1, cx, cy) {
+					continue
+				}
+				child, err := t.render(level+1, cx, cy)
+				if err != nil {
+					return nil, err
+				}
+				if merged == nil {
+					merged = image.NewRGBA(image.Rect(0, 0, 2*size, 2*size))
+				}
+				at := image.Pt(dx*size, dy*size)
+				draw.Draw(merged, child.Bounds().Add(at), child, image.Point{}, draw.Src)
+				links = append(links, &NetworkLink{
+					Region: t.region(level+1, cx, cy),
+					Link: &Link{
+						Href:            fmt.Sprintf("../../%d/%d/%d.kml", level+1, cx, cy),
+						ViewRefreshMode: ViewRefreshOnRegion,
+					},
+				})
+			}
+		}
+		// Only the part of the merged image covered by children is kept.
+		s := t.span(level + 1)
+		w := min(2*s, t.img.Bounds().Dx()-x*2*s)
+		h := min(2*s, t.img.Bounds().Dy()-y*2*s)
+		scale := s / size
+		tile = halve(merged.SubImage(image.Rect(0, 0, ceilDiv(w, scale), ceilDiv(h, scale))).(*image.RGBA))
+	}
+
+	dir := fmt.Sprintf("tiles/%d/%d", level, x)
+	imgName := fmt.Sprintf("%d%s", y, t.ext)
+	if err := t.put(path.Join(dir, imgName), t.encodeImage(tile)); err != nil {
+		return nil, err
+	}
+
+	tb := t.tileBox(level, x, y)
+	doc := &Document{
+		Name:   fmt.Sprintf("%d/%d/%d", level, x, y),
+		Region: t.region(level, x, y),
+		Features: append([]Feature{&GroundOverlay{
+			DrawOrder: level,
+			Icon:      &Icon{Href: imgName},
+			LatLonBox: &tb,
+		}}, links...),
+	}
+	k := NewKML()
+	k.Feature = doc
+	if err := t.put(path.Join(dir, fmt.Sprintf("%d.kml", y)), func(w io.Writer) error {
+		return k.WriteIndent(w, "", "  ")
+	}); err != nil {
+		return nil, err
+	}
+	return tile, nil
+}
+
+func (t *tiler) encodeImage(img image.Image) func(io.Writer) error {
+	return func(w io.Writer) error {
+		if t.opts.JPEGQuality > 0 {
+			return jpeg.Encode(w, img, &jpeg.Options{Quality: t.opts.JPEGQuality})
+		}
+		return png.Encode(w, img)
+	}
+}
+
+// halve downsamples an image to half its size by averaging 2x2 blocks.
+// Odd trailing rows and columns average the pixels available.
+func halve(src *image.RGBA) *image.RGBA {
+	b := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, ceilDiv(b.Dx(), 2), ceilDiv(b.Dy(), 2)))
+	for y := 0; y < dst.Bounds().Dy(); y++ {
+		for x := 0; x < dst.Bounds().Dx(); x++ {
+			var sum [4]int
+			n := 0
+			for dy := 0; dy < 2; dy++ {
+				for dx := 0; dx < 2; dx++ {
+					sx, sy := b.Min.X+2*x+dx, b.Min.Y+2*y+dy
+					if sx >= b.Max.X || sy >= b.Max.Y {
+						continue
+					}
+					i := src.PixOffset(sx, sy)
+					for c := 0; c < 4; c++ {
+						sum[c] += int(src.Pix[i+c])
+					}
+					n++
+				}
+			}
+			i := dst.PixOffset(x, y)
+			for c := 0; c < 4; c++ {
+				dst.Pix[i+c] = uint8((sum[c] + n/2) / n)
+			}
+		}
+	}
+	return dst
+}
+
+func ceilDiv(a, b int) int {
+	return (a + b - 1) / b
+}
@@ -0,0 +1,233 @@
+package kml
+
+import (
+	"archive/zip"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// gradientImage returns an opaque test image whose colors vary by position.
+func gradientImage(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	return img
+}
+
+var superOverlayBox = LatLonBox{North: 40, South: 37, East: -119, West: -125}
+
+// TestWriteSuperOverlayDirectory tests the pyramid layout, links and tile sizes
+func TestWriteSuperOverlayDirectory(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "out")
+	if err := WriteSuperOverlay(dir, gradientImage(600, 300), superOverlayBox, SuperOverlayOptions{Name: "Ortho"}); err != nil {
+		t.Fatalf("WriteSuperOverlay() error: %v", err)
+	}
+
+	var files []string
+	filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			rel, _ := filepath.Rel(dir, p)
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	sort.Strings(files)
+	// 1 tile at level 0, 2x1 at level 1 and 3x2 at level 2, each with an image and a KML file.
+	if len(files) != 1+2*(1+2+6) {
+		t.Errorf("Expected 19 files, got %d: %v", len(files), files)
+	}
+
+	root, err := ParseFile(filepath.Join(dir, "doc.kml"))
+	if err != nil {
+		t.Fatalf("ParseFile(doc.kml) error: %v", err)
+	}
+	link := root.Feature.(*Document).Features[0].(*NetworkLink)
+	if link.Name != "Ortho" || link.Link.Href != "tiles/0/0/0.kml" || link.Link.ViewRefreshMode != ViewRefreshOnRegion {
+		t.Errorf("Unexpected root link: %+v %+v", link, link.Link)
+	}
+	if link.Region.LatLonAltBox.North != 40 || link.Region.Lod.MinLodPixels != 128 {
+		t.Errorf("Unexpected root region: %+v", link.Region)
+	}
+
+	top, err := ParseFile(filepath.Join(dir, "tiles/0/0/0.kml"))
+	if err != nil {
+		t.Fatalf("ParseFile(0/0/0.kml) error: %v", err)
+	}
+	doc := top.Feature.(*Document)
+	if len(doc.Features) != 3 {
+		t.Fatalf("Expected overlay and 2 child links, got %d features", len(doc.Features))
+	}
+	overlay := doc.Features[0].(*GroundOverlay)
+	if overlay.Icon.Href != "0.png" || *overlay.LatLonBox != superOverlayBox {
+		t.Errorf("Unexpected level 0 overlay: %+v %+v", overlay.Icon, overlay.LatLonBox)
+	}
+	right := doc.Features[2].(*NetworkLink)
+	if right.Link.Href != "../../1/1/0.kml" {
+		t.Errorf("Expected link to tile 1/1/0, got %q", right.Link.Href)
+	}
+	// Level 1 tiles span 512 of the 600 source pixels, so the right tile covers 88.
+	if west := right.Region.LatLonAltBox.West; !floatNear(west, -125+6*512.0/600, 1e-9) {
+		t.Errorf("Unexpected right tile west edge %v", west)
+	}
+
+	sizes := map[string]image.Point{
+		"tiles/0/0/0.png": {150, 75},
+		"tiles/1/1/0.png": {44, 150},
+		"tiles/2/2/1.png": {88, 44},
+		"tiles/2/0/0.png": {256, 256},
+	}
+	for name, want := range sizes {
+		f, err := os.Open(filepath.Join(dir, name))
+		if err != nil {
+			t.Errorf("Expected tile %s: %v", name, err)
+			continue
+		}
+		cfg, err := png.DecodeConfig(f)
+		f.Close()
+		if err != nil || (image.Point{cfg.Width, cfg.Height}) != want {
+			t.Errorf("%s: expected %v, got %dx%d (%v)", name, want, cfg.Width, cfg.Height, err)
+		}
+	}
+
+	leaf, err := ParseFile(filepath.Join(dir, "tiles/2/2/1.kml"))
+	if err != nil {
+		t.Fatalf("ParseFile(leaf) error: %v", err)
+	}
+	if n := len(leaf.Feature.(*Document).Features); n != 1 {
+		t.Errorf("Expected leaf tile without links, got %d features", n)
+	}
+}
+
+// TestWriteSuperOverlayKMZ tests writing the pyramid to a KMZ archive
+func TestWriteSuperOverlayKMZ(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ortho.kmz")
+	opts := SuperOverlayOptions{TileSize: 64, JPEGQuality: 80}
+	if err := WriteSuperOverlay(path, gradientImage(100, 100), superOverlayBox, opts); err != nil {
+		t.Fatalf("WriteSuperOverlay() error: %v", err)
+	}
+
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatalf("Expected a zip archive: %v", err)
+	}
+	defer zr.Close()
+
+	names := make(map[string]bool)
+	for _, f := range zr.File {
+		names[f.Name] = true
+	}
+	for _, want := range []string{"doc.kml", "tiles/0/0/0.kml", "tiles/0/0/0.jpg", "tiles/1/1/1.jpg"} {
+		if !names[want] {
+			t.Errorf("Expected %s in archive, got %v", want, names)
+		}
+	}
+
+	f, err := zr.Open("tiles/1/0/0.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if cfg, err := jpeg.DecodeConfig(f); err != nil || cfg.Width != 64 {
+		t.Errorf("Expected 64px JPEG tile, got %+v (%v)", cfg, err)
+	}
+
+	k, err := ParseFile(path)
+	if err != nil {
+		t.Fatalf("ParseFile(kmz) error: %v", err)
+	}
+	if _, ok := k.Feature.(*Document).Features[0].(*NetworkLink); !ok {
+		t.Error("Expected root document with a NetworkLink")
+	}
+}
+
+// TestWriteSuperOverlayErrors tests argument validation
+func TestWriteSuperOverlayErrors(t *testing.T) {
+	dir := t.TempDir()
+	img := gradientImage(10, 10)
+	tests := []struct {
+		name string
+		img  image.Image
+		box  LatLonBox
+	}{
+		{"inverted box", img, LatLonBox{North: 1, South: 2, East: 1, West: 0}},
+		{"rotated box", img, LatLonBox{North: 1, South: 0, East: 1, West: 0, Rotation: 10}},
+		{"empty image", image.NewRGBA(image.Rect(0, 0, 0, 0)), LatLonBox{North: 1, South: 0, East: 1, West: 0}},
+	}
+	for _, tt := range tests {
+		if err := WriteSuperOverlay(dir, tt.img, tt.box, SuperOverlayOptions{}); err == nil {
+			t.Errorf("%s: expected error", tt.name)
+		}
+	}
+}
+
+// TestHalve tests 2x2 averaging with odd edges
+func TestHalve(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 3, 1))
+	src.Set(0, 0, color.RGBA{R: 10, A: 255})
+	src.Set(1, 0, color.RGBA{R: 20, A: 255})
+	src.Set(2, 0, color.RGBA{R: 90, A: 255})
+
+	dst := halve(src)
+	if dst.Bounds().Dx() != 2 || dst.Bounds().Dy() != 1 {
+		t.Fatalf("Expected 2x1 result, got %v", dst.Bounds())
+	}
+	if r := dst.RGBAAt(0, 0).R; r != 15 {
+		t.Errorf("Expected averaged red 15, got %d", r)
+	}
+	if r := dst.RGBAAt(1, 0).R; r != 90 {
+		t.Errorf("Expected edge pixel kept, got %d", r)
+	}
+}
+
+// TestNetworkLinkRoundTrip tests NetworkLink parsing, writing and traversal
+func TestNetworkLinkRoundTrip(t *testing.T) {
+	input := `<kml xmlns="http://www.opengis.net/kml/2.2"><Folder>
+	<NetworkLink id="live"><name>Feed</name><refreshVisibility>1</refreshVisibility><flyToView>1</flyToView>
+	<Url><href>http://example.com/feed.kml</href><refreshMode>onInterval</refreshMode><refreshInterval>30</refreshInterval></Url>
+	</NetworkLink></Folder></kml>`
+
+	k, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	nl, ok := k.FindByID("live").(*NetworkLink)
+	if !ok {
+		t.Fatalf("Expected NetworkLink, got %T", k.FindByID("live"))
+	}
+	if !nl.RefreshVisibility || !nl.FlyToView || nl.Link.RefreshMode != RefreshOnInterval || nl.Link.RefreshInterval != 30 {
+		t.Errorf("Unexpected NetworkLink: %+v %+v", nl, nl.Link)
+	}
+
+	data, err := k.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes() error: %v", err)
+	}
+	if !strings.Contains(string(data), "<Link><href>http://example.com/feed.kml</href>") {
+		t.Errorf("Expected Url written as Link, got %s", data)
+	}
+	again, err := ParseBytes(data)
+	if err != nil {
+		t.Fatalf("ParseBytes() error: %v", err)
+	}
+	if again.FindByID("live").Hash() != nl.Hash() {
+		t.Error("Expected NetworkLink to round-trip unchanged")
+	}
+
+	if assets := k.Assets(); len(assets) != 1 || assets[0].Kind != AssetLink {
+		t.Errorf("Expected link asset, got %+v", assets)
+	}
+	k.RewriteHrefs(func(s string) string { return strings.Replace(s, "http:", "https:", 1) })
+	if nl.Link.Href != "https://example.com/feed.kml" {
+		t.Errorf("Expected rewritten link href, got %q", nl.Link.Href)
+	}
+}
@@ -0,0 +1,229 @@
+package kml
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ColorRamp maps a position t in [0, 1] to a color.
+type ColorRamp interface {
+	At(t float64) Color
+}
+
+// twoColorRamp interpolates linearly between two colors.
+type twoColorRamp struct {
+	low, high Color
+}
+
+// At implements ColorRamp.
+func (r twoColorRamp) At(t float64) Color {
+	return lerpColor(r.low, r.high, t)
+}
+
+// TwoColorRamp returns a ColorRamp that blends linearly from low to high.
+func TwoColorRamp(low, high Color) ColorRamp {
+	return twoColorRamp{low: low, high: high}
+}
+
+// BreakMethod computes class breaks for a set of values. It returns the
+// upper bound of each class in ascending order; the last break is the
+// maximum value. Values are not modified.
+type BreakMethod func(values []float64, classes int) []float64
+
+// EqualInterval divides the range of the values into classes of equal width.
+func EqualInterval(values []float64, classes int) []float64 {
+	if len(values) == 0 || classes <= 0 {
+		return nil
+	}
+	lo, hi := values[0], values[0]
+	for _, v := range values[1:] {
+		lo = math.Min(lo, v)
+		hi = math.Max(hi, v)
+	}
+	breaks := make([]float64, classes)
+	for i := range breaks {
+		breaks[i] = lo + (hi-lo)*float64(i+1)/float64(classes)
+	}
+	breaks[classes-1] = hi
+	return breaks
+}
+
+// Quantile places an equal number of values in each class.
+func Quantile(values []float64, classes int) []float64 {
+	if len(values) == 0 || classes <= 0 {
+		return nil
+	}
+	sorted := sortedCopy(values)
+	breaks := make([]float64, classes)
+	for i := range breaks {
+		idx := int(math.Ceil(float64((i+1)*len(sorted))/float64(classes))) - 1
+		if idx < 0 {
+			idx = 0
+		}
+		breaks[i] = sorted[idx]
+	}
+	return breaks
+}
+
+// Jenks computes Jenks natural breaks, choosing the classes that minimize the
+// variance of the values within each class. It runs in O(classes·n²) time.
+// When there are fewer distinct values than classes, each distinct value
+// gets its own class.
+func Jenks(values []float64, classes int) []float64 {
+	if len(values) == 0 || classes <= 0 {
+		return nil
+	}
+	data := sortedCopy(values)
+	n := len(data)
+	distinct := 1
+	for i := 1; i < n; i++ {
+		if data[i] != data[i-1] {
+			distinct++
+		}
+	}
+	if classes > distinct {
+		classes = distinct
+	}
+
+	// lower[l][j] is the 1-based index of the first value of the last class
+	// in the best split of data[:l] into j classes; variance holds its cost.
+	lower := make([][]int, n+1)
+	variance := make([][]float64, n+1)
+	for l := range lower {
+		lower[l] = make([]int, classes+1)
+		variance[l] = make([]float64, classes+1)
+		for j := 1; j <= classes; j++ {
+			if l >= 2 {
+				variance[l][j] = math.Inf(1)
+			}
+			lower[l][j] = 1
+		}
+	}
+
+	for l := 2; l <= n; l++ {
+		var sum, sumSq, count float64
+		for m := 1; m <= l; m++ {
+			first := l - m + 1
+			v := data[first-1]
+			sum += v
+			sumSq += v * v
+			count++
+			cost := sumSq - sum*sum/count
+			if prev := first - 1; prev != 0 {
+				// data[:prev] can only be split into at most prev classes.
+				for j := 2; j <= classes && j-1 <= prev; j++ {
+					if c := cost + variance[prev][j-1]; variance[l][j] >= c {
+						lower[l][j] = first
+						variance[l][j] = c
+					}
+				}
+			}
+		}
+		lower[l][1] = 1
+		variance[l][1] = sumSq - sum*sum/count
+	}
+
+	breaks := make([]float64, classes)
+	breaks[classes-1] = data[n-1]
+	end := n
+	for j := classes; j >= 2; j-- {
+		first := lower[end][j]
+		breaks[j-2] = data[first-2]
+		end = first - 1
+	}
+	return breaks
+}
+
+// sortedCopy returns the values sorted in ascending order without modifying
+// the input.
+func sortedCopy(values []float64) []float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	return sorted
+}
+
+// classOf returns the index of the class a value falls into.
+func classOf(breaks []float64, v float64) int {
+	i := sort.SearchFloat64s(breaks, v)
+	if i >= len(breaks) {
+		i = len(breaks) - 1
+	}
+	return i
+}
+
+// NumericData returns a value function for StyleByValue that reads the named
+// ExtendedData field as a number. Placemarks without the field, or whose
+// value does not parse, yield NaN.
+func NumericData(name string) func(*Placemark) float64 {
+	return func(p *Placemark) float64 {
+		s, ok := p.dataValue(name)
+		if !ok {
+			return math.NaN()
+		}
+		v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		if err != nil {
+			return math.NaN()
+		}
+		return v
+	}
+}
+
+// StyleByValue classifies placemarks by a numeric value and points each one
+// at a generated style for its class, producing a choropleth for polygons
+// and graduated symbols for points. The classes are colored along ramp from
+// lowest to highest, and icon scale grows from 0.8 to 1.6 across them.
+//
+// Breaks are computed with method, EqualInterval by default. Placemarks for
+// which value returns NaN are left unchanged. StyleByValue returns the
+// generated styles, with IDs "class-0" to "class-N", which should be added
+// to the Document holding the placemarks, and the upper bound of each class.
+// Pass the styles through HashStyleIDs for IDs that identify their colors
+// across files.
+func StyleByValue(pms []*Placemark, value func(*Placemark) float64, ramp ColorRamp, classes int, method ...BreakMethod) ([]Style, []float64) {
+	if classes <= 0 {
+		classes = 5
+	}
+	breakFn := BreakMethod(EqualInterval)
+	if len(method) > 0 && method[0] != nil {
+		breakFn = method[0]
+	}
+
+	values := make([]float64, len(pms))
+	var valid []float64
+	for i, pm := range pms {
+		values[i] = value(pm)
+		if !math.IsNaN(values[i]) {
+			valid = append(valid, values[i])
+		}
+	}
+	breaks := breakFn(valid, classes)
+	if len(breaks) == 0 {
+		return nil, nil
+	}
+
+	styles := make([]Style, len(breaks))
+	for i := range styles {
+		t := 0.0
+		if len(breaks) > 1 {
+			t = float64(i) / float64(len(breaks)-1)
+		}
+		color := ramp.At(t)
+		styles[i] = Style{
+			ID:        fmt.Sprintf("class-%d", i),
+			IconStyle: &IconStyle{Color: color, Scale: 0.8 + 0.8*t},
+			LineStyle: &LineStyle{Color: color, Width: 2},
+			PolyStyle: &PolyStyle{Color: color},
+		}
+	}
+
+	for i, pm := range pms {
+		if math.IsNaN(values[i]) {
+			continue
+		}
+		pm.StyleURL = "#" + styles[classOf(breaks, values[i])].ID
+	}
+	return styles, breaks
+}
@@ -0,0 +1,151 @@
+package kml
+
+import (
+	"math"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+// TestBreakMethods tests equal-interval, quantile and Jenks class breaks
+func TestBreakMethods(t *testing.T) {
+	values := []float64{1, 2, 3, 10, 11, 12, 30, 31, 32}
+
+	tests := []struct {
+		name   string
+		method BreakMethod
+		want   []float64
+	}{
+		{"equal interval", EqualInterval, []float64{11.333333333333334, 21.666666666666668, 32}},
+		{"quantile", Quantile, []float64{3, 12, 32}},
+		{"jenks", Jenks, []float64{3, 12, 32}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.method(values, 3)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Expected %d breaks, got %v", len(tt.want), got)
+			}
+			for i := range got {
+				if !floatNear(got[i], tt.want[i], 1e-9) {
+					t.Errorf("Expected breaks %v, got %v", tt.want, got)
+					break
+				}
+			}
+		})
+	}
+
+	if got := EqualInterval(nil, 3); got != nil {
+		t.Errorf("Expected nil breaks for no values, got %v", got)
+	}
+}
+
+// TestJenksNaturalBreaks tests that Jenks separates uneven clusters
+func TestJenksNaturalBreaks(t *testing.T) {
+	values := []float64{4, 5, 9, 10, 1, 2, 50, 52, 3}
+
+	got := Jenks(values, 3)
+	want := []float64{5, 10, 52}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected breaks %v, got %v", want, got)
+	}
+	if values[0] != 4 {
+		t.Error("Expected input values to be left unsorted")
+	}
+
+	if got := Jenks([]float64{7, 3}, 4); !reflect.DeepEqual(got, []float64{3, 7}) {
+		t.Errorf("Expected one class per value, got %v", got)
+	}
+}
+
+// TestJenksRepeatedValues tests Jenks with repeated and constant values
+func TestJenksRepeatedValues(t *testing.T) {
+	tests := []struct {
+		values  []float64
+		classes int
+		want    []float64
+	}{
+		{[]float64{1, 1, 1, 1}, 3, []float64{1}},
+		{[]float64{0, 0, 0, 10}, 4, []float64{0, 10}},
+		{[]float64{5, 5, 5}, 3, []float64{5}},
+		{[]float64{3, 3, 3, 3, 3, 9}, 4, []float64{3, 9}},
+		{[]float64{1, 1, 2, 2, 2, 7, 7, 8}, 3, []float64{1, 2, 8}},
+		{[]float64{4, 4, 4, 4, 1, 9, 9}, 2, []float64{4, 9}},
+	}
+
+	for _, tt := range tests {
+		if got := Jenks(tt.values, tt.classes); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Jenks(%v, %d): expected %v, got %v", tt.values, tt.classes, tt.want, got)
+		}
+	}
+}
+
+// TestStyleByValue tests class assignment and generated styles
+func TestStyleByValue(t *testing.T) {
+	var pms []*Placemark
+	for _, v := range []string{"0", "25", "50", "100", "n/a"} {
+		pm := pointPlacemark(v, 0, 0)
+		pm.ExtendedData = &ExtendedData{Data: []Data{{Name: "pop", Value: v}}}
+		pms = append(pms, pm)
+	}
+	pms = append(pms, pointPlacemark("none", 0, 0))
+
+	ramp := TwoColorRamp(RGBA(255, 255, 0, 255), RGBA(255, 0, 0, 255))
+	styles, breaks := StyleByValue(pms, NumericData("pop"), ramp, 4)
+
+	if len(styles) != 4 || len(breaks) != 4 {
+		t.Fatalf("Expected 4 styles and breaks, got %d and %d", len(styles), len(breaks))
+	}
+	if styles[0].PolyStyle.Color != RGBA(255, 255, 0, 255) {
+		t.Errorf("Expected first class to use ramp start, got %s", styles[0].PolyStyle.Color.Hex())
+	}
+	if styles[3].IconStyle.Color != RGBA(255, 0, 0, 255) {
+		t.Errorf("Expected last class to use ramp end, got %s", styles[3].IconStyle.Color.Hex())
+	}
+	if styles[0].IconStyle.Scale >= styles[3].IconStyle.Scale {
+		t.Error("Expected icon scale to grow across classes")
+	}
+
+	want := []string{"#class-0", "#class-0", "#class-1", "#class-3", "", ""}
+	for i, pm := range pms {
+		if pm.StyleURL != want[i] {
+			t.Errorf("Placemark %s: expected styleUrl %q, got %q", pm.Name, want[i], pm.StyleURL)
+		}
+	}
+}
+
+// TestStyleByValueQuantile tests passing a break method
+func TestStyleByValueQuantile(t *testing.T) {
+	var pms []*Placemark
+	for i := 1; i <= 8; i++ {
+		pms = append(pms, pointPlacemark(strconv.Itoa(i*i), 0, 0))
+	}
+	value := func(p *Placemark) float64 {
+		v, _ := strconv.ParseFloat(p.Name, 64)
+		return v
+	}
+
+	_, breaks := StyleByValue(pms, value, TwoColorRamp(White, Black), 2, Quantile)
+	if !reflect.DeepEqual(breaks, []float64{16, 64}) {
+		t.Errorf("Expected quantile breaks [16 64], got %v", breaks)
+	}
+	if pms[3].StyleURL != "#class-0" || pms[4].StyleURL != "#class-1" {
+		t.Errorf("Unexpected classes %q and %q", pms[3].StyleURL, pms[4].StyleURL)
+	}
+
+	if styles, _ := StyleByValue(nil, value, TwoColorRamp(White, Black), 3); styles != nil {
+		t.Errorf("Expected no styles without values, got %d", len(styles))
+	}
+}
+
+// TestNumericData tests reading numeric ExtendedData fields
+func TestNumericData(t *testing.T) {
+	pm := &Placemark{ExtendedData: &ExtendedData{Data: []Data{{Name: "v", Value: " 2.5 "}}}}
+	if got := NumericData("v")(pm); got != 2.5 {
+		t.Errorf("Expected 2.5, got %v", got)
+	}
+	if got := NumericData("missing")(pm); !math.IsNaN(got) {
+		t.Errorf("Expected NaN for missing field, got %v", got)
+	}
+}
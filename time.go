@@ -0,0 +1,144 @@
+package kml
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TimeStamp places a feature at a single moment on the time slider.
+type TimeStamp struct {
+	ID   string
+	When time.Time
+}
+
+// TimeSpan limits a feature to a period on the time slider. A zero Begin or
+// End leaves that side of the period open.
+type TimeSpan struct {
+	ID    string
+	Begin time.Time
+	End   time.Time
+}
+
+// MarshalXML implements custom XML marshaling for TimeStamp.
+func (ts *TimeStamp) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	start.Name.Local = "TimeStamp"
+	if ts.ID != "" {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "id"}, Value: ts.ID})
+	}
+
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	if err := encodeTime(e, "when", ts.When); err != nil {
+		return err
+	}
+	return e.EncodeToken(start.End())
+}
+
+// UnmarshalXML implements custom XML unmarshaling for TimeStamp.
+func (ts *TimeStamp) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	return decodeTimes(d, start, &ts.ID, map[string]*time.Time{"when": &ts.When})
+}
+
+// MarshalXML implements custom XML marshaling for TimeSpan.
+func (ts *TimeSpan) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	start.Name.Local = "TimeSpan"
+	if ts.ID != "" {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "id"}, Value: ts.ID})
+	}
+
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	if err := encodeTime(e, "begin", ts.Begin); err != nil {
+		return err
+	}
+	if err := encodeTime(e, "end", ts.End); err != nil {
+		return err
+	}
+	return e.EncodeToken(start.End())
+}
+
+// UnmarshalXML implements custom XML unmarshaling for TimeSpan.
+func (ts *TimeSpan) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	return decodeTimes(d, start, &ts.ID, map[string]*time.Time{"begin": &ts.Begin, "end": &ts.End})
+}
+
+// encodeTime writes t as the named element, or nothing if t is zero.
+func encodeTime(e *xml.Encoder, name string, t time.Time) error {
+	if t.IsZero() {
+		return nil
+	}
+	return e.EncodeElement(formatDateTime(t), xml.StartElement{Name: xml.Name{Local: name}})
+}
+
+// decodeTimes reads the id attribute and the named time children of a time
+// primitive element.
+func decodeTimes(d *xml.Decoder, start xml.StartElement, id *string, fields map[string]*time.Time) error {
+	for _, attr := range start.Attr {
+		if attr.Name.Local == "id" {
+			*id = attr.Value
+		}
+	}
+
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return err
+		}
+
+		switch el := token.(type) {
+		case xml.StartElement:
+			field, ok := fields[el.Name.Local]
+			if !ok {
+				if err := skipElement(d, el); err != nil {
+					return err
+				}
+				continue
+			}
+			var s string
+			if err := d.DecodeElement(&s, &el); err != nil {
+				return err
+			}
+			t, err := parseDateTime(s)
+			if err != nil {
+				if err := recoverable(d, "invalid "+el.Name.Local, err); err != nil {
+					return err
+				}
+				continue
+			}
+			*field = t
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+// dateTimeLayouts are the forms of the XML Schema dateTime, date, gYearMonth
+// and gYear types that KML allows for time values.
+var dateTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02",
+	"2006-01",
+	"2006",
+}
+
+// parseDateTime parses a KML time value. Values without a time zone are
+// taken as UTC.
+func parseDateTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range dateTimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q", s)
+}
+
+// formatDateTime formats t as an XML Schema dateTime.
+func formatDateTime(t time.Time) string {
+	return t.Format(time.RFC3339Nano)
+}
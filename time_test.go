@@ -0,0 +1,116 @@
+package kml
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestTimePrimitivesParse tests decoding TimeStamp and TimeSpan
+func TestTimePrimitivesParse(t *testing.T) {
+	data := `<kml xmlns="http://www.opengis.net/kml/2.2">
+  <Folder>
+    <TimeSpan id="s"><begin>2020-01</begin><end>2020-02-01T00:00:00Z</end></TimeSpan>
+    <Placemark>
+      <TimeStamp><when>2020-01-15T12:00:00+02:00</when></TimeStamp>
+      <Point><coordinates>1,2</coordinates></Point>
+    </Placemark>
+    <Placemark>
+      <TimeSpan><end>2021</end></TimeSpan>
+    </Placemark>
+  </Folder>
+</kml>`
+
+	k, err := ParseBytes([]byte(data))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	folder := k.Feature.(*Folder)
+	if folder.TimeSpan == nil || folder.TimeSpan.ID != "s" {
+		t.Fatalf("Expected folder TimeSpan with id s, got %+v", folder.TimeSpan)
+	}
+	if want := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC); !folder.TimeSpan.Begin.Equal(want) {
+		t.Errorf("Expected begin %v, got %v", want, folder.TimeSpan.Begin)
+	}
+
+	pm := folder.Features[0].(*Placemark)
+	if want := time.Date(2020, 1, 15, 10, 0, 0, 0, time.UTC); pm.TimeStamp == nil || !pm.TimeStamp.When.Equal(want) {
+		t.Errorf("Expected TimeStamp %v, got %+v", want, pm.TimeStamp)
+	}
+
+	open := folder.Features[1].(*Placemark).TimeSpan
+	if open == nil || !open.Begin.IsZero() || open.End.Year() != 2021 {
+		t.Errorf("Expected open-ended TimeSpan, got %+v", open)
+	}
+}
+
+// TestTimePrimitivesWrite tests encoding and round-tripping time primitives
+func TestTimePrimitivesWrite(t *testing.T) {
+	when := time.Date(2022, 3, 4, 5, 6, 7, 0, time.UTC)
+	k := NewKML()
+	k.Feature = &Folder{
+		TimeSpan: &TimeSpan{Begin: when},
+		Features: []Feature{&Placemark{Name: "a", TimeStamp: &TimeStamp{ID: "t", When: when}}},
+	}
+
+	data, err := k.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes failed: %v", err)
+	}
+	out := string(data)
+	for _, want := range []string{
+		"<TimeSpan><begin>2022-03-04T05:06:07Z</begin></TimeSpan>",
+		`<TimeStamp id="t"><when>2022-03-04T05:06:07Z</when></TimeStamp>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected output to contain %s, got %s", want, out)
+		}
+	}
+
+	parsed, err := ParseBytes(data)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if parsed.Feature.Hash() != k.Feature.Hash() {
+		t.Error("Expected time primitives to round-trip")
+	}
+}
+
+// TestTimeStampInvalid tests that a malformed time fails the parse
+func TestTimeStampInvalid(t *testing.T) {
+	data := `<kml><Placemark><TimeStamp><when>soon</when></TimeStamp></Placemark></kml>`
+	if _, err := ParseBytes([]byte(data)); err == nil {
+		t.Error("Expected error for invalid when")
+	}
+}
+
+// TestParseDateTime tests the KML time forms
+func TestParseDateTime(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"1997", time.Date(1997, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"1997-07", time.Date(1997, 7, 1, 0, 0, 0, 0, time.UTC)},
+		{"1997-07-16", time.Date(1997, 7, 16, 0, 0, 0, 0, time.UTC)},
+		{"1997-07-16T07:30:15Z", time.Date(1997, 7, 16, 7, 30, 15, 0, time.UTC)},
+		{"1997-07-16T10:30:15+03:00", time.Date(1997, 7, 16, 7, 30, 15, 0, time.UTC)},
+		{" 1997-07-16T07:30:15 ", time.Date(1997, 7, 16, 7, 30, 15, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseDateTime(tt.in)
+			if err != nil {
+				t.Fatalf("parseDateTime failed: %v", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	if _, err := parseDateTime("yesterday"); err == nil {
+		t.Error("Expected error for invalid time")
+	}
+}
@@ -0,0 +1,139 @@
+package kml
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// looseTimeLayouts are common timestamp forms found in exported data that
+// are not valid KML time values.
+var looseTimeLayouts = []string{
+	"2006-01-02T15:04:05.999999999Z0700",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"20060102T150405Z0700",
+	"20060102T150405Z",
+	"20060102T150405",
+	"20060102_150405",
+	"2006/01/02 15:04:05",
+	"2006/01/02",
+}
+
+// nameTimePattern matches a timestamp embedded in text, such as a
+// placemark named "IMG_20230512_142301" or "Fix 2023-05-12 14:23".
+var nameTimePattern = regexp.MustCompile(`\d{4}-\d{2}-\d{2}(?:[T ]\d{2}:\d{2}(?::\d{2}(?:\.\d+)?)?(?:Z|[+-]\d{2}:?\d{2})?)?|\d{8}[T_]\d{6}Z?`)
+
+// ParseTime parses a timestamp in any KML time form, a common ISO 8601
+// variant such as "2006-01-02 15:04:05" or "20060102T150405Z", or as Unix
+// time. Unix times are read as seconds, optionally fractional, or as
+// milliseconds from 1e11 upwards, which as seconds would be past the year
+// 5000. Values without a time zone are taken as UTC.
+func ParseTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if t, err := parseDateTime(s); err == nil {
+		return t, nil
+	}
+	for _, layout := range looseTimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+
+	if v, err := strconv.ParseFloat(s, 64); err == nil && !math.IsInf(v, 0) && !math.IsNaN(v) {
+		if math.Abs(v) >= 1e11 {
+			v /= 1000
+		}
+		sec, frac := math.Modf(v)
+		return time.Unix(int64(sec), int64(math.Round(frac*1e9))).UTC(), nil
+	}
+	return time.Time{}, fmt.Errorf("kml: unrecognized time %q", s)
+}
+
+// isTimeFieldName reports whether an ExtendedData field name suggests it
+// holds a timestamp, like "time", "Timestamp", "date_time" or "created_at".
+func isTimeFieldName(name string) bool {
+	lower := strings.ToLower(strings.TrimSpace(name))
+	if strings.HasSuffix(lower, "_at") || strings.HasSuffix(lower, "-at") {
+		return true
+	}
+	compact := strings.NewReplacer("_", "", "-", "", " ", "").Replace(lower)
+	switch compact {
+	case "when", "ts", "utc", "epoch", "unixtime":
+		return true
+	}
+	return strings.HasSuffix(compact, "time") ||
+		strings.HasSuffix(compact, "timestamp") ||
+		strings.HasPrefix(compact, "date") ||
+		strings.HasSuffix(compact, "date")
+}
+
+// DataTime looks for a timestamp in the placemark's ExtendedData and name.
+// If fields are given, only those ExtendedData fields are tried, in order.
+// Otherwise every field with a time-like name, such as "time", "timestamp",
+// "date" or "created_at", is tried in document order, followed by a
+// timestamp embedded in the placemark name. It returns the parsed time and
+// the field it came from, "name" for the placemark name, and reports false
+// when nothing parses.
+func DataTime(p *Placemark, fields ...string) (time.Time, string, bool) {
+	if len(fields) > 0 {
+		for _, name := range fields {
+			if v, ok := p.dataValue(name); ok {
+				if t, err := ParseTime(v); err == nil {
+					return t, name, true
+				}
+			}
+		}
+		return time.Time{}, "", false
+	}
+
+	if ed := p.ExtendedData; ed != nil {
+		for _, d := range ed.Data {
+			if isTimeFieldName(d.Name) {
+				if t, err := ParseTime(d.Value); err == nil {
+					return t, d.Name, true
+				}
+			}
+		}
+		for _, sd := range ed.SchemaData {
+			for _, d := range sd.SimpleData {
+				if isTimeFieldName(d.Name) {
+					if t, err := ParseTime(d.Value); err == nil {
+						return t, d.Name, true
+					}
+				}
+			}
+		}
+	}
+
+	if m := nameTimePattern.FindString(p.Name); m != "" {
+		if t, err := ParseTime(m); err == nil {
+			return t, "name", true
+		}
+	}
+	return time.Time{}, "", false
+}
+
+// InferTimeStamps gives every placemark that has no Time a TimeStamp taken
+// from its ExtendedData or name, as found by DataTime with the given fields,
+// upgrading a legacy point dump into time-aware KML. It returns the number
+// of placemarks stamped.
+func (k *KML) InferTimeStamps(fields ...string) int {
+	stamped := 0
+	for _, pm := range k.Placemarks() {
+		if _, ok := pm.Time(); ok {
+			continue
+		}
+		if t, _, ok := DataTime(pm, fields...); ok {
+			pm.TimeStamp = &TimeStamp{When: t}
+			stamped++
+		}
+	}
+	return stamped
+}
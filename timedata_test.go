@@ -0,0 +1,133 @@
+package kml
+
+import (
+	"testing"
+	"time"
+)
+
+// TestParseTime tests ISO 8601 variants and Unix times
+func TestParseTime(t *testing.T) {
+	want := time.Date(2023, 5, 12, 14, 23, 1, 0, time.UTC)
+
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"2023-05-12T14:23:01Z", want},
+		{"2023-05-12T16:23:01+0200", want},
+		{"2023-05-12 14:23:01", want},
+		{"2023-05-12 16:23:01+02:00", want},
+		{"20230512T142301Z", want},
+		{"20230512_142301", want},
+		{"2023/05/12 14:23:01", want},
+		{"2023-05-12T14:23", want.Add(-time.Second)},
+		{"1683901381", want},
+		{"1683901381000", want},
+		{"1683901381.5", want.Add(500 * time.Millisecond)},
+		{"2023", time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseTime(tt.in)
+			if err != nil {
+				t.Fatalf("ParseTime failed: %v", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	for _, bad := range []string{"", "noon", "12/05/2023"} {
+		if _, err := ParseTime(bad); err == nil {
+			t.Errorf("Expected error for %q", bad)
+		}
+	}
+}
+
+// TestIsTimeFieldName tests recognition of timestamp field names
+func TestIsTimeFieldName(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"time", true},
+		{"Timestamp", true},
+		{"date_time", true},
+		{"created_at", true},
+		{"GPS Time", true},
+		{"DateRecorded", true},
+		{"when", true},
+		{"lat", false},
+		{"format", false},
+		{"name", false},
+	}
+
+	for _, tt := range tests {
+		if got := isTimeFieldName(tt.name); got != tt.want {
+			t.Errorf("isTimeFieldName(%q): expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
+
+// TestDataTime tests locating a time in ExtendedData and names
+func TestDataTime(t *testing.T) {
+	want := time.Date(2023, 5, 12, 14, 23, 1, 0, time.UTC)
+	data := func(pairs ...string) *ExtendedData {
+		ed := &ExtendedData{}
+		for i := 0; i+1 < len(pairs); i += 2 {
+			ed.Data = append(ed.Data, Data{Name: pairs[i], Value: pairs[i+1]})
+		}
+		return ed
+	}
+
+	tests := []struct {
+		name   string
+		pm     *Placemark
+		fields []string
+		field  string
+		ok     bool
+	}{
+		{"time-like field", &Placemark{ExtendedData: data("speed", "3", "timestamp", "1683901381")}, nil, "timestamp", true},
+		{"unparsable field skipped", &Placemark{ExtendedData: data("date", "n/a", "recorded_at", "2023-05-12 14:23:01")}, nil, "recorded_at", true},
+		{"schema data", &Placemark{ExtendedData: &ExtendedData{SchemaData: []SchemaData{{SimpleData: []SimpleData{{Name: "GPS_Time", Value: "20230512T142301Z"}}}}}}, nil, "GPS_Time", true},
+		{"name pattern", &Placemark{Name: "IMG_20230512_142301.jpg"}, nil, "name", true},
+		{"explicit field", &Placemark{ExtendedData: data("t", "1683901381", "time", "2000")}, []string{"t"}, "t", true},
+		{"explicit field missing", &Placemark{Name: "2023-05-12", ExtendedData: data("time", "2000")}, []string{"t"}, "", false},
+		{"nothing", &Placemark{Name: "Gate A"}, nil, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, field, ok := DataTime(tt.pm, tt.fields...)
+			if ok != tt.ok || field != tt.field {
+				t.Fatalf("Expected (%q, %v), got (%q, %v)", tt.field, tt.ok, field, ok)
+			}
+			if ok && !got.Equal(want) {
+				t.Errorf("Expected %v, got %v", want, got)
+			}
+		})
+	}
+}
+
+// TestInferTimeStamps tests stamping placemarks from their data
+func TestInferTimeStamps(t *testing.T) {
+	existing := time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)
+	timed := &Placemark{Name: "2020-01-01", TimeStamp: &TimeStamp{When: existing}}
+	fromData := &Placemark{ExtendedData: &ExtendedData{Data: []Data{{Name: "time", Value: "2023-05-12T14:23:01Z"}}}}
+	untimed := &Placemark{Name: "Gate A"}
+
+	k := NewKML()
+	k.Feature = &Document{Features: []Feature{timed, fromData, untimed}}
+
+	if n := k.InferTimeStamps(); n != 1 {
+		t.Errorf("Expected 1 placemark stamped, got %d", n)
+	}
+	if fromData.TimeStamp == nil || fromData.TimeStamp.When.Year() != 2023 {
+		t.Errorf("Expected TimeStamp from data, got %+v", fromData.TimeStamp)
+	}
+	if !timed.TimeStamp.When.Equal(existing) || untimed.TimeStamp != nil {
+		t.Error("Expected other placemarks to be unchanged")
+	}
+}
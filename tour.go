@@ -0,0 +1,355 @@
+package kml
+
+import (
+	"encoding/xml"
+	"strconv"
+	"time"
+)
+
+// Modes of a FlyTo.
+const (
+	FlyToBounce = "bounce" // Zoom out and back in, as when jumping to a view
+	FlyToSmooth = "smooth" // Move continuously through the neighboring views
+)
+
+// Tour is a gx:Tour: a playlist of camera moves the viewer plays back as a
+// flight. It implements the Feature interface. Tours are gx extensions and
+// are left out when writing a version before 2.2; see TargetVersion.
+type Tour struct {
+	ID          string
+	Name        string
+	Description string
+	Playlist    []TourPrimitive
+	Comments    []string // See PreserveComments
+
+	span *Span // See RecordSpans
+}
+
+// TourPrimitive is a step of a Tour's playlist: a *FlyTo or a *Wait.
+type TourPrimitive interface {
+	tourPrimitive() string
+}
+
+// FlyTo moves the view to a Camera or LookAt over Duration seconds. When,
+// if set, is the time the time slider shows on arrival, so a tour can play
+// back the features of a time-aware document as it flies.
+type FlyTo struct {
+	Duration float64
+	Mode     string // FlyToBounce or FlyToSmooth; empty means bounce
+	Camera   *Camera
+	LookAt   *LookAt
+	When     time.Time
+}
+
+// Wait pauses the tour for Duration seconds.
+type Wait struct {
+	Duration float64
+}
+
+func (f *FlyTo) tourPrimitive() string {
+	return "FlyTo"
+}
+
+func (w *Wait) tourPrimitive() string {
+	return "Wait"
+}
+
+// featureType implements the Feature interface.
+func (t *Tour) featureType() string {
+	return "Tour"
+}
+
+// Hash implements the Feature interface.
+func (t *Tour) Hash() string {
+	return hashFeature(t)
+}
+
+// Duration returns the time the tour takes to play.
+func (t *Tour) Duration() time.Duration {
+	var secs float64
+	for _, p := range t.Playlist {
+		switch p := p.(type) {
+		case *FlyTo:
+			secs += p.Duration
+		case *Wait:
+			secs += p.Duration
+		}
+	}
+	return time.Duration(secs * float64(time.Second))
+}
+
+// tourCamera and tourLookAt are the views of a FlyTo, with the
+// gx:TimeStamp that is written inside the view element.
+type tourCamera struct {
+	TimeStamp *tourTime
+	*Camera
+}
+
+type tourLookAt struct {
+	TimeStamp *tourTime
+	*LookAt
+}
+
+type tourTime struct {
+	XMLName xml.Name
+	When    string `xml:"when"`
+}
+
+// MarshalXML implements custom XML marshaling for Tour. The element is
+// written as gx:Tour with the gx prefix declared on it, and not at all for
+// a TargetVersion before KML 2.2, which VersionRule reports.
+func (t *Tour) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if targetVersion(e).preGx() {
+		return nil
+	}
+
+	start = gxElement(e, "Tour")
+	if t.ID != "" {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "id"}, Value: t.ID})
+	}
+
+	if err := encodeComments(e, t.Comments); err != nil {
+		return err
+	}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+
+	if t.Name != "" {
+		if err := e.EncodeElement(t.Name, xml.StartElement{Name: xml.Name{Local: "name"}}); err != nil {
+			return err
+		}
+	}
+
+	if t.Description != "" {
+		if err := encodeDescription(e, t.Description); err != nil {
+			return err
+		}
+	}
+
+	playlist := gxElement(e, "Playlist")
+	playlist.Attr = nil // Declared on the tour
+	if err := e.EncodeToken(playlist); err != nil {
+		return err
+	}
+	for _, p := range t.Playlist {
+		if err := encodeTourPrimitive(e, p); err != nil {
+			return err
+		}
+	}
+	if err := e.EncodeToken(playlist.End()); err != nil {
+		return err
+	}
+
+	if err := e.EncodeToken(start.End()); err != nil {
+		return err
+	}
+	featureWritten(e)
+	return nil
+}
+
+// encodeTourPrimitive writes a playlist step of a tour.
+func encodeTourPrimitive(e *xml.Encoder, p TourPrimitive) error {
+	gx := func(local string) xml.StartElement {
+		el := gxElement(e, local)
+		el.Attr = nil // Declared on the tour
+		return el
+	}
+	start := gx(p.tourPrimitive())
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+
+	switch p := p.(type) {
+	case *FlyTo:
+		if err := e.EncodeElement(formatDuration(p.Duration), gx("duration")); err != nil {
+			return err
+		}
+		if p.Mode != "" {
+			if err := e.EncodeElement(p.Mode, gx("flyToMode")); err != nil {
+				return err
+			}
+		}
+		var when *tourTime
+		if !p.When.IsZero() {
+			when = &tourTime{XMLName: gx("TimeStamp").Name, When: formatDateTime(p.When)}
+		}
+		switch {
+		case p.Camera != nil:
+			if err := e.EncodeElement(tourCamera{when, p.Camera}, xml.StartElement{Name: xml.Name{Local: "Camera"}}); err != nil {
+				return err
+			}
+		case p.LookAt != nil:
+			if err := e.EncodeElement(tourLookAt{when, p.LookAt}, xml.StartElement{Name: xml.Name{Local: "LookAt"}}); err != nil {
+				return err
+			}
+		}
+	case *Wait:
+		if err := e.EncodeElement(formatDuration(p.Duration), gx("duration")); err != nil {
+			return err
+		}
+	}
+
+	return e.EncodeToken(start.End())
+}
+
+// formatDuration formats a gx:duration in seconds.
+func formatDuration(secs float64) string {
+	return strconv.FormatFloat(secs, 'f', -1, 64)
+}
+
+// UnmarshalXML implements custom XML unmarshaling for Tour. Playlist steps
+// other than gx:FlyTo and gx:Wait, such as gx:AnimatedUpdate and
+// gx:SoundCue, are skipped.
+func (t *Tour) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	t.Comments = takeComments(d)
+	beginSpan(d)
+
+	for _, attr := range start.Attr {
+		if attr.Name.Local == "id" {
+			t.ID = attr.Value
+		}
+	}
+
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return err
+		}
+
+		switch el := token.(type) {
+		case xml.StartElement:
+			switch el.Name.Local {
+			case "name":
+				if err := d.DecodeElement(&t.Name, &el); err != nil {
+					return err
+				}
+			case "description":
+				if err := d.DecodeElement(&t.Description, &el); err != nil {
+					return err
+				}
+			case "Playlist":
+				if err := t.decodePlaylist(d); err != nil {
+					return err
+				}
+			default:
+				if err := skipElement(d, el); err != nil {
+					return err
+				}
+			}
+		case xml.EndElement:
+			featureParsed(d, t)
+			return nil
+		}
+	}
+}
+
+// decodePlaylist reads the steps of a gx:Playlist whose start tag was just
+// read from d.
+func (t *Tour) decodePlaylist(d *xml.Decoder) error {
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return err
+		}
+
+		switch el := token.(type) {
+		case xml.StartElement:
+			switch el.Name.Local {
+			case "FlyTo":
+				var f FlyTo
+				if err := f.decode(d); err != nil {
+					return err
+				}
+				t.Playlist = append(t.Playlist, &f)
+			case "Wait":
+				var w Wait
+				if err := decodeChildren(d, func(el xml.StartElement) error {
+					if el.Name.Local == "duration" {
+						return decodeDuration(d, el, &w.Duration)
+					}
+					return skipElement(d, el)
+				}); err != nil {
+					return err
+				}
+				t.Playlist = append(t.Playlist, &w)
+			default:
+				if err := skipElement(d, el); err != nil {
+					return err
+				}
+			}
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+// decode reads the children of a gx:FlyTo whose start tag was just read
+// from d.
+func (f *FlyTo) decode(d *xml.Decoder) error {
+	return decodeChildren(d, func(el xml.StartElement) error {
+		var when *tourTime
+		switch el.Name.Local {
+		case "duration":
+			return decodeDuration(d, el, &f.Duration)
+		case "flyToMode":
+			return d.DecodeElement(&f.Mode, &el)
+		case "Camera":
+			view := tourCamera{Camera: &Camera{}}
+			if err := d.DecodeElement(&view, &el); err != nil {
+				return err
+			}
+			f.Camera, when = view.Camera, view.TimeStamp
+		case "LookAt":
+			view := tourLookAt{LookAt: &LookAt{}}
+			if err := d.DecodeElement(&view, &el); err != nil {
+				return err
+			}
+			f.LookAt, when = view.LookAt, view.TimeStamp
+		default:
+			return skipElement(d, el)
+		}
+		if when != nil {
+			t, err := parseDateTime(when.When)
+			if err != nil {
+				return recoverable(d, "invalid gx:TimeStamp", err)
+			}
+			f.When = t
+		}
+		return nil
+	})
+}
+
+// decodeChildren calls child with each child element of the element whose
+// start tag was just read from d, until its end tag.
+func decodeChildren(d *xml.Decoder, child func(xml.StartElement) error) error {
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return err
+		}
+
+		switch el := token.(type) {
+		case xml.StartElement:
+			if err := child(el); err != nil {
+				return err
+			}
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+// decodeDuration reads a gx:duration element into secs.
+func decodeDuration(d *xml.Decoder, el xml.StartElement, secs *float64) error {
+	var s string
+	if err := d.DecodeElement(&s, &el); err != nil {
+		return err
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return recoverable(d, "invalid gx:duration", err)
+	}
+	*secs = v
+	return nil
+}
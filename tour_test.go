@@ -0,0 +1,164 @@
+package kml
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// tourDocument returns a document holding a placemark and a tour
+func tourDocument() *KML {
+	when := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	k := NewKML()
+	k.Feature = &Document{
+		Name: "Flights",
+		Features: []Feature{
+			&Placemark{Name: "Start", Geometry: &Point{Coordinates: Coordinate{Lon: 1, Lat: 2}}},
+			&Tour{
+				ID:          "t1",
+				Name:        "Flight",
+				Description: "Over the start",
+				Playlist: []TourPrimitive{
+					&FlyTo{Duration: 3, Mode: FlyToBounce, Camera: &Camera{Longitude: 1, Latitude: 1.99, Altitude: 250, Heading: 10, Tilt: 60}, When: when},
+					&Wait{Duration: 1.5},
+					&FlyTo{Duration: 2, Mode: FlyToSmooth, LookAt: &LookAt{Longitude: 1, Latitude: 2, Range: 300}},
+				},
+			},
+		},
+	}
+	return k
+}
+
+// TestTourMarshal tests the gx elements written for a tour
+func TestTourMarshal(t *testing.T) {
+	out, err := tourDocument().Bytes()
+	if err != nil {
+		t.Fatalf("Bytes failed: %v", err)
+	}
+	s := string(out)
+	for _, want := range []string{
+		`<gx:Tour xmlns:gx="http://www.google.com/kml/ext/2.2" id="t1"><name>Flight</name>`,
+		`<gx:Playlist><gx:FlyTo><gx:duration>3</gx:duration><gx:flyToMode>bounce</gx:flyToMode><Camera><gx:TimeStamp><when>2024-05-01T12:00:00Z</when></gx:TimeStamp><longitude>1</longitude>`,
+		`<gx:Wait><gx:duration>1.5</gx:duration></gx:Wait>`,
+		`<LookAt><longitude>1</longitude><latitude>2</latitude><range>300</range></LookAt></gx:FlyTo></gx:Playlist></gx:Tour>`,
+	} {
+		if !strings.Contains(s, want) {
+			t.Errorf("Expected output to contain %s, got:\n%s", want, s)
+		}
+	}
+}
+
+// TestTourRoundTrip tests that a written tour parses back unchanged
+func TestTourRoundTrip(t *testing.T) {
+	k := tourDocument()
+	out, err := k.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes failed: %v", err)
+	}
+	back, err := ParseBytes(out)
+	if err != nil {
+		t.Fatalf("ParseBytes failed: %v", err)
+	}
+	got, ok := back.FindByID("t1").(*Tour)
+	if !ok {
+		t.Fatalf("Expected *Tour with ID t1, got %T", back.FindByID("t1"))
+	}
+	want := k.Feature.(*Document).Features[1].(*Tour)
+	if !reflect.DeepEqual(got.Playlist, want.Playlist) {
+		t.Errorf("Expected playlist %+v, got %+v", want.Playlist, got.Playlist)
+	}
+	if got.Name != want.Name || got.Description != want.Description {
+		t.Errorf("Expected name %q and description %q, got %q and %q", want.Name, want.Description, got.Name, got.Description)
+	}
+	if d := got.Duration(); d != 6500*time.Millisecond {
+		t.Errorf("Expected duration 6.5s, got %v", d)
+	}
+}
+
+// TestTourUnmarshal tests reading a tour written by another tool
+func TestTourUnmarshal(t *testing.T) {
+	input := `<kml xmlns="http://www.opengis.net/kml/2.2" xmlns:gx="http://www.google.com/kml/ext/2.2">
+<gx:Tour>
+  <name>Tour</name>
+  <gx:Playlist>
+    <gx:SoundCue><href>music.mp3</href></gx:SoundCue>
+    <gx:FlyTo>
+      <gx:duration>5.0</gx:duration>
+      <Camera><longitude>3</longitude><latitude>4</latitude><altitude>100</altitude></Camera>
+    </gx:FlyTo>
+    <gx:AnimatedUpdate><gx:duration>1</gx:duration></gx:AnimatedUpdate>
+    <gx:Wait><gx:duration>2</gx:duration></gx:Wait>
+  </gx:Playlist>
+</gx:Tour>
+</kml>`
+	k, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	tour, ok := k.Feature.(*Tour)
+	if !ok {
+		t.Fatalf("Expected *Tour, got %T", k.Feature)
+	}
+	if len(tour.Playlist) != 2 {
+		t.Fatalf("Expected 2 playlist steps, got %d", len(tour.Playlist))
+	}
+	fly, ok := tour.Playlist[0].(*FlyTo)
+	if !ok || fly.Duration != 5 || fly.Mode != "" || fly.Camera == nil || fly.Camera.Altitude != 100 || !fly.When.IsZero() {
+		t.Errorf("Expected 5s FlyTo to camera at 100m, got %+v", tour.Playlist[0])
+	}
+	if wait, ok := tour.Playlist[1].(*Wait); !ok || wait.Duration != 2 {
+		t.Errorf("Expected 2s Wait, got %+v", tour.Playlist[1])
+	}
+}
+
+// TestTourTargetVersion tests that tours are left out before KML 2.2
+func TestTourTargetVersion(t *testing.T) {
+	out, err := tourDocument().Bytes(TargetVersion(Version21))
+	if err != nil {
+		t.Fatalf("Bytes failed: %v", err)
+	}
+	if bytes.Contains(out, []byte("Tour")) || bytes.Contains(out, []byte("gx:")) {
+		t.Errorf("Expected no tour in KML 2.1 output, got:\n%s", out)
+	}
+	if !bytes.Contains(out, []byte("<name>Start</name>")) {
+		t.Errorf("Expected the placemark to be kept, got:\n%s", out)
+	}
+}
+
+// TestTourVisitor tests that Accept calls VisitTour
+func TestTourVisitor(t *testing.T) {
+	r := &recordingVisitor{}
+	if err := tourDocument().Accept(r); err != nil {
+		t.Fatalf("Accept failed: %v", err)
+	}
+	want := []string{"Document:Flights", "Placemark:Start", "Point:", "Tour:Flight"}
+	if !reflect.DeepEqual(r.visited, want) {
+		t.Errorf("Expected %v, got %v", want, r.visited)
+	}
+}
+
+// TestTourJSON tests that tours survive a JSON round trip
+func TestTourJSON(t *testing.T) {
+	k := tourDocument()
+	data, err := json.Marshal(k)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	var back KML
+	if err := json.Unmarshal(data, &back); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	want, _ := k.Bytes()
+	got, _ := back.Bytes()
+	if !bytes.Equal(got, want) {
+		t.Errorf("Expected KML after JSON round trip:\n%s\ngot:\n%s", want, got)
+	}
+
+	bad := `{"Feature":{"type":"Tour","Playlist":[{"type":"SoundCue"}]}}`
+	if err := json.Unmarshal([]byte(bad), &back); err == nil || !strings.Contains(err.Error(), "SoundCue") {
+		t.Errorf("Expected unknown primitive error, got %v", err)
+	}
+}
@@ -0,0 +1,197 @@
+package kml
+
+import (
+	"encoding/xml"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Track is a gx:Track: a path whose points each carry the time at which it
+// was recorded, as logged by GPS receivers. When and Coords are parallel;
+// When may be empty for an untimed track.
+type Track struct {
+	ID           string
+	AltitudeMode AltitudeMode
+	When         []time.Time
+	Coords       []Coordinate
+}
+
+func (t *Track) geometryType() string {
+	return "Track"
+}
+
+// MarshalXML implements custom XML marshaling for Track. The element is
+// written as gx:Track with the gx prefix declared on it, followed by all
+// when elements and then all gx:coord elements. See TargetVersion for how
+// other versions write it.
+func (t *Track) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if targetVersion(e).preGx() {
+		return e.Encode(&LineString{ID: t.ID, AltitudeMode: t.AltitudeMode, Coordinates: t.Coords})
+	}
+
+	start = gxElement(e, "Track")
+	if t.ID != "" {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "id"}, Value: t.ID})
+	}
+
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+
+	if t.AltitudeMode != "" {
+		if err := encodeAltitudeMode(e, t.AltitudeMode); err != nil {
+			return err
+		}
+	}
+
+	for _, when := range t.When {
+		if err := e.EncodeElement(formatDateTime(when), xml.StartElement{Name: xml.Name{Local: "when"}}); err != nil {
+			return err
+		}
+	}
+
+	coord := gxElement(e, "coord")
+	coord.Attr = nil // Declared on the track
+	format := strconvFloat
+	if cfg := writeConfigFor(e); cfg != nil && cfg.format != nil {
+		format = cfg.format
+	}
+	for _, c := range t.Coords {
+		if err := e.EncodeElement(formatTrackCoord(c, format), coord); err != nil {
+			return err
+		}
+	}
+
+	return e.EncodeToken(start.End())
+}
+
+// UnmarshalXML implements custom XML unmarshaling for Track.
+func (t *Track) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for _, attr := range start.Attr {
+		if attr.Name.Local == "id" {
+			t.ID = attr.Value
+		}
+	}
+
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return err
+		}
+
+		switch el := token.(type) {
+		case xml.StartElement:
+			switch el.Name.Local {
+			case "altitudeMode":
+				var mode string
+				if err := d.DecodeElement(&mode, &el); err != nil {
+					return err
+				}
+				t.AltitudeMode = AltitudeMode(mode)
+			case "when":
+				var s string
+				if err := d.DecodeElement(&s, &el); err != nil {
+					return err
+				}
+				when, err := parseDateTime(s)
+				if err != nil {
+					if err := recoverable(d, "invalid when", err); err != nil {
+						return err
+					}
+				}
+				t.When = append(t.When, when)
+			case "coord":
+				var s string
+				if err := d.DecodeElement(&s, &el); err != nil {
+					return err
+				}
+				c, err := parseTrackCoord(s)
+				if err != nil {
+					if err := recoverable(d, "invalid gx:coord", err); err != nil {
+						return err
+					}
+					continue
+				}
+				t.Coords = append(t.Coords, c)
+			default:
+				if err := skipElement(d, el); err != nil {
+					return err
+				}
+			}
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+// ToGeoJSON converts a Track to a GeoJSON LineString. Timestamps are dropped.
+func (t *Track) ToGeoJSON() GeoJSONGeometry {
+	return GeoJSONGeometry{
+		Type:        "LineString",
+		Coordinates: coordsToGeoJSON(t.Coords),
+	}
+}
+
+// LineString returns the path of the track without its timestamps.
+func (t *Track) LineString() *LineString {
+	return &LineString{
+		AltitudeMode: t.AltitudeMode,
+		Coordinates:  append([]Coordinate(nil), t.Coords...),
+	}
+}
+
+// Speeds returns the speed at each point of the track in meters per second,
+// averaged over the segments either side of it. Points whose neighbors are
+// not timed, or recorded at the same instant, have a NaN speed.
+func (t *Track) Speeds() []float64 {
+	speeds := make([]float64, len(t.Coords))
+	segment := func(i int) (dist, secs float64) {
+		if i < 0 || i+1 >= len(t.Coords) || i+1 >= len(t.When) {
+			return 0, 0
+		}
+		return t.Coords[i].DistanceTo(t.Coords[i+1]), t.When[i+1].Sub(t.When[i]).Seconds()
+	}
+	for i := range speeds {
+		d1, s1 := segment(i - 1)
+		d2, s2 := segment(i)
+		if s1+s2 <= 0 {
+			speeds[i] = math.NaN()
+			continue
+		}
+		speeds[i] = (d1 + d2) / (s1 + s2)
+	}
+	return speeds
+}
+
+// parseTrackCoord parses a gx:coord value, "lon lat [alt]" separated by
+// spaces.
+func parseTrackCoord(s string) (Coordinate, error) {
+	fields := strings.Fields(s)
+	if len(fields) < 2 || len(fields) > 3 {
+		return Coordinate{}, fmt.Errorf("invalid gx:coord %q", s)
+	}
+	var vals [3]float64
+	for i, f := range fields {
+		v, err := strconv.ParseFloat(f, 64)
+		if err != nil {
+			return Coordinate{}, fmt.Errorf("invalid gx:coord %q: %w", s, err)
+		}
+		vals[i] = v
+	}
+	return Coordinate{Lon: vals[0], Lat: vals[1], Alt: vals[2]}, nil
+}
+
+// formatTrackCoord formats c as a gx:coord value, writing each value with
+// format.
+func formatTrackCoord(c Coordinate, format func(float64) string) string {
+	return format(c.Lon) + " " + format(c.Lat) + " " + format(c.Alt)
+}
+
+// strconvFloat formats v in decimal notation with the fewest digits that
+// represent it exactly.
+func strconvFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,111 @@
+package kml
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+const trackKML = `<?xml version="1.0" encoding="UTF-8"?>
+<kml xmlns="http://www.opengis.net/kml/2.2" xmlns:gx="http://www.google.com/kml/ext/2.2">
+  <Placemark>
+    <name>Run</name>
+    <gx:Track id="t1">
+      <altitudeMode>clampToGround</altitudeMode>
+      <when>2010-05-28T02:02:09Z</when>
+      <when>2010-05-28T02:02:35Z</when>
+      <when>2010-05-28T02:02:44Z</when>
+      <gx:coord>-122.207881 37.371915 156.0</gx:coord>
+      <gx:coord>-122.205712 37.373288 152.0</gx:coord>
+      <gx:coord>-122.204678 37.373939 147.0</gx:coord>
+    </gx:Track>
+  </Placemark>
+</kml>`
+
+// TestTrackParse tests decoding a gx:Track
+func TestTrackParse(t *testing.T) {
+	k, err := ParseBytes([]byte(trackKML))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	track, ok := k.Feature.(*Placemark).Geometry.(*Track)
+	if !ok {
+		t.Fatalf("Expected *Track geometry, got %T", k.Feature.(*Placemark).Geometry)
+	}
+	if track.ID != "t1" || track.AltitudeMode != AltitudeModeClampToGround {
+		t.Errorf("Unexpected id %q or altitude mode %q", track.ID, track.AltitudeMode)
+	}
+	if len(track.When) != 3 || len(track.Coords) != 3 {
+		t.Fatalf("Expected 3 times and coords, got %d and %d", len(track.When), len(track.Coords))
+	}
+	if want := time.Date(2010, 5, 28, 2, 2, 35, 0, time.UTC); !track.When[1].Equal(want) {
+		t.Errorf("Expected %v, got %v", want, track.When[1])
+	}
+	if want := (Coordinate{Lon: -122.205712, Lat: 37.373288, Alt: 152}); track.Coords[1] != want {
+		t.Errorf("Expected %v, got %v", want, track.Coords[1])
+	}
+
+	sw, ne := k.Bounds()
+	if sw.Lon != -122.207881 || ne.Lat != 37.373939 {
+		t.Errorf("Unexpected bounds %v %v", sw, ne)
+	}
+}
+
+// TestTrackRoundTrip tests that a Track survives write and parse
+func TestTrackRoundTrip(t *testing.T) {
+	start := time.Date(2024, 1, 2, 3, 4, 5, 500000000, time.UTC)
+	k := NewKML()
+	k.Feature = &Placemark{Geometry: &Track{
+		When:   []time.Time{start, start.Add(time.Minute)},
+		Coords: []Coordinate{Coord(1, 2), Coord(3, 4, 5)},
+	}}
+
+	data, err := k.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes failed: %v", err)
+	}
+	out := string(data)
+	for _, want := range []string{`<gx:Track xmlns:gx="http://www.google.com/kml/ext/2.2">`, "<when>2024-01-02T03:04:05.5Z</when>", "<gx:coord>3 4 5</gx:coord>"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected output to contain %s, got %s", want, out)
+		}
+	}
+
+	parsed, err := ParseBytes(data)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	track := parsed.Feature.(*Placemark).Geometry.(*Track)
+	if !track.When[0].Equal(start) || track.Coords[1] != Coord(3, 4, 5) {
+		t.Errorf("Track did not round-trip: %+v", track)
+	}
+}
+
+// TestTrackSpeeds tests per-point speeds from timed coordinates
+func TestTrackSpeeds(t *testing.T) {
+	a := Coord(0, 0)
+	b := a.Destination(90, 100)
+	c := b.Destination(90, 300)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	track := &Track{
+		When:   []time.Time{start, start.Add(10 * time.Second), start.Add(20 * time.Second)},
+		Coords: []Coordinate{a, b, c},
+	}
+
+	speeds := track.Speeds()
+	want := []float64{10, 20, 30}
+	for i := range want {
+		if !floatNear(speeds[i], want[i], 1e-6) {
+			t.Errorf("Point %d: expected %.1f m/s, got %f", i, want[i], speeds[i])
+		}
+	}
+
+	untimed := &Track{Coords: []Coordinate{a, b}}
+	for _, s := range untimed.Speeds() {
+		if !math.IsNaN(s) {
+			t.Errorf("Expected NaN speed for untimed track, got %f", s)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package kml
+
+// TransformGeometries replaces the geometry of every Placemark with the
+// result of fn, which may return its argument unchanged, a new geometry or
+// nil to remove it. The parts of a MultiGeometry are transformed first and
+// then the MultiGeometry itself, so fn sees it with its new parts; parts
+// replaced with nil are dropped from it. It returns the number of
+// geometries fn replaced or removed.
+//
+// This turns passes such as buffering every Point into a Circle into a
+// single call:
+//
+//	k.TransformGeometries(func(g kml.Geometry) kml.Geometry {
+//		if p, ok := g.(*kml.Point); ok {
+//			return kml.Circle(p.Coordinates, 50, 32)
+//		}
+//		return g
+//	})
+func (k *KML) TransformGeometries(fn func(g Geometry) Geometry) int {
+	k.ResetBounds()
+	n := 0
+	k.Walk(func(f Feature) error {
+		if pm, ok := f.(*Placemark); ok && pm.Geometry != nil {
+			pm.Geometry = transformGeometry(pm.Geometry, fn, &n)
+		}
+		return nil
+	})
+	return n
+}
+
+// transformGeometry implements TransformGeometries for one geometry,
+// counting replacements in n.
+func transformGeometry(g Geometry, fn func(Geometry) Geometry, n *int) Geometry {
+	if multi, ok := g.(*MultiGeometry); ok {
+		parts := multi.Geometries[:0]
+		for _, part := range multi.Geometries {
+			if part = transformGeometry(part, fn, n); part != nil {
+				parts = append(parts, part)
+			}
+		}
+		clear(multi.Geometries[len(parts):])
+		multi.Geometries = parts
+	}
+
+	result := fn(g)
+	if result != g {
+		*n++
+	}
+	return result
+}
@@ -0,0 +1,82 @@
+package kml
+
+import (
+	"testing"
+	"time"
+)
+
+// TestTransformGeometries tests replacing, keeping and removing geometries
+func TestTransformGeometries(t *testing.T) {
+	point := &Point{Coordinates: Coordinate{Lon: 1, Lat: 2}}
+	line := &LineString{Coordinates: []Coordinate{{Lon: 0, Lat: 0}, {Lon: 1, Lat: 1}}}
+	multi := &MultiGeometry{Geometries: []Geometry{&Point{}, &LinearRing{}, &LineString{}}}
+	polygon := &Polygon{}
+
+	k := NewKML()
+	k.Feature = &Folder{Features: []Feature{
+		&Placemark{Name: "point", Geometry: point},
+		&Placemark{Name: "line", Geometry: line},
+		&Placemark{Name: "multi", Geometry: multi},
+		&Placemark{Name: "polygon", Geometry: polygon},
+		&Placemark{Name: "empty"},
+	}}
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var sawMulti int
+	n := k.TransformGeometries(func(g Geometry) Geometry {
+		switch geom := g.(type) {
+		case *Point:
+			return Circle(geom.Coordinates, 50, 16)
+		case *LineString:
+			track := &Track{Coords: geom.Coordinates}
+			for i := range geom.Coordinates {
+				track.When = append(track.When, start.Add(time.Duration(i)*time.Minute))
+			}
+			return track
+		case *LinearRing:
+			return nil
+		case *MultiGeometry:
+			sawMulti = len(geom.Geometries)
+		}
+		return g
+	})
+
+	// point, line, the multi's point, ring and line string
+	if n != 5 {
+		t.Errorf("Expected 5 replacements, got %d", n)
+	}
+	pms := k.Placemarks()
+	if circle, ok := pms[0].Geometry.(*Polygon); !ok || len(circle.OuterBoundary.Coordinates) != 17 {
+		t.Errorf("Expected the point to become a 16-segment circle, got %#v", pms[0].Geometry)
+	}
+	if track, ok := pms[1].Geometry.(*Track); !ok || len(track.When) != 2 {
+		t.Errorf("Expected the line to become a timed track, got %#v", pms[1].Geometry)
+	}
+	if pms[2].Geometry != multi || sawMulti != 2 {
+		t.Errorf("Expected the MultiGeometry to be kept with 2 parts, saw %d", sawMulti)
+	}
+	if _, ok := multi.Geometries[0].(*Polygon); !ok {
+		t.Errorf("Expected the multi's point to become a circle, got %#v", multi.Geometries[0])
+	}
+	if _, ok := multi.Geometries[1].(*Track); !ok {
+		t.Errorf("Expected the multi's line to become a track, got %#v", multi.Geometries[1])
+	}
+	if pms[3].Geometry != polygon {
+		t.Error("Expected the polygon to be kept")
+	}
+	if pms[4].Geometry != nil {
+		t.Error("Expected the placemark without geometry to be left alone")
+	}
+}
+
+// TestTransformGeometriesRemove tests removing a placemark's geometry
+func TestTransformGeometriesRemove(t *testing.T) {
+	k := NewKML()
+	k.Feature = &Placemark{Geometry: &Point{}}
+	if n := k.TransformGeometries(func(Geometry) Geometry { return nil }); n != 1 {
+		t.Errorf("Expected 1 removal, got %d", n)
+	}
+	if k.Feature.(*Placemark).Geometry != nil {
+		t.Error("Expected the geometry to be removed")
+	}
+}
@@ -0,0 +1,139 @@
+package kml
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// SplitTrack divides a timed track into movements and stops, the output of
+// trip analysis, and returns them as placemarks of a Folder in time order.
+//
+// A stop is a run of points that stay within stopRadius meters of the
+// first of them for at least gap. It becomes a Point placemark at the mean
+// position of the run named "Stop 1", "Stop 2" and so on, with a TimeSpan,
+// the dwell time in seconds as ExtendedData "dwell" and a description
+// giving it as a duration. The movements between stops become LineString
+// placemarks named "Segment 1" and so on, with a TimeSpan and ExtendedData
+// "distance" in meters and "duration" in seconds. A movement is also split
+// where recording stopped for longer than gap, as when a logger was off;
+// segments begin and end at the stops either side so the path stays joined.
+//
+// An untimed track gives a single segment.
+func SplitTrack(t *Track, gap time.Duration, stopRadius float64) *Folder {
+	folder := &Folder{Name: "Segments"}
+	n := len(t.Coords)
+	if len(t.When) != n {
+		if n > 1 {
+			folder.Features = append(folder.Features, &Placemark{
+				Name:     "Segment 1",
+				Geometry: &LineString{AltitudeMode: t.AltitudeMode, Coordinates: append([]Coordinate(nil), t.Coords...)},
+			})
+		}
+		return folder
+	}
+
+	type run struct{ from, to int }
+	var stops []run
+	for i := 0; i < n; {
+		j := i
+		for j+1 < n && t.Coords[i].DistanceTo(t.Coords[j+1]) <= stopRadius {
+			j++
+		}
+		if j > i && t.When[j].Sub(t.When[i]) >= gap {
+			stops = append(stops, run{i, j})
+			i = j + 1
+			continue
+		}
+		i++
+	}
+
+	// Movements run from the end of one stop to the start of the next,
+	// broken at gaps in recording.
+	var moves []run
+	move := func(from, to int) {
+		start := from
+		for k := from; k < to; k++ {
+			if t.When[k+1].Sub(t.When[k]) > gap {
+				moves = append(moves, run{start, k})
+				start = k + 1
+			}
+		}
+		moves = append(moves, run{start, to})
+	}
+	prev := 0
+	for _, s := range stops {
+		move(prev, s.from)
+		prev = s.to
+	}
+	move(prev, n-1)
+
+	type item struct {
+		begin time.Time
+		pm    *Placemark
+	}
+	var items []item
+	for _, m := range moves {
+		if m.to <= m.from {
+			continue
+		}
+		coords := append([]Coordinate(nil), t.Coords[m.from:m.to+1]...)
+		dist := 0.0
+		for k := 1; k < len(coords); k++ {
+			dist += coords[k-1].DistanceTo(coords[k])
+		}
+		begin, end := t.When[m.from], t.When[m.to]
+		items = append(items, item{begin, &Placemark{
+			Geometry: &LineString{AltitudeMode: t.AltitudeMode, Coordinates: coords},
+			TimeSpan: &TimeSpan{Begin: begin, End: end},
+			ExtendedData: &ExtendedData{
+				Data: []Data{
+					{Name: "distance", Value: strconv.FormatFloat(dist, 'f', 1, 64)},
+					{Name: "duration", Value: strconv.FormatFloat(end.Sub(begin).Seconds(), 'f', -1, 64)},
+				},
+			},
+		}})
+	}
+	for _, s := range stops {
+		var c Coordinate
+		count := float64(s.to - s.from + 1)
+		for _, p := range t.Coords[s.from : s.to+1] {
+			c.Lon += p.Lon / count
+			c.Lat += p.Lat / count
+			c.Alt += p.Alt / count
+		}
+		begin, end := t.When[s.from], t.When[s.to]
+		dwell := end.Sub(begin)
+		items = append(items, item{begin, &Placemark{
+			Description: fmt.Sprintf("Stopped for %s", dwell),
+			Geometry:    &Point{AltitudeMode: t.AltitudeMode, Coordinates: c},
+			TimeSpan:    &TimeSpan{Begin: begin, End: end},
+			ExtendedData: &ExtendedData{
+				Data: []Data{{Name: "dwell", Value: strconv.FormatFloat(dwell.Seconds(), 'f', -1, 64)}},
+			},
+		}})
+	}
+
+	// A stop and the segment leaving it begin together; the stop goes
+	// first.
+	sort.SliceStable(items, func(i, j int) bool {
+		if !items[i].begin.Equal(items[j].begin) {
+			return items[i].begin.Before(items[j].begin)
+		}
+		_, stop := items[i].pm.Geometry.(*Point)
+		return stop
+	})
+	var segments, stopCount int
+	for _, it := range items {
+		if _, ok := it.pm.Geometry.(*Point); ok {
+			stopCount++
+			it.pm.Name = fmt.Sprintf("Stop %d", stopCount)
+		} else {
+			segments++
+			it.pm.Name = fmt.Sprintf("Segment %d", segments)
+		}
+		folder.Features = append(folder.Features, it.pm)
+	}
+	return folder
+}
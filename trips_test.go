@@ -0,0 +1,117 @@
+package kml
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// leg is a run of points of a test track, each step meters north of the
+// one before and interval later
+type leg struct {
+	points   int
+	step     float64
+	interval time.Duration
+}
+
+// tripTrack returns a timed track built from legs
+func tripTrack(start time.Time, legs ...leg) *Track {
+	tr := &Track{}
+	pos, when := Coordinate{}, start
+	for li, leg := range legs {
+		for i := 0; i < leg.points; i++ {
+			if li > 0 || i > 0 {
+				pos = pos.Destination(0, leg.step)
+				when = when.Add(leg.interval)
+			}
+			tr.Coords = append(tr.Coords, pos)
+			tr.When = append(tr.When, when)
+		}
+	}
+	return tr
+}
+
+// TestSplitTrack tests the segments and stops of a trip
+func TestSplitTrack(t *testing.T) {
+	start := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	tr := tripTrack(start,
+		leg{5, 100, 10 * time.Second}, // Points 0-4 drive
+		leg{10, 1, time.Minute},       // Points 5-14 park
+		leg{4, 100, 10 * time.Second}, // Points 15-18 drive on
+		leg{1, 1000, time.Hour},       // Point 19 after the logger was off
+		leg{3, 100, 10 * time.Second}, // Points 20-22 drive
+	)
+
+	folder := SplitTrack(tr, 5*time.Minute, 20)
+	var names []string
+	for _, f := range folder.Features {
+		names = append(names, f.(*Placemark).Name)
+	}
+	want := []string{"Segment 1", "Stop 1", "Segment 2", "Segment 3"}
+	if !reflect.DeepEqual(names, want) {
+		t.Fatalf("Expected %v, got %v", want, names)
+	}
+
+	seg1 := folder.Features[0].(*Placemark)
+	if ls := seg1.Geometry.(*LineString); len(ls.Coordinates) != 5 {
+		t.Errorf("Expected first segment to end where the stop begins with 5 points, got %d", len(ls.Coordinates))
+	}
+	if got, _ := seg1.dataValue("duration"); got != "40" {
+		t.Errorf("Expected duration 40, got %q", got)
+	}
+	if got, _ := seg1.dataValue("distance"); got != "400.0" {
+		t.Errorf("Expected distance 400.0, got %q", got)
+	}
+	if !seg1.TimeSpan.Begin.Equal(start) {
+		t.Errorf("Expected segment to begin at %v, got %v", start, seg1.TimeSpan.Begin)
+	}
+
+	stop := folder.Features[1].(*Placemark)
+	// The stop begins on arrival at point 4
+	if got, _ := stop.dataValue("dwell"); got != "600" {
+		t.Errorf("Expected dwell 600, got %q", got)
+	}
+	if stop.Description != "Stopped for 10m0s" {
+		t.Errorf("Expected description of the dwell, got %q", stop.Description)
+	}
+	mid := Coordinate{}.Destination(0, 405)
+	if pt := stop.Geometry.(*Point); !floatNear(pt.Coordinates.Lat, mid.Lat, 1e-9) {
+		t.Errorf("Expected stop at the mean position %v, got %v", mid, pt.Coordinates)
+	}
+
+	// The second segment leaves the stop and ends at the recording gap
+	seg2 := folder.Features[2].(*Placemark).Geometry.(*LineString)
+	if len(seg2.Coordinates) != 5 || seg2.Coordinates[0] != tr.Coords[14] || seg2.Coordinates[4] != tr.Coords[18] {
+		t.Errorf("Expected segment from point 14 to 18, got %v", seg2.Coordinates)
+	}
+	seg3 := folder.Features[3].(*Placemark).Geometry.(*LineString)
+	if len(seg3.Coordinates) != 4 || seg3.Coordinates[0] != tr.Coords[19] {
+		t.Errorf("Expected segment from point 19, got %v", seg3.Coordinates)
+	}
+}
+
+// TestSplitTrackEdges tests tracks without stops or times
+func TestSplitTrackEdges(t *testing.T) {
+	start := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+
+	moving := tripTrack(start, leg{5, 100, 10 * time.Second})
+	if f := SplitTrack(moving, time.Minute, 20); len(f.Features) != 1 || f.Features[0].(*Placemark).Name != "Segment 1" {
+		t.Errorf("Expected a single segment, got %d features", len(f.Features))
+	}
+
+	parked := tripTrack(start, leg{5, 1, time.Minute})
+	f := SplitTrack(parked, time.Minute, 20)
+	if len(f.Features) != 1 || f.Features[0].(*Placemark).Name != "Stop 1" {
+		t.Errorf("Expected a single stop, got %d features", len(f.Features))
+	}
+
+	untimed := &Track{Coords: moving.Coords}
+	f = SplitTrack(untimed, time.Minute, 20)
+	if len(f.Features) != 1 || len(f.Features[0].(*Placemark).Geometry.(*LineString).Coordinates) != 5 {
+		t.Errorf("Expected the untimed track as one segment, got %d features", len(f.Features))
+	}
+
+	if f = SplitTrack(&Track{}, time.Minute, 20); len(f.Features) != 0 {
+		t.Errorf("Expected no features for an empty track, got %d", len(f.Features))
+	}
+}
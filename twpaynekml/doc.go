@@ -0,0 +1,16 @@
+// Package twpaynekml converts between the document model of package kml
+// and the elements of github.com/twpayne/go-kml, so a project can parse
+// with kml and render with go-kml, or the other way round, while it
+// migrates from one to the other.
+//
+// The package is a module of its own, so go-kml is a dependency only of
+// programs that require github.com/robert-malhotra/go-kml/twpaynekml, and
+// the converters are built only with the twpayne build tag:
+//
+//	go get github.com/robert-malhotra/go-kml/twpaynekml
+//	go build -tags twpayne
+//
+// Conversions go through KML: elements are marshaled and parsed, and
+// features are added to go-kml trees as elements that marshal themselves.
+// Anything either library does not model is dropped on the way.
+package twpaynekml
@@ -0,0 +1,10 @@
+module github.com/robert-malhotra/go-kml/twpaynekml
+
+go 1.25
+
+replace github.com/robert-malhotra/go-kml => ../
+
+require (
+	github.com/robert-malhotra/go-kml v0.0.0-00010101000000-000000000000
+	github.com/twpayne/go-kml/v3 v3.6.0
+)
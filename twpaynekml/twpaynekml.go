@@ -0,0 +1,100 @@
+//go:build twpayne
+
+package twpaynekml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+
+	gokml "github.com/twpayne/go-kml/v3"
+
+	kml "github.com/robert-malhotra/go-kml"
+)
+
+// FromElement parses a go-kml element into a document. The element may be
+// a whole document, as made by gokml.KML or gokml.GxKML, or a single
+// feature such as a Placemark or Folder, which becomes the document's
+// feature. The options are those of kml.Parse.
+func FromElement(e gokml.Element, opts ...kml.ParseOption) (*kml.KML, error) {
+	data, root, err := marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	if root != "kml" {
+		data = wrap(data, "", "")
+	}
+	return kml.ParseBytes(data, opts...)
+}
+
+// GeometryFromElement parses a go-kml geometry element, such as one made
+// by gokml.Point or gokml.Polygon, into a geometry.
+func GeometryFromElement(e gokml.Element) (kml.Geometry, error) {
+	data, root, err := marshal(e)
+	if err != nil {
+		return nil, err
+	}
+	k, err := kml.ParseBytes(wrap(data, "<Placemark>", "</Placemark>"))
+	if err != nil {
+		return nil, err
+	}
+	pm, ok := k.Feature.(*kml.Placemark)
+	if !ok || pm.Geometry == nil {
+		return nil, fmt.Errorf("twpaynekml: %s is not a geometry element", root)
+	}
+	return pm.Geometry, nil
+}
+
+// ToElement returns f as a go-kml element, to be added to a go-kml tree
+// such as gokml.Document(gokml.Name("Merged"), ToElement(pm)). The element
+// writes f as kml.Write does, so later changes to f are reflected in it.
+// A whole *kml.KML, like every feature and geometry of package kml, is
+// already a go-kml element and may be used as one directly.
+func ToElement(f kml.Feature) gokml.Element {
+	return element{f}
+}
+
+// element is a feature written as a go-kml element.
+type element struct {
+	feature kml.Feature
+}
+
+// MarshalXML implements xml.Marshaler by writing the feature's element.
+func (el element) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	return e.Encode(el.feature)
+}
+
+// marshal returns the XML of e and the local name of its root element.
+func marshal(e gokml.Element) ([]byte, string, error) {
+	data, err := xml.Marshal(e)
+	if err != nil {
+		return nil, "", fmt.Errorf("twpaynekml: error marshaling element: %w", err)
+	}
+	d := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := d.RawToken()
+		if errors.Is(err, io.EOF) {
+			return nil, "", errors.New("twpaynekml: element is empty")
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("twpaynekml: error reading element: %w", err)
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			return data, start.Name.Local, nil
+		}
+	}
+}
+
+// wrap encloses data, and the open and close tags, in a kml element
+// declaring the KML and gx namespaces the element may use undeclared.
+func wrap(data []byte, open, close string) []byte {
+	var b bytes.Buffer
+	b.WriteString(`<kml xmlns="` + kml.DefaultNamespace + `" xmlns:gx="` + kml.GxNamespace + `">`)
+	b.WriteString(open)
+	b.Write(data)
+	b.WriteString(close)
+	b.WriteString(`</kml>`)
+	return b.Bytes()
+}
@@ -0,0 +1,79 @@
+//go:build twpayne
+
+package twpaynekml
+
+import (
+	"encoding/xml"
+	"testing"
+
+	gokml "github.com/twpayne/go-kml/v3"
+
+	kml "github.com/robert-malhotra/go-kml"
+)
+
+// TestFromElement tests parsing go-kml documents and features
+func TestFromElement(t *testing.T) {
+	placemark := gokml.Placemark(
+		gokml.Name("Well"),
+		gokml.Point(gokml.Coordinates(gokml.Coordinate{Lon: 1, Lat: 2})),
+	)
+	tests := []struct {
+		name    string
+		element gokml.Element
+	}{
+		{"document", gokml.KML(gokml.Document(placemark))},
+		{"feature", gokml.Folder(placemark)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k, err := FromElement(tt.element)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			pms := k.Placemarks()
+			if len(pms) != 1 || pms[0].Name != "Well" {
+				t.Fatalf("Expected the Well placemark, got %+v", pms)
+			}
+			if pt, ok := pms[0].Geometry.(*kml.Point); !ok || pt.Coordinates != kml.Coord(1, 2) {
+				t.Errorf("Expected point 1,2, got %+v", pms[0].Geometry)
+			}
+		})
+	}
+}
+
+// TestGeometryFromElement tests parsing go-kml geometries
+func TestGeometryFromElement(t *testing.T) {
+	g, err := GeometryFromElement(gokml.LineString(gokml.Coordinates(gokml.Coordinate{Lon: 1, Lat: 2}, gokml.Coordinate{Lon: 3, Lat: 4})))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if ls, ok := g.(*kml.LineString); !ok || len(ls.Coordinates) != 2 {
+		t.Errorf("Expected a LineString of two points, got %+v", g)
+	}
+
+	if _, err := GeometryFromElement(gokml.Name("not a geometry")); err == nil {
+		t.Errorf("Expected an error for a non-geometry element")
+	}
+}
+
+// TestToElement tests rendering features inside go-kml trees
+func TestToElement(t *testing.T) {
+	pm := &kml.Placemark{Name: "Ours", Geometry: &kml.Point{Coordinates: kml.Coord(5, 6)}}
+	data, err := xml.Marshal(gokml.KML(gokml.Document(gokml.Name("Merged"), ToElement(pm))))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	k, err := kml.ParseBytes(data)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	doc, ok := k.Feature.(*kml.Document)
+	if !ok || doc.Name != "Merged" || len(doc.Features) != 1 {
+		t.Fatalf("Expected the Merged document, got %+v", k.Feature)
+	}
+	if got := doc.Features[0].(*kml.Placemark); got.Name != "Ours" || got.Geometry.(*kml.Point).Coordinates != kml.Coord(5, 6) {
+		t.Errorf("Expected the placemark written by kml, got %+v", got)
+	}
+}
@@ -0,0 +1,111 @@
+package kml
+
+// FindByKey returns the first placemark, in Walk order, whose ExtendedData
+// field keyField, a Data element or SimpleData of a SchemaData, has value.
+// Returns nil if not found.
+func (k *KML) FindByKey(keyField, value string) *Placemark {
+	var result *Placemark
+	k.Walk(func(f Feature) error {
+		if pm, ok := f.(*Placemark); ok {
+			if v, ok := pm.dataValue(keyField); ok && v == value {
+				result = pm
+				return errStopWalk
+			}
+		}
+		return nil
+	})
+	return result
+}
+
+// Upsert merges pm into the document by the value of its ExtendedData
+// field keyField, treated as a primary key: the first placemark with the
+// same value, found as FindByKey finds it, is replaced by pm in the
+// Document or Folder holding it, and if there is none pm is appended to
+// the top-level Document or Folder, which is added around the feature of
+// the document if it has neither. This is the merge step for layers
+// regenerated from a data source, where each run carries the current
+// state of every record. Upsert reports whether a placemark was replaced,
+// and fails with ErrMissingKey if pm has no value for keyField. It also
+// discards the TextSearch index and cached bounds.
+func (k *KML) Upsert(keyField string, pm *Placemark) (bool, error) {
+	key, ok := pm.dataValue(keyField)
+	if !ok {
+		return false, ErrMissingKey
+	}
+	defer k.ResetTextSearch()
+	defer k.ResetBounds()
+
+	if old := k.FindByKey(keyField, key); old != nil {
+		if k.Feature == Feature(old) {
+			k.Feature = pm
+			return true, nil
+		}
+		k.Walk(func(f Feature) error {
+			if children := childrenOf(f); children != nil {
+				for i, child := range *children {
+					if child == Feature(old) {
+						(*children)[i] = pm
+						return errStopWalk
+					}
+				}
+			}
+			return nil
+		})
+		return true, nil
+	}
+
+	root := childrenOf(k.Feature)
+	if root == nil {
+		doc := &Document{}
+		if k.Feature != nil {
+			doc.Features = []Feature{k.Feature}
+		}
+		k.Feature = doc
+		root = &doc.Features
+	}
+	*root = append(*root, pm)
+	return false, nil
+}
+
+// DeleteByKey removes every placemark whose ExtendedData field keyField
+// has value from the Document or Folder holding it, and returns the number
+// removed. A top-level placemark with the value leaves the document empty.
+// Removing any also discards the TextSearch index and cached bounds.
+func (k *KML) DeleteByKey(keyField, value string) int {
+	matches := func(f Feature) bool {
+		pm, ok := f.(*Placemark)
+		if !ok {
+			return false
+		}
+		v, ok := pm.dataValue(keyField)
+		return ok && v == value
+	}
+
+	if matches(k.Feature) {
+		k.Feature = nil
+		k.ResetTextSearch()
+		k.ResetBounds()
+		return 1
+	}
+	removed := 0
+	k.Walk(func(f Feature) error {
+		if children := childrenOf(f); children != nil {
+			kept := (*children)[:0]
+			for _, child := range *children {
+				if matches(child) {
+					removed++
+					continue
+				}
+				kept = append(kept, child)
+			}
+			clear((*children)[len(kept):])
+			*children = kept
+		}
+		return nil
+	})
+	if removed > 0 {
+		k.ResetTextSearch()
+		k.ResetBounds()
+	}
+	return removed
+}
@@ -0,0 +1,130 @@
+package kml
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// keyed returns a placemark with the given name and "id" field.
+func keyed(name, id string) *Placemark {
+	return &Placemark{Name: name, ExtendedData: &ExtendedData{Data: []Data{{Name: "id", Value: id}}}}
+}
+
+// TestUpsert tests replacing and appending placemarks by key
+func TestUpsert(t *testing.T) {
+	inner := &Folder{Features: []Feature{keyed("b", "2")}}
+	k := NewKML()
+	k.Feature = &Document{Features: []Feature{keyed("a", "1"), inner}}
+
+	tests := []struct {
+		name     string
+		pm       *Placemark
+		key      string
+		replaced bool
+	}{
+		{"replace nested", keyed("b v2", "2"), "2", true},
+		{"replace top", keyed("a v2", "1"), "1", true},
+		{"append", keyed("c", "3"), "3", false},
+		{"schema data", &Placemark{Name: "b v3", ExtendedData: &ExtendedData{SchemaData: []SchemaData{{SimpleData: []SimpleData{{Name: "id", Value: "2"}}}}}}, "2", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			replaced, err := k.Upsert("id", tt.pm)
+			if err != nil {
+				t.Fatalf("Upsert failed: %v", err)
+			}
+			if replaced != tt.replaced {
+				t.Errorf("Expected replaced %v, got %v", tt.replaced, replaced)
+			}
+			if got := k.FindByKey("id", tt.key); got != tt.pm {
+				t.Errorf("Expected %q to be found, got %+v", tt.pm.Name, got)
+			}
+		})
+	}
+
+	var names []string
+	for _, pm := range k.Placemarks() {
+		names = append(names, pm.Name)
+	}
+	if want := "a v2,b v3,c"; strings.Join(names, ",") != want {
+		t.Errorf("Expected placemarks %s, got %s", want, strings.Join(names, ","))
+	}
+
+	if _, err := k.Upsert("id", &Placemark{Name: "no key"}); !errors.Is(err, ErrMissingKey) {
+		t.Errorf("Expected ErrMissingKey, got %v", err)
+	}
+}
+
+// TestUpsertRoot tests upserting into documents without a container
+func TestUpsertRoot(t *testing.T) {
+	k := NewKML()
+	if _, err := k.Upsert("id", keyed("a", "1")); err != nil {
+		t.Fatalf("Upsert failed: %v", err)
+	}
+	if doc, ok := k.Feature.(*Document); !ok || len(doc.Features) != 1 {
+		t.Fatalf("Expected a Document holding the placemark, got %+v", k.Feature)
+	}
+
+	k.Feature = keyed("a", "1")
+	replacement := keyed("a v2", "1")
+	if replaced, _ := k.Upsert("id", replacement); !replaced || k.Feature != Feature(replacement) {
+		t.Errorf("Expected the top-level placemark replaced, got %+v", k.Feature)
+	}
+	if _, err := k.Upsert("id", keyed("b", "2")); err != nil {
+		t.Fatalf("Upsert failed: %v", err)
+	}
+	if doc, ok := k.Feature.(*Document); !ok || len(doc.Features) != 2 || doc.Features[0] != Feature(replacement) {
+		t.Errorf("Expected a Document around both placemarks, got %+v", k.Feature)
+	}
+}
+
+// TestDeleteByKey tests removing placemarks by key
+func TestDeleteByKey(t *testing.T) {
+	inner := &Folder{Features: []Feature{keyed("b", "2"), keyed("c", "1")}}
+	k := NewKML()
+	k.Feature = &Document{Features: []Feature{keyed("a", "1"), inner, keyed("d", "3")}}
+
+	if n := k.DeleteByKey("id", "1"); n != 2 {
+		t.Errorf("Expected 2 placemarks removed, got %d", n)
+	}
+	if k.FindByKey("id", "1") != nil {
+		t.Errorf("Expected no placemark with the key left")
+	}
+	if n := len(k.Placemarks()); n != 2 {
+		t.Errorf("Expected 2 placemarks left, got %d", n)
+	}
+	if n := k.DeleteByKey("id", "9"); n != 0 {
+		t.Errorf("Expected nothing removed, got %d", n)
+	}
+
+	k.Feature = keyed("a", "1")
+	if n := k.DeleteByKey("id", "1"); n != 1 || k.Feature != nil {
+		t.Errorf("Expected the top-level placemark removed, got %d and %+v", n, k.Feature)
+	}
+}
+
+// TestUpsertResetsTextSearch tests that searches see upserted and deleted placemarks
+func TestUpsertResetsTextSearch(t *testing.T) {
+	k := NewKML()
+	k.Feature = &Document{Features: []Feature{keyed("Harbour", "1"), keyed("Quarry", "2")}}
+	if got := searchNames(k.TextSearch("harbour")); len(got) != 1 {
+		t.Fatalf("Expected to find the harbour, got %v", got)
+	}
+
+	if _, err := k.Upsert("id", keyed("Marina", "1")); err != nil {
+		t.Fatalf("Upsert failed: %v", err)
+	}
+	if got := searchNames(k.TextSearch("harbour")); got != nil {
+		t.Errorf("Expected the replaced placemark gone, got %v", got)
+	}
+	if got := searchNames(k.TextSearch("marina")); len(got) != 1 {
+		t.Errorf("Expected to find the marina, got %v", got)
+	}
+
+	k.DeleteByKey("id", "2")
+	if got := searchNames(k.TextSearch("quarry")); got != nil {
+		t.Errorf("Expected the deleted placemark gone, got %v", got)
+	}
+}
@@ -0,0 +1,298 @@
+package kml
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ErrOutsideUTM is returned when converting a coordinate outside the UTM
+// latitude range of 80°S to 84°N. The polar regions use the UPS grid,
+// which is not supported.
+var ErrOutsideUTM = errors.New("kml: coordinate outside UTM latitude range")
+
+// UTM is a position on the Universal Transverse Mercator grid (WGS84).
+type UTM struct {
+	Zone     int     // Longitude zone, 1 to 60
+	Band     byte    // Latitude band letter, 'C' to 'X' excluding 'I' and 'O'
+	Easting  float64 // Meters, including the 500 km false easting
+	Northing float64 // Meters, including the 10,000 km false northing south of the equator
+}
+
+const (
+	utmScale       = 0.9996
+	utmBands       = "CDEFGHJKLMNPQRSTUVWX"
+	mgrsColLetters = "ABCDEFGHJKLMNPQRSTUVWXYZ"
+	mgrsRowLetters = "ABCDEFGHJKLMNPQRSTUV"
+)
+
+// String formats u as e.g. "18S 323487 4306483", with the grid values
+// rounded to the meter.
+func (u UTM) String() string {
+	return fmt.Sprintf("%d%c %.0f %.0f", u.Zone, u.Band, math.Floor(u.Easting+0.5), math.Floor(u.Northing+0.5))
+}
+
+// UTM converts c to UTM, honouring the Norway and Svalbard zone
+// exceptions. It returns ErrOutsideUTM for latitudes outside 80°S–84°N.
+func (c Coordinate) UTM() (UTM, error) {
+	if c.Lat < -80 || c.Lat > 84 || math.IsNaN(c.Lat) || math.IsNaN(c.Lon) {
+		return UTM{}, ErrOutsideUTM
+	}
+	lon := math.Mod(c.Lon+540, 360) - 180
+	zone := utmZone(lon, c.Lat)
+	e, n := utmForward(c.Lat, lon, zone)
+	return UTM{Zone: zone, Band: utmBand(c.Lat), Easting: e, Northing: n}, nil
+}
+
+// Coordinate converts u back to a geographic coordinate.
+func (u UTM) Coordinate() Coordinate {
+	lat, lon := utmInverse(u.Easting, u.Northing, u.Zone, u.Band >= 'N')
+	return Coordinate{Lon: lon, Lat: lat}
+}
+
+// ParseUTM parses a UTM reference such as "18S 323487 4306483". The zone
+// and band may be separated by a space, and the band letter selects the
+// hemisphere.
+func ParseUTM(s string) (UTM, error) {
+	fields := strings.Fields(strings.ToUpper(s))
+	if len(fields) == 4 {
+		fields = []string{fields[0] + fields[1], fields[2], fields[3]}
+	}
+	if len(fields) != 3 {
+		return UTM{}, fmt.Errorf("kml: invalid UTM reference %q", s)
+	}
+
+	zone, band, rest, err := parseZoneBand(fields[0])
+	if err != nil || rest != "" {
+		return UTM{}, fmt.Errorf("kml: invalid UTM zone %q", fields[0])
+	}
+	e, err := strconv.ParseFloat(fields[1], 64)
+	if err != nil {
+		return UTM{}, fmt.Errorf("kml: invalid UTM easting %q", fields[1])
+	}
+	n, err := strconv.ParseFloat(fields[2], 64)
+	if err != nil {
+		return UTM{}, fmt.Errorf("kml: invalid UTM northing %q", fields[2])
+	}
+	return UTM{Zone: zone, Band: band, Easting: e, Northing: n}, nil
+}
+
+// MGRS formats c as a Military Grid Reference System string such as
+// "18SUJ2348606483". digits is the number of digits per easting and
+// northing, from 0 (100 km precision) to 5 (1 m precision); values outside
+// that range are clamped. Grid values are truncated, as MGRS requires.
+func (c Coordinate) MGRS(digits int) (string, error) {
+	u, err := c.UTM()
+	if err != nil {
+		return "", err
+	}
+	digits = max(0, min(5, digits))
+
+	col := int(math.Floor(u.Easting / 100000))
+	row := int(math.Floor(u.Northing/100000)) % 20
+	colLetter := mgrsColLetters[((u.Zone-1)%3)*8+col-1]
+	if u.Zone%2 == 0 {
+		row = (row + 5) % 20
+	}
+	rowLetter := mgrsRowLetters[row]
+
+	scale := math.Pow(10, float64(5-digits))
+	e := int(math.Mod(u.Easting, 100000) / scale)
+	n := int(math.Mod(u.Northing, 100000) / scale)
+
+	s := fmt.Sprintf("%d%c%c%c", u.Zone, u.Band, colLetter, rowLetter)
+	if digits > 0 {
+		s += fmt.Sprintf("%0*d%0*d", digits, e, digits, n)
+	}
+	return s, nil
+}
+
+// ParseMGRS parses an MGRS reference such as "18SUJ2348606483" or
+// "18S UJ 23486 06483" and returns the south-west corner of the grid
+// square it denotes.
+func ParseMGRS(s string) (Coordinate, error) {
+	compact := strings.ToUpper(strings.Join(strings.Fields(s), ""))
+	zone, band, rest, err := parseZoneBand(compact)
+	if err != nil || len(rest) < 2 {
+		return Coordinate{}, fmt.Errorf("kml: invalid MGRS reference %q", s)
+	}
+
+	colIdx := strings.IndexByte(mgrsColLetters, rest[0]) - ((zone-1)%3)*8
+	rowIdx := strings.IndexByte(mgrsRowLetters, rest[1])
+	if colIdx < 0 || colIdx >= 8 || rowIdx < 0 {
+		return Coordinate{}, fmt.Errorf("kml: invalid MGRS square %q", rest[:2])
+	}
+	if zone%2 == 0 {
+		rowIdx = (rowIdx + 15) % 20
+	}
+
+	digits := rest[2:]
+	if len(digits)%2 != 0 || len(digits) > 10 {
+		return Coordinate{}, fmt.Errorf("kml: invalid MGRS numeric location %q", digits)
+	}
+	var e, n float64
+	if half := len(digits) / 2; half > 0 {
+		ev, err1 := strconv.Atoi(digits[:half])
+		nv, err2 := strconv.Atoi(digits[half:])
+		if err1 != nil || err2 != nil {
+			return Coordinate{}, fmt.Errorf("kml: invalid MGRS numeric location %q", digits)
+		}
+		scale := math.Pow(10, float64(5-half))
+		e, n = float64(ev)*scale, float64(nv)*scale
+	}
+
+	easting := float64(colIdx+1)*100000 + e
+	northing := float64(rowIdx)*100000 + n
+
+	// The row letters repeat every 2,000 km; pick the cycle that places
+	// the square within its latitude band.
+	bandMin := mgrsBandMinNorthing(band, zone)
+	for northing < bandMin-100000 {
+		northing += 2000000
+	}
+
+	return UTM{Zone: zone, Band: band, Easting: easting, Northing: northing}.Coordinate(), nil
+}
+
+// parseZoneBand splits a leading zone number and band letter from s.
+func parseZoneBand(s string) (zone int, band byte, rest string, err error) {
+	i := 0
+	for i < len(s) && i < 2 && unicode.IsDigit(rune(s[i])) {
+		i++
+	}
+	zone, err = strconv.Atoi(s[:i])
+	if err != nil || zone < 1 || zone > 60 || i >= len(s) {
+		return 0, 0, "", fmt.Errorf("kml: invalid UTM zone")
+	}
+	band = s[i]
+	if strings.IndexByte(utmBands, band) < 0 {
+		return 0, 0, "", fmt.Errorf("kml: invalid UTM band %q", band)
+	}
+	return zone, band, s[i+1:], nil
+}
+
+// utmZone returns the UTM zone for a longitude in [-180, 180) and a
+// latitude, including the Norway and Svalbard exceptions.
+func utmZone(lon, lat float64) int {
+	zone := int(math.Floor((lon+180)/6)) + 1
+	if zone > 60 {
+		zone = 60
+	}
+	if lat >= 56 && lat < 64 && lon >= 3 && lon < 12 {
+		return 32
+	}
+	if lat >= 72 {
+		switch {
+		case lon >= 0 && lon < 9:
+			return 31
+		case lon >= 9 && lon < 21:
+			return 33
+		case lon >= 21 && lon < 33:
+			return 35
+		case lon >= 33 && lon < 42:
+			return 37
+		}
+	}
+	return zone
+}
+
+// utmBand returns the latitude band letter for lat; band X extends to 84°N.
+func utmBand(lat float64) byte {
+	i := int(math.Floor((lat + 80) / 8))
+	return utmBands[max(0, min(len(utmBands)-1, i))]
+}
+
+// mgrsBandMinNorthing returns the smallest northing within a latitude band.
+// Parallels bow poleward in the projection, so the minimum lies on the
+// central meridian north of the equator and on the zone edge south of it.
+func mgrsBandMinNorthing(band byte, zone int) float64 {
+	lat := -80 + 8*float64(strings.IndexByte(utmBands, band))
+	cm := utmCentralMeridian(zone)
+	_, atCM := utmForward(lat, cm, zone)
+	_, atEdge := utmForward(lat, cm+3, zone)
+	return math.Min(atCM, atEdge)
+}
+
+func utmCentralMeridian(zone int) float64 {
+	return float64(zone-1)*6 - 180 + 3
+}
+
+// utmForward projects a latitude and longitude onto the given zone using
+// the transverse Mercator series expansion (Snyder, 1987).
+func utmForward(lat, lon float64, zone int) (easting, northing float64) {
+	e2 := wgs84F * (2 - wgs84F)
+	ep2 := e2 / (1 - e2)
+	phi := toRadians(lat)
+	sinPhi, cosPhi, tanPhi := math.Sin(phi), math.Cos(phi), math.Tan(phi)
+
+	N := wgs84A / math.Sqrt(1-e2*sinPhi*sinPhi)
+	T := tanPhi * tanPhi
+	C := ep2 * cosPhi * cosPhi
+	A := cosPhi * toRadians(lon-utmCentralMeridian(zone))
+	M := meridianArc(phi)
+
+	easting = utmScale*N*(A+(1-T+C)*math.Pow(A, 3)/6+
+		(5-18*T+T*T+72*C-58*ep2)*math.Pow(A, 5)/120) + 500000
+	northing = utmScale * (M + N*tanPhi*(A*A/2+(5-T+9*C+4*C*C)*math.Pow(A, 4)/24+
+		(61-58*T+T*T+600*C-330*ep2)*math.Pow(A, 6)/720))
+	if lat < 0 {
+		northing += 10000000
+	}
+	return easting, northing
+}
+
+// utmInverse converts grid values in the given zone and hemisphere back to
+// a latitude and longitude.
+func utmInverse(easting, northing float64, zone int, north bool) (lat, lon float64) {
+	e2 := wgs84F * (2 - wgs84F)
+	ep2 := e2 / (1 - e2)
+	if !north {
+		northing -= 10000000
+	}
+
+	M := northing / utmScale
+	mu := M / (wgs84A * (1 - e2/4 - 3*e2*e2/64 - 5*e2*e2*e2/256))
+	e1 := (1 - math.Sqrt(1-e2)) / (1 + math.Sqrt(1-e2))
+	phi1 := mu + (3*e1/2-27*math.Pow(e1, 3)/32)*math.Sin(2*mu) +
+		(21*e1*e1/16-55*math.Pow(e1, 4)/32)*math.Sin(4*mu) +
+		(151*math.Pow(e1, 3)/96)*math.Sin(6*mu) +
+		(1097*math.Pow(e1, 4)/512)*math.Sin(8*mu)
+
+	sinPhi1, cosPhi1, tanPhi1 := math.Sin(phi1), math.Cos(phi1), math.Tan(phi1)
+	C1 := ep2 * cosPhi1 * cosPhi1
+	T1 := tanPhi1 * tanPhi1
+	N1 := wgs84A / math.Sqrt(1-e2*sinPhi1*sinPhi1)
+	R1 := wgs84A * (1 - e2) / math.Pow(1-e2*sinPhi1*sinPhi1, 1.5)
+	D := (easting - 500000) / (N1 * utmScale)
+
+	phi := phi1 - (N1*tanPhi1/R1)*(D*D/2-
+		(5+3*T1+10*C1-4*C1*C1-9*ep2)*math.Pow(D, 4)/24+
+		(61+90*T1+298*C1+45*T1*T1-252*ep2-3*C1*C1)*math.Pow(D, 6)/720)
+	lambda := (D - (1+2*T1+C1)*math.Pow(D, 3)/6 +
+		(5-2*C1+28*T1-3*C1*C1+8*ep2+24*T1*T1)*math.Pow(D, 5)/120) / cosPhi1
+
+	return toDegrees(phi), utmCentralMeridian(zone) + toDegrees(lambda)
+}
+
+// meridianArc returns the distance along the WGS84 meridian from the
+// equator to latitude phi, in meters.
+func meridianArc(phi float64) float64 {
+	e2 := wgs84F * (2 - wgs84F)
+	e4, e6 := e2*e2, e2*e2*e2
+	return wgs84A * ((1-e2/4-3*e4/64-5*e6/256)*phi -
+		(3*e2/8+3*e4/32+45*e6/1024)*math.Sin(2*phi) +
+		(15*e4/256+45*e6/1024)*math.Sin(4*phi) -
+		(35*e6/3072)*math.Sin(6*phi))
+}
+
+// AnnotateMGRS stamps every placemark with its MGRS grid reference at the
+// given precision, stored in an ExtendedData field named "mgrs". It
+// returns the number of placemarks annotated; see AnnotateGrid.
+func (k *KML) AnnotateMGRS(digits int) int {
+	return k.AnnotateGrid("mgrs", func(c Coordinate) (string, error) {
+		return c.MGRS(digits)
+	})
+}
@@ -0,0 +1,158 @@
+package kml
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestCoordinateUTM tests conversion to UTM, including zone exceptions
+func TestCoordinateUTM(t *testing.T) {
+	tests := []struct {
+		name string
+		c    Coordinate
+		want string
+	}{
+		{"Washington Monument", Coord(-77.0352, 38.8895), "18S 323487 4306483"},
+		{"Eiffel Tower", Coord(2.2945, 48.8584), "31U 448252 5411955"},
+		{"Sydney Opera House", Coord(151.2153, -33.8568), "56H 334901 6252289"},
+		{"equator on central meridian", Coord(3, 0), "31N 500000 0"},
+		{"Norway exception", Coord(5, 60), "32V 276980 6658157"},
+		{"Svalbard exception", Coord(15, 78), "33X 500000 8658370"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := tt.c.UTM()
+			if err != nil {
+				t.Fatalf("UTM() error: %v", err)
+			}
+			if got := u.String(); got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+
+			back := u.Coordinate()
+			if !floatNear(back.Lon, tt.c.Lon, 1e-7) || !floatNear(back.Lat, tt.c.Lat, 1e-7) {
+				t.Errorf("Expected round trip to %v, got %v", tt.c, back)
+			}
+		})
+	}
+
+	if _, err := Coord(0, 85).UTM(); !errors.Is(err, ErrOutsideUTM) {
+		t.Errorf("Expected ErrOutsideUTM, got %v", err)
+	}
+}
+
+// TestParseUTM tests parsing UTM references
+func TestParseUTM(t *testing.T) {
+	u, err := ParseUTM("56 H 334901 6252289")
+	if err != nil {
+		t.Fatalf("ParseUTM() error: %v", err)
+	}
+	if u.Zone != 56 || u.Band != 'H' || u.Easting != 334901 || u.Northing != 6252289 {
+		t.Errorf("Unexpected UTM: %+v", u)
+	}
+	c := u.Coordinate()
+	if !floatNear(c.Lon, 151.2153, 1e-5) || !floatNear(c.Lat, -33.8568, 1e-5) {
+		t.Errorf("Expected Sydney, got %v", c)
+	}
+
+	for _, s := range []string{"", "61N 500000 0", "18I 1 2", "18S abc 4306483", "18S 323487", "S 1 2"} {
+		if _, err := ParseUTM(s); err == nil {
+			t.Errorf("Expected error for %q", s)
+		}
+	}
+}
+
+// TestCoordinateMGRS tests MGRS formatting at different precisions
+func TestCoordinateMGRS(t *testing.T) {
+	c := Coord(-77.0352, 38.8895)
+	tests := []struct {
+		digits int
+		want   string
+	}{
+		{5, "18SUJ2348606483"},
+		{4, "18SUJ23480648"},
+		{1, "18SUJ20"},
+		{0, "18SUJ"},
+		{9, "18SUJ2348606483"},
+	}
+
+	for _, tt := range tests {
+		got, err := c.MGRS(tt.digits)
+		if err != nil {
+			t.Fatalf("MGRS(%d) error: %v", tt.digits, err)
+		}
+		if got != tt.want {
+			t.Errorf("MGRS(%d): expected %q, got %q", tt.digits, tt.want, got)
+		}
+	}
+
+	if got, _ := Coord(-157.9, 21.4).MGRS(5); got != "4QFJ1401966817" {
+		t.Errorf("Expected even-zone row offset, got %q", got)
+	}
+	if _, err := Coord(0, -85).MGRS(5); !errors.Is(err, ErrOutsideUTM) {
+		t.Errorf("Expected ErrOutsideUTM, got %v", err)
+	}
+}
+
+// TestParseMGRS tests MGRS parsing and round trips across bands
+func TestParseMGRS(t *testing.T) {
+	c, err := ParseMGRS("18S UJ 23486 06483")
+	if err != nil {
+		t.Fatalf("ParseMGRS() error: %v", err)
+	}
+	if !floatNear(c.Lon, -77.0352, 1e-4) || !floatNear(c.Lat, 38.8895, 1e-4) {
+		t.Errorf("Expected Washington Monument, got %v", c)
+	}
+
+	points := []Coordinate{
+		Coord(2.2945, 48.8584), Coord(151.2153, -33.8568), Coord(-157.9, 21.4),
+		Coord(10, 60), Coord(15, 78), Coord(-70, -79.5), Coord(20, 83.5), Coord(-0.5, 0.1),
+	}
+	for _, p := range points {
+		m, err := p.MGRS(5)
+		if err != nil {
+			t.Fatalf("MGRS() error for %v: %v", p, err)
+		}
+		back, err := ParseMGRS(m)
+		if err != nil {
+			t.Fatalf("ParseMGRS(%q) error: %v", m, err)
+		}
+		if d := p.DistanceTo(back); d > 1.5 {
+			t.Errorf("%q: expected round trip within a meter of %v, got %v (%.2fm)", m, p, back, d)
+		}
+	}
+
+	for _, s := range []string{"", "18S", "18SIJ", "18SUJ123", "18SUJ12a4", "61SUJ"} {
+		if _, err := ParseMGRS(s); err == nil {
+			t.Errorf("Expected error for %q", s)
+		}
+	}
+}
+
+// TestAnnotateMGRS tests stamping placemarks with grid references
+func TestAnnotateMGRS(t *testing.T) {
+	line := &Placemark{Name: "line", Geometry: &LineString{Coordinates: []Coordinate{Coord(2.29, 48.85), Coord(2.2990, 48.8668)}}}
+	stale := pointPlacemark("stale", -77.0352, 38.8895)
+	stale.ExtendedData = &ExtendedData{Data: []Data{{Name: "mgrs", Value: "old"}, {Name: "other", Value: "x"}}}
+
+	k := NewKML()
+	k.Feature = &Document{Features: []Feature{
+		stale,
+		&Folder{Features: []Feature{line, pointPlacemark("polar", 0, 89)}},
+		&Placemark{Name: "empty"},
+	}}
+
+	if n := k.AnnotateMGRS(4); n != 2 {
+		t.Errorf("Expected 2 annotated placemarks, got %d", n)
+	}
+	if got, _ := stale.dataValue("mgrs"); got != "18SUJ23480648" {
+		t.Errorf("Expected replaced value, got %q", got)
+	}
+	if len(stale.ExtendedData.Data) != 2 {
+		t.Errorf("Expected existing fields kept, got %v", stale.ExtendedData.Data)
+	}
+	if got, _ := line.dataValue("mgrs"); got != "31UDQ48251195" {
+		t.Errorf("Expected line annotated at its bounds center, got %q", got)
+	}
+}
@@ -0,0 +1,243 @@
+package kml
+
+import "fmt"
+
+// IssueKind classifies a polygon validity problem.
+type IssueKind int
+
+const (
+	// IssueTooFewPoints marks a ring with fewer than three distinct
+	// positions (four including the closing one).
+	IssueTooFewPoints IssueKind = iota
+	// IssueSelfIntersection marks two segments of one ring that cross or
+	// overlap, such as the crossing of a bowtie.
+	IssueSelfIntersection
+	// IssueRingsCross marks a segment of one ring crossing or touching a
+	// segment of another ring of the same polygon.
+	IssueRingsCross
+	// IssueHoleOutsideShell marks a hole that lies outside the outer boundary.
+	IssueHoleOutsideShell
+	// IssueNestedHole marks a hole that lies inside another hole.
+	IssueNestedHole
+)
+
+// String returns a short description of the issue kind.
+func (k IssueKind) String() string {
+	switch k {
+	case IssueTooFewPoints:
+		return "too few points"
+	case IssueSelfIntersection:
+		return "self-intersection"
+	case IssueRingsCross:
+		return "rings cross"
+	case IssueHoleOutsideShell:
+		return "hole outside shell"
+	case IssueNestedHole:
+		return "nested hole"
+	}
+	return "unknown"
+}
+
+// ValidityIssue describes one problem found by Polygon.ValidityIssues.
+//
+// Rings are numbered 0 for the outer boundary and i+1 for
+// InnerBoundaries[i]. Segment i of a ring runs from coordinate i to
+// coordinate i+1 (wrapping to the first coordinate for an unclosed ring).
+// Segment indices are -1 when the issue concerns a whole ring.
+type ValidityIssue struct {
+	Kind         IssueKind
+	Ring         int
+	Segment      int
+	OtherRing    int
+	OtherSegment int
+}
+
+// String returns a human-readable description of the issue.
+func (v ValidityIssue) String() string {
+	switch v.Kind {
+	case IssueSelfIntersection:
+		return fmt.Sprintf("%s in ring %d between segments %d and %d", v.Kind, v.Ring, v.Segment, v.OtherSegment)
+	case IssueRingsCross:
+		return fmt.Sprintf("%s: ring %d segment %d crosses ring %d segment %d", v.Kind, v.Ring, v.Segment, v.OtherRing, v.OtherSegment)
+	case IssueNestedHole:
+		return fmt.Sprintf("%s: ring %d is inside ring %d", v.Kind, v.Ring, v.OtherRing)
+	}
+	return fmt.Sprintf("%s in ring %d", v.Kind, v.Ring)
+}
+
+// SelfIntersections returns the index pairs of non-adjacent segments that
+// intersect, plus adjacent segments that fold back over each other. Each
+// pair is reported once with the lower index first. The check is
+// O(n²) in the number of coordinates and treats coordinates as planar.
+func (lr *LinearRing) SelfIntersections() [][2]int {
+	segs := ringSegments(lr.Coordinates)
+	n := len(segs)
+
+	var pairs [][2]int
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			si, sj := segs[i], segs[j]
+			adjacent := j == i+1 || (i == 0 && j == n-1 && n > 2)
+			if adjacent {
+				if segmentsOverlap(si, sj) {
+					pairs = append(pairs, [2]int{si.index, sj.index})
+				}
+				continue
+			}
+			if segmentsIntersect(si.a, si.b, sj.a, sj.b) {
+				pairs = append(pairs, [2]int{si.index, sj.index})
+			}
+		}
+	}
+	return pairs
+}
+
+// IsSimple reports whether the ring has at least three distinct positions
+// and no self-intersections.
+func (lr *LinearRing) IsSimple() bool {
+	return len(ringSegments(lr.Coordinates)) >= 3 && len(lr.SelfIntersections()) == 0
+}
+
+// IsValid reports whether the polygon has no validity issues.
+func (p *Polygon) IsValid() bool {
+	return len(p.ValidityIssues()) == 0
+}
+
+// ValidityIssues checks the polygon for rings with too few points,
+// self-intersecting rings such as bowties, rings crossing each other, and
+// holes outside the shell or inside other holes.
+func (p *Polygon) ValidityIssues() []ValidityIssue {
+	rings := make([][]Coordinate, 0, len(p.InnerBoundaries)+1)
+	rings = append(rings, p.OuterBoundary.Coordinates)
+	for _, hole := range p.InnerBoundaries {
+		rings = append(rings, hole.Coordinates)
+	}
+
+	var issues []ValidityIssue
+	segs := make([][]ringSegment, len(rings))
+	for r, coords := range rings {
+		segs[r] = ringSegments(coords)
+		if len(segs[r]) < 3 {
+			issues = append(issues, ValidityIssue{Kind: IssueTooFewPoints, Ring: r, Segment: -1, OtherRing: -1, OtherSegment: -1})
+			continue
+		}
+		lr := LinearRing{Coordinates: coords}
+		for _, pair := range lr.SelfIntersections() {
+			issues = append(issues, ValidityIssue{Kind: IssueSelfIntersection, Ring: r, Segment: pair[0], OtherRing: r, OtherSegment: pair[1]})
+		}
+	}
+
+	for a := 0; a < len(rings); a++ {
+		for b := a + 1; b < len(rings); b++ {
+			if len(segs[a]) < 3 || len(segs[b]) < 3 {
+				continue
+			}
+			crossed := false
+			for _, sa := range segs[a] {
+				for _, sb := range segs[b] {
+					if segmentsIntersect(sa.a, sa.b, sb.a, sb.b) {
+						issues = append(issues, ValidityIssue{Kind: IssueRingsCross, Ring: a, Segment: sa.index, OtherRing: b, OtherSegment: sb.index})
+						crossed = true
+					}
+				}
+			}
+			if crossed {
+				continue
+			}
+
+			// The rings are disjoint, so one vertex decides containment.
+			switch {
+			case a == 0 && !ringContains(rings[0], rings[b][0]):
+				issues = append(issues, ValidityIssue{Kind: IssueHoleOutsideShell, Ring: b, Segment: -1, OtherRing: 0, OtherSegment: -1})
+			case a > 0 && ringContains(rings[a], rings[b][0]):
+				issues = append(issues, ValidityIssue{Kind: IssueNestedHole, Ring: b, Segment: -1, OtherRing: a, OtherSegment: -1})
+			case a > 0 && ringContains(rings[b], rings[a][0]):
+				issues = append(issues, ValidityIssue{Kind: IssueNestedHole, Ring: a, Segment: -1, OtherRing: b, OtherSegment: -1})
+			}
+		}
+	}
+
+	return issues
+}
+
+// ringSegment is a non-degenerate segment of a ring and its index.
+type ringSegment struct {
+	a, b  Coordinate
+	index int
+}
+
+// ringSegments returns the segments of a ring in order, adding the closing
+// segment if the ring is not closed. Zero-length segments from repeated
+// positions are skipped but do not shift the indices of later segments.
+// Altitudes are ignored.
+func ringSegments(coords []Coordinate) []ringSegment {
+	pts := make([]Coordinate, len(coords), len(coords)+1)
+	for i, c := range coords {
+		pts[i] = Coordinate{Lon: c.Lon, Lat: c.Lat}
+	}
+	if len(pts) > 1 && pts[0] != pts[len(pts)-1] {
+		pts = append(pts, pts[0])
+	}
+
+	var segs []ringSegment
+	for i := 1; i < len(pts); i++ {
+		if pts[i-1] != pts[i] {
+			segs = append(segs, ringSegment{a: pts[i-1], b: pts[i], index: i - 1})
+		}
+	}
+	return segs
+}
+
+// orientation returns the sign of the cross product (b-a) x (c-a):
+// positive for a counter-clockwise turn, negative for clockwise, zero
+// for collinear points.
+func orientation(a, b, c Coordinate) float64 {
+	return (b.Lon-a.Lon)*(c.Lat-a.Lat) - (b.Lat-a.Lat)*(c.Lon-a.Lon)
+}
+
+// onSegment reports whether c, known to be collinear with a-b, lies within
+// the segment's bounding box.
+func onSegment(a, b, c Coordinate) bool {
+	return min(a.Lon, b.Lon) <= c.Lon && c.Lon <= max(a.Lon, b.Lon) &&
+		min(a.Lat, b.Lat) <= c.Lat && c.Lat <= max(a.Lat, b.Lat)
+}
+
+// segmentsIntersect reports whether segments p1-p2 and p3-p4 share any point.
+func segmentsIntersect(p1, p2, p3, p4 Coordinate) bool {
+	d1 := orientation(p3, p4, p1)
+	d2 := orientation(p3, p4, p2)
+	d3 := orientation(p1, p2, p3)
+	d4 := orientation(p1, p2, p4)
+
+	if ((d1 > 0 && d2 < 0) || (d1 < 0 && d2 > 0)) && ((d3 > 0 && d4 < 0) || (d3 < 0 && d4 > 0)) {
+		return true
+	}
+	return (d1 == 0 && onSegment(p3, p4, p1)) ||
+		(d2 == 0 && onSegment(p3, p4, p2)) ||
+		(d3 == 0 && onSegment(p1, p2, p3)) ||
+		(d4 == 0 && onSegment(p1, p2, p4))
+}
+
+// segmentsOverlap reports whether two segments sharing an endpoint fold
+// back over each other, sharing more than that single point.
+func segmentsOverlap(s, t ringSegment) bool {
+	// Identify the shared endpoint and the two far endpoints.
+	var shared, a, b Coordinate
+	switch {
+	case s.b == t.a:
+		shared, a, b = s.b, s.a, t.b
+	case s.a == t.b:
+		shared, a, b = s.a, s.b, t.a
+	case s.a == t.a:
+		shared, a, b = s.a, s.b, t.b
+	case s.b == t.b:
+		shared, a, b = s.b, s.a, t.a
+	default:
+		return segmentsIntersect(s.a, s.b, t.a, t.b)
+	}
+	if orientation(shared, a, b) != 0 {
+		return false
+	}
+	// Collinear: they overlap if both far ends are on the same side.
+	return (a.Lon-shared.Lon)*(b.Lon-shared.Lon)+(a.Lat-shared.Lat)*(b.Lat-shared.Lat) > 0
+}
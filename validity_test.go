@@ -0,0 +1,119 @@
+package kml
+
+import "testing"
+
+func ring(coords ...float64) LinearRing {
+	var lr LinearRing
+	for i := 0; i+1 < len(coords); i += 2 {
+		lr.Coordinates = append(lr.Coordinates, Coord(coords[i], coords[i+1]))
+	}
+	return lr
+}
+
+func TestLinearRingSelfIntersections(t *testing.T) {
+	tests := []struct {
+		name  string
+		ring  LinearRing
+		want  [][2]int
+		valid bool
+	}{
+		{"square", ring(0, 0, 1, 0, 1, 1, 0, 1, 0, 0), nil, true},
+		{"unclosed square", ring(0, 0, 1, 0, 1, 1, 0, 1), nil, true},
+		{"bowtie", ring(0, 0, 1, 1, 1, 0, 0, 1, 0, 0), [][2]int{{0, 2}}, false},
+		{"repeated vertex", ring(0, 0, 1, 0, 1, 0, 1, 1, 0, 1, 0, 0), nil, true},
+		{"repeated vertex keeps indices", ring(0, 0, 0, 0, 1, 1, 1, 0, 0, 1, 0, 0), [][2]int{{1, 3}}, false},
+		{"spike", ring(0, 0, 2, 0, 2, 2, 2, 3, 2, 1, 0, 2, 0, 0), [][2]int{{1, 3}, {1, 4}, {2, 3}}, false},
+		{"vertex touches edge", ring(0, 0, 4, 0, 4, 4, 2, 0, 0, 4, 0, 0), [][2]int{{0, 2}, {0, 3}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.ring.SelfIntersections()
+			if len(got) != len(tt.want) {
+				t.Fatalf("Expected %v, got %v", tt.want, got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Expected %v, got %v", tt.want, got)
+				}
+			}
+			if tt.ring.IsSimple() != tt.valid {
+				t.Errorf("Expected IsSimple() = %v", tt.valid)
+			}
+		})
+	}
+
+	short := ring(0, 0, 1, 1, 0, 0)
+	if short.IsSimple() {
+		t.Error("Expected degenerate ring to not be simple")
+	}
+}
+
+func TestPolygonValidityIssues(t *testing.T) {
+	tests := []struct {
+		name string
+		poly Polygon
+		want []ValidityIssue
+	}{
+		{"valid with hole", Polygon{
+			OuterBoundary:   square(0, 0, 10, 10),
+			InnerBoundaries: []LinearRing{square(2, 2, 4, 4), square(6, 6, 8, 8)},
+		}, nil},
+		{"bowtie shell", Polygon{
+			OuterBoundary: ring(0, 0, 1, 1, 1, 0, 0, 1, 0, 0),
+		}, []ValidityIssue{{Kind: IssueSelfIntersection, Ring: 0, Segment: 0, OtherRing: 0, OtherSegment: 2}}},
+		{"too few points", Polygon{
+			OuterBoundary: ring(0, 0, 1, 1, 0, 0),
+		}, []ValidityIssue{{Kind: IssueTooFewPoints, Ring: 0, Segment: -1, OtherRing: -1, OtherSegment: -1}}},
+		{"hole outside", Polygon{
+			OuterBoundary:   square(0, 0, 10, 10),
+			InnerBoundaries: []LinearRing{square(20, 20, 21, 21)},
+		}, []ValidityIssue{{Kind: IssueHoleOutsideShell, Ring: 1, Segment: -1, OtherRing: 0, OtherSegment: -1}}},
+		{"hole crosses shell", Polygon{
+			OuterBoundary:   square(0, 0, 10, 10),
+			InnerBoundaries: []LinearRing{square(8, 4, 12, 6)},
+		}, []ValidityIssue{
+			{Kind: IssueRingsCross, Ring: 0, Segment: 1, OtherRing: 1, OtherSegment: 0},
+			{Kind: IssueRingsCross, Ring: 0, Segment: 1, OtherRing: 1, OtherSegment: 2},
+		}},
+		{"nested hole", Polygon{
+			OuterBoundary:   square(0, 0, 10, 10),
+			InnerBoundaries: []LinearRing{square(1, 1, 9, 9), square(4, 4, 5, 5)},
+		}, []ValidityIssue{{Kind: IssueNestedHole, Ring: 2, Segment: -1, OtherRing: 1, OtherSegment: -1}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.poly.ValidityIssues()
+			if len(got) != len(tt.want) {
+				t.Fatalf("Expected %v, got %v", tt.want, got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Issue %d: expected %v, got %v", i, tt.want[i], got[i])
+				}
+			}
+			if tt.poly.IsValid() != (len(tt.want) == 0) {
+				t.Errorf("Expected IsValid() = %v", len(tt.want) == 0)
+			}
+		})
+	}
+}
+
+func TestValidityIssueString(t *testing.T) {
+	tests := []struct {
+		issue ValidityIssue
+		want  string
+	}{
+		{ValidityIssue{Kind: IssueSelfIntersection, Ring: 0, Segment: 0, OtherSegment: 2}, "self-intersection in ring 0 between segments 0 and 2"},
+		{ValidityIssue{Kind: IssueRingsCross, Ring: 0, Segment: 1, OtherRing: 1, OtherSegment: 3}, "rings cross: ring 0 segment 1 crosses ring 1 segment 3"},
+		{ValidityIssue{Kind: IssueHoleOutsideShell, Ring: 2}, "hole outside shell in ring 2"},
+		{ValidityIssue{Kind: IssueNestedHole, Ring: 2, OtherRing: 1}, "nested hole: ring 2 is inside ring 1"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.issue.String(); got != tt.want {
+			t.Errorf("Expected %q, got %q", tt.want, got)
+		}
+	}
+}
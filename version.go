@@ -0,0 +1,216 @@
+package kml
+
+import (
+	"encoding/xml"
+	"strings"
+)
+
+// Version identifies a KML schema version by its namespace.
+type Version int
+
+const (
+	VersionUnknown Version = iota // An unrecognized or missing namespace
+	Version20                     // http://earth.google.com/kml/2.0
+	Version21                     // http://earth.google.com/kml/2.1
+	Version22                     // http://www.opengis.net/kml/2.2, the OGC standard
+	Version23                     // http://www.opengis.net/kml/2.3
+)
+
+// String returns the version number, such as "2.2".
+func (v Version) String() string {
+	switch v {
+	case Version20:
+		return "2.0"
+	case Version21:
+		return "2.1"
+	case Version22:
+		return "2.2"
+	case Version23:
+		return "2.3"
+	}
+	return "unknown"
+}
+
+// Namespace returns the XML namespace of the version, or DefaultNamespace
+// for VersionUnknown.
+func (v Version) Namespace() string {
+	switch v {
+	case Version20:
+		return "http://earth.google.com/kml/2.0"
+	case Version21:
+		return "http://earth.google.com/kml/2.1"
+	case Version23:
+		return "http://www.opengis.net/kml/2.3"
+	}
+	return DefaultNamespace
+}
+
+// VersionOf returns the version a namespace declares. Google's pre-OGC
+// namespace for 2.2, http://earth.google.com/kml/2.2, is Version22.
+func VersionOf(namespace string) Version {
+	switch strings.TrimRight(namespace, "/") {
+	case "http://earth.google.com/kml/2.0":
+		return Version20
+	case "http://earth.google.com/kml/2.1":
+		return Version21
+	case "http://www.opengis.net/kml/2.2", "http://earth.google.com/kml/2.2":
+		return Version22
+	case "http://www.opengis.net/kml/2.3":
+		return Version23
+	}
+	return VersionUnknown
+}
+
+// Version returns the schema version declared by the document's namespace,
+// as read by Parse.
+func (k *KML) Version() Version {
+	return VersionOf(k.Xmlns)
+}
+
+// TargetVersion writes the document for version v: the root declares the
+// namespace of v, and elements are adapted where the versions differ. For
+// 2.3, gx:Track, gx:coord and gx:LatLonQuad are written as the Track,
+// coord and LatLonQuad elements the standard adopted. For 2.0 and 2.1,
+// which predate the gx extensions, tracks are written as LineStrings,
+// LatLonQuads are dropped and the sea-floor altitude modes fall back to
+// their ground equivalents; for 2.0, NetworkLink's Link is written as Url.
+// Elements a version lacks and cannot be converted, such as ExtendedData
+// before 2.2, are written unchanged. Lint the document with VersionRule to
+// find what will be lost or ignored.
+func TargetVersion(v Version) WriteOption {
+	return func(c *writeConfig) {
+		c.version = v
+	}
+}
+
+// targetVersion returns the version e writes, or VersionUnknown if it was
+// not configured with TargetVersion.
+func targetVersion(e *xml.Encoder) Version {
+	if cfg := writeConfigFor(e); cfg != nil {
+		return cfg.version
+	}
+	return VersionUnknown
+}
+
+// preGx reports whether v predates the gx extensions.
+func (v Version) preGx() bool {
+	return v == Version20 || v == Version21
+}
+
+// gxElement returns the start element for the gx extension element local,
+// declaring the gx prefix on it, or the plain element for KML 2.3.
+func gxElement(e *xml.Encoder, local string) xml.StartElement {
+	if targetVersion(e) == Version23 {
+		return xml.StartElement{Name: xml.Name{Local: local}}
+	}
+	return xml.StartElement{
+		Name: xml.Name{Local: "gx:" + local},
+		Attr: []xml.Attr{{Name: xml.Name{Local: "xmlns:gx"}, Value: GxNamespace}},
+	}
+}
+
+// encodeAltitudeMode writes an altitudeMode element, replacing the
+// sea-floor modes when e targets a version without them.
+func encodeAltitudeMode(e *xml.Encoder, m AltitudeMode) error {
+	if targetVersion(e).preGx() {
+		switch m {
+		case AltitudeModeClampToSeaFloor:
+			m = AltitudeModeClampToGround
+		case AltitudeModeRelativeToSeaFloor:
+			m = AltitudeModeRelativeToGround
+		}
+	}
+	return e.EncodeElement(m, xml.StartElement{Name: xml.Name{Local: "altitudeMode"}})
+}
+
+// VersionRule reports what of each feature version v cannot represent:
+// elements that TargetVersion converts with loss or drops, and elements
+// v lacks that are written anyway and likely ignored by its readers.
+func VersionRule(v Version) Rule {
+	return Rule{
+		Name:     "version-" + v.String(),
+		Severity: SeverityWarning,
+		Check: func(f Feature) []string {
+			var msgs []string
+			if v == Version20 {
+				if r := featureRegion(f); r != nil {
+					msgs = append(msgs, "Region requires KML 2.1")
+				}
+				if hasTime(f) {
+					msgs = append(msgs, "TimeStamp and TimeSpan require KML 2.1")
+				}
+			}
+			if !v.preGx() {
+				return msgs
+			}
+
+			switch feature := f.(type) {
+			case *Placemark:
+				if feature.ExtendedData != nil {
+					msgs = append(msgs, "ExtendedData requires KML 2.2")
+				}
+				if hasTrack(feature.Geometry) {
+					msgs = append(msgs, "gx:Track is written as a LineString without its times")
+				}
+				modes := make(map[AltitudeMode]bool)
+				altitudeModes(feature.Geometry, modes)
+				if modes[AltitudeModeClampToSeaFloor] || modes[AltitudeModeRelativeToSeaFloor] {
+					msgs = append(msgs, "sea-floor altitude modes are written as their ground equivalents")
+				}
+			case *GroundOverlay:
+				if feature.LatLonQuad != nil {
+					msgs = append(msgs, "gx:LatLonQuad is dropped")
+				}
+				if feature.AltitudeMode == AltitudeModeClampToSeaFloor || feature.AltitudeMode == AltitudeModeRelativeToSeaFloor {
+					msgs = append(msgs, "sea-floor altitude modes are written as their ground equivalents")
+				}
+			case *Tour:
+				msgs = append(msgs, "gx:Tour is dropped")
+			}
+			return msgs
+		},
+	}
+}
+
+// featureRegion returns the Region of f, if any.
+func featureRegion(f Feature) *Region {
+	switch feature := f.(type) {
+	case *Document:
+		return feature.Region
+	case *Folder:
+		return feature.Region
+	case *Placemark:
+		return feature.Region
+	case *GroundOverlay:
+		return feature.Region
+	case *NetworkLink:
+		return feature.Region
+	}
+	return nil
+}
+
+// hasTime reports whether f has a TimeStamp or TimeSpan.
+func hasTime(f Feature) bool {
+	switch feature := f.(type) {
+	case *Folder:
+		return feature.TimeStamp != nil || feature.TimeSpan != nil
+	case *Placemark:
+		return feature.TimeStamp != nil || feature.TimeSpan != nil
+	}
+	return false
+}
+
+// hasTrack reports whether g is or contains a Track.
+func hasTrack(g Geometry) bool {
+	switch geom := g.(type) {
+	case *Track:
+		return true
+	case *MultiGeometry:
+		for _, child := range geom.Geometries {
+			if hasTrack(child) {
+				return true
+			}
+		}
+	}
+	return false
+}
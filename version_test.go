@@ -0,0 +1,152 @@
+package kml
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestVersionOf tests detecting versions from namespaces
+func TestVersionOf(t *testing.T) {
+	tests := []struct {
+		namespace string
+		want      Version
+	}{
+		{"http://earth.google.com/kml/2.0", Version20},
+		{"http://earth.google.com/kml/2.1", Version21},
+		{"http://earth.google.com/kml/2.2", Version22},
+		{"http://www.opengis.net/kml/2.2", Version22},
+		{"http://www.opengis.net/kml/2.3/", Version23},
+		{"http://example.com/kml", VersionUnknown},
+	}
+
+	for _, tt := range tests {
+		if got := VersionOf(tt.namespace); got != tt.want {
+			t.Errorf("VersionOf(%q): expected %v, got %v", tt.namespace, tt.want, got)
+		}
+	}
+}
+
+// TestParseVersion tests the version declared by parsed documents
+func TestParseVersion(t *testing.T) {
+	k, err := ParseBytes([]byte(`<kml xmlns="http://earth.google.com/kml/2.1"><Placemark/></kml>`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if k.Version() != Version21 {
+		t.Errorf("Expected version 2.1, got %v", k.Version())
+	}
+
+	k, err = ParseBytes([]byte(`<kml xmlns="http://www.opengis.net/kml/2.3"><Placemark><Track><when>2024-01-01T00:00:00Z</when><coord>1 2 3</coord></Track></Placemark></kml>`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if k.Version() != Version23 {
+		t.Errorf("Expected version 2.3, got %v", k.Version())
+	}
+	if tr, ok := k.Placemarks()[0].Geometry.(*Track); !ok || len(tr.Coords) != 1 {
+		t.Errorf("Expected a 2.3 Track to parse, got %#v", k.Placemarks()[0].Geometry)
+	}
+}
+
+// versionDocument returns a document using elements that differ between
+// versions
+func versionDocument() *KML {
+	k := NewKML()
+	k.Feature = &Document{Features: []Feature{
+		&Placemark{Name: "track", Geometry: &Track{
+			AltitudeMode: AltitudeModeClampToSeaFloor,
+			When:         []time.Time{time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+			Coords:       []Coordinate{{Lon: 1, Lat: 2, Alt: 3}},
+		}},
+		&GroundOverlay{Name: "scan", LatLonQuad: &LatLonQuad{Coordinates: Coordinates{{Lon: 0, Lat: 0}, {Lon: 1, Lat: 0}, {Lon: 1, Lat: 1}, {Lon: 0, Lat: 1}}}},
+		&NetworkLink{Name: "tiles", Link: &Link{Href: "tiles.kml"}},
+	}}
+	return k
+}
+
+// TestTargetVersion tests adapting output to a schema version
+func TestTargetVersion(t *testing.T) {
+	tests := []struct {
+		version Version
+		want    []string
+		absent  []string
+	}{
+		{
+			version: Version23,
+			want:    []string{`xmlns="http://www.opengis.net/kml/2.3"`, "<Track>", "<coord>1 2 3</coord>", "<LatLonQuad>", "<Link>", "clampToSeaFloor"},
+			absent:  []string{"gx:"},
+		},
+		{
+			version: Version22,
+			want:    []string{`xmlns="http://www.opengis.net/kml/2.2"`, "<gx:Track", "<gx:coord>", "<gx:LatLonQuad"},
+		},
+		{
+			version: Version21,
+			want:    []string{`xmlns="http://earth.google.com/kml/2.1"`, "<LineString>", "<altitudeMode>clampToGround</altitudeMode>", "<coordinates>1,2,3</coordinates>", "<Link>"},
+			absent:  []string{"gx:", "Track", "LatLonQuad", "<when>"},
+		},
+		{
+			version: Version20,
+			want:    []string{`xmlns="http://earth.google.com/kml/2.0"`, "<Url><href>tiles.kml</href></Url>"},
+			absent:  []string{"<Link>"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version.String(), func(t *testing.T) {
+			out, err := versionDocument().Bytes(TargetVersion(tt.version))
+			if err != nil {
+				t.Fatalf("Write failed: %v", err)
+			}
+			s := string(out)
+			for _, want := range tt.want {
+				if !strings.Contains(s, want) {
+					t.Errorf("Expected output to contain %s, got %s", want, s)
+				}
+			}
+			for _, absent := range tt.absent {
+				if strings.Contains(s, absent) {
+					t.Errorf("Expected output without %s, got %s", absent, s)
+				}
+			}
+
+			k, err := ParseBytes(out)
+			if err != nil {
+				t.Fatalf("Re-parse failed: %v", err)
+			}
+			if k.Version() != tt.version {
+				t.Errorf("Expected version %v after re-parse, got %v", tt.version, k.Version())
+			}
+		})
+	}
+}
+
+// TestVersionRule tests reporting what a version cannot represent
+func TestVersionRule(t *testing.T) {
+	k := versionDocument()
+	doc := k.Feature.(*Document)
+	doc.Features[0].(*Placemark).ExtendedData = &ExtendedData{Data: []Data{{Name: "a", Value: "1"}}}
+	doc.Features[0].(*Placemark).TimeStamp = &TimeStamp{When: time.Now()}
+	doc.Features = append(doc.Features, &Tour{Name: "Flyover"})
+
+	if findings := Lint(k, VersionRule(Version22), VersionRule(Version23)); len(findings) != 0 {
+		t.Errorf("Expected no findings for 2.2 and 2.3, got %v", findings)
+	}
+
+	var got []string
+	for _, f := range Lint(k, VersionRule(Version20)) {
+		got = append(got, f.Rule+": "+f.Message)
+	}
+	want := []string{
+		"version-2.0: TimeStamp and TimeSpan require KML 2.1",
+		"version-2.0: ExtendedData requires KML 2.2",
+		"version-2.0: gx:Track is written as a LineString without its times",
+		"version-2.0: sea-floor altitude modes are written as their ground equivalents",
+		"version-2.0: gx:LatLonQuad is dropped",
+		"version-2.0: gx:Tour is dropped",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Expected findings:\n%s\ngot:\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+}
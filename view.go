@@ -0,0 +1,201 @@
+package kml
+
+import "math"
+
+// LookAt positions the virtual camera to look at a point on or above the
+// ground from Range meters away. Heading is the compass direction the
+// camera faces, and Tilt its angle from straight down, both in degrees.
+type LookAt struct {
+	ID           string       `xml:"id,attr,omitempty"`
+	Longitude    float64      `xml:"longitude"`
+	Latitude     float64      `xml:"latitude"`
+	Altitude     float64      `xml:"altitude,omitempty"`
+	Heading      float64      `xml:"heading,omitempty"`
+	Tilt         float64      `xml:"tilt,omitempty"`
+	Range        float64      `xml:"range"`
+	AltitudeMode AltitudeMode `xml:"altitudeMode,omitempty"`
+}
+
+// Camera positions the virtual camera at a point in space. Heading is the
+// compass direction the camera faces, Tilt its angle from straight down
+// and Roll its rotation about the view direction, all in degrees.
+type Camera struct {
+	ID           string       `xml:"id,attr,omitempty"`
+	Longitude    float64      `xml:"longitude"`
+	Latitude     float64      `xml:"latitude"`
+	Altitude     float64      `xml:"altitude"`
+	Heading      float64      `xml:"heading,omitempty"`
+	Tilt         float64      `xml:"tilt,omitempty"`
+	Roll         float64      `xml:"roll,omitempty"`
+	AltitudeMode AltitudeMode `xml:"altitudeMode,omitempty"`
+}
+
+// Camera returns the Camera equivalent to the LookAt: placed Range meters
+// from the target, back along the heading and up by the tilt.
+func (l *LookAt) Camera() *Camera {
+	tilt := toRadians(l.Tilt)
+	target := Coordinate{Lon: l.Longitude, Lat: l.Latitude}
+	pos := target
+	if back := l.Range * math.Sin(tilt); back > 0 {
+		pos = target.Destination(normalizeBearing(l.Heading+180), back)
+	}
+	return &Camera{
+		Longitude:    pos.Lon,
+		Latitude:     pos.Lat,
+		Altitude:     l.Altitude + l.Range*math.Cos(tilt),
+		Heading:      l.Heading,
+		Tilt:         l.Tilt,
+		AltitudeMode: l.AltitudeMode,
+	}
+}
+
+// ViewOptions describes the viewer looking through a Camera.
+type ViewOptions struct {
+	HorizFov float64 // Horizontal field of view in degrees (default 60)
+	VertFov  float64 // Vertical field of view in degrees (default 45)
+}
+
+// defaults returns a copy of the options with zero values replaced by defaults.
+func (o ViewOptions) defaults() ViewOptions {
+	if o.HorizFov <= 0 {
+		o.HorizFov = 60
+	}
+	if o.VertFov <= 0 {
+		o.VertFov = 45
+	}
+	return o
+}
+
+// ViewBounds approximates the region of the ground visible from the camera
+// as a box. The footprint of the view on a flat ground plane is clipped at
+// the horizon, projected onto the globe and boxed; roll, terrain and the
+// curvature of the Earth within the footprint are ignored, so the box is
+// best used, as a NetworkLink BBOX is, to select candidate features. The
+// box crosses the antimeridian when West is greater than East, and is the
+// whole world when the horizon is more than a quarter of the way around
+// the globe or a pole may be in view.
+func (c *Camera) ViewBounds(opts ViewOptions) LatLonBox {
+	opts = opts.defaults()
+	world := LatLonBox{North: 90, South: -90, East: 180, West: -180}
+
+	h := math.Max(c.Altitude, 1)
+	horizon := math.Sqrt(h * (2*earthRadius + h))
+	if horizon > math.Pi*earthRadius/2 {
+		return world
+	}
+
+	tilt := math.Max(0, math.Min(90, c.Tilt))
+	halfV := opts.VertFov / 2
+	tanHalfH := math.Tan(toRadians(opts.HorizFov / 2))
+
+	// Ground distances ahead of the camera's nadir of the near and far
+	// edges of the view, and the half-widths of the view at each
+	far := horizon
+	if tilt+halfV < 90 {
+		far = math.Min(far, h*math.Tan(toRadians(tilt+halfV)))
+	}
+	near := math.Max(-horizon, h*math.Tan(toRadians(tilt-halfV)))
+	halfWidth := func(d float64) float64 { return math.Hypot(h, d) * tanHalfH }
+
+	nadir := Coordinate{Lon: c.Longitude, Lat: c.Latitude}
+	corners := []Coordinate{
+		offsetPoint(nadir, c.Heading, near, -halfWidth(near)),
+		offsetPoint(nadir, c.Heading, near, halfWidth(near)),
+		offsetPoint(nadir, c.Heading, far, -halfWidth(far)),
+		offsetPoint(nadir, c.Heading, far, halfWidth(far)),
+	}
+
+	reach := 0.0
+	for _, p := range corners {
+		reach = math.Max(reach, haversine(nadir, p))
+	}
+	if haversine(nadir, Coord(0, 90)) <= reach || haversine(nadir, Coord(0, -90)) <= reach {
+		return world
+	}
+
+	box := LatLonBox{North: -90, South: 90}
+	minOff, maxOff := 0.0, 0.0
+	for _, p := range corners {
+		box.North = math.Max(box.North, p.Lat)
+		box.South = math.Min(box.South, p.Lat)
+		off := wrapLon(p.Lon - nadir.Lon)
+		minOff, maxOff = math.Min(minOff, off), math.Max(maxOff, off)
+	}
+	box.North = math.Max(box.North, nadir.Lat)
+	box.South = math.Min(box.South, nadir.Lat)
+	box.West = wrapLon(nadir.Lon + minOff)
+	box.East = wrapLon(nadir.Lon + maxOff)
+	return box
+}
+
+// offsetPoint returns the point forward meters along bearing from c and
+// then right meters to its right. Negative distances go back or left.
+func offsetPoint(c Coordinate, bearing, forward, right float64) Coordinate {
+	if forward < 0 {
+		bearing, forward = bearing+180, -forward
+	}
+	p := c.Destination(normalizeBearing(bearing), forward)
+	side := bearing + 90
+	if right < 0 {
+		side, right = bearing-90, -right
+	}
+	return p.Destination(normalizeBearing(side), right)
+}
+
+// FeaturesInView returns the Placemarks and GroundOverlays whose bounds
+// intersect the approximate region visible from camera, in document order.
+// A LookAt can be converted with its Camera method.
+func (k *KML) FeaturesInView(camera *Camera, opts ViewOptions) []Feature {
+	return k.FeaturesInBox(camera.ViewBounds(opts))
+}
+
+// FeaturesInBox returns the Placemarks and GroundOverlays whose bounds
+// intersect box, in document order. A box with West greater than East
+// crosses the antimeridian, as NetworkLink BBOX parameters may.
+func (k *KML) FeaturesInBox(box LatLonBox) []Feature {
+	return k.Filter(func(f Feature) bool {
+		switch feature := f.(type) {
+		case *Placemark:
+			if feature.Geometry == nil {
+				return false
+			}
+			coords := getGeometryCoordinates(feature.Geometry)
+			if len(coords) == 0 {
+				return false
+			}
+			sw, ne := BoundsOf(coords)
+			return boxesIntersect(box, LatLonBox{North: ne.Lat, South: sw.Lat, East: ne.Lon, West: sw.Lon})
+		case *GroundOverlay:
+			return feature.LatLonBox != nil && boxesIntersect(box, *feature.LatLonBox)
+		}
+		return false
+	})
+}
+
+// boxesIntersect reports whether two boxes overlap, either of which may
+// cross the antimeridian. Rotation is ignored.
+func boxesIntersect(a, b LatLonBox) bool {
+	if a.South > b.North || b.South > a.North {
+		return false
+	}
+	return lonRangesIntersect(a.West, a.East, b.West, b.East)
+}
+
+// lonRangesIntersect reports whether two longitude ranges overlap; a range
+// with west greater than east wraps through 180.
+func lonRangesIntersect(w1, e1, w2, e2 float64) bool {
+	split := func(w, e float64) [][2]float64 {
+		if w <= e {
+			return [][2]float64{{w, e}}
+		}
+		return [][2]float64{{w, 180}, {-180, e}}
+	}
+	for _, r1 := range split(w1, e1) {
+		for _, r2 := range split(w2, e2) {
+			if r1[0] <= r2[1] && r2[0] <= r1[1] {
+				return true
+			}
+		}
+	}
+	return false
+}
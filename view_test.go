@@ -0,0 +1,115 @@
+package kml
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// TestLookAtRoundTrip tests parsing and writing views on features
+func TestLookAtRoundTrip(t *testing.T) {
+	data := `<kml><Document>
+  <LookAt><longitude>-122.08</longitude><latitude>37.42</latitude><heading>30</heading><tilt>45</tilt><range>1500</range><altitudeMode>relativeToGround</altitudeMode></LookAt>
+  <Placemark><name>A</name>
+    <Camera><longitude>-122.1</longitude><latitude>37.4</latitude><altitude>800</altitude><heading>10</heading><tilt>60</tilt><roll>5</roll></Camera>
+    <Point><coordinates>-122.08,37.42</coordinates></Point>
+  </Placemark>
+</Document></kml>`
+
+	k, err := ParseBytes([]byte(data))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	doc := k.Feature.(*Document)
+	want := LookAt{Longitude: -122.08, Latitude: 37.42, Heading: 30, Tilt: 45, Range: 1500, AltitudeMode: AltitudeModeRelativeToGround}
+	if doc.LookAt == nil || *doc.LookAt != want {
+		t.Errorf("Expected LookAt %+v, got %+v", want, doc.LookAt)
+	}
+	if c := k.Placemarks()[0].Camera; c == nil || c.Altitude != 800 || c.Roll != 5 {
+		t.Errorf("Expected Camera at 800 m with roll 5, got %+v", c)
+	}
+
+	out, err := k.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes failed: %v", err)
+	}
+	for _, s := range []string{"<LookAt><longitude>-122.08</longitude>", "<range>1500</range>", "<Camera><longitude>-122.1</longitude>", "<roll>5</roll>"} {
+		if !strings.Contains(string(out), s) {
+			t.Errorf("Expected output to contain %s, got %s", s, out)
+		}
+	}
+	if strings.Index(string(out), "<Camera>") > strings.Index(string(out), "<Point>") {
+		t.Error("Expected Camera to be written before the geometry")
+	}
+}
+
+// TestLookAtCamera tests converting a LookAt to a Camera
+func TestLookAtCamera(t *testing.T) {
+	l := &LookAt{Longitude: 10, Latitude: 50, Heading: 90, Tilt: 60, Range: 2000}
+	c := l.Camera()
+
+	if !floatNear(c.Altitude, 1000, 1e-6) {
+		t.Errorf("Expected altitude 1000, got %f", c.Altitude)
+	}
+	if c.Longitude >= 10 || !floatNear(c.Latitude, 50, 1e-3) {
+		t.Errorf("Expected camera west of the target, got %f, %f", c.Longitude, c.Latitude)
+	}
+	if d := haversine(Coord(c.Longitude, c.Latitude), Coord(10, 50)); !floatNear(d, 2000*math.Sin(toRadians(60)), 20) {
+		t.Errorf("Expected camera %f m from target, got %f", 2000*math.Sin(toRadians(60)), d)
+	}
+}
+
+// TestViewBounds tests the approximate visible region of a camera
+func TestViewBounds(t *testing.T) {
+	down := (&Camera{Longitude: 0, Latitude: 0, Altitude: 10000}).ViewBounds(ViewOptions{HorizFov: 90, VertFov: 90})
+	if !floatNear(down.North, -down.South, 1e-6) || !floatNear(down.East, -down.West, 1e-6) {
+		t.Errorf("Expected a box centered on the nadir, got %+v", down)
+	}
+	if d := haversine(Coord(0, 0), Coord(0, down.North)); !floatNear(d, 10000, 100) {
+		t.Errorf("Expected the box to reach 10 km north, got %f", d)
+	}
+
+	tilted := (&Camera{Longitude: 0, Latitude: 0, Altitude: 1000, Tilt: 70}).ViewBounds(ViewOptions{})
+	if tilted.North <= -tilted.South {
+		t.Errorf("Expected a tilted view to extend further ahead, got %+v", tilted)
+	}
+
+	if w := (&Camera{Altitude: 2e7}).ViewBounds(ViewOptions{}); w != (LatLonBox{North: 90, South: -90, East: 180, West: -180}) {
+		t.Errorf("Expected the whole world from orbit, got %+v", w)
+	}
+
+	dateline := (&Camera{Longitude: 179.99, Latitude: 0, Altitude: 10000}).ViewBounds(ViewOptions{})
+	if dateline.West <= dateline.East {
+		t.Errorf("Expected a box crossing the antimeridian, got %+v", dateline)
+	}
+}
+
+// TestFeaturesInView tests selecting features in view
+func TestFeaturesInView(t *testing.T) {
+	k := NewKML()
+	k.Feature = &Document{Features: []Feature{
+		pointPlacemark("near", 0.01, 0.01),
+		pointPlacemark("far", 20, 20),
+		&Placemark{Name: "line", Geometry: &LineString{Coordinates: []Coordinate{Coord(-1, -1), Coord(1, 1)}}},
+		&GroundOverlay{Name: "overlay", LatLonBox: &LatLonBox{North: 0.05, South: 0.02, East: 0.05, West: 0.02}},
+		pointPlacemark("east", -179.99, 0),
+	}}
+
+	var names []string
+	for _, f := range k.FeaturesInView(&Camera{Altitude: 10000}, ViewOptions{}) {
+		switch f := f.(type) {
+		case *Placemark:
+			names = append(names, f.Name)
+		case *GroundOverlay:
+			names = append(names, f.Name)
+		}
+	}
+	if strings.Join(names, ",") != "near,line,overlay" {
+		t.Errorf("Expected near,line,overlay, got %v", names)
+	}
+
+	wrapped := k.FeaturesInBox(LatLonBox{North: 1, South: -1, East: -179, West: 179})
+	if len(wrapped) != 1 || wrapped[0].(*Placemark).Name != "east" {
+		t.Errorf("Expected only east across the antimeridian, got %v", wrapped)
+	}
+}
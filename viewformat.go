@@ -0,0 +1,127 @@
+package kml
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// DefaultViewFormat is the viewFormat Google Earth uses when a Link has
+// none: the bounding box of the view.
+const DefaultViewFormat = "BBOX=[bboxWest],[bboxSouth],[bboxEast],[bboxNorth]"
+
+// FullViewFormat requests every view parameter, each under its own name, in
+// the form ParseViewFormat decodes. Set it as the ViewFormat of a Link to
+// receive the full view with each refresh.
+const FullViewFormat = DefaultViewFormat +
+	"&lookatLon=[lookatLon]&lookatLat=[lookatLat]&lookatRange=[lookatRange]" +
+	"&lookatTilt=[lookatTilt]&lookatHeading=[lookatHeading]" +
+	"&lookatTerrainLon=[lookatTerrainLon]&lookatTerrainLat=[lookatTerrainLat]&lookatTerrainAlt=[lookatTerrainAlt]" +
+	"&cameraLon=[cameraLon]&cameraLat=[cameraLat]&cameraAlt=[cameraAlt]" +
+	"&horizFov=[horizFov]&vertFov=[vertFov]&horizPixels=[horizPixels]&vertPixels=[vertPixels]" +
+	"&terrainEnabled=[terrainEnabled]"
+
+// ViewParams is the view of a client refreshing a NetworkLink, as sent in
+// the query string built from the Link's viewFormat. Fields whose
+// parameters are absent are nil or zero.
+type ViewParams struct {
+	BBOX          *LatLonBox  // BBOX=west,south,east,north
+	LookAt        *LookAt     // lookatLon, lookatLat, lookatRange, lookatTilt, lookatHeading
+	LookAtTerrain *Coordinate // lookatTerrainLon, lookatTerrainLat, lookatTerrainAlt
+	Camera        *Camera     // cameraLon, cameraLat, cameraAlt, with the heading and tilt of the LookAt
+	HorizFov      float64     // horizFov, in degrees
+	VertFov       float64     // vertFov, in degrees
+	HorizPixels   int         // horizPixels
+	VertPixels    int         // vertPixels
+	Terrain       bool        // terrainEnabled
+}
+
+// ParseViewFormat decodes the view parameters of a NetworkLink refresh
+// request, such as r.URL.Query() in an HTTP handler. It understands the
+// BBOX parameter of DefaultViewFormat and the parameters of FullViewFormat;
+// other parameters are ignored. It returns an error if a parameter is
+// present but malformed.
+func ParseViewFormat(q url.Values) (*ViewParams, error) {
+	p := &ViewParams{}
+
+	if s := q.Get("BBOX"); s != "" {
+		parts := strings.Split(s, ",")
+		if len(parts) != 4 {
+			return nil, fmt.Errorf("kml: invalid BBOX %q: expected west,south,east,north", s)
+		}
+		var v [4]float64
+		for i, part := range parts {
+			f, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+			if err != nil {
+				return nil, fmt.Errorf("kml: invalid BBOX %q: %w", s, err)
+			}
+			v[i] = f
+		}
+		p.BBOX = &LatLonBox{West: v[0], South: v[1], East: v[2], North: v[3]}
+	}
+
+	var errs []error
+	num := func(key string) (float64, bool) {
+		s := q.Get(key)
+		if s == "" {
+			return 0, false
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("kml: invalid %s %q: %w", key, s, err))
+			return 0, false
+		}
+		return f, true
+	}
+
+	lon, hasLon := num("lookatLon")
+	lat, hasLat := num("lookatLat")
+	rng, _ := num("lookatRange")
+	tilt, _ := num("lookatTilt")
+	heading, _ := num("lookatHeading")
+	if hasLon && hasLat {
+		p.LookAt = &LookAt{Longitude: lon, Latitude: lat, Range: rng, Tilt: tilt, Heading: heading}
+	}
+
+	lon, hasLon = num("lookatTerrainLon")
+	lat, hasLat = num("lookatTerrainLat")
+	alt, _ := num("lookatTerrainAlt")
+	if hasLon && hasLat {
+		c := Coord(lon, lat, alt)
+		p.LookAtTerrain = &c
+	}
+
+	lon, hasLon = num("cameraLon")
+	lat, hasLat = num("cameraLat")
+	alt, _ = num("cameraAlt")
+	if hasLon && hasLat {
+		p.Camera = &Camera{Longitude: lon, Latitude: lat, Altitude: alt, Heading: heading, Tilt: tilt}
+	}
+
+	p.HorizFov, _ = num("horizFov")
+	p.VertFov, _ = num("vertFov")
+	w, _ := num("horizPixels")
+	h, _ := num("vertPixels")
+	p.HorizPixels, p.VertPixels = int(w), int(h)
+
+	if s := q.Get("terrainEnabled"); s != "" {
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("kml: invalid terrainEnabled %q: %w", s, err))
+		}
+		p.Terrain = b
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// ViewOptions returns the fields of view of the request, for use with
+// Camera.ViewBounds and FeaturesInView; absent values take their defaults.
+func (p *ViewParams) ViewOptions() ViewOptions {
+	return ViewOptions{HorizFov: p.HorizFov, VertFov: p.VertFov}
+}
@@ -0,0 +1,77 @@
+package kml
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// TestParseViewFormat tests decoding view parameters
+func TestParseViewFormat(t *testing.T) {
+	query := strings.NewReplacer(
+		"[bboxWest]", "-122.5", "[bboxSouth]", "37.5", "[bboxEast]", "-122", "[bboxNorth]", "38",
+		"[lookatLon]", "-122.25", "[lookatLat]", "37.75", "[lookatRange]", "12000", "[lookatTilt]", "30", "[lookatHeading]", "15",
+		"[lookatTerrainLon]", "-122.25", "[lookatTerrainLat]", "37.75", "[lookatTerrainAlt]", "40",
+		"[cameraLon]", "-122.3", "[cameraLat]", "37.7", "[cameraAlt]", "10400",
+		"[horizFov]", "60", "[vertFov]", "40", "[horizPixels]", "1280", "[vertPixels]", "800",
+		"[terrainEnabled]", "1",
+	).Replace(FullViewFormat)
+	q, err := url.ParseQuery(query)
+	if err != nil {
+		t.Fatalf("ParseQuery failed: %v", err)
+	}
+
+	p, err := ParseViewFormat(q)
+	if err != nil {
+		t.Fatalf("ParseViewFormat failed: %v", err)
+	}
+	if p.BBOX == nil || *p.BBOX != (LatLonBox{West: -122.5, South: 37.5, East: -122, North: 38}) {
+		t.Errorf("Expected BBOX, got %+v", p.BBOX)
+	}
+	wantLookAt := LookAt{Longitude: -122.25, Latitude: 37.75, Range: 12000, Tilt: 30, Heading: 15}
+	if p.LookAt == nil || *p.LookAt != wantLookAt {
+		t.Errorf("Expected LookAt %+v, got %+v", wantLookAt, p.LookAt)
+	}
+	if p.LookAtTerrain == nil || *p.LookAtTerrain != Coord(-122.25, 37.75, 40) {
+		t.Errorf("Expected terrain point, got %+v", p.LookAtTerrain)
+	}
+	wantCamera := Camera{Longitude: -122.3, Latitude: 37.7, Altitude: 10400, Heading: 15, Tilt: 30}
+	if p.Camera == nil || *p.Camera != wantCamera {
+		t.Errorf("Expected Camera %+v, got %+v", wantCamera, p.Camera)
+	}
+	if p.HorizFov != 60 || p.VertFov != 40 || p.HorizPixels != 1280 || p.VertPixels != 800 || !p.Terrain {
+		t.Errorf("Expected view parameters, got %+v", p)
+	}
+	if opts := p.ViewOptions(); opts.HorizFov != 60 || opts.VertFov != 40 {
+		t.Errorf("Expected view options 60x40, got %+v", opts)
+	}
+}
+
+// TestParseViewFormatDefault tests the default BBOX-only format
+func TestParseViewFormatDefault(t *testing.T) {
+	p, err := ParseViewFormat(url.Values{"BBOX": {"179,-1,-179,1"}})
+	if err != nil {
+		t.Fatalf("ParseViewFormat failed: %v", err)
+	}
+	if p.BBOX == nil || p.BBOX.West != 179 || p.BBOX.East != -179 {
+		t.Errorf("Expected BBOX crossing the antimeridian, got %+v", p.BBOX)
+	}
+	if p.LookAt != nil || p.Camera != nil {
+		t.Errorf("Expected no LookAt or Camera, got %+v", p)
+	}
+}
+
+// TestParseViewFormatErrors tests malformed parameters
+func TestParseViewFormatErrors(t *testing.T) {
+	tests := []url.Values{
+		{"BBOX": {"1,2,3"}},
+		{"BBOX": {"1,2,3,north"}},
+		{"lookatLon": {"east"}, "lookatLat": {"1"}},
+		{"terrainEnabled": {"maybe"}},
+	}
+	for _, q := range tests {
+		if _, err := ParseViewFormat(q); err == nil {
+			t.Errorf("Expected error for %v", q)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package kml
+
+import "errors"
+
+// Visitor receives the features and geometries of a document from Accept,
+// one method per type, so a pass over the tree needs no type switch.
+// Embed BaseVisitor to implement only the methods of interest; methods
+// added for new feature types will then default to doing nothing.
+//
+// Returning SkipChildren from a method skips the child features of a
+// Document or Folder, the geometry of a Placemark or the parts of a
+// MultiGeometry. Any other error stops the traversal and is returned by
+// Accept.
+type Visitor interface {
+	VisitDocument(d *Document) error
+	VisitFolder(f *Folder) error
+	VisitPlacemark(p *Placemark) error
+	VisitGroundOverlay(g *GroundOverlay) error
+	VisitScreenOverlay(s *ScreenOverlay) error
+	VisitNetworkLink(n *NetworkLink) error
+	VisitTour(t *Tour) error
+
+	// VisitGeometry is called with each geometry of a Placemark, a
+	// MultiGeometry before its parts.
+	VisitGeometry(g Geometry) error
+}
+
+// SkipChildren is returned by a Visitor method to skip the children of the
+// feature being visited. Accept does not return it.
+var SkipChildren = errors.New("kml: skip children")
+
+// BaseVisitor implements Visitor with methods that do nothing.
+type BaseVisitor struct{}
+
+func (BaseVisitor) VisitDocument(*Document) error           { return nil }
+func (BaseVisitor) VisitFolder(*Folder) error               { return nil }
+func (BaseVisitor) VisitPlacemark(*Placemark) error         { return nil }
+func (BaseVisitor) VisitGroundOverlay(*GroundOverlay) error { return nil }
+func (BaseVisitor) VisitScreenOverlay(*ScreenOverlay) error { return nil }
+func (BaseVisitor) VisitNetworkLink(*NetworkLink) error     { return nil }
+func (BaseVisitor) VisitTour(*Tour) error                   { return nil }
+func (BaseVisitor) VisitGeometry(Geometry) error            { return nil }
+
+// Accept visits the document's feature and everything beneath it with v,
+// depth-first in document order.
+func (k *KML) Accept(v Visitor) error {
+	if k.Feature == nil {
+		return nil
+	}
+	return k.Feature.Accept(v)
+}
+
+// Accept implements the Feature interface.
+func (d *Document) Accept(v Visitor) error {
+	return acceptChildren(v.VisitDocument(d), d.Features, v)
+}
+
+// Accept implements the Feature interface.
+func (f *Folder) Accept(v Visitor) error {
+	return acceptChildren(v.VisitFolder(f), f.Features, v)
+}
+
+// Accept implements the Feature interface.
+func (p *Placemark) Accept(v Visitor) error {
+	if err := v.VisitPlacemark(p); err != nil {
+		return skipped(err)
+	}
+	if p.Geometry == nil {
+		return nil
+	}
+	return acceptGeometry(p.Geometry, v)
+}
+
+// Accept implements the Feature interface.
+func (g *GroundOverlay) Accept(v Visitor) error {
+	return skipped(v.VisitGroundOverlay(g))
+}
+
+// Accept implements the Feature interface.
+func (s *ScreenOverlay) Accept(v Visitor) error {
+	return skipped(v.VisitScreenOverlay(s))
+}
+
+// Accept implements the Feature interface.
+// The features the link loads are not fetched or visited.
+func (n *NetworkLink) Accept(v Visitor) error {
+	return skipped(v.VisitNetworkLink(n))
+}
+
+// Accept implements the Feature interface.
+func (t *Tour) Accept(v Visitor) error {
+	return skipped(v.VisitTour(t))
+}
+
+// acceptChildren visits features with v unless err, the result of visiting
+// their container, stops or skips them.
+func acceptChildren(err error, features []Feature, v Visitor) error {
+	if err != nil {
+		return skipped(err)
+	}
+	for _, child := range features {
+		if err := child.Accept(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// acceptGeometry visits g and, for a MultiGeometry, its parts.
+func acceptGeometry(g Geometry, v Visitor) error {
+	if err := v.VisitGeometry(g); err != nil {
+		return skipped(err)
+	}
+	if multi, ok := g.(*MultiGeometry); ok {
+		for _, child := range multi.Geometries {
+			if err := acceptGeometry(child, v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// skipped returns err, or nil if it is SkipChildren.
+func skipped(err error) error {
+	if err == SkipChildren {
+		return nil
+	}
+	return err
+}
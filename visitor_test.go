@@ -0,0 +1,93 @@
+package kml
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// recordingVisitor records the type and name of everything it visits
+type recordingVisitor struct {
+	BaseVisitor
+	visited []string
+	skip    string // Name of a feature whose children to skip
+}
+
+func (r *recordingVisitor) visit(kind, name string) error {
+	r.visited = append(r.visited, kind+":"+name)
+	if name != "" && name == r.skip {
+		return SkipChildren
+	}
+	return nil
+}
+
+func (r *recordingVisitor) VisitDocument(d *Document) error   { return r.visit("Document", d.Name) }
+func (r *recordingVisitor) VisitFolder(f *Folder) error       { return r.visit("Folder", f.Name) }
+func (r *recordingVisitor) VisitPlacemark(p *Placemark) error { return r.visit("Placemark", p.Name) }
+func (r *recordingVisitor) VisitNetworkLink(n *NetworkLink) error {
+	return r.visit("NetworkLink", n.Name)
+}
+func (r *recordingVisitor) VisitTour(t *Tour) error        { return r.visit("Tour", t.Name) }
+func (r *recordingVisitor) VisitGeometry(g Geometry) error { return r.visit(g.geometryType(), "") }
+
+// visitorDocument returns a small tree of features for visitor tests
+func visitorDocument() *KML {
+	k := NewKML()
+	k.Feature = &Document{Name: "doc", Features: []Feature{
+		&Folder{Name: "a", Features: []Feature{
+			&Placemark{Name: "p1", Geometry: &MultiGeometry{Geometries: []Geometry{&Point{}, &LineString{}}}},
+		}},
+		&GroundOverlay{Name: "g"},
+		&NetworkLink{Name: "n"},
+		&Placemark{Name: "p2", Geometry: &Polygon{}},
+	}}
+	return k
+}
+
+// TestAccept tests visiting features and geometries in document order
+func TestAccept(t *testing.T) {
+	tests := []struct {
+		skip string
+		want string
+	}{
+		{"", "Document:doc Folder:a Placemark:p1 MultiGeometry: Point: LineString: NetworkLink:n Placemark:p2 Polygon:"},
+		{"a", "Document:doc Folder:a NetworkLink:n Placemark:p2 Polygon:"},
+		{"p1", "Document:doc Folder:a Placemark:p1 NetworkLink:n Placemark:p2 Polygon:"},
+		{"doc", "Document:doc"},
+	}
+
+	for _, tt := range tests {
+		v := &recordingVisitor{skip: tt.skip}
+		if err := visitorDocument().Accept(v); err != nil {
+			t.Fatalf("Accept failed: %v", err)
+		}
+		if got := strings.Join(v.visited, " "); got != tt.want {
+			t.Errorf("Skipping %q: expected %s, got %s", tt.skip, tt.want, got)
+		}
+	}
+}
+
+// stoppingVisitor fails on the first Placemark
+type stoppingVisitor struct {
+	BaseVisitor
+	geometries int
+}
+
+var errStop = errors.New("stop")
+
+func (s *stoppingVisitor) VisitPlacemark(*Placemark) error { return errStop }
+func (s *stoppingVisitor) VisitGeometry(Geometry) error    { s.geometries++; return nil }
+
+// TestAcceptError tests that an error stops the traversal
+func TestAcceptError(t *testing.T) {
+	v := &stoppingVisitor{}
+	if err := visitorDocument().Accept(v); err != errStop {
+		t.Errorf("Expected errStop, got %v", err)
+	}
+	if v.geometries != 0 {
+		t.Errorf("Expected no geometries visited, got %d", v.geometries)
+	}
+	if err := NewKML().Accept(v); err != nil {
+		t.Errorf("Expected nil for an empty document, got %v", err)
+	}
+}
@@ -0,0 +1,162 @@
+package kml
+
+import "fmt"
+
+// Delaunay triangulates the Point placemarks in pms and returns a Folder
+// with one LineString placemark per triangulation edge, named after the
+// two placemarks it joins. Placemarks without a Point geometry are ignored,
+// as are repeated positions. The Folder is empty if fewer than three
+// non-collinear points are given.
+func Delaunay(pms []*Placemark) *Folder {
+	points, coords := pointPlacemarks(pms)
+
+	folder := &Folder{Name: "Delaunay"}
+	seen := make(map[edge]bool)
+	for _, t := range triangulate(coords) {
+		for _, e := range []edge{newEdge(t.a, t.b), newEdge(t.b, t.c), newEdge(t.c, t.a)} {
+			if seen[e] {
+				continue
+			}
+			seen[e] = true
+			folder.Features = append(folder.Features, &Placemark{
+				Name: fmt.Sprintf("%s - %s", points[e.a].Name, points[e.b].Name),
+				Geometry: &LineString{
+					Coordinates: []Coordinate{flatCoordinate(coords[e.a]), flatCoordinate(coords[e.b])},
+				},
+			})
+		}
+	}
+	return folder
+}
+
+// Voronoi returns a Folder with the Voronoi cell of each Point placemark in
+// pms as a Polygon placemark carrying the source placemark's name. Cells
+// are computed in a local planar projection and clipped to the box from sw
+// to ne; if sw and ne are both zero, the points' bounding box expanded by
+// 10% on each side is used. Placemarks without a Point geometry, and
+// repeats of an earlier position, get no cell.
+func Voronoi(pms []*Placemark, sw, ne Coordinate) *Folder {
+	points, coords := pointPlacemarks(pms)
+	folder := &Folder{Name: "Voronoi"}
+	if len(coords) == 0 {
+		return folder
+	}
+
+	if sw == (Coordinate{}) && ne == (Coordinate{}) {
+		sw, ne = ringBounds(coords)
+		dLon := (ne.Lon - sw.Lon) * 0.1
+		dLat := (ne.Lat - sw.Lat) * 0.1
+		if dLon == 0 {
+			dLon = 0.01
+		}
+		if dLat == 0 {
+			dLat = 0.01
+		}
+		sw = Coordinate{Lon: sw.Lon - dLon, Lat: sw.Lat - dLat}
+		ne = Coordinate{Lon: ne.Lon + dLon, Lat: ne.Lat + dLat}
+	}
+
+	proj := newLocalProjection(coords)
+	pts := make([]planarPoint, len(coords))
+	for i, c := range coords {
+		pts[i] = proj.forward(c)
+	}
+	box := []planarPoint{
+		proj.forward(Coordinate{Lon: sw.Lon, Lat: sw.Lat}),
+		proj.forward(Coordinate{Lon: ne.Lon, Lat: sw.Lat}),
+		proj.forward(Coordinate{Lon: ne.Lon, Lat: ne.Lat}),
+		proj.forward(Coordinate{Lon: sw.Lon, Lat: ne.Lat}),
+	}
+
+	// A cell is bounded by the bisectors with its Delaunay neighbors. With
+	// no triangulation (collinear or too few points), every other point is
+	// a neighbor.
+	tris := triangulate(coords)
+	neighbors := make([]map[int]bool, len(coords))
+	for i := range neighbors {
+		neighbors[i] = make(map[int]bool)
+	}
+	for _, t := range tris {
+		for _, e := range []edge{newEdge(t.a, t.b), newEdge(t.b, t.c), newEdge(t.c, t.a)} {
+			neighbors[e.a][e.b] = true
+			neighbors[e.b][e.a] = true
+		}
+	}
+
+	seen := make(map[[2]float64]bool)
+	for i, c := range coords {
+		key := [2]float64{c.Lon, c.Lat}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		cell := box
+		for j := range coords {
+			if j == i || coords[j].Lon == c.Lon && coords[j].Lat == c.Lat {
+				continue
+			}
+			if len(tris) > 0 && !neighbors[i][j] {
+				continue
+			}
+			cell = clipHalfPlane(cell, pts[i], pts[j])
+		}
+		if len(cell) < 3 {
+			continue
+		}
+
+		ring := make([]Coordinate, 0, len(cell)+1)
+		for _, p := range cell {
+			ring = append(ring, proj.inverse(p))
+		}
+		ring = append(ring, ring[0])
+		folder.Features = append(folder.Features, &Placemark{
+			Name:     points[i].Name,
+			Geometry: &Polygon{OuterBoundary: LinearRing{Coordinates: ring}},
+		})
+	}
+	return folder
+}
+
+// clipHalfPlane clips the convex polygon poly to the half-plane of points
+// closer to a than to b (Sutherland-Hodgman against their bisector).
+func clipHalfPlane(poly []planarPoint, a, b planarPoint) []planarPoint {
+	nx, ny := b.x-a.x, b.y-a.y
+	mx, my := (a.x+b.x)/2, (a.y+b.y)/2
+	side := func(p planarPoint) float64 {
+		return (p.x-mx)*nx + (p.y-my)*ny
+	}
+
+	var out []planarPoint
+	for i, cur := range poly {
+		prev := poly[(i+len(poly)-1)%len(poly)]
+		sc, sp := side(cur), side(prev)
+		if (sc <= 0) != (sp <= 0) {
+			t := sp / (sp - sc)
+			out = append(out, planarPoint{x: prev.x + (cur.x-prev.x)*t, y: prev.y + (cur.y-prev.y)*t})
+		}
+		if sc <= 0 {
+			out = append(out, cur)
+		}
+	}
+	return out
+}
+
+// pointPlacemarks returns the placemarks of pms with Point geometries and
+// their coordinates.
+func pointPlacemarks(pms []*Placemark) ([]*Placemark, []Coordinate) {
+	var points []*Placemark
+	var coords []Coordinate
+	for _, pm := range pms {
+		if c, ok := pointOf(pm); ok {
+			points = append(points, pm)
+			coords = append(coords, c)
+		}
+	}
+	return points, coords
+}
+
+// flatCoordinate returns c without its altitude.
+func flatCoordinate(c Coordinate) Coordinate {
+	return Coordinate{Lon: c.Lon, Lat: c.Lat}
+}
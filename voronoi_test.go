@@ -0,0 +1,98 @@
+package kml
+
+import (
+	"math"
+	"testing"
+)
+
+func voronoiTestPlacemarks() []*Placemark {
+	return []*Placemark{
+		pointPlacemark("a", 0, 0),
+		pointPlacemark("b", 0.1, 0),
+		pointPlacemark("c", 0.1, 0.1),
+		pointPlacemark("d", 0, 0.1),
+		pointPlacemark("e", 0.05, 0.05),
+		{Name: "no geometry"},
+	}
+}
+
+func TestDelaunay(t *testing.T) {
+	folder := Delaunay(voronoiTestPlacemarks())
+
+	// 4 triangles around a center point: 4 outer edges + 4 spokes.
+	if len(folder.Features) != 8 {
+		t.Fatalf("Expected 8 edges, got %d", len(folder.Features))
+	}
+	spokes := 0
+	for _, f := range folder.Features {
+		pm := f.(*Placemark)
+		ls, ok := pm.Geometry.(*LineString)
+		if !ok || len(ls.Coordinates) != 2 {
+			t.Fatalf("Expected 2-point LineString, got %#v", pm.Geometry)
+		}
+		for _, c := range ls.Coordinates {
+			if c == Coord(0.05, 0.05) {
+				spokes++
+			}
+		}
+	}
+	if spokes != 4 {
+		t.Errorf("Expected 4 edges to the center point, got %d", spokes)
+	}
+
+	if empty := Delaunay([]*Placemark{pointPlacemark("a", 0, 0)}); len(empty.Features) != 0 {
+		t.Errorf("Expected no edges for a single point, got %d", len(empty.Features))
+	}
+}
+
+func TestVoronoi(t *testing.T) {
+	pms := voronoiTestPlacemarks()
+	folder := Voronoi(pms, Coordinate{}, Coordinate{})
+	if len(folder.Features) != 5 {
+		t.Fatalf("Expected 5 cells, got %d", len(folder.Features))
+	}
+
+	var total float64
+	for _, f := range folder.Features {
+		pm := f.(*Placemark)
+		poly := pm.Geometry.(*Polygon)
+		src := pms[indexOfName(pms, pm.Name)]
+		if !poly.Contains(src.Geometry.(*Point).Coordinates) {
+			t.Errorf("Expected cell %q to contain its point", pm.Name)
+		}
+		if !poly.IsValid() {
+			t.Errorf("Expected cell %q to be valid: %v", pm.Name, poly.ValidityIssues())
+		}
+		total += math.Abs(signedArea(poly.OuterBoundary.Coordinates))
+	}
+
+	// Cells tile the bounding box expanded by 10%: 0.12 x 0.12 degrees.
+	if !floatNear(total, 0.12*0.12, 1e-6) {
+		t.Errorf("Expected cells to tile the box (area %v), got %v", 0.12*0.12, total)
+	}
+}
+
+func TestVoronoiExplicitBoundsAndFewPoints(t *testing.T) {
+	pms := []*Placemark{pointPlacemark("west", 0, 0), pointPlacemark("east", 1, 0)}
+	folder := Voronoi(pms, Coord(-1, -1), Coord(2, 1))
+	if len(folder.Features) != 2 {
+		t.Fatalf("Expected 2 cells, got %d", len(folder.Features))
+	}
+
+	west := folder.Features[0].(*Placemark).Geometry.(*Polygon)
+	if !west.Contains(Coord(0.4, 0.9)) || west.Contains(Coord(0.6, 0)) {
+		t.Error("Expected west cell to end at the bisector")
+	}
+	if a := math.Abs(signedArea(west.OuterBoundary.Coordinates)); !floatNear(a, 3, 0.05) {
+		t.Errorf("Expected west cell area ~3, got %v", a)
+	}
+}
+
+func indexOfName(pms []*Placemark, name string) int {
+	for i, pm := range pms {
+		if pm.Name == name {
+			return i
+		}
+	}
+	return -1
+}
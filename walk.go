@@ -1,9 +1,9 @@
 package kml
 
-import "math"
+import "context"
 
 // Walk traverses all features in a KML document depth-first.
-// The callback is called for each feature (Document, Folder, Placemark).
+// The callback is called for each feature (Document, Folder, Placemark, overlays, NetworkLink).
 // If the callback returns an error, traversal stops and the error is returned.
 func (k *KML) Walk(fn func(Feature) error) error {
 	if k.Feature == nil {
@@ -33,8 +33,9 @@ func walkFeature(f Feature, fn func(Feature) error) error {
 				return err
 			}
 		}
-	case *Placemark:
-		// Placemarks have no child features
+	case *Placemark, *GroundOverlay, *ScreenOverlay, *NetworkLink, *Tour:
+		// Placemarks, overlays, network links and tours have no child features;
+		// the features a NetworkLink loads are not fetched.
 	}
 
 	return nil
@@ -77,6 +78,26 @@ func (k *KML) FindByID(id string) Feature {
 				result = feature
 				return errStopWalk
 			}
+		case *GroundOverlay:
+			if feature.ID == id {
+				result = feature
+				return errStopWalk
+			}
+		case *NetworkLink:
+			if feature.ID == id {
+				result = feature
+				return errStopWalk
+			}
+		case *ScreenOverlay:
+			if feature.ID == id {
+				result = feature
+				return errStopWalk
+			}
+		case *Tour:
+			if feature.ID == id {
+				result = feature
+				return errStopWalk
+			}
 		}
 		return nil
 	})
@@ -84,6 +105,48 @@ func (k *KML) FindByID(id string) Feature {
 	return result
 }
 
+// featureID returns the id attribute of f.
+func featureID(f Feature) string {
+	switch feature := f.(type) {
+	case *Document:
+		return feature.ID
+	case *Folder:
+		return feature.ID
+	case *Placemark:
+		return feature.ID
+	case *GroundOverlay:
+		return feature.ID
+	case *NetworkLink:
+		return feature.ID
+	case *ScreenOverlay:
+		return feature.ID
+	case *Tour:
+		return feature.ID
+	}
+	return ""
+}
+
+// featureName returns the name of f.
+func featureName(f Feature) string {
+	switch feature := f.(type) {
+	case *Document:
+		return feature.Name
+	case *Folder:
+		return feature.Name
+	case *Placemark:
+		return feature.Name
+	case *GroundOverlay:
+		return feature.Name
+	case *NetworkLink:
+		return feature.Name
+	case *ScreenOverlay:
+		return feature.Name
+	case *Tour:
+		return feature.Name
+	}
+	return ""
+}
+
 // errStopWalk is a sentinel error used to stop walking.
 var errStopWalk = &struct{ error }{error: nil}
 
@@ -105,57 +168,30 @@ func (k *KML) Filter(fn func(Feature) bool) []Feature {
 // Bounds returns the southwest and northeast corners of a bounding box
 // containing all coordinates in the document.
 // Returns zero coordinates if the document contains no geometry.
+// After CacheBounds, the bounds of each feature are computed once.
 func (k *KML) Bounds() (sw, ne Coordinate) {
-	// Initialize with extreme values
-	minLon := math.MaxFloat64
-	maxLon := -math.MaxFloat64
-	minLat := math.MaxFloat64
-	maxLat := -math.MaxFloat64
-
-	hasCoords := false
-
-	// Walk all features and collect coordinates
-	k.Walk(func(f Feature) error {
-		coords := collectCoordinates(f)
-		for _, c := range coords {
-			hasCoords = true
-			if c.Lon < minLon {
-				minLon = c.Lon
-			}
-			if c.Lon > maxLon {
-				maxLon = c.Lon
-			}
-			if c.Lat < minLat {
-				minLat = c.Lat
-			}
-			if c.Lat > maxLat {
-				maxLat = c.Lat
-			}
+	if k.bounds != nil {
+		if k.Feature == nil {
+			return Coordinate{}, Coordinate{}
 		}
-		return nil
-	})
-
-	// Return zero coordinates if no geometry found
-	if !hasCoords {
-		return Coordinate{}, Coordinate{}
+		return k.featureBox(k.Feature).corners()
 	}
-
-	// SW corner = (minLon, minLat), NE corner = (maxLon, maxLat)
-	sw = Coordinate{Lon: minLon, Lat: minLat}
-	ne = Coordinate{Lon: maxLon, Lat: maxLat}
-
+	sw, ne, _ = k.BoundsContext(context.Background())
 	return sw, ne
 }
 
 // collectCoordinates extracts all coordinates from a feature.
 func collectCoordinates(f Feature) []Coordinate {
-	// Only placemarks have geometry
-	p, ok := f.(*Placemark)
-	if !ok || p.Geometry == nil {
-		return nil
+	switch feature := f.(type) {
+	case *Placemark:
+		if feature.Geometry == nil {
+			return nil
+		}
+		return getGeometryCoordinates(feature.Geometry)
+	case *GroundOverlay:
+		return feature.Corners()
 	}
-
-	return getGeometryCoordinates(p.Geometry)
+	return nil
 }
 
 // getGeometryCoordinates extracts coordinates from a geometry.
@@ -171,6 +207,9 @@ func getGeometryCoordinates(g Geometry) []Coordinate {
 	case *LinearRing:
 		return geom.Coordinates
 
+	case *Track:
+		return geom.Coords
+
 	case *Polygon:
 		// Collect coordinates from outer boundary
 		coords := geom.OuterBoundary.Coordinates
@@ -0,0 +1,60 @@
+package kml
+
+import (
+	"context"
+	"math"
+)
+
+// boundsCheckInterval is how many coordinates BoundsContext reads between
+// checks of its context.
+const boundsCheckInterval = 4096
+
+// WalkContext is like Walk but checks ctx before each feature, so long
+// traversals stop once a request is cancelled or its deadline passes. It
+// then returns ctx.Err() without calling fn again.
+func (k *KML) WalkContext(ctx context.Context, fn func(Feature) error) error {
+	return k.Walk(func(f Feature) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return fn(f)
+	})
+}
+
+// BoundsContext is like Bounds but checks ctx between features and every
+// few thousand coordinates within one, so documents with huge geometries
+// are abandoned promptly. If ctx is done it returns ctx.Err() and zero
+// coordinates.
+func (k *KML) BoundsContext(ctx context.Context) (sw, ne Coordinate, err error) {
+	minLon, maxLon := math.MaxFloat64, -math.MaxFloat64
+	minLat, maxLat := math.MaxFloat64, -math.MaxFloat64
+	hasCoords := false
+
+	err = k.WalkContext(ctx, func(f Feature) error {
+		for i, c := range collectCoordinates(f) {
+			if i > 0 && i%boundsCheckInterval == 0 {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+			}
+			hasCoords = true
+			if c.Lon < minLon {
+				minLon = c.Lon
+			}
+			if c.Lon > maxLon {
+				maxLon = c.Lon
+			}
+			if c.Lat < minLat {
+				minLat = c.Lat
+			}
+			if c.Lat > maxLat {
+				maxLat = c.Lat
+			}
+		}
+		return nil
+	})
+	if err != nil || !hasCoords {
+		return Coordinate{}, Coordinate{}, err
+	}
+	return Coordinate{Lon: minLon, Lat: minLat}, Coordinate{Lon: maxLon, Lat: maxLat}, nil
+}
@@ -0,0 +1,76 @@
+package kml
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// countdownContext is a context that is cancelled once Err has been called
+// a number of times
+type countdownContext struct {
+	context.Context
+	calls int
+}
+
+func (c *countdownContext) Err() error {
+	if c.calls == 0 {
+		return context.Canceled
+	}
+	c.calls--
+	return nil
+}
+
+// TestWalkContext tests stopping a walk when its context is cancelled
+func TestWalkContext(t *testing.T) {
+	k := NewKML()
+	k.Feature = &Document{Features: []Feature{&Placemark{Name: "a"}, &Placemark{Name: "b"}, &Placemark{Name: "c"}}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var visited []string
+	err := k.WalkContext(ctx, func(f Feature) error {
+		visited = append(visited, featureName(f))
+		if featureName(f) == "a" {
+			cancel()
+		}
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if len(visited) != 2 {
+		t.Errorf("Expected the walk to stop after a, got %v", visited)
+	}
+
+	if err := k.WalkContext(context.Background(), func(Feature) error { return nil }); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+// TestBoundsContext tests bounds with a live and a cancelled context
+func TestBoundsContext(t *testing.T) {
+	coords := make([]Coordinate, 3*boundsCheckInterval)
+	for i := range coords {
+		coords[i] = Coord(float64(i%360)-180, float64(i%90))
+	}
+	k := NewKML()
+	k.Feature = &Placemark{Geometry: &LineString{Coordinates: coords}}
+
+	sw, ne, err := k.BoundsContext(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if wantSW, wantNE := k.Bounds(); sw != wantSW || ne != wantNE || sw != Coord(-180, 0) || ne != Coord(179, 89) {
+		t.Errorf("Expected %v %v, got %v %v", wantSW, wantNE, sw, ne)
+	}
+
+	// One check before the placemark and one inside its coordinates pass
+	ctx := &countdownContext{Context: context.Background(), calls: 2}
+	sw, ne, err = k.BoundsContext(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled inside the geometry, got %v", err)
+	}
+	if sw != (Coordinate{}) || ne != (Coordinate{}) {
+		t.Errorf("Expected zero bounds when cancelled, got %v %v", sw, ne)
+	}
+}
@@ -0,0 +1,119 @@
+package kml
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// WalkParallel calls fn for every feature of the document, as Walk does,
+// using up to workers goroutines; workers of 0 or less means
+// runtime.GOMAXPROCS(0). It suits callbacks that wait on slow work per
+// placemark, such as geocoding or elevation lookups, and fn must be safe
+// to call concurrently.
+//
+// The root container, and while it holds nothing but a single container
+// that container too, is visited first on the calling goroutine. The
+// subtrees of its children are then walked concurrently, each depth-first
+// on one goroutine, so fn sees a feature after its parent but in no set
+// order otherwise. A subtree stops at the first error fn returns, and no
+// further subtrees are started; WalkParallel returns the errors of the
+// subtrees that failed, joined in document order.
+func (k *KML) WalkParallel(fn func(Feature) error, workers int) error {
+	units, err := parallelUnits(k, fn)
+	if err != nil {
+		return err
+	}
+	return walkUnits(units, workers, func(_ int, f Feature) error {
+		return fn(f)
+	})
+}
+
+// MapParallel calls fn for every feature of k, as WalkParallel does, and
+// returns the results in the order Walk visits the features. If fn returns
+// an error, MapParallel returns nil and the errors WalkParallel would.
+func MapParallel[T any](k *KML, workers int, fn func(Feature) (T, error)) ([]T, error) {
+	var results []T
+	units, err := parallelUnits(k, func(f Feature) error {
+		v, err := fn(f)
+		results = append(results, v)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	parts := make([][]T, len(units))
+	err = walkUnits(units, workers, func(unit int, f Feature) error {
+		v, err := fn(f)
+		parts[unit] = append(parts[unit], v)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, part := range parts {
+		results = append(results, part...)
+	}
+	return results, nil
+}
+
+// parallelUnits visits the root container of k, and any chain of single
+// containers below it, with fn and returns the children whose subtrees are
+// walked concurrently.
+func parallelUnits(k *KML, fn func(Feature) error) ([]Feature, error) {
+	f := k.Feature
+	for f != nil {
+		if err := fn(f); err != nil {
+			return nil, err
+		}
+		children := childrenOf(f)
+		if children == nil {
+			return nil, nil
+		}
+		if len(*children) != 1 || childrenOf((*children)[0]) == nil {
+			return *children, nil
+		}
+		f = (*children)[0]
+	}
+	return nil, nil
+}
+
+// walkUnits walks the subtree of each unit with fn, which is given the
+// index of the unit, on up to workers goroutines.
+func walkUnits(units []Feature, workers int, fn func(unit int, f Feature) error) error {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	workers = min(workers, len(units))
+
+	errs := make([]error, len(units))
+	var failed atomic.Bool
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				err := walkFeature(units[i], func(f Feature) error {
+					return fn(i, f)
+				})
+				if err != nil {
+					errs[i] = err
+					failed.Store(true)
+				}
+			}
+		}()
+	}
+	for i := range units {
+		if failed.Load() {
+			break
+		}
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	return errors.Join(errs...)
+}
@@ -0,0 +1,124 @@
+package kml
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// parallelKML returns a document of folders holding numbered placemarks
+// inside a single top-level folder
+func parallelKML(folders, placemarks int) *KML {
+	top := &Folder{Name: "top"}
+	for i := range folders {
+		folder := &Folder{Name: fmt.Sprintf("f%d", i)}
+		for j := range placemarks {
+			folder.Features = append(folder.Features, &Placemark{Name: fmt.Sprintf("p%d.%d", i, j)})
+		}
+		top.Features = append(top.Features, folder)
+	}
+	k := NewKML()
+	k.Feature = &Document{Name: "doc", Features: []Feature{top}}
+	return k
+}
+
+// TestWalkParallel tests visiting every feature concurrently
+func TestWalkParallel(t *testing.T) {
+	k := parallelKML(8, 5)
+
+	var want []string
+	k.Walk(func(f Feature) error {
+		want = append(want, featureName(f))
+		return nil
+	})
+
+	var mu sync.Mutex
+	seen := make(map[string]int)
+	err := k.WalkParallel(func(f Feature) error {
+		mu.Lock()
+		seen[featureName(f)]++
+		mu.Unlock()
+		return nil
+	}, 3)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(seen) != len(want) {
+		t.Errorf("Expected %d features, got %d", len(want), len(seen))
+	}
+	for name, n := range seen {
+		if n != 1 {
+			t.Errorf("Expected %s visited once, got %d", name, n)
+		}
+	}
+
+	names, err := MapParallel(k, 0, func(f Feature) (string, error) {
+		return featureName(f), nil
+	})
+	if err != nil || !reflect.DeepEqual(names, want) {
+		t.Errorf("Expected %v in walk order, got %v, %v", want, names, err)
+	}
+}
+
+// TestWalkParallelConcurrent tests that subtrees run at the same time
+func TestWalkParallelConcurrent(t *testing.T) {
+	k := parallelKML(2, 1)
+	var arrived sync.WaitGroup
+	arrived.Add(2)
+	both := make(chan struct{})
+	go func() {
+		arrived.Wait()
+		close(both)
+	}()
+
+	err := k.WalkParallel(func(f Feature) error {
+		if _, ok := f.(*Placemark); !ok {
+			return nil
+		}
+		arrived.Done()
+		select {
+		case <-both:
+			return nil
+		case <-time.After(5 * time.Second):
+			return errors.New("subtrees were walked one at a time")
+		}
+	}, 2)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+// TestWalkParallelErrors tests joining subtree errors in document order
+func TestWalkParallelErrors(t *testing.T) {
+	k := parallelKML(4, 3)
+	errB := errors.New("b")
+	errD := errors.New("d")
+
+	err := k.WalkParallel(func(f Feature) error {
+		switch featureName(f) {
+		case "p1.0":
+			return errB
+		case "p3.2":
+			return errD
+		}
+		return nil
+	}, 4)
+	if !errors.Is(err, errB) {
+		t.Errorf("Expected the error of the second folder, got %v", err)
+	}
+	if errors.Is(err, errD) && err.Error() != "b\nd" {
+		t.Errorf("Expected errors in document order, got %q", err)
+	}
+
+	if _, err := MapParallel(k, 1, func(f Feature) (int, error) {
+		if featureName(f) == "top" {
+			return 0, errB
+		}
+		return 1, nil
+	}); err != errB {
+		t.Errorf("Expected the root error, got %v", err)
+	}
+}
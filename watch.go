@@ -0,0 +1,261 @@
+package kml
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Notifier reports changes to files. Watch uses PollNotifier unless given
+// another; an fsnotify watcher, for example, can be adapted by adding path
+// to it and forwarding the names of the files in its events.
+type Notifier interface {
+	// Notify starts watching path, a file or a directory, and returns a
+	// channel that receives the path of each file that changes, is
+	// created or is removed. The channel is closed once ctx is done.
+	Notify(ctx context.Context, path string) (<-chan string, error)
+}
+
+// PollNotifier returns a Notifier that checks the modification time and
+// size of the watched files every interval. It needs nothing but the
+// standard library and works on any file system at the cost of noticing
+// changes up to an interval late. A non-positive interval means the
+// default of WatchOptions, 500ms.
+func PollNotifier(interval time.Duration) Notifier {
+	if interval <= 0 {
+		interval = 500 * time.Millisecond
+	}
+	return pollNotifier{interval: interval}
+}
+
+// pollNotifier implements PollNotifier.
+type pollNotifier struct {
+	interval time.Duration
+}
+
+// fileState is what a pollNotifier compares to detect a change.
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// Notify implements the Notifier interface.
+func (n pollNotifier) Notify(ctx context.Context, path string) (<-chan string, error) {
+	before, err := snapshotFiles(path)
+	if err != nil {
+		return nil, err
+	}
+	changes := make(chan string)
+	go func() {
+		defer close(changes)
+		ticker := time.NewTicker(n.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			after, err := snapshotFiles(path)
+			if err != nil {
+				after = map[string]fileState{}
+			}
+			for _, changed := range changedFiles(before, after) {
+				select {
+				case changes <- changed:
+				case <-ctx.Done():
+					return
+				}
+			}
+			before = after
+		}
+	}()
+	return changes, nil
+}
+
+// snapshotFiles records the state of path, or of the KML files directly in
+// it if it is a directory.
+func snapshotFiles(path string) (map[string]fileState, error) {
+	files := make(map[string]fileState)
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return files, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		files[path] = fileState{info.ModTime(), info.Size()}
+		return files, nil
+	}
+
+	names, err := kmlFiles(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		if info, err := os.Stat(name); err == nil {
+			files[name] = fileState{info.ModTime(), info.Size()}
+		}
+	}
+	return files, nil
+}
+
+// changedFiles returns the files added, removed or changed between two
+// snapshots, sorted.
+func changedFiles(before, after map[string]fileState) []string {
+	var changed []string
+	for name, state := range after {
+		if old, ok := before[name]; !ok || !old.modTime.Equal(state.modTime) || old.size != state.size {
+			changed = append(changed, name)
+		}
+	}
+	for name := range before {
+		if _, ok := after[name]; !ok {
+			changed = append(changed, name)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// kmlFiles returns the KML, KMZ and gzipped KML files directly in dir,
+// sorted by name.
+func kmlFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && isKMLFile(entry.Name()) {
+			names = append(names, filepath.Join(dir, entry.Name()))
+		}
+	}
+	return names, nil
+}
+
+// isKMLFile reports whether name has the extension of a file Parse reads.
+func isKMLFile(name string) bool {
+	name = strings.ToLower(name)
+	return strings.HasSuffix(name, ".kml") || strings.HasSuffix(name, ".kmz") || strings.HasSuffix(name, ".kml.gz")
+}
+
+// WatchOptions configures Watch.
+type WatchOptions struct {
+	// Notifier reports changes. The default polls every 500ms.
+	Notifier Notifier
+
+	// Debounce is how long Watch waits after a change for more before
+	// parsing, so that a file written in several steps is parsed once.
+	// The default is 50ms.
+	Debounce time.Duration
+
+	// ParseOptions are passed to ParseFile.
+	ParseOptions []ParseOption
+}
+
+// defaults returns o with zero fields set to their defaults.
+func (o WatchOptions) defaults() WatchOptions {
+	if o.Notifier == nil {
+		o.Notifier = PollNotifier(500 * time.Millisecond)
+	}
+	if o.Debounce == 0 {
+		o.Debounce = 50 * time.Millisecond
+	}
+	return o
+}
+
+// Watch parses the KML file at path and calls fn with the result, then
+// parses it again and calls fn each time it changes, until ctx is done,
+// when it returns ctx.Err(). If path is a directory, each KML, KMZ and
+// gzipped KML file directly in it is parsed at the start and whenever it
+// changes, one call of fn per file; watch files one by one to tell their
+// documents apart. Parse failures, including of files removed or caught
+// half-written, are passed to fn rather than stopping the watch, and name
+// the file. This suits tools that live-preview a document being edited
+// elsewhere:
+//
+//	err := kml.Watch(ctx, "draft.kml", func(k *kml.KML, err error) {
+//		if err != nil {
+//			log.Print(err)
+//			return
+//		}
+//		preview.Show(k)
+//	}, kml.WatchOptions{})
+//
+// Calls of fn are made one at a time, from the goroutine that called
+// Watch. An error is returned without calling fn if the watch cannot be
+// started.
+func Watch(ctx context.Context, path string, fn func(*KML, error), opts WatchOptions) error {
+	opts = opts.defaults()
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	files := []string{path}
+	if info.IsDir() {
+		if files, err = kmlFiles(path); err != nil {
+			return err
+		}
+	}
+	changes, err := opts.Notifier.Notify(ctx, path)
+	if err != nil {
+		return err
+	}
+
+	parse := func(name string) {
+		fn(ParseFile(name, opts.ParseOptions...))
+	}
+	for _, name := range files {
+		parse(name)
+	}
+
+	for {
+		var changed string
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case name, ok := <-changes:
+			if !ok {
+				<-ctx.Done()
+				return ctx.Err()
+			}
+			changed = name
+		}
+
+		pending := []string{changed}
+		seen := map[string]bool{changed: true}
+		timer := time.NewTimer(opts.Debounce)
+	debounce:
+		for {
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case name, ok := <-changes:
+				if !ok {
+					break debounce
+				}
+				if !seen[name] {
+					seen[name] = true
+					pending = append(pending, name)
+				}
+			case <-timer.C:
+				break debounce
+			}
+		}
+		timer.Stop()
+
+		for _, name := range pending {
+			if info.IsDir() && !isKMLFile(name) {
+				continue
+			}
+			parse(name)
+		}
+	}
+}
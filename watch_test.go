@@ -0,0 +1,205 @@
+package kml
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// chanNotifier is a Notifier whose changes are sent by the test.
+type chanNotifier chan string
+
+func (n chanNotifier) Notify(ctx context.Context, path string) (<-chan string, error) {
+	out := make(chan string)
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case name := <-n:
+				select {
+				case out <- name:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+// watchResult is one call of a Watch callback.
+type watchResult struct {
+	name string
+	err  error
+}
+
+// startWatch runs Watch on path in the background, returning the results
+// of its calls and a function that stops it and returns its error.
+func startWatch(t *testing.T, path string, opts WatchOptions) (<-chan watchResult, func() error) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	results := make(chan watchResult, 16)
+	done := make(chan error, 1)
+	go func() {
+		done <- Watch(ctx, path, func(k *KML, err error) {
+			r := watchResult{err: err}
+			if k != nil {
+				r.name = featureName(k.Feature)
+			}
+			results <- r
+		}, opts)
+	}()
+	return results, func() error {
+		cancel()
+		return <-done
+	}
+}
+
+// nextResult waits for the next callback result.
+func nextResult(t *testing.T, results <-chan watchResult) watchResult {
+	t.Helper()
+	select {
+	case r := <-results:
+		return r
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for Watch")
+		return watchResult{}
+	}
+}
+
+// TestWatchPolling tests re-parsing a file when polling notices it change
+func TestWatchPolling(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "draft.kml")
+	if err := os.WriteFile(path, placemarkKML("first"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	results, stop := startWatch(t, path, WatchOptions{Notifier: PollNotifier(10 * time.Millisecond), Debounce: time.Millisecond})
+	if r := nextResult(t, results); r.err != nil || r.name != "first" {
+		t.Errorf("Expected first, got %+v", r)
+	}
+
+	if err := os.WriteFile(path, placemarkKML("second version"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if r := nextResult(t, results); r.err != nil || r.name != "second version" {
+		t.Errorf("Expected second version, got %+v", r)
+	}
+
+	if err := os.WriteFile(path, []byte("<kml><Placemark>"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if r := nextResult(t, results); r.err == nil || !strings.Contains(r.err.Error(), path) {
+		t.Errorf("Expected parse error naming the file, got %+v", r)
+	}
+
+	if err := stop(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+// TestWatchDirectory tests watching the KML files of a directory
+func TestWatchDirectory(t *testing.T) {
+	dir := t.TempDir()
+	for name, data := range map[string][]byte{
+		"a.kml":     placemarkKML("a"),
+		"b.kml.gz":  gzipBytes(t, placemarkKML("b")),
+		"notes.txt": []byte("not kml"),
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	n := make(chanNotifier)
+	results, stop := startWatch(t, dir, WatchOptions{Notifier: n, Debounce: 20 * time.Millisecond})
+	for _, want := range []string{"a", "b"} {
+		if r := nextResult(t, results); r.err != nil || r.name != want {
+			t.Errorf("Expected initial %s, got %+v", want, r)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "a.kml"), placemarkKML("a2"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// Repeated and non-KML changes within the debounce interval are dropped.
+	n <- filepath.Join(dir, "a.kml")
+	n <- filepath.Join(dir, "notes.txt")
+	n <- filepath.Join(dir, "a.kml")
+	if r := nextResult(t, results); r.err != nil || r.name != "a2" {
+		t.Errorf("Expected a2, got %+v", r)
+	}
+
+	n <- filepath.Join(dir, "gone.kml")
+	if r := nextResult(t, results); r.err == nil {
+		t.Errorf("Expected error for removed file, got %+v", r)
+	}
+
+	stop()
+	select {
+	case r := <-results:
+		t.Errorf("Expected no more calls, got %+v", r)
+	default:
+	}
+}
+
+// TestWatchErrors tests watches that cannot start
+func TestWatchErrors(t *testing.T) {
+	err := Watch(context.Background(), filepath.Join(t.TempDir(), "none.kml"), func(*KML, error) {
+		t.Error("Expected no call")
+	}, WatchOptions{})
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected os.ErrNotExist, got %v", err)
+	}
+}
+
+// TestPollNotifierInterval tests defaulting non-positive poll intervals
+func TestPollNotifierInterval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.kml")
+	if err := os.WriteFile(path, []byte("<kml/>"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, interval := range []time.Duration{0, -time.Second} {
+		n := PollNotifier(interval)
+		if got := n.(pollNotifier).interval; got != 500*time.Millisecond {
+			t.Errorf("PollNotifier(%v): expected 500ms, got %v", interval, got)
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		changes, err := n.Notify(ctx, path)
+		if err != nil {
+			t.Fatalf("Notify failed: %v", err)
+		}
+		cancel()
+		for range changes {
+		}
+	}
+}
+
+// TestChangedFiles tests comparing poll snapshots
+func TestChangedFiles(t *testing.T) {
+	now := time.Now()
+	before := map[string]fileState{
+		"same":    {now, 1},
+		"touched": {now, 1},
+		"grown":   {now, 1},
+		"removed": {now, 1},
+	}
+	after := map[string]fileState{
+		"same":    {now, 1},
+		"touched": {now.Add(time.Second), 1},
+		"grown":   {now, 2},
+		"added":   {now, 1},
+	}
+	got := changedFiles(before, after)
+	want := []string{"added", "grown", "removed", "touched"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,150 @@
+package kml
+
+import "math"
+
+// WGS84 ellipsoid parameters.
+const (
+	wgs84A = 6378137.0         // semi-major axis in meters
+	wgs84F = 1 / 298.257223563 // flattening
+	wgs84B = wgs84A * (1 - wgs84F)
+)
+
+// vincentyMaxIterations bounds the Vincenty iterations, which fail to
+// converge only for nearly antipodal points.
+const vincentyMaxIterations = 200
+
+// DistanceTo returns the distance in meters from c to other along the WGS84
+// ellipsoid, using Vincenty's inverse formula (accurate to within a
+// millimeter). For nearly antipodal points, where the formula does not
+// converge, the great-circle distance is returned. Altitude is ignored.
+func (c Coordinate) DistanceTo(other Coordinate) float64 {
+	dist, _, ok := vincentyInverse(c, other)
+	if !ok {
+		return haversine(c, other)
+	}
+	return dist
+}
+
+// BearingTo returns the initial bearing in degrees clockwise from true
+// north, in the range [0, 360), of the WGS84 geodesic from c to other. It
+// returns 0 if the points coincide.
+func (c Coordinate) BearingTo(other Coordinate) float64 {
+	_, bearing, ok := vincentyInverse(c, other)
+	if !ok {
+		// Great-circle initial bearing.
+		lat1, lat2 := toRadians(c.Lat), toRadians(other.Lat)
+		dLon := toRadians(other.Lon - c.Lon)
+		y := math.Sin(dLon) * math.Cos(lat2)
+		x := math.Cos(lat1)*math.Sin(lat2) - math.Sin(lat1)*math.Cos(lat2)*math.Cos(dLon)
+		bearing = toDegrees(math.Atan2(y, x))
+	}
+	return normalizeBearing(bearing)
+}
+
+// Destination returns the point reached by travelling meters along the
+// WGS84 geodesic from c with the given initial bearing in degrees, using
+// Vincenty's direct formula. The altitude of c is kept.
+func (c Coordinate) Destination(bearing, meters float64) Coordinate {
+	alpha1 := toRadians(bearing)
+	sinAlpha1, cosAlpha1 := math.Sin(alpha1), math.Cos(alpha1)
+
+	tanU1 := (1 - wgs84F) * math.Tan(toRadians(c.Lat))
+	cosU1 := 1 / math.Sqrt(1+tanU1*tanU1)
+	sinU1 := tanU1 * cosU1
+
+	sigma1 := math.Atan2(tanU1, cosAlpha1)
+	sinAlpha := cosU1 * sinAlpha1
+	cosSqAlpha := 1 - sinAlpha*sinAlpha
+	uSq := cosSqAlpha * (wgs84A*wgs84A - wgs84B*wgs84B) / (wgs84B * wgs84B)
+	A := 1 + uSq/16384*(4096+uSq*(-768+uSq*(320-175*uSq)))
+	B := uSq / 1024 * (256 + uSq*(-128+uSq*(74-47*uSq)))
+
+	sigma := meters / (wgs84B * A)
+	var sinSigma, cosSigma, cos2SigmaM float64
+	for i := 0; i < vincentyMaxIterations; i++ {
+		cos2SigmaM = math.Cos(2*sigma1 + sigma)
+		sinSigma, cosSigma = math.Sin(sigma), math.Cos(sigma)
+		deltaSigma := B * sinSigma * (cos2SigmaM + B/4*(cosSigma*(-1+2*cos2SigmaM*cos2SigmaM)-
+			B/6*cos2SigmaM*(-3+4*sinSigma*sinSigma)*(-3+4*cos2SigmaM*cos2SigmaM)))
+		next := meters/(wgs84B*A) + deltaSigma
+		if math.Abs(next-sigma) < 1e-12 {
+			sigma = next
+			break
+		}
+		sigma = next
+	}
+	cos2SigmaM = math.Cos(2*sigma1 + sigma)
+	sinSigma, cosSigma = math.Sin(sigma), math.Cos(sigma)
+
+	x := sinU1*sinSigma - cosU1*cosSigma*cosAlpha1
+	lat := math.Atan2(sinU1*cosSigma+cosU1*sinSigma*cosAlpha1, (1-wgs84F)*math.Sqrt(sinAlpha*sinAlpha+x*x))
+	lambda := math.Atan2(sinSigma*sinAlpha1, cosU1*cosSigma-sinU1*sinSigma*cosAlpha1)
+	C := wgs84F / 16 * cosSqAlpha * (4 + wgs84F*(4-3*cosSqAlpha))
+	L := lambda - (1-C)*wgs84F*sinAlpha*(sigma+C*sinSigma*(cos2SigmaM+C*cosSigma*(-1+2*cos2SigmaM*cos2SigmaM)))
+
+	lon := math.Mod(c.Lon+toDegrees(L)+540, 360) - 180
+	return Coordinate{Lon: lon, Lat: toDegrees(lat), Alt: c.Alt}
+}
+
+// vincentyInverse solves the inverse geodesic problem on the WGS84
+// ellipsoid, returning the distance in meters and initial bearing in
+// degrees. It reports false if the iteration does not converge.
+func vincentyInverse(a, b Coordinate) (dist, bearing float64, ok bool) {
+	L := toRadians(b.Lon - a.Lon)
+	tanU1 := (1 - wgs84F) * math.Tan(toRadians(a.Lat))
+	cosU1 := 1 / math.Sqrt(1+tanU1*tanU1)
+	sinU1 := tanU1 * cosU1
+	tanU2 := (1 - wgs84F) * math.Tan(toRadians(b.Lat))
+	cosU2 := 1 / math.Sqrt(1+tanU2*tanU2)
+	sinU2 := tanU2 * cosU2
+
+	lambda := L
+	var sinLambda, cosLambda, sinSigma, cosSigma, sigma, cosSqAlpha, cos2SigmaM float64
+	converged := false
+	for i := 0; i < vincentyMaxIterations; i++ {
+		sinLambda, cosLambda = math.Sin(lambda), math.Cos(lambda)
+		t1 := cosU2 * sinLambda
+		t2 := cosU1*sinU2 - sinU1*cosU2*cosLambda
+		sinSigma = math.Sqrt(t1*t1 + t2*t2)
+		if sinSigma == 0 {
+			return 0, 0, true // coincident points
+		}
+		cosSigma = sinU1*sinU2 + cosU1*cosU2*cosLambda
+		sigma = math.Atan2(sinSigma, cosSigma)
+		sinAlpha := cosU1 * cosU2 * sinLambda / sinSigma
+		cosSqAlpha = 1 - sinAlpha*sinAlpha
+		cos2SigmaM = 0 // equatorial line
+		if cosSqAlpha != 0 {
+			cos2SigmaM = cosSigma - 2*sinU1*sinU2/cosSqAlpha
+		}
+		C := wgs84F / 16 * cosSqAlpha * (4 + wgs84F*(4-3*cosSqAlpha))
+		prev := lambda
+		lambda = L + (1-C)*wgs84F*sinAlpha*(sigma+C*sinSigma*(cos2SigmaM+C*cosSigma*(-1+2*cos2SigmaM*cos2SigmaM)))
+		if math.Abs(lambda-prev) < 1e-12 {
+			converged = true
+			break
+		}
+	}
+	if !converged {
+		return 0, 0, false
+	}
+
+	uSq := cosSqAlpha * (wgs84A*wgs84A - wgs84B*wgs84B) / (wgs84B * wgs84B)
+	A := 1 + uSq/16384*(4096+uSq*(-768+uSq*(320-175*uSq)))
+	B := uSq / 1024 * (256 + uSq*(-128+uSq*(74-47*uSq)))
+	deltaSigma := B * sinSigma * (cos2SigmaM + B/4*(cosSigma*(-1+2*cos2SigmaM*cos2SigmaM)-
+		B/6*cos2SigmaM*(-3+4*sinSigma*sinSigma)*(-3+4*cos2SigmaM*cos2SigmaM)))
+
+	dist = wgs84B * A * (sigma - deltaSigma)
+	bearing = toDegrees(math.Atan2(cosU2*sinLambda, cosU1*sinU2-sinU1*cosU2*cosLambda))
+	return dist, bearing, true
+}
+
+// normalizeBearing maps a bearing in degrees to the range [0, 360).
+func normalizeBearing(deg float64) float64 {
+	deg = math.Mod(deg, 360)
+	if deg < 0 {
+		deg += 360
+	}
+	return deg
+}
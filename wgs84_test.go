@@ -0,0 +1,82 @@
+package kml
+
+import "testing"
+
+// Reference values from Vincenty's 1975 paper and GeographicLib.
+func TestCoordinateDistanceTo(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b Coordinate
+		want float64
+		tol  float64
+	}{
+		{"same point", Coord(10, 20), Coord(10, 20), 0, 0},
+		{"one degree of latitude at equator", Coord(0, 0), Coord(0, 1), 110574.389, 0.01},
+		{"one degree of longitude at equator", Coord(0, 0), Coord(1, 0), 111319.491, 0.01},
+		{"Flinders Peak to Buninyong", Coord(144.42486788888889, -37.951033416666665), Coord(143.92649552777778, -37.65282113888889), 54972.271, 0.01},
+		{"nearly antipodal falls back", Coord(0, 0), Coord(179.7, 0.5), 19936288, 50000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.a.DistanceTo(tt.b); !floatNear(got, tt.want, tt.tol+1e-9) {
+				t.Errorf("Expected %.3f, got %.3f", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCoordinateBearingTo(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b Coordinate
+		want float64
+	}{
+		{"north", Coord(0, 0), Coord(0, 1), 0},
+		{"east", Coord(0, 0), Coord(1, 0), 90},
+		{"south", Coord(0, 1), Coord(0, 0), 180},
+		{"west", Coord(1, 0), Coord(0, 0), 270},
+		{"Flinders Peak to Buninyong", Coord(144.42486788888889, -37.951033416666665), Coord(143.92649552777778, -37.65282113888889), 306.8681},
+		{"same point", Coord(5, 5), Coord(5, 5), 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.a.BearingTo(tt.b); !floatNear(got, tt.want, 1e-3) {
+				t.Errorf("Expected %.4f, got %.4f", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCoordinateDestination(t *testing.T) {
+	start := Coordinate{Lon: 144.42486788888889, Lat: -37.951033416666665, Alt: 50}
+	got := start.Destination(306.86815920, 54972.271)
+
+	if !floatNear(got.Lat, -37.65282113888889, 1e-7) || !floatNear(got.Lon, 143.92649552777778, 1e-7) {
+		t.Errorf("Expected Buninyong, got %v", got)
+	}
+	if got.Alt != 50 {
+		t.Errorf("Expected altitude to be kept, got %v", got.Alt)
+	}
+
+	wrapped := Coord(179.9, 0).Destination(90, 50000)
+	if wrapped.Lon > -179 || wrapped.Lon < -180 {
+		t.Errorf("Expected longitude to wrap past the antimeridian, got %v", wrapped.Lon)
+	}
+}
+
+func TestDestinationRoundTrip(t *testing.T) {
+	starts := []Coordinate{Coord(-122.08, 37.42), Coord(2.35, 48.86), Coord(151.2, -33.87)}
+	for _, a := range starts {
+		for _, bearing := range []float64{0, 45, 135, 225, 315} {
+			b := a.Destination(bearing, 250000)
+			if d := a.DistanceTo(b); !floatNear(d, 250000, 1e-3) {
+				t.Errorf("%v bearing %v: expected 250000m back, got %.4f", a, bearing, d)
+			}
+			if br := a.BearingTo(b); !floatNear(br, bearing, 1e-6) && !floatNear(br, bearing+360, 1e-6) {
+				t.Errorf("%v bearing %v: expected same bearing back, got %.8f", a, bearing, br)
+			}
+		}
+	}
+}
@@ -0,0 +1,320 @@
+package kml
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// WKB geometry type codes.
+const (
+	wkbPoint              = 1
+	wkbLineString         = 2
+	wkbPolygon            = 3
+	wkbMultiPoint         = 4
+	wkbMultiLineString    = 5
+	wkbMultiPolygon       = 6
+	wkbGeometryCollection = 7
+)
+
+// EWKB flags used by PostGIS in the high bits of the geometry type.
+const (
+	ewkbZ    = 0x80000000
+	ewkbM    = 0x40000000
+	ewkbSRID = 0x20000000
+)
+
+// errShortWKB is returned when WKB input ends before a geometry is complete.
+var errShortWKB = errors.New("kml: unexpected end of WKB data")
+
+// wkbReader decodes WKB and PostGIS EWKB geometries.
+type wkbReader struct {
+	data []byte
+	pos  int
+}
+
+// decodeWKB decodes a WKB or EWKB geometry. Multi-geometries and geometry
+// collections become MultiGeometry; M values are discarded.
+func decodeWKB(data []byte) (Geometry, error) {
+	r := &wkbReader{data: data}
+	g, err := r.geometry()
+	if err != nil {
+		return nil, err
+	}
+	if r.pos != len(r.data) {
+		return nil, fmt.Errorf("kml: %d trailing bytes after WKB geometry", len(r.data)-r.pos)
+	}
+	return g, nil
+}
+
+// geometry reads one geometry, including its byte order and type header.
+func (r *wkbReader) geometry() (Geometry, error) {
+	if r.pos >= len(r.data) {
+		return nil, errShortWKB
+	}
+	var order binary.ByteOrder
+	switch r.data[r.pos] {
+	case 0:
+		order = binary.BigEndian
+	case 1:
+		order = binary.LittleEndian
+	default:
+		return nil, fmt.Errorf("kml: invalid WKB byte order %d", r.data[r.pos])
+	}
+	r.pos++
+
+	typ, err := r.uint32(order)
+	if err != nil {
+		return nil, err
+	}
+
+	hasZ := typ&ewkbZ != 0
+	hasM := typ&ewkbM != 0
+	if typ&ewkbSRID != 0 {
+		if _, err := r.uint32(order); err != nil {
+			return nil, err
+		}
+	}
+	typ &^= ewkbZ | ewkbM | ewkbSRID
+
+	// ISO WKB encodes dimensions as thousands: 1000 Z, 2000 M, 3000 ZM.
+	switch typ / 1000 {
+	case 1:
+		hasZ = true
+	case 2:
+		hasM = true
+	case 3:
+		hasZ, hasM = true, true
+	}
+	typ %= 1000
+
+	dims := 2
+	if hasZ {
+		dims++
+	}
+	if hasM {
+		dims++
+	}
+
+	switch typ {
+	case wkbPoint:
+		c, err := r.coord(order, dims, hasZ)
+		if err != nil {
+			return nil, err
+		}
+		return &Point{Coordinates: c}, nil
+	case wkbLineString:
+		coords, err := r.coords(order, dims, hasZ)
+		if err != nil {
+			return nil, err
+		}
+		return &LineString{Coordinates: coords}, nil
+	case wkbPolygon:
+		return r.polygon(order, dims, hasZ)
+	case wkbMultiPoint, wkbMultiLineString, wkbMultiPolygon, wkbGeometryCollection:
+		n, err := r.count(order)
+		if err != nil {
+			return nil, err
+		}
+		mg := &MultiGeometry{}
+		for i := 0; i < n; i++ {
+			g, err := r.geometry()
+			if err != nil {
+				return nil, err
+			}
+			mg.Geometries = append(mg.Geometries, g)
+		}
+		return mg, nil
+	}
+
+	return nil, fmt.Errorf("kml: unsupported WKB geometry type %d", typ)
+}
+
+// polygon reads the rings of a polygon; the first ring is the outer boundary.
+func (r *wkbReader) polygon(order binary.ByteOrder, dims int, hasZ bool) (*Polygon, error) {
+	n, err := r.count(order)
+	if err != nil {
+		return nil, err
+	}
+	p := &Polygon{}
+	for i := 0; i < n; i++ {
+		coords, err := r.coords(order, dims, hasZ)
+		if err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			p.OuterBoundary = LinearRing{Coordinates: coords}
+		} else {
+			p.InnerBoundaries = append(p.InnerBoundaries, LinearRing{Coordinates: coords})
+		}
+	}
+	return p, nil
+}
+
+// coords reads a counted sequence of coordinates.
+func (r *wkbReader) coords(order binary.ByteOrder, dims int, hasZ bool) ([]Coordinate, error) {
+	n, err := r.count(order)
+	if err != nil {
+		return nil, err
+	}
+	if len(r.data)-r.pos < n*dims*8 {
+		return nil, errShortWKB
+	}
+	coords := make([]Coordinate, n)
+	for i := range coords {
+		if coords[i], err = r.coord(order, dims, hasZ); err != nil {
+			return nil, err
+		}
+	}
+	return coords, nil
+}
+
+// coord reads a single coordinate of the given dimension.
+func (r *wkbReader) coord(order binary.ByteOrder, dims int, hasZ bool) (Coordinate, error) {
+	if len(r.data)-r.pos < dims*8 {
+		return Coordinate{}, errShortWKB
+	}
+	var v [4]float64
+	for i := 0; i < dims; i++ {
+		v[i] = math.Float64frombits(order.Uint64(r.data[r.pos:]))
+		r.pos += 8
+	}
+	c := Coordinate{Lon: v[0], Lat: v[1]}
+	if hasZ {
+		c.Alt = v[2]
+	}
+	return c, nil
+}
+
+// count reads an element count and sanity-checks it against the input size.
+func (r *wkbReader) count(order binary.ByteOrder) (int, error) {
+	n, err := r.uint32(order)
+	if err != nil {
+		return 0, err
+	}
+	if int(n) > len(r.data)-r.pos {
+		return 0, errShortWKB
+	}
+	return int(n), nil
+}
+
+// uint32 reads a 32-bit unsigned integer.
+func (r *wkbReader) uint32(order binary.ByteOrder) (uint32, error) {
+	if len(r.data)-r.pos < 4 {
+		return 0, errShortWKB
+	}
+	v := order.Uint32(r.data[r.pos:])
+	r.pos += 4
+	return v, nil
+}
+
+// wkbWriter encodes geometries as little-endian ISO WKB.
+type wkbWriter struct {
+	buf  []byte
+	hasZ bool
+}
+
+// encodeWKB encodes g as little-endian ISO WKB, with Z values throughout
+// if any coordinate has an altitude. A MultiGeometry becomes a MultiPoint,
+// MultiLineString or MultiPolygon when its members are all Points,
+// LineStrings or Polygons, and a GeometryCollection otherwise. LinearRings
+// and Tracks become LineStrings.
+func encodeWKB(g Geometry) ([]byte, error) {
+	w := &wkbWriter{}
+	for _, c := range getGeometryCoordinates(g) {
+		if c.Alt != 0 {
+			w.hasZ = true
+			break
+		}
+	}
+	if err := w.geometry(g); err != nil {
+		return nil, err
+	}
+	return w.buf, nil
+}
+
+// geometry writes one geometry with its byte order and type header.
+func (w *wkbWriter) geometry(g Geometry) error {
+	switch geom := g.(type) {
+	case *Point:
+		w.header(wkbPoint)
+		w.coord(geom.Coordinates)
+	case *LineString:
+		w.header(wkbLineString)
+		w.coords(geom.Coordinates)
+	case *LinearRing:
+		w.header(wkbLineString)
+		w.coords(geom.Coordinates)
+	case *Track:
+		w.header(wkbLineString)
+		w.coords(geom.Coords)
+	case *Polygon:
+		w.header(wkbPolygon)
+		w.buf = binary.LittleEndian.AppendUint32(w.buf, uint32(1+len(geom.InnerBoundaries)))
+		w.coords(geom.OuterBoundary.Coordinates)
+		for _, inner := range geom.InnerBoundaries {
+			w.coords(inner.Coordinates)
+		}
+	case *MultiGeometry:
+		w.header(wkbMultiType(geom))
+		w.buf = binary.LittleEndian.AppendUint32(w.buf, uint32(len(geom.Geometries)))
+		for _, child := range geom.Geometries {
+			if err := w.geometry(child); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("kml: cannot encode %T as WKB", g)
+	}
+	return nil
+}
+
+// wkbMultiType returns the WKB type code for the members of mg.
+func wkbMultiType(mg *MultiGeometry) uint32 {
+	typ := uint32(wkbGeometryCollection)
+	for i, g := range mg.Geometries {
+		var member uint32
+		switch g.(type) {
+		case *Point:
+			member = wkbMultiPoint
+		case *LineString:
+			member = wkbMultiLineString
+		case *Polygon:
+			member = wkbMultiPolygon
+		default:
+			return wkbGeometryCollection
+		}
+		if i > 0 && member != typ {
+			return wkbGeometryCollection
+		}
+		typ = member
+	}
+	return typ
+}
+
+// header writes the byte order and ISO type code of a geometry.
+func (w *wkbWriter) header(typ uint32) {
+	if w.hasZ {
+		typ += 1000
+	}
+	w.buf = append(w.buf, 1)
+	w.buf = binary.LittleEndian.AppendUint32(w.buf, typ)
+}
+
+// coords writes a counted sequence of coordinates.
+func (w *wkbWriter) coords(coords []Coordinate) {
+	w.buf = binary.LittleEndian.AppendUint32(w.buf, uint32(len(coords)))
+	for _, c := range coords {
+		w.coord(c)
+	}
+}
+
+// coord writes a single coordinate.
+func (w *wkbWriter) coord(c Coordinate) {
+	w.buf = binary.LittleEndian.AppendUint64(w.buf, math.Float64bits(c.Lon))
+	w.buf = binary.LittleEndian.AppendUint64(w.buf, math.Float64bits(c.Lat))
+	if w.hasZ {
+		w.buf = binary.LittleEndian.AppendUint64(w.buf, math.Float64bits(c.Alt))
+	}
+}
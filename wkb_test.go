@@ -0,0 +1,210 @@
+package kml
+
+import (
+	"encoding/binary"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// wkbBuilder assembles little-endian WKB for tests.
+type wkbBuilder struct {
+	buf []byte
+}
+
+func (b *wkbBuilder) header(typ uint32) *wkbBuilder {
+	b.buf = append(b.buf, 1)
+	b.buf = binary.LittleEndian.AppendUint32(b.buf, typ)
+	return b
+}
+
+func (b *wkbBuilder) uint32(v uint32) *wkbBuilder {
+	b.buf = binary.LittleEndian.AppendUint32(b.buf, v)
+	return b
+}
+
+func (b *wkbBuilder) float(vs ...float64) *wkbBuilder {
+	for _, v := range vs {
+		b.buf = binary.LittleEndian.AppendUint64(b.buf, math.Float64bits(v))
+	}
+	return b
+}
+
+func TestDecodeWKBPoint(t *testing.T) {
+	data := (&wkbBuilder{}).header(wkbPoint).float(-122.08, 37.42).buf
+
+	g, err := decodeWKB(data)
+	if err != nil {
+		t.Fatalf("decodeWKB failed: %v", err)
+	}
+	pt, ok := g.(*Point)
+	if !ok {
+		t.Fatalf("Expected *Point, got %T", g)
+	}
+	if pt.Coordinates.Lon != -122.08 || pt.Coordinates.Lat != 37.42 {
+		t.Errorf("Expected (-122.08, 37.42), got %v", pt.Coordinates)
+	}
+}
+
+func TestDecodeWKBBigEndian(t *testing.T) {
+	data := []byte{0}
+	data = binary.BigEndian.AppendUint32(data, wkbPoint)
+	data = binary.BigEndian.AppendUint64(data, math.Float64bits(1.5))
+	data = binary.BigEndian.AppendUint64(data, math.Float64bits(2.5))
+
+	g, err := decodeWKB(data)
+	if err != nil {
+		t.Fatalf("decodeWKB failed: %v", err)
+	}
+	if c := g.(*Point).Coordinates; c.Lon != 1.5 || c.Lat != 2.5 {
+		t.Errorf("Expected (1.5, 2.5), got %v", c)
+	}
+}
+
+func TestDecodeWKBEWKBWithSRIDAndZ(t *testing.T) {
+	data := (&wkbBuilder{}).
+		header(wkbLineString|ewkbZ|ewkbSRID).
+		uint32(4326).
+		uint32(2).
+		float(0, 0, 10, 1, 1, 20).
+		buf
+
+	g, err := decodeWKB(data)
+	if err != nil {
+		t.Fatalf("decodeWKB failed: %v", err)
+	}
+	ls, ok := g.(*LineString)
+	if !ok {
+		t.Fatalf("Expected *LineString, got %T", g)
+	}
+	if len(ls.Coordinates) != 2 {
+		t.Fatalf("Expected 2 coordinates, got %d", len(ls.Coordinates))
+	}
+	if ls.Coordinates[1].Alt != 20 {
+		t.Errorf("Expected altitude 20, got %v", ls.Coordinates[1].Alt)
+	}
+}
+
+func TestDecodeWKBISOZM(t *testing.T) {
+	// ISO PointZM is 3001; the M value must be dropped.
+	data := (&wkbBuilder{}).header(3001).float(1, 2, 3, 99).buf
+
+	g, err := decodeWKB(data)
+	if err != nil {
+		t.Fatalf("decodeWKB failed: %v", err)
+	}
+	if c := g.(*Point).Coordinates; c != (Coordinate{Lon: 1, Lat: 2, Alt: 3}) {
+		t.Errorf("Expected (1, 2, 3), got %v", c)
+	}
+}
+
+func TestDecodeWKBPolygonWithHole(t *testing.T) {
+	data := (&wkbBuilder{}).
+		header(wkbPolygon).
+		uint32(2).
+		uint32(5).float(0, 0, 10, 0, 10, 10, 0, 10, 0, 0).
+		uint32(4).float(2, 2, 3, 2, 3, 3, 2, 2).
+		buf
+
+	g, err := decodeWKB(data)
+	if err != nil {
+		t.Fatalf("decodeWKB failed: %v", err)
+	}
+	poly, ok := g.(*Polygon)
+	if !ok {
+		t.Fatalf("Expected *Polygon, got %T", g)
+	}
+	if len(poly.OuterBoundary.Coordinates) != 5 {
+		t.Errorf("Expected outer ring with 5 coordinates")
+	}
+	if len(poly.InnerBoundaries) != 1 {
+		t.Errorf("Expected 1 inner boundary, got %d", len(poly.InnerBoundaries))
+	}
+}
+
+func TestDecodeWKBMultiPoint(t *testing.T) {
+	b := (&wkbBuilder{}).header(wkbMultiPoint).uint32(2)
+	b.header(wkbPoint).float(1, 1)
+	b.header(wkbPoint).float(2, 2)
+
+	g, err := decodeWKB(b.buf)
+	if err != nil {
+		t.Fatalf("decodeWKB failed: %v", err)
+	}
+	mg, ok := g.(*MultiGeometry)
+	if !ok {
+		t.Fatalf("Expected *MultiGeometry, got %T", g)
+	}
+	if len(mg.Geometries) != 2 {
+		t.Errorf("Expected 2 geometries, got %d", len(mg.Geometries))
+	}
+}
+
+func TestDecodeWKBErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"bad byte order", []byte{2, 1, 0, 0, 0}},
+		{"truncated", (&wkbBuilder{}).header(wkbPoint).float(1).buf},
+		{"unknown type", (&wkbBuilder{}).header(42).buf},
+		{"trailing bytes", append((&wkbBuilder{}).header(wkbPoint).float(1, 2).buf, 0)},
+		{"huge count", (&wkbBuilder{}).header(wkbLineString).uint32(1 << 30).buf},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := decodeWKB(tt.data); err == nil {
+				t.Error("Expected error")
+			}
+		})
+	}
+}
+
+func TestEncodeWKB(t *testing.T) {
+	square := LinearRing{Coordinates: []Coordinate{Coord(0, 0), Coord(1, 0), Coord(1, 1), Coord(0, 0)}}
+	tests := []struct {
+		name string
+		geom Geometry
+		typ  uint32
+		want Geometry
+	}{
+		{"point", &Point{Coordinates: Coord(-122.08, 37.42)}, wkbPoint, nil},
+		{"point z", &Point{Coordinates: Coord(-122.08, 37.42, 30)}, wkbPoint + 1000, nil},
+		{"line", &LineString{Coordinates: []Coordinate{Coord(0, 0), Coord(1, 1, 5)}}, wkbLineString + 1000, nil},
+		{"polygon", &Polygon{OuterBoundary: square, InnerBoundaries: []LinearRing{square}}, wkbPolygon, nil},
+		{"multipoint", &MultiGeometry{Geometries: []Geometry{&Point{Coordinates: Coord(1, 1)}, &Point{Coordinates: Coord(2, 2)}}}, wkbMultiPoint, nil},
+		{"collection", &MultiGeometry{Geometries: []Geometry{&Point{Coordinates: Coord(1, 1)}, &LineString{Coordinates: square.Coordinates}}}, wkbGeometryCollection, nil},
+		{"empty collection", &MultiGeometry{}, wkbGeometryCollection, nil},
+		{"ring", &square, wkbLineString, &LineString{Coordinates: square.Coordinates}},
+		{"track", &Track{Coords: []Coordinate{Coord(0, 0), Coord(1, 1)}}, wkbLineString, &LineString{Coordinates: []Coordinate{Coord(0, 0), Coord(1, 1)}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := encodeWKB(tt.geom)
+			if err != nil {
+				t.Fatalf("encodeWKB failed: %v", err)
+			}
+			if typ := binary.LittleEndian.Uint32(data[1:]); data[0] != 1 || typ != tt.typ {
+				t.Errorf("Expected little-endian type %d, got order %d type %d", tt.typ, data[0], typ)
+			}
+			g, err := decodeWKB(data)
+			if err != nil {
+				t.Fatalf("decodeWKB failed: %v", err)
+			}
+			want := tt.want
+			if want == nil {
+				want = tt.geom
+			}
+			if !reflect.DeepEqual(g, want) {
+				t.Errorf("Expected %+v, got %+v", want, g)
+			}
+		})
+	}
+
+	if _, err := encodeWKB(failingGeometry{}); err == nil {
+		t.Error("Expected error for an unknown geometry")
+	}
+}
@@ -0,0 +1,674 @@
+package kml
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// FromYAML builds a KML document from the YAML authoring format, a compact
+// schema mirroring the builder API that lets map layers be written by hand
+// and converted to KML in CI:
+//
+//	name: Survey
+//	styles:
+//	  - id: wells
+//	    icon: {href: "http://maps.google.com/mapfiles/kml/paddle/blu-circle.png", scale: 1.2}
+//	    line: {color: ff0000ff, width: 2}
+//	features:
+//	  - folder: Wells
+//	    features:
+//	      - placemark: Well 1
+//	        style: wells
+//	        point: [-122.0841, 37.4220]
+//	        data: {depth: 120}
+//	  - placemark: Road
+//	    line: [[-122.0, 37.0], [-121.0, 38.0]]
+//
+// The top level describes the Document (name, id, description, open,
+// styles and features). Each feature is a folder (folder, id, description,
+// open and features) or a placemark (placemark, id, description, style,
+// data and one of point, line or polygon), keyed by its name. Coordinates
+// are [lon, lat] or [lon, lat, alt]; a polygon is a list of rings, the
+// first being the outer boundary. A style value without a # refers to a
+// style in the document. Styles take icon (href, color, scale, heading, or
+// just the href), label (color, scale), line (color, width), poly (color,
+// fill, outline) and balloon (text, bgColor, textColor); colors are
+// AABBGGRR hex strings. Data values become ExtendedData Data elements.
+//
+// Unknown keys are reported as errors, which are *ParseError values
+// locating the problem in the YAML.
+func FromYAML(r io.Reader) (*KML, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	root, err := parseYAML(data)
+	if err != nil {
+		return nil, err
+	}
+	doc := &Document{}
+	if !root.null {
+		if doc, err = yamlDocument(root); err != nil {
+			return nil, err
+		}
+	}
+	k := NewKML()
+	k.Feature = doc
+	return k, nil
+}
+
+// ToYAML writes the document in the YAML authoring format read by
+// FromYAML. Fields the format has no key for, such as views, regions and
+// StyleMaps, are not written. It returns an error if the document contains
+// features other than Documents, Folders and Placemarks, or geometries
+// other than Points, LineStrings and Polygons.
+func (k *KML) ToYAML(w io.Writer) error {
+	var doc *Document
+	switch f := k.Feature.(type) {
+	case nil:
+		doc = &Document{}
+	case *Document:
+		doc = f
+	default:
+		doc = &Document{Features: []Feature{f}}
+	}
+	n, err := yamlFromDocument(doc)
+	if err != nil {
+		return err
+	}
+	var b strings.Builder
+	writeYAML(&b, n)
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// yamlDocument converts the top-level mapping of a YAML layer to a Document.
+func yamlDocument(n *yamlNode) (*Document, error) {
+	if err := yamlKeys(n, "document", "name", "id", "description", "open", "styles", "features"); err != nil {
+		return nil, err
+	}
+	doc := &Document{}
+	var err error
+	if doc.Name, err = yamlString(n.get("name")); err != nil {
+		return nil, err
+	}
+	if doc.ID, err = yamlString(n.get("id")); err != nil {
+		return nil, err
+	}
+	if doc.Description, err = yamlString(n.get("description")); err != nil {
+		return nil, err
+	}
+	if doc.Open, err = yamlBool(n.get("open")); err != nil {
+		return nil, err
+	}
+	if styles := n.get("styles"); styles != nil && !styles.null {
+		if styles.kind != yamlList {
+			return nil, styles.errorf("styles must be a list")
+		}
+		for _, item := range styles.items {
+			style, err := yamlStyle(item)
+			if err != nil {
+				return nil, err
+			}
+			doc.Styles = append(doc.Styles, *style)
+		}
+	}
+	if doc.Features, err = yamlFeatures(n.get("features")); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// yamlFeatures converts a features list, which may be nil or null.
+func yamlFeatures(n *yamlNode) ([]Feature, error) {
+	if n == nil || n.null {
+		return nil, nil
+	}
+	if n.kind != yamlList {
+		return nil, n.errorf("features must be a list")
+	}
+	var features []Feature
+	for _, item := range n.items {
+		if item.kind != yamlMap {
+			return nil, item.errorf("feature must be a mapping with a folder or placemark key")
+		}
+		var f Feature
+		var err error
+		switch {
+		case item.get("folder") != nil:
+			f, err = yamlFolder(item)
+		case item.get("placemark") != nil:
+			f, err = yamlPlacemark(item)
+		default:
+			return nil, item.errorf("feature must have a folder or placemark key")
+		}
+		if err != nil {
+			return nil, err
+		}
+		features = append(features, f)
+	}
+	return features, nil
+}
+
+// yamlFolder converts a mapping with a folder key to a Folder.
+func yamlFolder(n *yamlNode) (*Folder, error) {
+	if err := yamlKeys(n, "folder", "folder", "id", "description", "open", "features"); err != nil {
+		return nil, err
+	}
+	f := &Folder{}
+	var err error
+	if f.Name, err = yamlString(n.get("folder")); err != nil {
+		return nil, err
+	}
+	if f.ID, err = yamlString(n.get("id")); err != nil {
+		return nil, err
+	}
+	if f.Description, err = yamlString(n.get("description")); err != nil {
+		return nil, err
+	}
+	if f.Open, err = yamlBool(n.get("open")); err != nil {
+		return nil, err
+	}
+	if f.Features, err = yamlFeatures(n.get("features")); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// yamlPlacemark converts a mapping with a placemark key to a Placemark.
+func yamlPlacemark(n *yamlNode) (*Placemark, error) {
+	if err := yamlKeys(n, "placemark", "placemark", "id", "description", "style", "point", "line", "polygon", "data"); err != nil {
+		return nil, err
+	}
+	p := &Placemark{}
+	var err error
+	if p.Name, err = yamlString(n.get("placemark")); err != nil {
+		return nil, err
+	}
+	if p.ID, err = yamlString(n.get("id")); err != nil {
+		return nil, err
+	}
+	if p.Description, err = yamlString(n.get("description")); err != nil {
+		return nil, err
+	}
+	if p.StyleURL, err = yamlString(n.get("style")); err != nil {
+		return nil, err
+	}
+	if p.StyleURL != "" && !strings.Contains(p.StyleURL, "#") {
+		p.StyleURL = "#" + p.StyleURL
+	}
+
+	for _, key := range []string{"point", "line", "polygon"} {
+		v := n.get(key)
+		if v == nil {
+			continue
+		}
+		if p.Geometry != nil {
+			return nil, v.errorf("placemark can only have one of point, line or polygon")
+		}
+		switch key {
+		case "point":
+			c, err := yamlCoord(v)
+			if err != nil {
+				return nil, err
+			}
+			p.Geometry = &Point{Coordinates: c}
+		case "line":
+			coords, err := yamlCoords(v)
+			if err != nil {
+				return nil, err
+			}
+			p.Geometry = &LineString{Coordinates: coords}
+		case "polygon":
+			if v.kind != yamlList || len(v.items) == 0 {
+				return nil, v.errorf("polygon must be a list of rings")
+			}
+			polygon := &Polygon{}
+			for i, ring := range v.items {
+				coords, err := yamlCoords(ring)
+				if err != nil {
+					return nil, err
+				}
+				if i == 0 {
+					polygon.OuterBoundary.Coordinates = coords
+				} else {
+					polygon.InnerBoundaries = append(polygon.InnerBoundaries, LinearRing{Coordinates: coords})
+				}
+			}
+			p.Geometry = polygon
+		}
+	}
+
+	if data := n.get("data"); data != nil && !data.null {
+		if data.kind != yamlMap {
+			return nil, data.errorf("data must be a mapping")
+		}
+		p.ExtendedData = &ExtendedData{}
+		for i, name := range data.keys {
+			value, err := yamlString(data.values[i])
+			if err != nil {
+				return nil, err
+			}
+			p.ExtendedData.Data = append(p.ExtendedData.Data, Data{Name: name, Value: value})
+		}
+	}
+	return p, nil
+}
+
+// yamlStyle converts an item of the styles list to a Style.
+func yamlStyle(n *yamlNode) (*Style, error) {
+	if err := yamlKeys(n, "style", "id", "icon", "label", "line", "poly", "balloon"); err != nil {
+		return nil, err
+	}
+	s := &Style{}
+	var err error
+	if s.ID, err = yamlString(n.get("id")); err != nil {
+		return nil, err
+	}
+
+	if v := n.get("icon"); v != nil && !v.null {
+		s.IconStyle = &IconStyle{}
+		if v.kind == yamlScalar {
+			s.IconStyle.Icon = &Icon{Href: v.value}
+		} else {
+			if err := yamlKeys(v, "icon", "href", "color", "scale", "heading"); err != nil {
+				return nil, err
+			}
+			if href, err := yamlString(v.get("href")); err != nil {
+				return nil, err
+			} else if href != "" {
+				s.IconStyle.Icon = &Icon{Href: href}
+			}
+			if s.IconStyle.Color, err = yamlColor(v.get("color")); err != nil {
+				return nil, err
+			}
+			if s.IconStyle.Scale, err = yamlFloat(v.get("scale")); err != nil {
+				return nil, err
+			}
+			if s.IconStyle.Heading, err = yamlFloat(v.get("heading")); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if v := n.get("label"); v != nil && !v.null {
+		if err := yamlKeys(v, "label", "color", "scale"); err != nil {
+			return nil, err
+		}
+		s.LabelStyle = &LabelStyle{}
+		if s.LabelStyle.Color, err = yamlColor(v.get("color")); err != nil {
+			return nil, err
+		}
+		if s.LabelStyle.Scale, err = yamlFloat(v.get("scale")); err != nil {
+			return nil, err
+		}
+	}
+
+	if v := n.get("line"); v != nil && !v.null {
+		if err := yamlKeys(v, "line", "color", "width"); err != nil {
+			return nil, err
+		}
+		s.LineStyle = &LineStyle{}
+		if s.LineStyle.Color, err = yamlColor(v.get("color")); err != nil {
+			return nil, err
+		}
+		if s.LineStyle.Width, err = yamlFloat(v.get("width")); err != nil {
+			return nil, err
+		}
+	}
+
+	if v := n.get("poly"); v != nil && !v.null {
+		if err := yamlKeys(v, "poly", "color", "fill", "outline"); err != nil {
+			return nil, err
+		}
+		s.PolyStyle = &PolyStyle{}
+		if s.PolyStyle.Color, err = yamlColor(v.get("color")); err != nil {
+			return nil, err
+		}
+		for _, flag := range []struct {
+			key string
+			dst **bool
+		}{{"fill", &s.PolyStyle.Fill}, {"outline", &s.PolyStyle.Outline}} {
+			if b := v.get(flag.key); b != nil && !b.null {
+				on, err := yamlBool(b)
+				if err != nil {
+					return nil, err
+				}
+				*flag.dst = &on
+			}
+		}
+	}
+
+	if v := n.get("balloon"); v != nil && !v.null {
+		if err := yamlKeys(v, "balloon", "text", "bgColor", "textColor"); err != nil {
+			return nil, err
+		}
+		s.BalloonStyle = &BalloonStyle{}
+		if s.BalloonStyle.Text, err = yamlString(v.get("text")); err != nil {
+			return nil, err
+		}
+		if s.BalloonStyle.BgColor, err = yamlColor(v.get("bgColor")); err != nil {
+			return nil, err
+		}
+		if s.BalloonStyle.TextColor, err = yamlColor(v.get("textColor")); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// yamlKeys checks that n is a mapping using only the allowed keys.
+func yamlKeys(n *yamlNode, what string, allowed ...string) error {
+	if n.kind != yamlMap {
+		return n.errorf("%s must be a mapping", what)
+	}
+	for i, k := range n.keys {
+		known := false
+		for _, a := range allowed {
+			if k == a {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return n.values[i].errorf("unknown key %q in %s", k, what)
+		}
+	}
+	return nil
+}
+
+// yamlString returns the text of a scalar, or "" if n is nil or null.
+func yamlString(n *yamlNode) (string, error) {
+	if n == nil || n.null {
+		return "", nil
+	}
+	if n.kind != yamlScalar {
+		return "", n.errorf("expected a string")
+	}
+	return n.value, nil
+}
+
+// yamlBool returns the value of a true or false scalar, or false if n is
+// nil or null.
+func yamlBool(n *yamlNode) (bool, error) {
+	if n == nil || n.null {
+		return false, nil
+	}
+	if n.kind == yamlScalar {
+		switch strings.ToLower(n.value) {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+	}
+	return false, n.errorf("expected true or false")
+}
+
+// yamlFloat returns the value of a number scalar, or 0 if n is nil or null.
+func yamlFloat(n *yamlNode) (float64, error) {
+	if n == nil || n.null {
+		return 0, nil
+	}
+	if n.kind == yamlScalar {
+		if f, err := strconv.ParseFloat(n.value, 64); err == nil {
+			return f, nil
+		}
+	}
+	return 0, n.errorf("expected a number")
+}
+
+// yamlColor returns the value of a hex color scalar, or the zero Color if
+// n is nil or null.
+func yamlColor(n *yamlNode) (Color, error) {
+	if n == nil || n.null {
+		return Color{}, nil
+	}
+	if n.kind == yamlScalar {
+		if c, err := ParseColor(n.value); err == nil {
+			return c, nil
+		}
+	}
+	return Color{}, n.errorf("expected an AABBGGRR hex color")
+}
+
+// yamlCoord converts a [lon, lat] or [lon, lat, alt] list to a Coordinate.
+func yamlCoord(n *yamlNode) (Coordinate, error) {
+	if n.kind != yamlList || len(n.items) < 2 || len(n.items) > 3 {
+		return Coordinate{}, n.errorf("coordinate must be [lon, lat] or [lon, lat, alt]")
+	}
+	var v [3]float64
+	for i, item := range n.items {
+		f, err := yamlFloat(item)
+		if err != nil || item.null {
+			return Coordinate{}, item.errorf("expected a number")
+		}
+		v[i] = f
+	}
+	return Coordinate{Lon: v[0], Lat: v[1], Alt: v[2]}, nil
+}
+
+// yamlCoords converts a list of coordinate lists.
+func yamlCoords(n *yamlNode) ([]Coordinate, error) {
+	if n.kind != yamlList {
+		return nil, n.errorf("expected a list of coordinates")
+	}
+	coords := make([]Coordinate, 0, len(n.items))
+	for _, item := range n.items {
+		c, err := yamlCoord(item)
+		if err != nil {
+			return nil, err
+		}
+		coords = append(coords, c)
+	}
+	return coords, nil
+}
+
+// yamlFromDocument returns the top-level mapping ToYAML writes for doc.
+func yamlFromDocument(doc *Document) (*yamlNode, error) {
+	n := &yamlNode{kind: yamlMap}
+	setYAMLString(n, "name", doc.Name)
+	setYAMLString(n, "id", doc.ID)
+	setYAMLString(n, "description", doc.Description)
+	if doc.Open {
+		n.set("open", yamlPlain("true"))
+	}
+	if len(doc.Styles) > 0 {
+		styles := &yamlNode{kind: yamlList}
+		for i := range doc.Styles {
+			styles.items = append(styles.items, yamlFromStyle(&doc.Styles[i]))
+		}
+		n.set("styles", styles)
+	}
+	features, err := yamlFromFeatures(doc.Features)
+	if err != nil {
+		return nil, err
+	}
+	if features != nil {
+		n.set("features", features)
+	}
+	return n, nil
+}
+
+// yamlFromFeatures returns the features list for features, or nil if
+// there are none.
+func yamlFromFeatures(features []Feature) (*yamlNode, error) {
+	if len(features) == 0 {
+		return nil, nil
+	}
+	list := &yamlNode{kind: yamlList}
+	for _, f := range features {
+		n := &yamlNode{kind: yamlMap}
+		switch f := f.(type) {
+		case *Folder:
+			n.set("folder", yamlText(f.Name))
+			setYAMLString(n, "id", f.ID)
+			setYAMLString(n, "description", f.Description)
+			if f.Open {
+				n.set("open", yamlPlain("true"))
+			}
+			children, err := yamlFromFeatures(f.Features)
+			if err != nil {
+				return nil, err
+			}
+			if children != nil {
+				n.set("features", children)
+			}
+		case *Placemark:
+			if err := yamlFromPlacemark(n, f); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("kml: %s %q cannot be written as YAML", f.featureType(), featureName(f))
+		}
+		list.items = append(list.items, n)
+	}
+	return list, nil
+}
+
+// yamlFromPlacemark sets the keys of the placemark mapping n from p.
+func yamlFromPlacemark(n *yamlNode, p *Placemark) error {
+	n.set("placemark", yamlText(p.Name))
+	setYAMLString(n, "id", p.ID)
+	setYAMLString(n, "description", p.Description)
+	if p.StyleURL != "" {
+		style := p.StyleURL
+		if strings.HasPrefix(style, "#") && !strings.Contains(style[1:], "#") {
+			style = style[1:]
+		}
+		n.set("style", yamlText(style))
+	}
+
+	switch g := p.Geometry.(type) {
+	case nil:
+	case *Point:
+		n.set("point", yamlFromCoord(g.Coordinates))
+	case *LineString:
+		n.set("line", yamlFromCoords(g.Coordinates))
+	case *Polygon:
+		rings := &yamlNode{kind: yamlList}
+		rings.items = append(rings.items, yamlFromCoords(g.OuterBoundary.Coordinates))
+		for _, inner := range g.InnerBoundaries {
+			rings.items = append(rings.items, yamlFromCoords(inner.Coordinates))
+		}
+		n.set("polygon", rings)
+	default:
+		return fmt.Errorf("kml: placemark %q: %s cannot be written as YAML", p.Name, g.geometryType())
+	}
+
+	if p.ExtendedData != nil && len(p.ExtendedData.Data) > 0 {
+		data := &yamlNode{kind: yamlMap}
+		for _, d := range p.ExtendedData.Data {
+			if data.get(d.Name) == nil {
+				data.set(d.Name, yamlText(d.Value))
+			}
+		}
+		n.set("data", data)
+	}
+	return nil
+}
+
+// yamlFromStyle returns the styles list item for s.
+func yamlFromStyle(s *Style) *yamlNode {
+	n := &yamlNode{kind: yamlMap}
+	setYAMLString(n, "id", s.ID)
+	if is := s.IconStyle; is != nil {
+		icon := &yamlNode{kind: yamlMap}
+		if is.Icon != nil {
+			setYAMLString(icon, "href", is.Icon.Href)
+		}
+		setYAMLColor(icon, "color", is.Color)
+		setYAMLFloat(icon, "scale", is.Scale)
+		setYAMLFloat(icon, "heading", is.Heading)
+		if len(icon.keys) == 1 && icon.keys[0] == "href" {
+			n.set("icon", icon.values[0])
+		} else {
+			n.set("icon", icon)
+		}
+	}
+	if ls := s.LabelStyle; ls != nil {
+		label := &yamlNode{kind: yamlMap}
+		setYAMLColor(label, "color", ls.Color)
+		setYAMLFloat(label, "scale", ls.Scale)
+		n.set("label", label)
+	}
+	if ls := s.LineStyle; ls != nil {
+		line := &yamlNode{kind: yamlMap}
+		setYAMLColor(line, "color", ls.Color)
+		setYAMLFloat(line, "width", ls.Width)
+		n.set("line", line)
+	}
+	if ps := s.PolyStyle; ps != nil {
+		poly := &yamlNode{kind: yamlMap}
+		setYAMLColor(poly, "color", ps.Color)
+		if ps.Fill != nil {
+			poly.set("fill", yamlPlain(strconv.FormatBool(*ps.Fill)))
+		}
+		if ps.Outline != nil {
+			poly.set("outline", yamlPlain(strconv.FormatBool(*ps.Outline)))
+		}
+		n.set("poly", poly)
+	}
+	if bs := s.BalloonStyle; bs != nil {
+		balloon := &yamlNode{kind: yamlMap}
+		setYAMLString(balloon, "text", bs.Text)
+		setYAMLColor(balloon, "bgColor", bs.BgColor)
+		setYAMLColor(balloon, "textColor", bs.TextColor)
+		n.set("balloon", balloon)
+	}
+	return n
+}
+
+// yamlText returns a string scalar, quoted when written if it must be.
+func yamlText(s string) *yamlNode {
+	return &yamlNode{kind: yamlScalar, value: s}
+}
+
+// yamlPlain returns a scalar written unquoted, such as a number.
+func yamlPlain(s string) *yamlNode {
+	return &yamlNode{kind: yamlScalar, value: s, plain: true}
+}
+
+// setYAMLString sets key of n to s unless s is empty.
+func setYAMLString(n *yamlNode, key, s string) {
+	if s != "" {
+		n.set(key, yamlText(s))
+	}
+}
+
+// setYAMLFloat sets key of n to f unless f is zero.
+func setYAMLFloat(n *yamlNode, key string, f float64) {
+	if f != 0 {
+		n.set(key, yamlPlain(strconv.FormatFloat(f, 'f', -1, 64)))
+	}
+}
+
+// setYAMLColor sets key of n to the hex form of c unless c is zero.
+func setYAMLColor(n *yamlNode, key string, c Color) {
+	if c != (Color{}) {
+		n.set(key, yamlText(c.Hex()))
+	}
+}
+
+// yamlFromCoord returns c as a flow list, leaving out a zero altitude.
+func yamlFromCoord(c Coordinate) *yamlNode {
+	n := &yamlNode{kind: yamlList, flow: true}
+	n.items = append(n.items,
+		yamlPlain(strconv.FormatFloat(c.Lon, 'f', -1, 64)),
+		yamlPlain(strconv.FormatFloat(c.Lat, 'f', -1, 64)))
+	if c.Alt != 0 {
+		n.items = append(n.items, yamlPlain(strconv.FormatFloat(c.Alt, 'f', -1, 64)))
+	}
+	return n
+}
+
+// yamlFromCoords returns coords as a flow list of coordinate lists.
+func yamlFromCoords(coords []Coordinate) *yamlNode {
+	n := &yamlNode{kind: yamlList, flow: true}
+	for _, c := range coords {
+		n.items = append(n.items, yamlFromCoord(c))
+	}
+	return n
+}
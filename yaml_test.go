@@ -0,0 +1,235 @@
+package kml
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+const yamlLayer = `# Survey layer
+name: Survey
+description: |
+  Wells and roads
+  surveyed in <b>2024</b>
+styles:
+  - id: wells
+    icon: {href: "http://maps.google.com/mapfiles/kml/paddle/blu-circle.png", scale: 1.2}
+    label: {scale: 0.8}
+  - id: roads
+    line: {color: ff0000ff, width: 2}
+    poly:
+      color: 8000ff00
+      fill: true
+      outline: false
+features:
+  - folder: Wells
+    open: true
+    features:
+      - placemark: Well 1
+        id: w1
+        style: wells
+        point: [-122.0841, 37.4220, 10]
+        data:
+          depth: 120
+          owner: 'Smith & Sons'
+  - placemark: Road
+    style: "#roads"
+    line:
+      - [-122.0, 37.0]
+      - [-121.0, 38.0]
+  - placemark: Lot
+    polygon:
+      - [[0, 0], [4, 0], [4, 4], [0, 0]]
+      - [[1, 1], [2, 1], [2, 2], [1, 1]]
+`
+
+// TestFromYAML tests building a document from the YAML authoring format
+func TestFromYAML(t *testing.T) {
+	k, err := FromYAML(strings.NewReader(yamlLayer))
+	if err != nil {
+		t.Fatalf("FromYAML failed: %v", err)
+	}
+	doc, ok := k.Feature.(*Document)
+	if !ok {
+		t.Fatalf("Expected *Document, got %T", k.Feature)
+	}
+	if doc.Name != "Survey" {
+		t.Errorf("Expected name Survey, got %q", doc.Name)
+	}
+	if doc.Description != "Wells and roads\nsurveyed in <b>2024</b>\n" {
+		t.Errorf("Expected literal description, got %q", doc.Description)
+	}
+
+	if len(doc.Styles) != 2 {
+		t.Fatalf("Expected 2 styles, got %d", len(doc.Styles))
+	}
+	wells := doc.Styles[0]
+	if wells.IconStyle == nil || wells.IconStyle.Scale != 1.2 || wells.IconStyle.Icon == nil || !strings.HasSuffix(wells.IconStyle.Icon.Href, "blu-circle.png") {
+		t.Errorf("Expected icon style with scale 1.2, got %+v", wells.IconStyle)
+	}
+	if wells.LabelStyle == nil || wells.LabelStyle.Scale != 0.8 {
+		t.Errorf("Expected label scale 0.8, got %+v", wells.LabelStyle)
+	}
+	roads := doc.Styles[1]
+	if roads.LineStyle == nil || roads.LineStyle.Color != Red || roads.LineStyle.Width != 2 {
+		t.Errorf("Expected red line of width 2, got %+v", roads.LineStyle)
+	}
+	if ps := roads.PolyStyle; ps == nil || ps.Fill == nil || !*ps.Fill || ps.Outline == nil || *ps.Outline {
+		t.Errorf("Expected fill without outline, got %+v", ps)
+	}
+
+	if len(doc.Features) != 3 {
+		t.Fatalf("Expected 3 features, got %d", len(doc.Features))
+	}
+	folder, ok := doc.Features[0].(*Folder)
+	if !ok || folder.Name != "Wells" || !folder.Open || len(folder.Features) != 1 {
+		t.Fatalf("Expected open folder Wells with 1 feature, got %+v", doc.Features[0])
+	}
+	well := folder.Features[0].(*Placemark)
+	if well.ID != "w1" || well.StyleURL != "#wells" {
+		t.Errorf("Expected id w1 and styleUrl #wells, got %q and %q", well.ID, well.StyleURL)
+	}
+	if pt, ok := well.Geometry.(*Point); !ok || pt.Coordinates != (Coordinate{Lon: -122.0841, Lat: 37.4220, Alt: 10}) {
+		t.Errorf("Expected point geometry, got %+v", well.Geometry)
+	}
+	if got, _ := well.dataValue("depth"); got != "120" {
+		t.Errorf("Expected depth 120, got %q", got)
+	}
+	if got, _ := well.dataValue("owner"); got != "Smith & Sons" {
+		t.Errorf("Expected owner Smith & Sons, got %q", got)
+	}
+
+	road := doc.Features[1].(*Placemark)
+	if road.StyleURL != "#roads" {
+		t.Errorf("Expected styleUrl #roads, got %q", road.StyleURL)
+	}
+	if ls, ok := road.Geometry.(*LineString); !ok || len(ls.Coordinates) != 2 {
+		t.Errorf("Expected 2-point line, got %+v", road.Geometry)
+	}
+	lot := doc.Features[2].(*Placemark)
+	if poly, ok := lot.Geometry.(*Polygon); !ok || len(poly.OuterBoundary.Coordinates) != 4 || len(poly.InnerBoundaries) != 1 {
+		t.Errorf("Expected polygon with one hole, got %+v", lot.Geometry)
+	}
+}
+
+// TestYAMLRoundTrip tests that ToYAML output reads back as the same document
+func TestYAMLRoundTrip(t *testing.T) {
+	k, err := FromYAML(strings.NewReader(yamlLayer))
+	if err != nil {
+		t.Fatalf("FromYAML failed: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := k.ToYAML(&buf); err != nil {
+		t.Fatalf("ToYAML failed: %v", err)
+	}
+	back, err := FromYAML(&buf)
+	if err != nil {
+		t.Fatalf("FromYAML of ToYAML output failed: %v\n%s", err, buf.String())
+	}
+
+	want, _ := k.Bytes()
+	got, _ := back.Bytes()
+	if !bytes.Equal(got, want) {
+		t.Errorf("Expected KML after YAML round trip:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+// TestToYAML tests the written form
+func TestToYAML(t *testing.T) {
+	fill := true
+	k := NewKMLBuilder().
+		Document("Sites").
+		Style("red").
+		IconStyle().Icon("pin.png").Done().
+		Done().
+		Placemark("HQ").StyleURL("#red").Point(-122.5, 37.25).Done().(*DocumentBuilder).
+		Build()
+	k.Feature.(*Document).Styles = append(k.Feature.(*Document).Styles, Style{ID: "area", PolyStyle: &PolyStyle{Color: Blue, Fill: &fill}})
+
+	var buf bytes.Buffer
+	if err := k.ToYAML(&buf); err != nil {
+		t.Fatalf("ToYAML failed: %v", err)
+	}
+	want := `name: Sites
+styles:
+  - id: red
+    icon: pin.png
+  - id: area
+    poly:
+      color: ffff0000
+      fill: true
+features:
+  - placemark: HQ
+    style: red
+    point: [-122.5, 37.25]
+`
+	if got := buf.String(); got != want {
+		t.Errorf("Expected:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+// TestToYAMLUnsupported tests that features outside the schema are reported
+func TestToYAMLUnsupported(t *testing.T) {
+	tests := []struct {
+		name    string
+		feature Feature
+		want    string
+	}{
+		{"overlay", &GroundOverlay{Name: "image"}, `GroundOverlay "image"`},
+		{"network link", &NetworkLink{Name: "tiles"}, `NetworkLink "tiles"`},
+		{"track", &Placemark{Name: "run", Geometry: &Track{}}, `placemark "run": Track`},
+		{"multigeometry", &Placemark{Name: "m", Geometry: &MultiGeometry{}}, "MultiGeometry"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := NewKML()
+			k.Feature = &Document{Features: []Feature{&Folder{Features: []Feature{tt.feature}}}}
+			err := k.ToYAML(&bytes.Buffer{})
+			if err == nil {
+				t.Fatal("Expected an error, got nil")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+// TestFromYAMLErrors tests that schema errors are located in the YAML
+func TestFromYAMLErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		line int
+		want string
+	}{
+		{"unknown key", "name: x\ncolour: red\n", 2, `unknown key "colour" in document`},
+		{"unknown feature", "features:\n  - overlay: x\n", 2, "folder or placemark"},
+		{"unknown placemark key", "features:\n  - placemark: x\n    pont: [1, 2]\n", 3, `unknown key "pont" in placemark`},
+		{"bad coordinate", "features:\n  - placemark: x\n    point: [1]\n", 3, "[lon, lat]"},
+		{"bad number", "features:\n  - placemark: x\n    point: [1, north]\n", 3, "expected a number"},
+		{"two geometries", "features:\n  - placemark: x\n    point: [1, 2]\n    line: [[1, 2], [3, 4]]\n", 4, "only have one"},
+		{"bad color", "styles:\n  - id: s\n    line: {color: red}\n", 3, "hex color"},
+		{"bad bool", "open: maybe\n", 1, "true or false"},
+		{"styles not a list", "styles: x\n", 1, "must be a list"},
+		{"top level list", "- a\n", 1, "document must be a mapping"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := FromYAML(strings.NewReader(tt.yaml))
+			var pe *ParseError
+			if !errors.As(err, &pe) {
+				t.Fatalf("Expected *ParseError, got %v", err)
+			}
+			if pe.Line != tt.line {
+				t.Errorf("Expected line %d, got %d", tt.line, pe.Line)
+			}
+			if !strings.Contains(pe.Message, tt.want) {
+				t.Errorf("Expected message containing %q, got %q", tt.want, pe.Message)
+			}
+		})
+	}
+}
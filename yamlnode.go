@@ -0,0 +1,753 @@
+package kml
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The YAML authoring format is read and written by a small parser for the
+// subset of YAML it needs: block mappings and sequences, flow sequences and
+// mappings, plain, single- and double-quoted scalars, literal and folded
+// block scalars, and comments. Anchors, aliases, tags and multiple
+// documents are rejected.
+
+type yamlKind int
+
+const (
+	yamlScalar yamlKind = iota
+	yamlMap
+	yamlList
+)
+
+// yamlNode is a parsed YAML value. Scalars keep their text; typing is left
+// to the schema, which knows what each key holds.
+type yamlNode struct {
+	kind   yamlKind
+	value  string      // Scalar text
+	null   bool        // Scalar was empty, null or ~
+	keys   []string    // Mapping keys, in order
+	values []*yamlNode // Mapping values, parallel to keys
+	items  []*yamlNode // Sequence items
+	line   int
+	column int
+
+	plain bool // When writing: emit value unquoted (numbers, booleans)
+	flow  bool // When writing: emit a sequence on one line
+}
+
+// get returns the value of key in a mapping, or nil.
+func (n *yamlNode) get(key string) *yamlNode {
+	for i, k := range n.keys {
+		if k == key {
+			return n.values[i]
+		}
+	}
+	return nil
+}
+
+// set appends key to a mapping.
+func (n *yamlNode) set(key string, v *yamlNode) {
+	n.keys = append(n.keys, key)
+	n.values = append(n.values, v)
+}
+
+// errorf returns a ParseError at the node.
+func (n *yamlNode) errorf(format string, args ...any) error {
+	return &ParseError{Line: n.line, Column: n.column, Message: fmt.Sprintf(format, args...)}
+}
+
+type yamlLine struct {
+	num    int
+	indent int
+	raw    string // The line as written, for block scalars
+	text   string // Content after the indentation, comments removed
+}
+
+type yamlParser struct {
+	lines []yamlLine
+	pos   int
+}
+
+// parseYAML parses a YAML document.
+func parseYAML(data []byte) (*yamlNode, error) {
+	p := &yamlParser{}
+	for i, raw := range strings.Split(string(data), "\n") {
+		raw = strings.TrimRight(raw, "\r")
+		trimmed := strings.TrimLeft(raw, " ")
+		indent := len(raw) - len(trimmed)
+		text, err := stripYAMLComment(trimmed)
+		if err != nil {
+			return nil, &ParseError{Line: i + 1, Column: indent + 1, Message: err.Error()}
+		}
+		text = strings.TrimRight(text, " \t")
+		if strings.HasPrefix(trimmed, "\t") && text != "" {
+			return nil, &ParseError{Line: i + 1, Column: indent + 1, Message: "tabs cannot be used for indentation"}
+		}
+		p.lines = append(p.lines, yamlLine{num: i + 1, indent: indent, raw: raw, text: text})
+	}
+
+	l := p.peek()
+	if l != nil && l.text == "---" {
+		p.pos++
+		l = p.peek()
+	}
+	if l == nil {
+		return &yamlNode{kind: yamlScalar, null: true, line: 1, column: 1}, nil
+	}
+	if l.indent != 0 {
+		return nil, p.errorf(l, "unexpected indentation")
+	}
+	n, err := p.parseBlock(0)
+	if err != nil {
+		return nil, err
+	}
+	if l := p.peek(); l != nil && l.text != "..." {
+		if l.text == "---" {
+			return nil, p.errorf(l, "multiple documents are not supported")
+		}
+		return nil, p.errorf(l, "unexpected content")
+	}
+	return n, nil
+}
+
+// peek returns the next line with content, skipping blank and comment
+// lines, or nil at the end of the input.
+func (p *yamlParser) peek() *yamlLine {
+	for p.pos < len(p.lines) {
+		if l := &p.lines[p.pos]; l.text != "" {
+			return l
+		}
+		p.pos++
+	}
+	return nil
+}
+
+// errorf returns a ParseError at the start of line l.
+func (p *yamlParser) errorf(l *yamlLine, format string, args ...any) error {
+	return &ParseError{Line: l.num, Column: l.indent + 1, Message: fmt.Sprintf(format, args...)}
+}
+
+// parseBlock parses the block node starting at the next line, which is
+// indented by indent.
+func (p *yamlParser) parseBlock(indent int) (*yamlNode, error) {
+	l := p.peek()
+	if isYAMLDash(l.text) {
+		return p.parseList(indent)
+	}
+	if _, _, ok := splitYAMLKey(l.text); ok {
+		return p.parseMap(indent)
+	}
+	p.pos++
+	return parseYAMLInline(l.text, l.num, l.indent+1)
+}
+
+// parseList parses the block sequence whose dashes are indented by indent.
+func (p *yamlParser) parseList(indent int) (*yamlNode, error) {
+	first := p.peek()
+	list := &yamlNode{kind: yamlList, line: first.num, column: first.indent + 1}
+	for {
+		l := p.peek()
+		if l == nil || l.indent < indent || isYAMLMarker(l) {
+			return list, nil
+		}
+		if l.indent > indent {
+			return nil, p.errorf(l, "unexpected indentation")
+		}
+		if !isYAMLDash(l.text) {
+			return list, nil
+		}
+
+		rest := strings.TrimLeft(l.text[1:], " ")
+		column := l.indent + len(l.text) - len(rest)
+		if rest == "" {
+			p.pos++
+			item, err := p.parseNested(l, indent)
+			if err != nil {
+				return nil, err
+			}
+			list.items = append(list.items, item)
+			continue
+		}
+		if _, _, ok := splitYAMLKey(rest); ok || isYAMLDash(rest) {
+			// Treat the item as a block starting at its own column, so
+			// "- name: x" continues with keys aligned under "name".
+			l.indent, l.text = column, rest
+			item, err := p.parseBlock(column)
+			if err != nil {
+				return nil, err
+			}
+			list.items = append(list.items, item)
+			continue
+		}
+		p.pos++
+		item, err := p.parseValue(l, indent, rest, column+1)
+		if err != nil {
+			return nil, err
+		}
+		list.items = append(list.items, item)
+	}
+}
+
+// parseMap parses the block mapping whose keys are indented by indent.
+func (p *yamlParser) parseMap(indent int) (*yamlNode, error) {
+	first := p.peek()
+	m := &yamlNode{kind: yamlMap, line: first.num, column: first.indent + 1}
+	for {
+		l := p.peek()
+		if l == nil || l.indent < indent || isYAMLMarker(l) {
+			return m, nil
+		}
+		if l.indent > indent {
+			return nil, p.errorf(l, "unexpected indentation")
+		}
+		if isYAMLDash(l.text) {
+			return m, nil
+		}
+		key, rest, ok := splitYAMLKey(l.text)
+		if !ok {
+			return nil, p.errorf(l, "expected \"key: value\", got %q", l.text)
+		}
+		if m.get(key) != nil {
+			return nil, p.errorf(l, "duplicate key %q", key)
+		}
+		p.pos++
+
+		var v *yamlNode
+		var err error
+		if rest == "" {
+			v, err = p.parseNested(l, indent)
+			if err == nil && v.null {
+				// A sequence may sit at the same indentation as its key.
+				if next := p.peek(); next != nil && next.indent == indent && isYAMLDash(next.text) {
+					v, err = p.parseList(indent)
+				}
+			}
+		} else {
+			v, err = p.parseValue(l, indent, rest, l.indent+len(l.text)-len(rest)+1)
+		}
+		if err != nil {
+			return nil, err
+		}
+		m.set(key, v)
+	}
+}
+
+// parseNested parses the block under l, which has no inline value, or
+// returns a null scalar if the next line is not indented further.
+func (p *yamlParser) parseNested(l *yamlLine, indent int) (*yamlNode, error) {
+	next := p.peek()
+	if next == nil || next.indent <= indent {
+		return &yamlNode{kind: yamlScalar, null: true, line: l.num, column: l.indent + 1}, nil
+	}
+	return p.parseBlock(next.indent)
+}
+
+// parseValue parses an inline value, which may introduce a block scalar
+// on the following lines.
+func (p *yamlParser) parseValue(l *yamlLine, indent int, s string, column int) (*yamlNode, error) {
+	if s[0] == '|' || s[0] == '>' {
+		return p.parseBlockScalar(l, indent, s, column)
+	}
+	return parseYAMLInline(s, l.num, column)
+}
+
+// parseBlockScalar reads a literal (|) or folded (>) block scalar whose
+// lines are indented further than indent.
+func (p *yamlParser) parseBlockScalar(l *yamlLine, indent int, header string, column int) (*yamlNode, error) {
+	chomp := header[1:]
+	if chomp != "" && chomp != "-" && chomp != "+" {
+		return nil, &ParseError{Line: l.num, Column: column, Message: fmt.Sprintf("unsupported block scalar header %q", header)}
+	}
+
+	var lines []string
+	blockIndent := -1
+	for p.pos < len(p.lines) {
+		raw := p.lines[p.pos].raw
+		trimmed := strings.TrimLeft(raw, " ")
+		if trimmed == "" {
+			lines = append(lines, "")
+			p.pos++
+			continue
+		}
+		ind := len(raw) - len(trimmed)
+		if ind <= indent || (blockIndent >= 0 && ind < blockIndent) {
+			break
+		}
+		if blockIndent < 0 {
+			blockIndent = ind
+		}
+		lines = append(lines, raw[blockIndent:])
+		p.pos++
+	}
+
+	// Trailing blank lines only matter for the keep indicator.
+	trailing := 0
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+		trailing++
+	}
+
+	var text string
+	if header[0] == '|' {
+		text = strings.Join(lines, "\n")
+	} else {
+		text = foldYAMLLines(lines)
+	}
+	switch {
+	case len(lines) == 0:
+	case chomp == "+":
+		text += strings.Repeat("\n", trailing+1)
+	case chomp == "":
+		text += "\n"
+	}
+	return &yamlNode{kind: yamlScalar, value: text, line: l.num, column: column}, nil
+}
+
+// foldYAMLLines joins the lines of a folded block scalar: adjacent lines
+// are joined with a space, blank lines become line breaks and more
+// indented lines are kept as written.
+func foldYAMLLines(lines []string) string {
+	var b strings.Builder
+	for i, line := range lines {
+		if i > 0 {
+			prev := lines[i-1]
+			switch {
+			case line == "" && prev != "":
+				// The break ending prev is folded into the blank lines.
+			case line == "" || prev == "":
+				b.WriteByte('\n')
+			case strings.HasPrefix(line, " ") || strings.HasPrefix(prev, " "):
+				b.WriteByte('\n')
+			default:
+				b.WriteByte(' ')
+			}
+		}
+		b.WriteString(line)
+	}
+	return b.String()
+}
+
+// isYAMLMarker reports whether l starts or ends a document.
+func isYAMLMarker(l *yamlLine) bool {
+	return l.indent == 0 && (l.text == "---" || l.text == "...")
+}
+
+// isYAMLDash reports whether s starts a sequence item.
+func isYAMLDash(s string) bool {
+	return s == "-" || strings.HasPrefix(s, "- ")
+}
+
+// splitYAMLKey splits "key: value" into the key and the value text. It
+// reports false if s is not a mapping entry.
+func splitYAMLKey(s string) (key, rest string, ok bool) {
+	if s == "" {
+		return "", "", false
+	}
+	if s[0] == '"' || s[0] == '\'' {
+		end := quotedYAMLEnd(s)
+		if end < 0 || end >= len(s) || s[end] != ':' || (end+1 < len(s) && s[end+1] != ' ') {
+			return "", "", false
+		}
+		k, err := unquoteYAML(s[:end])
+		if err != nil {
+			return "", "", false
+		}
+		return k, strings.TrimSpace(s[end+1:]), true
+	}
+	if strings.ContainsRune("[{", rune(s[0])) {
+		return "", "", false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] == ':' && (i+1 == len(s) || s[i+1] == ' ') {
+			return strings.TrimSpace(s[:i]), strings.TrimSpace(s[i+1:]), i > 0
+		}
+	}
+	return "", "", false
+}
+
+// quotedYAMLEnd returns the index just past the quoted scalar at the start
+// of s, or -1 if it is not terminated.
+func quotedYAMLEnd(s string) int {
+	q := s[0]
+	for i := 1; i < len(s); i++ {
+		switch {
+		case q == '"' && s[i] == '\\':
+			i++
+		case s[i] == q && q == '\'' && i+1 < len(s) && s[i+1] == '\'':
+			i++
+		case s[i] == q:
+			return i + 1
+		}
+	}
+	return -1
+}
+
+// unquoteYAML decodes a single- or double-quoted scalar.
+func unquoteYAML(s string) (string, error) {
+	if s[0] == '\'' {
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'"), nil
+	}
+	v, err := strconv.Unquote(s)
+	if err != nil {
+		return "", fmt.Errorf("invalid double-quoted string %s", s)
+	}
+	return v, nil
+}
+
+// stripYAMLComment removes a trailing comment from a line, ignoring # inside
+// quoted scalars.
+func stripYAMLComment(s string) (string, error) {
+	var q byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case q == 0 && c == '#' && (i == 0 || s[i-1] == ' '):
+			return s[:i], nil
+		case q == 0 && (c == '"' || c == '\'') && opensYAMLQuote(s, i):
+			q = c
+		case q == '"' && c == '\\':
+			i++
+		case q == '\'' && c == '\'' && i+1 < len(s) && s[i+1] == '\'':
+			i++
+		case q != 0 && c == q:
+			q = 0
+		}
+	}
+	if q != 0 {
+		return "", errors.New("unterminated quoted string")
+	}
+	return s, nil
+}
+
+// opensYAMLQuote reports whether the quote at s[i] starts a quoted scalar,
+// rather than sitting inside a plain one.
+func opensYAMLQuote(s string, i int) bool {
+	j := i - 1
+	for j >= 0 && s[j] == ' ' {
+		j--
+	}
+	return j < 0 || strings.IndexByte(":-[{,", s[j]) >= 0
+}
+
+// parseYAMLInline parses a value written on one line: a quoted or plain
+// scalar, or a flow sequence or mapping.
+func parseYAMLInline(s string, line, column int) (*yamlNode, error) {
+	f := &yamlFlow{s: s, line: line, column: column}
+	n, err := f.value(false)
+	if err != nil {
+		return nil, err
+	}
+	f.space()
+	if f.pos < len(s) {
+		return nil, f.errorf("unexpected %q after value", s[f.pos:])
+	}
+	return n, nil
+}
+
+// yamlFlow parses flow-style values.
+type yamlFlow struct {
+	s      string
+	pos    int
+	line   int
+	column int
+}
+
+// errorf returns a ParseError at the current position.
+func (f *yamlFlow) errorf(format string, args ...any) error {
+	return &ParseError{Line: f.line, Column: f.column + f.pos, Message: fmt.Sprintf(format, args...)}
+}
+
+// space skips spaces.
+func (f *yamlFlow) space() {
+	for f.pos < len(f.s) && f.s[f.pos] == ' ' {
+		f.pos++
+	}
+}
+
+// value parses one value; inFlow reports whether it is inside brackets,
+// where commas and closing brackets end plain scalars.
+func (f *yamlFlow) value(inFlow bool) (*yamlNode, error) {
+	f.space()
+	n := &yamlNode{kind: yamlScalar, line: f.line, column: f.column + f.pos}
+	if f.pos == len(f.s) {
+		n.null = true
+		return n, nil
+	}
+	switch c := f.s[f.pos]; c {
+	case '[':
+		return f.list()
+	case '{':
+		return f.mapping()
+	case '"', '\'':
+		end := quotedYAMLEnd(f.s[f.pos:])
+		if end < 0 {
+			return nil, f.errorf("unterminated quoted string")
+		}
+		v, err := unquoteYAML(f.s[f.pos : f.pos+end])
+		if err != nil {
+			return nil, f.errorf("%v", err)
+		}
+		f.pos += end
+		n.value = v
+		return n, nil
+	case '&', '*', '!':
+		return nil, f.errorf("anchors, aliases and tags are not supported")
+	case '|', '>':
+		return nil, f.errorf("block scalars must end the line")
+	}
+
+	start := f.pos
+	for f.pos < len(f.s) {
+		c := f.s[f.pos]
+		if inFlow && (c == ',' || c == ']' || c == '}') {
+			break
+		}
+		if inFlow && c == ':' && (f.pos+1 == len(f.s) || f.s[f.pos+1] == ' ') {
+			break
+		}
+		f.pos++
+	}
+	n.value = strings.TrimSpace(f.s[start:f.pos])
+	switch n.value {
+	case "", "~", "null", "Null", "NULL":
+		n.value, n.null = "", true
+	}
+	return n, nil
+}
+
+// list parses a flow sequence starting at '['.
+func (f *yamlFlow) list() (*yamlNode, error) {
+	n := &yamlNode{kind: yamlList, line: f.line, column: f.column + f.pos}
+	f.pos++
+	for {
+		f.space()
+		if f.pos < len(f.s) && f.s[f.pos] == ']' {
+			f.pos++
+			return n, nil
+		}
+		item, err := f.value(true)
+		if err != nil {
+			return nil, err
+		}
+		n.items = append(n.items, item)
+		if err := f.separator(']'); err != nil {
+			return nil, err
+		}
+		if f.s[f.pos-1] == ']' {
+			return n, nil
+		}
+	}
+}
+
+// mapping parses a flow mapping starting at '{'.
+func (f *yamlFlow) mapping() (*yamlNode, error) {
+	n := &yamlNode{kind: yamlMap, line: f.line, column: f.column + f.pos}
+	f.pos++
+	for {
+		f.space()
+		if f.pos < len(f.s) && f.s[f.pos] == '}' {
+			f.pos++
+			return n, nil
+		}
+		k, err := f.value(true)
+		if err != nil {
+			return nil, err
+		}
+		if k.kind != yamlScalar {
+			return nil, f.errorf("mapping keys must be scalars")
+		}
+		f.space()
+		if f.pos == len(f.s) || f.s[f.pos] != ':' {
+			return nil, f.errorf("expected ':' after key %q", k.value)
+		}
+		f.pos++
+		v, err := f.value(true)
+		if err != nil {
+			return nil, err
+		}
+		if n.get(k.value) != nil {
+			return nil, f.errorf("duplicate key %q", k.value)
+		}
+		n.set(k.value, v)
+		if err := f.separator('}'); err != nil {
+			return nil, err
+		}
+		if f.s[f.pos-1] == '}' {
+			return n, nil
+		}
+	}
+}
+
+// separator consumes the comma between flow items or the closing bracket.
+func (f *yamlFlow) separator(end byte) error {
+	f.space()
+	if f.pos == len(f.s) {
+		return f.errorf("expected ',' or '%c'", end)
+	}
+	if c := f.s[f.pos]; c == ',' || c == end {
+		f.pos++
+		return nil
+	}
+	return f.errorf("expected ',' or '%c', got %q", end, f.s[f.pos])
+}
+
+// writeYAML writes n as a YAML document.
+func writeYAML(b *strings.Builder, n *yamlNode) {
+	switch {
+	case n.kind == yamlMap && len(n.keys) > 0:
+		writeYAMLMap(b, n, 0)
+	case n.kind == yamlList && len(n.items) > 0 && !n.flow:
+		writeYAMLList(b, n, 0)
+	default:
+		b.WriteString(yamlInline(n, 0))
+		b.WriteByte('\n')
+	}
+}
+
+// writeYAMLMap writes the block mapping n, its first key at the current
+// column and the rest indented by indent.
+func writeYAMLMap(b *strings.Builder, n *yamlNode, indent int) {
+	for i, k := range n.keys {
+		if i > 0 {
+			b.WriteString(strings.Repeat(" ", indent))
+		}
+		b.WriteString(yamlScalarText(k, false))
+		b.WriteByte(':')
+		writeYAMLValue(b, n.values[i], indent)
+	}
+}
+
+// writeYAMLList writes the block sequence n, its first dash at the current
+// column and the rest indented by indent.
+func writeYAMLList(b *strings.Builder, n *yamlNode, indent int) {
+	for i, item := range n.items {
+		if i > 0 {
+			b.WriteString(strings.Repeat(" ", indent))
+		}
+		b.WriteString("- ")
+		switch {
+		case item.kind == yamlMap && len(item.keys) > 0:
+			writeYAMLMap(b, item, indent+2)
+		case item.kind == yamlList && len(item.items) > 0 && !item.flow:
+			writeYAMLList(b, item, indent+2)
+		default:
+			b.WriteString(yamlInline(item, indent+2))
+			b.WriteByte('\n')
+		}
+	}
+}
+
+// writeYAMLValue writes the value of a mapping entry, after its colon.
+func writeYAMLValue(b *strings.Builder, v *yamlNode, indent int) {
+	switch {
+	case v.kind == yamlMap && len(v.keys) > 0:
+		b.WriteByte('\n')
+		b.WriteString(strings.Repeat(" ", indent+2))
+		writeYAMLMap(b, v, indent+2)
+	case v.kind == yamlList && len(v.items) > 0 && !v.flow:
+		b.WriteByte('\n')
+		b.WriteString(strings.Repeat(" ", indent+2))
+		writeYAMLList(b, v, indent+2)
+	default:
+		b.WriteByte(' ')
+		b.WriteString(yamlInline(v, indent+2))
+		b.WriteByte('\n')
+	}
+}
+
+// yamlInline returns v written on one line, or as a literal block scalar
+// indented by indent if it is a multi-line string.
+func yamlInline(v *yamlNode, indent int) string {
+	switch v.kind {
+	case yamlMap:
+		parts := make([]string, len(v.keys))
+		for i, k := range v.keys {
+			parts[i] = yamlScalarText(k, true) + ": " + yamlInline(v.values[i], indent)
+		}
+		return "{" + strings.Join(parts, ", ") + "}"
+	case yamlList:
+		parts := make([]string, len(v.items))
+		for i, item := range v.items {
+			parts[i] = yamlInline(item, indent)
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	}
+	switch {
+	case v.null:
+		return "null"
+	case v.plain:
+		return v.value
+	case strings.Contains(v.value, "\n") && yamlLiteralOK(v.value):
+		body := strings.TrimSuffix(v.value, "\n")
+		header := "|"
+		if body == v.value {
+			header = "|-"
+		}
+		pad := strings.Repeat(" ", indent)
+		var b strings.Builder
+		b.WriteString(header)
+		for _, line := range strings.Split(body, "\n") {
+			b.WriteByte('\n')
+			if line != "" {
+				b.WriteString(pad + line)
+			}
+		}
+		return b.String()
+	}
+	return yamlScalarText(v.value, true)
+}
+
+// yamlLiteralOK reports whether s can be written as a literal block scalar
+// and read back unchanged.
+func yamlLiteralOK(s string) bool {
+	if strings.HasPrefix(s, " ") || strings.HasSuffix(s, "\n\n") || strings.HasSuffix(strings.TrimSuffix(s, "\n"), "\n") {
+		return false
+	}
+	for _, line := range strings.Split(s, "\n") {
+		if strings.TrimRight(line, " \t") != line || strings.ContainsAny(line, "\r\t") {
+			return false
+		}
+	}
+	return !strings.ContainsFunc(s, func(r rune) bool {
+		return r != '\n' && !strconv.IsPrint(r)
+	})
+}
+
+// yamlScalarText returns s as a plain scalar if it reads back as the same
+// string, and double-quoted otherwise.
+func yamlScalarText(s string, inFlow bool) string {
+	if yamlPlainOK(s, inFlow) {
+		return s
+	}
+	return strconv.Quote(s)
+}
+
+// yamlPlainOK reports whether s can be written as a plain scalar, inside
+// brackets if inFlow, and read back as the same string.
+func yamlPlainOK(s string, inFlow bool) bool {
+	if s == "" || s != strings.TrimSpace(s) {
+		return false
+	}
+	if strings.ContainsRune("-?:,[]{}#&*!|>'\"%@`~", rune(s[0])) {
+		return false
+	}
+	if strings.Contains(s, ": ") || strings.Contains(s, " #") || strings.HasSuffix(s, ":") {
+		return false
+	}
+	if inFlow && strings.ContainsAny(s, ",[]{}") {
+		return false
+	}
+	if strings.ContainsFunc(s, func(r rune) bool { return !strconv.IsPrint(r) }) {
+		return false
+	}
+	switch strings.ToLower(s) {
+	case "null", "true", "false", "yes", "no", "on", "off":
+		return false
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return false
+	}
+	return true
+}
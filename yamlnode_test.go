@@ -0,0 +1,205 @@
+package kml
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// yamlValue converts a parsed node to maps, slices and strings for comparison
+func yamlValue(n *yamlNode) any {
+	switch n.kind {
+	case yamlMap:
+		m := make(map[string]any, len(n.keys))
+		for i, k := range n.keys {
+			m[k] = yamlValue(n.values[i])
+		}
+		return m
+	case yamlList:
+		list := make([]any, len(n.items))
+		for i, item := range n.items {
+			list[i] = yamlValue(item)
+		}
+		return list
+	}
+	if n.null {
+		return nil
+	}
+	return n.value
+}
+
+// TestParseYAML tests the supported YAML constructs
+func TestParseYAML(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want any
+	}{
+		{
+			name: "mapping",
+			yaml: "a: 1\nb: two words\nc:\n",
+			want: map[string]any{"a": "1", "b": "two words", "c": nil},
+		},
+		{
+			name: "nested",
+			yaml: "a:\n  b:\n    c: x\n  d: y\n",
+			want: map[string]any{"a": map[string]any{"b": map[string]any{"c": "x"}, "d": "y"}},
+		},
+		{
+			name: "sequence of mappings",
+			yaml: "items:\n  - name: a\n    size: 1\n  - name: b\n",
+			want: map[string]any{"items": []any{map[string]any{"name": "a", "size": "1"}, map[string]any{"name": "b"}}},
+		},
+		{
+			name: "sequence at key indentation",
+			yaml: "items:\n- a\n- b\nnext: c\n",
+			want: map[string]any{"items": []any{"a", "b"}, "next": "c"},
+		},
+		{
+			name: "nested sequences",
+			yaml: "- - 1\n  - 2\n- [3, 4]\n",
+			want: []any{[]any{"1", "2"}, []any{"3", "4"}},
+		},
+		{
+			name: "flow",
+			yaml: "a: [1, [2, 3], {b: c, 'd': \"e, f\"}, ~]\nempty: []\n",
+			want: map[string]any{"a": []any{"1", []any{"2", "3"}, map[string]any{"b": "c", "d": "e, f"}, nil}, "empty": []any{}},
+		},
+		{
+			name: "quoted",
+			yaml: "a: \"tab\\there\"\nb: 'it''s'\n\"c d\": '#1'\n",
+			want: map[string]any{"a": "tab\there", "b": "it's", "c d": "#1"},
+		},
+		{
+			name: "comments",
+			yaml: "# header\na: x # note\nb: \"y # kept\"\nc: z#not\n",
+			want: map[string]any{"a": "x", "b": "y # kept", "c": "z#not"},
+		},
+		{
+			name: "literal",
+			yaml: "a: |\n  one\n    two\n\n  three\nb: x\n",
+			want: map[string]any{"a": "one\n  two\n\nthree\n", "b": "x"},
+		},
+		{
+			name: "literal strip and keep",
+			yaml: "a: |-\n  one\nb: |+\n  two\n\nc: x\n",
+			want: map[string]any{"a": "one", "b": "two\n\n", "c": "x"},
+		},
+		{
+			name: "folded",
+			yaml: "a: >\n  one\n  two\n\n  three\n",
+			want: map[string]any{"a": "one two\nthree\n"},
+		},
+		{
+			name: "literal in sequence",
+			yaml: "- |\n  text # not a comment\n- b\n",
+			want: []any{"text # not a comment\n", "b"},
+		},
+		{
+			name: "document marker",
+			yaml: "---\na: b\n...\n",
+			want: map[string]any{"a": "b"},
+		},
+		{
+			name: "apostrophe in plain scalar",
+			yaml: "a: Bob's place\n",
+			want: map[string]any{"a": "Bob's place"},
+		},
+		{
+			name: "url value",
+			yaml: "href: http://example.com/a.png\n",
+			want: map[string]any{"href": "http://example.com/a.png"},
+		},
+		{
+			name: "empty",
+			yaml: "# nothing\n",
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := parseYAML([]byte(tt.yaml))
+			if err != nil {
+				t.Fatalf("parseYAML failed: %v", err)
+			}
+			if got := yamlValue(n); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %#v, got %#v", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestParseYAMLErrors tests that malformed YAML is reported with its line
+func TestParseYAMLErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		line int
+		want string
+	}{
+		{"bad indentation", "a: 1\n   b: 2\n", 2, "unexpected indentation"},
+		{"duplicate key", "a: 1\na: 2\n", 2, "duplicate key"},
+		{"not a mapping entry", "a: 1\njust text\n", 2, "expected \"key: value\""},
+		{"tab", "a:\n\tb: 1\n", 2, "tabs"},
+		{"unterminated quote", "a: \"x\n", 1, "unterminated"},
+		{"unclosed flow", "a: [1, 2\n", 1, "expected ','"},
+		{"alias", "a: *ref\n", 1, "aliases"},
+		{"multiple documents", "a: 1\n---\nb: 2\n", 2, "multiple documents"},
+		{"trailing text", "a: [1] x\n", 1, "after value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseYAML([]byte(tt.yaml))
+			var pe *ParseError
+			if !errors.As(err, &pe) {
+				t.Fatalf("Expected *ParseError, got %v", err)
+			}
+			if pe.Line != tt.line {
+				t.Errorf("Expected line %d, got %d", tt.line, pe.Line)
+			}
+			if !strings.Contains(pe.Message, tt.want) {
+				t.Errorf("Expected message containing %q, got %q", tt.want, pe.Message)
+			}
+		})
+	}
+}
+
+// TestWriteYAMLScalars tests that written strings read back unchanged
+func TestWriteYAMLScalars(t *testing.T) {
+	values := []string{
+		"plain", "two words", "", " padded ", "true", "null", "12", "1e3",
+		"a: b", "a #b", "#x", "- x", "[x]", "{x}", "a,b", "it's", `say "hi"`,
+		"line\nbreak", "trailing\n", "  indented\nlines", "tab\there", "x:",
+		"http://example.com/a.png", "ünïcode", "<p>html</p>\n<b>bold</b>",
+	}
+
+	list := &yamlNode{kind: yamlList}
+	flow := &yamlNode{kind: yamlList, flow: true}
+	m := &yamlNode{kind: yamlMap}
+	for _, v := range values {
+		list.items = append(list.items, yamlText(v))
+		if !strings.Contains(v, "\n") {
+			flow.items = append(flow.items, yamlText(v))
+		}
+		if m.get(v) == nil {
+			m.set(v, yamlText(v))
+		}
+	}
+	doc := &yamlNode{kind: yamlMap}
+	doc.set("list", list)
+	doc.set("flow", flow)
+	doc.set("map", m)
+
+	var b strings.Builder
+	writeYAML(&b, doc)
+	n, err := parseYAML([]byte(b.String()))
+	if err != nil {
+		t.Fatalf("parseYAML failed: %v\n%s", err, b.String())
+	}
+	if got, want := yamlValue(n), yamlValue(doc); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %#v, got %#v\n%s", want, got, b.String())
+	}
+}